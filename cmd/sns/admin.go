@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/config"
+	"github.com/ilhamosaurus/sns-platform/internal/counter"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditrepo "github.com/ilhamosaurus/sns-platform/internal/module/audit/repository"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	tenantrepo "github.com/ilhamosaurus/sns-platform/internal/module/tenant/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	usersvc "github.com/ilhamosaurus/sns-platform/internal/module/user/service"
+	"github.com/ilhamosaurus/sns-platform/internal/retention"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// tenantSlug is the admin --tenant flag
+var tenantSlug string
+
+func newAdminCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Manage users and data directly in the configured database",
+	}
+	cmd.PersistentFlags().StringVar(&tenantSlug, "tenant", "", "slug of the tenant to act in when tenancy is enabled")
+	cmd.AddCommand(
+		newCreateUserCmd(),
+		newVerifyUserCmd(),
+		newBanUserCmd(),
+		newUnbanUserCmd(),
+		newResetPasswordCmd(),
+		newGrantRoleCmd(),
+		newRecountCmd(),
+		newRetentionCmd(),
+	)
+	return cmd
+}
+
+// openAdmin connects to the database and returns a context scoped to the
+// --tenant flag, so user lookups and new rows stay inside that tenant
+func openAdmin(ctx context.Context) (context.Context, *config.AppConfig, *gorm.DB, error) {
+	cfg, db, err := openDB()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if tenantSlug == "" {
+		return tenant.WithID(ctx, tenant.DefaultID), cfg, db, nil
+	}
+	if !cfg.Tenancy.Enable {
+		pkgdb.Close()
+		return nil, nil, nil, errors.New("--tenant requires tenancy.enable")
+	}
+	id, err := tenantrepo.NewTenantRepository(db).IDBySlug(ctx, tenantSlug)
+	if err != nil {
+		pkgdb.Close()
+		return nil, nil, nil, fmt.Errorf("failed to find tenant %s: %w", tenantSlug, err)
+	}
+	return tenant.WithID(ctx, id), cfg, db, nil
+}
+
+// updateUser applies updates to the user named by the command's argument and
+// audits the changed columns
+func updateUser(cmd *cobra.Command, username string, updates map[string]any) (*model.User, error) {
+	ctx, _, db, err := openAdmin(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer pkgdb.Close()
+
+	repo := userrepo.NewUserRepository(db)
+	user, err := repo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user %s: %w", username, err)
+	}
+	before, err := userColumns(db, user, slices.Collect(maps.Keys(updates)))
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.Update(ctx, user.ID, updates); err != nil {
+		return nil, fmt.Errorf("failed to update user %s: %w", username, err)
+	}
+
+	after := maps.Clone(updates)
+	if _, ok := after["password"]; ok {
+		after["password"] = auditsvc.Redacted
+	}
+	auditAdmin(ctx, db, &model.AuditLog{
+		Action:     model.AuditAdminUpdateUser,
+		TargetType: model.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     before,
+		After:      after,
+	})
+	return user, nil
+}
+
+// userColumns returns the user's values of columns, with the password hash redacted
+func userColumns(db *gorm.DB, user *model.User, columns []string) (map[string]any, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(user); err != nil {
+		return nil, fmt.Errorf("failed to parse user schema: %w", err)
+	}
+	values := make(map[string]any, len(columns))
+	for _, column := range columns {
+		if column == "password" {
+			values[column] = auditsvc.Redacted
+			continue
+		}
+		if field := stmt.Schema.LookUpField(column); field != nil {
+			values[column], _ = field.ValueOf(context.Background(), reflect.ValueOf(user).Elem())
+		}
+	}
+	return values, nil
+}
+
+// auditAdmin records an admin command; the CLI has no actor or IP to attach
+func auditAdmin(ctx context.Context, db *gorm.DB, entry *model.AuditLog) {
+	auditsvc.NewAuditService(auditrepo.NewAuditRepository(db)).Record(ctx, entry)
+}
+
+// hashPassword hashes password, generating a random one when it is empty
+func hashPassword(password string) (hash, generated string, err error) {
+	if password == "" {
+		password = rand.Text()
+		generated = password
+	}
+	b, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(b), generated, nil
+}
+
+func parseRole(role string) (types.UserRole, error) {
+	r := types.StringToUserRole(role)
+	if r == types.UserRoleUnknown {
+		return r, fmt.Errorf("invalid role %q: expected user or admin", role)
+	}
+	return r, nil
+}
+
+func newCreateUserCmd() *cobra.Command {
+	var user model.User
+	var password, role string
+	cmd := &cobra.Command{
+		Use:   "create-user",
+		Short: "Create a user account, e.g. the first administrator",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if user.Role, err = parseRole(role); err != nil {
+				return err
+			}
+			hash, generated, err := hashPassword(password)
+			if err != nil {
+				return err
+			}
+			user.PasswordHash = hash
+			user.IsVerified = true
+
+			ctx, cfg, db, err := openAdmin(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer pkgdb.Close()
+			if err := usersvc.NewUsernamePolicy(cfg.Users.ReservedUsernames).Validate(user.Username); err != nil {
+				return err
+			}
+			user.Email = usersvc.NormalizeEmail(user.Email, cfg.Users.StripEmailPlus)
+			if err := userrepo.NewUserRepository(db).Create(ctx, &user); err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+			auditAdmin(ctx, db, &model.AuditLog{
+				Action:     model.AuditAdminCreateUser,
+				TargetType: model.AuditTargetUser,
+				TargetID:   user.ID,
+				After:      map[string]any{"username": user.Username, "email": user.Email, "role": user.Role.String()},
+			})
+
+			fmt.Printf("created %s %s with id %d\n", user.Role, user.Username, user.ID)
+			if generated != "" {
+				fmt.Printf("generated password: %s\n", generated)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&user.Username, "username", "", "login name")
+	cmd.Flags().StringVar(&user.Email, "email", "", "email address")
+	cmd.Flags().StringVar(&user.FullName, "full-name", "", "display name")
+	cmd.Flags().StringVar(&password, "password", "", "password, generated and printed when empty")
+	cmd.Flags().StringVar(&role, "role", types.UserRoleUser.String(), "user or admin")
+	cmd.MarkFlagRequired("username")
+	cmd.MarkFlagRequired("email")
+	return cmd
+}
+
+func newVerifyUserCmd() *cobra.Command {
+	var revoke bool
+	cmd := &cobra.Command{
+		Use:   "verify-user USERNAME",
+		Short: "Give a user the verified badge",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := updateUser(cmd, args[0], map[string]any{"is_verified": !revoke}); err != nil {
+				return err
+			}
+			if revoke {
+				fmt.Printf("removed the verified badge from %s\n", args[0])
+			} else {
+				fmt.Printf("verified %s\n", args[0])
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&revoke, "revoke", false, "remove the badge instead")
+	return cmd
+}
+
+func newBanUserCmd() *cobra.Command {
+	var reason string
+	cmd := &cobra.Command{
+		Use:   "ban-user USERNAME",
+		Short: "Ban a user from the platform",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			updates := map[string]any{"banned_at": time.Now().UTC(), "ban_reason": reason}
+			if _, err := updateUser(cmd, args[0], updates); err != nil {
+				return err
+			}
+			fmt.Printf("banned %s\n", args[0])
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", "", "why the user was banned, kept for other admins")
+	return cmd
+}
+
+func newUnbanUserCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unban-user USERNAME",
+		Short: "Lift a user's ban",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := updateUser(cmd, args[0], map[string]any{"banned_at": nil, "ban_reason": ""}); err != nil {
+				return err
+			}
+			fmt.Printf("unbanned %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newResetPasswordCmd() *cobra.Command {
+	var password string
+	cmd := &cobra.Command{
+		Use:   "reset-password USERNAME",
+		Short: "Set a temporary password the user must change at next login",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hash, generated, err := hashPassword(password)
+			if err != nil {
+				return err
+			}
+			updates := map[string]any{"password": hash, "password_reset_required": true}
+			if _, err := updateUser(cmd, args[0], updates); err != nil {
+				return err
+			}
+			fmt.Printf("reset the password of %s\n", args[0])
+			if generated != "" {
+				fmt.Printf("temporary password: %s\n", generated)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&password, "password", "", "temporary password, generated and printed when empty")
+	return cmd
+}
+
+func newGrantRoleCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "grant-role USERNAME ROLE",
+		Short: "Change a user's role to user or admin",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			role, err := parseRole(args[1])
+			if err != nil {
+				return err
+			}
+			user, err := updateUser(cmd, args[0], map[string]any{"role": role})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("changed the role of %s from %s to %s\n", args[0], user.Role, role)
+			return nil
+		},
+	}
+}
+
+func newRecountCmd() *cobra.Command {
+	var (
+		dryRun    bool
+		batchSize int
+	)
+	cmd := &cobra.Command{
+		Use:   "recount",
+		Short: "Recompute follower, post, like, comment and member counters, reporting drifted rows",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer pkgdb.Close()
+
+			opts := []counter.Option{counter.WithBatchSize(batchSize)}
+			if dryRun {
+				opts = append(opts, counter.DryRun())
+			}
+			// Counters span every tenant, so the context carries none, and
+			// a pass over a large table may outlast the query timeout
+			results, err := counter.Reconcile(pkgdb.WithQueryTimeout(cmd.Context(), 0), db, opts...)
+			if err != nil {
+				return err
+			}
+			fixed := make(map[string]any, len(results))
+			for _, r := range results {
+				fmt.Printf("%-26s %d drifted, %d fixed\n", r.Counter.Name(), r.Drifted, r.Fixed)
+				for _, d := range r.Samples {
+					fmt.Printf("  id %d: stored %d, actual %d\n", d.ID, d.Stored, d.Actual)
+				}
+				if more := r.Drifted - int64(len(r.Samples)); more > 0 {
+					fmt.Printf("  and %d more\n", more)
+				}
+				fixed[r.Counter.Name()] = r.Fixed
+			}
+			if dryRun {
+				return nil
+			}
+			auditAdmin(cmd.Context(), db, &model.AuditLog{
+				Action:     model.AuditAdminRecount,
+				TargetType: model.AuditTargetCounters,
+				After:      fixed,
+			})
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report drifted counters without fixing them")
+	cmd.Flags().IntVar(&batchSize, "batch-size", counter.DefaultBatchSize, "IDs checked per statement")
+	return cmd
+}
+
+func newRetentionCmd() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "retention",
+		Short: "Delete the rows the retention policies let expire, reporting how many per policy",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer pkgdb.Close()
+
+			retentionConfig := cfg.GetRetentionConfig()
+			policies, err := retention.Policies(retentionConfig)
+			if err != nil {
+				return err
+			}
+			if len(policies) == 0 {
+				fmt.Println("no retention periods are configured")
+				return nil
+			}
+			opts := []retention.Option{retention.WithBatchSize(retentionConfig.BatchSize)}
+			if dryRun {
+				opts = append(opts, retention.DryRun())
+			}
+			// Policies span every tenant, so the context carries none, and
+			// a batch of deletes may outlast the query timeout
+			results, err := retention.Apply(pkgdb.WithQueryTimeout(cmd.Context(), 0), db, policies, opts...)
+			if err != nil {
+				return err
+			}
+			deleted := make(map[string]any, len(results))
+			for _, r := range results {
+				fmt.Printf("%-34s %d expired before %s, %d deleted\n", r.Policy.Name, r.Expired, r.Cutoff.Format(time.DateOnly), r.Deleted)
+				if r.Deleted > 0 {
+					deleted[r.Policy.Name] = r.Deleted
+				}
+			}
+			if dryRun || len(deleted) == 0 {
+				return nil
+			}
+			auditAdmin(cmd.Context(), db, &model.AuditLog{
+				Action:     model.AuditAdminApplyRetention,
+				TargetType: model.AuditTargetRetention,
+				After:      deleted,
+			})
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report expired rows without deleting them")
+	return cmd
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ilhamosaurus/sns-platform/internal/integrity"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"github.com/spf13/cobra"
+)
+
+func newCheckCmd() *cobra.Command {
+	var (
+		fix       bool
+		batchSize int
+	)
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Find reactions, follows and feed entries left dangling and negative counters, failing while any remain",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer pkgdb.Close()
+
+			opts := []integrity.Option{integrity.WithBatchSize(batchSize)}
+			if fix {
+				opts = append(opts, integrity.Fix())
+			}
+			// Checks span every tenant, so the context carries none, and
+			// scanning for dangling rows may outlast the query timeout
+			results, err := integrity.Run(pkgdb.WithQueryTimeout(cmd.Context(), 0), db, opts...)
+			if err != nil {
+				return err
+			}
+			var found, remaining int64
+			fixed := make(map[string]any, len(results))
+			for _, r := range results {
+				fmt.Printf("%-36s %d found, %d fixed\n", r.Check.Name, r.Found, r.Fixed)
+				if len(r.Samples) > 0 {
+					fmt.Printf("  %s ids %v", r.Check.Table, r.Samples)
+					if more := r.Found - int64(len(r.Samples)); more > 0 {
+						fmt.Printf(" and %d more", more)
+					}
+					fmt.Println()
+				}
+				found += r.Found
+				remaining += r.Found - r.Fixed
+				if r.Fixed > 0 {
+					fixed[r.Check.Name] = r.Fixed
+				}
+			}
+
+			if len(fixed) > 0 {
+				auditAdmin(cmd.Context(), db, &model.AuditLog{
+					Action:     model.AuditAdminFixIntegrity,
+					TargetType: model.AuditTargetIntegrity,
+					After:      fixed,
+				})
+				fmt.Println("\nremoved rows may leave counters drifted; run sns admin recount to repair them")
+			}
+			if remaining > 0 {
+				if fix {
+					return fmt.Errorf("%d of %d problems were left unfixed", remaining, found)
+				}
+				return fmt.Errorf("found %d problems; rerun with --fix to repair them", found)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&fix, "fix", false, "repair the problems found as well as reporting them")
+	cmd.Flags().IntVar(&batchSize, "batch-size", integrity.DefaultBatchSize, "IDs checked per statement")
+	return cmd
+}
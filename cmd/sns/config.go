@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ilhamosaurus/sns-platform/config"
+	"github.com/spf13/cobra"
+)
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+
+	var verbose bool
+	validate := &cobra.Command{
+		Use:   "validate",
+		Short: "Load the configuration with environment overrides and check it",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				return err
+			}
+			if verbose {
+				cfg.PrintConfig()
+			}
+			fmt.Println("configuration is valid")
+			return nil
+		},
+	}
+	validate.Flags().BoolVarP(&verbose, "verbose", "v", false, "print the resolved configuration, without secrets")
+
+	cmd.AddCommand(validate)
+	return cmd
+}
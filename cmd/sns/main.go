@@ -0,0 +1,63 @@
+// Command sns runs the social network server and its maintenance tasks
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ilhamosaurus/sns-platform/config"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// configPath is the --config flag shared by every command
+var configPath string
+
+func main() {
+	root := &cobra.Command{
+		Use:           "sns",
+		Short:         "Social network server and administration tool",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.PersistentFlags().StringVarP(&configPath, "config", "c", "config/config.yaml", "path to the YAML config file, empty to use environment variables only")
+
+	root.AddCommand(
+		newServeCmd(),
+		newMigrateCmd(),
+		newSeedCmd(),
+		newAdminCmd(),
+		newCheckCmd(),
+		newConfigCmd(),
+	)
+
+	if err := root.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// loadConfig reads the configuration and sets up logging from it
+func loadConfig() (*config.AppConfig, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	logger.Init(cfg.GetLoggerConfig())
+	return cfg, nil
+}
+
+// openDB loads the configuration and connects to its database
+func openDB() (*config.AppConfig, *gorm.DB, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := pkgdb.Initialize(cfg.GetDatabaseConfig())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return cfg, db, nil
+}
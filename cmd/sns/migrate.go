@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage the database schema",
+	}
+
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Create or update every table and index",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, _, err := openDB(); err != nil {
+				return err
+			}
+			defer pkgdb.Close()
+			return pkgdb.Migrate()
+		},
+	}
+
+	var yes bool
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Drop every table, deleting all data",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !yes {
+				return errors.New("migrate down deletes all data; pass --yes to confirm")
+			}
+			if _, _, err := openDB(); err != nil {
+				return err
+			}
+			defer pkgdb.Close()
+			return pkgdb.Rollback()
+		},
+	}
+	down.Flags().BoolVar(&yes, "yes", false, "confirm dropping every table")
+
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "List tables and columns that migrate up would create",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, _, err := openDB(); err != nil {
+				return err
+			}
+			defer pkgdb.Close()
+
+			statuses, err := pkgdb.Status()
+			if err != nil {
+				return err
+			}
+			pending := 0
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TABLE\tSTATUS")
+			for _, s := range statuses {
+				state := "up to date"
+				switch {
+				case !s.Exists:
+					state = "missing"
+				case len(s.MissingColumns) > 0:
+					state = "missing columns: " + strings.Join(s.MissingColumns, ", ")
+				}
+				if s.Pending() {
+					pending++
+				}
+				fmt.Fprintf(w, "%s\t%s\n", s.Table, state)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("\n%d of %d tables need migrating\n", pending, len(statuses))
+			return nil
+		},
+	}
+
+	cmd.AddCommand(up, down, status)
+	return cmd
+}
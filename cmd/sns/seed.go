@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ilhamosaurus/sns-platform/internal/seed"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"github.com/spf13/cobra"
+)
+
+func newSeedCmd() *cobra.Command {
+	var (
+		scale  int
+		prefix string
+		rseed  uint64
+	)
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Fill the database with generated users, follows, posts and likes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer pkgdb.Close()
+
+			opts := seed.Scale(scale)
+			opts.FanOut = cfg.GetFeedConfig()
+			opts.BatchSize = cfg.Database.BatchSize
+			opts.UsernamePrefix = prefix
+			if cmd.Flags().Changed("seed") {
+				opts.Seed = rseed
+			}
+
+			// Bulk inserts at large scales may outlast the query timeout
+			summary, err := seed.Run(pkgdb.WithQueryTimeout(cmd.Context(), 0), db, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("created %d users, %d follows, %d posts and %d likes (password %q)\n",
+				summary.Users, summary.Follows, summary.Posts, summary.Likes, seed.Password)
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&scale, "scale", 100, "number of users to create")
+	cmd.Flags().StringVar(&prefix, "prefix", "", "username prefix, unique per run by default")
+	cmd.Flags().Uint64Var(&rseed, "seed", 0, "random seed for reproducible data")
+	return cmd
+}
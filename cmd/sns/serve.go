@@ -0,0 +1,549 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/config"
+	"github.com/ilhamosaurus/sns-platform/internal/hydrator"
+	"github.com/ilhamosaurus/sns-platform/internal/job"
+	abusehandler "github.com/ilhamosaurus/sns-platform/internal/module/abuse/handler"
+	abusesvc "github.com/ilhamosaurus/sns-platform/internal/module/abuse/service"
+	adminhandler "github.com/ilhamosaurus/sns-platform/internal/module/admin/handler"
+	analyticshandler "github.com/ilhamosaurus/sns-platform/internal/module/analytics/handler"
+	analyticsrepo "github.com/ilhamosaurus/sns-platform/internal/module/analytics/repository"
+	analyticssvc "github.com/ilhamosaurus/sns-platform/internal/module/analytics/service"
+	announcementhandler "github.com/ilhamosaurus/sns-platform/internal/module/announcement/handler"
+	announcementrepo "github.com/ilhamosaurus/sns-platform/internal/module/announcement/repository"
+	announcementsvc "github.com/ilhamosaurus/sns-platform/internal/module/announcement/service"
+	archivehandler "github.com/ilhamosaurus/sns-platform/internal/module/archive/handler"
+	archiverepo "github.com/ilhamosaurus/sns-platform/internal/module/archive/repository"
+	archivesvc "github.com/ilhamosaurus/sns-platform/internal/module/archive/service"
+	audithandler "github.com/ilhamosaurus/sns-platform/internal/module/audit/handler"
+	auditrepo "github.com/ilhamosaurus/sns-platform/internal/module/audit/repository"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	closefriendhandler "github.com/ilhamosaurus/sns-platform/internal/module/closefriend/handler"
+	closefriendrepo "github.com/ilhamosaurus/sns-platform/internal/module/closefriend/repository"
+	commenthandler "github.com/ilhamosaurus/sns-platform/internal/module/comment/handler"
+	commentrepo "github.com/ilhamosaurus/sns-platform/internal/module/comment/repository"
+	commentsvc "github.com/ilhamosaurus/sns-platform/internal/module/comment/service"
+	communityhandler "github.com/ilhamosaurus/sns-platform/internal/module/community/handler"
+	communityrepo "github.com/ilhamosaurus/sns-platform/internal/module/community/repository"
+	communitysvc "github.com/ilhamosaurus/sns-platform/internal/module/community/service"
+	directoryrepo "github.com/ilhamosaurus/sns-platform/internal/module/directory/repository"
+	directorysvc "github.com/ilhamosaurus/sns-platform/internal/module/directory/service"
+	discoveryhandler "github.com/ilhamosaurus/sns-platform/internal/module/discovery/handler"
+	discoveryrepo "github.com/ilhamosaurus/sns-platform/internal/module/discovery/repository"
+	discoverysvc "github.com/ilhamosaurus/sns-platform/internal/module/discovery/service"
+	drafthandler "github.com/ilhamosaurus/sns-platform/internal/module/draft/handler"
+	draftrepo "github.com/ilhamosaurus/sns-platform/internal/module/draft/repository"
+	embedhandler "github.com/ilhamosaurus/sns-platform/internal/module/embed/handler"
+	embedsvc "github.com/ilhamosaurus/sns-platform/internal/module/embed/service"
+	emojihandler "github.com/ilhamosaurus/sns-platform/internal/module/emoji/handler"
+	emojirepo "github.com/ilhamosaurus/sns-platform/internal/module/emoji/repository"
+	emojisvc "github.com/ilhamosaurus/sns-platform/internal/module/emoji/service"
+	exporthandler "github.com/ilhamosaurus/sns-platform/internal/module/export/handler"
+	exportrepo "github.com/ilhamosaurus/sns-platform/internal/module/export/repository"
+	exportsvc "github.com/ilhamosaurus/sns-platform/internal/module/export/service"
+	feedhandler "github.com/ilhamosaurus/sns-platform/internal/module/feed/handler"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	feedsvc "github.com/ilhamosaurus/sns-platform/internal/module/feed/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/feed/stream"
+	followhandler "github.com/ilhamosaurus/sns-platform/internal/module/follow/handler"
+	followrepo "github.com/ilhamosaurus/sns-platform/internal/module/follow/repository"
+	followsvc "github.com/ilhamosaurus/sns-platform/internal/module/follow/service"
+	invitehandler "github.com/ilhamosaurus/sns-platform/internal/module/invite/handler"
+	inviterepo "github.com/ilhamosaurus/sns-platform/internal/module/invite/repository"
+	invitesvc "github.com/ilhamosaurus/sns-platform/internal/module/invite/service"
+	legalholdhandler "github.com/ilhamosaurus/sns-platform/internal/module/legalhold/handler"
+	legalholdrepo "github.com/ilhamosaurus/sns-platform/internal/module/legalhold/repository"
+	legalholdsvc "github.com/ilhamosaurus/sns-platform/internal/module/legalhold/service"
+	messagehandler "github.com/ilhamosaurus/sns-platform/internal/module/message/handler"
+	messagerepo "github.com/ilhamosaurus/sns-platform/internal/module/message/repository"
+	messagesvc "github.com/ilhamosaurus/sns-platform/internal/module/message/service"
+	notificationhandler "github.com/ilhamosaurus/sns-platform/internal/module/notification/handler"
+	notificationrepo "github.com/ilhamosaurus/sns-platform/internal/module/notification/repository"
+	notificationsvc "github.com/ilhamosaurus/sns-platform/internal/module/notification/service"
+	oauthhandler "github.com/ilhamosaurus/sns-platform/internal/module/oauth/handler"
+	oauthrepo "github.com/ilhamosaurus/sns-platform/internal/module/oauth/repository"
+	oauthsvc "github.com/ilhamosaurus/sns-platform/internal/module/oauth/service"
+	onboardinghandler "github.com/ilhamosaurus/sns-platform/internal/module/onboarding/handler"
+	onboardingrepo "github.com/ilhamosaurus/sns-platform/internal/module/onboarding/repository"
+	onboardingsvc "github.com/ilhamosaurus/sns-platform/internal/module/onboarding/service"
+	orghandler "github.com/ilhamosaurus/sns-platform/internal/module/organization/handler"
+	orgrepo "github.com/ilhamosaurus/sns-platform/internal/module/organization/repository"
+	orgsvc "github.com/ilhamosaurus/sns-platform/internal/module/organization/service"
+	pagehandler "github.com/ilhamosaurus/sns-platform/internal/module/page/handler"
+	pagerepo "github.com/ilhamosaurus/sns-platform/internal/module/page/repository"
+	pagesvc "github.com/ilhamosaurus/sns-platform/internal/module/page/service"
+	phonehandler "github.com/ilhamosaurus/sns-platform/internal/module/phone/handler"
+	phonerepo "github.com/ilhamosaurus/sns-platform/internal/module/phone/repository"
+	phonesvc "github.com/ilhamosaurus/sns-platform/internal/module/phone/service"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	presencehandler "github.com/ilhamosaurus/sns-platform/internal/module/presence/handler"
+	presencesvc "github.com/ilhamosaurus/sns-platform/internal/module/presence/service"
+	profilevisithandler "github.com/ilhamosaurus/sns-platform/internal/module/profilevisit/handler"
+	profilevisitrepo "github.com/ilhamosaurus/sns-platform/internal/module/profilevisit/repository"
+	profilevisitsvc "github.com/ilhamosaurus/sns-platform/internal/module/profilevisit/service"
+	reactionhandler "github.com/ilhamosaurus/sns-platform/internal/module/reaction/handler"
+	reactionrepo "github.com/ilhamosaurus/sns-platform/internal/module/reaction/repository"
+	reactionsvc "github.com/ilhamosaurus/sns-platform/internal/module/reaction/service"
+	reposthandler "github.com/ilhamosaurus/sns-platform/internal/module/repost/handler"
+	repostrepo "github.com/ilhamosaurus/sns-platform/internal/module/repost/repository"
+	repostsvc "github.com/ilhamosaurus/sns-platform/internal/module/repost/service"
+	restrictionhandler "github.com/ilhamosaurus/sns-platform/internal/module/restriction/handler"
+	restrictionrepo "github.com/ilhamosaurus/sns-platform/internal/module/restriction/repository"
+	scimhandler "github.com/ilhamosaurus/sns-platform/internal/module/scim/handler"
+	scimrepo "github.com/ilhamosaurus/sns-platform/internal/module/scim/repository"
+	scimsvc "github.com/ilhamosaurus/sns-platform/internal/module/scim/service"
+	securityhandler "github.com/ilhamosaurus/sns-platform/internal/module/security/handler"
+	securityrepo "github.com/ilhamosaurus/sns-platform/internal/module/security/repository"
+	securitysvc "github.com/ilhamosaurus/sns-platform/internal/module/security/service"
+	shortlinkhandler "github.com/ilhamosaurus/sns-platform/internal/module/shortlink/handler"
+	shortlinkrepo "github.com/ilhamosaurus/sns-platform/internal/module/shortlink/repository"
+	shortlinksvc "github.com/ilhamosaurus/sns-platform/internal/module/shortlink/service"
+	ssohandler "github.com/ilhamosaurus/sns-platform/internal/module/sso/handler"
+	ssorepo "github.com/ilhamosaurus/sns-platform/internal/module/sso/repository"
+	ssosvc "github.com/ilhamosaurus/sns-platform/internal/module/sso/service"
+	tenantrepo "github.com/ilhamosaurus/sns-platform/internal/module/tenant/repository"
+	translationhandler "github.com/ilhamosaurus/sns-platform/internal/module/translation/handler"
+	translationrepo "github.com/ilhamosaurus/sns-platform/internal/module/translation/repository"
+	translationsvc "github.com/ilhamosaurus/sns-platform/internal/module/translation/service"
+	userhandler "github.com/ilhamosaurus/sns-platform/internal/module/user/handler"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	usersvc "github.com/ilhamosaurus/sns-platform/internal/module/user/service"
+	waitlisthandler "github.com/ilhamosaurus/sns-platform/internal/module/waitlist/handler"
+	waitlistrepo "github.com/ilhamosaurus/sns-platform/internal/module/waitlist/repository"
+	waitlistsvc "github.com/ilhamosaurus/sns-platform/internal/module/waitlist/service"
+	"github.com/ilhamosaurus/sns-platform/internal/task"
+	"github.com/ilhamosaurus/sns-platform/pkg/broadcast"
+	"github.com/ilhamosaurus/sns-platform/pkg/cache"
+	"github.com/ilhamosaurus/sns-platform/pkg/challenge"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"github.com/ilhamosaurus/sns-platform/pkg/errorreport"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/i18n"
+	"github.com/ilhamosaurus/sns-platform/pkg/jobs"
+	"github.com/ilhamosaurus/sns-platform/pkg/ldapdir"
+	"github.com/ilhamosaurus/sns-platform/pkg/lock"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/maintenance"
+	"github.com/ilhamosaurus/sns-platform/pkg/metrics"
+	"github.com/ilhamosaurus/sns-platform/pkg/oauth"
+	"github.com/ilhamosaurus/sns-platform/pkg/quota"
+	"github.com/ilhamosaurus/sns-platform/pkg/richtext"
+	"github.com/ilhamosaurus/sns-platform/pkg/scheduler"
+	"github.com/ilhamosaurus/sns-platform/pkg/server"
+	"github.com/ilhamosaurus/sns-platform/pkg/signedurl"
+	"github.com/ilhamosaurus/sns-platform/pkg/sms"
+	"github.com/ilhamosaurus/sns-platform/pkg/storage"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+	"github.com/ilhamosaurus/sns-platform/pkg/translate"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"github.com/ilhamosaurus/sns-platform/pkg/velocity"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// schedulerLeaderTTL is how long the scheduler goes without a leader after
+// the leading instance dies
+const schedulerLeaderTTL = 30 * time.Second
+
+func newServeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Run the HTTP API with its job worker, event bus and scheduler",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return serve(ctx)
+		},
+	}
+}
+
+func serve(ctx context.Context) error {
+	cfg, db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer pkgdb.Close()
+
+	flush, err := errorreport.Init(cfg.GetErrorReportConfig())
+	if err != nil {
+		return err
+	}
+	defer flush()
+
+	if cfg.Migrations.AutoMigrate {
+		if err := pkgdb.Migrate(); err != nil {
+			return err
+		}
+	}
+	if cfg.Migrations.SeedData {
+		if err := pkgdb.Seed(); err != nil {
+			return err
+		}
+	}
+
+	var redisClient *redis.Client
+	if cfg.Redis.Enable {
+		if redisClient, err = cache.NewRedisClient(ctx, cfg.GetRedisConfig()); err != nil {
+			return err
+		}
+		defer redisClient.Close()
+	}
+
+	bus, err := eventbus.New(ctx, cfg.GetEventBusConfig(), redisClient)
+	if err != nil {
+		return err
+	}
+	catalog, err := i18n.Default()
+	if err != nil {
+		return err
+	}
+
+	queue := jobs.NewQueue(db)
+	metrics.RegisterJobQueue(queue)
+	job.RecordEventDeadLetters(bus, queue)
+
+	hyd := hydrator.NewHydrator(db)
+	feedOpts := []feedrepo.Option{feedrepo.WithFanOut(cfg.GetFeedConfig()), feedrepo.WithExplore(cfg.GetExploreConfig()), feedrepo.WithAffinity(cfg.GetAffinityConfig())}
+	var notificationOpts []notificationrepo.Option
+	if cfg.Feed.CacheTTL > 0 {
+		store := cache.NewStore(redisClient)
+		feedOpts = append(feedOpts, feedrepo.WithCache(store, cfg.Feed.CacheTTL))
+		notificationOpts = append(notificationOpts, notificationrepo.WithCache(store, cfg.Feed.CacheTTL))
+	}
+	feedRepo := feedrepo.NewFeedRepository(db, feedOpts...)
+	notificationRepo := notificationrepo.NewNotificationRepository(db, notificationOpts...)
+	userRepo := userrepo.NewUserRepository(db)
+	postRepo := postrepo.NewPostRepository(db)
+
+	// Realtime signals go through Redis when it is enabled, else through
+	// LISTEN/NOTIFY on PostgreSQL, else stay within this instance
+	var listenDB *sql.DB
+	if redisClient == nil && pkgdb.DatabaseType(cfg.Database.Type) == pkgdb.PostgreSQL {
+		if listenDB, err = db.DB(); err != nil {
+			return err
+		}
+	}
+	broadcaster := broadcast.New(redisClient, listenDB)
+	queue.WakeWorkers(broadcaster)
+	maintenanceMode := maintenance.New(broadcaster, cfg.GetMaintenanceState(), "/admin/maintenance")
+	hub := stream.NewHub(broadcaster, feedRepo, postRepo, hyd)
+
+	auditService := auditsvc.NewAuditService(auditrepo.NewAuditRepository(db))
+	exportService := exportsvc.NewExportService(db, exportrepo.NewExportRepository(db), cfg.GetExportConfig())
+	media := storage.NewLocalStorage(cfg.Storage.Dir, cfg.Storage.BaseURL)
+	archiveService := archivesvc.NewArchiveService(archiverepo.NewArchiveRepository(db), media, cfg.GetImportConfig())
+	emojiService := emojisvc.NewEmojiService(emojirepo.NewEmojiRepository(db), media, auditService)
+	richOptions := cfg.GetRichTextOptions()
+	richOptions.CustomEmoji = emojiService.Lookup
+	richtext.Configure(richOptions)
+	securityService := securitysvc.NewSecurityService(securityrepo.NewSecurityRepository(db), bus, nil)
+	restrictionRepo := restrictionrepo.NewRestrictionRepository(db)
+	notificationService := notificationsvc.NewNotificationService(notificationRepo, userRepo, restrictionRepo, hyd, catalog)
+	analyticsService := analyticssvc.NewAnalyticsService(analyticsrepo.NewAnalyticsRepository(db), postRepo, userRepo, cfg.GetAnalyticsConfig())
+	repostService := repostsvc.NewRepostService(repostrepo.NewRepostRepository(db), postRepo, bus)
+	embedService := embedsvc.NewEmbedService(postRepo, userRepo, embedsvc.Config{ProviderName: cfg.App.Name, PublicURL: cfg.App.PublicURL})
+	shortLinkService := shortlinksvc.NewShortLinkService(shortlinkrepo.NewShortLinkRepository(db), postRepo, userRepo, shortlinksvc.Config{PublicURL: cfg.App.PublicURL})
+	oauthService := oauthsvc.NewOAuthService(oauthrepo.NewOAuthRepository(db), cfg.GetOAuthServiceConfig())
+	smsSender, err := sms.New(cfg.GetSMSConfig())
+	if err != nil {
+		return err
+	}
+	phoneService := phonesvc.NewPhoneService(phonerepo.NewPhoneRepository(db), userRepo, smsSender, catalog, phonesvc.Config{AppName: cfg.App.Name})
+	velocityTracker := velocity.New(velocity.NewStore(redisClient), cfg.GetVelocityConfig())
+	var challenges *challenge.Challenge
+	if challengeConfig := cfg.GetChallengeConfig(); challengeConfig.Provider != challenge.ProviderNone {
+		challenges, err = challenge.New(quota.NewStore(redisClient), challengeConfig, challengeRisk(userRepo, velocityTracker, cfg.Challenge.NewAccountAge))
+		if err != nil {
+			return err
+		}
+	}
+	inviteService := invitesvc.NewInviteService(inviterepo.NewInviteRepository(db), userRepo, auditService, cfg.GetInviteConfig())
+	waitlistService := waitlistsvc.NewWaitlistService(waitlistrepo.NewWaitlistRepository(db), bus, auditService, challenges, cfg.GetWaitlistConfig())
+	ssoService, err := ssosvc.NewSSOService(ssorepo.NewSSORepository(db), userRepo, challenges, inviteService, waitlistService, cfg.GetSSOServiceConfig())
+	if err != nil {
+		return err
+	}
+	accountService := usersvc.NewAccountService(userRepo, auditService)
+	translator, err := translate.New(cfg.GetTranslateConfig())
+	if err != nil {
+		return err
+	}
+	translationService := translationsvc.NewTranslationService(translationrepo.NewTranslationRepository(db), userRepo, translator)
+	profileVisitService := profilevisitsvc.NewProfileVisitService(profilevisitrepo.NewProfileVisitRepository(db), userRepo, auditService)
+	onboardingService := onboardingsvc.NewOnboardingService(onboardingrepo.NewOnboardingRepository(db), userRepo, onboardingsvc.Config{StripEmailPlus: cfg.Users.StripEmailPlus})
+	discoveryService := discoverysvc.NewDiscoveryService(discoveryrepo.NewDiscoveryRepository(db), userRepo, auditService)
+	// Presence lives in Redis alone, so without it users show no presence
+	var presenceService presencesvc.PresenceService
+	if redisClient != nil {
+		presenceService = presencesvc.NewPresenceService(redisClient, 0)
+		presenceService.OnChange(presencesvc.PublishTo(bus))
+	}
+
+	job.EnqueueFanoutOnPostCreated(bus, queue)
+	job.EnqueueWaitlistActivationEmails(bus, queue, cfg.App.PublicURL)
+	securitysvc.NotifyOnNewDeviceLogin(bus, notificationRepo)
+	analyticssvc.IngestReportedEngagement(bus, analyticsService)
+	profilevisitsvc.RecordOnProfileViews(bus, profileVisitService)
+	auditsvc.RecordModeration(bus, auditService)
+	usersvc.ReactivateOnLogin(bus, accountService)
+	onboardingsvc.TrackOnboarding(bus, onboardingService)
+	abusesvc.Track(bus, velocityTracker)
+	if cfg.Feed.CacheTTL > 0 && cfg.Feed.WarmOnLogin {
+		feedsvc.WarmOnLogin(bus, feedRepo, notificationRepo)
+	}
+
+	var quotas *quota.Quota
+	if cfg.Quota.Enable {
+		quotas = quota.New(quota.NewStore(redisClient), cfg.GetQuotaConfig(), quotaTier(userRepo))
+	}
+	closeFriendRepo := closefriendrepo.NewCloseFriendRepository(db)
+	followRepo := followrepo.NewFollowRepository(db)
+	orgRepo := orgrepo.NewOrganizationRepository(db)
+	orgService := orgsvc.NewOrganizationService(orgRepo, userRepo, feedRepo, bus, auditService, quotas)
+	pageService := pagesvc.NewPageService(pagerepo.NewPageRepository(db), userRepo, hyd, bus, auditService, challenges, quotas)
+	announcementService := announcementsvc.NewAnnouncementService(announcementrepo.NewAnnouncementRepository(db), auditService)
+	followService := followsvc.NewFollowService(followRepo, userRepo, quotas, bus)
+	messageService := messagesvc.NewMessageService(messagerepo.NewMessageRepository(db), userRepo, followRepo, restrictionRepo, quotas, auditService, bus)
+	commentRepo := commentrepo.NewCommentRepository(db)
+	commentService := commentsvc.NewCommentService(commentRepo, postRepo, userRepo, followRepo, closeFriendRepo, restrictionRepo, orgRepo, notificationService, challenges, bus)
+	reactionService := reactionsvc.NewReactionService(reactionrepo.NewReactionRepository(db), postRepo, commentRepo, closeFriendRepo, orgRepo, bus)
+	communityService := communitysvc.NewCommunityService(communityrepo.NewCommunityRepository(db), feedRepo, bus)
+	// The challenge comes first so a failed one does not use up quota
+	communityService.OnPost(communitysvc.RequireChallenge(challenges))
+	communityService.OnPost(communitysvc.LimitPosts(quotas))
+	commentService.OnCreate(communityService.FilterComment)
+
+	worker := jobs.NewWorker(queue, jobs.WorkerConfig{Queues: job.Queues})
+	worker.WakeOn(broadcaster)
+	worker.PauseWhile(maintenanceMode.Enabled)
+	job.RegisterFeedHandlers(worker, feedRepo, hub)
+	job.RegisterExportHandlers(worker, exportService)
+	job.RegisterImportHandlers(worker, archiveService)
+	job.RegisterDeliveryHandlers(worker, queue, userRepo, job.LogSender{}, job.LogSender{})
+
+	mux := http.NewServeMux()
+	closefriendhandler.NewCloseFriendHandler(closeFriendRepo).Register(mux)
+	restrictionhandler.NewRestrictionHandler(restrictionRepo).Register(mux)
+	followhandler.NewFollowHandler(followService).Register(mux)
+	commenthandler.NewCommentHandler(commentService).Register(mux)
+	reactionhandler.NewReactionHandler(reactionService).Register(mux)
+	communityhandler.NewCommunityHandler(communityService).Register(mux)
+	communityhandler.NewModerationHandler(communityService).Register(mux)
+	reposthandler.NewRepostHandler(repostService).Register(mux)
+	embedhandler.NewEmbedHandler(embedService).Register(mux)
+	shortlinkhandler.NewShortLinkHandler(shortLinkService).Register(mux)
+	oauthhandler.NewOAuthHandler(oauthService).Register(mux)
+	ssohandler.NewSSOHandler(ssoService, securityService, phoneService, userRepo, signedurl.NewSigner(cfg.SSO.SigningKey), quota.NewStore(redisClient), cfg.GetSSOConfig()).Register(mux)
+	translationhandler.NewTranslationHandler(translationService).Register(mux)
+	notificationhandler.NewNotificationHandler(notificationService).Register(mux)
+	exporthandler.NewExportHandler(exportService, queue, signedurl.NewSigner(cfg.Export.SigningKey), cfg.Export.LinkTTL, auditService).Register(mux)
+	exporthandler.NewTimelineHandler(exportrepo.NewTimelineRepository(db)).Register(mux)
+	archivehandler.NewArchiveHandler(archiveService, queue).Register(mux)
+	securityhandler.NewSecurityHandler(securityService).Register(mux)
+	feedhandler.NewStreamHandler(hub, feedRepo).Register(mux)
+	feedhandler.NewFeedHandler(feedRepo, announcementService).Register(mux)
+	userhandler.NewUserHandler(userRepo, accountService, presenceService).Register(mux)
+	if presenceService != nil {
+		presencehandler.NewPresenceHandler(presenceService).Register(mux)
+	}
+	analyticshandler.NewAnalyticsHandler(analyticsService, bus).Register(mux)
+	profilevisithandler.NewProfileVisitHandler(profileVisitService).Register(mux)
+	onboardinghandler.NewOnboardingHandler(onboardingService).Register(mux)
+	discoveryhandler.NewDiscoveryHandler(discoveryService).Register(mux)
+	phonehandler.NewPhoneHandler(phoneService, securityService).Register(mux)
+	invitehandler.NewInviteHandler(inviteService).Register(mux)
+	waitlisthandler.NewWaitlistHandler(waitlistService).Register(mux)
+	messagehandler.NewMessageHandler(messageService).Register(mux)
+	drafthandler.NewDraftHandler(draftrepo.NewDraftRepository(db)).Register(mux)
+	orghandler.NewOrganizationHandler(orgService).Register(mux)
+	pagehandler.NewPageHandler(pageService).Register(mux)
+	announcementhandler.NewAnnouncementHandler(announcementService).Register(mux)
+	emojihandler.NewEmojiHandler(emojiService).Register(mux)
+	if cfg.SCIM.Enable {
+		scimService := scimsvc.NewSCIMService(scimrepo.NewSCIMRepository(db), auditService, cfg.GetSCIMServiceConfig())
+		scimhandler.NewSCIMHandler(scimService, cfg.SCIM.Token).Register(mux)
+	}
+	if quotas != nil {
+		quotas.Register(mux)
+	}
+	if challenges != nil {
+		challenges.Register(mux)
+	}
+
+	adminMux := http.NewServeMux()
+	adminhandler.NewJobHandler(queue, auditService).Register(adminMux)
+	adminhandler.NewMaintenanceHandler(maintenanceMode, auditService).Register(adminMux)
+	audithandler.NewAuditHandler(auditService).Register(adminMux)
+	announcementhandler.NewAdminHandler(announcementService).Register(adminMux)
+	emojihandler.NewAdminHandler(emojiService).Register(adminMux)
+	invitehandler.NewAdminHandler(inviteService).Register(adminMux)
+	waitlisthandler.NewAdminHandler(waitlistService).Register(adminMux)
+	abusehandler.NewAdminHandler(velocityTracker).Register(adminMux)
+	legalholdhandler.NewAdminHandler(legalholdsvc.NewLegalHoldService(legalholdrepo.NewLegalHoldRepository(db), auditService)).Register(adminMux)
+	mux.Handle("/admin/", requireAdmin(userRepo, adminMux))
+
+	appAuth := oauth.Middleware(oauthService, quota.NewStore(redisClient), cfg.GetOAuthConfig())
+	principal := oauth.Principal(oauthService, cfg.GetOAuthConfig())
+	srv, err := server.New(middleware(cfg, db, mux, appAuth, principal, maintenanceMode), cfg.GetServerConfig())
+	if err != nil {
+		return err
+	}
+	// Metrics are served on their own port rather than to API clients
+	var metricsSrv *server.Server
+	if cfg.App.MetricsPort > 0 {
+		metricsMux := http.NewServeMux()
+		metrics.Register(metricsMux)
+		if metricsSrv, err = server.New(metricsMux, server.Config{Addr: fmt.Sprintf(":%d", cfg.App.MetricsPort)}); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	background := func(run func(ctx context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(ctx)
+		}()
+	}
+	background(bus.Run)
+	if metricsSrv != nil {
+		background(func(ctx context.Context) {
+			if err := metricsSrv.Run(ctx); err != nil {
+				slog.ErrorContext(ctx, "metrics server failed", slog.Any("error", err))
+			}
+		})
+	}
+	background(broadcaster.Run)
+	background(worker.Run)
+	background(emojiService.Run)
+	if presenceService != nil {
+		background(presenceService.Run)
+	}
+	if cfg.Scheduler.Enabled {
+		var locker *lock.Locker
+		var elector *lock.Elector
+		if redisClient != nil {
+			locker = lock.NewLocker(redisClient)
+			elector = locker.NewElector("scheduler", schedulerLeaderTTL)
+			metrics.RegisterLocker(locker)
+		}
+		var directoryService directorysvc.DirectoryService
+		if cfg.LDAP.URL != "" {
+			directoryService = directorysvc.NewDirectoryService(directoryrepo.NewDirectoryRepository(db), ldapdir.New(cfg.GetLDAPConfig()), auditService, cfg.GetDirectoryServiceConfig())
+		}
+		s := scheduler.New(locker)
+		s.PauseWhile(maintenanceMode.Enabled)
+		if elector != nil {
+			s.LeaderOnly(elector)
+			background(elector.Run)
+		}
+		task.Register(s, cfg.Scheduler, task.Deps{FeedRepo: feedRepo, CommentRepo: commentRepo, NotificationRepo: notificationRepo, ExportService: exportService, AnalyticsService: analyticsService, DirectoryService: directoryService, WaitlistService: waitlistService, WaitlistBatch: cfg.Users.WaitlistBatch, Retention: cfg.GetRetentionConfig(), Media: media, DB: db})
+		background(s.Run)
+	}
+
+	// Background loops also stop when the server fails on its own
+	err = srv.Run(ctx)
+	cancel()
+	wg.Wait()
+	return err
+}
+
+// middleware wraps the routes in the request pipeline. Metrics sits directly
+// on the mux so it sees the matched route pattern; app access tokens are
+// resolved inside the tenant so they are looked up in the right one, and
+// principal holds a request with a token to the tenant it was issued in.
+// Maintenance turns writes away before any of it runs.
+func middleware(cfg *config.AppConfig, db *gorm.DB, mux *http.ServeMux, appAuth func(http.Handler) http.Handler, principal func(*http.Request) (int64, bool), mode *maintenance.Mode) http.Handler {
+	handler := appAuth(quota.Middleware(challenge.Middleware(metrics.Middleware(mux))))
+	if cfg.Tenancy.Enable {
+		tenantConfig := cfg.GetTenantConfig()
+		tenantConfig.Principal = principal
+		handler = tenant.Middleware(tenantrepo.NewTenantRepository(db), tenantConfig)(handler)
+	}
+	handler = errorreport.Middleware(mode.Middleware(handler))
+	return logger.Middleware(handler)
+}
+
+// quotaTier puts admins and verified users in the tiers of those names
+func quotaTier(users userrepo.UserRepository) quota.TierFunc {
+	return func(ctx context.Context, userID int64) (string, error) {
+		user, err := users.GetByID(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case user.Role == types.UserRoleAdmin:
+			return "admin", nil
+		case user.IsVerified:
+			return "verified", nil
+		default:
+			return quota.DefaultTier, nil
+		}
+	}
+}
+
+// challengeRisk flags requests nobody is signed in to, users over a velocity
+// threshold and unverified accounts younger than newAccountAge, the ones
+// spam mostly comes from
+func challengeRisk(users userrepo.UserRepository, tracker *velocity.Tracker, newAccountAge time.Duration) challenge.RiskFunc {
+	return func(ctx context.Context, userID int64, action challenge.Action) (bool, error) {
+		if userID == 0 {
+			return true, nil
+		}
+		user, err := users.GetByID(ctx, userID)
+		if err != nil {
+			return false, err
+		}
+		if user.Role == types.UserRoleAdmin {
+			return false, nil
+		}
+		// Acting faster than a velocity threshold allows is risky however
+		// established the account
+		if breached, err := tracker.Breached(ctx, userID); err != nil || breached {
+			return breached, err
+		}
+		if user.IsVerified {
+			return false, nil
+		}
+		return time.Since(user.CreatedAt) < newAccountAge, nil
+	}
+}
+
+// requireAdmin only lets users with the admin role through to next
+func requireAdmin(users userrepo.UserRepository, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := logger.UserID(r.Context())
+		if !ok {
+			httpx.Error(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+		user, err := users.GetByID(r.Context(), userID)
+		if err != nil {
+			httpx.Fail(w, err)
+			return
+		}
+		if user.Role != types.UserRoleAdmin || user.IsBanned() {
+			httpx.Error(w, http.StatusForbidden, "admin role required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,24 +1,82 @@
 package config
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
+	analyticssvc "github.com/ilhamosaurus/sns-platform/internal/module/analytics/service"
+	archivesvc "github.com/ilhamosaurus/sns-platform/internal/module/archive/service"
+	directorysvc "github.com/ilhamosaurus/sns-platform/internal/module/directory/service"
+	exportsvc "github.com/ilhamosaurus/sns-platform/internal/module/export/service"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	invitesvc "github.com/ilhamosaurus/sns-platform/internal/module/invite/service"
+	oauthsvc "github.com/ilhamosaurus/sns-platform/internal/module/oauth/service"
+	scimsvc "github.com/ilhamosaurus/sns-platform/internal/module/scim/service"
+	ssohandler "github.com/ilhamosaurus/sns-platform/internal/module/sso/handler"
+	ssosvc "github.com/ilhamosaurus/sns-platform/internal/module/sso/service"
+	waitlistsvc "github.com/ilhamosaurus/sns-platform/internal/module/waitlist/service"
+	"github.com/ilhamosaurus/sns-platform/internal/retention"
+	"github.com/ilhamosaurus/sns-platform/pkg/cache"
+	"github.com/ilhamosaurus/sns-platform/pkg/challenge"
 	"github.com/ilhamosaurus/sns-platform/pkg/db"
+	"github.com/ilhamosaurus/sns-platform/pkg/errorreport"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/ldapdir"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/maintenance"
+	"github.com/ilhamosaurus/sns-platform/pkg/oauth"
+	"github.com/ilhamosaurus/sns-platform/pkg/oidc"
+	"github.com/ilhamosaurus/sns-platform/pkg/quota"
+	"github.com/ilhamosaurus/sns-platform/pkg/richtext"
+	"github.com/ilhamosaurus/sns-platform/pkg/saml"
+	"github.com/ilhamosaurus/sns-platform/pkg/secrets"
+	"github.com/ilhamosaurus/sns-platform/pkg/server"
+	"github.com/ilhamosaurus/sns-platform/pkg/sms"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+	"github.com/ilhamosaurus/sns-platform/pkg/translate"
+	"github.com/ilhamosaurus/sns-platform/pkg/velocity"
 	"gopkg.in/yaml.v3"
 )
 
 // AppConfig represents the entire application configuration
 type AppConfig struct {
-	Database   DatabaseConfig  `yaml:"database"`
-	Postgres   PostgresConfig  `yaml:"postgres"`
-	MySQL      MySQLConfig     `yaml:"mysql"`
-	SQLite     SQLiteConfig    `yaml:"sqlite"`
-	Redis      RedisConfig     `yaml:"redis"`
-	App        ApplicationInfo `yaml:"app"`
-	Migrations MigrationConfig `yaml:"migrations"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Postgres    PostgresConfig    `yaml:"postgres"`
+	MySQL       MySQLConfig       `yaml:"mysql"`
+	SQLite      SQLiteConfig      `yaml:"sqlite"`
+	Redis       RedisConfig       `yaml:"redis"`
+	App         ApplicationInfo   `yaml:"app"`
+	Server      ServerConfig      `yaml:"server"`
+	Log         LogConfig         `yaml:"log"`
+	Sentry      SentryConfig      `yaml:"sentry"`
+	Migrations  MigrationConfig   `yaml:"migrations"`
+	Scheduler   SchedulerConfig   `yaml:"scheduler"`
+	EventBus    EventBusConfig    `yaml:"event_bus"`
+	Tenancy     TenancyConfig     `yaml:"tenancy"`
+	Export      ExportConfig      `yaml:"export"`
+	Import      ImportConfig      `yaml:"import"`
+	Storage     StorageConfig     `yaml:"storage"`
+	Feed        FeedConfig        `yaml:"feed"`
+	Analytics   AnalyticsConfig   `yaml:"analytics"`
+	Quota       QuotaConfig       `yaml:"quota"`
+	Translation TranslationConfig `yaml:"translation"`
+	Content     ContentConfig     `yaml:"content"`
+	SMS         SMSConfig         `yaml:"sms"`
+	Challenge   ChallengeConfig   `yaml:"challenge"`
+	Velocity    VelocityConfig    `yaml:"velocity"`
+	OAuth       OAuthConfig       `yaml:"oauth"`
+	SSO         SSOConfig         `yaml:"sso"`
+	SCIM        SCIMConfig        `yaml:"scim"`
+	LDAP        LDAPConfig        `yaml:"ldap"`
+	Users       UsersConfig       `yaml:"users"`
+	Retention   RetentionConfig   `yaml:"retention"`
+	Maintenance MaintenanceConfig `yaml:"maintenance"`
 
 	// Environment-specific configs
 	Development *EnvironmentConfig `yaml:"development,omitempty"`
@@ -29,66 +87,379 @@ type AppConfig struct {
 
 // DatabaseConfig holds common database settings
 type DatabaseConfig struct {
-	Type            string        `yaml:"type"`
-	MaxIdleConns    int           `yaml:"max_idle_conns"`
-	MaxOpenConns    int           `yaml:"max_open_conns"`
-	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
-	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
-	LogLevel        string        `yaml:"log_level"`
-	PrepareStmt     bool          `yaml:"prepare_stmt"`
-	SkipDefaultTxn  bool          `yaml:"skip_default_txn"`
+	Type                  string        `yaml:"type" env:"DB_TYPE"`
+	MaxIdleConns          int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	MaxOpenConns          int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	ConnMaxLifetime       time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime       time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	ConnMaxLifetimeJitter time.Duration `yaml:"conn_max_lifetime_jitter" env:"DB_CONN_MAX_LIFETIME_JITTER"` // Spread connection expiry, at most half of conn_max_lifetime
+	WarmConns             int           `yaml:"warm_conns" env:"DB_WARM_CONNS"`                             // Connections opened at startup
+	LogLevel              string        `yaml:"log_level" env:"DB_LOG_LEVEL"`
+	PrepareStmt           bool          `yaml:"prepare_stmt" env:"DB_PREPARE_STMT"`
+	SkipDefaultTxn        bool          `yaml:"skip_default_txn" env:"DB_SKIP_DEFAULT_TXN"`
+	SlowThreshold         time.Duration `yaml:"slow_threshold" env:"DB_SLOW_THRESHOLD"`
+	QueryTimeout          time.Duration `yaml:"query_timeout" env:"DB_QUERY_TIMEOUT"` // Negative disables
+	BatchSize             int           `yaml:"batch_size" env:"DB_BATCH_SIZE"`       // Rows per INSERT for bulk writes
 }
 
 // PostgresConfig holds PostgreSQL-specific settings
 type PostgresConfig struct {
-	Host     string `yaml:"host"`
-	Port     string `yaml:"port"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
-	DBName   string `yaml:"dbname"`
-	SSLMode  string `yaml:"sslmode"`
+	Host     string `yaml:"host" env:"POSTGRES_HOST,DB_HOST"`
+	Port     string `yaml:"port" env:"POSTGRES_PORT,DB_PORT"`
+	User     string `yaml:"user" env:"POSTGRES_USER,DB_USER"`
+	Password string `yaml:"password" env:"POSTGRES_PASSWORD,DB_PASSWORD"`
+	DBName   string `yaml:"dbname" env:"POSTGRES_DB,DB_NAME"`
+	SSLMode  string `yaml:"sslmode" env:"POSTGRES_SSLMODE,DB_SSLMODE"`
 }
 
 // MySQLConfig holds MySQL-specific settings
 type MySQLConfig struct {
-	Host     string `yaml:"host"`
-	Port     string `yaml:"port"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
-	DBName   string `yaml:"dbname"`
-	Charset  string `yaml:"charset"`
+	Host     string `yaml:"host" env:"MYSQL_HOST"`
+	Port     string `yaml:"port" env:"MYSQL_PORT"`
+	User     string `yaml:"user" env:"MYSQL_USER,DB_USER"`
+	Password string `yaml:"password" env:"MYSQL_PASSWORD,DB_PASSWORD"`
+	DBName   string `yaml:"dbname" env:"MYSQL_DATABASE,DB_NAME"`
+	Charset  string `yaml:"charset" env:"MYSQL_CHARSET,DB_CHARSET"`
 }
 
 // SQLiteConfig holds SQLite-specific settings
 type SQLiteConfig struct {
-	FilePath string `yaml:"filepath"`
+	FilePath     string        `yaml:"filepath" env:"SQLITE_FILEPATH,DB_FILEPATH"`
+	JournalMode  string        `yaml:"journal_mode" env:"SQLITE_JOURNAL_MODE"`   // delete, truncate, persist, memory, wal, off
+	Synchronous  string        `yaml:"synchronous" env:"SQLITE_SYNCHRONOUS"`     // off, normal, full, extra
+	BusyTimeout  time.Duration `yaml:"busy_timeout" env:"SQLITE_BUSY_TIMEOUT"`   // How long a write waits for the lock
+	ForeignKeys  string        `yaml:"foreign_keys" env:"SQLITE_FOREIGN_KEYS"`   // on, off
+	SingleWriter bool          `yaml:"single_writer" env:"SQLITE_SINGLE_WRITER"` // One connection, so writes queue instead of colliding
 }
 
 // RedisConfig holds Redis configuration
 type RedisConfig struct {
-	Enable       bool   `yaml:"enable"`
-	Host         string `yaml:"host"`
-	Port         string `yaml:"port"`
-	Password     string `yaml:"password"`
-	DB           int    `yaml:"db"`
-	PoolSize     int    `yaml:"pool_size"`
-	MinIdleConns int    `yaml:"min_idle_conns"`
+	Enable       bool   `yaml:"enable" env:"REDIS_ENABLE"`
+	Host         string `yaml:"host" env:"REDIS_HOST"`
+	Port         string `yaml:"port" env:"REDIS_PORT"`
+	Username     string `yaml:"username" env:"REDIS_USERNAME"`
+	Password     string `yaml:"password" env:"REDIS_PASSWORD"`
+	DB           int    `yaml:"db" env:"REDIS_DB"`
+	PoolSize     int    `yaml:"pool_size" env:"REDIS_POOL_SIZE"`
+	MinIdleConns int    `yaml:"min_idle_conns" env:"REDIS_MIN_IDLE_CONNS"`
+	TLS          bool   `yaml:"tls" env:"REDIS_TLS"`
 }
 
+// Feature flags under app.features
+const (
+	FeatureInviteOnly = "invite_only" // Sign-ups need an invite code
+	FeatureWaitlist   = "waitlist"    // Sign-ups without an invite wait their turn on the waitlist
+)
+
 // ApplicationInfo holds application metadata
 type ApplicationInfo struct {
-	Name        string          `yaml:"name"`
-	Version     string          `yaml:"version"`
-	Environment string          `yaml:"environment"`
-	Port        int             `yaml:"port"`
-	Features    map[string]bool `yaml:"features"`
+	Name        string          `yaml:"name" env:"APP_NAME"`
+	Version     string          `yaml:"version" env:"APP_VERSION"`
+	Environment string          `yaml:"environment" env:"APP_ENV"`
+	Port        int             `yaml:"port" env:"APP_PORT"`
+	MetricsPort int             `yaml:"metrics_port" env:"APP_METRICS_PORT"` // Serves GET /metrics apart from the API; 0 turns it off
+	PublicURL   string          `yaml:"public_url" env:"APP_PUBLIC_URL"`     // Origin clients open, used in links such as oEmbed responses
+	Features    map[string]bool `yaml:"features" env:"FEATURE"`              // FEATURE_ENABLE_CACHING=true
+}
+
+// ServerConfig holds HTTP server tuning, TLS and proxy settings
+type ServerConfig struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	MaxBodyBytes      int64         `yaml:"max_body_bytes" env:"SERVER_MAX_BODY_BYTES"`   // For routes without an entry in body_limits
+	TrustedProxies    []string      `yaml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"` // CIDRs, comma-separated in env
+	TLS               TLSConfig     `yaml:"tls"`
+
+	BodyLimits map[string]BodyLimitConfig `yaml:"body_limits" env:"SERVER_BODY_LIMIT"` // SERVER_BODY_LIMIT_IMPORT_BYTES_PER_SECOND=4194304
+}
+
+// BodyLimitConfig sets the body limits of the routes matching a pattern
+type BodyLimitConfig struct {
+	Route          string `yaml:"route" env:"ROUTE"`                       // ServeMux pattern, e.g. "POST /me/imports"
+	MaxBytes       int64  `yaml:"max_bytes" env:"MAX_BYTES"`               // 0 keeps server.max_body_bytes
+	BytesPerSecond int64  `yaml:"bytes_per_second" env:"BYTES_PER_SECOND"` // Upload pace; 0 for none
+}
+
+// TLSConfig holds HTTPS settings; set either cert/key files or autocert domains
+type TLSConfig struct {
+	CertFile         string   `yaml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile          string   `yaml:"key_file" env:"TLS_KEY_FILE"`
+	AutocertDomains  []string `yaml:"autocert_domains" env:"TLS_AUTOCERT_DOMAINS"`
+	AutocertEmail    string   `yaml:"autocert_email" env:"TLS_AUTOCERT_EMAIL"`
+	AutocertCacheDir string   `yaml:"autocert_cache_dir" env:"TLS_AUTOCERT_CACHE_DIR"`
+}
+
+// LogConfig holds application logging settings
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`   // debug, info, warn, error
+	Format string `yaml:"format" env:"LOG_FORMAT"` // json, text
+}
+
+// SentryConfig holds error reporting settings
+type SentryConfig struct {
+	DSN              string  `yaml:"dsn" env:"SENTRY_DSN"` // Empty disables reporting
+	SampleRate       float64 `yaml:"sample_rate" env:"SENTRY_SAMPLE_RATE"`
+	TracesSampleRate float64 `yaml:"traces_sample_rate" env:"SENTRY_TRACES_SAMPLE_RATE"`
+	Debug            bool    `yaml:"debug" env:"SENTRY_DEBUG"`
 }
 
 // MigrationConfig holds migration settings
 type MigrationConfig struct {
-	AutoMigrate   bool `yaml:"auto_migrate"`
-	SeedData      bool `yaml:"seed_data"`
-	CreateIndexes bool `yaml:"create_indexes"`
+	AutoMigrate   bool `yaml:"auto_migrate" env:"MIGRATIONS_AUTO_MIGRATE"`
+	SeedData      bool `yaml:"seed_data" env:"MIGRATIONS_SEED_DATA"`
+	CreateIndexes bool `yaml:"create_indexes" env:"MIGRATIONS_CREATE_INDEXES"`
+}
+
+// SchedulerConfig holds periodic maintenance task settings
+type SchedulerConfig struct {
+	Enabled bool                  `yaml:"enabled" env:"SCHEDULER_ENABLED"`
+	Tasks   map[string]TaskConfig `yaml:"tasks" env:"SCHEDULER_TASK"` // SCHEDULER_TASK_FEED_PRUNING_INTERVAL=12h
+}
+
+// TaskConfig holds settings for a single scheduled task
+type TaskConfig struct {
+	Enabled   bool          `yaml:"enabled" env:"ENABLED"`
+	Interval  time.Duration `yaml:"interval" env:"INTERVAL"`
+	Retention time.Duration `yaml:"retention" env:"RETENTION"` // For cleanup tasks, or how far back a task reaches
+}
+
+// EventBusConfig holds domain event bus settings
+type EventBusConfig struct {
+	Driver   string `yaml:"driver" env:"EVENT_BUS_DRIVER"`     // memory, redis, nats
+	Workers  int    `yaml:"workers" env:"EVENT_BUS_WORKERS"`   // For memory driver
+	Buffer   int    `yaml:"buffer" env:"EVENT_BUS_BUFFER"`     // For memory driver
+	URL      string `yaml:"url" env:"EVENT_BUS_URL"`           // For nats driver
+	Stream   string `yaml:"stream" env:"EVENT_BUS_STREAM"`     // For redis and nats drivers
+	Group    string `yaml:"group" env:"EVENT_BUS_GROUP"`       // For redis and nats drivers
+	Consumer string `yaml:"consumer" env:"EVENT_BUS_CONSUMER"` // For redis driver
+	MaxLen   int64  `yaml:"max_len" env:"EVENT_BUS_MAX_LEN"`   // For redis and nats drivers
+
+	MaxRetries int `yaml:"max_retries" env:"EVENT_BUS_MAX_RETRIES"` // Handler attempts before an event is dead-lettered
+}
+
+// TenancyConfig holds multi-tenant settings
+type TenancyConfig struct {
+	Enable   bool   `yaml:"enable" env:"TENANCY_ENABLE"`
+	Header   string `yaml:"header" env:"TENANCY_HEADER"`     // Header carrying the tenant slug
+	Required bool   `yaml:"required" env:"TENANCY_REQUIRED"` // Reject requests that match no tenant
+}
+
+// ExportConfig holds "download my data" archive settings
+type ExportConfig struct {
+	Dir        string        `yaml:"dir" env:"EXPORT_DIR"`
+	SigningKey string        `yaml:"signing_key" env:"EXPORT_SIGNING_KEY"` // HMAC key for download links
+	LinkTTL    time.Duration `yaml:"link_ttl" env:"EXPORT_LINK_TTL"`       // Lifetime of a signed download link
+	Retention  time.Duration `yaml:"retention" env:"EXPORT_RETENTION"`     // How long a finished archive is kept
+}
+
+// ImportConfig holds Twitter/Mastodon archive import settings
+type ImportConfig struct {
+	Dir     string `yaml:"dir" env:"IMPORT_DIR"`           // Where uploaded archives wait for their import job
+	MaxSize int64  `yaml:"max_size" env:"IMPORT_MAX_SIZE"` // Largest accepted archive in bytes
+}
+
+// StorageConfig holds media storage settings
+type StorageConfig struct {
+	Dir     string `yaml:"dir" env:"STORAGE_DIR"`
+	BaseURL string `yaml:"base_url" env:"STORAGE_BASE_URL"` // URL prefix the stored files are served from
+}
+
+// FeedConfig holds feed fan-out settings
+type FeedConfig struct {
+	FanOutChunkSize int   `yaml:"fan_out_chunk_size" env:"FEED_FAN_OUT_CHUNK_SIZE"` // Followers per worker task
+	FanOutBatchSize int   `yaml:"fan_out_batch_size" env:"FEED_FAN_OUT_BATCH_SIZE"` // Feed rows per INSERT, 0 uses database.batch_size
+	FanOutWorkers   int   `yaml:"fan_out_workers" env:"FEED_FAN_OUT_WORKERS"`       // Concurrent inserts per fan-out job
+	PullThreshold   int64 `yaml:"pull_threshold" env:"FEED_PULL_THRESHOLD"`         // Follower count from which posts are merged on read, 0 disables
+
+	CacheTTL    time.Duration `yaml:"cache_ttl" env:"FEED_CACHE_TTL"`         // First home feed page and unread counts, 0 disables
+	WarmOnLogin bool          `yaml:"warm_on_login" env:"FEED_WARM_ON_LOGIN"` // Fill both caches as users log in
+
+	ExploreWindows    []time.Duration `yaml:"explore_windows" env:"FEED_EXPLORE_WINDOWS"`       // Explore time ranges the trending_recompute task materializes
+	ExploreCandidates int             `yaml:"explore_candidates" env:"FEED_EXPLORE_CANDIDATES"` // Posts kept per window, tenant, language and region
+
+	AffinityMinScore int64         `yaml:"affinity_min_score" env:"FEED_AFFINITY_MIN_SCORE"` // Affinity from which an author's posts are lifted, 0 disables
+	AffinityWithin   time.Duration `yaml:"affinity_within" env:"FEED_AFFINITY_WITHIN"`       // How recent a lifted post must be
+}
+
+// AnalyticsConfig holds creator insights settings
+type AnalyticsConfig struct {
+	ImpressionDedupeWindow time.Duration `yaml:"impression_dedupe_window" env:"ANALYTICS_IMPRESSION_DEDUPE_WINDOW"` // Repeat views within this count once
+}
+
+// QuotaConfig holds per-user caps on write actions
+type QuotaConfig struct {
+	Enable bool                       `yaml:"enable" env:"QUOTA_ENABLE"`
+	Tiers  map[string]QuotaTierConfig `yaml:"tiers" env:"QUOTA_TIER"` // QUOTA_TIER_VERIFIED_POSTS_PER_DAY=200
+}
+
+// QuotaTierConfig holds the caps of one tier; zero leaves an action unlimited
+type QuotaTierConfig struct {
+	PostsPerDay             int `yaml:"posts_per_day" env:"POSTS_PER_DAY"`
+	DMsToNonFollowersPerDay int `yaml:"dms_to_non_followers_per_day" env:"DMS_TO_NON_FOLLOWERS_PER_DAY"`
+	FollowsPerHour          int `yaml:"follows_per_hour" env:"FOLLOWS_PER_HOUR"`
+}
+
+// TranslationConfig holds machine translation settings
+type TranslationConfig struct {
+	Provider string        `yaml:"provider" env:"TRANSLATION_PROVIDER"` // none, deepl or google
+	APIKey   string        `yaml:"api_key" env:"TRANSLATION_API_KEY"`
+	Endpoint string        `yaml:"endpoint" env:"TRANSLATION_ENDPOINT"` // Overrides the provider's API URL
+	Timeout  time.Duration `yaml:"timeout" env:"TRANSLATION_TIMEOUT"`
+}
+
+// ContentConfig holds how post and comment text is rendered to HTML
+type ContentConfig struct {
+	Markdown   bool   `yaml:"markdown" env:"CONTENT_MARKDOWN"`       // Render **bold**, *italic*, `code` and [links](url)
+	MentionURL string `yaml:"mention_url" env:"CONTENT_MENTION_URL"` // %s is the username
+	HashtagURL string `yaml:"hashtag_url" env:"CONTENT_HASHTAG_URL"` // %s is the tag
+}
+
+// SMSConfig holds the text message provider settings
+type SMSConfig struct {
+	Provider   string        `yaml:"provider" env:"SMS_PROVIDER"` // mock or twilio
+	AccountSID string        `yaml:"account_sid" env:"SMS_ACCOUNT_SID"`
+	AuthToken  string        `yaml:"auth_token" env:"SMS_AUTH_TOKEN"`
+	From       string        `yaml:"from" env:"SMS_FROM"`         // Sending number in E.164, or a messaging service SID
+	Endpoint   string        `yaml:"endpoint" env:"SMS_ENDPOINT"` // Overrides the provider's API URL
+	Timeout    time.Duration `yaml:"timeout" env:"SMS_TIMEOUT"`
+}
+
+// ChallengeConfig holds the CAPTCHA and proof-of-work settings of risky actions
+type ChallengeConfig struct {
+	Provider      string        `yaml:"provider" env:"CHALLENGE_PROVIDER"` // none, pow, hcaptcha or turnstile
+	SiteKey       string        `yaml:"site_key" env:"CHALLENGE_SITE_KEY"`
+	Secret        string        `yaml:"secret" env:"CHALLENGE_SECRET"`     // CAPTCHA secret, or the proof-of-work signing key
+	Endpoint      string        `yaml:"endpoint" env:"CHALLENGE_ENDPOINT"` // Overrides the provider's verification URL
+	Timeout       time.Duration `yaml:"timeout" env:"CHALLENGE_TIMEOUT"`
+	Difficulty    int           `yaml:"difficulty" env:"CHALLENGE_DIFFICULTY"` // Leading zero bits of a proof of work
+	TTL           time.Duration `yaml:"ttl" env:"CHALLENGE_TTL"`
+	Actions       []string      `yaml:"actions" env:"CHALLENGE_ACTIONS"`
+	NewAccountAge time.Duration `yaml:"new_account_age" env:"CHALLENGE_NEW_ACCOUNT_AGE"` // Unverified accounts younger than this are risky
+}
+
+// VelocityConfig holds the rolling window per-user action rates are measured
+// over and the count above which each is flagged as abuse; zero never flags
+type VelocityConfig struct {
+	Window            time.Duration `yaml:"window" env:"VELOCITY_WINDOW"`
+	Posts             int           `yaml:"posts" env:"VELOCITY_POSTS"`
+	Follows           int           `yaml:"follows" env:"VELOCITY_FOLLOWS"`
+	MessageRecipients int           `yaml:"message_recipients" env:"VELOCITY_MESSAGE_RECIPIENTS"` // Distinct users messaged
+}
+
+// OAuthConfig holds the OAuth provider settings for third-party apps
+type OAuthConfig struct {
+	CodeTTL         time.Duration `yaml:"code_ttl" env:"OAUTH_CODE_TTL"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"OAUTH_ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"OAUTH_REFRESH_TOKEN_TTL"`
+	AppRateLimit    int           `yaml:"app_rate_limit" env:"OAUTH_APP_RATE_LIMIT"` // Requests per window across all of an app's users
+	AppRateWindow   time.Duration `yaml:"app_rate_window" env:"OAUTH_APP_RATE_WINDOW"`
+}
+
+// SSOConfig holds single sign-on settings for corporate deployments
+type SSOConfig struct {
+	SigningKey  string                       `yaml:"signing_key" env:"SSO_SIGNING_KEY"` // HMAC key for the login state cookie and tickets
+	TicketTTL   time.Duration                `yaml:"ticket_ttl" env:"SSO_TICKET_TTL"`
+	RedirectURL string                       `yaml:"redirect_url" env:"SSO_REDIRECT_URL"` // Where browsers land with ?ticket=; empty answers with JSON
+	Providers   map[string]SSOProviderConfig `yaml:"providers" env:"SSO_PROVIDER"`        // SSO_PROVIDER_CORP_CLIENT_ID=...
+}
+
+// SSOProviderConfig configures one OIDC or SAML identity provider
+type SSOProviderConfig struct {
+	Type        string `yaml:"type" env:"TYPE"` // oidc or saml
+	DisplayName string `yaml:"display_name" env:"DISPLAY_NAME"`
+
+	// OIDC
+	Issuer       string   `yaml:"issuer" env:"ISSUER"`
+	ClientID     string   `yaml:"client_id" env:"CLIENT_ID"`
+	ClientSecret string   `yaml:"client_secret" env:"CLIENT_SECRET"`
+	Scopes       []string `yaml:"scopes" env:"SCOPES"` // openid is always requested
+
+	// SAML
+	IDPEntityID    string `yaml:"idp_entity_id" env:"IDP_ENTITY_ID"`
+	IDPSSOURL      string `yaml:"idp_sso_url" env:"IDP_SSO_URL"`
+	IDPCertificate string `yaml:"idp_certificate" env:"IDP_CERTIFICATE"` // PEM; several allow for rotation
+	NameIDFormat   string `yaml:"name_id_format" env:"NAME_ID_FORMAT"`
+
+	// Accounts
+	AllowedDomains []string `yaml:"allowed_domains" env:"ALLOWED_DOMAINS"` // Email domains allowed to sign in; empty allows any
+	JIT            bool     `yaml:"jit" env:"JIT"`                         // Create accounts on first sign-in
+	LinkByEmail    bool     `yaml:"link_by_email" env:"LINK_BY_EMAIL"`     // Link first sign-ins to the account with the same verified email
+
+	// Claims or attributes mapped onto user fields; empty uses the protocol's usual names
+	UsernameAttribute  string `yaml:"username_attribute" env:"USERNAME_ATTRIBUTE"`
+	EmailAttribute     string `yaml:"email_attribute" env:"EMAIL_ATTRIBUTE"`
+	FullNameAttribute  string `yaml:"full_name_attribute" env:"FULL_NAME_ATTRIBUTE"`
+	AvatarURLAttribute string `yaml:"avatar_url_attribute" env:"AVATAR_URL_ATTRIBUTE"`
+	LanguageAttribute  string `yaml:"language_attribute" env:"LANGUAGE_ATTRIBUTE"`
+}
+
+// SCIMConfig holds the SCIM user provisioning API settings
+type SCIMConfig struct {
+	Enable bool   `yaml:"enable" env:"SCIM_ENABLE"`
+	Token  string `yaml:"token" env:"SCIM_TOKEN"` // Bearer token the identity provider authenticates with
+}
+
+// LDAPConfig holds the directory the ldap_sync task syncs accounts and
+// the org chart from
+type LDAPConfig struct {
+	URL                string        `yaml:"url" env:"LDAP_URL"` // ldap://host:389 or ldaps://host:636
+	StartTLS           bool          `yaml:"start_tls" env:"LDAP_START_TLS"`
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify" env:"LDAP_INSECURE_SKIP_VERIFY"`
+	BindDN             string        `yaml:"bind_dn" env:"LDAP_BIND_DN"`
+	BindPassword       string        `yaml:"bind_password" env:"LDAP_BIND_PASSWORD"`
+	BaseDN             string        `yaml:"base_dn" env:"LDAP_BASE_DN"`
+	Filter             string        `yaml:"filter" env:"LDAP_FILTER"` // Entries that get accounts
+	PageSize           int           `yaml:"page_size" env:"LDAP_PAGE_SIZE"`
+	Timeout            time.Duration `yaml:"timeout" env:"LDAP_TIMEOUT"`
+	TenantID           int64         `yaml:"tenant_id" env:"LDAP_TENANT_ID"` // Tenant the accounts belong to
+
+	AutoFollow  bool `yaml:"auto_follow" env:"LDAP_AUTO_FOLLOW"`     // Teammates, managers and reports follow each other
+	MaxTeamSize int  `yaml:"max_team_size" env:"LDAP_MAX_TEAM_SIZE"` // Larger teams only follow their manager
+	BatchSize   int  `yaml:"batch_size" env:"LDAP_BATCH_SIZE"`       // Follows and suspensions per transaction
+
+	// Attributes mapped onto user fields; empty uses Active Directory's
+	IDAttribute       string `yaml:"id_attribute" env:"LDAP_ID_ATTRIBUTE"`
+	UsernameAttribute string `yaml:"username_attribute" env:"LDAP_USERNAME_ATTRIBUTE"`
+	EmailAttribute    string `yaml:"email_attribute" env:"LDAP_EMAIL_ATTRIBUTE"`
+	FullNameAttribute string `yaml:"full_name_attribute" env:"LDAP_FULL_NAME_ATTRIBUTE"`
+	ManagerAttribute  string `yaml:"manager_attribute" env:"LDAP_MANAGER_ATTRIBUTE"`
+}
+
+// UsersConfig holds the rules account identifiers are normalized and
+// validated by
+type UsersConfig struct {
+	StripEmailPlus    bool     `yaml:"strip_email_plus" env:"USERS_STRIP_EMAIL_PLUS"`     // Drop "+tag" from email local parts
+	ReservedUsernames []string `yaml:"reserved_usernames" env:"USERS_RESERVED_USERNAMES"` // Empty uses usersvc.DefaultReservedUsernames
+
+	// Invites; sign-ups need one when the invite_only feature flag is on
+	InviteLimit int           `yaml:"invite_limit" env:"USERS_INVITE_LIMIT"` // Unused invites a user may hold at once
+	InviteUses  int           `yaml:"invite_uses" env:"USERS_INVITE_USES"`   // Sign-ups one invite admits
+	InviteTTL   time.Duration `yaml:"invite_ttl" env:"USERS_INVITE_TTL"`     // Zero never expires
+
+	// Waitlist; sign-ups go through it when the waitlist feature flag is on
+	WaitlistBatch int `yaml:"waitlist_batch" env:"USERS_WAITLIST_BATCH"` // Entries each waitlist_activation run lets in
+}
+
+// RetentionConfig holds how long rows are kept before the data_retention
+// task deletes them for good; zero keeps them forever
+type RetentionConfig struct {
+	Messages      time.Duration `yaml:"messages" env:"RETENTION_MESSAGES"`
+	Notifications time.Duration `yaml:"notifications" env:"RETENTION_NOTIFICATIONS"`
+	AuditLogs     time.Duration `yaml:"audit_logs" env:"RETENTION_AUDIT_LOGS"`
+	SoftDeleted   time.Duration `yaml:"soft_deleted" env:"RETENTION_SOFT_DELETED"` // Counted from the deletion
+	BatchSize     int           `yaml:"batch_size" env:"RETENTION_BATCH_SIZE"`
+	DryRun        bool          `yaml:"dry_run" env:"RETENTION_DRY_RUN"` // Log what would be deleted instead
+}
+
+// MaintenanceConfig holds the maintenance state an instance starts in; admins
+// switch it at runtime through /admin/maintenance
+type MaintenanceConfig struct {
+	Enabled    bool          `yaml:"enabled" env:"MAINTENANCE_ENABLED"`
+	Message    string        `yaml:"message" env:"MAINTENANCE_MESSAGE"`         // Shown to clients whose writes are turned away
+	RetryAfter time.Duration `yaml:"retry_after" env:"MAINTENANCE_RETRY_AFTER"` // Sent as Retry-After
 }
 
 // EnvironmentConfig holds environment-specific overrides
@@ -98,22 +469,38 @@ type EnvironmentConfig struct {
 	MySQL    MySQLConfig    `yaml:"mysql"`
 	SQLite   SQLiteConfig   `yaml:"sqlite"`
 	LogLevel string         `yaml:"log_level"`
+
+	// Challenge replaces the whole challenge section when it names a provider
+	Challenge ChallengeConfig `yaml:"challenge"`
+
+	// Retention periods set here replace the ones of the retention section
+	// one by one; dry_run turns on dry runs
+	Retention RetentionConfig `yaml:"retention"`
 }
 
 var Config *AppConfig
 
-// Load loads configuration from YAML file and environment variables
-func Load(configPath string) (*AppConfig, error) {
-	// Read YAML file
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
+// LoadFromEnv loads configuration from environment variables only
+func LoadFromEnv() (*AppConfig, error) {
+	return Load("")
+}
 
-	// Parse YAML
+// Load loads configuration from YAML file and environment variables. An
+// empty configPath skips the file entirely and builds the configuration from
+// environment variables and defaults only.
+func Load(configPath string) (*AppConfig, error) {
 	var config AppConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if configPath != "" {
+		// Read YAML file
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		// Parse YAML
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
 	}
 
 	// Get environment (from env var or config)
@@ -135,7 +522,13 @@ func Load(configPath string) (*AppConfig, error) {
 		return nil, fmt.Errorf("failed to override with environment variables: %w", err)
 	}
 
-	// Validate configuration
+	// Resolve secret references in credential fields
+	if err := resolveSecrets(&config, secrets.NewManager()); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+
+	// Fill unset fields, then validate everything in one pass
+	applyDefaults(&config)
 	if err := validateConfig(&config); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
@@ -190,103 +583,109 @@ func applyEnvironmentOverrides(config *AppConfig, env string) error {
 		config.SQLite = envConfig.SQLite
 	}
 
-	return nil
-}
-
-// overrideWithEnvVars overrides config with environment variables
-func overrideWithEnvVars(config *AppConfig) error {
-	// Database type
-	if dbType := os.Getenv("DB_TYPE"); dbType != "" {
-		config.Database.Type = dbType
+	if envConfig.Challenge.Provider != "" {
+		config.Challenge = envConfig.Challenge
 	}
 
-	// PostgreSQL
-	if host := os.Getenv("DB_HOST"); host != "" {
-		config.Postgres.Host = host
-	}
-	if port := os.Getenv("DB_PORT"); port != "" {
-		config.Postgres.Port = port
-	}
-	if user := os.Getenv("DB_USER"); user != "" {
-		config.Postgres.User = user
-		config.MySQL.User = user // Apply to MySQL too
-	}
-	if password := os.Getenv("DB_PASSWORD"); password != "" {
-		config.Postgres.Password = password
-		config.MySQL.Password = password
-	}
-	if dbName := os.Getenv("DB_NAME"); dbName != "" {
-		config.Postgres.DBName = dbName
-		config.MySQL.DBName = dbName
+	for _, period := range []struct{ to, from *time.Duration }{
+		{&config.Retention.Messages, &envConfig.Retention.Messages},
+		{&config.Retention.Notifications, &envConfig.Retention.Notifications},
+		{&config.Retention.AuditLogs, &envConfig.Retention.AuditLogs},
+		{&config.Retention.SoftDeleted, &envConfig.Retention.SoftDeleted},
+	} {
+		if *period.from != 0 {
+			*period.to = *period.from
+		}
 	}
-	if sslMode := os.Getenv("DB_SSLMODE"); sslMode != "" {
-		config.Postgres.SSLMode = sslMode
+	if envConfig.Retention.BatchSize > 0 {
+		config.Retention.BatchSize = envConfig.Retention.BatchSize
 	}
-
-	// MySQL specific
-	if charset := os.Getenv("DB_CHARSET"); charset != "" {
-		config.MySQL.Charset = charset
+	if envConfig.Retention.DryRun {
+		config.Retention.DryRun = true
 	}
 
-	// SQLite
-	if filepath := os.Getenv("DB_FILEPATH"); filepath != "" {
-		config.SQLite.FilePath = filepath
-	}
+	return nil
+}
 
-	// Redis
-	if redisHost := os.Getenv("REDIS_HOST"); redisHost != "" {
-		config.Redis.Host = redisHost
-	}
-	if redisPort := os.Getenv("REDIS_PORT"); redisPort != "" {
-		config.Redis.Port = redisPort
-	}
-	if redisPassword := os.Getenv("REDIS_PASSWORD"); redisPassword != "" {
-		config.Redis.Password = redisPassword
+// overrideWithEnvVars overrides config with environment variables. Every
+// field is mapped through its `env` tag; see applyEnv for the naming rules.
+func overrideWithEnvVars(config *AppConfig) error {
+	// Single-DSN connection URLs; the individual variables below still win
+	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
+		if err := applyDatabaseURL(config, databaseURL); err != nil {
+			return err
+		}
 	}
-
-	// Application
-	if appPort := os.Getenv("APP_PORT"); appPort != "" {
-		fmt.Sscanf(appPort, "%d", &config.App.Port)
+	if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
+		if err := applyRedisURL(config, redisURL); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return applyEnv(config)
 }
 
-// validateConfig validates the configuration
-func validateConfig(config *AppConfig) error {
-	// Validate database type
-	dbType := db.DatabaseType(config.Database.Type)
-	switch dbType {
-	case db.PostgreSQL:
-		if config.Postgres.Host == "" || config.Postgres.Port == "" {
-			return fmt.Errorf("PostgreSQL configuration is incomplete")
+// resolveSecrets replaces secret references (vault:, awssm:, env:, file:) in
+// credential fields with their values so passwords never live in the YAML file
+func resolveSecrets(config *AppConfig, manager *secrets.Manager) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	fields := map[string]*string{
+		"postgres.password":   &config.Postgres.Password,
+		"mysql.password":      &config.MySQL.Password,
+		"redis.password":      &config.Redis.Password,
+		"sentry.dsn":          &config.Sentry.DSN,
+		"export.signing_key":  &config.Export.SigningKey,
+		"translation.api_key": &config.Translation.APIKey,
+		"sms.auth_token":      &config.SMS.AuthToken,
+		"challenge.secret":    &config.Challenge.Secret,
+		"sso.signing_key":     &config.SSO.SigningKey,
+		"scim.token":          &config.SCIM.Token,
+		"ldap.bind_password":  &config.LDAP.BindPassword,
+	}
+	for name, field := range fields {
+		value, err := manager.Resolve(ctx, *field)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
 		}
-	case db.MySQL:
-		if config.MySQL.Host == "" || config.MySQL.Port == "" {
-			return fmt.Errorf("MySQL configuration is incomplete")
+		*field = value
+	}
+
+	for name, provider := range config.SSO.Providers {
+		fields := map[string]*string{
+			"client_secret":   &provider.ClientSecret,
+			"idp_certificate": &provider.IDPCertificate,
 		}
-	case db.SQLite:
-		if config.SQLite.FilePath == "" {
-			return fmt.Errorf("SQLite file path is required")
+		for field, value := range fields {
+			resolved, err := manager.Resolve(ctx, *value)
+			if err != nil {
+				return fmt.Errorf("sso.providers.%s.%s: %w", name, field, err)
+			}
+			*value = resolved
 		}
-	default:
-		return fmt.Errorf("unsupported database type: %s", config.Database.Type)
+		config.SSO.Providers[name] = provider
 	}
-
 	return nil
 }
 
 // GetDatabaseConfig converts AppConfig to database.Config
 func (c *AppConfig) GetDatabaseConfig() db.Config {
 	dbConfig := db.Config{
-		Type:            db.DatabaseType(c.Database.Type),
-		MaxIdleConns:    c.Database.MaxIdleConns,
-		MaxOpenConns:    c.Database.MaxOpenConns,
-		ConnMaxLifetime: c.Database.ConnMaxLifetime,
-		ConnMaxIdleTime: c.Database.ConnMaxIdleTime,
-		LogLevel:        c.Database.LogLevel,
-		PrepareStmt:     c.Database.PrepareStmt,
-		SkipDefaultTxn:  c.Database.SkipDefaultTxn,
+		Type:                  db.DatabaseType(c.Database.Type),
+		MaxIdleConns:          c.Database.MaxIdleConns,
+		MaxOpenConns:          c.Database.MaxOpenConns,
+		ConnMaxLifetime:       c.Database.ConnMaxLifetime,
+		ConnMaxIdleTime:       c.Database.ConnMaxIdleTime,
+		ConnMaxLifetimeJitter: c.Database.ConnMaxLifetimeJitter,
+		WarmConns:             c.Database.WarmConns,
+		LogLevel:              c.Database.LogLevel,
+		PrepareStmt:           c.Database.PrepareStmt,
+		SkipDefaultTxn:        c.Database.SkipDefaultTxn,
+		SlowThreshold:         c.Database.SlowThreshold,
+		QueryTimeout:          c.Database.QueryTimeout,
+		BatchSize:             c.Database.BatchSize,
+		MultiTenant:           c.Tenancy.Enable,
 	}
 
 	// Set database-specific configs
@@ -307,11 +706,399 @@ func (c *AppConfig) GetDatabaseConfig() db.Config {
 		dbConfig.Charset = c.MySQL.Charset
 	case db.SQLite:
 		dbConfig.FilePath = c.SQLite.FilePath
+		dbConfig.SQLite = db.SQLiteConfig{
+			JournalMode:  c.SQLite.JournalMode,
+			Synchronous:  c.SQLite.Synchronous,
+			BusyTimeout:  c.SQLite.BusyTimeout,
+			ForeignKeys:  strings.EqualFold(c.SQLite.ForeignKeys, "on"),
+			SingleWriter: c.SQLite.SingleWriter,
+		}
 	}
 
 	return dbConfig
 }
 
+// GetRedisConfig converts AppConfig to cache.Config
+func (c *AppConfig) GetRedisConfig() cache.Config {
+	return cache.Config{
+		Host:         c.Redis.Host,
+		Port:         c.Redis.Port,
+		Username:     c.Redis.Username,
+		Password:     c.Redis.Password,
+		DB:           c.Redis.DB,
+		PoolSize:     c.Redis.PoolSize,
+		MinIdleConns: c.Redis.MinIdleConns,
+		TLS:          c.Redis.TLS,
+	}
+}
+
+// GetServerConfig converts AppConfig to server.Config
+func (c *AppConfig) GetServerConfig() server.Config {
+	return server.Config{
+		Addr:              fmt.Sprintf(":%d", c.App.Port),
+		ReadTimeout:       c.Server.ReadTimeout,
+		ReadHeaderTimeout: c.Server.ReadHeaderTimeout,
+		WriteTimeout:      c.Server.WriteTimeout,
+		IdleTimeout:       c.Server.IdleTimeout,
+		ShutdownTimeout:   c.Server.ShutdownTimeout,
+		MaxBodyBytes:      c.Server.MaxBodyBytes,
+		RouteLimits:       c.GetRouteLimits(),
+		TrustedProxies:    c.Server.TrustedProxies,
+		TLS: server.TLSConfig{
+			CertFile:         c.Server.TLS.CertFile,
+			KeyFile:          c.Server.TLS.KeyFile,
+			AutocertDomains:  c.Server.TLS.AutocertDomains,
+			AutocertEmail:    c.Server.TLS.AutocertEmail,
+			AutocertCacheDir: c.Server.TLS.AutocertCacheDir,
+		},
+	}
+}
+
+// GetRouteLimits converts the body limits to server.RouteLimit, ordered by name
+func (c *AppConfig) GetRouteLimits() []server.RouteLimit {
+	limits := make([]server.RouteLimit, 0, len(c.Server.BodyLimits))
+	for _, name := range slices.Sorted(maps.Keys(c.Server.BodyLimits)) {
+		limit := c.Server.BodyLimits[name]
+		limits = append(limits, server.RouteLimit{
+			Pattern:        limit.Route,
+			MaxBodyBytes:   limit.MaxBytes,
+			BytesPerSecond: limit.BytesPerSecond,
+		})
+	}
+	return limits
+}
+
+// GetRichTextOptions converts AppConfig to richtext.Options
+func (c *AppConfig) GetRichTextOptions() richtext.Options {
+	return richtext.Options{
+		Markdown:   c.Content.Markdown,
+		MentionURL: c.Content.MentionURL,
+		HashtagURL: c.Content.HashtagURL,
+	}
+}
+
+// GetTenantConfig converts AppConfig to tenant.Config
+func (c *AppConfig) GetTenantConfig() tenant.Config {
+	return tenant.Config{
+		Header:   c.Tenancy.Header,
+		Required: c.Tenancy.Required,
+	}
+}
+
+// GetExportConfig converts AppConfig to the export service config
+func (c *AppConfig) GetExportConfig() exportsvc.Config {
+	return exportsvc.Config{
+		Dir:       c.Export.Dir,
+		Retention: c.Export.Retention,
+	}
+}
+
+// GetImportConfig converts AppConfig to the archive import service config
+func (c *AppConfig) GetImportConfig() archivesvc.Config {
+	return archivesvc.Config{
+		Dir:     c.Import.Dir,
+		MaxSize: c.Import.MaxSize,
+	}
+}
+
+// GetFeedConfig converts AppConfig to the feed fan-out config
+func (c *AppConfig) GetFeedConfig() feedrepo.FanOutConfig {
+	return feedrepo.FanOutConfig{
+		ChunkSize:     c.Feed.FanOutChunkSize,
+		BatchSize:     c.Feed.FanOutBatchSize,
+		Workers:       c.Feed.FanOutWorkers,
+		PullThreshold: c.Feed.PullThreshold,
+	}
+}
+
+// GetExploreConfig converts AppConfig to the explore precomputation config.
+// Candidates count as stale after three missed trending_recompute runs; with
+// the task disabled no window is precomputed.
+func (c *AppConfig) GetExploreConfig() feedrepo.ExploreConfig {
+	task := c.Scheduler.Tasks["trending_recompute"]
+	if !task.Enabled {
+		return feedrepo.ExploreConfig{}
+	}
+	return feedrepo.ExploreConfig{
+		Windows:    c.Feed.ExploreWindows,
+		Candidates: c.Feed.ExploreCandidates,
+		MaxAge:     3 * task.Interval,
+	}
+}
+
+// GetAffinityConfig converts AppConfig to the home feed affinity config
+func (c *AppConfig) GetAffinityConfig() feedrepo.AffinityConfig {
+	return feedrepo.AffinityConfig{
+		MinScore: c.Feed.AffinityMinScore,
+		Within:   c.Feed.AffinityWithin,
+	}
+}
+
+// GetAnalyticsConfig converts AppConfig to the analytics service config
+func (c *AppConfig) GetAnalyticsConfig() analyticssvc.Config {
+	return analyticssvc.Config{
+		DedupeWindow: c.Analytics.ImpressionDedupeWindow,
+	}
+}
+
+// GetTranslateConfig converts AppConfig to translate.Config
+func (c *AppConfig) GetTranslateConfig() translate.Config {
+	return translate.Config{
+		Provider: strings.ToLower(c.Translation.Provider),
+		APIKey:   c.Translation.APIKey,
+		Endpoint: c.Translation.Endpoint,
+		Timeout:  c.Translation.Timeout,
+	}
+}
+
+// GetSMSConfig converts AppConfig to sms.Config
+func (c *AppConfig) GetSMSConfig() sms.Config {
+	return sms.Config{
+		Provider:   strings.ToLower(c.SMS.Provider),
+		AccountSID: c.SMS.AccountSID,
+		AuthToken:  c.SMS.AuthToken,
+		From:       c.SMS.From,
+		Endpoint:   c.SMS.Endpoint,
+		Timeout:    c.SMS.Timeout,
+	}
+}
+
+// GetChallengeConfig converts AppConfig to challenge.Config
+func (c *AppConfig) GetChallengeConfig() challenge.Config {
+	actions := make([]challenge.Action, len(c.Challenge.Actions))
+	for i, action := range c.Challenge.Actions {
+		actions[i] = challenge.Action(strings.ToLower(action))
+	}
+	return challenge.Config{
+		Provider:   strings.ToLower(c.Challenge.Provider),
+		SiteKey:    c.Challenge.SiteKey,
+		Secret:     c.Challenge.Secret,
+		Endpoint:   c.Challenge.Endpoint,
+		Timeout:    c.Challenge.Timeout,
+		Difficulty: c.Challenge.Difficulty,
+		TTL:        c.Challenge.TTL,
+		Actions:    actions,
+	}
+}
+
+// GetVelocityConfig converts AppConfig to velocity.Config
+func (c *AppConfig) GetVelocityConfig() velocity.Config {
+	return velocity.Config{
+		Window: c.Velocity.Window,
+		Thresholds: map[velocity.Metric]int64{
+			velocity.MetricPosts:             int64(c.Velocity.Posts),
+			velocity.MetricFollows:           int64(c.Velocity.Follows),
+			velocity.MetricMessageRecipients: int64(c.Velocity.MessageRecipients),
+		},
+	}
+}
+
+// GetOAuthServiceConfig converts AppConfig to the OAuth provider service config
+func (c *AppConfig) GetOAuthServiceConfig() oauthsvc.Config {
+	return oauthsvc.Config{
+		CodeTTL:         c.OAuth.CodeTTL,
+		AccessTokenTTL:  c.OAuth.AccessTokenTTL,
+		RefreshTokenTTL: c.OAuth.RefreshTokenTTL,
+	}
+}
+
+// GetOAuthConfig converts AppConfig to the app access token middleware config
+func (c *AppConfig) GetOAuthConfig() oauth.Config {
+	return oauth.Config{
+		RateLimit:  int64(c.OAuth.AppRateLimit),
+		RateWindow: c.OAuth.AppRateWindow,
+		// SCIM clients authenticate with the SCIM token, not an access token
+		Passthrough: []string{"/scim/"},
+	}
+}
+
+// GetSSOServiceConfig converts AppConfig to the single sign-on service
+// config. Callback, ACS and entity ID URLs are derived from app.public_url.
+func (c *AppConfig) GetSSOServiceConfig() ssosvc.Config {
+	base := strings.TrimSuffix(c.App.PublicURL, "/") + "/sso/"
+	var providers []ssosvc.Provider
+	for _, name := range slices.Sorted(maps.Keys(c.SSO.Providers)) {
+		p := c.SSO.Providers[name]
+		providers = append(providers, ssosvc.Provider{
+			Name:        name,
+			Protocol:    strings.ToLower(p.Type),
+			DisplayName: cmp.Or(p.DisplayName, name),
+			OIDC: oidc.Config{
+				Issuer:       p.Issuer,
+				ClientID:     p.ClientID,
+				ClientSecret: p.ClientSecret,
+				RedirectURL:  base + name + "/callback",
+				Scopes:       p.Scopes,
+			},
+			SAML: saml.Config{
+				EntityID:       base + name + "/metadata",
+				ACSURL:         base + name + "/acs",
+				IDPEntityID:    p.IDPEntityID,
+				IDPSSOURL:      p.IDPSSOURL,
+				IDPCertificate: p.IDPCertificate,
+				NameIDFormat:   p.NameIDFormat,
+			},
+			AllowedDomains: p.AllowedDomains,
+			JIT:            p.JIT,
+			LinkByEmail:    p.LinkByEmail,
+			Attributes: ssosvc.Attributes{
+				Username:  p.UsernameAttribute,
+				Email:     p.EmailAttribute,
+				FullName:  p.FullNameAttribute,
+				AvatarURL: p.AvatarURLAttribute,
+				Language:  p.LanguageAttribute,
+			},
+		})
+	}
+	return ssosvc.Config{
+		Providers:         providers,
+		StripEmailPlus:    c.Users.StripEmailPlus,
+		ReservedUsernames: c.Users.ReservedUsernames,
+		InviteOnly:        c.App.Features[FeatureInviteOnly],
+		Waitlist:          c.App.Features[FeatureWaitlist],
+	}
+}
+
+// GetSSOConfig converts AppConfig to the single sign-on handler config
+func (c *AppConfig) GetSSOConfig() ssohandler.Config {
+	return ssohandler.Config{
+		TicketTTL:    c.SSO.TicketTTL,
+		RedirectURL:  c.SSO.RedirectURL,
+		SecureCookie: strings.HasPrefix(c.App.PublicURL, "https://"),
+	}
+}
+
+// GetSCIMServiceConfig converts AppConfig to the SCIM service config.
+// Resource locations are derived from app.public_url.
+func (c *AppConfig) GetSCIMServiceConfig() scimsvc.Config {
+	return scimsvc.Config{
+		BaseURL:           strings.TrimSuffix(c.App.PublicURL, "/") + "/scim/v2",
+		StripEmailPlus:    c.Users.StripEmailPlus,
+		ReservedUsernames: c.Users.ReservedUsernames,
+	}
+}
+
+// GetLDAPConfig converts AppConfig to the directory client config
+func (c *AppConfig) GetLDAPConfig() ldapdir.Config {
+	return ldapdir.Config{
+		URL:                c.LDAP.URL,
+		StartTLS:           c.LDAP.StartTLS,
+		InsecureSkipVerify: c.LDAP.InsecureSkipVerify,
+		BindDN:             c.LDAP.BindDN,
+		BindPassword:       c.LDAP.BindPassword,
+		BaseDN:             c.LDAP.BaseDN,
+		Filter:             c.LDAP.Filter,
+		PageSize:           c.LDAP.PageSize,
+		Timeout:            c.LDAP.Timeout,
+	}
+}
+
+// GetDirectoryServiceConfig converts AppConfig to the directory sync config
+func (c *AppConfig) GetDirectoryServiceConfig() directorysvc.Config {
+	return directorysvc.Config{
+		TenantID: c.LDAP.TenantID,
+		Attributes: directorysvc.Attributes{
+			ID:       c.LDAP.IDAttribute,
+			Username: c.LDAP.UsernameAttribute,
+			Email:    c.LDAP.EmailAttribute,
+			FullName: c.LDAP.FullNameAttribute,
+			Manager:  c.LDAP.ManagerAttribute,
+		},
+		AutoFollow:        c.LDAP.AutoFollow,
+		MaxTeamSize:       c.LDAP.MaxTeamSize,
+		BatchSize:         c.LDAP.BatchSize,
+		StripEmailPlus:    c.Users.StripEmailPlus,
+		ReservedUsernames: c.Users.ReservedUsernames,
+	}
+}
+
+// GetInviteConfig converts AppConfig to invitesvc.Config
+func (c *AppConfig) GetInviteConfig() invitesvc.Config {
+	return invitesvc.Config{
+		Limit: c.Users.InviteLimit,
+		Uses:  c.Users.InviteUses,
+		TTL:   c.Users.InviteTTL,
+	}
+}
+
+// GetMaintenanceState converts AppConfig to the maintenance.State an
+// instance starts in
+func (c *AppConfig) GetMaintenanceState() maintenance.State {
+	return maintenance.State{
+		Enabled:    c.Maintenance.Enabled,
+		Message:    c.Maintenance.Message,
+		RetryAfter: c.Maintenance.RetryAfter,
+	}
+}
+
+// GetWaitlistConfig converts AppConfig to waitlistsvc.Config
+func (c *AppConfig) GetWaitlistConfig() waitlistsvc.Config {
+	return waitlistsvc.Config{StripEmailPlus: c.Users.StripEmailPlus}
+}
+
+// GetRetentionConfig converts AppConfig to retention.Config
+func (c *AppConfig) GetRetentionConfig() retention.Config {
+	return retention.Config{
+		Messages:      c.Retention.Messages,
+		Notifications: c.Retention.Notifications,
+		AuditLogs:     c.Retention.AuditLogs,
+		SoftDeleted:   c.Retention.SoftDeleted,
+		BatchSize:     c.Retention.BatchSize,
+		DryRun:        c.Retention.DryRun,
+	}
+}
+
+// GetQuotaConfig converts AppConfig to quota.Config
+func (c *AppConfig) GetQuotaConfig() quota.Config {
+	tiers := make(map[string]quota.Tier, len(c.Quota.Tiers))
+	for name, tier := range c.Quota.Tiers {
+		tiers[name] = quota.Tier{
+			quota.ActionPost:            {Limit: int64(tier.PostsPerDay), Window: 24 * time.Hour},
+			quota.ActionDMToNonFollower: {Limit: int64(tier.DMsToNonFollowersPerDay), Window: 24 * time.Hour},
+			quota.ActionFollow:          {Limit: int64(tier.FollowsPerHour), Window: time.Hour},
+		}
+	}
+	return quota.Config{Tiers: tiers}
+}
+
+// GetLoggerConfig converts AppConfig to logger.Config
+func (c *AppConfig) GetLoggerConfig() logger.Config {
+	return logger.Config{
+		Level:  c.Log.Level,
+		Format: c.Log.Format,
+	}
+}
+
+// GetErrorReportConfig converts AppConfig to errorreport.Config, tagging
+// events with the application environment and version
+func (c *AppConfig) GetErrorReportConfig() errorreport.Config {
+	return errorreport.Config{
+		DSN:              c.Sentry.DSN,
+		Environment:      c.App.Environment,
+		Release:          c.App.Name + "@" + c.App.Version,
+		SampleRate:       c.Sentry.SampleRate,
+		TracesSampleRate: c.Sentry.TracesSampleRate,
+		Debug:            c.Sentry.Debug,
+	}
+}
+
+// GetEventBusConfig converts AppConfig to eventbus.Config
+func (c *AppConfig) GetEventBusConfig() eventbus.Config {
+	return eventbus.Config{
+		Driver:  c.EventBus.Driver,
+		Workers: c.EventBus.Workers,
+		Buffer:  c.EventBus.Buffer,
+		URL:     c.EventBus.URL,
+		Stream: eventbus.StreamConfig{
+			Stream:     c.EventBus.Stream,
+			Group:      c.EventBus.Group,
+			Consumer:   c.EventBus.Consumer,
+			MaxLen:     c.EventBus.MaxLen,
+			MaxRetries: c.EventBus.MaxRetries,
+		},
+	}
+}
+
 // PrintConfig prints the current configuration (safe for logging)
 func (c *AppConfig) PrintConfig() {
 	fmt.Println("=== Application Configuration ===")
@@ -319,6 +1106,9 @@ func (c *AppConfig) PrintConfig() {
 	fmt.Printf("Version: %s\n", c.App.Version)
 	fmt.Printf("Environment: %s\n", c.App.Environment)
 	fmt.Printf("Port: %d\n", c.App.Port)
+	fmt.Printf("Metrics Port: %d\n", c.App.MetricsPort)
+	fmt.Printf("TLS: %v\n", c.Server.TLS.CertFile != "" || len(c.Server.TLS.AutocertDomains) > 0)
+	fmt.Printf("Trusted Proxies: %v\n", c.Server.TrustedProxies)
 	fmt.Println()
 
 	fmt.Println("=== Database Configuration ===")
@@ -326,6 +1116,8 @@ func (c *AppConfig) PrintConfig() {
 	fmt.Printf("Max Idle Conns: %d\n", c.Database.MaxIdleConns)
 	fmt.Printf("Max Open Conns: %d\n", c.Database.MaxOpenConns)
 	fmt.Printf("Log Level: %s\n", c.Database.LogLevel)
+	fmt.Printf("Slow Query Threshold: %s\n", c.Database.SlowThreshold)
+	fmt.Printf("Query Timeout: %s\n", c.Database.QueryTimeout)
 
 	switch db.DatabaseType(c.Database.Type) {
 	case db.PostgreSQL:
@@ -340,6 +1132,8 @@ func (c *AppConfig) PrintConfig() {
 		fmt.Printf("Charset: %s\n", c.MySQL.Charset)
 	case db.SQLite:
 		fmt.Printf("File: %s\n", c.SQLite.FilePath)
+		fmt.Printf("Journal Mode: %s\n", c.SQLite.JournalMode)
+		fmt.Printf("Single Writer: %t\n", c.SQLite.SingleWriter)
 	}
 
 	fmt.Println()
@@ -349,6 +1143,22 @@ func (c *AppConfig) PrintConfig() {
 	fmt.Printf("Pool Size: %d\n", c.Redis.PoolSize)
 	fmt.Println()
 
+	fmt.Println("=== Event Bus Configuration ===")
+	fmt.Printf("Driver: %s\n", c.EventBus.Driver)
+	if c.EventBus.Driver == eventbus.DriverRedis {
+		fmt.Printf("Stream: %s\n", c.EventBus.Stream)
+		fmt.Printf("Group: %s\n", c.EventBus.Group)
+	}
+	fmt.Println()
+
+	fmt.Println("=== Tenancy ===")
+	fmt.Printf("Multi-Tenant: %v\n", c.Tenancy.Enable)
+	if c.Tenancy.Enable {
+		fmt.Printf("Header: %s\n", c.Tenancy.Header)
+		fmt.Printf("Required: %v\n", c.Tenancy.Required)
+	}
+	fmt.Println()
+
 	fmt.Println("=== Migration Settings ===")
 	fmt.Printf("Auto Migrate: %v\n", c.Migrations.AutoMigrate)
 	fmt.Printf("Seed Data: %v\n", c.Migrations.SeedData)
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv walks v and overrides every field carrying an `env` tag.
+//
+// Scalar fields list one or more variable names; the first one set wins, so
+// `env:"POSTGRES_HOST,DB_HOST"` keeps older names working. Slices are read
+// as comma-separated lists of their element type. Map fields name a
+// prefix: map[string]bool reads PREFIX_<KEY>=value and map[string]struct reads
+// PREFIX_<KEY>_<FIELD>=value using the struct's own env tags as field names.
+// Keys are lower-cased. Pointer fields (environment overrides) are skipped.
+func applyEnv(v any) error {
+	return applyEnvStruct(reflect.ValueOf(v).Elem(), "")
+}
+
+func applyEnvStruct(rv reflect.Value, path string) error {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		value := rv.Field(i)
+		name := path + field.Name
+
+		tag := field.Tag.Get("env")
+		switch {
+		case field.Type.Kind() == reflect.Struct && field.Type != durationType:
+			if err := applyEnvStruct(value, name+"."); err != nil {
+				return err
+			}
+		case tag == "":
+			continue
+		case field.Type.Kind() == reflect.Map:
+			if err := applyEnvMap(value, tag, name); err != nil {
+				return err
+			}
+		case field.Type.Kind() != reflect.Pointer:
+			for _, key := range strings.Split(tag, ",") {
+				raw, ok := os.LookupEnv(key)
+				if !ok {
+					continue
+				}
+				if err := setFromString(value, raw); err != nil {
+					return fmt.Errorf("%s: invalid value %q for %s: %w", key, raw, name, err)
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func applyEnvMap(value reflect.Value, prefix, name string) error {
+	if value.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+	prefix += "_"
+	elemType := value.Type().Elem()
+
+	for _, kv := range os.Environ() {
+		key, raw, _ := strings.Cut(kv, "=")
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		if value.IsNil() {
+			value.Set(reflect.MakeMap(value.Type()))
+		}
+
+		if elemType.Kind() != reflect.Struct {
+			elem := reflect.New(elemType).Elem()
+			if err := setFromString(elem, raw); err != nil {
+				return fmt.Errorf("%s: invalid value %q for %s: %w", key, raw, name, err)
+			}
+			value.SetMapIndex(reflect.ValueOf(strings.ToLower(rest)), elem)
+			continue
+		}
+
+		// Match on the field suffix so keys may themselves contain underscores
+		for i := range elemType.NumField() {
+			suffix := "_" + elemType.Field(i).Tag.Get("env")
+			mapKey, ok := strings.CutSuffix(rest, suffix)
+			if suffix == "_" || !ok || mapKey == "" {
+				continue
+			}
+			k := reflect.ValueOf(strings.ToLower(mapKey))
+			elem := reflect.New(elemType).Elem()
+			if existing := value.MapIndex(k); existing.IsValid() {
+				elem.Set(existing)
+			}
+			if err := setFromString(elem.Field(i), raw); err != nil {
+				return fmt.Errorf("%s: invalid value %q for %s: %w", key, raw, name, err)
+			}
+			value.SetMapIndex(k, elem)
+			break
+		}
+	}
+	return nil
+}
+
+// setFromString parses raw into v according to v's type
+func setFromString(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		// Comma-separated list
+		items := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, raw := range strings.Split(raw, ",") {
+			if raw = strings.TrimSpace(raw); raw == "" {
+				continue
+			}
+			item := reflect.New(v.Type().Elem()).Elem()
+			if err := setFromString(item, raw); err != nil {
+				return err
+			}
+			items = reflect.Append(items, item)
+		}
+		v.Set(items)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,508 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	ssosvc "github.com/ilhamosaurus/sns-platform/internal/module/sso/service"
+	waitlistsvc "github.com/ilhamosaurus/sns-platform/internal/module/waitlist/service"
+	"github.com/ilhamosaurus/sns-platform/internal/retention"
+	"github.com/ilhamosaurus/sns-platform/pkg/challenge"
+	"github.com/ilhamosaurus/sns-platform/pkg/db"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/maintenance"
+	"github.com/ilhamosaurus/sns-platform/pkg/richtext"
+	"github.com/ilhamosaurus/sns-platform/pkg/server"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+	"github.com/ilhamosaurus/sns-platform/pkg/translate"
+	"github.com/ilhamosaurus/sns-platform/pkg/velocity"
+)
+
+// ssoProviderName is what identity provider names may look like; they
+// appear in URLs and are stored with linked identities
+var ssoProviderName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// ValidationError lists every configuration problem found in one pass
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d problem(s):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// validator accumulates problems keyed by their YAML path
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(field, format string, args ...any) {
+	v.problems = append(v.problems, field+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.addf(field, "is required")
+	}
+}
+
+// linkFormat checks a URL format that takes one string
+func (v *validator) linkFormat(field, format string) {
+	if strings.Count(format, "%") != 1 || !strings.Contains(format, "%s") {
+		v.addf(field, "must hold %%s once and no other verb, got %q", format)
+	}
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	if !slices.Contains(allowed, strings.ToLower(value)) {
+		v.addf(field, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+	}
+}
+
+func (v *validator) port(field, value string) {
+	if value == "" {
+		v.addf(field, "is required")
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > 65535 {
+		v.addf(field, "must be a port number between 1 and 65535, got %q", value)
+	}
+}
+
+func (v *validator) nonNegative(field string, n int) {
+	if n < 0 {
+		v.addf(field, "must not be negative, got %d", n)
+	}
+}
+
+func (v *validator) duration(field string, d time.Duration) {
+	if d < 0 {
+		v.addf(field, "must not be negative, got %s", d)
+	}
+}
+
+func (v *validator) ratio(field string, f float64) {
+	if f < 0 || f > 1 {
+		v.addf(field, "must be between 0 and 1, got %g", f)
+	}
+}
+
+// applyDefaults fills unset fields with the values the application would
+// otherwise assume implicitly, so validation and PrintConfig see real values
+func applyDefaults(config *AppConfig) {
+	setDefault(&config.Database.Type, string(db.SQLite))
+	setDefault(&config.Database.MaxIdleConns, 10)
+	setDefault(&config.Database.MaxOpenConns, 100)
+	setDefault(&config.Database.ConnMaxLifetime, time.Hour)
+	setDefault(&config.Database.ConnMaxIdleTime, 10*time.Minute)
+	setDefault(&config.Database.LogLevel, "info")
+	setDefault(&config.Database.BatchSize, db.DefaultBatchSize)
+	setDefault(&config.Database.QueryTimeout, 30*time.Second)
+
+	setDefault(&config.Postgres.Port, "5432")
+	setDefault(&config.Postgres.SSLMode, "disable")
+	setDefault(&config.MySQL.Port, "3306")
+	setDefault(&config.MySQL.Charset, "utf8mb4")
+	setDefault(&config.SQLite.JournalMode, "wal")
+	setDefault(&config.SQLite.Synchronous, "normal")
+	setDefault(&config.SQLite.BusyTimeout, 5*time.Second)
+	setDefault(&config.SQLite.ForeignKeys, "on")
+
+	setDefault(&config.Redis.Host, "localhost")
+	setDefault(&config.Redis.Port, "6379")
+	setDefault(&config.Redis.PoolSize, 10)
+
+	setDefault(&config.App.Environment, "development")
+	setDefault(&config.App.Port, 8080)
+
+	setDefault(&config.Server.ReadTimeout, 15*time.Second)
+	setDefault(&config.Server.ReadHeaderTimeout, 5*time.Second)
+	setDefault(&config.Server.WriteTimeout, 30*time.Second)
+	setDefault(&config.Server.IdleTimeout, 2*time.Minute)
+	setDefault(&config.Server.ShutdownTimeout, 30*time.Second)
+	setDefault(&config.Server.MaxBodyBytes, 1<<20)
+
+	setDefault(&config.Log.Level, "info")
+	setDefault(&config.Log.Format, "json")
+
+	setDefault(&config.EventBus.Driver, eventbus.DriverMemory)
+
+	setDefault(&config.Tenancy.Header, tenant.DefaultHeader)
+
+	setDefault(&config.Export.Dir, "./data/exports")
+	setDefault(&config.Export.LinkTTL, 15*time.Minute)
+	setDefault(&config.Export.Retention, 7*24*time.Hour)
+
+	setDefault(&config.Import.Dir, "./data/imports")
+	setDefault(&config.Import.MaxSize, 512<<20)
+	if _, ok := config.Server.BodyLimits["import"]; !ok {
+		if config.Server.BodyLimits == nil {
+			config.Server.BodyLimits = make(map[string]BodyLimitConfig)
+		}
+		// Room for the multipart framing around the archive
+		config.Server.BodyLimits["import"] = BodyLimitConfig{Route: "POST /me/imports", MaxBytes: config.Import.MaxSize + 1<<20}
+	}
+
+	setDefault(&config.Storage.Dir, "./data/media")
+	setDefault(&config.Storage.BaseURL, "/media")
+
+	setDefault(&config.Feed.FanOutChunkSize, feedrepo.DefaultFanOutConfig.ChunkSize)
+	setDefault(&config.Feed.FanOutWorkers, feedrepo.DefaultFanOutConfig.Workers)
+	if config.Feed.ExploreWindows == nil {
+		config.Feed.ExploreWindows = feedrepo.DefaultExploreConfig.Windows
+	}
+	setDefault(&config.Feed.ExploreCandidates, feedrepo.DefaultExploreConfig.Candidates)
+
+	setDefault(&config.Analytics.ImpressionDedupeWindow, 30*time.Minute)
+
+	setDefault(&config.Translation.Provider, translate.ProviderNone)
+	setDefault(&config.Translation.Timeout, 10*time.Second)
+
+	setDefault(&config.Content.MentionURL, richtext.DefaultOptions.MentionURL)
+	setDefault(&config.Content.HashtagURL, richtext.DefaultOptions.HashtagURL)
+
+	setDefault(&config.Challenge.Provider, challenge.ProviderNone)
+	setDefault(&config.Challenge.Timeout, 10*time.Second)
+	setDefault(&config.Challenge.Difficulty, 20)
+	setDefault(&config.Challenge.TTL, 5*time.Minute)
+
+	setDefault(&config.Velocity.Window, velocity.DefaultWindow)
+
+	setDefault(&config.OAuth.CodeTTL, 10*time.Minute)
+	setDefault(&config.OAuth.AccessTokenTTL, time.Hour)
+	setDefault(&config.OAuth.RefreshTokenTTL, 30*24*time.Hour)
+	setDefault(&config.OAuth.AppRateLimit, 3000)
+	setDefault(&config.OAuth.AppRateWindow, 15*time.Minute)
+
+	setDefault(&config.LDAP.PageSize, 500)
+	setDefault(&config.LDAP.Timeout, 30*time.Second)
+	setDefault(&config.LDAP.MaxTeamSize, 25)
+	setDefault(&config.LDAP.BatchSize, 500)
+
+	setDefault(&config.Users.InviteLimit, 5)
+	setDefault(&config.Users.InviteUses, 1)
+	setDefault(&config.Users.WaitlistBatch, 100)
+
+	setDefault(&config.Retention.BatchSize, retention.DefaultBatchSize)
+	setDefault(&config.Maintenance.RetryAfter, maintenance.DefaultRetryAfter)
+
+	setDefault(&config.SSO.TicketTTL, 2*time.Minute)
+	for name, provider := range config.SSO.Providers {
+		if strings.EqualFold(provider.Type, ssosvc.ProtocolOIDC) && len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"profile", "email"}
+			config.SSO.Providers[name] = provider
+		}
+	}
+}
+
+func setDefault[T comparable](field *T, value T) {
+	var zero T
+	if *field == zero {
+		*field = value
+	}
+}
+
+// validateConfig checks the whole configuration and reports every violation
+func validateConfig(config *AppConfig) error {
+	v := &validator{}
+
+	// Database
+	switch db.DatabaseType(config.Database.Type) {
+	case db.PostgreSQL:
+		v.required("postgres.host", config.Postgres.Host)
+		v.port("postgres.port", config.Postgres.Port)
+		v.required("postgres.user", config.Postgres.User)
+		v.required("postgres.dbname", config.Postgres.DBName)
+		v.oneOf("postgres.sslmode", config.Postgres.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	case db.MySQL:
+		v.required("mysql.host", config.MySQL.Host)
+		v.port("mysql.port", config.MySQL.Port)
+		v.required("mysql.user", config.MySQL.User)
+		v.required("mysql.dbname", config.MySQL.DBName)
+	case db.SQLite:
+		v.required("sqlite.filepath", config.SQLite.FilePath)
+		v.oneOf("sqlite.journal_mode", config.SQLite.JournalMode, "delete", "truncate", "persist", "memory", "wal", "off")
+		v.oneOf("sqlite.synchronous", config.SQLite.Synchronous, "off", "normal", "full", "extra")
+		v.duration("sqlite.busy_timeout", config.SQLite.BusyTimeout)
+		v.oneOf("sqlite.foreign_keys", config.SQLite.ForeignKeys, "on", "off")
+	default:
+		v.addf("database.type", "must be one of postgres, mysql, sqlite, got %q", config.Database.Type)
+	}
+	v.nonNegative("database.max_idle_conns", config.Database.MaxIdleConns)
+	v.nonNegative("database.max_open_conns", config.Database.MaxOpenConns)
+	if config.Database.MaxOpenConns > 0 && config.Database.MaxIdleConns > config.Database.MaxOpenConns {
+		v.addf("database.max_idle_conns", "must not exceed max_open_conns (%d)", config.Database.MaxOpenConns)
+	}
+	v.duration("database.conn_max_lifetime", config.Database.ConnMaxLifetime)
+	v.duration("database.conn_max_idle_time", config.Database.ConnMaxIdleTime)
+	v.duration("database.conn_max_lifetime_jitter", config.Database.ConnMaxLifetimeJitter)
+	if config.Database.ConnMaxLifetimeJitter > config.Database.ConnMaxLifetime/2 {
+		v.addf("database.conn_max_lifetime_jitter", "must not exceed half of conn_max_lifetime (%s)", config.Database.ConnMaxLifetime)
+	}
+	v.nonNegative("database.warm_conns", config.Database.WarmConns)
+	if config.Database.WarmConns > config.Database.MaxIdleConns {
+		v.addf("database.warm_conns", "must not exceed max_idle_conns (%d)", config.Database.MaxIdleConns)
+	}
+	v.nonNegative("database.batch_size", config.Database.BatchSize)
+	v.oneOf("database.log_level", config.Database.LogLevel, "silent", "error", "warn", "info")
+
+	// Redis
+	if config.Redis.Enable {
+		v.required("redis.host", config.Redis.Host)
+		v.port("redis.port", config.Redis.Port)
+		if config.Redis.DB < 0 || config.Redis.DB > 15 {
+			v.addf("redis.db", "must be between 0 and 15, got %d", config.Redis.DB)
+		}
+		v.nonNegative("redis.pool_size", config.Redis.PoolSize)
+		v.nonNegative("redis.min_idle_conns", config.Redis.MinIdleConns)
+	}
+
+	// Application
+	if config.App.Port < 1 || config.App.Port > 65535 {
+		v.addf("app.port", "must be between 1 and 65535, got %d", config.App.Port)
+	}
+	if config.App.MetricsPort < 0 || config.App.MetricsPort > 65535 || (config.App.MetricsPort != 0 && config.App.MetricsPort == config.App.Port) {
+		v.addf("app.metrics_port", "must be 0 or a port between 1 and 65535 other than app.port, got %d", config.App.MetricsPort)
+	}
+	v.oneOf("app.environment", config.App.Environment, "development", "testing", "staging", "production")
+	if config.App.PublicURL != "" {
+		if u, err := url.Parse(config.App.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.addf("app.public_url", "must be an http or https URL, got %q", config.App.PublicURL)
+		}
+	}
+	v.duration("server.read_timeout", config.Server.ReadTimeout)
+	v.duration("server.read_header_timeout", config.Server.ReadHeaderTimeout)
+	v.duration("server.write_timeout", config.Server.WriteTimeout)
+	v.duration("server.idle_timeout", config.Server.IdleTimeout)
+	v.duration("server.shutdown_timeout", config.Server.ShutdownTimeout)
+	if config.Server.MaxBodyBytes < 0 {
+		v.addf("server.max_body_bytes", "must not be negative, got %d", config.Server.MaxBodyBytes)
+	}
+	if (config.Server.TLS.CertFile == "") != (config.Server.TLS.KeyFile == "") {
+		v.addf("server.tls", "cert_file and key_file must be set together")
+	}
+	if config.Server.TLS.CertFile != "" && len(config.Server.TLS.AutocertDomains) > 0 {
+		v.addf("server.tls", "use either cert_file/key_file or autocert_domains, not both")
+	}
+	for _, name := range slices.Sorted(maps.Keys(config.Server.BodyLimits)) {
+		limit := config.Server.BodyLimits[name]
+		v.required("server.body_limits."+name+".route", limit.Route)
+		if limit.MaxBytes < 0 || limit.BytesPerSecond < 0 {
+			v.addf("server.body_limits."+name, "max_bytes and bytes_per_second must not be negative")
+		}
+	}
+	if _, err := server.NewBodyLimiter(config.Server.MaxBodyBytes, config.GetRouteLimits()); err != nil {
+		v.addf("server.body_limits", "%v", err)
+	}
+	if _, err := server.NewClientIPResolver(config.Server.TrustedProxies); err != nil {
+		v.addf("server.trusted_proxies", "%v", err)
+	}
+	v.oneOf("log.level", config.Log.Level, "debug", "info", "warn", "error")
+	v.oneOf("log.format", config.Log.Format, "json", "text")
+
+	// Scheduler
+	for _, name := range slices.Sorted(maps.Keys(config.Scheduler.Tasks)) {
+		task := config.Scheduler.Tasks[name]
+		if task.Enabled && task.Interval <= 0 {
+			v.addf("scheduler.tasks."+name+".interval", "must be positive for an enabled task")
+		}
+		v.duration("scheduler.tasks."+name+".retention", task.Retention)
+	}
+
+	// Event bus
+	switch config.EventBus.Driver {
+	case eventbus.DriverMemory:
+	case eventbus.DriverRedis:
+		if !config.Redis.Enable {
+			v.addf("event_bus.driver", "%s requires redis.enable", config.EventBus.Driver)
+		}
+	case eventbus.DriverNATS:
+		v.required("event_bus.url", config.EventBus.URL)
+	default:
+		v.addf("event_bus.driver", "must be one of memory, redis, nats, got %q", config.EventBus.Driver)
+	}
+	v.nonNegative("event_bus.max_retries", config.EventBus.MaxRetries)
+
+	// Data export
+	if strings.EqualFold(config.App.Environment, "production") || strings.EqualFold(config.App.Environment, "staging") {
+		v.required("export.signing_key", config.Export.SigningKey)
+	}
+	v.duration("export.link_ttl", config.Export.LinkTTL)
+	v.duration("export.retention", config.Export.Retention)
+
+	// Archive import
+	if config.Import.MaxSize < 0 {
+		v.addf("import.max_size", "must not be negative, got %d", config.Import.MaxSize)
+	}
+
+	// Feed fan-out
+	v.nonNegative("feed.fan_out_chunk_size", config.Feed.FanOutChunkSize)
+	v.nonNegative("feed.fan_out_batch_size", config.Feed.FanOutBatchSize)
+	v.nonNegative("feed.fan_out_workers", config.Feed.FanOutWorkers)
+	v.duration("feed.cache_ttl", config.Feed.CacheTTL)
+	if config.Feed.PullThreshold < 0 {
+		v.addf("feed.pull_threshold", "must not be negative, got %d", config.Feed.PullThreshold)
+	}
+	for _, window := range config.Feed.ExploreWindows {
+		if window <= 0 {
+			v.addf("feed.explore_windows", "must be positive, got %s", window)
+		}
+	}
+	v.nonNegative("feed.explore_candidates", config.Feed.ExploreCandidates)
+	if config.Feed.AffinityMinScore < 0 {
+		v.addf("feed.affinity_min_score", "must not be negative, got %d", config.Feed.AffinityMinScore)
+	}
+	v.duration("feed.affinity_within", config.Feed.AffinityWithin)
+
+	// Quotas
+	for _, name := range slices.Sorted(maps.Keys(config.Quota.Tiers)) {
+		tier := config.Quota.Tiers[name]
+		v.nonNegative("quota.tiers."+name+".posts_per_day", tier.PostsPerDay)
+		v.nonNegative("quota.tiers."+name+".dms_to_non_followers_per_day", tier.DMsToNonFollowersPerDay)
+		v.nonNegative("quota.tiers."+name+".follows_per_hour", tier.FollowsPerHour)
+	}
+
+	// Translation
+	v.oneOf("translation.provider", config.Translation.Provider, translate.ProviderNone, translate.ProviderDeepL, translate.ProviderGoogle)
+	if !strings.EqualFold(config.Translation.Provider, translate.ProviderNone) {
+		v.required("translation.api_key", config.Translation.APIKey)
+	}
+	v.duration("translation.timeout", config.Translation.Timeout)
+
+	// Content rendering
+	v.linkFormat("content.mention_url", config.Content.MentionURL)
+	v.linkFormat("content.hashtag_url", config.Content.HashtagURL)
+
+	// Invites and the waitlist
+	v.nonNegative("users.invite_limit", config.Users.InviteLimit)
+	v.nonNegative("users.invite_uses", config.Users.InviteUses)
+	v.duration("users.invite_ttl", config.Users.InviteTTL)
+	if config.Users.WaitlistBatch < 1 || config.Users.WaitlistBatch > waitlistsvc.MaxBatch {
+		v.addf("users.waitlist_batch", "must be between 1 and %d, got %d", waitlistsvc.MaxBatch, config.Users.WaitlistBatch)
+	}
+
+	// Retention
+	v.duration("retention.messages", config.Retention.Messages)
+	v.duration("retention.notifications", config.Retention.Notifications)
+	v.duration("retention.audit_logs", config.Retention.AuditLogs)
+	v.duration("retention.soft_deleted", config.Retention.SoftDeleted)
+	v.nonNegative("retention.batch_size", config.Retention.BatchSize)
+
+	// Maintenance
+	v.duration("maintenance.retry_after", config.Maintenance.RetryAfter)
+
+	// Challenges
+	v.oneOf("challenge.provider", config.Challenge.Provider, challenge.ProviderNone, challenge.ProviderPoW, challenge.ProviderHCaptcha, challenge.ProviderTurnstile)
+	switch strings.ToLower(config.Challenge.Provider) {
+	case challenge.ProviderHCaptcha, challenge.ProviderTurnstile:
+		v.required("challenge.site_key", config.Challenge.SiteKey)
+		v.required("challenge.secret", config.Challenge.Secret)
+	case challenge.ProviderPoW:
+		if strings.EqualFold(config.App.Environment, "production") || strings.EqualFold(config.App.Environment, "staging") {
+			v.required("challenge.secret", config.Challenge.Secret)
+		}
+	}
+	v.duration("challenge.timeout", config.Challenge.Timeout)
+	if config.Challenge.Difficulty < 1 || config.Challenge.Difficulty > 28 {
+		v.addf("challenge.difficulty", "must be between 1 and 28, got %d", config.Challenge.Difficulty)
+	}
+	v.duration("challenge.ttl", config.Challenge.TTL)
+	v.duration("challenge.new_account_age", config.Challenge.NewAccountAge)
+
+	// Velocity
+	v.duration("velocity.window", config.Velocity.Window)
+	v.nonNegative("velocity.posts", config.Velocity.Posts)
+	v.nonNegative("velocity.follows", config.Velocity.Follows)
+	v.nonNegative("velocity.message_recipients", config.Velocity.MessageRecipients)
+	for i, action := range config.Challenge.Actions {
+		if !slices.Contains(challenge.Actions, challenge.Action(strings.ToLower(action))) {
+			v.addf(fmt.Sprintf("challenge.actions[%d]", i), "must be one of sign_in, post, got %q", action)
+		}
+	}
+
+	// OAuth provider
+	v.duration("oauth.code_ttl", config.OAuth.CodeTTL)
+	v.duration("oauth.access_token_ttl", config.OAuth.AccessTokenTTL)
+	v.duration("oauth.refresh_token_ttl", config.OAuth.RefreshTokenTTL)
+	v.nonNegative("oauth.app_rate_limit", config.OAuth.AppRateLimit)
+	v.duration("oauth.app_rate_window", config.OAuth.AppRateWindow)
+
+	// Single sign-on
+	if len(config.SSO.Providers) > 0 {
+		v.required("app.public_url", config.App.PublicURL)
+		if strings.EqualFold(config.App.Environment, "production") || strings.EqualFold(config.App.Environment, "staging") {
+			v.required("sso.signing_key", config.SSO.SigningKey)
+		}
+	}
+	v.duration("sso.ticket_ttl", config.SSO.TicketTTL)
+	if config.SSO.RedirectURL != "" {
+		if u, err := url.Parse(config.SSO.RedirectURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.addf("sso.redirect_url", "must be an http or https URL, got %q", config.SSO.RedirectURL)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(config.SSO.Providers)) {
+		provider := config.SSO.Providers[name]
+		path := "sso.providers." + name
+		if !ssoProviderName.MatchString(name) {
+			v.addf(path, "name must be lowercase letters, digits, dashes and underscores")
+		}
+		switch strings.ToLower(provider.Type) {
+		case ssosvc.ProtocolOIDC:
+			v.required(path+".issuer", provider.Issuer)
+			v.required(path+".client_id", provider.ClientID)
+		case ssosvc.ProtocolSAML:
+			v.required(path+".idp_entity_id", provider.IDPEntityID)
+			v.required(path+".idp_sso_url", provider.IDPSSOURL)
+			v.required(path+".idp_certificate", provider.IDPCertificate)
+		default:
+			v.addf(path+".type", "must be one of oidc, saml, got %q", provider.Type)
+		}
+		if !provider.JIT && !provider.LinkByEmail {
+			v.addf(path, "enable jit, link_by_email or both, or nobody can sign in")
+		}
+	}
+
+	// SCIM provisioning
+	if config.SCIM.Enable {
+		v.required("scim.token", config.SCIM.Token)
+		if config.SCIM.Token != "" && len(config.SCIM.Token) < 32 {
+			v.addf("scim.token", "must be at least 32 characters")
+		}
+	}
+
+	// LDAP directory sync
+	if config.Scheduler.Tasks["ldap_sync"].Enabled {
+		v.required("ldap.url", config.LDAP.URL)
+	}
+	if config.LDAP.URL != "" {
+		u, err := url.Parse(config.LDAP.URL)
+		switch {
+		case err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "":
+			v.addf("ldap.url", "must be an ldap or ldaps URL, got %q", config.LDAP.URL)
+		case u.Scheme == "ldaps" && config.LDAP.StartTLS:
+			v.addf("ldap.start_tls", "cannot be used with an ldaps URL, which is already encrypted")
+		}
+		v.required("ldap.base_dn", config.LDAP.BaseDN)
+	}
+	v.duration("ldap.timeout", config.LDAP.Timeout)
+	v.nonNegative("ldap.page_size", config.LDAP.PageSize)
+	v.nonNegative("ldap.max_team_size", config.LDAP.MaxTeamSize)
+	v.nonNegative("ldap.batch_size", config.LDAP.BatchSize)
+
+	// Error reporting
+	v.ratio("sentry.sample_rate", config.Sentry.SampleRate)
+	v.ratio("sentry.traces_sample_rate", config.Sentry.TracesSampleRate)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// Package counter recomputes the denormalized counters on users, posts,
+// comments and communities from the rows they count. Counters are kept in
+// step incrementally as things happen; Reconcile repairs any drift left by
+// failed writes, manual edits or bugs.
+package counter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+// Counter is one denormalized column and the rows it counts
+type Counter struct {
+	Table  string
+	Column string
+
+	// Source groups the counted rows into (id, n) pairs, id being the key
+	// matched against Table.id
+	Source string
+}
+
+// Name identifies the counter in logs and results
+func (c Counter) Name() string {
+	return c.Table + "." + c.Column
+}
+
+// Actual is the SQL expression counting a row's source rows, for use in
+// statements on Table
+func (c Counter) Actual() string {
+	// The grouped derived table is materialized, which lets MySQL count rows
+	// of the table being updated
+	return fmt.Sprintf("COALESCE((SELECT src.n FROM (%s) src WHERE src.id = %s.id), 0)", c.Source, c.Table)
+}
+
+// Counters lists every denormalized counter Reconcile maintains
+var Counters = []Counter{
+	{"users", "follower_count", "SELECT following_id AS id, COUNT(*) AS n FROM follows WHERE deleted_at IS NULL GROUP BY following_id"},
+	{"users", "following_count", "SELECT follower_id AS id, COUNT(*) AS n FROM follows WHERE deleted_at IS NULL GROUP BY follower_id"},
+	{"users", "post_count", "SELECT user_id AS id, COUNT(*) AS n FROM posts WHERE deleted_at IS NULL GROUP BY user_id"},
+	{"posts", "like_count", "SELECT post_id AS id, COUNT(*) AS n FROM reactions WHERE post_id IS NOT NULL AND deleted_at IS NULL GROUP BY post_id"},
+	{"posts", "comment_count", "SELECT post_id AS id, COUNT(*) AS n FROM comments WHERE pending_approval = FALSE AND deleted_at IS NULL GROUP BY post_id"},
+	{"comments", "likes_count", "SELECT comment_id AS id, COUNT(*) AS n FROM reactions WHERE comment_id IS NOT NULL AND deleted_at IS NULL GROUP BY comment_id"},
+	{"comments", "replies_count", "SELECT parent_id AS id, COUNT(*) AS n FROM comments WHERE parent_id IS NOT NULL AND pending_approval = FALSE AND deleted_at IS NULL GROUP BY parent_id"},
+	{"communities", "member_count", "SELECT community_id AS id, COUNT(*) AS n FROM community_members WHERE status = '" + types.MembershipStatusActive.String() + "' AND deleted_at IS NULL GROUP BY community_id"},
+	{"communities", "post_count", "SELECT community_id AS id, COUNT(*) AS n FROM posts WHERE community_id IS NOT NULL AND deleted_at IS NULL GROUP BY community_id"},
+}
+
+const (
+	// DefaultBatchSize is how many IDs of a table each pass checks
+	DefaultBatchSize = 5000
+
+	// MaxSamples is how many drifted rows a Result lists
+	MaxSamples = 10
+)
+
+// Options tunes a Reconcile call
+type Options struct {
+	BatchSize int
+	DryRun    bool // Report drift without fixing it
+}
+
+// Option customizes a Reconcile call
+type Option func(*Options)
+
+// WithBatchSize sets how many IDs each pass checks; zero keeps DefaultBatchSize
+func WithBatchSize(n int) Option {
+	return func(o *Options) {
+		if n > 0 {
+			o.BatchSize = n
+		}
+	}
+}
+
+// DryRun makes Reconcile only report drift
+func DryRun() Option {
+	return func(o *Options) { o.DryRun = true }
+}
+
+// Discrepancy is a row whose stored counter differs from its source rows
+type Discrepancy struct {
+	ID     int64
+	Stored int64
+	Actual int64
+}
+
+// Result reports how many rows of a counter had drifted and how many of them
+// were fixed, which is none on a dry run
+type Result struct {
+	Counter Counter
+	Drifted int64
+	Fixed   int64
+	Samples []Discrepancy // The first MaxSamples drifted rows
+}
+
+// Reconcile checks every counter against its source rows in batches of IDs
+// and rewrites the ones that differ. Each batch is fixed on its own, so a
+// failure keeps the batches already done.
+func Reconcile(ctx context.Context, db *gorm.DB, opts ...Option) ([]Result, error) {
+	o := Options{BatchSize: DefaultBatchSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	results := make([]Result, 0, len(Counters))
+	for _, c := range Counters {
+		result, err := reconcile(ctx, db, c, o)
+		if err != nil {
+			return results, fmt.Errorf("failed to reconcile %s: %w", c.Name(), err)
+		}
+		if result.Drifted > 0 {
+			slog.InfoContext(ctx, "found drifted counters", slog.String("counter", c.Name()),
+				slog.Int64("rows", result.Drifted), slog.Int64("fixed", result.Fixed))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func reconcile(ctx context.Context, db *gorm.DB, c Counter, o Options) (Result, error) {
+	result := Result{Counter: c}
+	db = db.WithContext(ctx)
+
+	var maxID int64
+	if err := db.Raw(fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s", c.Table)).Scan(&maxID).Error; err != nil {
+		return result, err
+	}
+
+	actual := c.Actual()
+	drifted := fmt.Sprintf("deleted_at IS NULL AND %s <> %s", c.Column, actual)
+	check := fmt.Sprintf("SELECT id, %s AS stored, %s AS actual FROM %s WHERE id > ? AND id <= ? AND %s ORDER BY id",
+		c.Column, actual, c.Table, drifted)
+	fix := fmt.Sprintf("UPDATE %s SET %s = %s WHERE id IN ? AND %s", c.Table, c.Column, actual, drifted)
+
+	for from := int64(0); from < maxID; from += int64(o.BatchSize) {
+		var rows []Discrepancy
+		if err := db.Raw(check, from, from+int64(o.BatchSize)).Scan(&rows).Error; err != nil {
+			return result, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		result.Drifted += int64(len(rows))
+		if n := MaxSamples - len(result.Samples); n > 0 {
+			result.Samples = append(result.Samples, rows[:min(n, len(rows))]...)
+		}
+		if o.DryRun {
+			continue
+		}
+
+		ids := make([]int64, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+		tx := db.Exec(fix, ids)
+		if tx.Error != nil {
+			return result, tx.Error
+		}
+		result.Fixed += tx.RowsAffected
+	}
+	return result, nil
+}
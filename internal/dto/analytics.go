@@ -0,0 +1,118 @@
+package dto
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+)
+
+// DateLayout formats the days of insight series
+const DateLayout = "2006-01-02"
+
+// PostInsights summarizes a post's performance over a range of days
+type PostInsights struct {
+	PostID         int64               `json:"post_id"`
+	Since          string              `json:"since"` // First day, inclusive
+	Until          string              `json:"until"` // Last day, inclusive
+	Impressions    int64               `json:"impressions"`
+	Reach          int64               `json:"reach"` // Accounts that saw the post for the first time in the range
+	Likes          int64               `json:"likes"`
+	Comments       int64               `json:"comments"`
+	LinkClicks     int64               `json:"link_clicks"`
+	EngagementRate float64             `json:"engagement_rate"` // (likes + comments) / impressions
+	Sources        []PostSourceInsight `json:"sources"`         // Busiest first
+	Daily          []PostInsightDay    `json:"daily"`
+}
+
+// PostSourceInsight is how much of a post's activity came through one surface
+type PostSourceInsight struct {
+	Source      string  `json:"source"` // One of model.ViewSources, empty for activity the client did not attribute
+	Impressions int64   `json:"impressions"`
+	Share       float64 `json:"share"` // Of the post's impressions in the range
+	LinkClicks  int64   `json:"link_clicks"`
+}
+
+// NewPostSourceInsights sums daily source stats per source, busiest first;
+// impressions is the post's total in the same range
+func NewPostSourceInsights(stats []*model.PostSourceDailyStat, impressions int64) []PostSourceInsight {
+	bySource := make(map[string]*PostSourceInsight)
+	for _, stat := range stats {
+		insight := bySource[stat.Source]
+		if insight == nil {
+			insight = &PostSourceInsight{Source: stat.Source}
+			bySource[stat.Source] = insight
+		}
+		insight.Impressions += stat.Impressions
+		insight.LinkClicks += stat.LinkClicks
+	}
+
+	out := make([]PostSourceInsight, 0, len(bySource))
+	for _, insight := range bySource {
+		if impressions > 0 {
+			insight.Share = float64(insight.Impressions) / float64(impressions)
+		}
+		out = append(out, *insight)
+	}
+	slices.SortFunc(out, func(a, b PostSourceInsight) int {
+		return cmp.Or(cmp.Compare(b.Impressions, a.Impressions), cmp.Compare(b.LinkClicks, a.LinkClicks), cmp.Compare(a.Source, b.Source))
+	})
+	return out
+}
+
+// PostInsightDay is one day of a post's performance
+type PostInsightDay struct {
+	Day            string  `json:"day"`
+	Impressions    int64   `json:"impressions"`
+	Reach          int64   `json:"reach"`
+	Likes          int64   `json:"likes"`
+	Comments       int64   `json:"comments"`
+	LinkClicks     int64   `json:"link_clicks"`
+	EngagementRate float64 `json:"engagement_rate"`
+}
+
+// AccountInsights summarizes an account's audience and reach over a range of days
+type AccountInsights struct {
+	Since           string              `json:"since"`
+	Until           string              `json:"until"`
+	FollowerCount   int64               `json:"follower_count"`
+	FollowersGained int64               `json:"followers_gained"`
+	FollowersLost   int64               `json:"followers_lost"`
+	Impressions     int64               `json:"impressions"`
+	Reach           int64               `json:"reach"` // Accounts that saw any of the posts for the first time in the range
+	Engagements     int64               `json:"engagements"`
+	EngagementRate  float64             `json:"engagement_rate"` // engagements / impressions
+	LinkClicks      int64               `json:"link_clicks"`
+	ProfileViews    int64               `json:"profile_views"`
+	Daily           []AccountInsightDay `json:"daily"`
+}
+
+// AccountInsightDay is one day of an account's audience and reach
+type AccountInsightDay struct {
+	Day             string  `json:"day"`
+	FollowerCount   int64   `json:"follower_count"` // At the end of the day
+	FollowersGained int64   `json:"followers_gained"`
+	FollowersLost   int64   `json:"followers_lost"`
+	Impressions     int64   `json:"impressions"`
+	Reach           int64   `json:"reach"`
+	Engagements     int64   `json:"engagements"`
+	EngagementRate  float64 `json:"engagement_rate"`
+	LinkClicks      int64   `json:"link_clicks"`
+	ProfileViews    int64   `json:"profile_views"`
+}
+
+// GrowthPoint is a user's counters as snapshotted at the end of one day
+type GrowthPoint struct {
+	Day            string `json:"day"`
+	FollowerCount  int64  `json:"follower_count"`
+	FollowingCount int64  `json:"following_count"`
+	PostCount      int64  `json:"post_count"`
+}
+
+// EngagementRate is engagements per impression, zero without impressions
+func EngagementRate(engagements, impressions int64) float64 {
+	if impressions == 0 {
+		return 0
+	}
+	return float64(engagements) / float64(impressions)
+}
@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+)
+
+// Announcement is a running announcement as shown above the feed
+type Announcement struct {
+	ID      int64     `json:"id"`
+	Title   string    `json:"title"`
+	Body    string    `json:"body"`
+	LinkURL string    `json:"link_url,omitempty"`
+	EndsAt  time.Time `json:"ends_at"`
+}
+
+func NewAnnouncements(announcements []*model.Announcement) []*Announcement {
+	out := make([]*Announcement, len(announcements))
+	for i, a := range announcements {
+		out[i] = &Announcement{ID: a.ID, Title: a.Title, Body: a.Body, LinkURL: a.LinkURL, EndsAt: a.EndsAt}
+	}
+	return out
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "github.com/ilhamosaurus/sns-platform/internal/model"
+
+// CommunityModeration is what a community's moderators configure for
+// auto-moderation
+type CommunityModeration struct {
+	FilterRules     []string `json:"filter_rules"`      // Words and phrases refused in posts and comments
+	AutoHideReports int      `json:"auto_hide_reports"` // Reports that hide a comment; zero never hides
+}
+
+func NewCommunityModeration(community *model.Community, rules []*model.CommunityFilterRule) *CommunityModeration {
+	m := &CommunityModeration{FilterRules: make([]string, len(rules)), AutoHideReports: community.AutoHideReports}
+	for i, rule := range rules {
+		m.FilterRules[i] = rule.Pattern
+	}
+	return m
+}
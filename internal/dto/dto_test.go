@@ -0,0 +1,532 @@
+package dto_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+var (
+	created = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	updated = created.Add(time.Hour)
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// fields marshals v and returns its top-level JSON keys, to check which
+// optional fields are left out
+func fields(t *testing.T, v any) map[string]bool {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[string]bool, len(m))
+	for k := range m {
+		keys[k] = true
+	}
+	return keys
+}
+
+func TestNewPostResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		post    *model.Post
+		want    *dto.PostResponse
+		present []string // JSON keys that must be set
+		absent  []string // JSON keys that must be left out
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "plain",
+			post: &model.Post{
+				BaseModel: model.BaseModel{ID: 1, CreatedAt: created, UpdatedAt: updated},
+				UserID:    2,
+				Content:   "Hello world",
+				MediaType: types.MediaTypeText,
+				IsPublic:  true,
+			},
+			want: &dto.PostResponse{
+				ID:          1,
+				UserID:      2,
+				Content:     "Hello world",
+				ContentHTML: "<p>Hello world</p>",
+				Slug:        "hello-world",
+				MediaType:   types.MediaTypeText,
+				IsPublic:    true,
+				CreatedAt:   created,
+				UpdatedAt:   updated,
+			},
+			present: []string{"content_html", "slug", "is_public", "like_count"},
+			absent:  []string{"community_id", "organization_id", "page_id", "repost_of_id", "media_url", "language", "region", "latitude", "longitude"},
+		},
+		{
+			name: "optional fields",
+			post: &model.Post{
+				BaseModel:       model.BaseModel{ID: 3, CreatedAt: created, UpdatedAt: updated},
+				UserID:          2,
+				CommunityID:     ptr(int64(4)),
+				OrganizationID:  ptr(int64(5)),
+				PageID:          ptr(int64(6)),
+				RepostOfID:      ptr(int64(7)),
+				MediaType:       types.MediaTypeImage,
+				MediaURL:        "https://cdn.example.com/a.jpg",
+				Language:        "en",
+				Region:          "ID",
+				Latitude:        ptr(-6.2),
+				Longitude:       ptr(106.8),
+				CloseFriends:    true,
+				LikeCount:       8,
+				CommentCount:    9,
+				RepostsDisabled: true,
+				EmbedsDisabled:  true,
+			},
+			want: &dto.PostResponse{
+				ID:              3,
+				UserID:          2,
+				CommunityID:     ptr(int64(4)),
+				OrganizationID:  ptr(int64(5)),
+				PageID:          ptr(int64(6)),
+				RepostOfID:      ptr(int64(7)),
+				MediaType:       types.MediaTypeImage,
+				MediaURL:        "https://cdn.example.com/a.jpg",
+				Language:        "en",
+				Region:          "ID",
+				Latitude:        ptr(-6.2),
+				Longitude:       ptr(106.8),
+				IsCloseFriends:  true,
+				LikeCount:       8,
+				CommentCount:    9,
+				RepostsDisabled: true,
+				EmbedsDisabled:  true,
+				CreatedAt:       created,
+				UpdatedAt:       updated,
+			},
+			present: []string{"community_id", "organization_id", "page_id", "repost_of_id", "media_url", "language", "region", "latitude", "longitude"},
+			absent:  []string{"slug"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dto.NewPostResponse(tt.post)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("NewPostResponse = %+v, want %+v", got, tt.want)
+			}
+			if got == nil {
+				return
+			}
+			keys := fields(t, got)
+			for _, k := range tt.present {
+				if !keys[k] {
+					t.Errorf("JSON is missing %q", k)
+				}
+			}
+			for _, k := range tt.absent {
+				if keys[k] {
+					t.Errorf("JSON has %q, want it left out", k)
+				}
+			}
+		})
+	}
+}
+
+func TestNewCommentResponse(t *testing.T) {
+	hiddenAt := created.Add(time.Minute)
+	tests := []struct {
+		name    string
+		comment *model.Comment
+		want    *dto.CommentResponse
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "top level",
+			comment: &model.Comment{
+				BaseModel: model.BaseModel{ID: 1, CreatedAt: created, UpdatedAt: updated},
+				PostID:    2,
+				UserID:    3,
+				Content:   "Nice",
+			},
+			want: &dto.CommentResponse{ID: 1, PostID: 2, UserID: 3, Content: "Nice", ContentHTML: "<p>Nice</p>", CreatedAt: created, UpdatedAt: updated},
+		},
+		{
+			name: "hidden reply pending approval",
+			comment: &model.Comment{
+				BaseModel:       model.BaseModel{ID: 4},
+				PostID:          2,
+				UserID:          3,
+				ParentID:        ptr(int64(1)),
+				ReplyToUserID:   ptr(int64(5)),
+				Content:         "Agreed",
+				LikesCount:      6,
+				RepliesCount:    7,
+				PendingApproval: true,
+				HiddenAt:        &hiddenAt,
+			},
+			want: &dto.CommentResponse{
+				ID:              4,
+				PostID:          2,
+				UserID:          3,
+				ParentID:        ptr(int64(1)),
+				ReplyToUserID:   ptr(int64(5)),
+				Content:         "Agreed",
+				ContentHTML:     "<p>Agreed</p>",
+				LikesCount:      6,
+				RepliesCount:    7,
+				PendingApproval: true,
+				Hidden:          true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dto.NewCommentResponse(tt.comment); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewCommentResponse = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewUserResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   *model.User
+		want   *dto.UserResponse
+		absent []string
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "author without timestamps",
+			user: &model.User{BaseModel: model.BaseModel{ID: 1}, Username: "alice", FullName: "Alice"},
+			want: &dto.UserResponse{ID: 1, Username: "alice", FullName: "Alice"},
+			// An author loaded with a post has no bio or creation time
+			absent: []string{"bio", "created_at"},
+		},
+		{
+			name: "full",
+			user: &model.User{
+				BaseModel:  model.BaseModel{ID: 2, CreatedAt: created},
+				Username:   "bob",
+				FullName:   "Bob",
+				Bio:        "Hi",
+				AvatarURL:  "https://cdn.example.com/bob.png",
+				IsVerified: true,
+				IsPrivate:  true,
+				Email:      "bob@example.com",
+			},
+			want: &dto.UserResponse{ID: 2, Username: "bob", FullName: "Bob", Bio: "Hi", AvatarURL: "https://cdn.example.com/bob.png", IsVerified: true, IsPrivate: true, CreatedAt: created},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dto.NewUserResponse(tt.user)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("NewUserResponse = %+v, want %+v", got, tt.want)
+			}
+			if got == nil {
+				return
+			}
+			keys := fields(t, got)
+			for _, k := range tt.absent {
+				if keys[k] {
+					t.Errorf("JSON has %q, want it left out", k)
+				}
+			}
+			if keys["email"] {
+				t.Error("JSON has the email address")
+			}
+		})
+	}
+}
+
+func TestNewUserResponses(t *testing.T) {
+	got := dto.NewUserResponses([]*model.User{{Username: "alice"}, nil})
+	if len(got) != 2 || got[0].Username != "alice" || got[1] != nil {
+		t.Errorf("NewUserResponses = %+v, want alice and nil", got)
+	}
+	if got := dto.NewUserResponses(nil); got == nil || len(got) != 0 {
+		t.Errorf("NewUserResponses(nil) = %#v, want an empty slice", got)
+	}
+}
+
+func TestNewUserProfile(t *testing.T) {
+	user := &model.User{BaseModel: model.BaseModel{ID: 1, UpdatedAt: updated}, Username: "alice", FollowerCount: 2, FollwingCount: 3, PostCount: 4}
+	tests := []struct {
+		name        string
+		isFollowing bool
+	}{
+		{name: "not following"},
+		{name: "following", isFollowing: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dto.NewUserProfile(user, tt.isFollowing)
+			want := &dto.UserProfile{
+				UserResponse:   &dto.UserResponse{ID: 1, Username: "alice"},
+				FollowerCount:  2,
+				FollowingCount: 3,
+				PostCount:      4,
+				IsFollowing:    tt.isFollowing,
+				UpdatedAt:      updated,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("NewUserProfile = %+v, want %+v", got, want)
+			}
+			if keys := fields(t, got); keys["presence"] || keys["updated_at"] {
+				t.Errorf("JSON keys = %v, want presence and updated_at left out", keys)
+			}
+		})
+	}
+}
+
+func TestNewPageResponse(t *testing.T) {
+	if got := dto.NewPageResponse(nil); got != nil {
+		t.Errorf("NewPageResponse(nil) = %+v, want nil", got)
+	}
+	page := &model.Page{BaseModel: model.BaseModel{ID: 1}, Handle: "acme", Name: "Acme", AvatarURL: "https://cdn.example.com/acme.png", IsVerified: true}
+	want := &dto.PageResponse{ID: 1, Handle: "acme", Name: "Acme", AvatarURL: "https://cdn.example.com/acme.png", IsVerified: true}
+	if got := dto.NewPageResponse(page); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewPageResponse = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewPageManagers(t *testing.T) {
+	tests := []struct {
+		name     string
+		managers []*model.PageManager
+		want     []*dto.PageManager
+	}{
+		{
+			name: "none",
+			want: []*dto.PageManager{},
+		},
+		{
+			name: "deleted account left out",
+			managers: []*model.PageManager{
+				{BaseModel: model.BaseModel{CreatedAt: created}, Role: types.PageRoleAdmin, User: &model.User{BaseModel: model.BaseModel{ID: 1}, Username: "alice"}},
+				{Role: types.PageRoleEditor},
+			},
+			want: []*dto.PageManager{{User: &dto.UserResponse{ID: 1, Username: "alice"}, Role: types.PageRoleAdmin, AddedAt: created}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dto.NewPageManagers(tt.managers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewPageManagers = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewOrganizationMembers(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []*model.OrganizationMember
+		want    []*dto.OrganizationMember
+	}{
+		{
+			name: "none",
+			want: []*dto.OrganizationMember{},
+		},
+		{
+			name: "deleted account left out",
+			members: []*model.OrganizationMember{
+				{Role: types.OrganizationRoleMember},
+				{BaseModel: model.BaseModel{CreatedAt: created}, Role: types.OrganizationRoleOwner, User: &model.User{BaseModel: model.BaseModel{ID: 2}, Username: "bob"}},
+			},
+			want: []*dto.OrganizationMember{{User: &dto.UserResponse{ID: 2, Username: "bob"}, Role: types.OrganizationRoleOwner, JoinedAt: created}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dto.NewOrganizationMembers(tt.members); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewOrganizationMembers = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCommunityModeration(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []*model.CommunityFilterRule
+		want  *dto.CommunityModeration
+	}{
+		{
+			name: "no rules",
+			want: &dto.CommunityModeration{FilterRules: []string{}, AutoHideReports: 3},
+		},
+		{
+			name:  "rules",
+			rules: []*model.CommunityFilterRule{{Pattern: "spam"}, {Pattern: "buy now"}},
+			want:  &dto.CommunityModeration{FilterRules: []string{"spam", "buy now"}, AutoHideReports: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dto.NewCommunityModeration(&model.Community{AutoHideReports: 3}, tt.rules)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewCommunityModeration = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewOAuthAppResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		app  *model.OAuthApp
+		want *dto.OAuthAppResponse
+	}{
+		{
+			name: "public",
+			app:  &model.OAuthApp{BaseModel: model.BaseModel{ID: 1, CreatedAt: created}, Name: "App", ClientID: "abc", RedirectURIs: "app://callback", Scopes: "read"},
+			want: &dto.OAuthAppResponse{ID: 1, Name: "App", ClientID: "abc", RedirectURIs: []string{"app://callback"}, Scopes: "read", CreatedAt: created},
+		},
+		{
+			name: "confidential",
+			app:  &model.OAuthApp{Name: "Web", ClientID: "def", SecretHash: "hash", RedirectURIs: "https://a.example.com/cb\nhttps://b.example.com/cb", Scopes: "read write"},
+			want: &dto.OAuthAppResponse{Name: "Web", ClientID: "def", RedirectURIs: []string{"https://a.example.com/cb", "https://b.example.com/cb"}, Scopes: "read write", Confidential: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dto.NewOAuthAppResponse(tt.app)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewOAuthAppResponse = %+v, want %+v", got, tt.want)
+			}
+			if fields(t, got)["client_secret"] {
+				t.Error("JSON has client_secret, want it left out until registration sets it")
+			}
+		})
+	}
+}
+
+func TestNewShortLinkResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		link *model.ShortLink
+		want string
+	}{
+		{name: "post", link: &model.ShortLink{Code: "a", TargetType: types.ShortLinkTargetPost}, want: "post"},
+		{name: "profile", link: &model.ShortLink{Code: "b", TargetType: types.ShortLinkTargetProfile}, want: "profile"},
+		{name: "unknown", link: &model.ShortLink{Code: "c"}, want: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dto.NewShortLinkResponse(tt.link, "https://s.example.com/"+tt.link.Code)
+			if got.TargetType != tt.want || got.Code != tt.link.Code || got.URL != "https://s.example.com/"+tt.link.Code {
+				t.Errorf("NewShortLinkResponse = %+v, want target type %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAnnouncements(t *testing.T) {
+	if got := dto.NewAnnouncements(nil); got == nil || len(got) != 0 {
+		t.Errorf("NewAnnouncements(nil) = %#v, want an empty slice", got)
+	}
+	got := dto.NewAnnouncements([]*model.Announcement{{BaseModel: model.BaseModel{ID: 1}, Title: "Maintenance", Body: "Tonight", EndsAt: updated}})
+	want := []*dto.Announcement{{ID: 1, Title: "Maintenance", Body: "Tonight", EndsAt: updated}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewAnnouncements = %+v, want %+v", got, want)
+	}
+	if fields(t, got[0])["link_url"] {
+		t.Error("JSON has link_url, want it left out when empty")
+	}
+}
+
+func TestNewEmojiList(t *testing.T) {
+	builtin := dto.NewEmojiList(nil)
+	if len(builtin) == 0 {
+		t.Fatal("NewEmojiList(nil) is empty, want the built-in emoji")
+	}
+	for _, e := range builtin {
+		if e.Emoji == "" || e.URL != "" {
+			t.Errorf("built-in %+v, want an emoji and no URL", e)
+		}
+	}
+
+	got := dto.NewEmojiList([]*model.CustomEmoji{{Shortcode: "party_parrot", URL: "https://cdn.example.com/parrot.gif"}})
+	if len(got) != len(builtin)+1 {
+		t.Fatalf("NewEmojiList = %d emoji, want %d", len(got), len(builtin)+1)
+	}
+	if last := got[len(got)-1]; last.Shortcode != "party_parrot" || last.Emoji != "" || !strings.HasSuffix(last.URL, "parrot.gif") {
+		t.Errorf("custom emoji = %+v, want it last with its URL", last)
+	}
+}
+
+func TestNewPostSourceInsights(t *testing.T) {
+	tests := []struct {
+		name        string
+		stats       []*model.PostSourceDailyStat
+		impressions int64
+		want        []dto.PostSourceInsight
+	}{
+		{
+			name: "none",
+			want: []dto.PostSourceInsight{},
+		},
+		{
+			name: "summed per source, busiest first",
+			stats: []*model.PostSourceDailyStat{
+				{Source: "feed", Impressions: 10, LinkClicks: 1},
+				{Source: "", Impressions: 5},
+				{Source: "feed", Impressions: 20, LinkClicks: 2},
+				{Source: "search", Impressions: 5, LinkClicks: 1},
+			},
+			impressions: 40,
+			want: []dto.PostSourceInsight{
+				{Source: "feed", Impressions: 30, Share: 0.75, LinkClicks: 3},
+				{Source: "search", Impressions: 5, Share: 0.125, LinkClicks: 1},
+				{Source: "", Impressions: 5, Share: 0.125},
+			},
+		},
+		{
+			name:  "no impressions",
+			stats: []*model.PostSourceDailyStat{{Source: "profile", LinkClicks: 2}},
+			want:  []dto.PostSourceInsight{{Source: "profile", LinkClicks: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dto.NewPostSourceInsights(tt.stats, tt.impressions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewPostSourceInsights = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngagementRate(t *testing.T) {
+	tests := []struct {
+		engagements, impressions int64
+		want                     float64
+	}{
+		{0, 0, 0},
+		{5, 0, 0},
+		{0, 10, 0},
+		{5, 20, 0.25},
+	}
+	for _, tt := range tests {
+		if got := dto.EngagementRate(tt.engagements, tt.impressions); got != tt.want {
+			t.Errorf("EngagementRate(%d, %d) = %v, want %v", tt.engagements, tt.impressions, got, tt.want)
+		}
+	}
+}
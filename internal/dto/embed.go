@@ -0,0 +1,15 @@
+package dto
+
+// OEmbed is a rich oEmbed response for a post, see https://oembed.com
+type OEmbed struct {
+	Version      string `json:"version"`
+	Type         string `json:"type"`
+	ProviderName string `json:"provider_name,omitempty"`
+	ProviderURL  string `json:"provider_url,omitempty"`
+	AuthorName   string `json:"author_name"`
+	AuthorURL    string `json:"author_url"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       *int   `json:"height"` // Always null; the embed grows with its content
+	CacheAge     int    `json:"cache_age"`
+}
@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/richtext"
+)
+
+// Emoji is a shortcode content can use as :shortcode:, with either the
+// built-in emoji it stands for or the image of a custom one
+type Emoji struct {
+	Shortcode string `json:"shortcode"`
+	Emoji     string `json:"emoji,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// NewEmojiList lists the built-in emoji and then the custom ones, each by shortcode
+func NewEmojiList(custom []*model.CustomEmoji) []*Emoji {
+	builtin := richtext.BuiltinShortcodes()
+	out := make([]*Emoji, 0, len(builtin)+len(custom))
+	for _, code := range builtin {
+		out = append(out, &Emoji{Shortcode: code, Emoji: richtext.BuiltinEmoji[code]})
+	}
+	for _, e := range custom {
+		out = append(out, &Emoji{Shortcode: e.Shortcode, URL: e.URL})
+	}
+	return out
+}
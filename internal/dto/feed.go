@@ -1,12 +1,11 @@
 package dto
 
-import "github.com/ilhamosaurus/sns-platform/internal/model"
-
 type FeedPost struct {
-	*model.Post
-	Author       *model.User `json:"author"`
-	HasUserLiked bool        `json:"has_user_liked"`
-	HasUserSaved bool        `json:"has_user_saved"`
+	*PostResponse
+	Author       *UserResponse `json:"author"`
+	Page         *PageResponse `json:"page,omitempty"` // The page the post was published as
+	HasUserLiked bool          `json:"has_user_liked"`
+	HasUserSaved bool          `json:"has_user_saved"`
 }
 
 type PostDetail struct {
@@ -16,8 +15,11 @@ type PostDetail struct {
 }
 
 type CommentWithReplies struct {
-	*model.Comment
-	Author       *model.User           `json:"author"`
-	HasUserLiked bool                  `json:"has_user_liked"`
-	Replies      []*CommentWithReplies `json:"replies,omitempty"`
+	*CommentResponse
+	Author          *UserResponse         `json:"author"`
+	ReplyingTo      *UserResponse         `json:"replying_to,omitempty"` // Author of the parent comment, for "Replying to @username"
+	HasUserLiked    bool                  `json:"has_user_liked"`
+	ReactionSummary map[string]int64      `json:"reaction_summary"`
+	TopReactors     []*UserResponse       `json:"top_reactors"` // The latest to react, newest first
+	Replies         []*CommentWithReplies `json:"replies,omitempty"`
 }
@@ -0,0 +1,12 @@
+package dto
+
+import "github.com/ilhamosaurus/sns-platform/internal/model"
+
+// InviteTrace places a user in the invite graph, for admins tracing abuse
+// back to whoever let it in
+type InviteTrace struct {
+	User     *UserResponse   `json:"user"`
+	Inviters []*UserResponse `json:"inviters"` // The user's inviter, their inviter, and so on up the chain
+	Invitees []*UserResponse `json:"invitees"` // Accounts that signed up with the user's invites
+	Invites  []*model.Invite `json:"invites"`  // The user's invites, newest first
+}
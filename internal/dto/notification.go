@@ -0,0 +1,10 @@
+package dto
+
+// QuietHours is a user's daily do-not-disturb window. Start and End are
+// "15:04" in TimeZone; a window whose end is before its start runs past midnight.
+type QuietHours struct {
+	Enabled  bool   `json:"enabled"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	TimeZone string `json:"time_zone"`
+}
@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+)
+
+// OAuthAppRequest registers a third-party app
+type OAuthAppRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Confidential bool     `json:"confidential"` // Issue a client secret; leave off for mobile and browser apps
+}
+
+// OAuthAppResponse is an app as its owner sees it
+type OAuthAppResponse struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret,omitempty"` // Only returned at registration
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       string    `json:"scopes"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func NewOAuthAppResponse(app *model.OAuthApp) *OAuthAppResponse {
+	return &OAuthAppResponse{
+		ID:           app.ID,
+		Name:         app.Name,
+		ClientID:     app.ClientID,
+		RedirectURIs: app.RedirectURIList(),
+		Scopes:       app.Scopes,
+		Confidential: app.Confidential(),
+		CreatedAt:    app.CreatedAt,
+	}
+}
+
+// OAuthConsent describes an authorization request for the consent screen
+type OAuthConsent struct {
+	ClientID    string   `json:"client_id"`
+	AppName     string   `json:"app_name"`
+	RedirectURI string   `json:"redirect_uri"`
+	Scopes      []string `json:"scopes"`
+}
+
+// OAuthTokenResponse is a successful token endpoint response
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Seconds
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// AuthorizedApp is an app the user has granted access to
+type AuthorizedApp struct {
+	AppID        int64     `json:"app_id"`
+	Name         string    `json:"name"`
+	Scopes       string    `json:"scopes"`
+	AuthorizedAt time.Time `json:"authorized_at"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+import "github.com/ilhamosaurus/sns-platform/pkg/types"
+
+// Onboarding is a user's progress through the checklist shown to new users
+type Onboarding struct {
+	Steps        []OnboardingStep `json:"steps"`
+	Completeness int              `json:"completeness"` // Percent of the steps done
+	Done         bool             `json:"done"`         // Every step done or the checklist dismissed; clients stop showing it
+}
+
+type OnboardingStep struct {
+	Step types.OnboardingStep `json:"step"`
+	Done bool                 `json:"done"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// OrganizationMember is a member of an organization as listed to the other members
+type OrganizationMember struct {
+	User     *UserResponse          `json:"user"`
+	Role     types.OrganizationRole `json:"role"`
+	JoinedAt time.Time              `json:"joined_at"`
+}
+
+// NewOrganizationMembers maps memberships with their users loaded; members
+// whose account is gone are left out
+func NewOrganizationMembers(members []*model.OrganizationMember) []*OrganizationMember {
+	out := make([]*OrganizationMember, 0, len(members))
+	for _, member := range members {
+		if member.User == nil {
+			continue
+		}
+		out = append(out, &OrganizationMember{
+			User:     NewUserResponse(member.User),
+			Role:     member.Role,
+			JoinedAt: member.CreatedAt,
+		})
+	}
+	return out
+}
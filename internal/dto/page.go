@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// PageResponse is the view of a page shown next to its posts
+type PageResponse struct {
+	ID         int64  `json:"id"`
+	Handle     string `json:"handle"`
+	Name       string `json:"name"`
+	AvatarURL  string `json:"avatar_url"`
+	IsVerified bool   `json:"is_verified"`
+}
+
+// NewPageResponse maps a page to its view next to posts; nil maps to nil
+func NewPageResponse(page *model.Page) *PageResponse {
+	if page == nil {
+		return nil
+	}
+	return &PageResponse{
+		ID:         page.ID,
+		Handle:     page.Handle,
+		Name:       page.Name,
+		AvatarURL:  page.AvatarURL,
+		IsVerified: page.IsVerified,
+	}
+}
+
+// PageProfile is a page as shown on its own profile
+type PageProfile struct {
+	*model.Page
+	IsFollowing bool           `json:"is_following"`
+	Role        types.PageRole `json:"role,omitzero"` // The viewer's role when they manage the page
+}
+
+// PageManager is a manager of a page as listed to the other managers
+type PageManager struct {
+	User    *UserResponse  `json:"user"`
+	Role    types.PageRole `json:"role"`
+	AddedAt time.Time      `json:"added_at"`
+}
+
+// NewPageManagers maps managers with their users loaded; managers whose
+// account is gone are left out
+func NewPageManagers(managers []*model.PageManager) []*PageManager {
+	out := make([]*PageManager, 0, len(managers))
+	for _, manager := range managers {
+		if manager.User == nil {
+			continue
+		}
+		out = append(out, &PageManager{
+			User:    NewUserResponse(manager.User),
+			Role:    manager.Role,
+			AddedAt: manager.CreatedAt,
+		})
+	}
+	return out
+}
+
+// PageInsights sums the performance of a page's posts over a range of days
+type PageInsights struct {
+	PageID         int64            `json:"page_id"`
+	Since          string           `json:"since"` // First day, inclusive
+	Until          string           `json:"until"` // Last day, inclusive
+	FollowerCount  int64            `json:"follower_count"`
+	PostCount      int64            `json:"post_count"`
+	Impressions    int64            `json:"impressions"`
+	Reach          int64            `json:"reach"` // Summed per post, so an account reached by two posts counts twice
+	Likes          int64            `json:"likes"`
+	Comments       int64            `json:"comments"`
+	LinkClicks     int64            `json:"link_clicks"`
+	EngagementRate float64          `json:"engagement_rate"` // (likes + comments) / impressions
+	Daily          []PostInsightDay `json:"daily"`
+}
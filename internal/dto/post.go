@@ -0,0 +1,113 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/richtext"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// PostResponse is the client view of a post, without its relations
+type PostResponse struct {
+	ID              int64               `json:"id"`
+	UserID          int64               `json:"user_id"`
+	CommunityID     *int64              `json:"community_id,omitempty"`
+	OrganizationID  *int64              `json:"organization_id,omitempty"`
+	PageID          *int64              `json:"page_id,omitempty"`
+	RepostOfID      *int64              `json:"repost_of_id,omitempty"`
+	Content         string              `json:"content"`
+	ContentHTML     string              `json:"content_html"`   // Content sanitized and rendered for display
+	Slug            string              `json:"slug,omitempty"` // Pretty URL segment, /posts/{id}/{slug}
+	MediaType       types.MediaType     `json:"media_type"`
+	MediaURL        string              `json:"media_url,omitempty"`
+	Language        string              `json:"language,omitempty"` // ISO 639-1; omitted when undetermined
+	Region          string              `json:"region,omitempty"`   // ISO 3166-1 alpha-2; omitted when unknown
+	Latitude        *float64            `json:"latitude,omitempty"`
+	Longitude       *float64            `json:"longitude,omitempty"`
+	IsPublic        bool                `json:"is_public"`
+	IsCloseFriends  bool                `json:"is_close_friends"`
+	ViewCount       int64               `json:"view_count"`
+	ShareCount      int64               `json:"share_count"`
+	LikeCount       int64               `json:"like_count"`
+	CommentCount    int64               `json:"comment_count"`
+	CommentPolicy   types.CommentPolicy `json:"comment_policy"`
+	RepostsDisabled bool                `json:"reposts_disabled"` // Clients hide the repost button for everyone but the author
+	EmbedsDisabled  bool                `json:"embeds_disabled"`  // Clients hide the embed option
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
+
+// CommentResponse is the client view of a comment, without its relations
+type CommentResponse struct {
+	ID              int64     `json:"id"`
+	PostID          int64     `json:"post_id"`
+	UserID          int64     `json:"user_id"`
+	ParentID        *int64    `json:"parent_id"`
+	ReplyToUserID   *int64    `json:"reply_to_user_id,omitempty"`
+	Content         string    `json:"content"`
+	ContentHTML     string    `json:"content_html"` // Content sanitized and rendered for display
+	LikesCount      int64     `json:"likes_count"`
+	RepliesCount    int64     `json:"replies_count"`
+	PendingApproval bool      `json:"pending_approval,omitempty"` // Shown only to its author and the post author
+	Hidden          bool      `json:"hidden,omitempty"`           // Hidden over reports; shown only to its author
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// NewPostResponse maps a post to its client view; nil maps to nil
+func NewPostResponse(post *model.Post) *PostResponse {
+	if post == nil {
+		return nil
+	}
+	return &PostResponse{
+		ID:              post.ID,
+		UserID:          post.UserID,
+		CommunityID:     post.CommunityID,
+		OrganizationID:  post.OrganizationID,
+		PageID:          post.PageID,
+		RepostOfID:      post.RepostOfID,
+		Content:         post.Content,
+		ContentHTML:     richtext.Render(post.Content),
+		Slug:            post.Slug(),
+		MediaType:       post.MediaType,
+		MediaURL:        post.MediaURL,
+		Language:        post.Language,
+		Region:          post.Region,
+		Latitude:        post.Latitude,
+		Longitude:       post.Longitude,
+		IsPublic:        post.IsPublic,
+		IsCloseFriends:  post.CloseFriends,
+		ViewCount:       post.ViewCount,
+		ShareCount:      post.ShareCount,
+		LikeCount:       post.LikeCount,
+		CommentCount:    post.CommentCount,
+		CommentPolicy:   post.CommentPolicy,
+		RepostsDisabled: post.RepostsDisabled,
+		EmbedsDisabled:  post.EmbedsDisabled,
+		CreatedAt:       post.CreatedAt,
+		UpdatedAt:       post.UpdatedAt,
+	}
+}
+
+// NewCommentResponse maps a comment to its client view; nil maps to nil
+func NewCommentResponse(comment *model.Comment) *CommentResponse {
+	if comment == nil {
+		return nil
+	}
+	return &CommentResponse{
+		ID:              comment.ID,
+		PostID:          comment.PostID,
+		UserID:          comment.UserID,
+		ParentID:        comment.ParentID,
+		ReplyToUserID:   comment.ReplyToUserID,
+		Content:         comment.Content,
+		ContentHTML:     richtext.Render(comment.Content),
+		LikesCount:      comment.LikesCount,
+		RepliesCount:    comment.RepliesCount,
+		PendingApproval: comment.PendingApproval,
+		Hidden:          comment.HiddenAt != nil,
+		CreatedAt:       comment.CreatedAt,
+		UpdatedAt:       comment.UpdatedAt,
+	}
+}
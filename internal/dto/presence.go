@@ -0,0 +1,9 @@
+package dto
+
+import "time"
+
+type Presence struct {
+	UserID   int64      `json:"user_id"`
+	Online   bool       `json:"online"`
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// ProfileVisitor is an account that viewed the caller's profile
+type ProfileVisitor struct {
+	User        *UserResponse `json:"user"`
+	LastVisitOn string        `json:"last_visit_on"` // UTC day of the most recent visit
+	VisitDays   int64         `json:"visit_days"`    // Days with a visit in the requested range
+}
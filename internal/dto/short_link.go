@@ -0,0 +1,22 @@
+package dto
+
+import "github.com/ilhamosaurus/sns-platform/internal/model"
+
+// ShortLinkResponse is a share link made by the caller
+type ShortLinkResponse struct {
+	Code       string `json:"code"`
+	URL        string `json:"url"`
+	TargetType string `json:"target_type"`
+	TargetID   int64  `json:"target_id"`
+	ClickCount int64  `json:"click_count"`
+}
+
+func NewShortLinkResponse(link *model.ShortLink, url string) *ShortLinkResponse {
+	return &ShortLinkResponse{
+		Code:       link.Code,
+		URL:        url,
+		TargetType: link.TargetType.String(),
+		TargetID:   link.TargetID,
+		ClickCount: link.ClickCount,
+	}
+}
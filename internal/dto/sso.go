@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// SSOProvider is an identity provider users can sign in with
+type SSOProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Protocol    string `json:"protocol"` // oidc or saml
+}
+
+// SSOLogin is a completed single sign-on. Ticket is a short-lived, signed
+// path the session layer POSTs to, once, to learn who signed in.
+type SSOLogin struct {
+	User        *UserResponse `json:"user"`
+	Provisioned bool          `json:"provisioned"`
+	Ticket      string        `json:"ticket"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+// Translation is a post or comment translated into the viewer's language
+type Translation struct {
+	SourceLanguage string `json:"source_language,omitempty"` // Empty when undetermined
+	TargetLanguage string `json:"target_language"`
+	Text           string `json:"text"`
+	Provider       string `json:"provider"` // none when the text is returned untranslated
+}
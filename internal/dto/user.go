@@ -1,11 +1,74 @@
 package dto
 
-import "github.com/ilhamosaurus/sns-platform/internal/model"
+import (
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+)
+
+// UserResponse is the public view of a user shown to other users
+type UserResponse struct {
+	ID         int64     `json:"id"`
+	Username   string    `json:"username"`
+	FullName   string    `json:"full_name"`
+	Bio        string    `json:"bio,omitempty"`
+	AvatarURL  string    `json:"avatar_url"`
+	IsVerified bool      `json:"is_verified"`
+	IsPrivate  bool      `json:"is_private"`
+	CreatedAt  time.Time `json:"created_at,omitzero"` // Not loaded for post and comment authors
+}
 
 type UserProfile struct {
-	model.User
-	FollowerCount  int64 `json:"follower_count"`
-	FollowingCount int64 `json:"following_count"`
-	PostCount      int64 `json:"post_count"`
-	IsFollowing    bool  `json:"is_following"`
+	*UserResponse
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	PostCount      int64     `json:"post_count"`
+	IsFollowing    bool      `json:"is_following"`
+	Presence       *Presence `json:"presence,omitempty"`
+	UpdatedAt      time.Time `json:"-"` // Serves as Last-Modified; counter changes bump it too
+}
+
+// NewUserResponse maps a user to its public view; nil maps to nil
+func NewUserResponse(user *model.User) *UserResponse {
+	if user == nil {
+		return nil
+	}
+	return &UserResponse{
+		ID:         user.ID,
+		Username:   user.Username,
+		FullName:   user.FullName,
+		Bio:        user.Bio,
+		AvatarURL:  user.AvatarURL,
+		IsVerified: user.IsVerified,
+		IsPrivate:  user.IsPrivate,
+		CreatedAt:  user.CreatedAt,
+	}
+}
+
+func NewUserResponses(users []*model.User) []*UserResponse {
+	out := make([]*UserResponse, len(users))
+	for i, user := range users {
+		out[i] = NewUserResponse(user)
+	}
+	return out
+}
+
+// NewUserProfile builds the profile of user as seen by a viewer who does or
+// does not follow them
+func NewUserProfile(user *model.User, isFollowing bool) *UserProfile {
+	return &UserProfile{
+		UserResponse:   NewUserResponse(user),
+		FollowerCount:  user.FollowerCount,
+		FollowingCount: user.FollwingCount,
+		PostCount:      user.PostCount,
+		IsFollowing:    isFollowing,
+		UpdatedAt:      user.UpdatedAt,
+	}
+}
+
+// Discoverability is what a user can be found by in others' address books
+type Discoverability struct {
+	DiscoverableByEmail bool   `json:"discoverable_by_email"`
+	DiscoverableByPhone bool   `json:"discoverable_by_phone"`
+	Phone               string `json:"phone"` // E.164; empty unless a number was verified
 }
@@ -0,0 +1,16 @@
+package dto
+
+import "time"
+
+// WaitlistPosition tells someone on the waitlist where they stand
+type WaitlistPosition struct {
+	Email       string     `json:"email"`
+	Position    int64      `json:"position,omitempty"` // 1 is next in line; zero once activated
+	ActivatedAt *time.Time `json:"activated_at,omitempty"`
+}
+
+// WaitlistStats counts the waitlist's entries by state
+type WaitlistStats struct {
+	Waiting   int64 `json:"waiting"`
+	Activated int64 `json:"activated"`
+}
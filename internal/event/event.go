@@ -0,0 +1,221 @@
+package event
+
+import (
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+const (
+	NamePostCreated     = "post.created"
+	NameUserFollowed    = "user.followed"
+	NameUserUnfollowed  = "user.unfollowed"
+	NameCommentAdded    = "comment.added"
+	NameReactionAdded   = "reaction.added"
+	NameMessageSent     = "message.sent"
+	NamePresenceChanged = "presence.changed"
+	NameNewDeviceLogin  = "security.new_device_login"
+	NameUserLoggedIn    = "security.login"
+
+	NameWaitlistActivated = "waitlist.activated"
+
+	NameVelocityBreached = "abuse.velocity_breached"
+
+	NameEngagementReported = "analytics.engagement_reported"
+
+	NameCommunityJoinRequested   = "community.join_requested"
+	NameCommunityMemberJoined    = "community.member_joined"
+	NameCommunityMemberLeft      = "community.member_left"
+	NameCommunityMemberBanned    = "community.member_banned"
+	NameCommunityPostRemoved     = "community.post_removed"
+	NameCommunityCommentHidden   = "community.comment_hidden"
+	NameCommunityCommentRestored = "community.comment_restored"
+)
+
+type PostCreated struct {
+	PostID         int64     `json:"post_id"`
+	AuthorID       int64     `json:"author_id"`
+	CommunityID    *int64    `json:"community_id,omitempty"`
+	OrganizationID *int64    `json:"organization_id,omitempty"`
+	PageID         *int64    `json:"page_id,omitempty"`
+	IsPublic       bool      `json:"is_public"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (PostCreated) EventName() string { return NamePostCreated }
+
+type UserFollowed struct {
+	FollowerID  int64 `json:"follower_id"`
+	FollowingID int64 `json:"following_id"`
+}
+
+func (UserFollowed) EventName() string { return NameUserFollowed }
+
+type UserUnfollowed struct {
+	FollowerID  int64 `json:"follower_id"`
+	FollowingID int64 `json:"following_id"`
+}
+
+func (UserUnfollowed) EventName() string { return NameUserUnfollowed }
+
+type CommentAdded struct {
+	CommentID    int64  `json:"comment_id"`
+	PostID       int64  `json:"post_id"`
+	PostAuthorID int64  `json:"post_author_id"`
+	UserID       int64  `json:"user_id"`
+	ParentID     *int64 `json:"parent_id,omitempty"`
+}
+
+func (CommentAdded) EventName() string { return NameCommentAdded }
+
+type ReactionAdded struct {
+	ReactionID int64              `json:"reaction_id"`
+	UserID     int64              `json:"user_id"`
+	PostID     *int64             `json:"post_id,omitempty"`
+	CommentID  *int64             `json:"comment_id,omitempty"`
+	Type       types.ReactionType `json:"type"`
+}
+
+func (ReactionAdded) EventName() string { return NameReactionAdded }
+
+type MessageSent struct {
+	MessageID  int64 `json:"message_id"`
+	SenderID   int64 `json:"sender_id"`
+	ReceiverID int64 `json:"receiver_id"`
+}
+
+func (MessageSent) EventName() string { return NameMessageSent }
+
+type PresenceChanged struct {
+	UserID   int64     `json:"user_id"`
+	Online   bool      `json:"online"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+func (PresenceChanged) EventName() string { return NamePresenceChanged }
+
+type NewDeviceLogin struct {
+	SecurityEventID int64     `json:"security_event_id"`
+	UserID          int64     `json:"user_id"`
+	IP              string    `json:"ip"`
+	Device          string    `json:"device"`
+	Location        string    `json:"location,omitempty"`
+	OccurredAt      time.Time `json:"occurred_at"`
+}
+
+func (NewDeviceLogin) EventName() string { return NameNewDeviceLogin }
+
+type UserLoggedIn struct {
+	UserID     int64     `json:"user_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func (UserLoggedIn) EventName() string { return NameUserLoggedIn }
+
+// WaitlistActivated is published for each waitlisted email whose turn came;
+// the address may now create its account
+type WaitlistActivated struct {
+	EntryID     int64     `json:"entry_id"`
+	Email       string    `json:"email"`
+	ActivatedAt time.Time `json:"activated_at"`
+}
+
+func (WaitlistActivated) EventName() string { return NameWaitlistActivated }
+
+// VelocityBreached is published when a user goes over the velocity threshold
+// of Metric, once per crossing
+type VelocityBreached struct {
+	UserID     int64         `json:"user_id"`
+	Metric     string        `json:"metric"`
+	Count      int64         `json:"count"`
+	Threshold  int64         `json:"threshold"`
+	Window     time.Duration `json:"window"`
+	OccurredAt time.Time     `json:"occurred_at"`
+}
+
+func (VelocityBreached) EventName() string { return NameVelocityBreached }
+
+type CommunityJoinRequested struct {
+	CommunityID int64 `json:"community_id"`
+	UserID      int64 `json:"user_id"`
+}
+
+func (CommunityJoinRequested) EventName() string { return NameCommunityJoinRequested }
+
+type CommunityMemberJoined struct {
+	CommunityID int64 `json:"community_id"`
+	UserID      int64 `json:"user_id"`
+	ApprovedBy  int64 `json:"approved_by,omitempty"`
+}
+
+func (CommunityMemberJoined) EventName() string { return NameCommunityMemberJoined }
+
+type CommunityMemberLeft struct {
+	CommunityID int64 `json:"community_id"`
+	UserID      int64 `json:"user_id"`
+}
+
+func (CommunityMemberLeft) EventName() string { return NameCommunityMemberLeft }
+
+type CommunityMemberBanned struct {
+	CommunityID int64 `json:"community_id"`
+	UserID      int64 `json:"user_id"`
+	ModeratorID int64 `json:"moderator_id"`
+}
+
+func (CommunityMemberBanned) EventName() string { return NameCommunityMemberBanned }
+
+type CommunityPostRemoved struct {
+	CommunityID int64  `json:"community_id"`
+	PostID      int64  `json:"post_id"`
+	AuthorID    int64  `json:"author_id"`
+	ModeratorID int64  `json:"moderator_id"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+func (CommunityPostRemoved) EventName() string { return NameCommunityPostRemoved }
+
+// CommunityCommentHidden is published when reports hide a comment
+type CommunityCommentHidden struct {
+	CommunityID int64 `json:"community_id"`
+	CommentID   int64 `json:"comment_id"`
+	AuthorID    int64 `json:"author_id"`
+	Reports     int64 `json:"reports"`
+}
+
+func (CommunityCommentHidden) EventName() string { return NameCommunityCommentHidden }
+
+type CommunityCommentRestored struct {
+	CommunityID int64 `json:"community_id"`
+	CommentID   int64 `json:"comment_id"`
+	AuthorID    int64 `json:"author_id"`
+	ModeratorID int64 `json:"moderator_id"`
+}
+
+func (CommunityCommentRestored) EventName() string { return NameCommunityCommentRestored }
+
+// Engagement kinds a client can report
+const (
+	EngagementImpression  = "impression"   // PostID was shown to the viewer
+	EngagementProfileView = "profile_view" // The viewer opened UserID's profile
+	EngagementLinkClick   = "link_click"   // The viewer followed URL in PostID
+)
+
+// Engagement is one client-side interaction with a post or profile
+type Engagement struct {
+	Type   string `json:"type"`
+	PostID int64  `json:"post_id,omitempty"`
+	UserID int64  `json:"user_id,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Source string `json:"source,omitempty"` // Where an impression or link click happened, one of model.ViewSources
+}
+
+// EngagementReported carries a batch of engagements reported by one viewer;
+// TenantID is set when the report was made within a tenant
+type EngagementReported struct {
+	TenantID    *int64       `json:"tenant_id,omitempty"`
+	ViewerID    int64        `json:"viewer_id"`
+	Engagements []Engagement `json:"engagements"`
+}
+
+func (EngagementReported) EventName() string { return NameEngagementReported }
@@ -0,0 +1,328 @@
+package hydrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+// authorColumns are the user fields exposed next to posts and comments
+var authorColumns = []string{"id", "username", "full_name", "avatar_url", "is_verified"}
+
+// pageColumns are the page fields exposed next to page posts
+var pageColumns = []string{"id", "handle", "name", "avatar_url", "is_verified"}
+
+// TopReactors is how many of the latest reactors each comment lists
+const TopReactors = 3
+
+// Hydrator turns plain posts and comments into DTOs. Each relation is loaded
+// with a single WHERE id IN (...) query for the whole page and joined in
+// memory, so list queries stay simple and each loader can be cached or reused
+// on its own, e.g. by a GraphQL resolver.
+type Hydrator struct {
+	db *gorm.DB
+}
+
+func NewHydrator(db *gorm.DB) *Hydrator {
+	return &Hydrator{db: db}
+}
+
+// Posts builds feed DTOs in the order given; posts whose author or page no
+// longer exists are left out. A zero viewerID skips the viewer-specific flags.
+func (h *Hydrator) Posts(ctx context.Context, posts []*model.Post, viewerID int64) ([]*dto.FeedPost, error) {
+	if len(posts) == 0 {
+		return []*dto.FeedPost{}, nil
+	}
+
+	postIDs := make([]int64, len(posts))
+	authorIDs := make([]int64, len(posts))
+	var pageIDs []int64
+	for i, post := range posts {
+		postIDs[i] = post.ID
+		authorIDs[i] = post.UserID
+		if post.PageID != nil {
+			pageIDs = append(pageIDs, *post.PageID)
+		}
+	}
+
+	authors, err := h.Users(ctx, authorIDs)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := h.Pages(ctx, pageIDs)
+	if err != nil {
+		return nil, err
+	}
+	liked, err := h.LikedPosts(ctx, viewerID, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := h.SavedPosts(ctx, viewerID, postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	feedPosts := make([]*dto.FeedPost, 0, len(posts))
+	for _, post := range posts {
+		author, ok := authors[post.UserID]
+		if !ok {
+			continue
+		}
+		feedPost := &dto.FeedPost{
+			PostResponse: dto.NewPostResponse(post),
+			Author:       dto.NewUserResponse(author),
+			HasUserLiked: liked[post.ID],
+			HasUserSaved: saved[post.ID],
+		}
+		if post.PageID != nil {
+			if feedPost.Page = dto.NewPageResponse(pages[*post.PageID]); feedPost.Page == nil {
+				continue
+			}
+		}
+		feedPosts = append(feedPosts, feedPost)
+	}
+	return feedPosts, nil
+}
+
+// CommentTree builds the comment threads of a post from its flat comment
+// list, keeping its order at every level, with each comment's reaction
+// counts and latest reactors; replies name the author they answer. Replies
+// whose parent is missing or whose author no longer exists are left out.
+func (h *Hydrator) CommentTree(ctx context.Context, comments []*model.Comment, viewerID int64) ([]*dto.CommentWithReplies, error) {
+	if len(comments) == 0 {
+		return []*dto.CommentWithReplies{}, nil
+	}
+
+	commentIDs := make([]int64, len(comments))
+	authorIDs := make([]int64, len(comments))
+	for i, comment := range comments {
+		commentIDs[i] = comment.ID
+		authorIDs[i] = comment.UserID
+	}
+
+	summaries, err := h.CommentReactionSummaries(ctx, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+	reactors, err := h.CommentReactors(ctx, commentIDs, TopReactors)
+	if err != nil {
+		return nil, err
+	}
+	// Authors, reply targets and reactors share one profile query
+	userIDs := authorIDs
+	for _, comment := range comments {
+		if comment.ReplyToUserID != nil {
+			userIDs = append(userIDs, *comment.ReplyToUserID)
+		}
+	}
+	for _, ids := range reactors {
+		userIDs = append(userIDs, ids...)
+	}
+	users, err := h.Users(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	liked, err := h.LikedComments(ctx, viewerID, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[int64]*dto.CommentWithReplies, len(comments))
+	for _, comment := range comments {
+		author, ok := users[comment.UserID]
+		if !ok {
+			continue
+		}
+		node := &dto.CommentWithReplies{
+			CommentResponse: dto.NewCommentResponse(comment),
+			Author:          dto.NewUserResponse(author),
+			HasUserLiked:    liked[comment.ID],
+			ReactionSummary: summaries[comment.ID],
+			TopReactors:     []*dto.UserResponse{},
+		}
+		if node.ReactionSummary == nil {
+			node.ReactionSummary = map[string]int64{}
+		}
+		if comment.ReplyToUserID != nil {
+			if target, ok := users[*comment.ReplyToUserID]; ok {
+				node.ReplyingTo = dto.NewUserResponse(target)
+			}
+		}
+		for _, id := range reactors[comment.ID] {
+			if reactor, ok := users[id]; ok {
+				node.TopReactors = append(node.TopReactors, dto.NewUserResponse(reactor))
+			}
+		}
+		nodes[comment.ID] = node
+	}
+
+	roots := make([]*dto.CommentWithReplies, 0, len(nodes))
+	for _, comment := range comments {
+		node, ok := nodes[comment.ID]
+		if !ok {
+			continue
+		}
+		if comment.ParentID == nil {
+			roots = append(roots, node)
+		} else if parent, ok := nodes[*comment.ParentID]; ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+	return roots, nil
+}
+
+// Users loads the public profile fields of the given users, keyed by ID
+func (h *Hydrator) Users(ctx context.Context, ids []int64) (map[int64]*model.User, error) {
+	ids = unique(ids)
+	users := make(map[int64]*model.User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	var rows []*model.User
+	err := h.db.WithContext(ctx).
+		Select(authorColumns).
+		Where("id IN ? AND deleted_at IS NULL", ids).
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load users: %w", err)
+	}
+	for _, user := range rows {
+		users[user.ID] = user
+	}
+	return users, nil
+}
+
+// Pages loads the fields shown next to page posts, keyed by ID
+func (h *Hydrator) Pages(ctx context.Context, ids []int64) (map[int64]*model.Page, error) {
+	ids = unique(ids)
+	pages := make(map[int64]*model.Page, len(ids))
+	if len(ids) == 0 {
+		return pages, nil
+	}
+
+	var rows []*model.Page
+	err := h.db.WithContext(ctx).
+		Select(pageColumns).
+		Where("id IN ? AND deleted_at IS NULL", ids).
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pages: %w", err)
+	}
+	for _, page := range rows {
+		pages[page.ID] = page
+	}
+	return pages, nil
+}
+
+// CommentReactionSummaries counts the reactions on each comment by type, in
+// one grouped query over all the comments
+func (h *Hydrator) CommentReactionSummaries(ctx context.Context, commentIDs []int64) (map[int64]map[string]int64, error) {
+	summaries := make(map[int64]map[string]int64)
+	if len(commentIDs) == 0 {
+		return summaries, nil
+	}
+
+	var rows []struct {
+		CommentID int64
+		Type      types.ReactionType
+		Count     int64
+	}
+	err := h.db.WithContext(ctx).Model(&model.Reaction{}).
+		Select("comment_id, type, COUNT(*) AS count").
+		Where("comment_id IN ? AND deleted_at IS NULL", unique(commentIDs)).
+		Group("comment_id, type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load comment reactions: %w", err)
+	}
+	for _, row := range rows {
+		if summaries[row.CommentID] == nil {
+			summaries[row.CommentID] = make(map[string]int64)
+		}
+		summaries[row.CommentID][row.Type.String()] = row.Count
+	}
+	return summaries, nil
+}
+
+// CommentReactors returns the IDs of up to limit of the latest users to react
+// to each comment, newest first, in one query over all the comments
+func (h *Hydrator) CommentReactors(ctx context.Context, commentIDs []int64, limit int) (map[int64][]int64, error) {
+	reactors := make(map[int64][]int64)
+	if len(commentIDs) == 0 || limit <= 0 {
+		return reactors, nil
+	}
+
+	ranked := h.db.Model(&model.Reaction{}).
+		Select("comment_id, user_id, ROW_NUMBER() OVER (PARTITION BY comment_id ORDER BY created_at DESC, id DESC) AS nth").
+		Where("comment_id IN ? AND deleted_at IS NULL", unique(commentIDs))
+	var rows []struct {
+		CommentID int64
+		UserID    int64
+	}
+	err := h.db.WithContext(ctx).Table("(?) AS ranked", ranked).
+		Select("comment_id, user_id").
+		Where("nth <= ?", limit).
+		Order("comment_id, nth").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load comment reactors: %w", err)
+	}
+	for _, row := range rows {
+		reactors[row.CommentID] = append(reactors[row.CommentID], row.UserID)
+	}
+	return reactors, nil
+}
+
+// LikedPosts reports which of the posts viewerID has liked
+func (h *Hydrator) LikedPosts(ctx context.Context, viewerID int64, postIDs []int64) (map[int64]bool, error) {
+	return h.idSet(ctx, viewerID, postIDs, "post_id",
+		h.db.Model(&model.Reaction{}).Where("type = ?", types.ReactionTypeLike))
+}
+
+// LikedComments reports which of the comments viewerID has liked
+func (h *Hydrator) LikedComments(ctx context.Context, viewerID int64, commentIDs []int64) (map[int64]bool, error) {
+	return h.idSet(ctx, viewerID, commentIDs, "comment_id",
+		h.db.Model(&model.Reaction{}).Where("type = ?", types.ReactionTypeLike))
+}
+
+// SavedPosts reports which of the posts viewerID has bookmarked
+func (h *Hydrator) SavedPosts(ctx context.Context, viewerID int64, postIDs []int64) (map[int64]bool, error) {
+	return h.idSet(ctx, viewerID, postIDs, "post_id", h.db.Model(&model.Bookmark{}))
+}
+
+// idSet returns the subset of ids found in column for viewerID's rows of query
+func (h *Hydrator) idSet(ctx context.Context, viewerID int64, ids []int64, column string, query *gorm.DB) (map[int64]bool, error) {
+	set := make(map[int64]bool)
+	if viewerID == 0 || len(ids) == 0 {
+		return set, nil
+	}
+
+	var found []int64
+	err := query.WithContext(ctx).
+		Where("user_id = ? AND "+column+" IN ? AND deleted_at IS NULL", viewerID, unique(ids)).
+		Pluck(column, &found).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load viewer %s flags: %w", column, err)
+	}
+	for _, id := range found {
+		set[id] = true
+	}
+	return set, nil
+}
+
+func unique(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	out := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			out = append(out, id)
+		}
+	}
+	return out
+}
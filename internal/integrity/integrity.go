@@ -0,0 +1,176 @@
+// Package integrity finds rows that contradict the rows they depend on in
+// ways no foreign key catches: soft deletes leave references to the deleted
+// rows in place, fanned-out feed entries outlive the follow that put them
+// there, and counters kept by increments can go negative. Run reports each
+// kind of problem and can repair it.
+package integrity
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ilhamosaurus/sns-platform/internal/counter"
+	"gorm.io/gorm"
+)
+
+// Check is one kind of problem: the rows of Table matching Where, repaired
+// by the assignments in Fix
+type Check struct {
+	Name  string
+	Table string
+	Where string
+	Fix   string
+}
+
+// softDelete removes a row the way the application would
+const softDelete = "deleted_at = CURRENT_TIMESTAMP"
+
+// Checks lists every problem Run looks for; rows are removed before the
+// counters counting them are recomputed
+var Checks = append([]Check{
+	{
+		Name:  "reactions on deleted posts",
+		Table: "reactions",
+		Where: "deleted_at IS NULL AND post_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = reactions.post_id AND posts.deleted_at IS NULL)",
+		Fix:   softDelete,
+	},
+	{
+		Name:  "reactions on deleted comments",
+		Table: "reactions",
+		Where: "deleted_at IS NULL AND comment_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM comments WHERE comments.id = reactions.comment_id AND comments.deleted_at IS NULL)",
+		Fix:   softDelete,
+	},
+	{
+		Name:  "follows of deleted users",
+		Table: "follows",
+		Where: "deleted_at IS NULL AND (" +
+			"NOT EXISTS (SELECT 1 FROM users WHERE users.id = follows.follower_id AND users.deleted_at IS NULL) OR " +
+			"NOT EXISTS (SELECT 1 FROM users WHERE users.id = follows.following_id AND users.deleted_at IS NULL))",
+		Fix: softDelete,
+	},
+	{
+		// Authors see their own posts; everyone else follows the author, or
+		// the page a post was published as
+		Name:  "feed entries of unfollowed authors",
+		Table: "activity_feeds",
+		Where: "deleted_at IS NULL AND user_id <> author_id AND " +
+			"NOT EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = activity_feeds.user_id AND follows.following_id = activity_feeds.author_id AND follows.deleted_at IS NULL) AND " +
+			"NOT EXISTS (SELECT 1 FROM posts JOIN page_follows ON page_follows.page_id = posts.page_id " +
+			"WHERE posts.id = activity_feeds.post_id AND page_follows.user_id = activity_feeds.user_id AND page_follows.deleted_at IS NULL)",
+		Fix: softDelete,
+	},
+}, negativeCounters()...)
+
+func negativeCounters() []Check {
+	checks := make([]Check, len(counter.Counters))
+	for i, c := range counter.Counters {
+		checks[i] = Check{
+			Name:  "negative " + c.Name(),
+			Table: c.Table,
+			Where: fmt.Sprintf("deleted_at IS NULL AND %s < 0", c.Column),
+			Fix:   fmt.Sprintf("%s = %s", c.Column, c.Actual()),
+		}
+	}
+	return checks
+}
+
+const (
+	// DefaultBatchSize is how many IDs of a table each pass checks
+	DefaultBatchSize = 5000
+
+	// MaxSamples is how many IDs of problem rows a Result lists
+	MaxSamples = 10
+)
+
+// Options tunes a Run call
+type Options struct {
+	BatchSize int
+	Fix       bool // Repair the problems found as well as reporting them
+}
+
+// Option customizes a Run call
+type Option func(*Options)
+
+// WithBatchSize sets how many IDs each pass checks; zero keeps DefaultBatchSize
+func WithBatchSize(n int) Option {
+	return func(o *Options) {
+		if n > 0 {
+			o.BatchSize = n
+		}
+	}
+}
+
+// Fix makes Run repair the problems it finds
+func Fix() Option {
+	return func(o *Options) { o.Fix = true }
+}
+
+// Result reports how many rows had a problem and how many of them were
+// fixed, which is none unless Run was asked to
+type Result struct {
+	Check   Check
+	Found   int64
+	Fixed   int64
+	Samples []int64 // IDs of the first MaxSamples rows found
+}
+
+// Run looks for every problem in Checks in batches of IDs. With Fix each
+// batch is repaired on its own, so a failure keeps the batches already done.
+func Run(ctx context.Context, db *gorm.DB, opts ...Option) ([]Result, error) {
+	o := Options{BatchSize: DefaultBatchSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	results := make([]Result, 0, len(Checks))
+	for _, c := range Checks {
+		result, err := run(ctx, db, c, o)
+		if err != nil {
+			return results, fmt.Errorf("failed to check %s: %w", c.Name, err)
+		}
+		if result.Found > 0 {
+			slog.InfoContext(ctx, "found integrity problems", slog.String("check", c.Name),
+				slog.Int64("rows", result.Found), slog.Int64("fixed", result.Fixed))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func run(ctx context.Context, db *gorm.DB, c Check, o Options) (Result, error) {
+	result := Result{Check: c}
+	db = db.WithContext(ctx)
+
+	var maxID int64
+	if err := db.Raw(fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s", c.Table)).Scan(&maxID).Error; err != nil {
+		return result, err
+	}
+
+	find := fmt.Sprintf("SELECT id FROM %s WHERE id > ? AND id <= ? AND %s ORDER BY id", c.Table, c.Where)
+	fix := fmt.Sprintf("UPDATE %s SET %s WHERE id IN ? AND %s", c.Table, c.Fix, c.Where)
+
+	for from := int64(0); from < maxID; from += int64(o.BatchSize) {
+		var ids []int64
+		if err := db.Raw(find, from, from+int64(o.BatchSize)).Scan(&ids).Error; err != nil {
+			return result, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		result.Found += int64(len(ids))
+		if n := MaxSamples - len(result.Samples); n > 0 {
+			result.Samples = append(result.Samples, ids[:min(n, len(ids))]...)
+		}
+		if !o.Fix {
+			continue
+		}
+
+		tx := db.Exec(fix, ids)
+		if tx.Error != nil {
+			return result, tx.Error
+		}
+		result.Fixed += tx.RowsAffected
+	}
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	notificationsvc "github.com/ilhamosaurus/sns-platform/internal/module/notification/service"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/jobs"
+)
+
+// PushSender delivers a push notification to the user's devices
+type PushSender interface {
+	SendPush(ctx context.Context, user *model.User, p SendPushPayload) error
+}
+
+// Mailer delivers an email
+type Mailer interface {
+	SendEmail(ctx context.Context, p SendEmailPayload) error
+}
+
+// LogSender writes deliveries to the log instead of sending them; it stands
+// in until a push or mail provider is configured
+type LogSender struct{}
+
+func (LogSender) SendPush(ctx context.Context, user *model.User, p SendPushPayload) error {
+	slog.InfoContext(ctx, "push notification", slog.Int64("user_id", user.ID), slog.String("title", p.Title))
+	return nil
+}
+
+func (LogSender) SendEmail(ctx context.Context, p SendEmailPayload) error {
+	slog.InfoContext(ctx, "email", slog.String("to", p.To), slog.String("template", p.Template))
+	return nil
+}
+
+// RegisterDeliveryHandlers wires push and email delivery into the worker.
+// Deliveries that are not urgent and come due during the recipient's quiet
+// hours are queued again for the end of the window.
+func RegisterDeliveryHandlers(w *jobs.Worker, queue *jobs.Queue, userRepo userrepo.UserRepository, push PushSender, mailer Mailer) {
+	jobs.Register(w, TypeSendPush, func(ctx context.Context, p SendPushPayload) error {
+		user, err := userRepo.GetByID(ctx, p.UserID)
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if held, err := holdForQuietHours(ctx, queue, user, p.Urgent, TypeSendPush, p, QueuePush); held || err != nil {
+			return err
+		}
+		return push.SendPush(ctx, user, p)
+	})
+
+	jobs.Register(w, TypeSendEmail, func(ctx context.Context, p SendEmailPayload) error {
+		if p.UserID != 0 {
+			user, err := userRepo.GetByID(ctx, p.UserID)
+			if errors.Is(err, apperr.ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if held, err := holdForQuietHours(ctx, queue, user, p.Urgent, TypeSendEmail, p, QueueEmail); held || err != nil {
+				return err
+			}
+		}
+		return mailer.SendEmail(ctx, p)
+	})
+}
+
+// holdForQuietHours queues a delivery again for the end of the user's quiet
+// hours when it is not urgent and they are on, and reports whether it did
+func holdForQuietHours(ctx context.Context, queue *jobs.Queue, user *model.User, urgent bool, jobType string, payload any, queueName string) (bool, error) {
+	if urgent {
+		return false, nil
+	}
+	until, quiet := notificationsvc.QuietUntil(user, time.Now())
+	if !quiet {
+		return false, nil
+	}
+	if _, err := queue.Enqueue(ctx, jobType, payload, jobs.WithQueue(queueName), jobs.WithRunAt(until.UTC())); err != nil {
+		return false, err
+	}
+	return true, nil
+}
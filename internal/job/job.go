@@ -0,0 +1,169 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	archivesvc "github.com/ilhamosaurus/sns-platform/internal/module/archive/service"
+	exportsvc "github.com/ilhamosaurus/sns-platform/internal/module/export/service"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/module/feed/stream"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/jobs"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// Job types
+const (
+	TypeFeedFanout    = "feed:fanout"
+	TypeSendEmail     = "email:send"
+	TypeSendPush      = "push:send"
+	TypeProcessMedia  = "media:process"
+	TypeSendDigest    = "digest:send"
+	TypeDataExport    = "export:user_data"
+	TypeArchiveImport = "import:archive"
+)
+
+// Email templates
+const (
+	TemplateWaitlistActivated = "waitlist_activated"
+)
+
+// Queue names
+const (
+	QueueFeed   = "feed"
+	QueueEmail  = "email"
+	QueuePush   = "push"
+	QueueMedia  = "media"
+	QueueDigest = "digest"
+	QueueExport = "export"
+	QueueImport = "import"
+)
+
+// Queues lists the queues jobs of this package are enqueued on, for the
+// worker to poll
+var Queues = []string{jobs.DefaultQueue, QueueFeed, QueueEmail, QueuePush, QueueMedia, QueueDigest, QueueExport, QueueImport}
+
+type FeedFanoutPayload struct {
+	PostID      int64     `json:"post_id"`
+	AuthorID    int64     `json:"author_id"`
+	PostCreated time.Time `json:"post_created"`
+}
+
+type SendEmailPayload struct {
+	To       string         `json:"to"`
+	Template string         `json:"template"`
+	Data     map[string]any `json:"data"`
+	UserID   int64          `json:"user_id,omitempty"` // Recipient account whose quiet hours apply, if any
+	Urgent   bool           `json:"urgent,omitempty"`  // Sent even during quiet hours, e.g. security notices
+}
+
+type SendPushPayload struct {
+	UserID         int64             `json:"user_id"`
+	NotificationID int64             `json:"notification_id,omitempty"`
+	Title          string            `json:"title"`
+	Body           string            `json:"body"`
+	Data           map[string]string `json:"data,omitempty"`
+	Urgent         bool              `json:"urgent,omitempty"` // Sent even during quiet hours, e.g. security notices
+}
+
+type ProcessMediaPayload struct {
+	PostID   int64  `json:"post_id"`
+	MediaURL string `json:"media_url"`
+}
+
+type SendDigestPayload struct {
+	UserID int64     `json:"user_id"`
+	Since  time.Time `json:"since"`
+}
+
+type DataExportPayload struct {
+	ExportID int64 `json:"export_id"`
+}
+
+type ArchiveImportPayload struct {
+	ImportID int64 `json:"import_id"`
+}
+
+// RegisterFeedHandlers wires feed-related job handlers into the worker; when
+// hub is set, connected clients are told about each fanned-out post
+func RegisterFeedHandlers(w *jobs.Worker, feedRepo feedrepo.FeedRepository, hub *stream.Hub) {
+	jobs.Register(w, TypeFeedFanout, func(ctx context.Context, p FeedFanoutPayload) error {
+		if err := feedRepo.FanOutPost(ctx, p.PostID, p.AuthorID, p.PostCreated); err != nil {
+			return err
+		}
+		if hub == nil {
+			return nil
+		}
+		update := stream.Update{PostID: p.PostID, AuthorID: p.AuthorID, PostCreated: p.PostCreated}
+		if err := hub.Publish(ctx, update); err != nil {
+			slog.WarnContext(ctx, "failed to publish feed update", slog.Int64("post_id", p.PostID), slog.Any("error", err))
+		}
+		return nil
+	})
+}
+
+// RegisterExportHandlers wires the data export builder into the worker
+func RegisterExportHandlers(w *jobs.Worker, exportService exportsvc.ExportService) {
+	jobs.Register(w, TypeDataExport, func(ctx context.Context, p DataExportPayload) error {
+		return exportService.Build(ctx, p.ExportID)
+	})
+}
+
+// RegisterImportHandlers wires the archive importer into the worker
+func RegisterImportHandlers(w *jobs.Worker, archiveService archivesvc.ArchiveService) {
+	jobs.Register(w, TypeArchiveImport, func(ctx context.Context, p ArchiveImportPayload) error {
+		return archiveService.Run(ctx, p.ImportID)
+	})
+}
+
+// EnqueueFanoutOnPostCreated schedules a fan-out job for every new post;
+// community posts live in the community feed and are not fanned out
+func EnqueueFanoutOnPostCreated(bus eventbus.Bus, queue *jobs.Queue) {
+	eventbus.On(bus, func(ctx context.Context, e event.PostCreated) error {
+		if e.CommunityID != nil {
+			return nil
+		}
+		_, err := queue.Enqueue(ctx, TypeFeedFanout, FeedFanoutPayload{
+			PostID:      e.PostID,
+			AuthorID:    e.AuthorID,
+			PostCreated: e.CreatedAt,
+		}, jobs.WithQueue(QueueFeed))
+		return err
+	})
+}
+
+// EnqueueWaitlistActivationEmails tells each activated waitlist email that
+// it can now sign up at signInURL
+func EnqueueWaitlistActivationEmails(bus eventbus.Bus, queue *jobs.Queue, signInURL string) {
+	eventbus.On(bus, func(ctx context.Context, e event.WaitlistActivated) error {
+		_, err := queue.Enqueue(ctx, TypeSendEmail, SendEmailPayload{
+			To:       e.Email,
+			Template: TemplateWaitlistActivated,
+			Data:     map[string]any{"sign_in_url": signInURL, "activated_at": e.ActivatedAt},
+		}, jobs.WithQueue(QueueEmail))
+		return err
+	})
+}
+
+// RecordEventDeadLetters stores events the bus gave up on alongside failed
+// jobs so they can be inspected through the same admin API
+func RecordEventDeadLetters(bus eventbus.Broker, queue *jobs.Queue) {
+	bus.OnDeadLetter(func(ctx context.Context, dl eventbus.DeadLetter) {
+		err := queue.AddDeadLetter(ctx, &model.DeadLetter{
+			Source:   types.DeadLetterSourceEvent,
+			SourceID: dl.MessageID,
+			Queue:    dl.Group,
+			Type:     dl.Name,
+			Payload:  string(dl.Payload),
+			Error:    dl.Err.Error(),
+			Attempts: dl.Attempts,
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "failed to record event dead letter", slog.Any("error", err))
+		}
+	})
+}
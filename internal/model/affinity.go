@@ -0,0 +1,23 @@
+package model
+
+// Affinity is how much UserID engaged with AuthorID's posts lately: the
+// reactions and comments UserID left on them, weighted into Score by the
+// affinity_recompute task. The home feed lifts fresh posts of the authors a
+// user has the most affinity with.
+type Affinity struct {
+	BaseModel
+	TenantID  int64 `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID    int64 `gorm:"column:user_id;not null;uniqueIndex:idx_affinity_user_author" json:"user_id"`
+	AuthorID  int64 `gorm:"column:author_id;not null;uniqueIndex:idx_affinity_user_author;index" json:"author_id"`
+	Reactions int64 `gorm:"column:reactions;default:0" json:"reactions"`
+	Comments  int64 `gorm:"column:comments;default:0" json:"comments"`
+	Score     int64 `gorm:"column:score;default:0" json:"score"`
+
+	// Relationships
+	User   *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Author *User `gorm:"foreignKey:AuthorID;constraint:OnDelete:CASCADE" json:"author,omitempty"`
+}
+
+func (Affinity) TableName() string {
+	return "affinities"
+}
@@ -0,0 +1,122 @@
+package model
+
+import "time"
+
+// Surfaces a post can be seen on, as reported by clients
+const (
+	ViewSourceFeed      = "feed"       // Home, explore and other feeds
+	ViewSourceProfile   = "profile"    // The author's profile
+	ViewSourceHashtag   = "hashtag"    // A hashtag's posts
+	ViewSourceShareLink = "share_link" // A link to the post shared elsewhere
+	ViewSourceEmbed     = "embed"      // The post embedded in another site
+)
+
+// ViewSources lists the surfaces impressions and link clicks are attributed to
+var ViewSources = []string{ViewSourceFeed, ViewSourceProfile, ViewSourceHashtag, ViewSourceShareLink, ViewSourceEmbed}
+
+// PostImpression records a post being shown to an account. The analytics
+// rollup aggregates impressions into PostDailyStat, PostSourceDailyStat and
+// AccountDailyStat.
+type PostImpression struct {
+	BaseModel
+	TenantID int64  `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	PostID   int64  `gorm:"column:post_id;not null;index:idx_impression_post_viewer" json:"post_id"`
+	ViewerID int64  `gorm:"column:viewer_id;not null;index:idx_impression_post_viewer;index" json:"viewer_id"`
+	Source   string `gorm:"column:source;size:16;not null;default:''" json:"source,omitempty"` // One of ViewSources, empty when not reported
+
+	// Relationships
+	Post *Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"post,omitempty"`
+}
+
+// ProfileView records an account opening another account's profile
+type ProfileView struct {
+	BaseModel
+	TenantID  int64 `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	ProfileID int64 `gorm:"column:profile_id;not null;index:idx_profile_view_profile_viewer" json:"profile_id"`
+	ViewerID  int64 `gorm:"column:viewer_id;not null;index:idx_profile_view_profile_viewer;index" json:"viewer_id"`
+
+	// Relationships
+	Profile *User `gorm:"foreignKey:ProfileID;constraint:OnDelete:CASCADE" json:"profile,omitempty"`
+}
+
+// LinkClick records an account following a link in a post
+type LinkClick struct {
+	BaseModel
+	TenantID int64  `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	PostID   int64  `gorm:"column:post_id;not null;index" json:"post_id"`
+	ViewerID int64  `gorm:"column:viewer_id;not null;index" json:"viewer_id"`
+	URL      string `gorm:"column:url;size:2048;not null" json:"url"`
+	Source   string `gorm:"column:source;size:16;not null;default:''" json:"source,omitempty"` // One of ViewSources, empty when not reported
+
+	// Relationships
+	Post *Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"post,omitempty"`
+}
+
+// PostDailyStat is the activity on one post during one UTC day
+type PostDailyStat struct {
+	BaseModel
+	TenantID    int64     `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	PostID      int64     `gorm:"column:post_id;not null;uniqueIndex:idx_post_stat_day" json:"post_id"`
+	AuthorID    int64     `gorm:"column:author_id;not null;index:idx_post_stat_author_day" json:"author_id"`
+	Day         time.Time `gorm:"column:day;not null;uniqueIndex:idx_post_stat_day;index:idx_post_stat_author_day" json:"day"`
+	Impressions int64     `gorm:"column:impressions;default:0" json:"impressions"`
+	Reach       int64     `gorm:"column:reach;default:0" json:"reach"` // Accounts that saw the post for the first time
+	Likes       int64     `gorm:"column:likes;default:0" json:"likes"`
+	Comments    int64     `gorm:"column:comments;default:0" json:"comments"`
+	LinkClicks  int64     `gorm:"column:link_clicks;default:0" json:"link_clicks"`
+
+	// Relationships
+	Post *Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"post,omitempty"`
+}
+
+// PostSourceDailyStat is the activity on one post during one UTC day that
+// came through one surface; Source is empty for activity clients did not
+// attribute
+type PostSourceDailyStat struct {
+	BaseModel
+	TenantID    int64     `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	PostID      int64     `gorm:"column:post_id;not null;uniqueIndex:idx_post_source_stat_day" json:"post_id"`
+	Day         time.Time `gorm:"column:day;not null;uniqueIndex:idx_post_source_stat_day;index" json:"day"`
+	Source      string    `gorm:"column:source;size:16;not null;default:'';uniqueIndex:idx_post_source_stat_day" json:"source"`
+	Impressions int64     `gorm:"column:impressions;default:0" json:"impressions"`
+	LinkClicks  int64     `gorm:"column:link_clicks;default:0" json:"link_clicks"`
+
+	// Relationships
+	Post *Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"post,omitempty"`
+}
+
+// AccountDailyStat is the audience and reach of one account during one UTC day
+type AccountDailyStat struct {
+	BaseModel
+	TenantID        int64     `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID          int64     `gorm:"column:user_id;not null;uniqueIndex:idx_account_stat_day" json:"user_id"`
+	Day             time.Time `gorm:"column:day;not null;uniqueIndex:idx_account_stat_day" json:"day"`
+	FollowersGained int64     `gorm:"column:followers_gained;default:0" json:"followers_gained"`
+	FollowersLost   int64     `gorm:"column:followers_lost;default:0" json:"followers_lost"`
+	Impressions     int64     `gorm:"column:impressions;default:0" json:"impressions"` // Across all of the account's posts
+	Reach           int64     `gorm:"column:reach;default:0" json:"reach"`             // Accounts that saw any of its posts for the first time
+	Engagements     int64     `gorm:"column:engagements;default:0" json:"engagements"` // Likes and comments received
+	LinkClicks      int64     `gorm:"column:link_clicks;default:0" json:"link_clicks"`
+	ProfileViews    int64     `gorm:"column:profile_views;default:0" json:"profile_views"`
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+// UserStatsDaily is a snapshot of a user's counters taken once per UTC day
+type UserStatsDaily struct {
+	BaseModel
+	TenantID       int64     `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID         int64     `gorm:"column:user_id;not null;uniqueIndex:idx_user_stats_day" json:"user_id"`
+	Day            time.Time `gorm:"column:day;not null;uniqueIndex:idx_user_stats_day;index" json:"day"`
+	FollowerCount  int64     `gorm:"column:follower_count;default:0" json:"follower_count"`
+	FollowingCount int64     `gorm:"column:following_count;default:0" json:"following_count"`
+	PostCount      int64     `gorm:"column:post_count;default:0" json:"post_count"`
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+func (UserStatsDaily) TableName() string {
+	return "user_stats_daily"
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// Announcement is a platform message from an admin, shown above every user's
+// feed from StartsAt until EndsAt. It is not a post: it cannot be liked,
+// commented on or reposted, and it never reaches analytics or counters.
+type Announcement struct {
+	BaseModel
+	TenantID int64     `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	AuthorID int64     `gorm:"column:author_id;not null;index" json:"author_id"`
+	Title    string    `gorm:"column:title;size:200;not null" json:"title"`
+	Body     string    `gorm:"column:body;type:text;not null" json:"body"`
+	LinkURL  string    `gorm:"column:link_url;size:255" json:"link_url,omitempty"`
+	StartsAt time.Time `gorm:"column:starts_at;not null;index:idx_announcements_window" json:"starts_at"`
+	EndsAt   time.Time `gorm:"column:ends_at;not null;index:idx_announcements_window" json:"ends_at"`
+
+	// Relationships
+	Author *User `gorm:"foreignKey:AuthorID;constraint:OnDelete:CASCADE" json:"author,omitempty"`
+}
+
+// AnnouncementDismissal hides an announcement from one user's feed
+type AnnouncementDismissal struct {
+	BaseModel
+	TenantID       int64 `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	AnnouncementID int64 `gorm:"column:announcement_id;not null;uniqueIndex:idx_announcement_dismissal" json:"announcement_id"`
+	UserID         int64 `gorm:"column:user_id;not null;uniqueIndex:idx_announcement_dismissal;index" json:"user_id"`
+
+	// Relationships
+	Announcement *Announcement `gorm:"foreignKey:AnnouncementID;constraint:OnDelete:CASCADE" json:"announcement,omitempty"`
+	User         *User         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// ArchiveImport tracks the import of a Twitter or Mastodon archive uploaded by a user
+type ArchiveImport struct {
+	BaseModel
+	TenantID       int64              `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID         int64              `gorm:"column:user_id;not null;index" json:"user_id"`
+	Source         types.ImportSource `gorm:"column:source;size:20;not null" json:"source"`
+	Status         types.ImportStatus `gorm:"column:status;size:20;not null;index" json:"status"`
+	FilePath       string             `gorm:"column:file_path;size:255" json:"-"`
+	TotalItems     int                `gorm:"column:total_items;default:0" json:"total_items"`
+	ProcessedItems int                `gorm:"column:processed_items;default:0" json:"processed_items"`
+	ImportedItems  int                `gorm:"column:imported_items;default:0" json:"imported_items"`
+	SkippedItems   int                `gorm:"column:skipped_items;default:0" json:"skipped_items"` // Items already imported earlier
+	Error          string             `gorm:"column:error;type:text" json:"error,omitempty"`
+	CompletedAt    *time.Time         `gorm:"column:completed_at" json:"completed_at"`
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+// ImportedItem maps an item of an external archive to the post created from it,
+// so importing the same archive twice does not duplicate posts
+type ImportedItem struct {
+	BaseModel
+	TenantID   int64              `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID     int64              `gorm:"column:user_id;not null;uniqueIndex:idx_imported_item_source" json:"user_id"`
+	Source     types.ImportSource `gorm:"column:source;size:20;not null;uniqueIndex:idx_imported_item_source" json:"source"`
+	ExternalID string             `gorm:"column:external_id;size:255;not null;uniqueIndex:idx_imported_item_source" json:"external_id"`
+	ImportID   int64              `gorm:"column:import_id;not null;index" json:"import_id"`
+	PostID     int64              `gorm:"column:post_id;not null;index" json:"post_id"`
+
+	// Relationships
+	Post *Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"post,omitempty"`
+}
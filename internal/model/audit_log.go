@@ -0,0 +1,93 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Audited actions
+const (
+	AuditAdminCreateUser          = "admin.create_user"
+	AuditAdminUpdateUser          = "admin.update_user" // Verification, bans, password resets and roles
+	AuditAdminRecount             = "admin.recount"
+	AuditAdminApplyRetention      = "admin.apply_retention"
+	AuditAdminFixIntegrity        = "admin.fix_integrity"
+	AuditAdminPlaceLegalHold      = "admin.place_legal_hold"
+	AuditAdminReleaseLegalHold    = "admin.release_legal_hold"
+	AuditAdminRequeueJob          = "admin.requeue_dead_letter"
+	AuditAdminDiscardJob          = "admin.discard_dead_letter"
+	AuditAdminRevokeInvites       = "admin.revoke_invites"
+	AuditAdminActivateWaitlist    = "admin.activate_waitlist" // By an admin or the scheduled batch
+	AuditAdminSetMaintenance      = "admin.set_maintenance"
+	AuditPrivacyChange            = "privacy.change"
+	AuditCommunityPostRemoval     = "community.remove_post"
+	AuditCommunityMemberBan       = "community.ban_member"
+	AuditCommunityCommentHide     = "community.hide_comment" // By members' reports; no actor
+	AuditCommunityCommentRestore  = "community.restore_comment"
+	AuditDataExportRequest        = "data_export.request"
+	AuditDataExportDownload       = "data_export.download"
+	AuditAccountDeactivate        = "account.deactivate"
+	AuditAccountReactivate        = "account.reactivate" // By the user or by logging in
+	AuditProvisionCreateUser      = "provisioning.create_user"
+	AuditProvisionUpdateUser      = "provisioning.update_user" // Attribute changes, suspension and restoring deleted accounts
+	AuditProvisionDeleteUser      = "provisioning.delete_user"
+	AuditOrganizationAddMember    = "organization.add_member"
+	AuditOrganizationRemoveMember = "organization.remove_member" // By an admin or by leaving
+	AuditOrganizationSetRole      = "organization.set_role"
+	AuditPageAddManager           = "page.add_manager"
+	AuditPageRemoveManager        = "page.remove_manager" // By an admin or by stepping down
+	AuditPageSetRole              = "page.set_role"
+	AuditAnnouncementCreate       = "announcement.create"
+	AuditAnnouncementUpdate       = "announcement.update"
+	AuditAnnouncementDelete       = "announcement.delete"
+	AuditCustomEmojiCreate        = "custom_emoji.create"
+	AuditCustomEmojiDelete        = "custom_emoji.delete"
+)
+
+// Kinds of audit targets
+const (
+	AuditTargetUser         = "user"
+	AuditTargetPost         = "post"
+	AuditTargetComment      = "comment"
+	AuditTargetDataExport   = "data_export"
+	AuditTargetDeadLetter   = "dead_letter"
+	AuditTargetCounters     = "counters"
+	AuditTargetOrganization = "organization"
+	AuditTargetPage         = "page"
+	AuditTargetAnnouncement = "announcement"
+	AuditTargetWaitlist     = "waitlist"
+	AuditTargetRetention    = "retention"
+	AuditTargetLegalHold    = "legal_hold"
+	AuditTargetCustomEmoji  = "custom_emoji"
+	AuditTargetMaintenance  = "maintenance"
+	AuditTargetIntegrity    = "integrity"
+)
+
+// ErrAuditLogImmutable is returned on attempts to change or remove an audit log entry
+var ErrAuditLogImmutable = errors.New("audit log entries cannot be changed")
+
+// AuditLog is an append-only record of a sensitive operation. It has no
+// updated_at or deleted_at, and its hooks refuse updates and deletes; only
+// the audit_logs retention policy removes entries, once they expire.
+type AuditLog struct {
+	ID         int64          `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TenantID   int64          `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	ActorID    *int64         `gorm:"column:actor_id;index" json:"actor_id"` // Nil for the CLI and background jobs
+	Action     string         `gorm:"column:action;size:64;not null;index" json:"action"`
+	TargetType string         `gorm:"column:target_type;size:32;not null;index:idx_audit_target" json:"target_type"`
+	TargetID   int64          `gorm:"column:target_id;not null;index:idx_audit_target" json:"target_id"`
+	IP         string         `gorm:"column:ip;size:45" json:"ip,omitempty"`
+	Before     map[string]any `gorm:"column:before_state;type:text;serializer:json" json:"before,omitempty"`
+	After      map[string]any `gorm:"column:after_state;type:text;serializer:json" json:"after,omitempty"`
+	CreatedAt  time.Time      `gorm:"column:created_at;index" json:"created_at"`
+}
+
+func (*AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+func (*AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
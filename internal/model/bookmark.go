@@ -0,0 +1,13 @@
+package model
+
+// Bookmark is a post a user saved for later
+type Bookmark struct {
+	BaseModel
+	TenantID int64 `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID   int64 `gorm:"column:user_id;not null;uniqueIndex:idx_bookmark_user_post" json:"user_id"`
+	PostID   int64 `gorm:"column:post_id;not null;uniqueIndex:idx_bookmark_user_post;index" json:"post_id"`
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Post *Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"post,omitempty"`
+}
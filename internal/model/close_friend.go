@@ -0,0 +1,22 @@
+package model
+
+import "gorm.io/gorm"
+
+// CloseFriend puts FriendID on UserID's close friends list
+type CloseFriend struct {
+	BaseModel
+	TenantID int64 `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID   int64 `gorm:"column:user_id;not null;index:idx_close_friend_pair,unique" json:"user_id"`
+	FriendID int64 `gorm:"column:friend_id;not null;index:idx_close_friend_pair,unique;index" json:"friend_id"`
+
+	// Relationships
+	User   *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Friend *User `gorm:"foreignKey:FriendID;constraint:OnDelete:CASCADE" json:"friend,omitempty"`
+}
+
+func (c *CloseFriend) BeforeCreate(tx *gorm.DB) error {
+	if c.UserID == c.FriendID {
+		return gorm.ErrInvalidData
+	}
+	return nil
+}
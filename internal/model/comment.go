@@ -1,18 +1,35 @@
 package model
 
+import "time"
+
 type Comment struct {
 	BaseModel
-	PostID       int64  `gorm:"column:post_id;not null;index:idx_post_created" json:"post_id"`
-	UserID       int64  `gorm:"column:user_id;not null;index" json:"user_id"`
-	ParentID     *int64 `gorm:"column:parent_id;index" json:"parent_id"` // For nested comments/replies
-	Content      string `gorm:"column:content;type:text;not null" json:"content"`
-	LikesCount   int64  `gorm:"column:likes_count;default:0" json:"likes_count"`
-	RepliesCount int64  `gorm:"column:replies_count;default:0" json:"replies_count"`
+	TenantID      int64  `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	PostID        int64  `gorm:"column:post_id;not null;index:idx_post_created" json:"post_id"`
+	UserID        int64  `gorm:"column:user_id;not null;index" json:"user_id"`
+	ParentID      *int64 `gorm:"column:parent_id;index" json:"parent_id"`               // For nested comments/replies
+	ReplyToUserID *int64 `gorm:"column:reply_to_user_id;index" json:"reply_to_user_id"` // Author of the parent comment, set on replies
+	Content       string `gorm:"column:content;type:text;not null" json:"content"`
+	LikesCount    int64  `gorm:"column:likes_count;default:0" json:"likes_count"`
+	RepliesCount  int64  `gorm:"column:replies_count;default:0" json:"replies_count"`
+
+	// Orders the "top" comment sort; rescored periodically from the counts
+	// and the comment's age
+	RankScore float64 `gorm:"column:rank_score;default:0" json:"-"`
+
+	// Set on comments by users the post author restricted; only the commenter
+	// and the post author see them until the author approves
+	PendingApproval bool `gorm:"column:pending_approval;default:false;index" json:"pending_approval"`
+
+	// Set when a community hid the comment over its reports; only the
+	// commenter still sees it until a moderator restores it
+	HiddenAt *time.Time `gorm:"column:hidden_at;index" json:"hidden_at,omitempty"`
 
 	// Relationships
 	Post      *Post       `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"post,omitempty"`
 	User      *User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
 	Parent    *Comment    `gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE" json:"parent,omitempty"`
+	ReplyTo   *User       `gorm:"foreignKey:ReplyToUserID;constraint:OnDelete:SET NULL" json:"reply_to,omitempty"`
 	Replies   []*Comment  `gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE" json:"replies,omitempty"`
 	Reactions []*Reaction `gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE" json:"reactions,omitempty"`
 }
@@ -0,0 +1,64 @@
+package model
+
+import "github.com/ilhamosaurus/sns-platform/pkg/types"
+
+type Community struct {
+	BaseModel
+	TenantID    int64  `gorm:"column:tenant_id;not null;default:0;uniqueIndex:idx_communities_tenant_slug" json:"-"`
+	Slug        string `gorm:"column:slug;uniqueIndex:idx_communities_tenant_slug;size:50;not null" json:"slug"`
+	Name        string `gorm:"column:name;size:100;not null" json:"name"`
+	Description string `gorm:"column:description;type:text" json:"description"`
+	AvatarURL   string `gorm:"column:avatar_url;size:255" json:"avatar_url"`
+	OwnerID     int64  `gorm:"column:owner_id;not null;index" json:"owner_id"`
+	IsPrivate   bool   `gorm:"column:is_private;default:false;index" json:"is_private"` // Joining requires moderator approval
+	MemberCount int64  `gorm:"column:member_count;default:0" json:"member_count"`
+	PostCount   int64  `gorm:"column:post_count;default:0" json:"post_count"`
+
+	// Comments on the community's posts are hidden once this many members
+	// report them; zero leaves reported comments up
+	AutoHideReports int `gorm:"column:auto_hide_reports;not null;default:0" json:"auto_hide_reports"`
+
+	// Relationships
+	Owner   *User              `gorm:"foreignKey:OwnerID;constraint:OnDelete:CASCADE" json:"owner,omitempty"`
+	Members []*CommunityMember `gorm:"foreignKey:CommunityID;constraint:OnDelete:CASCADE" json:"members,omitempty"`
+}
+
+type CommunityMember struct {
+	BaseModel
+	TenantID    int64                  `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	CommunityID int64                  `gorm:"column:community_id;not null;index:idx_community_user,unique" json:"community_id"`
+	UserID      int64                  `gorm:"column:user_id;not null;index:idx_community_user,unique;index" json:"user_id"`
+	Role        types.CommunityRole    `gorm:"column:role;size:20;not null" json:"role"`           // owner, moderator, member
+	Status      types.MembershipStatus `gorm:"column:status;size:20;not null;index" json:"status"` // pending, active, banned
+
+	// Relationships
+	Community *Community `gorm:"foreignKey:CommunityID;constraint:OnDelete:CASCADE" json:"community,omitempty"`
+	User      *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+// CommunityFilterRule is a word or phrase a community refuses in its posts
+// and the comments on them. Matching ignores case, repeated letters and
+// look-alike characters, as in "sh1iit", and a * matches any letters.
+type CommunityFilterRule struct {
+	BaseModel
+	TenantID    int64  `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	CommunityID int64  `gorm:"column:community_id;not null;index" json:"community_id"`
+	Pattern     string `gorm:"column:pattern;size:100;not null" json:"pattern"`
+
+	// Relationships
+	Community *Community `gorm:"foreignKey:CommunityID;constraint:OnDelete:CASCADE" json:"community,omitempty"`
+}
+
+// CommentReport is a member's report of a comment on a community post; each
+// member reports a comment once
+type CommentReport struct {
+	BaseModel
+	TenantID   int64  `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	CommentID  int64  `gorm:"column:comment_id;not null;uniqueIndex:idx_comment_report" json:"comment_id"`
+	ReporterID int64  `gorm:"column:reporter_id;not null;uniqueIndex:idx_comment_report;index" json:"reporter_id"`
+	Reason     string `gorm:"column:reason;size:200" json:"reason,omitempty"`
+
+	// Relationships
+	Comment  *Comment `gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE" json:"comment,omitempty"`
+	Reporter *User    `gorm:"foreignKey:ReporterID;constraint:OnDelete:CASCADE" json:"reporter,omitempty"`
+}
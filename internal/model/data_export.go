@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// DataExport tracks a user's "download my data" archive
+type DataExport struct {
+	BaseModel
+	TenantID    int64              `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID      int64              `gorm:"column:user_id;not null;index" json:"user_id"`
+	Status      types.ExportStatus `gorm:"column:status;size:20;not null;index" json:"status"`
+	FilePath    string             `gorm:"column:file_path;size:255" json:"-"`
+	SizeBytes   int64              `gorm:"column:size_bytes;default:0" json:"size_bytes"`
+	Error       string             `gorm:"column:error;type:text" json:"error,omitempty"`
+	CompletedAt *time.Time         `gorm:"column:completed_at" json:"completed_at"`
+	ExpiresAt   *time.Time         `gorm:"column:expires_at;index" json:"expires_at"` // Archive is deleted after this
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "github.com/ilhamosaurus/sns-platform/pkg/types"
+
+type DeadLetter struct {
+	BaseModel
+	Source   types.DeadLetterSource `gorm:"column:source;size:20;not null;index" json:"source"` // job, event
+	SourceID string                 `gorm:"column:source_id;size:64" json:"source_id"`          // Job ID or stream message ID
+	Queue    string                 `gorm:"column:queue;size:100;index" json:"queue"`           // Job queue or event consumer group
+	Type     string                 `gorm:"column:type;size:100;not null;index" json:"type"`    // Job type or event name
+	Payload  string                 `gorm:"column:payload;type:text" json:"payload"`
+	Error    string                 `gorm:"column:error;type:text" json:"error"`
+	Attempts int                    `gorm:"column:attempts;default:0" json:"attempts"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// DirectoryUser links a user to their entry in the LDAP directory the
+// accounts are synced from. EntryID is the entry's stable identifier
+// (objectGUID or entryUUID); DN and ManagerDN, as of the last sync, place
+// the user in the org chart.
+type DirectoryUser struct {
+	BaseModel
+	TenantID  int64     `gorm:"column:tenant_id;not null;default:0;uniqueIndex:idx_directory_users_entry" json:"-"`
+	UserID    int64     `gorm:"column:user_id;not null;uniqueIndex" json:"user_id"`
+	EntryID   string    `gorm:"column:entry_id;size:255;not null;uniqueIndex:idx_directory_users_entry" json:"entry_id"`
+	DN        string    `gorm:"column:dn;size:1024;not null" json:"dn"`
+	ManagerDN string    `gorm:"column:manager_dn;size:1024" json:"manager_dn"`
+	SyncedAt  time.Time `gorm:"column:synced_at;not null;index" json:"synced_at"` // Last time the entry was seen
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+// DirectoryFollow is a follow the directory sync wants between teammates.
+// Owned is set when the sync created the follow rather than finding it,
+// so only follows it owns are removed when the team changes.
+type DirectoryFollow struct {
+	BaseModel
+	TenantID    int64 `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	FollowerID  int64 `gorm:"column:follower_id;not null;uniqueIndex:idx_directory_follows_pair" json:"follower_id"`
+	FollowingID int64 `gorm:"column:following_id;not null;uniqueIndex:idx_directory_follows_pair" json:"following_id"`
+	Owned       bool  `gorm:"column:owned;not null" json:"owned"`
+}
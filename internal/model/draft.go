@@ -0,0 +1,17 @@
+package model
+
+import "github.com/ilhamosaurus/sns-platform/pkg/types"
+
+// Draft is an unsent direct message or comment, kept per target so it
+// follows the user across devices
+type Draft struct {
+	BaseModel
+	TenantID   int64             `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID     int64             `gorm:"column:user_id;not null;index:idx_draft_target,unique" json:"-"`
+	TargetType types.DraftTarget `gorm:"column:target_type;size:20;not null;index:idx_draft_target,unique" json:"target_type"`
+	TargetID   int64             `gorm:"column:target_id;not null;index:idx_draft_target,unique" json:"target_id"`
+	Content    string            `gorm:"column:content;type:text;not null" json:"content"`
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// CustomEmoji is an image admins upload to be used as :Shortcode: in posts,
+// comments and messages. Content renders without knowing its tenant, so
+// custom emoji are shared by every tenant.
+type CustomEmoji struct {
+	BaseModel
+	Shortcode   string `gorm:"column:shortcode;size:32;not null;uniqueIndex" json:"shortcode"`
+	URL         string `gorm:"column:url;size:255;not null" json:"url"`
+	StorageKey  string `gorm:"column:storage_key;size:255;not null" json:"-"`
+	CreatedByID int64  `gorm:"column:created_by_id;not null" json:"created_by_id"`
+}
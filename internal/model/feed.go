@@ -4,6 +4,7 @@ import "time"
 
 type ActivityFeed struct {
 	BaseModel
+	TenantID    int64     `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
 	UserID      int64     `gorm:"column:user_id;not null;index:idx_user_created" json:"user_id"`
 	PostID      int64     `gorm:"column:post_id;not null;index" json:"post_id"`
 	AuthorID    int64     `gorm:"column:author_id;not null;index" json:"author_id"`
@@ -18,3 +19,24 @@ type ActivityFeed struct {
 func (ActivityFeed) TableName() string {
 	return "activity_feeds"
 }
+
+// ExploreCandidate is a post ranked among the most engaging of its tenant,
+// language and region within an explore window. The trending_recompute task replaces
+// the candidates of each window on every run; explore pages over them rather
+// than ranking every recent post per request.
+type ExploreCandidate struct {
+	BaseModel
+	TenantID      int64  `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	WindowSeconds int64  `gorm:"column:window_seconds;not null;index:idx_explore_window_score" json:"window_seconds"`
+	PostID        int64  `gorm:"column:post_id;not null;index" json:"post_id"`
+	Language      string `gorm:"column:language;size:10" json:"language"` // Empty for posts of undetermined language
+	Region        string `gorm:"column:region;size:2" json:"region"`      // Empty for posts of unknown region
+	Score         int64  `gorm:"column:score;not null;index:idx_explore_window_score" json:"score"`
+
+	// Relationships
+	Post *Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"post,omitempty"`
+}
+
+func (ExploreCandidate) TableName() string {
+	return "explore_candidates"
+}
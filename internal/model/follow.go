@@ -4,6 +4,7 @@ import "gorm.io/gorm"
 
 type Follow struct {
 	BaseModel
+	TenantID    int64 `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
 	FollowerID  int64 `gorm:"column:follower_id;not null;index:idx_follower_following,unique" json:"follower_id"`
 	FollowingID int64 `gorm:"column:following_id;not null;index:idx_follower_following,unique" json:"following_id"`
 
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Invite is a code a user hands out to let others sign up. It admits up to
+// MaxUses accounts until it expires or its inviter revokes it; each account
+// it admitted records it and its inviter, which forms the invite graph.
+type Invite struct {
+	BaseModel
+	TenantID  int64      `gorm:"column:tenant_id;not null;default:0;uniqueIndex:idx_invites_tenant_code" json:"-"`
+	InviterID int64      `gorm:"column:inviter_id;not null;index" json:"inviter_id"`
+	Code      string     `gorm:"column:code;size:32;not null;uniqueIndex:idx_invites_tenant_code" json:"code"`
+	MaxUses   int        `gorm:"column:max_uses;not null;default:1" json:"max_uses"`
+	Uses      int        `gorm:"column:uses;not null;default:0" json:"uses"`
+	ExpiresAt *time.Time `gorm:"column:expires_at" json:"expires_at,omitempty"`
+	RevokedAt *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+
+	// Relationships
+	Inviter *User `gorm:"foreignKey:InviterID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (Invite) TableName() string {
+	return "invites"
+}
+
+// Usable reports whether the invite can still admit an account at now
+func (i *Invite) Usable(now time.Time) bool {
+	return i.RevokedAt == nil && i.Uses < i.MaxUses && (i.ExpiresAt == nil || now.Before(*i.ExpiresAt))
+}
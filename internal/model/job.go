@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type Job struct {
+	BaseModel
+	Queue       string          `gorm:"column:queue;size:50;not null;index:idx_queue_status_run" json:"queue"`
+	Type        string          `gorm:"column:type;size:100;not null;index" json:"type"`
+	Payload     string          `gorm:"column:payload;type:text" json:"payload"`
+	Status      types.JobStatus `gorm:"column:status;size:20;not null;index:idx_queue_status_run" json:"status"`
+	Attempts    int             `gorm:"column:attempts;default:0" json:"attempts"`
+	MaxAttempts int             `gorm:"column:max_attempts;default:5" json:"max_attempts"`
+	RunAt       time.Time       `gorm:"column:run_at;not null;index:idx_queue_status_run" json:"run_at"`
+	LockedAt    *time.Time      `gorm:"column:locked_at" json:"locked_at"`
+	FinishedAt  *time.Time      `gorm:"column:finished_at" json:"finished_at"`
+	LastError   string          `gorm:"column:last_error;type:text" json:"last_error"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// Legal hold targets
+const (
+	LegalHoldUser    = "user" // The account and everything it owns
+	LegalHoldPost    = "post"
+	LegalHoldComment = "comment"
+	LegalHoldMessage = "message"
+)
+
+// LegalHoldTargets lists the kinds of records a legal hold can cover
+var LegalHoldTargets = []string{LegalHoldUser, LegalHoldPost, LegalHoldComment, LegalHoldMessage}
+
+// LegalHold preserves a user or a piece of content for litigation or an
+// investigation: while it is active, retention purges and the hard deletes
+// of deleted accounts leave the target alone, even if it was deleted. Holds
+// are released rather than removed, so they leave a record.
+type LegalHold struct {
+	ID           int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TenantID     int64      `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	TargetType   string     `gorm:"column:target_type;size:32;not null;index:idx_legal_holds_target" json:"target_type"`
+	TargetID     int64      `gorm:"column:target_id;not null;index:idx_legal_holds_target" json:"target_id"`
+	Reason       string     `gorm:"column:reason;type:text;not null" json:"reason"` // The matter or case the hold is for
+	PlacedByID   *int64     `gorm:"column:placed_by_id" json:"placed_by_id,omitempty"`
+	CreatedAt    time.Time  `gorm:"column:created_at" json:"created_at"`
+	ReleasedAt   *time.Time `gorm:"column:released_at;index" json:"released_at,omitempty"`
+	ReleasedByID *int64     `gorm:"column:released_by_id" json:"released_by_id,omitempty"`
+}
+
+func (LegalHold) TableName() string {
+	return "legal_holds"
+}
+
+// Active reports whether the hold still preserves its target
+func (h *LegalHold) Active() bool {
+	return h.ReleasedAt == nil
+}
+
+// HeldIDs is a subquery of the IDs of targetType records under an active
+// legal hold, for deletions to leave out
+func HeldIDs(targetType string) string {
+	return "SELECT target_id FROM legal_holds WHERE target_type = '" + targetType + "' AND released_at IS NULL"
+}
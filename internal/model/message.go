@@ -4,12 +4,15 @@ import "time"
 
 type Message struct {
 	BaseModel
-	SenderID   int64      `gorm:"column:sender_id;not null;index:idx_sender_receiver" json:"sender_id"`
-	ReceiverID int64      `gorm:"column:receiver_id;not null;index:idx_sender_receiver" json:"receiver_id"`
-	Content    string     `gorm:"column:content;type:text;not null" json:"content"`
-	MediaURL   string     `gorm:"column:media_url;size:255" json:"media_url"`
-	IsRead     bool       `gorm:"column:is_read;default:false;index" json:"is_read"`
-	ReadAt     *time.Time `gorm:"column:read_at" json:"read_at"`
+	TenantID    int64      `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	SenderID    int64      `gorm:"column:sender_id;not null;index:idx_sender_receiver" json:"sender_id"`
+	ReceiverID  int64      `gorm:"column:receiver_id;not null;index:idx_sender_receiver" json:"receiver_id"`
+	Content     string     `gorm:"column:content;type:text;not null" json:"content"`
+	ContentHTML string     `gorm:"-" json:"content_html"` // Content rendered for display when read
+	MediaURL    string     `gorm:"column:media_url;size:255" json:"media_url"`
+	IsRead      bool       `gorm:"column:is_read;default:false;index" json:"is_read"`
+	ReadAt      *time.Time `gorm:"column:read_at" json:"read_at"`
+	IsRequest   bool       `gorm:"column:is_request;default:false;index" json:"is_request"` // From a stranger, held until the receiver accepts
 
 	// Relationships
 	Sender   *User `gorm:"foreignKey:SenderID;constraint:OnDelete:CASCADE" json:"sender,omitempty"`
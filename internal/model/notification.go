@@ -2,15 +2,37 @@ package model
 
 import "github.com/ilhamosaurus/sns-platform/pkg/types"
 
+// Notification template keys, rendered in the recipient's language by pkg/i18n
+const (
+	NotificationKeyFollow         = "notification.follow"
+	NotificationKeyLike           = "notification.like"
+	NotificationKeyComment        = "notification.comment"
+	NotificationKeyReply          = "notification.reply"
+	NotificationKeyMention        = "notification.mention"
+	NotificationKeyNewDeviceLogin = "notification.security.new_device_login"
+	NotificationKeyLegacy         = "notification.legacy" // Free text written before templating, in the "text" param
+
+	// Rendered in place of a notification whose target was deleted, and of
+	// the name of a deleted actor
+	NotificationKeyDeletedContent = "notification.deleted_content"
+	NotificationKeyDeletedActor   = "notification.deleted_actor"
+)
+
 type Notification struct {
 	BaseModel
-	UserID     int64                    `gorm:"column:user_id;not null;index:idx_user_read_created" json:"user_id"`
-	ActorID    int64                    `gorm:"column:actor_id;not null;index" json:"actor_id"` // User who triggered the notification
-	Type       types.NotificationType   `gorm:"column:type;size:50;not null;index" json:"type"` // follow, like, comment, mention
-	TargetType types.NotificationTarget `gorm:"column:target_type;size:50" json:"target_type"`  // post, comment, user
-	TargetID   int64                    `gorm:"column:target_id;index" json:"target_id"`
-	Message    string                   `gorm:"column:message;type:text" json:"message"`
-	IsRead     bool                     `gorm:"column:is_read;default:false;index:idx_user_read_created" json:"is_read"`
+	TenantID    int64                    `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID      int64                    `gorm:"column:user_id;not null;index:idx_user_read_created" json:"user_id"`
+	ActorID     int64                    `gorm:"column:actor_id;not null;index" json:"actor_id"` // User who triggered the notification
+	Type        types.NotificationType   `gorm:"column:type;size:50;not null;index" json:"type"` // follow, like, comment, mention
+	TargetType  types.NotificationTarget `gorm:"column:target_type;size:50" json:"target_type"`  // post, comment, user
+	TargetID    int64                    `gorm:"column:target_id;index" json:"target_id"`
+	TemplateKey string                   `gorm:"column:template_key;size:100" json:"template_key"`
+	Params      map[string]string        `gorm:"column:params;type:text;serializer:json" json:"params,omitempty"`
+	Message     string                   `gorm:"-" json:"message"` // Rendered from TemplateKey when read
+	IsRead      bool                     `gorm:"column:is_read;default:false;index:idx_user_read_created" json:"is_read"`
+
+	// Set when read if the target no longer exists; Message then says so
+	TargetDeleted bool `gorm:"-" json:"target_deleted,omitempty"`
 
 	// Relationships
 	User  *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
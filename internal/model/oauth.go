@@ -0,0 +1,78 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// OAuthApp is a third-party client registered by a user. Public clients
+// (mobile and browser apps) have no secret and rely on PKCE alone.
+type OAuthApp struct {
+	BaseModel
+	TenantID     int64  `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	OwnerID      int64  `gorm:"column:owner_id;not null;index" json:"owner_id"`
+	Name         string `gorm:"column:name;size:100;not null" json:"name"`
+	ClientID     string `gorm:"column:client_id;size:64;not null;uniqueIndex" json:"client_id"`
+	SecretHash   string `gorm:"column:secret_hash;size:64" json:"-"`
+	RedirectURIs string `gorm:"column:redirect_uris;type:text;not null" json:"-"` // One per line
+	Scopes       string `gorm:"column:scopes;size:100;not null" json:"scopes"`    // The most the app may ask for, space separated
+
+	// Relationships
+	Owner *User `gorm:"foreignKey:OwnerID;constraint:OnDelete:CASCADE" json:"owner,omitempty"`
+}
+
+func (OAuthApp) TableName() string {
+	return "oauth_apps"
+}
+
+// Confidential reports whether the app authenticates with a client secret
+func (a *OAuthApp) Confidential() bool {
+	return a.SecretHash != ""
+}
+
+// RedirectURIList returns the registered redirect URIs
+func (a *OAuthApp) RedirectURIList() []string {
+	return strings.Fields(a.RedirectURIs)
+}
+
+// OAuthCode is an authorization code waiting to be exchanged for tokens
+type OAuthCode struct {
+	BaseModel
+	TenantID      int64     `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	AppID         int64     `gorm:"column:app_id;not null;index" json:"app_id"`
+	UserID        int64     `gorm:"column:user_id;not null;index" json:"user_id"`
+	CodeHash      string    `gorm:"column:code_hash;size:64;not null;uniqueIndex" json:"-"`
+	RedirectURI   string    `gorm:"column:redirect_uri;size:2048;not null" json:"redirect_uri"`
+	Scopes        string    `gorm:"column:scopes;size:100;not null" json:"scopes"`
+	CodeChallenge string    `gorm:"column:code_challenge;size:128;not null" json:"-"` // S256
+	ExpiresAt     time.Time `gorm:"column:expires_at;not null" json:"expires_at"`
+
+	// Relationships
+	App *OAuthApp `gorm:"foreignKey:AppID;constraint:OnDelete:CASCADE" json:"app,omitempty"`
+}
+
+func (OAuthCode) TableName() string {
+	return "oauth_codes"
+}
+
+// OAuthToken is an access token and its refresh token issued to an app for
+// a user. Refreshing replaces the row.
+type OAuthToken struct {
+	BaseModel
+	TenantID         int64     `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	AppID            int64     `gorm:"column:app_id;not null;index:idx_oauth_token_user_app" json:"app_id"`
+	UserID           int64     `gorm:"column:user_id;not null;index:idx_oauth_token_user_app" json:"user_id"`
+	AccessHash       string    `gorm:"column:access_hash;size:64;not null;uniqueIndex" json:"-"`
+	RefreshHash      string    `gorm:"column:refresh_hash;size:64;not null;uniqueIndex" json:"-"`
+	Scopes           string    `gorm:"column:scopes;size:100;not null" json:"scopes"`
+	ExpiresAt        time.Time `gorm:"column:expires_at;not null" json:"expires_at"`
+	RefreshExpiresAt time.Time `gorm:"column:refresh_expires_at;not null" json:"refresh_expires_at"`
+
+	// Relationships
+	App  *OAuthApp `gorm:"foreignKey:AppID;constraint:OnDelete:CASCADE" json:"app,omitempty"`
+	User *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+func (OAuthToken) TableName() string {
+	return "oauth_tokens"
+}
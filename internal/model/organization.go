@@ -0,0 +1,33 @@
+package model
+
+import "github.com/ilhamosaurus/sns-platform/pkg/types"
+
+// Organization is a workspace inside a tenant, such as a company or a team.
+// Posts made inside it are only visible to its members.
+type Organization struct {
+	BaseModel
+	TenantID    int64  `gorm:"column:tenant_id;not null;default:0;uniqueIndex:idx_organizations_tenant_slug" json:"-"`
+	Slug        string `gorm:"column:slug;uniqueIndex:idx_organizations_tenant_slug;size:50;not null" json:"slug"`
+	Name        string `gorm:"column:name;size:100;not null" json:"name"`
+	Description string `gorm:"column:description;type:text" json:"description"`
+	AvatarURL   string `gorm:"column:avatar_url;size:255" json:"avatar_url"`
+	OwnerID     int64  `gorm:"column:owner_id;not null;index" json:"owner_id"`
+	MemberCount int64  `gorm:"column:member_count;default:0" json:"member_count"`
+	PostCount   int64  `gorm:"column:post_count;default:0" json:"post_count"`
+
+	// Relationships
+	Owner   *User                 `gorm:"foreignKey:OwnerID;constraint:OnDelete:CASCADE" json:"owner,omitempty"`
+	Members []*OrganizationMember `gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE" json:"members,omitempty"`
+}
+
+type OrganizationMember struct {
+	BaseModel
+	TenantID       int64                  `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	OrganizationID int64                  `gorm:"column:organization_id;not null;index:idx_organization_user,unique" json:"organization_id"`
+	UserID         int64                  `gorm:"column:user_id;not null;index:idx_organization_user,unique;index" json:"user_id"`
+	Role           types.OrganizationRole `gorm:"column:role;size:20;not null" json:"role"` // owner, admin, member
+
+	// Relationships
+	Organization *Organization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE" json:"organization,omitempty"`
+	User         *User         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
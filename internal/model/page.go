@@ -0,0 +1,46 @@
+package model
+
+import "github.com/ilhamosaurus/sns-platform/pkg/types"
+
+// Page is a brand or public figure account. It is not a user: people manage
+// it through PageManager and post on its behalf, and others follow it
+// through PageFollow.
+type Page struct {
+	BaseModel
+	TenantID      int64  `gorm:"column:tenant_id;not null;default:0;uniqueIndex:idx_pages_tenant_handle" json:"-"`
+	Handle        string `gorm:"column:handle;uniqueIndex:idx_pages_tenant_handle;size:50;not null" json:"handle"`
+	Name          string `gorm:"column:name;size:100;not null;index" json:"name"`
+	Bio           string `gorm:"column:bio;type:text" json:"bio"`
+	Category      string `gorm:"column:category;size:50" json:"category"`
+	AvatarURL     string `gorm:"column:avatar_url;size:255" json:"avatar_url"`
+	IsVerified    bool   `gorm:"column:is_verified;default:false" json:"is_verified"`
+	FollowerCount int64  `gorm:"column:follower_count;default:0" json:"follower_count"`
+	PostCount     int64  `gorm:"column:post_count;default:0" json:"post_count"`
+
+	// Relationships
+	Managers []*PageManager `gorm:"foreignKey:PageID;constraint:OnDelete:CASCADE" json:"managers,omitempty"`
+}
+
+type PageManager struct {
+	BaseModel
+	TenantID int64          `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	PageID   int64          `gorm:"column:page_id;not null;index:idx_page_manager,unique" json:"page_id"`
+	UserID   int64          `gorm:"column:user_id;not null;index:idx_page_manager,unique;index" json:"user_id"`
+	Role     types.PageRole `gorm:"column:role;size:20;not null" json:"role"` // admin, editor, analyst
+
+	// Relationships
+	Page *Page `gorm:"foreignKey:PageID;constraint:OnDelete:CASCADE" json:"page,omitempty"`
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+// PageFollow puts a page's posts in a user's feed
+type PageFollow struct {
+	BaseModel
+	TenantID int64 `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	PageID   int64 `gorm:"column:page_id;not null;uniqueIndex:idx_page_follows_pair" json:"page_id"`
+	UserID   int64 `gorm:"column:user_id;not null;uniqueIndex:idx_page_follows_pair;index" json:"user_id"`
+
+	// Relationships
+	Page *Page `gorm:"foreignKey:PageID;constraint:OnDelete:CASCADE" json:"page,omitempty"`
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
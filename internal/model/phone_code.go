@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// PhoneCode is a one-time code texted to a user, waiting to be entered. A
+// user has at most one per purpose; sending another replaces it.
+type PhoneCode struct {
+	BaseModel
+	TenantID  int64                  `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID    int64                  `gorm:"column:user_id;not null;index" json:"user_id"`
+	Purpose   types.PhoneCodePurpose `gorm:"column:purpose;size:20;not null" json:"purpose"`
+	Phone     string                 `gorm:"column:phone;size:20;not null" json:"-"` // Where the code was sent, in E.164
+	CodeHash  string                 `gorm:"column:code_hash;size:64;not null" json:"-"`
+	Attempts  int                    `gorm:"column:attempts;not null;default:0" json:"attempts"` // Codes entered so far
+	ExpiresAt time.Time              `gorm:"column:expires_at;not null" json:"expires_at"`
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+func (PhoneCode) TableName() string {
+	return "phone_codes"
+}
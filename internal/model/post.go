@@ -1,21 +1,115 @@
 package model
 
-import "github.com/ilhamosaurus/sns-platform/pkg/types"
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/langdetect"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
 
 type Post struct {
 	BaseModel
-	UserID       int64           `gorm:"column:user_id;not null;index:idx_user_created" json:"user_id"`
-	Content      string          `gorm:"type:text" json:"content"`
-	MediaType    types.MediaType `gorm:"column:media_type;size:20;index" json:"media_type"` // image, video, text
-	MediaURL     string          `gorm:"column:media_url;size:255" json:"media_url"`
-	IsPublic     bool            `gorm:"column:is_public;default:true;index" json:"is_public"`
-	ViewCount    int64           `gorm:"column:view_count;default:0" json:"view_count"`
-	ShareCount   int64           `gorm:"column:share_count;default:0" json:"share_count"`
-	LikeCount    int64           `gorm:"column:like_count;default:0" json:"like_count"`
-	CommentCount int64           `gorm:"column:comment_count;default:0" json:"comment_count"`
+	TenantID        int64               `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID          int64               `gorm:"column:user_id;not null;index" json:"user_id"`
+	CommunityID     *int64              `gorm:"column:community_id;index" json:"community_id,omitempty"`       // Set for posts made inside a community
+	OrganizationID  *int64              `gorm:"column:organization_id;index" json:"organization_id,omitempty"` // Set for posts only an organization's members may see
+	PageID          *int64              `gorm:"column:page_id;index" json:"page_id,omitempty"`                 // Set for posts published as a page; UserID is the manager who wrote it
+	RepostOfID      *int64              `gorm:"column:repost_of_id;index" json:"repost_of_id,omitempty"`       // Set on reposts, pointing at the original post
+	Content         string              `gorm:"type:text" json:"content"`
+	MediaType       types.MediaType     `gorm:"column:media_type;size:20;index" json:"media_type"` // image, video, text, audio, gif, link, document
+	MediaURL        string              `gorm:"column:media_url;size:255" json:"media_url"`
+	Language        string              `gorm:"column:language;size:10;index" json:"language"` // ISO 639-1, detected from Content; empty when undetermined
+	Region          string              `gorm:"column:region;size:2;index" json:"region"`      // ISO 3166-1 alpha-2, geotagged or the author's; empty when unknown
+	IsPublic        bool                `gorm:"column:is_public;index" json:"is_public"`
+	CloseFriends    bool                `gorm:"column:is_close_friends;default:false;index" json:"is_close_friends"` // Only the author's close friends can see it
+	CommentPolicy   types.CommentPolicy `gorm:"column:comment_policy;size:20" json:"comment_policy"`                 // Who may comment; unset is everyone
+	RepostsDisabled bool                `gorm:"column:reposts_disabled;default:false" json:"reposts_disabled"`       // Others may not repost it
+	EmbedsDisabled  bool                `gorm:"column:embeds_disabled;default:false" json:"embeds_disabled"`         // Not served through oEmbed
+	ViewCount       int64               `gorm:"column:view_count;default:0" json:"view_count"`
+	ShareCount      int64               `gorm:"column:share_count;default:0" json:"share_count"`
+	LikeCount       int64               `gorm:"column:like_count;default:0" json:"like_count"`
+	CommentCount    int64               `gorm:"column:comment_count;default:0" json:"comment_count"`
+
+	// Geotag, in WGS 84 degrees; both or neither are set
+	Latitude  *float64 `gorm:"column:latitude;index:idx_posts_location" json:"latitude,omitempty"`
+	Longitude *float64 `gorm:"column:longitude;index:idx_posts_location" json:"longitude,omitempty"`
 
 	// Relationships
-	User      *User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
-	Comments  []*Comment  `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"comments,omitempty"`
-	Reactions []*Reaction `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"reactions,omitempty"`
+	User         *User         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Community    *Community    `gorm:"foreignKey:CommunityID;constraint:OnDelete:CASCADE" json:"community,omitempty"`
+	Organization *Organization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE" json:"organization,omitempty"`
+	Page         *Page         `gorm:"foreignKey:PageID;constraint:OnDelete:CASCADE" json:"page,omitempty"`
+	RepostOf     *Post         `gorm:"foreignKey:RepostOfID;constraint:OnDelete:CASCADE" json:"repost_of,omitempty"`
+	Comments     []*Comment    `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"comments,omitempty"`
+	Reactions    []*Reaction   `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"reactions,omitempty"`
+}
+
+// BeforeCreate detects the language of the post unless one was given. Its
+// region is the creator's to set, with SetAuthorRegions when not geotagged.
+func (p *Post) BeforeCreate(tx *gorm.DB) error {
+	if p.Language == "" {
+		p.Language = langdetect.Detect(p.Content)
+	}
+	return nil
+}
+
+// SetAuthorRegions places each post that was not geotagged in its author's
+// region, looking the authors up in a single query
+func SetAuthorRegions(tx *gorm.DB, posts ...*Post) error {
+	var authorIDs []int64
+	for _, p := range posts {
+		if p.Region == "" && p.UserID != 0 && !slices.Contains(authorIDs, p.UserID) {
+			authorIDs = append(authorIDs, p.UserID)
+		}
+	}
+	if len(authorIDs) == 0 {
+		return nil
+	}
+
+	var authors []User
+	err := tx.Session(&gorm.Session{NewDB: true}).Model(&User{}).
+		Select("id", "region").
+		Where("id IN ? AND region <> ''", authorIDs).
+		Find(&authors).Error
+	if err != nil {
+		return err
+	}
+	regions := make(map[int64]string, len(authors))
+	for _, a := range authors {
+		regions[a.ID] = a.Region
+	}
+	for _, p := range posts {
+		if p.Region == "" {
+			p.Region = regions[p.UserID]
+		}
+	}
+	return nil
+}
+
+// ValidGeotag reports whether lat and lng are both unset, or both set to a
+// point on Earth
+func ValidGeotag(lat, lng *float64) bool {
+	if lat == nil || lng == nil {
+		return lat == nil && lng == nil
+	}
+	return *lat >= -90 && *lat <= 90 && *lng >= -180 && *lng <= 180
+}
+
+// Slug is a readable URL segment made from the first words of the post, e.g.
+// "hello-from-alice"; it is empty when the post starts with no ASCII words
+func (p *Post) Slug() string {
+	const maxWords, maxLen = 8, 60
+	var words []string
+	for _, field := range strings.FieldsFunc(strings.ToLower(p.Content), func(r rune) bool {
+		return !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+	}) {
+		if len(words) == maxWords || len(strings.Join(append(words, field), "-")) > maxLen {
+			break
+		}
+		words = append(words, field)
+	}
+	return strings.Join(words, "-")
 }
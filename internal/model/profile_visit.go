@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// ProfileVisit records that ViewerID opened ProfileID's profile during a UTC
+// day. Visits are only kept between accounts that both opted in to tracking.
+type ProfileVisit struct {
+	BaseModel
+	TenantID  int64     `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	ProfileID int64     `gorm:"column:profile_id;not null;uniqueIndex:idx_profile_visit_day" json:"profile_id"`
+	ViewerID  int64     `gorm:"column:viewer_id;not null;uniqueIndex:idx_profile_visit_day;index" json:"viewer_id"`
+	Day       time.Time `gorm:"column:day;not null;uniqueIndex:idx_profile_visit_day" json:"day"`
+
+	// Relationships
+	Profile *User `gorm:"foreignKey:ProfileID;constraint:OnDelete:CASCADE" json:"profile,omitempty"`
+	Viewer  *User `gorm:"foreignKey:ViewerID;constraint:OnDelete:CASCADE" json:"viewer,omitempty"`
+}
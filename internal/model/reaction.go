@@ -7,6 +7,7 @@ import (
 
 type Reaction struct {
 	BaseModel
+	TenantID  int64              `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
 	UserID    int64              `gorm:"column:user_id;not null;index:idx_user_target" json:"user_id"`
 	PostID    *int64             `gorm:"column:post_id;index:idx_user_target" json:"post_id"`
 	CommentID *int64             `gorm:"column:comment_id;index:idx_user_target" json:"comment_id"`
@@ -0,0 +1,24 @@
+package model
+
+import "gorm.io/gorm"
+
+// Restriction limits RestrictedID's reach into UserID's account without a
+// block: their comments on UserID's posts wait for approval and their direct
+// messages land in message requests
+type Restriction struct {
+	BaseModel
+	TenantID     int64 `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID       int64 `gorm:"column:user_id;not null;index:idx_restriction_pair,unique" json:"user_id"`
+	RestrictedID int64 `gorm:"column:restricted_id;not null;index:idx_restriction_pair,unique;index" json:"restricted_id"`
+
+	// Relationships
+	User       *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Restricted *User `gorm:"foreignKey:RestrictedID;constraint:OnDelete:CASCADE" json:"restricted,omitempty"`
+}
+
+func (r *Restriction) BeforeCreate(tx *gorm.DB) error {
+	if r.UserID == r.RestrictedID {
+		return gorm.ErrInvalidData
+	}
+	return nil
+}
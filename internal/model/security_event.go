@@ -0,0 +1,20 @@
+package model
+
+import "github.com/ilhamosaurus/sns-platform/pkg/types"
+
+// SecurityEvent is one entry in a user's login and account security history
+type SecurityEvent struct {
+	BaseModel
+	TenantID   int64                   `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	UserID     int64                   `gorm:"column:user_id;not null;index" json:"user_id"`
+	Type       types.SecurityEventType `gorm:"column:type;size:30;not null;index" json:"type"` // login, login_failed, password_changed, 2fa_enabled...
+	IP         string                  `gorm:"column:ip;size:45" json:"ip"`
+	UserAgent  string                  `gorm:"column:user_agent;size:512" json:"user_agent"`
+	Device     string                  `gorm:"column:device;size:100" json:"device"`               // Human-readable, e.g. "Chrome on macOS"
+	DeviceHash string                  `gorm:"column:device_hash;size:64;index" json:"-"`          // Fingerprint used to spot new devices
+	Location   string                  `gorm:"column:location;size:100" json:"location,omitempty"` // Best-effort, from edge proxy headers
+	NewDevice  bool                    `gorm:"column:new_device;default:false" json:"new_device"`
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
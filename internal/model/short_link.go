@@ -0,0 +1,19 @@
+package model
+
+import "github.com/ilhamosaurus/sns-platform/pkg/types"
+
+// ShortLink is a compact share code for a post (/p/{code}) or profile
+// (/u/{code}). Each user gets their own code per target, so clicks are
+// attributed to whoever shared the link.
+type ShortLink struct {
+	BaseModel
+	TenantID   int64                 `gorm:"column:tenant_id;not null;default:0;index" json:"-"`
+	Code       string                `gorm:"column:code;size:16;not null;uniqueIndex" json:"code"`
+	TargetType types.ShortLinkTarget `gorm:"column:target_type;size:20;not null;index:idx_short_link_sharer_target,unique" json:"target_type"`
+	TargetID   int64                 `gorm:"column:target_id;not null;index:idx_short_link_sharer_target,unique" json:"target_id"`
+	CreatedBy  int64                 `gorm:"column:created_by;not null;index:idx_short_link_sharer_target,unique" json:"created_by"`
+	ClickCount int64                 `gorm:"column:click_count;default:0" json:"click_count"`
+
+	// Relationships
+	Creator *User `gorm:"foreignKey:CreatedBy;constraint:OnDelete:CASCADE" json:"creator,omitempty"`
+}
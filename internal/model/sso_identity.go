@@ -0,0 +1,21 @@
+package model
+
+// SSOIdentity links a user to their account at a single sign-on identity
+// provider. Provider is the name the provider is configured under and
+// Subject the provider's stable identifier for the user (the OIDC sub claim
+// or the SAML NameID).
+type SSOIdentity struct {
+	BaseModel
+	TenantID int64  `gorm:"column:tenant_id;not null;default:0;uniqueIndex:idx_sso_identities_provider_subject" json:"-"`
+	UserID   int64  `gorm:"column:user_id;not null;index" json:"user_id"`
+	Provider string `gorm:"column:provider;size:50;not null;uniqueIndex:idx_sso_identities_provider_subject" json:"provider"`
+	Subject  string `gorm:"column:subject;size:255;not null;uniqueIndex:idx_sso_identities_provider_subject" json:"-"`
+	Email    string `gorm:"column:email;size:100" json:"email"` // As last asserted by the provider
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+func (SSOIdentity) TableName() string {
+	return "sso_identities"
+}
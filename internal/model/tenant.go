@@ -0,0 +1,10 @@
+package model
+
+// Tenant is an isolated community hosted on a shared deployment
+type Tenant struct {
+	BaseModel
+	Slug     string  `gorm:"column:slug;uniqueIndex;size:50;not null" json:"slug"`
+	Name     string  `gorm:"column:name;size:100;not null" json:"name"`
+	Domain   *string `gorm:"column:domain;uniqueIndex;size:255" json:"domain"` // Custom domain, resolved from the Host header
+	IsActive bool    `gorm:"column:is_active;default:true;index" json:"is_active"`
+}
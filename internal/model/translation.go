@@ -0,0 +1,13 @@
+package model
+
+// Translation caches a machine translation of a text, keyed by the SHA-256 of
+// the text and the target language, so edited posts and comments are
+// translated afresh and identical texts share one translation
+type Translation struct {
+	BaseModel
+	ContentHash    string `gorm:"column:content_hash;size:64;not null;index:idx_translation_key,unique" json:"-"`
+	TargetLanguage string `gorm:"column:target_language;size:10;not null;index:idx_translation_key,unique" json:"target_language"`
+	SourceLanguage string `gorm:"column:source_language;size:10" json:"source_language"`
+	Text           string `gorm:"column:text;type:text;not null" json:"text"`
+	Provider       string `gorm:"column:provider;size:20;not null" json:"provider"`
+}
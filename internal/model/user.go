@@ -1,18 +1,93 @@
 package model
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
 type User struct {
 	BaseModel
-	Username      string `gorm:"column:username;uniqueIndex;size:50;not null" json:"username"`
-	Email         string `gorm:"column:email;uniqueIndex;size:100;not null" json:"email"`
-	PasswordHash  string `gorm:"column:password;size:255;not null" json:"-"`
-	FullName      string `gorm:"column:full_name;size:100" json:"full_name"`
-	Bio           string `gorm:"column:bio;type:text" json:"bio"`
-	AvatarURL     string `gorm:"column:avatar_url;size:255" json:"avatar_url"`
-	IsVerified    bool   `gorm:"column:is_verified;default:false;index" json:"is_verified"`
-	IsPrivate     bool   `gorm:"column:is_private;default:false" json:"is_private"`
-	FollwingCount int64  `gorm:"column:following_count;default:0" json:"following_count"`
-	FollowerCount int64  `gorm:"column:follower_count;default:0" json:"follower_count"`
-	PostCount     int64  `gorm:"column:post_count;default:0" json:"post_count"`
+	TenantID      int64          `gorm:"column:tenant_id;not null;default:0;uniqueIndex:idx_users_tenant_username;uniqueIndex:idx_users_tenant_email" json:"-"`
+	Username      string         `gorm:"column:username;uniqueIndex:idx_users_tenant_username;size:50;not null" json:"username"`
+	Email         string         `gorm:"column:email;uniqueIndex:idx_users_tenant_email;size:100;not null" json:"email"`
+	PasswordHash  string         `gorm:"column:password;size:255;not null" json:"-"`
+	FullName      string         `gorm:"column:full_name;size:100" json:"full_name"`
+	Bio           string         `gorm:"column:bio;type:text" json:"bio"`
+	AvatarURL     string         `gorm:"column:avatar_url;size:255" json:"avatar_url"`
+	IsVerified    bool           `gorm:"column:is_verified;default:false;index" json:"is_verified"`
+	IsPrivate     bool           `gorm:"column:is_private;default:false" json:"is_private"`
+	FollwingCount int64          `gorm:"column:following_count;default:0" json:"following_count"`
+	FollowerCount int64          `gorm:"column:follower_count;default:0" json:"follower_count"`
+	PostCount     int64          `gorm:"column:post_count;default:0" json:"post_count"`
+	Role          types.UserRole `gorm:"column:role;size:20" json:"role"`
+	Language      string         `gorm:"column:language;size:10;default:en" json:"language"` // Used to render notifications
+
+	// ContentLanguages lists the ISO 639-1 codes, comma separated, of the
+	// languages the user wants to discover posts in; empty means all
+	ContentLanguages string `gorm:"column:content_languages;size:100" json:"-"`
+
+	// Region is the ISO 3166-1 alpha-2 country the user set on their
+	// profile; their posts are placed there unless geotagged elsewhere
+	Region string `gorm:"column:region;size:2" json:"-"`
+
+	// Privacy
+	ProfileVisitsEnabled bool           `gorm:"column:profile_visits_enabled;default:false" json:"profile_visits_enabled"` // Opted in to who-viewed-my-profile
+	DMPolicy             types.DMPolicy `gorm:"column:dm_policy;size:20" json:"dm_policy"`                                 // Who can start a conversation; unset is everyone
+
+	// Contact discovery finds users who opted in by the SHA-256 of their
+	// email or phone, as HashContact computes it, in someone's address book.
+	// The phone number, in E.164, is set once a code texted to it is entered;
+	// numbers set before verification existed have no PhoneVerifiedAt.
+	Phone               string     `gorm:"column:phone;size:20" json:"-"`
+	PhoneVerifiedAt     *time.Time `gorm:"column:phone_verified_at" json:"-"`
+	DiscoverableByEmail bool       `gorm:"column:discoverable_by_email;default:false" json:"discoverable_by_email"`
+	DiscoverableByPhone bool       `gorm:"column:discoverable_by_phone;default:false" json:"discoverable_by_phone"`
+	EmailHash           string     `gorm:"column:email_hash;size:64;index" json:"-"`
+	PhoneHash           string     `gorm:"column:phone_hash;size:64;index" json:"-"`
+
+	// TwoFactorSMS asks sign-ins for a code texted to the verified phone
+	TwoFactorSMS bool `gorm:"column:two_factor_sms;default:false" json:"-"`
+
+	// Quiet hours hold back non-urgent pushes and emails daily from start to
+	// end ("15:04"), read in TimeZone
+	QuietHoursEnabled bool   `gorm:"column:quiet_hours_enabled;default:false" json:"-"`
+	QuietHoursStart   string `gorm:"column:quiet_hours_start;size:5" json:"-"`
+	QuietHoursEnd     string `gorm:"column:quiet_hours_end;size:5" json:"-"`
+	TimeZone          string `gorm:"column:time_zone;size:64;default:UTC" json:"-"` // IANA name
+
+	// Moderation
+	BannedAt              *time.Time `gorm:"column:banned_at;index" json:"-"`
+	BanReason             string     `gorm:"column:ban_reason;size:255" json:"-"`
+	PasswordResetRequired bool       `gorm:"column:password_reset_required;default:false" json:"-"` // Set when an admin resets the password
+
+	// Deactivation hides the account, its posts and its comments until the
+	// user reactivates or logs in again; nothing is deleted
+	DeactivatedAt *time.Time `gorm:"column:deactivated_at;index" json:"-"`
+
+	// Provisioning: accounts an identity provider manages over SCIM carry
+	// its ID for them. A suspended account is also deactivated, and only the
+	// provider can lift the suspension; logging in does not.
+	ExternalID  string     `gorm:"column:external_id;size:255;index" json:"-"`
+	SuspendedAt *time.Time `gorm:"column:suspended_at" json:"-"`
+
+	// Onboarding: the first follow and first post are stamped once, so
+	// unfollowing or deleting later does not reopen them; the avatar and bio
+	// steps follow the profile. OnboardedAt is set when every step is done or
+	// the user dismisses the checklist.
+	FirstFollowAt *time.Time `gorm:"column:first_follow_at" json:"-"`
+	FirstPostAt   *time.Time `gorm:"column:first_post_at" json:"-"`
+	OnboardedAt   *time.Time `gorm:"column:onboarded_at" json:"-"`
+
+	// Invite graph: who invited the user, and with which invite, when they
+	// signed up with one
+	InvitedByID *int64 `gorm:"column:invited_by_id;index" json:"-"`
+	InviteID    *int64 `gorm:"column:invite_id" json:"-"`
 
 	// Relationships
 	Posts            []*Post         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"posts,omitempty"`
@@ -24,3 +99,93 @@ type User struct {
 	ReceivedMessages []*Message      `gorm:"foreignKey:ReceiverID;constraint:OnDelete:CASCADE" json:"received_messages,omitempty"`
 	Notifications    []*Notification `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"notifications,omitempty"`
 }
+
+// TombstonePrefix starts the username of every deleted user
+const TombstonePrefix = "~deleted-"
+
+// Tombstone returns the username and email a deleted user is renamed to, so
+// their own are free to register again. Neither can be signed up with:
+// usernames never contain "~" and the .invalid domain does not resolve.
+func Tombstone(id int64) (username, email string) {
+	return fmt.Sprintf("%s%d", TombstonePrefix, id), fmt.Sprintf("deleted-%d@users.invalid", id)
+}
+
+// IsBanned reports whether an admin has banned the user
+func (u *User) IsBanned() bool {
+	return u.BannedAt != nil
+}
+
+// IsSuspended reports whether the identity provider provisioning the user
+// has suspended them
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// PreferredLanguages returns the languages the user wants to discover posts in, none for all
+func (u *User) PreferredLanguages() []string {
+	if u.ContentLanguages == "" {
+		return nil
+	}
+	return strings.Split(u.ContentLanguages, ",")
+}
+
+// ParseRegion normalizes an ISO 3166-1 alpha-2 country code, such as "id"
+// to "ID", reporting whether code has that shape
+func ParseRegion(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return "", false
+	}
+	return code, true
+}
+
+// HasVerifiedPhone reports whether the user's phone number was verified by
+// a texted code
+func (u *User) HasVerifiedPhone() bool {
+	return u.Phone != "" && u.PhoneVerifiedAt != nil
+}
+
+// IsDeactivated reports whether the user has deactivated their account
+func (u *User) IsDeactivated() bool {
+	return u.DeactivatedAt != nil
+}
+
+// BeforeCreate gives new users the regular role unless another was chosen
+// and hashes their contact identifiers
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.Role == types.UserRoleUnknown {
+		u.Role = types.UserRoleUser
+	}
+	u.EmailHash = HashContact(u.Email)
+	u.PhoneHash = HashContact(u.Phone)
+	return nil
+}
+
+// BeforeUpdate rehashes the email and phone when an update by column map
+// changes them. UpdateColumns skips hooks, so callers writing either column
+// that way write its hash too.
+func (u *User) BeforeUpdate(tx *gorm.DB) error {
+	updates, ok := tx.Statement.Dest.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if email, ok := updates["email"].(string); ok {
+		tx.Statement.SetColumn("email_hash", HashContact(email))
+	}
+	if phone, ok := updates["phone"].(string); ok {
+		tx.Statement.SetColumn("phone_hash", HashContact(phone))
+	}
+	return nil
+}
+
+// HashContact returns the hex SHA-256 of a normalized email (trimmed and
+// lowercased) or E.164 phone number, as clients upload them for contact
+// discovery; nothing hashes to nothing
+func HashContact(identifier string) string {
+	identifier = strings.ToLower(strings.TrimSpace(identifier))
+	if identifier == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(identifier))
+	return hex.EncodeToString(sum[:])
+}
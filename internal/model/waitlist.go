@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// WaitlistEntry is a sign-up held back while the waitlist feature is on.
+// Entries are activated in the order they joined; an activated email may
+// then create its account.
+type WaitlistEntry struct {
+	BaseModel
+	TenantID    int64      `gorm:"column:tenant_id;not null;default:0;uniqueIndex:idx_waitlist_tenant_email" json:"-"`
+	Email       string     `gorm:"column:email;size:100;not null;uniqueIndex:idx_waitlist_tenant_email" json:"email"`
+	ActivatedAt *time.Time `gorm:"column:activated_at;index" json:"activated_at,omitempty"`
+}
+
+func (WaitlistEntry) TableName() string {
+	return "waitlist"
+}
+
+// Activated reports whether the entry's turn has come
+func (e *WaitlistEntry) Activated() bool {
+	return e.ActivatedAt != nil
+}
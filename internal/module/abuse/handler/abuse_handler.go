@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/velocity"
+)
+
+type AdminHandler struct {
+	tracker *velocity.Tracker
+}
+
+func NewAdminHandler(tracker *velocity.Tracker) *AdminHandler {
+	return &AdminHandler{tracker: tracker}
+}
+
+// Register mounts the abuse signal routes; callers are expected to wrap mux with admin auth
+func (h *AdminHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/users/{id}/velocity", h.Velocity)
+}
+
+// Velocity returns a user's posts, follows and distinct message recipients
+// within the rolling window, each against its threshold
+func (h *AdminHandler) Velocity(w http.ResponseWriter, r *http.Request) {
+	userID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	rates, err := h.tracker.Rates(r.Context(), userID)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	breached := false
+	for _, rate := range rates {
+		breached = breached || rate.Breached
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{
+		"user_id":  userID,
+		"window":   h.tracker.Window().String(),
+		"rates":    rates,
+		"breached": breached,
+	})
+}
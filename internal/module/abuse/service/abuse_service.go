@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/metrics"
+	"github.com/ilhamosaurus/sns-platform/pkg/velocity"
+)
+
+// Track feeds published posts, follows and direct messages into tracker, as
+// the post, follow and message services publish them, and alerts on every
+// threshold a user goes over: the breach is logged,
+// counted in the abuse_velocity_breaches_total metric and published as a
+// VelocityBreached event for whatever acts on abuse signals
+func Track(bus eventbus.Bus, tracker *velocity.Tracker) {
+	eventbus.On(bus, func(ctx context.Context, e event.PostCreated) error {
+		record(ctx, tracker, e.AuthorID, velocity.MetricPosts, e.PostID)
+		return nil
+	})
+	eventbus.On(bus, func(ctx context.Context, e event.UserFollowed) error {
+		record(ctx, tracker, e.FollowerID, velocity.MetricFollows, e.FollowingID)
+		return nil
+	})
+	eventbus.On(bus, func(ctx context.Context, e event.MessageSent) error {
+		record(ctx, tracker, e.SenderID, velocity.MetricMessageRecipients, e.ReceiverID)
+		return nil
+	})
+
+	tracker.OnBreach(velocity.LogBreach)
+	tracker.OnBreach(func(ctx context.Context, breach velocity.Breach) {
+		metrics.VelocityBreaches.WithLabelValues(string(breach.Metric)).Inc()
+		if err := bus.Publish(ctx, event.VelocityBreached{
+			UserID:     breach.UserID,
+			Metric:     string(breach.Metric),
+			Count:      breach.Count,
+			Threshold:  breach.Threshold,
+			Window:     breach.Window,
+			OccurredAt: time.Now().UTC(),
+		}); err != nil {
+			slog.WarnContext(ctx, "failed to publish velocity breach", slog.Int64("user_id", breach.UserID), slog.Any("error", err))
+		}
+	})
+}
+
+// record counts an action; the action itself already happened, so a failure
+// is only logged
+func record(ctx context.Context, tracker *velocity.Tracker, userID int64, metric velocity.Metric, item int64) {
+	if err := tracker.Record(ctx, userID, metric, item); err != nil {
+		slog.WarnContext(ctx, "failed to record velocity", slog.Int64("user_id", userID), slog.String("metric", string(metric)), slog.Any("error", err))
+	}
+}
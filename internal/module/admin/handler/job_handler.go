@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/jobs"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type JobHandler struct {
+	queue *jobs.Queue
+	audit auditsvc.AuditService
+}
+
+func NewJobHandler(queue *jobs.Queue, audit auditsvc.AuditService) *JobHandler {
+	return &JobHandler{queue: queue, audit: audit}
+}
+
+// Register mounts the job admin routes; callers are expected to wrap mux with admin auth
+func (h *JobHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/jobs/stats", h.Stats)
+	mux.HandleFunc("GET /admin/jobs/failed", h.Failed)
+	mux.HandleFunc("GET /admin/jobs/dead-letters", h.DeadLetters)
+	mux.HandleFunc("GET /admin/jobs/dead-letters/{id}", h.DeadLetter)
+	mux.HandleFunc("POST /admin/jobs/dead-letters/{id}/requeue", h.Requeue)
+	mux.HandleFunc("DELETE /admin/jobs/dead-letters/{id}", h.Discard)
+}
+
+// Stats returns queue depth per queue and status
+func (h *JobHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.queue.Stats(r.Context())
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"queues": stats})
+}
+
+// Failed lists the most recent permanently failed jobs
+func (h *JobHandler) Failed(w http.ResponseWriter, r *http.Request) {
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	failed, err := h.queue.ListFailed(r.Context(), limit)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"jobs": failed})
+}
+
+// DeadLetters lists dead-lettered jobs and events, optionally filtered by ?source=job|event
+func (h *JobHandler) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	source := types.StringToDeadLetterSource(r.URL.Query().Get("source"))
+	letters, err := h.queue.ListDeadLetters(r.Context(), source, limit)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"dead_letters": letters})
+}
+
+// DeadLetter returns a single dead letter including its payload and error
+func (h *JobHandler) DeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid dead letter id")
+		return
+	}
+	dl, err := h.queue.GetDeadLetter(r.Context(), id)
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, dl)
+}
+
+// Requeue turns a job dead letter back into a pending job
+func (h *JobHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid dead letter id")
+		return
+	}
+	job, err := h.queue.Requeue(r.Context(), id)
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+	h.audit.Record(r.Context(), &model.AuditLog{
+		Action:     model.AuditAdminRequeueJob,
+		TargetType: model.AuditTargetDeadLetter,
+		TargetID:   id,
+		After:      map[string]any{"job_id": job.ID, "type": job.Type, "queue": job.Queue},
+	})
+	httpx.JSON(w, http.StatusOK, map[string]any{"job": job})
+}
+
+// Discard permanently removes a dead letter
+func (h *JobHandler) Discard(w http.ResponseWriter, r *http.Request) {
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid dead letter id")
+		return
+	}
+	dl, err := h.queue.GetDeadLetter(r.Context(), id)
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+	if err := h.queue.Discard(r.Context(), id); err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+	h.audit.Record(r.Context(), &model.AuditLog{
+		Action:     model.AuditAdminDiscardJob,
+		TargetType: model.AuditTargetDeadLetter,
+		TargetID:   id,
+		Before:     map[string]any{"source": dl.Source.String(), "queue": dl.Queue, "type": dl.Type, "error": dl.Error},
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeDeadLetterError(w http.ResponseWriter, err error) {
+	httpx.Fail(w, err)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/maintenance"
+)
+
+// MaxMaintenanceMessageLength caps the notice shown while writes are refused
+const MaxMaintenanceMessageLength = 500
+
+type MaintenanceHandler struct {
+	mode  *maintenance.Mode
+	audit auditsvc.AuditService
+}
+
+func NewMaintenanceHandler(mode *maintenance.Mode, audit auditsvc.AuditService) *MaintenanceHandler {
+	return &MaintenanceHandler{mode: mode, audit: audit}
+}
+
+// Register mounts the maintenance routes; callers are expected to wrap mux
+// with admin auth and exempt the path from the maintenance middleware
+func (h *MaintenanceHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/maintenance", h.Get)
+	mux.HandleFunc("PUT /admin/maintenance", h.Set)
+}
+
+// maintenanceState is a maintenance.State as the API shows it
+type maintenanceState struct {
+	Enabled    bool       `json:"enabled"`
+	Message    string     `json:"message,omitempty"`
+	RetryAfter int        `json:"retry_after,omitempty"` // Seconds
+	Since      *time.Time `json:"since,omitempty"`
+}
+
+func newMaintenanceState(state maintenance.State) maintenanceState {
+	return maintenanceState{
+		Enabled:    state.Enabled,
+		Message:    state.Message,
+		RetryAfter: int(state.RetryAfter / time.Second),
+		Since:      state.Since,
+	}
+}
+
+// Get returns whether the platform is in maintenance
+func (h *MaintenanceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	httpx.JSON(w, http.StatusOK, newMaintenanceState(h.mode.State()))
+}
+
+// Set switches maintenance on or off on every instance
+func (h *MaintenanceHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var body maintenanceState
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(body.Message) > MaxMaintenanceMessageLength {
+		httpx.Error(w, http.StatusBadRequest, "message is too long")
+		return
+	}
+	if body.RetryAfter < 0 {
+		httpx.Error(w, http.StatusBadRequest, "retry_after must not be negative")
+		return
+	}
+
+	before := h.mode.State()
+	record := func(after maintenance.State) {
+		h.audit.Record(r.Context(), &model.AuditLog{
+			Action:     model.AuditAdminSetMaintenance,
+			TargetType: model.AuditTargetMaintenance,
+			Before:     map[string]any{"enabled": before.Enabled, "message": before.Message},
+			After:      map[string]any{"enabled": after.Enabled, "message": after.Message},
+		})
+	}
+	next := maintenance.State{Enabled: body.Enabled, Message: body.Message, RetryAfter: time.Duration(body.RetryAfter) * time.Second}
+	// The entry is written while the database surely takes writes: before
+	// maintenance starts and after it ends
+	if next.Enabled {
+		record(next)
+	}
+	state, err := h.mode.Set(r.Context(), next)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	if !state.Enabled {
+		record(state)
+	}
+	httpx.JSON(w, http.StatusOK, newMaintenanceState(state))
+}
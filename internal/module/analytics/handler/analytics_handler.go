@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/analytics/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+)
+
+type AnalyticsHandler struct {
+	service service.AnalyticsService
+	bus     eventbus.Bus
+}
+
+func NewAnalyticsHandler(svc service.AnalyticsService, bus eventbus.Bus) *AnalyticsHandler {
+	return &AnalyticsHandler{service: svc, bus: bus}
+}
+
+// Register mounts the event, impression and insights routes; they expect an authenticated user in the request context
+func (h *AnalyticsHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /events", h.ReportEvents)
+	mux.HandleFunc("POST /me/impressions", h.RecordImpressions)
+	mux.HandleFunc("GET /me/insights", h.AccountInsights)
+	mux.HandleFunc("GET /me/insights/growth", h.GrowthHistory)
+	mux.HandleFunc("GET /posts/{id}/insights", h.PostInsights)
+}
+
+// ReportEvents accepts a batch of impressions, profile views and link clicks
+// and queues it on the event bus; the events are stored asynchronously
+func (h *AnalyticsHandler) ReportEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		Events []event.Engagement `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(body.Events) > service.MaxEventBatch {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("at most %d events can be reported at once", service.MaxEventBatch))
+		return
+	}
+	for i, e := range body.Events {
+		if err := checkEngagement(e); err != nil {
+			httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("event %d: %v", i, err))
+			return
+		}
+	}
+
+	if len(body.Events) > 0 {
+		reported := event.EngagementReported{ViewerID: userID, Engagements: body.Events}
+		if id, ok := tenant.ID(r.Context()); ok {
+			reported.TenantID = &id
+		}
+		if err := h.bus.Publish(r.Context(), reported); err != nil {
+			httpx.Error(w, http.StatusServiceUnavailable, "failed to queue events")
+			return
+		}
+	}
+	httpx.JSON(w, http.StatusAccepted, map[string]any{"accepted": len(body.Events)})
+}
+
+// checkEngagement reports what is missing from a client-reported engagement
+func checkEngagement(e event.Engagement) error {
+	switch e.Type {
+	case event.EngagementImpression:
+		if e.PostID <= 0 {
+			return errors.New("post_id is required")
+		}
+		if err := checkSource(e.Source); err != nil {
+			return err
+		}
+	case event.EngagementProfileView:
+		if e.UserID <= 0 {
+			return errors.New("user_id is required")
+		}
+	case event.EngagementLinkClick:
+		if e.PostID <= 0 {
+			return errors.New("post_id is required")
+		}
+		if e.URL == "" || len(e.URL) > service.MaxLinkURLLength {
+			return fmt.Errorf("url must be 1 to %d characters", service.MaxLinkURLLength)
+		}
+		if u, err := url.Parse(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.New("url must be an http or https link")
+		}
+		if err := checkSource(e.Source); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown event type %q", e.Type)
+	}
+	return nil
+}
+
+// checkSource accepts an empty source or one of model.ViewSources
+func checkSource(source string) error {
+	if source != "" && !slices.Contains(model.ViewSources, source) {
+		return fmt.Errorf("source must be one of %s", strings.Join(model.ViewSources, ", "))
+	}
+	return nil
+}
+
+// RecordImpressions takes the IDs of the posts the client has shown the caller
+// and, optionally, the surface it showed them on
+func (h *AnalyticsHandler) RecordImpressions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		PostIDs []int64 `json:"post_ids"`
+		Source  string  `json:"source"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(body.PostIDs) > service.MaxImpressionBatch {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("at most %d posts can be reported at once", service.MaxImpressionBatch))
+		return
+	}
+	if err := checkSource(body.Source); err != nil {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	n, err := h.service.RecordImpressions(r.Context(), userID, body.PostIDs, body.Source)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusAccepted, map[string]any{"recorded": n})
+}
+
+// PostInsights returns the daily performance of one of the caller's posts
+func (h *AnalyticsHandler) PostInsights(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	postID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+
+	insights, err := h.service.PostInsights(r.Context(), userID, postID, httpx.QueryInt(r, "days", 30))
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, insights)
+}
+
+// AccountInsights returns the caller's follower growth and reach per day
+func (h *AnalyticsHandler) AccountInsights(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	insights, err := h.service.AccountInsights(r.Context(), userID, httpx.QueryInt(r, "days", 30))
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, insights)
+}
+
+// GrowthHistory returns the caller's daily follower, following and post counts
+func (h *AnalyticsHandler) GrowthHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	points, err := h.service.GrowthHistory(r.Context(), userID, httpx.QueryInt(r, "days", 90))
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"points": points})
+}
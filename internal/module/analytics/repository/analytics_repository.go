@@ -0,0 +1,463 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"gorm.io/gorm"
+)
+
+type AnalyticsRepository interface {
+	RecordImpressions(ctx context.Context, viewerID int64, postIDs []int64, source string, dedupeSince time.Time) (int, error)
+	RecordProfileViews(ctx context.Context, viewerID int64, profileIDs []int64, dedupeSince time.Time) (int, error)
+	RecordLinkClicks(ctx context.Context, viewerID int64, clicks []*model.LinkClick) (int, error)
+	RollupDay(ctx context.Context, day time.Time) error
+	PostStats(ctx context.Context, postID int64, from, to time.Time) ([]*model.PostDailyStat, error)
+	PostSourceStats(ctx context.Context, postID int64, from, to time.Time) ([]*model.PostSourceDailyStat, error)
+	AccountStats(ctx context.Context, userID int64, from, to time.Time) ([]*model.AccountDailyStat, error)
+	SnapshotUserStats(ctx context.Context, day time.Time) (int64, error)
+	UserStatsSeries(ctx context.Context, userID int64, from, to time.Time) ([]*model.UserStatsDaily, error)
+}
+
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+// RecordImpressions stores an impression of each post for the viewer,
+// attributed to source. Posts that are missing, the viewer's own, or already
+// seen by the viewer since dedupeSince, through any source, are skipped; the
+// number stored is returned.
+func (r *analyticsRepository) RecordImpressions(ctx context.Context, viewerID int64, postIDs []int64, source string, dedupeSince time.Time) (int, error) {
+	if len(postIDs) == 0 {
+		return 0, nil
+	}
+	db := r.db.WithContext(ctx)
+
+	var visible []int64
+	err := db.Model(&model.Post{}).
+		Where("id IN ? AND user_id <> ? AND deleted_at IS NULL", postIDs, viewerID).
+		Pluck("id", &visible).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load impressed posts: %w", err)
+	}
+	var seen []int64
+	err = db.Model(&model.PostImpression{}).
+		Where("viewer_id = ? AND post_id IN ? AND created_at >= ? AND deleted_at IS NULL", viewerID, visible, dedupeSince).
+		Pluck("post_id", &seen).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load recent impressions: %w", err)
+	}
+
+	skip := make(map[int64]bool, len(seen))
+	for _, id := range seen {
+		skip[id] = true
+	}
+	impressions := make([]*model.PostImpression, 0, len(visible))
+	for _, id := range visible {
+		if !skip[id] {
+			skip[id] = true
+			impressions = append(impressions, &model.PostImpression{PostID: id, ViewerID: viewerID, Source: source})
+		}
+	}
+	if err := pkgdb.CreateInBatches(ctx, r.db, impressions, 0); err != nil {
+		return 0, fmt.Errorf("failed to record impressions: %w", err)
+	}
+	return len(impressions), nil
+}
+
+// RecordProfileViews stores a view of each profile by the viewer. Profiles
+// that are missing, the viewer's own, or already viewed by the viewer since
+// dedupeSince are skipped; the number stored is returned.
+func (r *analyticsRepository) RecordProfileViews(ctx context.Context, viewerID int64, profileIDs []int64, dedupeSince time.Time) (int, error) {
+	if len(profileIDs) == 0 {
+		return 0, nil
+	}
+	db := r.db.WithContext(ctx)
+
+	var visible []int64
+	err := db.Model(&model.User{}).
+		Where("id IN ? AND id <> ? AND deleted_at IS NULL", profileIDs, viewerID).
+		Pluck("id", &visible).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load viewed profiles: %w", err)
+	}
+	var seen []int64
+	err = db.Model(&model.ProfileView{}).
+		Where("viewer_id = ? AND profile_id IN ? AND created_at >= ? AND deleted_at IS NULL", viewerID, visible, dedupeSince).
+		Pluck("profile_id", &seen).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load recent profile views: %w", err)
+	}
+
+	skip := make(map[int64]bool, len(seen))
+	for _, id := range seen {
+		skip[id] = true
+	}
+	views := make([]*model.ProfileView, 0, len(visible))
+	for _, id := range visible {
+		if !skip[id] {
+			skip[id] = true
+			views = append(views, &model.ProfileView{ProfileID: id, ViewerID: viewerID})
+		}
+	}
+	if err := pkgdb.CreateInBatches(ctx, r.db, views, 0); err != nil {
+		return 0, fmt.Errorf("failed to record profile views: %w", err)
+	}
+	return len(views), nil
+}
+
+// RecordLinkClicks stores the viewer's clicks, skipping those on missing posts
+// or the viewer's own; the number stored is returned
+func (r *analyticsRepository) RecordLinkClicks(ctx context.Context, viewerID int64, clicks []*model.LinkClick) (int, error) {
+	if len(clicks) == 0 {
+		return 0, nil
+	}
+	postIDs := make([]int64, len(clicks))
+	for i, click := range clicks {
+		postIDs[i] = click.PostID
+	}
+
+	var visible []int64
+	err := r.db.WithContext(ctx).Model(&model.Post{}).
+		Where("id IN ? AND user_id <> ? AND deleted_at IS NULL", postIDs, viewerID).
+		Pluck("id", &visible).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load clicked posts: %w", err)
+	}
+	keep := make(map[int64]bool, len(visible))
+	for _, id := range visible {
+		keep[id] = true
+	}
+	stored := make([]*model.LinkClick, 0, len(clicks))
+	for _, click := range clicks {
+		if keep[click.PostID] {
+			click.ViewerID = viewerID
+			stored = append(stored, click)
+		}
+	}
+	if err := pkgdb.CreateInBatches(ctx, r.db, stored, 0); err != nil {
+		return 0, fmt.Errorf("failed to record link clicks: %w", err)
+	}
+	return len(stored), nil
+}
+
+type idCount struct {
+	ID int64
+	N  int64
+}
+
+// count runs a grouped (id, n) query and returns it as a map
+func (r *analyticsRepository) count(ctx context.Context, query string, args ...any) (map[int64]int64, error) {
+	var rows []idCount
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ID] = row.N
+	}
+	return counts, nil
+}
+
+// postSource identifies the activity on a post through one surface
+type postSource struct {
+	PostID int64
+	Source string
+}
+
+// countBySource counts the rows of table created in [start, end) per post
+// and source
+func (r *analyticsRepository) countBySource(ctx context.Context, table string, start, end time.Time) (map[postSource]int64, error) {
+	var rows []struct {
+		PostID int64
+		Source string
+		N      int64
+	}
+	err := r.db.WithContext(ctx).Table(table).
+		Select("post_id, source, COUNT(*) AS n").
+		Where("created_at >= ? AND created_at < ? AND deleted_at IS NULL", start, end).
+		Group("post_id, source").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[postSource]int64, len(rows))
+	for _, row := range rows {
+		counts[postSource{row.PostID, row.Source}] = row.N
+	}
+	return counts, nil
+}
+
+// RollupDay recomputes the post and account stats of the UTC day containing
+// day from impressions, link clicks, profile views, reactions, comments and
+// follows, with impressions and link clicks also split by source, replacing
+// any earlier rollup of that day
+func (r *analyticsRepository) RollupDay(ctx context.Context, day time.Time) error {
+	start := day.UTC().Truncate(24 * time.Hour)
+	end := start.Add(24 * time.Hour)
+
+	queries := []struct {
+		name  string
+		query string
+	}{
+		{"impressions", `SELECT post_id AS id, COUNT(*) AS n FROM post_impressions
+			WHERE created_at >= ? AND created_at < ? AND deleted_at IS NULL GROUP BY post_id`},
+		{"reach", `SELECT i.post_id AS id, COUNT(DISTINCT i.viewer_id) AS n FROM post_impressions i
+			WHERE i.created_at >= ? AND i.created_at < ? AND i.deleted_at IS NULL AND NOT EXISTS (
+				SELECT 1 FROM post_impressions e
+				WHERE e.post_id = i.post_id AND e.viewer_id = i.viewer_id AND e.created_at < i.created_at AND e.deleted_at IS NULL)
+			GROUP BY i.post_id`},
+		{"likes", `SELECT post_id AS id, COUNT(*) AS n FROM reactions
+			WHERE post_id IS NOT NULL AND created_at >= ? AND created_at < ? AND deleted_at IS NULL GROUP BY post_id`},
+		{"comments", `SELECT post_id AS id, COUNT(*) AS n FROM comments
+			WHERE created_at >= ? AND created_at < ? AND pending_approval = FALSE AND deleted_at IS NULL GROUP BY post_id`},
+		{"link clicks", `SELECT post_id AS id, COUNT(*) AS n FROM link_clicks
+			WHERE created_at >= ? AND created_at < ? AND deleted_at IS NULL GROUP BY post_id`},
+		{"profile views", `SELECT profile_id AS id, COUNT(*) AS n FROM profile_views
+			WHERE created_at >= ? AND created_at < ? AND deleted_at IS NULL GROUP BY profile_id`},
+		{"account reach", `SELECT p.user_id AS id, COUNT(DISTINCT i.viewer_id) AS n FROM post_impressions i
+			JOIN posts p ON p.id = i.post_id
+			WHERE i.created_at >= ? AND i.created_at < ? AND i.deleted_at IS NULL AND NOT EXISTS (
+				SELECT 1 FROM post_impressions e JOIN posts ep ON ep.id = e.post_id
+				WHERE ep.user_id = p.user_id AND e.viewer_id = i.viewer_id AND e.created_at < i.created_at AND e.deleted_at IS NULL)
+			GROUP BY p.user_id`},
+		// Unfollows soft-delete the follow, so both counts include deleted rows
+		{"followers gained", `SELECT following_id AS id, COUNT(*) AS n FROM follows
+			WHERE created_at >= ? AND created_at < ? GROUP BY following_id`},
+		{"followers lost", `SELECT following_id AS id, COUNT(*) AS n FROM follows
+			WHERE deleted_at >= ? AND deleted_at < ? GROUP BY following_id`},
+	}
+	counts := make(map[string]map[int64]int64, len(queries))
+	for _, q := range queries {
+		c, err := r.count(ctx, q.query, start, end)
+		if err != nil {
+			return fmt.Errorf("failed to count %s: %w", q.name, err)
+		}
+		counts[q.name] = c
+	}
+
+	impressionSources, err := r.countBySource(ctx, "post_impressions", start, end)
+	if err != nil {
+		return fmt.Errorf("failed to count impressions by source: %w", err)
+	}
+	clickSources, err := r.countBySource(ctx, "link_clicks", start, end)
+	if err != nil {
+		return fmt.Errorf("failed to count link clicks by source: %w", err)
+	}
+
+	postIDs := keys(counts["impressions"], counts["likes"], counts["comments"], counts["link clicks"])
+	var posts []*model.Post
+	if len(postIDs) > 0 {
+		err := r.db.WithContext(ctx).Select("id", "tenant_id", "user_id").
+			Where("id IN ? AND deleted_at IS NULL", postIDs).
+			Find(&posts).Error
+		if err != nil {
+			return fmt.Errorf("failed to load posts: %w", err)
+		}
+	}
+
+	accounts := make(map[int64]*model.AccountDailyStat)
+	account := func(userID int64) *model.AccountDailyStat {
+		if accounts[userID] == nil {
+			accounts[userID] = &model.AccountDailyStat{UserID: userID, Day: start}
+		}
+		return accounts[userID]
+	}
+
+	postStats := make([]*model.PostDailyStat, len(posts))
+	for i, post := range posts {
+		stat := &model.PostDailyStat{
+			TenantID:    post.TenantID,
+			PostID:      post.ID,
+			AuthorID:    post.UserID,
+			Day:         start,
+			Impressions: counts["impressions"][post.ID],
+			Reach:       counts["reach"][post.ID],
+			Likes:       counts["likes"][post.ID],
+			Comments:    counts["comments"][post.ID],
+			LinkClicks:  counts["link clicks"][post.ID],
+		}
+		postStats[i] = stat
+
+		a := account(post.UserID)
+		a.Impressions += stat.Impressions
+		a.Engagements += stat.Likes + stat.Comments
+		a.LinkClicks += stat.LinkClicks
+	}
+
+	tenants := make(map[int64]int64, len(posts))
+	for _, post := range posts {
+		tenants[post.ID] = post.TenantID
+	}
+	sources := make(map[postSource]*model.PostSourceDailyStat)
+	source := func(key postSource) *model.PostSourceDailyStat {
+		if sources[key] == nil {
+			sources[key] = &model.PostSourceDailyStat{TenantID: tenants[key.PostID], PostID: key.PostID, Day: start, Source: key.Source}
+		}
+		return sources[key]
+	}
+	for key, n := range impressionSources {
+		if _, ok := tenants[key.PostID]; ok {
+			source(key).Impressions = n
+		}
+	}
+	for key, n := range clickSources {
+		if _, ok := tenants[key.PostID]; ok {
+			source(key).LinkClicks = n
+		}
+	}
+	sourceStats := make([]*model.PostSourceDailyStat, 0, len(sources))
+	for _, stat := range sources {
+		sourceStats = append(sourceStats, stat)
+	}
+
+	for id, n := range counts["account reach"] {
+		account(id).Reach = n
+	}
+	for id, n := range counts["profile views"] {
+		account(id).ProfileViews = n
+	}
+	for id, n := range counts["followers gained"] {
+		account(id).FollowersGained = n
+	}
+	for id, n := range counts["followers lost"] {
+		account(id).FollowersLost = n
+	}
+
+	userIDs := keys(accounts)
+	var users []*model.User
+	if len(userIDs) > 0 {
+		err := r.db.WithContext(ctx).Select("id", "tenant_id").
+			Where("id IN ? AND deleted_at IS NULL", userIDs).
+			Find(&users).Error
+		if err != nil {
+			return fmt.Errorf("failed to load accounts: %w", err)
+		}
+	}
+	accountStats := make([]*model.AccountDailyStat, len(users))
+	for i, user := range users {
+		accountStats[i] = accounts[user.ID]
+		accountStats[i].TenantID = user.TenantID
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("day = ?", start).Delete(&model.PostDailyStat{}).Error; err != nil {
+			return fmt.Errorf("failed to clear post stats: %w", err)
+		}
+		if err := tx.Unscoped().Where("day = ?", start).Delete(&model.PostSourceDailyStat{}).Error; err != nil {
+			return fmt.Errorf("failed to clear post source stats: %w", err)
+		}
+		if err := tx.Unscoped().Where("day = ?", start).Delete(&model.AccountDailyStat{}).Error; err != nil {
+			return fmt.Errorf("failed to clear account stats: %w", err)
+		}
+		if err := pkgdb.CreateInBatches(ctx, tx, postStats, 0); err != nil {
+			return fmt.Errorf("failed to store post stats: %w", err)
+		}
+		if err := pkgdb.CreateInBatches(ctx, tx, sourceStats, 0); err != nil {
+			return fmt.Errorf("failed to store post source stats: %w", err)
+		}
+		if err := pkgdb.CreateInBatches(ctx, tx, accountStats, 0); err != nil {
+			return fmt.Errorf("failed to store account stats: %w", err)
+		}
+		return nil
+	})
+}
+
+func keys[V any](maps ...map[int64]V) []int64 {
+	seen := make(map[int64]bool)
+	var ids []int64
+	for _, m := range maps {
+		for id := range m {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// PostStats returns the post's daily stats for days in [from, to), oldest first
+func (r *analyticsRepository) PostStats(ctx context.Context, postID int64, from, to time.Time) ([]*model.PostDailyStat, error) {
+	var stats []*model.PostDailyStat
+	err := r.db.WithContext(ctx).
+		Where("post_id = ? AND day >= ? AND day < ? AND deleted_at IS NULL", postID, from, to).
+		Order("day").
+		Find(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load post stats: %w", err)
+	}
+	return stats, nil
+}
+
+// PostSourceStats returns the post's daily stats per source for days in
+// [from, to), oldest first
+func (r *analyticsRepository) PostSourceStats(ctx context.Context, postID int64, from, to time.Time) ([]*model.PostSourceDailyStat, error) {
+	var stats []*model.PostSourceDailyStat
+	err := r.db.WithContext(ctx).
+		Where("post_id = ? AND day >= ? AND day < ? AND deleted_at IS NULL", postID, from, to).
+		Order("day, source").
+		Find(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load post source stats: %w", err)
+	}
+	return stats, nil
+}
+
+// AccountStats returns the account's daily stats for days in [from, to), oldest first
+func (r *analyticsRepository) AccountStats(ctx context.Context, userID int64, from, to time.Time) ([]*model.AccountDailyStat, error) {
+	var stats []*model.AccountDailyStat
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND day >= ? AND day < ? AND deleted_at IS NULL", userID, from, to).
+		Order("day").
+		Find(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load account stats: %w", err)
+	}
+	return stats, nil
+}
+
+// SnapshotUserStats copies every user's follower, following and post counts
+// into user_stats_daily for the UTC day containing day, replacing an earlier
+// snapshot of that day, and returns the number of users recorded
+func (r *analyticsRepository) SnapshotUserStats(ctx context.Context, day time.Time) (int64, error) {
+	start := day.UTC().Truncate(24 * time.Hour)
+	now := time.Now().UTC()
+
+	var n int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("day = ?", start).Delete(&model.UserStatsDaily{}).Error; err != nil {
+			return fmt.Errorf("failed to clear user stats: %w", err)
+		}
+		result := tx.Exec(`INSERT INTO user_stats_daily
+			(tenant_id, user_id, day, follower_count, following_count, post_count, created_at, updated_at)
+			SELECT tenant_id, id, ?, follower_count, following_count, post_count, ?, ?
+			FROM users WHERE deleted_at IS NULL`, start, now, now)
+		if result.Error != nil {
+			return fmt.Errorf("failed to snapshot user stats: %w", result.Error)
+		}
+		n = result.RowsAffected
+		return nil
+	})
+	return n, err
+}
+
+// UserStatsSeries returns the user's daily snapshots for days in [from, to), oldest first
+func (r *analyticsRepository) UserStatsSeries(ctx context.Context, userID int64, from, to time.Time) ([]*model.UserStatsDaily, error) {
+	var series []*model.UserStatsDaily
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND day >= ? AND day < ? AND deleted_at IS NULL", userID, from, to).
+		Order("day").
+		Find(&series).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user stats: %w", err)
+	}
+	return series, nil
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/analytics/repository"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+)
+
+// Limits on a single request
+const (
+	MaxImpressionBatch = 100 // Posts reported in one call
+	MaxEventBatch      = 100 // Engagements reported in one call
+	MaxLinkURLLength   = 2048
+	MaxInsightDays     = 90
+	MaxGrowthDays      = 365
+)
+
+// Config tunes impression counting
+type Config struct {
+	DedupeWindow time.Duration // Repeat views of a post or profile by one account within this count once
+}
+
+type AnalyticsService interface {
+	RecordImpressions(ctx context.Context, viewerID int64, postIDs []int64, source string) (int, error)
+	Ingest(ctx context.Context, viewerID int64, engagements []event.Engagement) (int, error)
+	PostInsights(ctx context.Context, viewerID, postID int64, days int) (*dto.PostInsights, error)
+	AccountInsights(ctx context.Context, userID int64, days int) (*dto.AccountInsights, error)
+	Rollup(ctx context.Context, now time.Time) error
+	SnapshotUserStats(ctx context.Context, now time.Time) (int64, error)
+	GrowthHistory(ctx context.Context, userID int64, days int) ([]dto.GrowthPoint, error)
+}
+
+type analyticsService struct {
+	repo     repository.AnalyticsRepository
+	postRepo postrepo.PostRepository
+	userRepo userrepo.UserRepository
+	config   Config
+}
+
+func NewAnalyticsService(repo repository.AnalyticsRepository, postRepo postrepo.PostRepository, userRepo userrepo.UserRepository, config Config) AnalyticsService {
+	if config.DedupeWindow <= 0 {
+		config.DedupeWindow = 30 * time.Minute
+	}
+	return &analyticsService{repo: repo, postRepo: postRepo, userRepo: userRepo, config: config}
+}
+
+// RecordImpressions counts the posts as shown to the viewer on source, one of
+// model.ViewSources or empty when the client does not say
+func (s *analyticsService) RecordImpressions(ctx context.Context, viewerID int64, postIDs []int64, source string) (int, error) {
+	return s.repo.RecordImpressions(ctx, viewerID, postIDs, source, time.Now().UTC().Add(-s.config.DedupeWindow))
+}
+
+// Ingest stores a batch of reported engagements by type and returns how many
+// were kept after deduplication
+func (s *analyticsService) Ingest(ctx context.Context, viewerID int64, engagements []event.Engagement) (int, error) {
+	impressions := make(map[string][]int64) // Post IDs by source
+	var sources []string
+	var profiles []int64
+	var clicks []*model.LinkClick
+	for _, e := range engagements {
+		switch e.Type {
+		case event.EngagementImpression:
+			if _, ok := impressions[e.Source]; !ok {
+				sources = append(sources, e.Source)
+			}
+			impressions[e.Source] = append(impressions[e.Source], e.PostID)
+		case event.EngagementProfileView:
+			profiles = append(profiles, e.UserID)
+		case event.EngagementLinkClick:
+			clicks = append(clicks, &model.LinkClick{PostID: e.PostID, URL: e.URL, Source: e.Source})
+		}
+	}
+
+	// A post reported through several sources counts for the first of them
+	since := time.Now().UTC().Add(-s.config.DedupeWindow)
+	var n int
+	for _, source := range sources {
+		recorded, err := s.repo.RecordImpressions(ctx, viewerID, impressions[source], source, since)
+		n += recorded
+		if err != nil {
+			return n, err
+		}
+	}
+	views, err := s.repo.RecordProfileViews(ctx, viewerID, profiles, since)
+	if err != nil {
+		return n, err
+	}
+	clicked, err := s.repo.RecordLinkClicks(ctx, viewerID, clicks)
+	if err != nil {
+		return n + views, err
+	}
+	return n + views + clicked, nil
+}
+
+// IngestReportedEngagement stores engagement reported through the events
+// endpoint as it comes off the bus
+func IngestReportedEngagement(bus eventbus.Bus, svc AnalyticsService) {
+	eventbus.On(bus, func(ctx context.Context, e event.EngagementReported) error {
+		if e.TenantID != nil {
+			ctx = tenant.WithID(ctx, *e.TenantID)
+		}
+		_, err := svc.Ingest(ctx, e.ViewerID, e.Engagements)
+		return err
+	})
+}
+
+// Rollup aggregates yesterday and today. Yesterday is redone so activity
+// recorded after its last run before midnight is included.
+func (s *analyticsService) Rollup(ctx context.Context, now time.Time) error {
+	for _, day := range []time.Time{now.Add(-24 * time.Hour), now} {
+		if err := s.repo.RollupDay(ctx, day); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SnapshotUserStats records today's follower, following and post counts of
+// every user; later runs on the same day replace the snapshot
+func (s *analyticsService) SnapshotUserStats(ctx context.Context, now time.Time) (int64, error) {
+	return s.repo.SnapshotUserStats(ctx, now)
+}
+
+// GrowthHistory returns the user's daily counter snapshots over the last
+// days, oldest first; days without a snapshot are left out
+func (s *analyticsService) GrowthHistory(ctx context.Context, userID int64, days int) ([]dto.GrowthPoint, error) {
+	from, to := dayRange(days, MaxGrowthDays)
+	series, err := s.repo.UserStatsSeries(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]dto.GrowthPoint, len(series))
+	for i, stat := range series {
+		points[i] = dto.GrowthPoint{
+			Day:            stat.Day.UTC().Format(dto.DateLayout),
+			FollowerCount:  stat.FollowerCount,
+			FollowingCount: stat.FollowingCount,
+			PostCount:      stat.PostCount,
+		}
+	}
+	return points, nil
+}
+
+// dayRange returns the first day of a range of days ending today, capped at
+// limit days, and the exclusive end of today
+func dayRange(days, limit int) (from, to time.Time) {
+	days = min(max(days, 1), limit)
+	to = time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	return to.AddDate(0, 0, -days), to
+}
+
+// PostInsights returns the performance of the last days of a post; only its
+// author may see them
+func (s *analyticsService) PostInsights(ctx context.Context, viewerID, postID int64, days int) (*dto.PostInsights, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.UserID != viewerID {
+		return nil, apperr.Forbidden("only the author can see post insights")
+	}
+
+	from, to := dayRange(days, MaxInsightDays)
+	stats, err := s.repo.PostStats(ctx, postID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byDay := make(map[string]*model.PostDailyStat, len(stats))
+	for _, stat := range stats {
+		byDay[stat.Day.UTC().Format(dto.DateLayout)] = stat
+	}
+
+	insights := &dto.PostInsights{
+		PostID: postID,
+		Since:  from.Format(dto.DateLayout),
+		Until:  to.Add(-time.Hour).Format(dto.DateLayout),
+	}
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		d := dto.PostInsightDay{Day: day.Format(dto.DateLayout)}
+		if stat := byDay[d.Day]; stat != nil {
+			d.Impressions, d.Reach, d.Likes, d.Comments = stat.Impressions, stat.Reach, stat.Likes, stat.Comments
+			d.LinkClicks = stat.LinkClicks
+			d.EngagementRate = dto.EngagementRate(d.Likes+d.Comments, d.Impressions)
+		}
+		insights.Impressions += d.Impressions
+		insights.Reach += d.Reach
+		insights.Likes += d.Likes
+		insights.Comments += d.Comments
+		insights.LinkClicks += d.LinkClicks
+		insights.Daily = append(insights.Daily, d)
+	}
+	insights.EngagementRate = dto.EngagementRate(insights.Likes+insights.Comments, insights.Impressions)
+
+	sourceStats, err := s.repo.PostSourceStats(ctx, postID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	insights.Sources = dto.NewPostSourceInsights(sourceStats, insights.Impressions)
+	return insights, nil
+}
+
+// AccountInsights returns the audience and reach of the user over the last days
+func (s *analyticsService) AccountInsights(ctx context.Context, userID int64, days int) (*dto.AccountInsights, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := dayRange(days, MaxInsightDays)
+	stats, err := s.repo.AccountStats(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byDay := make(map[string]*model.AccountDailyStat, len(stats))
+	for _, stat := range stats {
+		byDay[stat.Day.UTC().Format(dto.DateLayout)] = stat
+	}
+
+	insights := &dto.AccountInsights{
+		Since:         from.Format(dto.DateLayout),
+		Until:         to.Add(-time.Hour).Format(dto.DateLayout),
+		FollowerCount: user.FollowerCount,
+	}
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		d := dto.AccountInsightDay{Day: day.Format(dto.DateLayout)}
+		if stat := byDay[d.Day]; stat != nil {
+			d.FollowersGained, d.FollowersLost = stat.FollowersGained, stat.FollowersLost
+			d.Impressions, d.Reach, d.Engagements = stat.Impressions, stat.Reach, stat.Engagements
+			d.LinkClicks, d.ProfileViews = stat.LinkClicks, stat.ProfileViews
+			d.EngagementRate = dto.EngagementRate(d.Engagements, d.Impressions)
+		}
+		insights.FollowersGained += d.FollowersGained
+		insights.FollowersLost += d.FollowersLost
+		insights.Impressions += d.Impressions
+		insights.Reach += d.Reach
+		insights.Engagements += d.Engagements
+		insights.LinkClicks += d.LinkClicks
+		insights.ProfileViews += d.ProfileViews
+		insights.Daily = append(insights.Daily, d)
+	}
+	insights.EngagementRate = dto.EngagementRate(insights.Engagements, insights.Impressions)
+
+	// Historical follower counts are worked back from the live counter
+	count := user.FollowerCount
+	for i := len(insights.Daily) - 1; i >= 0; i-- {
+		d := &insights.Daily[i]
+		d.FollowerCount = count
+		count -= d.FollowersGained - d.FollowersLost
+	}
+	return insights, nil
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/announcement/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type AnnouncementHandler struct {
+	service service.AnnouncementService
+}
+
+func NewAnnouncementHandler(svc service.AnnouncementService) *AnnouncementHandler {
+	return &AnnouncementHandler{service: svc}
+}
+
+// Register mounts the route users dismiss announcements with; it expects an
+// authenticated user in the request context. The feed lists the running ones.
+func (h *AnnouncementHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /announcements/{id}/dismiss", h.Dismiss)
+}
+
+// Dismiss hides an announcement from the caller's feed
+func (h *AnnouncementHandler) Dismiss(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid announcement id")
+		return
+	}
+
+	if err := h.service.Dismiss(r.Context(), id, userID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type AdminHandler struct {
+	service service.AnnouncementService
+}
+
+func NewAdminHandler(svc service.AnnouncementService) *AdminHandler {
+	return &AdminHandler{service: svc}
+}
+
+// Register mounts the announcement admin routes; callers are expected to wrap mux with admin auth
+func (h *AdminHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/announcements", h.List)
+	mux.HandleFunc("POST /admin/announcements", h.Create)
+	mux.HandleFunc("PATCH /admin/announcements/{id}", h.Update)
+	mux.HandleFunc("DELETE /admin/announcements/{id}", h.Delete)
+}
+
+// announcementBody is the editable part of an announcement; absent fields
+// are left as they are
+type announcementBody struct {
+	Title    *string    `json:"title"`
+	Body     *string    `json:"body"`
+	LinkURL  *string    `json:"link_url"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+// apply copies the fields present in b to a and returns what is wrong with
+// the result, or "" when it is fine
+func (b *announcementBody) apply(a *model.Announcement) string {
+	if b.Title != nil {
+		a.Title = strings.TrimSpace(*b.Title)
+	}
+	if b.Body != nil {
+		a.Body = strings.TrimSpace(*b.Body)
+	}
+	if b.LinkURL != nil {
+		a.LinkURL = strings.TrimSpace(*b.LinkURL)
+	}
+	if b.StartsAt != nil {
+		a.StartsAt = b.StartsAt.UTC()
+	}
+	if b.EndsAt != nil {
+		a.EndsAt = b.EndsAt.UTC()
+	}
+
+	switch {
+	case a.Title == "" || utf8.RuneCountInString(a.Title) > 200:
+		return "title must be 1 to 200 characters"
+	case a.Body == "" || utf8.RuneCountInString(a.Body) > 5000:
+		return "body must be 1 to 5000 characters"
+	case len(a.LinkURL) > 255:
+		return "link_url must be at most 255 characters"
+	case a.EndsAt.IsZero():
+		return "ends_at is required"
+	case !a.EndsAt.After(a.StartsAt):
+		return "ends_at must be after starts_at"
+	}
+	return ""
+}
+
+// List returns every announcement, scheduled, running or past, latest start first
+func (h *AdminHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	announcements, err := h.service.List(r.Context(), limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"announcements": announcements})
+}
+
+// Create schedules an announcement; starts_at defaults to now
+func (h *AdminHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body announcementBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	announcement := &model.Announcement{AuthorID: userID, StartsAt: time.Now().UTC()}
+	if msg := body.apply(announcement); msg != "" {
+		httpx.Error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	if err := h.service.Create(r.Context(), announcement); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, announcement)
+}
+
+// Update edits an announcement's text or window; ending it now takes it off feeds
+func (h *AdminHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid announcement id")
+		return
+	}
+
+	var body announcementBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	announcement, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	if msg := body.apply(announcement); msg != "" {
+		httpx.Error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	if err := h.service.Update(r.Context(), userID, announcement); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, announcement)
+}
+
+// Delete withdraws an announcement
+func (h *AdminHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid announcement id")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), userID, id); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
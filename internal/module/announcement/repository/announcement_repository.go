@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AnnouncementRepository interface {
+	Create(ctx context.Context, announcement *model.Announcement) error
+	Update(ctx context.Context, id int64, updates map[string]any) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*model.Announcement, error)
+	List(ctx context.Context, limit, offset int) ([]*model.Announcement, error)
+	Active(ctx context.Context, userID int64, at time.Time) ([]*model.Announcement, error)
+	Dismiss(ctx context.Context, announcementID, userID int64) error
+}
+
+func NewAnnouncementRepository(db *gorm.DB) AnnouncementRepository {
+	return &announcementRepository{db: db}
+}
+
+type announcementRepository struct {
+	db *gorm.DB
+}
+
+func (r *announcementRepository) Create(ctx context.Context, announcement *model.Announcement) error {
+	return apperr.Translate(r.db.WithContext(ctx).Create(announcement).Error, "announcement")
+}
+
+func (r *announcementRepository) Update(ctx context.Context, id int64, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.Announcement{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(updates).Error
+}
+
+func (r *announcementRepository) Delete(ctx context.Context, id int64) error {
+	res := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Announcement{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return apperr.NotFound("announcement not found")
+	}
+	return nil
+}
+
+func (r *announcementRepository) GetByID(ctx context.Context, id int64) (*model.Announcement, error) {
+	var announcement model.Announcement
+	if err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&announcement).Error; err != nil {
+		return nil, apperr.Translate(err, "announcement")
+	}
+	return &announcement, nil
+}
+
+// List returns every announcement, past, current and scheduled, latest start first
+func (r *announcementRepository) List(ctx context.Context, limit, offset int) ([]*model.Announcement, error) {
+	var announcements []*model.Announcement
+	err := r.db.WithContext(ctx).
+		Where("deleted_at IS NULL").
+		Order("starts_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&announcements).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list announcements: %w", err)
+	}
+	return announcements, nil
+}
+
+// Active returns the announcements running at the given time that the user
+// has not dismissed, latest start first
+func (r *announcementRepository) Active(ctx context.Context, userID int64, at time.Time) ([]*model.Announcement, error) {
+	dismissed := r.db.Model(&model.AnnouncementDismissal{}).
+		Select("announcement_id").
+		Where("user_id = ? AND deleted_at IS NULL", userID)
+	var announcements []*model.Announcement
+	err := r.db.WithContext(ctx).
+		Where("starts_at <= ? AND ends_at > ? AND deleted_at IS NULL", at, at).
+		Where("id NOT IN (?)", dismissed).
+		Order("starts_at DESC, id DESC").
+		Find(&announcements).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load announcements: %w", err)
+	}
+	return announcements, nil
+}
+
+// Dismiss hides the announcement from the user; dismissing twice is a no-op
+func (r *announcementRepository) Dismiss(ctx context.Context, announcementID, userID int64) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.AnnouncementDismissal{AnnouncementID: announcementID, UserID: userID}).Error
+	return apperr.Translate(err, "announcement dismissal")
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/announcement/repository"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+)
+
+var (
+	ErrAnnouncementNotFound = apperr.NotFound("announcement not found")
+	ErrInvalidWindow        = apperr.Conflict("announcement must end after it starts")
+)
+
+type AnnouncementService interface {
+	Create(ctx context.Context, announcement *model.Announcement) error
+	Get(ctx context.Context, id int64) (*model.Announcement, error)
+	Update(ctx context.Context, adminID int64, announcement *model.Announcement) error
+	Delete(ctx context.Context, adminID, id int64) error
+	List(ctx context.Context, limit, offset int) ([]*model.Announcement, error)
+	Active(ctx context.Context, userID int64) ([]*model.Announcement, error)
+	Dismiss(ctx context.Context, id, userID int64) error
+}
+
+type announcementService struct {
+	repo  repository.AnnouncementRepository
+	audit auditsvc.AuditService
+}
+
+func NewAnnouncementService(repo repository.AnnouncementRepository, audit auditsvc.AuditService) AnnouncementService {
+	return &announcementService{repo: repo, audit: audit}
+}
+
+// Create schedules an announcement; AuthorID is the admin publishing it
+func (s *announcementService) Create(ctx context.Context, announcement *model.Announcement) error {
+	if !announcement.EndsAt.After(announcement.StartsAt) {
+		return ErrInvalidWindow
+	}
+	if err := s.repo.Create(ctx, announcement); err != nil {
+		return fmt.Errorf("failed to create announcement: %w", err)
+	}
+	s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    &announcement.AuthorID,
+		Action:     model.AuditAnnouncementCreate,
+		TargetType: model.AuditTargetAnnouncement,
+		TargetID:   announcement.ID,
+		After:      snapshot(announcement),
+	})
+	return nil
+}
+
+func (s *announcementService) Get(ctx context.Context, id int64) (*model.Announcement, error) {
+	announcement, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, ErrAnnouncementNotFound
+	}
+	return announcement, err
+}
+
+// Update saves the edited text and window of an announcement loaded with Get
+func (s *announcementService) Update(ctx context.Context, adminID int64, announcement *model.Announcement) error {
+	if !announcement.EndsAt.After(announcement.StartsAt) {
+		return ErrInvalidWindow
+	}
+	before, err := s.Get(ctx, announcement.ID)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Update(ctx, announcement.ID, map[string]any{
+		"title":     announcement.Title,
+		"body":      announcement.Body,
+		"link_url":  announcement.LinkURL,
+		"starts_at": announcement.StartsAt,
+		"ends_at":   announcement.EndsAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update announcement: %w", err)
+	}
+	s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    &adminID,
+		Action:     model.AuditAnnouncementUpdate,
+		TargetType: model.AuditTargetAnnouncement,
+		TargetID:   announcement.ID,
+		Before:     snapshot(before),
+		After:      snapshot(announcement),
+	})
+	return nil
+}
+
+// Delete withdraws an announcement, running or not
+func (s *announcementService) Delete(ctx context.Context, adminID, id int64) error {
+	before, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    &adminID,
+		Action:     model.AuditAnnouncementDelete,
+		TargetType: model.AuditTargetAnnouncement,
+		TargetID:   id,
+		Before:     snapshot(before),
+	})
+	return nil
+}
+
+func (s *announcementService) List(ctx context.Context, limit, offset int) ([]*model.Announcement, error) {
+	return s.repo.List(ctx, limit, offset)
+}
+
+// Active returns the running announcements the user has not dismissed
+func (s *announcementService) Active(ctx context.Context, userID int64) ([]*model.Announcement, error) {
+	return s.repo.Active(ctx, userID, time.Now())
+}
+
+// Dismiss hides an announcement from the user's feed for good
+func (s *announcementService) Dismiss(ctx context.Context, id, userID int64) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Dismiss(ctx, id, userID)
+}
+
+func snapshot(a *model.Announcement) map[string]any {
+	return map[string]any{
+		"title":     a.Title,
+		"starts_at": a.StartsAt,
+		"ends_at":   a.EndsAt,
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/job"
+	"github.com/ilhamosaurus/sns-platform/internal/module/archive/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/jobs"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type ArchiveHandler struct {
+	service service.ArchiveService
+	queue   *jobs.Queue
+}
+
+func NewArchiveHandler(svc service.ArchiveService, queue *jobs.Queue) *ArchiveHandler {
+	return &ArchiveHandler{service: svc, queue: queue}
+}
+
+// Register mounts the archive import routes; they expect an authenticated user in the request context
+func (h *ArchiveHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /me/imports", h.Upload)
+	mux.HandleFunc("GET /me/imports/{id}", h.Status)
+}
+
+// Upload accepts a Twitter or Mastodon archive, either as the "archive" part
+// of a multipart form or as a raw application/zip body, and schedules its import.
+// The source is given by the "source" query parameter.
+func (h *ArchiveHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	source := types.StringToImportSource(r.URL.Query().Get("source"))
+	if source == types.ImportSourceUnknown {
+		httpx.Error(w, http.StatusBadRequest, "source must be twitter or mastodon")
+		return
+	}
+
+	body, err := archiveBody(r)
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	imp, err := h.service.Upload(r.Context(), userID, source, body)
+	if err != nil {
+		writeArchiveError(w, err)
+		return
+	}
+
+	_, err = h.queue.Enqueue(r.Context(), job.TypeArchiveImport, job.ArchiveImportPayload{ImportID: imp.ID}, jobs.WithQueue(job.QueueImport))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to enqueue archive import", slog.Int64("import_id", imp.ID), slog.Any("error", err))
+		httpx.Error(w, http.StatusInternalServerError, "failed to schedule archive import")
+		return
+	}
+
+	httpx.JSON(w, http.StatusAccepted, map[string]any{
+		"import":     imp,
+		"status_url": fmt.Sprintf("/me/imports/%d", imp.ID),
+	})
+}
+
+// Status reports the progress of an import
+func (h *ArchiveHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid import id")
+		return
+	}
+
+	imp, err := h.service.Get(r.Context(), userID, id)
+	if err != nil {
+		writeArchiveError(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"import": imp})
+}
+
+// archiveBody streams the uploaded archive without buffering it in memory
+func archiveBody(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, errors.New("missing archive file")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "archive" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+func writeArchiveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrArchiveTooLarge):
+		httpx.Error(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, service.ErrUnsupportedSource), errors.Is(err, service.ErrInvalidArchive):
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+	default:
+		httpx.Fail(w, err)
+	}
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+// Progress holds an import's item counters
+type Progress struct {
+	Processed int
+	Imported  int
+	Skipped   int
+}
+
+type ArchiveRepository interface {
+	Create(ctx context.Context, imp *model.ArchiveImport) error
+	GetByID(ctx context.Context, id int64) (*model.ArchiveImport, error)
+	MarkProcessing(ctx context.Context, id int64, total int) error
+	UpdateProgress(ctx context.Context, id int64, progress Progress) error
+	MarkCompleted(ctx context.Context, id int64, progress Progress) error
+	MarkFailed(ctx context.Context, id int64, cause error) error
+
+	HasItem(ctx context.Context, userID int64, source types.ImportSource, externalID string) (bool, error)
+	UserRegion(ctx context.Context, userID int64) (string, error)
+	CreatePost(ctx context.Context, post *model.Post, item *model.ImportedItem) error
+}
+
+func NewArchiveRepository(db *gorm.DB) ArchiveRepository {
+	return &archiveRepository{db: db}
+}
+
+type archiveRepository struct {
+	db *gorm.DB
+}
+
+func (r *archiveRepository) Create(ctx context.Context, imp *model.ArchiveImport) error {
+	return r.db.WithContext(ctx).Create(imp).Error
+}
+
+func (r *archiveRepository) GetByID(ctx context.Context, id int64) (*model.ArchiveImport, error) {
+	var imp model.ArchiveImport
+	if err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&imp).Error; err != nil {
+		return nil, apperr.Translate(err, "archive import")
+	}
+	return &imp, nil
+}
+
+func (r *archiveRepository) MarkProcessing(ctx context.Context, id int64, total int) error {
+	return r.update(ctx, id, map[string]any{"status": types.ImportStatusProcessing, "total_items": total, "error": ""})
+}
+
+func (r *archiveRepository) UpdateProgress(ctx context.Context, id int64, progress Progress) error {
+	return r.update(ctx, id, progressColumns(progress))
+}
+
+func (r *archiveRepository) MarkCompleted(ctx context.Context, id int64, progress Progress) error {
+	updates := progressColumns(progress)
+	updates["status"] = types.ImportStatusCompleted
+	updates["file_path"] = ""
+	updates["completed_at"] = time.Now().UTC()
+	return r.update(ctx, id, updates)
+}
+
+func (r *archiveRepository) MarkFailed(ctx context.Context, id int64, cause error) error {
+	return r.update(ctx, id, map[string]any{"status": types.ImportStatusFailed, "error": cause.Error()})
+}
+
+func (r *archiveRepository) HasItem(ctx context.Context, userID int64, source types.ImportSource, externalID string) (bool, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&model.ImportedItem{}).
+		Where("user_id = ? AND source = ? AND external_id = ? AND deleted_at IS NULL", userID, source, externalID).
+		Limit(1).
+		Pluck("id", &ids).Error
+	return len(ids) > 0, err
+}
+
+// UserRegion returns the region the user's posts are placed in; empty when unset
+func (r *archiveRepository) UserRegion(ctx context.Context, userID int64) (string, error) {
+	var regions []string
+	err := r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Limit(1).Pluck("region", &regions).Error
+	if err != nil || len(regions) == 0 {
+		return "", err
+	}
+	return regions[0], nil
+}
+
+// CreatePost stores an imported post, keeping its original timestamps, together
+// with the item mapping used for duplicate detection
+func (r *archiveRepository) CreatePost(ctx context.Context, post *model.Post, item *model.ImportedItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(post).Error; err != nil {
+			return err
+		}
+		item.PostID = post.ID
+		return tx.Create(item).Error
+	})
+}
+
+func (r *archiveRepository) update(ctx context.Context, id int64, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.ArchiveImport{}).Where("id = ? AND deleted_at IS NULL", id).Updates(updates).Error
+}
+
+func progressColumns(p Progress) map[string]any {
+	return map[string]any{
+		"processed_items": p.Processed,
+		"imported_items":  p.Imported,
+		"skipped_items":   p.Skipped,
+	}
+}
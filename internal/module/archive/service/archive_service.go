@@ -0,0 +1,328 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/archive/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/storage"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+const (
+	// DefaultMaxSize is the largest archive accepted for import
+	DefaultMaxSize = 512 << 20
+
+	// progressInterval is how many items are processed between progress updates
+	progressInterval = 50
+
+	// MediaPrefix is the storage directory imported post media is kept under
+	MediaPrefix = "imports/"
+)
+
+var (
+	ErrImportNotFound    = apperr.NotFound("archive import not found")
+	ErrUnsupportedSource = errors.New("unsupported archive source")
+	ErrInvalidArchive    = errors.New("invalid archive")
+	ErrArchiveTooLarge   = errors.New("archive is too large")
+)
+
+// Config controls where uploaded archives are kept and how large they may be
+type Config struct {
+	Dir     string
+	MaxSize int64
+}
+
+type ArchiveService interface {
+	Upload(ctx context.Context, userID int64, source types.ImportSource, r io.Reader) (*model.ArchiveImport, error)
+	Get(ctx context.Context, userID, id int64) (*model.ArchiveImport, error)
+	Run(ctx context.Context, id int64) error
+}
+
+type archiveService struct {
+	repo    repository.ArchiveRepository
+	storage storage.Storage
+	config  Config
+}
+
+func NewArchiveService(repo repository.ArchiveRepository, store storage.Storage, config Config) ArchiveService {
+	if config.Dir == "" {
+		config.Dir = "./data/imports"
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = DefaultMaxSize
+	}
+	return &archiveService{repo: repo, storage: store, config: config}
+}
+
+// archiveItem is a post read from an external archive
+type archiveItem struct {
+	ExternalID string
+	Content    string
+	CreatedAt  time.Time
+	Public     bool
+	Media      []archiveMedia
+}
+
+// archiveMedia is an attachment stored inside the archive
+type archiveMedia struct {
+	File *zip.File
+	Type types.MediaType
+}
+
+// Upload stores the archive and creates a pending import; the caller enqueues
+// the job that runs it
+func (s *archiveService) Upload(ctx context.Context, userID int64, source types.ImportSource, r io.Reader) (*model.ArchiveImport, error) {
+	if source != types.ImportSourceTwitter && source != types.ImportSourceMastodon {
+		return nil, ErrUnsupportedSource
+	}
+
+	file, err := s.save(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse once up front so a wrong or corrupt upload is rejected immediately
+	archive, err := s.readArchive(file, source)
+	if err != nil {
+		os.Remove(file)
+		return nil, err
+	}
+	archive.Close()
+
+	imp := &model.ArchiveImport{
+		UserID:   userID,
+		Source:   source,
+		Status:   types.ImportStatusPending,
+		FilePath: file,
+	}
+	if err := s.repo.Create(ctx, imp); err != nil {
+		os.Remove(file)
+		return nil, fmt.Errorf("failed to create archive import: %w", err)
+	}
+	return imp, nil
+}
+
+// Get returns an import owned by userID
+func (s *archiveService) Get(ctx context.Context, userID, id int64) (*model.ArchiveImport, error) {
+	imp, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, apperr.ErrNotFound) || (err == nil && imp.UserID != userID) {
+		return nil, ErrImportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// Run imports every item of the archive; it runs inside a background job.
+// Items imported by an earlier attempt are skipped, so retries are safe.
+func (s *archiveService) Run(ctx context.Context, id int64) error {
+	imp, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load archive import: %w", err)
+	}
+	if imp.Status == types.ImportStatusCompleted {
+		return nil
+	}
+	// The job has no request, so scope everything to the importing user's tenant
+	ctx = tenant.WithID(ctx, imp.TenantID)
+
+	progress, err := s.run(ctx, imp)
+	if err != nil {
+		if markErr := s.repo.MarkFailed(ctx, id, err); markErr != nil {
+			slog.WarnContext(ctx, "failed to mark archive import failed", slog.Int64("import_id", id), slog.Any("error", markErr))
+		}
+		return err
+	}
+
+	if err := s.repo.MarkCompleted(ctx, id, progress); err != nil {
+		return fmt.Errorf("failed to mark archive import completed: %w", err)
+	}
+	os.Remove(imp.FilePath)
+	slog.InfoContext(ctx, "archive import completed",
+		slog.Int64("import_id", id),
+		slog.Int64("user_id", imp.UserID),
+		slog.String("source", imp.Source.String()),
+		slog.Int("imported", progress.Imported),
+		slog.Int("skipped", progress.Skipped),
+	)
+	return nil
+}
+
+func (s *archiveService) run(ctx context.Context, imp *model.ArchiveImport) (repository.Progress, error) {
+	var progress repository.Progress
+
+	archive, err := s.readArchive(imp.FilePath, imp.Source)
+	if err != nil {
+		return progress, err
+	}
+	defer archive.Close()
+
+	if err := s.repo.MarkProcessing(ctx, imp.ID, len(archive.items)); err != nil {
+		return progress, fmt.Errorf("failed to mark archive import processing: %w", err)
+	}
+	// Every post is the importing user's, so their region is looked up once
+	region, err := s.repo.UserRegion(ctx, imp.UserID)
+	if err != nil {
+		return progress, fmt.Errorf("failed to look up region: %w", err)
+	}
+
+	for i, item := range archive.items {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+
+		imported, err := s.importItem(ctx, imp, item, region)
+		if err != nil {
+			return progress, err
+		}
+		progress.Processed++
+		if imported {
+			progress.Imported++
+		} else {
+			progress.Skipped++
+		}
+
+		if (i+1)%progressInterval == 0 {
+			if err := s.repo.UpdateProgress(ctx, imp.ID, progress); err != nil {
+				slog.WarnContext(ctx, "failed to update archive import progress", slog.Int64("import_id", imp.ID), slog.Any("error", err))
+			}
+		}
+	}
+	return progress, nil
+}
+
+// importItem creates the post for item, placed in region, unless it was
+// imported before
+func (s *archiveService) importItem(ctx context.Context, imp *model.ArchiveImport, item archiveItem, region string) (bool, error) {
+	exists, err := s.repo.HasItem(ctx, imp.UserID, imp.Source, item.ExternalID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check imported item: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	post := &model.Post{
+		UserID:    imp.UserID,
+		Content:   item.Content,
+		MediaType: types.MediaTypeText,
+		Region:    region,
+		IsPublic:  item.Public,
+	}
+	post.CreatedAt = item.CreatedAt
+	post.UpdatedAt = item.CreatedAt
+
+	// Posts carry a single attachment, so only the first one is kept
+	if len(item.Media) > 0 {
+		media := item.Media[0]
+		url, err := s.uploadMedia(ctx, imp, item, media)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to import media, importing post without it",
+				slog.Int64("import_id", imp.ID),
+				slog.String("external_id", item.ExternalID),
+				slog.Any("error", err),
+			)
+		} else {
+			post.MediaType = media.Type
+			post.MediaURL = url
+		}
+	}
+
+	err = s.repo.CreatePost(ctx, post, &model.ImportedItem{
+		UserID:     imp.UserID,
+		Source:     imp.Source,
+		ExternalID: item.ExternalID,
+		ImportID:   imp.ID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create imported post: %w", err)
+	}
+	return true, nil
+}
+
+func (s *archiveService) uploadMedia(ctx context.Context, imp *model.ArchiveImport, item archiveItem, media archiveMedia) (string, error) {
+	rc, err := media.File.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	ext := path.Ext(media.File.Name)
+	key := fmt.Sprintf(MediaPrefix+"%d/%s/%s%s", imp.UserID, imp.Source, hashKey(item.ExternalID), ext)
+	return s.storage.Put(ctx, key, rc, mime.TypeByExtension(ext))
+}
+
+// save copies the upload into the import directory, enforcing MaxSize
+func (s *archiveService) save(r io.Reader) (string, error) {
+	if err := os.MkdirAll(s.config.Dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create import directory: %w", err)
+	}
+	f, err := os.CreateTemp(s.config.Dir, "import-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to create import file: %w", err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, io.LimitReader(r, s.config.MaxSize+1))
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr), err == nil && n > s.config.MaxSize:
+		err = ErrArchiveTooLarge
+	case err == nil:
+		err = f.Close()
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		if errors.Is(err, ErrArchiveTooLarge) {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to save archive: %w", err)
+	}
+	return filepath.Clean(f.Name()), nil
+}
+
+// parsedArchive holds the items of an open archive; Close releases the file
+type parsedArchive struct {
+	*zip.ReadCloser
+	items []archiveItem
+}
+
+func (s *archiveService) readArchive(file string, source types.ImportSource) (*parsedArchive, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	var items []archiveItem
+	switch source {
+	case types.ImportSourceTwitter:
+		items, err = parseTwitter(&zr.Reader)
+	case types.ImportSourceMastodon:
+		items, err = parseMastodon(&zr.Reader)
+	default:
+		err = ErrUnsupportedSource
+	}
+	if err != nil {
+		zr.Close()
+		if errors.Is(err, ErrUnsupportedSource) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	return &parsedArchive{ReadCloser: zr, items: items}, nil
+}
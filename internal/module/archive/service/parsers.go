@@ -0,0 +1,257 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// maxDataFileSize bounds how much of a single data file is decoded
+const maxDataFileSize = 256 << 20
+
+const activityStreamsPublic = "https://www.w3.org/ns/activitystreams#Public"
+
+var (
+	twitterTweetsFile = regexp.MustCompile(`(^|/)data/tweets?(-part\d+)?\.js$`)
+	htmlBreak         = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlParagraph     = regexp.MustCompile(`(?i)</p>\s*<p[^>]*>`)
+	htmlTag           = regexp.MustCompile(`<[^>]*>`)
+)
+
+type twitterTweet struct {
+	ID        string `json:"id_str"`
+	FullText  string `json:"full_text"`
+	CreatedAt string `json:"created_at"`
+	Entities  struct {
+		URLs []struct {
+			URL         string `json:"url"`
+			ExpandedURL string `json:"expanded_url"`
+		} `json:"urls"`
+	} `json:"entities"`
+	ExtendedEntities struct {
+		Media []struct {
+			URL  string `json:"url"`
+			Type string `json:"type"` // photo, video, animated_gif
+		} `json:"media"`
+	} `json:"extended_entities"`
+}
+
+// parseTwitter reads data/tweets.js (split into tweets-partN.js for large
+// accounts) and the media under data/tweets_media. Retweets are left out.
+func parseTwitter(zr *zip.Reader) ([]archiveItem, error) {
+	// Media files are named "<tweet id>-<original name>"
+	media := make(map[string][]*zip.File)
+	for _, f := range zr.File {
+		dir, name := path.Split(f.Name)
+		if !strings.HasSuffix(dir, "/tweets_media/") && !strings.HasSuffix(dir, "/tweet_media/") {
+			continue
+		}
+		if id, _, ok := strings.Cut(name, "-"); ok {
+			media[id] = append(media[id], f)
+		}
+	}
+
+	var (
+		items []archiveItem
+		found bool
+	)
+	for _, f := range zr.File {
+		if !twitterTweetsFile.MatchString(f.Name) {
+			continue
+		}
+		found = true
+
+		tweets, err := readTwitterFile(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tweets {
+			if t.ID == "" || strings.HasPrefix(t.FullText, "RT @") {
+				continue
+			}
+			createdAt, err := time.Parse(time.RubyDate, t.CreatedAt)
+			if err != nil {
+				return nil, fmt.Errorf("tweet %s: invalid created_at %q", t.ID, t.CreatedAt)
+			}
+
+			text := t.FullText
+			for _, u := range t.Entities.URLs {
+				text = strings.ReplaceAll(text, u.URL, u.ExpandedURL)
+			}
+			item := archiveItem{ExternalID: t.ID, CreatedAt: createdAt.UTC(), Public: true}
+			for i, m := range t.ExtendedEntities.Media {
+				text = strings.ReplaceAll(text, m.URL, "")
+				if i < len(media[t.ID]) {
+					item.Media = append(item.Media, archiveMedia{File: media[t.ID][i], Type: twitterMediaType(m.Type)})
+				}
+			}
+			item.Content = strings.TrimSpace(html.UnescapeString(text))
+			items = append(items, item)
+		}
+	}
+	if !found {
+		return nil, errors.New("data/tweets.js not found, is this a Twitter archive?")
+	}
+	return items, nil
+}
+
+// readTwitterFile decodes a file of the form "window.YTD.tweets.part0 = [...]"
+func readTwitterFile(f *zip.File) ([]twitterTweet, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(io.LimitReader(rc, maxDataFileSize))
+	if _, err := br.ReadString('='); err != nil {
+		return nil, fmt.Errorf("%s: unexpected format", f.Name)
+	}
+
+	var entries []struct {
+		Tweet *twitterTweet `json:"tweet"`
+	}
+	if err := json.NewDecoder(br).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	tweets := make([]twitterTweet, 0, len(entries))
+	for _, e := range entries {
+		if e.Tweet != nil {
+			tweets = append(tweets, *e.Tweet)
+		}
+	}
+	return tweets, nil
+}
+
+func twitterMediaType(t string) types.MediaType {
+	switch t {
+	case "video":
+		return types.MediaTypeVideo
+	case "animated_gif":
+		return types.MediaTypeGIF
+	default:
+		return types.MediaTypeImage
+	}
+}
+
+type mastodonNote struct {
+	ID         string   `json:"id"`
+	Published  string   `json:"published"`
+	Content    string   `json:"content"`
+	To         []string `json:"to"`
+	Cc         []string `json:"cc"`
+	Attachment []struct {
+		MediaType string `json:"mediaType"`
+		URL       string `json:"url"`
+	} `json:"attachment"`
+}
+
+// parseMastodon reads the ActivityPub outbox.json of a Mastodon archive and
+// the media_attachments it references. Boosts and direct messages are left
+// out; followers-only posts are imported as private.
+func parseMastodon(zr *zip.Reader) ([]archiveItem, error) {
+	files := make(map[string]*zip.File, len(zr.File))
+	var outbox *zip.File
+	for _, f := range zr.File {
+		files[f.Name] = f
+		if path.Base(f.Name) == "outbox.json" && (outbox == nil || len(f.Name) < len(outbox.Name)) {
+			outbox = f
+		}
+	}
+	if outbox == nil {
+		return nil, errors.New("outbox.json not found, is this a Mastodon archive?")
+	}
+	root := path.Dir(outbox.Name)
+
+	rc, err := outbox.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var collection struct {
+		OrderedItems []struct {
+			Type   string          `json:"type"`
+			Object json.RawMessage `json:"object"`
+		} `json:"orderedItems"`
+	}
+	if err := json.NewDecoder(io.LimitReader(rc, maxDataFileSize)).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("%s: %w", outbox.Name, err)
+	}
+
+	var items []archiveItem
+	for _, activity := range collection.OrderedItems {
+		if activity.Type != "Create" {
+			continue
+		}
+		var note mastodonNote
+		if err := json.Unmarshal(activity.Object, &note); err != nil || note.ID == "" {
+			continue
+		}
+
+		public := slices.Contains(note.To, activityStreamsPublic) || slices.Contains(note.Cc, activityStreamsPublic)
+		followersOnly := false
+		for _, to := range note.To {
+			followersOnly = followersOnly || strings.HasSuffix(to, "/followers")
+		}
+		if !public && !followersOnly {
+			continue
+		}
+
+		published, err := time.Parse(time.RFC3339, note.Published)
+		if err != nil {
+			return nil, fmt.Errorf("status %s: invalid published %q", note.ID, note.Published)
+		}
+		item := archiveItem{
+			ExternalID: note.ID,
+			Content:    htmlToText(note.Content),
+			CreatedAt:  published.UTC(),
+			Public:     public,
+		}
+		for _, a := range note.Attachment {
+			u, err := url.Parse(a.URL)
+			if err != nil {
+				continue
+			}
+			f, ok := files[path.Join(root, strings.TrimPrefix(u.Path, "/"))]
+			if !ok {
+				continue
+			}
+			mediaType := types.MediaTypeFromContentType(a.MediaType)
+			if !mediaType.HasFile() {
+				continue
+			}
+			item.Media = append(item.Media, archiveMedia{File: f, Type: mediaType})
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// htmlToText turns the HTML of a status into plain text, keeping line breaks
+func htmlToText(s string) string {
+	s = htmlBreak.ReplaceAllString(s, "\n")
+	s = htmlParagraph.ReplaceAllString(s, "\n\n")
+	s = htmlTag.ReplaceAllString(s, "")
+	return strings.TrimSpace(html.UnescapeString(s))
+}
+
+// hashKey derives a storage-safe name from an external ID, which may be a URL
+func hashKey(externalID string) string {
+	sum := sha256.Sum256([]byte(externalID))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/audit/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+)
+
+type AuditHandler struct {
+	service service.AuditService
+}
+
+func NewAuditHandler(svc service.AuditService) *AuditHandler {
+	return &AuditHandler{service: svc}
+}
+
+// Register mounts the audit log routes; callers are expected to wrap mux with admin auth
+func (h *AuditHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/audit-logs", h.List)
+	mux.HandleFunc("GET /admin/moderation/export", h.ExportModeration)
+}
+
+// List returns audit log entries, newest first, filtered by the actor_id,
+// action, target_type, target_id, since and until (RFC 3339) query parameters
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := repository.Filter{
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+	}
+	var err error
+	for param, dst := range map[string]*int64{"actor_id": &filter.ActorID, "target_id": &filter.TargetID} {
+		if v := q.Get(param); v != "" {
+			if *dst, err = strconv.ParseInt(v, 10, 64); err != nil {
+				httpx.Error(w, http.StatusBadRequest, "invalid "+param)
+				return
+			}
+		}
+	}
+	if !parseRange(w, r, &filter) {
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	entries, err := h.service.List(r.Context(), filter, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"audit_logs": entries})
+}
+
+// ExportModeration streams the moderation actions in the audit log as CSV,
+// oldest first, for trust & safety review. The category query parameter
+// narrows them to one of service.ModerationCategories, and since and until
+// (RFC 3339) to a date range. Rows are read and written a batch at a time,
+// so the export can be any size; an interrupted download resumes by passing
+// the last id received as after.
+func (h *AuditHandler) ExportModeration(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	category := q.Get("category")
+	actions, ok := service.ModerationActions(category)
+	if !ok {
+		httpx.Error(w, http.StatusBadRequest, "unknown category")
+		return
+	}
+	filter := repository.Filter{Actions: actions}
+	if !parseRange(w, r, &filter) {
+		return
+	}
+	var after int64
+	if v := q.Get("after"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			httpx.Error(w, http.StatusBadRequest, "invalid after cursor")
+			return
+		}
+		after = id
+	}
+
+	name := "moderation"
+	if category != "" {
+		name += "-" + category
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "created_at", "category", "action", "actor_id", "target_type", "target_id", "ip", "before", "after"})
+	err := h.service.Each(r.Context(), filter, after, func(entries []*model.AuditLog) error {
+		for _, entry := range entries {
+			var actorID string
+			if entry.ActorID != nil {
+				actorID = strconv.FormatInt(*entry.ActorID, 10)
+			}
+			cw.Write([]string{
+				strconv.FormatInt(entry.ID, 10),
+				entry.CreatedAt.UTC().Format(time.RFC3339),
+				service.ModerationCategory(entry.Action),
+				entry.Action,
+				actorID,
+				entry.TargetType,
+				strconv.FormatInt(entry.TargetID, 10),
+				entry.IP,
+				snapshot(entry.Before),
+				snapshot(entry.After),
+			})
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+	if err != nil {
+		// The status is already sent, so the download just ends early
+		slog.WarnContext(r.Context(), "moderation export stopped", slog.Any("error", err))
+	}
+}
+
+// parseRange reads the since and until (RFC 3339) query parameters into
+// filter, answering 400 if either is malformed
+func parseRange(w http.ResponseWriter, r *http.Request, filter *repository.Filter) bool {
+	for param, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := r.URL.Query().Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				httpx.Error(w, http.StatusBadRequest, "invalid "+param+", expected RFC 3339")
+				return false
+			}
+			*dst = t
+		}
+	}
+	return true
+}
+
+// snapshot renders a before or after state as a JSON cell
+func snapshot(state map[string]any) string {
+	if len(state) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
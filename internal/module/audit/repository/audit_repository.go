@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"gorm.io/gorm"
+)
+
+// Filter narrows an audit log query; zero fields match everything
+type Filter struct {
+	ActorID    int64
+	Action     string
+	Actions    []string // Any of these actions
+	TargetType string
+	TargetID   int64
+	Since      time.Time // Inclusive
+	Until      time.Time // Exclusive
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *model.AuditLog) error
+	List(ctx context.Context, filter Filter, limit, offset int) ([]*model.AuditLog, error)
+	Each(ctx context.Context, filter Filter, after int64, batchSize int, fn func([]*model.AuditLog) error) error
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func (r *auditRepository) Create(ctx context.Context, entry *model.AuditLog) error {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// List returns the entries matching filter, newest first
+func (r *auditRepository) List(ctx context.Context, filter Filter, limit, offset int) ([]*model.AuditLog, error) {
+	var entries []*model.AuditLog
+	if err := r.query(ctx, filter).Order("id DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	return entries, nil
+}
+
+// Each passes the entries matching filter with IDs above after to fn, oldest
+// first, batchSize at a time. Batches are read by ID from where the last one
+// ended, so exports of any size hold one batch in memory and later inserts
+// do not shift them.
+func (r *auditRepository) Each(ctx context.Context, filter Filter, after int64, batchSize int, fn func([]*model.AuditLog) error) error {
+	for {
+		var entries []*model.AuditLog
+		err := r.query(ctx, filter).Where("id > ?", after).Order("id ASC").Limit(batchSize).Find(&entries).Error
+		if err != nil {
+			return fmt.Errorf("failed to read audit logs: %w", err)
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+		if len(entries) < batchSize {
+			return nil
+		}
+		after = entries[len(entries)-1].ID
+	}
+}
+
+func (r *auditRepository) query(ctx context.Context, filter Filter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&model.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if len(filter.Actions) > 0 {
+		query = query.Where("action IN ?", filter.Actions)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+	return query
+}
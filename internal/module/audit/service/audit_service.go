@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/audit/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+// Redacted replaces secrets, such as password hashes, in snapshots
+const Redacted = "[redacted]"
+
+// eachBatch is how many entries Each reads at a time
+const eachBatch = 500
+
+// ModerationCategories groups the audited actions trust & safety reviews
+// by what they moderate
+var ModerationCategories = map[string][]string{
+	"community":  {model.AuditCommunityPostRemoval, model.AuditCommunityMemberBan},
+	"account":    {model.AuditAdminUpdateUser, model.AuditProvisionUpdateUser, model.AuditProvisionDeleteUser, model.AuditAccountDeactivate, model.AuditAccountReactivate},
+	"legal_hold": {model.AuditAdminPlaceLegalHold, model.AuditAdminReleaseLegalHold},
+}
+
+// ModerationCategory returns the category action falls in, or "" if it is
+// not a moderation action
+func ModerationCategory(action string) string {
+	for category, actions := range ModerationCategories {
+		if slices.Contains(actions, action) {
+			return category
+		}
+	}
+	return ""
+}
+
+// ModerationActions returns the actions of category, or of every category
+// when it is empty, and false for an unknown category
+func ModerationActions(category string) ([]string, bool) {
+	if category != "" {
+		actions, ok := ModerationCategories[category]
+		return actions, ok
+	}
+	var actions []string
+	for _, category := range slices.Sorted(maps.Keys(ModerationCategories)) {
+		actions = append(actions, ModerationCategories[category]...)
+	}
+	return actions, true
+}
+
+type AuditService interface {
+	Record(ctx context.Context, entry *model.AuditLog)
+	List(ctx context.Context, filter repository.Filter, limit, offset int) ([]*model.AuditLog, error)
+	Each(ctx context.Context, filter repository.Filter, after int64, fn func([]*model.AuditLog) error) error
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+// Record appends entry to the audit log, taking the actor and IP from the
+// request in ctx when the entry leaves them empty. The audited operation has
+// already happened, so a failure is logged rather than returned.
+func (s *auditService) Record(ctx context.Context, entry *model.AuditLog) {
+	if entry.ActorID == nil {
+		if userID, ok := logger.UserID(ctx); ok {
+			entry.ActorID = &userID
+		}
+	}
+	if entry.IP == "" {
+		entry.IP = httpx.ContextClientIP(ctx)
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "failed to record audit log", slog.String("action", entry.Action), slog.String("target_type", entry.TargetType), slog.Int64("target_id", entry.TargetID), slog.Any("error", err))
+	}
+}
+
+func (s *auditService) List(ctx context.Context, filter repository.Filter, limit, offset int) ([]*model.AuditLog, error) {
+	return s.repo.List(ctx, filter, limit, offset)
+}
+
+// Each streams the entries matching filter after the given ID to fn, oldest
+// first, a batch at a time
+func (s *auditService) Each(ctx context.Context, filter repository.Filter, after int64, fn func([]*model.AuditLog) error) error {
+	return s.repo.Each(ctx, filter, after, eachBatch, fn)
+}
+
+// RecordModeration audits post removals and bans by community moderators,
+// and comments hidden over reports and restored
+func RecordModeration(bus eventbus.Bus, svc AuditService) {
+	eventbus.On(bus, func(ctx context.Context, e event.CommunityPostRemoved) error {
+		svc.Record(ctx, &model.AuditLog{
+			ActorID:    &e.ModeratorID,
+			Action:     model.AuditCommunityPostRemoval,
+			TargetType: model.AuditTargetPost,
+			TargetID:   e.PostID,
+			After:      map[string]any{"community_id": e.CommunityID, "author_id": e.AuthorID, "reason": e.Reason},
+		})
+		return nil
+	})
+	eventbus.On(bus, func(ctx context.Context, e event.CommunityMemberBanned) error {
+		svc.Record(ctx, &model.AuditLog{
+			ActorID:    &e.ModeratorID,
+			Action:     model.AuditCommunityMemberBan,
+			TargetType: model.AuditTargetUser,
+			TargetID:   e.UserID,
+			After:      map[string]any{"community_id": e.CommunityID},
+		})
+		return nil
+	})
+	eventbus.On(bus, func(ctx context.Context, e event.CommunityCommentHidden) error {
+		svc.Record(ctx, &model.AuditLog{
+			Action:     model.AuditCommunityCommentHide,
+			TargetType: model.AuditTargetComment,
+			TargetID:   e.CommentID,
+			After:      map[string]any{"community_id": e.CommunityID, "author_id": e.AuthorID, "reports": e.Reports},
+		})
+		return nil
+	})
+	eventbus.On(bus, func(ctx context.Context, e event.CommunityCommentRestored) error {
+		svc.Record(ctx, &model.AuditLog{
+			ActorID:    &e.ModeratorID,
+			Action:     model.AuditCommunityCommentRestore,
+			TargetType: model.AuditTargetComment,
+			TargetID:   e.CommentID,
+			After:      map[string]any{"community_id": e.CommunityID, "author_id": e.AuthorID},
+		})
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/module/closefriend/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type CloseFriendHandler struct {
+	repo repository.CloseFriendRepository
+}
+
+func NewCloseFriendHandler(repo repository.CloseFriendRepository) *CloseFriendHandler {
+	return &CloseFriendHandler{repo: repo}
+}
+
+// Register mounts the close friends routes; they expect an authenticated user in the request context
+func (h *CloseFriendHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/close-friends", h.List)
+	mux.HandleFunc("PUT /me/close-friends/{userID}", h.Add)
+	mux.HandleFunc("DELETE /me/close-friends/{userID}", h.Remove)
+}
+
+// List returns the caller's close friends
+func (h *CloseFriendHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	users, err := h.repo.List(r.Context(), userID, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"users": dto.NewUserResponses(users)})
+}
+
+// Add puts a user on the caller's close friends list
+func (h *CloseFriendHandler) Add(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	friendID, err := httpx.PathInt64(r, "userID")
+	if err != nil || friendID == userID {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.repo.Add(r.Context(), userID, friendID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Remove takes a user off the caller's close friends list
+func (h *CloseFriendHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	friendID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.repo.Remove(r.Context(), userID, friendID); err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
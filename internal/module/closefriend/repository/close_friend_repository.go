@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+type CloseFriendRepository interface {
+	Add(ctx context.Context, userID, friendID int64) error
+	Remove(ctx context.Context, userID, friendID int64) error
+	List(ctx context.Context, userID int64, limit, offset int) ([]*model.User, error)
+	IsCloseFriend(ctx context.Context, userID, friendID int64) (bool, error)
+}
+
+func NewCloseFriendRepository(db *gorm.DB) CloseFriendRepository {
+	return &closeFriendRepository{db: db}
+}
+
+type closeFriendRepository struct {
+	db *gorm.DB
+}
+
+// Add puts friendID on userID's list; adding an existing friend is a no-op
+func (r *closeFriendRepository) Add(ctx context.Context, userID, friendID int64) error {
+	entry := model.CloseFriend{UserID: userID, FriendID: friendID}
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND friend_id = ?", userID, friendID).
+		FirstOrCreate(&entry).Error
+	return apperr.Translate(err, "close friend")
+}
+
+// Remove deletes the entry outright so the pair can be added again later
+func (r *closeFriendRepository) Remove(ctx context.Context, userID, friendID int64) error {
+	return r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND friend_id = ?", userID, friendID).
+		Delete(&model.CloseFriend{}).Error
+}
+
+// List returns the users on userID's close friends list, most recently added first
+func (r *closeFriendRepository) List(ctx context.Context, userID int64, limit, offset int) ([]*model.User, error) {
+	var users []*model.User
+	err := r.db.WithContext(ctx).Table("users").
+		Select("users.*").
+		Joins("INNER JOIN close_friends ON close_friends.friend_id = users.id AND close_friends.deleted_at IS NULL").
+		Where("close_friends.user_id = ? AND users.deleted_at IS NULL", userID).
+		Order("close_friends.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *closeFriendRepository) IsCloseFriend(ctx context.Context, userID, friendID int64) (bool, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&model.CloseFriend{}).
+		Where("user_id = ? AND friend_id = ? AND deleted_at IS NULL", userID, friendID).
+		Limit(1).
+		Pluck("id", &ids).Error
+	return len(ids) > 0, err
+}
+
+// VisibleTo restricts a posts query to rows viewerID may see: close friends
+// posts only reach their author and the people on the author's list
+func VisibleTo(viewerID int64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`(posts.is_close_friends = ? OR posts.user_id = ? OR EXISTS (
+			SELECT 1 FROM close_friends
+			WHERE close_friends.user_id = posts.user_id
+				AND close_friends.friend_id = ?
+				AND close_friends.deleted_at IS NULL))`, false, viewerID, viewerID)
+	}
+}
+
+// VisibleToColumn is VisibleTo for queries covering many viewers at once;
+// viewerColumn names a trusted column holding each row's viewer ID
+func VisibleToColumn(viewerColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`(posts.is_close_friends = ? OR posts.user_id = `+viewerColumn+` OR EXISTS (
+			SELECT 1 FROM close_friends
+			WHERE close_friends.user_id = posts.user_id
+				AND close_friends.friend_id = `+viewerColumn+`
+				AND close_friends.deleted_at IS NULL))`, false)
+	}
+}
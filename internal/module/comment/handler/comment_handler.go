@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/comment/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type CommentHandler struct {
+	service service.CommentService
+}
+
+func NewCommentHandler(svc service.CommentService) *CommentHandler {
+	return &CommentHandler{service: svc}
+}
+
+// Register mounts the comment routes; they expect an authenticated user in the request context
+func (h *CommentHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /posts/{id}/comments", h.Create)
+	mux.HandleFunc("PUT /posts/{id}/comment-policy", h.SetPolicy)
+	mux.HandleFunc("POST /comments/{id}/approve", h.Approve)
+}
+
+// Create comments on a post, or replies to one of its comments
+func (h *CommentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	postID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+
+	var body struct {
+		Content  string `json:"content"`
+		ParentID *int64 `json:"parent_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	body.Content = strings.TrimSpace(body.Content)
+	if body.Content == "" || utf8.RuneCountInString(body.Content) > service.MaxCommentLength {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("content must be 1 to %d characters", service.MaxCommentLength))
+		return
+	}
+
+	comment := &model.Comment{PostID: postID, UserID: userID, ParentID: body.ParentID, Content: body.Content}
+	if err := h.service.Create(r.Context(), comment); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, dto.NewCommentResponse(comment))
+}
+
+// Approve publishes a comment by a restricted user on one of the caller's posts
+func (h *CommentHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	commentID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid comment id")
+		return
+	}
+
+	if err := h.service.Approve(r.Context(), userID, commentID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetPolicy changes who may comment on one of the caller's posts
+func (h *CommentHandler) SetPolicy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	postID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+
+	var body struct {
+		CommentPolicy *types.CommentPolicy `json:"comment_policy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.CommentPolicy == nil || *body.CommentPolicy >= types.CommentPolicyUnknown {
+		httpx.Error(w, http.StatusBadRequest, "comment_policy must be everyone, followers, mentioned or off")
+		return
+	}
+
+	if err := h.service.SetPolicy(r.Context(), userID, postID, *body.CommentPolicy); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"comment_policy": body.CommentPolicy})
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *model.Comment) error
+	GetByID(ctx context.Context, id int64) (*model.Comment, error)
+	Approve(ctx context.Context, comment *model.Comment) (bool, error)
+	Rescore(ctx context.Context, since, now time.Time) (int64, error)
+}
+
+const (
+	// replyWeight is how many likes a reply counts for in the rank score
+	replyWeight = 2
+
+	// rankGravity is how fast rank scores decay with age; scores are divided
+	// by (hours + 2) raised to it
+	rankGravity = 1.5
+
+	// rescoreBatchSize is how many comments Rescore updates per transaction
+	rescoreBatchSize = 500
+)
+
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+// Create stores a comment and, unless it awaits approval, counts it on its
+// post and parent comment
+func (r *commentRepository) Create(ctx context.Context, comment *model.Comment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return apperr.Translate(err, "comment")
+		}
+		if comment.PendingApproval {
+			return nil
+		}
+		return countComment(tx, comment)
+	})
+}
+
+func (r *commentRepository) GetByID(ctx context.Context, id int64) (*model.Comment, error) {
+	var comment model.Comment
+	err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&comment).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "comment")
+	}
+	return &comment, nil
+}
+
+// Approve publishes a comment awaiting approval and counts it; it reports
+// false if the comment was not pending
+func (r *commentRepository) Approve(ctx context.Context, comment *model.Comment) (bool, error) {
+	approved := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Comment{}).
+			Where("id = ? AND pending_approval = ? AND deleted_at IS NULL", comment.ID, true).
+			UpdateColumn("pending_approval", false)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		approved = true
+		return countComment(tx, comment)
+	})
+	return approved, err
+}
+
+// countComment adds a published comment to its post's comment count and its
+// parent's reply count
+func countComment(tx *gorm.DB, comment *model.Comment) error {
+	err := tx.Model(&model.Post{}).
+		Where("id = ? AND deleted_at IS NULL", comment.PostID).
+		UpdateColumn("comment_count", gorm.Expr("comment_count + 1")).Error
+	if err != nil || comment.ParentID == nil {
+		return err
+	}
+	return tx.Model(&model.Comment{}).
+		Where("id = ? AND deleted_at IS NULL", *comment.ParentID).
+		UpdateColumn("replies_count", gorm.Expr("replies_count + 1")).Error
+}
+
+// RankScore weighs a comment's likes and replies against its age, so a
+// comment needs more engagement to stay on top as it gets older
+func RankScore(likes, replies int64, age time.Duration) float64 {
+	engagement := float64(likes + replyWeight*replies)
+	if engagement <= 0 {
+		return 0
+	}
+	hours := max(age.Hours(), 0)
+	return engagement / math.Pow(hours+2, rankGravity)
+}
+
+// Rescore recomputes the rank score of the published comments created since
+// the given time as of now, and returns how many were updated. Older
+// comments keep their last score.
+func (r *commentRepository) Rescore(ctx context.Context, since, now time.Time) (int64, error) {
+	var (
+		comments []*model.Comment
+		updated  int64
+	)
+	err := r.db.WithContext(ctx).
+		Select("id", "likes_count", "replies_count", "created_at", "rank_score").
+		Where("created_at >= ? AND pending_approval = ? AND deleted_at IS NULL", since, false).
+		FindInBatches(&comments, rescoreBatchSize, func(_ *gorm.DB, _ int) error {
+			return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				for _, comment := range comments {
+					score := RankScore(comment.LikesCount, comment.RepliesCount, now.Sub(comment.CreatedAt))
+					if score == comment.RankScore {
+						continue
+					}
+					err := tx.Model(&model.Comment{}).
+						Where("id = ?", comment.ID).
+						UpdateColumn("rank_score", score).Error
+					if err != nil {
+						return err
+					}
+					updated++
+				}
+				return nil
+			})
+		}).Error
+	if err != nil {
+		return updated, fmt.Errorf("failed to rescore comments: %w", err)
+	}
+	return updated, nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	closefriendrepo "github.com/ilhamosaurus/sns-platform/internal/module/closefriend/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/module/comment/repository"
+	followrepo "github.com/ilhamosaurus/sns-platform/internal/module/follow/repository"
+	notificationsvc "github.com/ilhamosaurus/sns-platform/internal/module/notification/service"
+	orgrepo "github.com/ilhamosaurus/sns-platform/internal/module/organization/repository"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	restrictionrepo "github.com/ilhamosaurus/sns-platform/internal/module/restriction/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/challenge"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// MaxCommentLength is the most characters a comment may hold
+const MaxCommentLength = 2200
+
+// ErrCommentsLimited is returned when a post's comment policy leaves the commenter out
+var ErrCommentsLimited = apperr.Forbidden("comments on this post are limited")
+
+// mentionPattern matches an @username mention
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w+)`)
+
+// CommentHook inspects a comment before it is stored; returning an error
+// rejects the comment
+type CommentHook func(ctx context.Context, post *model.Post, comment *model.Comment) error
+
+type CommentService interface {
+	Create(ctx context.Context, comment *model.Comment) error
+	Approve(ctx context.Context, userID, commentID int64) error
+	SetPolicy(ctx context.Context, userID, postID int64, policy types.CommentPolicy) error
+	OnCreate(hook CommentHook)
+}
+
+type commentService struct {
+	repo          repository.CommentRepository
+	postRepo      postrepo.PostRepository
+	userRepo      userrepo.UserRepository
+	followRepo    followrepo.FollowRepository
+	closeFriends  closefriendrepo.CloseFriendRepository
+	restrictions  restrictionrepo.RestrictionRepository
+	orgs          orgrepo.OrganizationRepository
+	notifications notificationsvc.NotificationService
+	challenges    *challenge.Challenge
+	bus           eventbus.Bus
+	hooks         []CommentHook
+}
+
+func NewCommentService(repo repository.CommentRepository, postRepo postrepo.PostRepository, userRepo userrepo.UserRepository, followRepo followrepo.FollowRepository, closeFriends closefriendrepo.CloseFriendRepository, restrictions restrictionrepo.RestrictionRepository, orgs orgrepo.OrganizationRepository, notifications notificationsvc.NotificationService, challenges *challenge.Challenge, bus eventbus.Bus) CommentService {
+	return &commentService{repo: repo, postRepo: postRepo, userRepo: userRepo, followRepo: followRepo, closeFriends: closeFriends, restrictions: restrictions, orgs: orgs, notifications: notifications, challenges: challenges, bus: bus}
+}
+
+// Create stores a comment on a post the commenter can see and whose comment
+// policy lets them in. Comments by users the post author restricted wait for
+// the author's approval. Replies are addressed to the parent comment's
+// author, who is notified once the reply is published. Risky commenters
+// must pass a challenge first, and every comment must pass the hooks.
+func (s *commentService) Create(ctx context.Context, comment *model.Comment) error {
+	post, err := s.postRepo.GetByID(ctx, comment.PostID)
+	if err != nil {
+		return err
+	}
+	if post.CloseFriends && post.UserID != comment.UserID {
+		ok, err := s.closeFriends.IsCloseFriend(ctx, post.UserID, comment.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to check close friends: %w", err)
+		}
+		if !ok {
+			return apperr.NotFound("post not found")
+		}
+	}
+	if post.OrganizationID != nil {
+		if _, err := s.orgs.GetMember(ctx, *post.OrganizationID, comment.UserID); err != nil {
+			if errors.Is(err, apperr.ErrNotFound) {
+				return apperr.NotFound("post not found")
+			}
+			return fmt.Errorf("failed to check organization membership: %w", err)
+		}
+	}
+	if err := s.checkPolicy(ctx, post, comment.UserID); err != nil {
+		return err
+	}
+	comment.ReplyToUserID = nil
+	if comment.ParentID != nil {
+		parent, err := s.repo.GetByID(ctx, *comment.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent.PostID != post.ID {
+			return apperr.NotFound("comment not found")
+		}
+		comment.ReplyToUserID = &parent.UserID
+	}
+
+	if err := s.challenges.Require(ctx, comment.UserID, challenge.ActionPost); err != nil {
+		return err
+	}
+	for _, hook := range s.hooks {
+		if err := hook(ctx, post, comment); err != nil {
+			return err
+		}
+	}
+
+	restricted, err := s.restrictions.IsRestricted(ctx, post.UserID, comment.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to check restriction: %w", err)
+	}
+	comment.PendingApproval = restricted
+	if err := s.repo.Create(ctx, comment); err != nil {
+		return err
+	}
+	if !comment.PendingApproval {
+		s.published(ctx, post, comment)
+	}
+	return nil
+}
+
+// OnCreate registers a hook run before every comment is stored
+func (s *commentService) OnCreate(hook CommentHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// Approve publishes a comment awaiting approval; only the post author may.
+// Approving a published comment is a no-op.
+func (s *commentService) Approve(ctx context.Context, userID, commentID int64) error {
+	comment, err := s.repo.GetByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	post, err := s.postRepo.GetByID(ctx, comment.PostID)
+	if err != nil {
+		return err
+	}
+	if post.UserID != userID {
+		return apperr.Forbidden("only the post author can approve comments")
+	}
+	approved, err := s.repo.Approve(ctx, comment)
+	if err != nil {
+		return fmt.Errorf("failed to approve comment: %w", err)
+	}
+	if approved {
+		s.published(ctx, post, comment)
+	}
+	return nil
+}
+
+// published announces a comment once others can see it. Neither a failed
+// notification nor a failed event fails the comment.
+func (s *commentService) published(ctx context.Context, post *model.Post, comment *model.Comment) {
+	s.notifyReply(ctx, comment)
+	err := s.bus.Publish(ctx, event.CommentAdded{
+		CommentID:    comment.ID,
+		PostID:       post.ID,
+		PostAuthorID: post.UserID,
+		UserID:       comment.UserID,
+		ParentID:     comment.ParentID,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to publish comment", slog.Int64("comment_id", comment.ID), slog.Any("error", err))
+	}
+}
+
+// notifyReply tells the parent comment's author about a published reply.
+// This is separate from any notice to the post author, so someone replying
+// under their own post still reaches the commenter. A failed notification
+// does not fail the reply.
+func (s *commentService) notifyReply(ctx context.Context, comment *model.Comment) {
+	if comment.ReplyToUserID == nil {
+		return
+	}
+	err := s.notifications.Notify(ctx, &model.Notification{
+		UserID:      *comment.ReplyToUserID,
+		ActorID:     comment.UserID,
+		Type:        types.NotificationTypeComment,
+		TargetType:  types.NotificationTargetComment,
+		TargetID:    comment.ID,
+		TemplateKey: model.NotificationKeyReply,
+		Params:      map[string]string{"excerpt": notificationsvc.Excerpt(comment.Content)},
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to notify reply", slog.Int64("comment_id", comment.ID), slog.Any("error", err))
+	}
+}
+
+// checkPolicy returns ErrCommentsLimited unless the post's comment policy
+// admits the commenter; the author may always comment
+func (s *commentService) checkPolicy(ctx context.Context, post *model.Post, commenterID int64) error {
+	if commenterID == post.UserID {
+		return nil
+	}
+	switch post.CommentPolicy {
+	case types.CommentPolicyEveryone:
+		return nil
+	case types.CommentPolicyFollowers:
+		ok, err := s.followRepo.IsFollowing(ctx, commenterID, post.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to check follow: %w", err)
+		}
+		if ok {
+			return nil
+		}
+	case types.CommentPolicyMentioned:
+		commenter, err := s.userRepo.GetByID(ctx, commenterID)
+		if err != nil {
+			return err
+		}
+		for _, m := range mentionPattern.FindAllStringSubmatch(post.Content, -1) {
+			if strings.EqualFold(m[1], commenter.Username) {
+				return nil
+			}
+		}
+	}
+	return ErrCommentsLimited
+}
+
+// SetPolicy changes who may comment on one of the user's posts; comments
+// already made stay
+func (s *commentService) SetPolicy(ctx context.Context, userID, postID int64, policy types.CommentPolicy) error {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if post.UserID != userID {
+		return apperr.Forbidden("only the post author can change who may comment")
+	}
+	return s.postRepo.Update(ctx, postID, map[string]any{"comment_policy": policy})
+}
@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/community/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type CommunityHandler struct {
+	service service.CommunityService
+}
+
+func NewCommunityHandler(svc service.CommunityService) *CommunityHandler {
+	return &CommunityHandler{service: svc}
+}
+
+// Register mounts the community and membership routes; they expect an
+// authenticated user in the request context
+func (h *CommunityHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /communities", h.Create)
+	mux.HandleFunc("GET /communities/{id}", h.Get)
+	mux.HandleFunc("POST /communities/{id}/join", h.Join)
+	mux.HandleFunc("POST /communities/{id}/leave", h.Leave)
+	mux.HandleFunc("GET /communities/{id}/requests", h.Requests)
+	mux.HandleFunc("POST /communities/{id}/requests/{userID}/approve", h.Approve)
+	mux.HandleFunc("DELETE /communities/{id}/requests/{userID}", h.Reject)
+	mux.HandleFunc("POST /communities/{id}/posts", h.CreatePost)
+	mux.HandleFunc("GET /communities/{id}/feed", h.Feed)
+}
+
+// Create starts a community owned by the caller
+func (h *CommunityHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		Slug        string `json:"slug"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		AvatarURL   string `json:"avatar_url"`
+		IsPrivate   bool   `json:"is_private"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	body.Slug = strings.ToLower(strings.TrimSpace(body.Slug))
+	body.Name = strings.TrimSpace(body.Name)
+	body.Description = strings.TrimSpace(body.Description)
+	if !validSlug(body.Slug) {
+		httpx.Error(w, http.StatusBadRequest, "slug must be 2 to 50 lowercase letters, digits or hyphens")
+		return
+	}
+	if body.Name == "" || utf8.RuneCountInString(body.Name) > service.MaxNameLength {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("name must be 1 to %d characters", service.MaxNameLength))
+		return
+	}
+	if utf8.RuneCountInString(body.Description) > service.MaxDescriptionLength {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("description must be at most %d characters", service.MaxDescriptionLength))
+		return
+	}
+	if len(body.AvatarURL) > 255 {
+		httpx.Error(w, http.StatusBadRequest, "avatar_url must be at most 255 characters")
+		return
+	}
+
+	community := &model.Community{
+		Slug:        body.Slug,
+		Name:        body.Name,
+		Description: body.Description,
+		AvatarURL:   body.AvatarURL,
+		OwnerID:     userID,
+		IsPrivate:   body.IsPrivate,
+	}
+	if err := h.service.Create(r.Context(), community); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, community)
+}
+
+// Get returns a community
+func (h *CommunityHandler) Get(w http.ResponseWriter, r *http.Request) {
+	_, communityID, ok := caller(w, r)
+	if !ok {
+		return
+	}
+
+	community, err := h.service.Get(r.Context(), communityID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, community)
+}
+
+// Join makes the caller a member of a public community, or asks to join a
+// private one
+func (h *CommunityHandler) Join(w http.ResponseWriter, r *http.Request) {
+	userID, communityID, ok := caller(w, r)
+	if !ok {
+		return
+	}
+
+	member, err := h.service.Join(r.Context(), communityID, userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	status := http.StatusCreated
+	if member.Status == types.MembershipStatusPending {
+		status = http.StatusAccepted
+	}
+	httpx.JSON(w, status, member)
+}
+
+// Leave ends the caller's membership or withdraws their request to join
+func (h *CommunityHandler) Leave(w http.ResponseWriter, r *http.Request) {
+	userID, communityID, ok := caller(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Leave(r.Context(), communityID, userID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Requests lists the requests to join waiting for a moderator
+func (h *CommunityHandler) Requests(w http.ResponseWriter, r *http.Request) {
+	userID, communityID, ok := caller(w, r)
+	if !ok {
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	requests, err := h.service.PendingRequests(r.Context(), communityID, userID, limit, offset)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"requests": requests})
+}
+
+// Approve lets a user who asked to join in; moderators only
+func (h *CommunityHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	userID, communityID, ok := caller(w, r)
+	if !ok {
+		return
+	}
+	memberID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.service.Approve(r.Context(), communityID, userID, memberID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Reject declines a request to join; moderators only
+func (h *CommunityHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	userID, communityID, ok := caller(w, r)
+	if !ok {
+		return
+	}
+	memberID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.service.Reject(r.Context(), communityID, userID, memberID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreatePost posts in a community the caller is a member of
+func (h *CommunityHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
+	userID, communityID, ok := caller(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Content   string          `json:"content"`
+		MediaType types.MediaType `json:"media_type"`
+		MediaURL  string          `json:"media_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	body.Content = strings.TrimSpace(body.Content)
+	if body.Content == "" || utf8.RuneCountInString(body.Content) > service.MaxPostLength {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("content must be 1 to %d characters", service.MaxPostLength))
+		return
+	}
+	if body.MediaType == types.MediaTypeUnknown {
+		body.MediaType = types.MediaTypeText
+	}
+
+	post := &model.Post{UserID: userID, Content: body.Content, MediaType: body.MediaType, MediaURL: body.MediaURL, IsPublic: true}
+	if err := h.service.CreatePost(r.Context(), communityID, post); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, dto.NewPostResponse(post))
+}
+
+// Feed returns a page of the community's posts; private communities show
+// them to members only
+func (h *CommunityHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	userID, communityID, ok := caller(w, r)
+	if !ok {
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 20), 1), 100)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	posts, err := h.service.Feed(r.Context(), communityID, userID, limit, offset)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"posts": posts})
+}
+
+// caller returns the authenticated user and the {id} community of the request
+func caller(w http.ResponseWriter, r *http.Request) (userID, communityID int64, ok bool) {
+	userID, ok = logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return 0, 0, false
+	}
+	communityID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid community id")
+		return 0, 0, false
+	}
+	return userID, communityID, true
+}
+
+func validSlug(s string) bool {
+	if len(s) < 2 || len(s) > 50 || s[0] == '-' || s[len(s)-1] == '-' {
+		return false
+	}
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/module/community/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type ModerationHandler struct {
+	service service.CommunityService
+}
+
+func NewModerationHandler(svc service.CommunityService) *ModerationHandler {
+	return &ModerationHandler{service: svc}
+}
+
+// Register mounts the community auto-moderation routes; they expect an
+// authenticated user in the request context
+func (h *ModerationHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /communities/{id}/moderation", h.Settings)
+	mux.HandleFunc("PUT /communities/{id}/moderation", h.UpdateSettings)
+	mux.HandleFunc("POST /comments/{id}/report", h.Report)
+	mux.HandleFunc("POST /comments/{id}/restore", h.Restore)
+}
+
+// Settings returns a community's filter rules and auto-hide threshold to its moderators
+func (h *ModerationHandler) Settings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	communityID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid community id")
+		return
+	}
+
+	settings, err := h.service.ModerationSettings(r.Context(), communityID, userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, settings)
+}
+
+// UpdateSettings replaces a community's filter rules and auto-hide threshold
+func (h *ModerationHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	communityID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid community id")
+		return
+	}
+
+	var body dto.CommunityModeration
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(body.FilterRules) > service.MaxFilterRules {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("at most %d filter rules are allowed", service.MaxFilterRules))
+		return
+	}
+	for i, rule := range body.FilterRules {
+		rule = strings.TrimSpace(rule)
+		if utf8.RuneCountInString(rule) > service.MaxFilterRuleLength || !service.ValidFilterRule(rule) {
+			httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("filter rules must be at most %d characters with a letter or digit", service.MaxFilterRuleLength))
+			return
+		}
+		body.FilterRules[i] = rule
+	}
+	if body.AutoHideReports < 0 || body.AutoHideReports > service.MaxAutoHideReports {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("auto_hide_reports must be 0 to %d", service.MaxAutoHideReports))
+		return
+	}
+
+	if err := h.service.SetModerationSettings(r.Context(), communityID, userID, &body); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, body)
+}
+
+// Report files the caller's report of a comment on a community post
+func (h *ModerationHandler) Report(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	commentID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid comment id")
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	// The reason is optional, and so is the body
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	body.Reason = strings.TrimSpace(body.Reason)
+	if utf8.RuneCountInString(body.Reason) > service.MaxReportReasonLength {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("reason must be at most %d characters", service.MaxReportReasonLength))
+		return
+	}
+
+	if err := h.service.ReportComment(r.Context(), commentID, userID, body.Reason); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Restore lets a moderator show a comment hidden over its reports again
+func (h *ModerationHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	commentID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid comment id")
+		return
+	}
+
+	if err := h.service.RestoreComment(r.Context(), commentID, userID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
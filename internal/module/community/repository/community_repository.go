@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CommunityRepository interface {
+	Create(ctx context.Context, community *model.Community) error
+	Update(ctx context.Context, id int64, updates map[string]any) error
+	GetByID(ctx context.Context, id int64) (*model.Community, error)
+	GetBySlug(ctx context.Context, slug string) (*model.Community, error)
+	Delete(ctx context.Context, id int64) error
+	ListByMember(ctx context.Context, userID int64, limit, offset int) ([]*model.Community, error)
+
+	GetMember(ctx context.Context, communityID, userID int64) (*model.CommunityMember, error)
+	AddMember(ctx context.Context, member *model.CommunityMember) error
+	SetMemberStatus(ctx context.Context, communityID, userID int64, status types.MembershipStatus) error
+	SetMemberRole(ctx context.Context, communityID, userID int64, role types.CommunityRole) error
+	RemoveMember(ctx context.Context, communityID, userID int64) error
+	ListMembers(ctx context.Context, communityID int64, status types.MembershipStatus, limit, offset int) ([]*model.CommunityMember, error)
+
+	CreatePost(ctx context.Context, post *model.Post) error
+	DeletePost(ctx context.Context, communityID, postID int64) (authorID int64, err error)
+
+	FilterRules(ctx context.Context, communityID int64) ([]*model.CommunityFilterRule, error)
+	SetFilterRules(ctx context.Context, communityID int64, patterns []string) error
+	GetComment(ctx context.Context, commentID int64) (comment *model.Comment, communityID int64, err error)
+	ReportComment(ctx context.Context, report *model.CommentReport) (reports int64, err error)
+	HideComment(ctx context.Context, commentID int64, at time.Time) (bool, error)
+	RestoreComment(ctx context.Context, commentID int64) (bool, error)
+}
+
+func NewCommunityRepository(db *gorm.DB) CommunityRepository {
+	return &communityRepository{db: db}
+}
+
+type communityRepository struct {
+	db *gorm.DB
+}
+
+// Create stores the community together with its owner's active membership
+func (r *communityRepository) Create(ctx context.Context, community *model.Community) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		community.MemberCount = 1
+		if err := tx.Create(community).Error; err != nil {
+			return apperr.Translate(err, "community")
+		}
+		return tx.Create(&model.CommunityMember{
+			CommunityID: community.ID,
+			UserID:      community.OwnerID,
+			Role:        types.CommunityRoleOwner,
+			Status:      types.MembershipStatusActive,
+		}).Error
+	})
+}
+
+func (r *communityRepository) Update(ctx context.Context, id int64, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.Community{}).Where("id = ? AND deleted_at IS NULL", id).Updates(updates).Error
+}
+
+func (r *communityRepository) GetByID(ctx context.Context, id int64) (*model.Community, error) {
+	var community model.Community
+	if err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&community).Error; err != nil {
+		return nil, apperr.Translate(err, "community")
+	}
+	return &community, nil
+}
+
+func (r *communityRepository) GetBySlug(ctx context.Context, slug string) (*model.Community, error) {
+	var community model.Community
+	if err := r.db.WithContext(ctx).Where("slug = ? AND deleted_at IS NULL", slug).First(&community).Error; err != nil {
+		return nil, apperr.Translate(err, "community")
+	}
+	return &community, nil
+}
+
+func (r *communityRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).Delete(&model.Community{}).Error
+}
+
+// ListByMember returns the communities the user is an active member of
+func (r *communityRepository) ListByMember(ctx context.Context, userID int64, limit, offset int) ([]*model.Community, error) {
+	var communities []*model.Community
+	err := r.db.WithContext(ctx).Table("communities").
+		Select("communities.*").
+		Joins(`INNER JOIN community_members ON community_members.community_id = communities.id
+			AND community_members.user_id = ?
+			AND community_members.status = ?
+			AND community_members.deleted_at IS NULL`, userID, types.MembershipStatusActive).
+		Where("communities.deleted_at IS NULL").
+		Order("communities.name ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&communities).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list communities: %w", err)
+	}
+	return communities, nil
+}
+
+func (r *communityRepository) GetMember(ctx context.Context, communityID, userID int64) (*model.CommunityMember, error) {
+	var member model.CommunityMember
+	err := r.db.WithContext(ctx).
+		Where("community_id = ? AND user_id = ? AND deleted_at IS NULL", communityID, userID).
+		First(&member).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "community member")
+	}
+	return &member, nil
+}
+
+func (r *communityRepository) AddMember(ctx context.Context, member *model.CommunityMember) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(member).Error; err != nil {
+			return apperr.Translate(err, "community member")
+		}
+		if member.Status == types.MembershipStatusActive {
+			return adjustMemberCount(tx, member.CommunityID, 1)
+		}
+		return nil
+	})
+}
+
+// SetMemberStatus changes a membership status, keeping member_count in step
+// with the number of active members
+func (r *communityRepository) SetMemberStatus(ctx context.Context, communityID, userID int64, status types.MembershipStatus) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var member model.CommunityMember
+		if err := tx.Where("community_id = ? AND user_id = ? AND deleted_at IS NULL", communityID, userID).First(&member).Error; err != nil {
+			return apperr.Translate(err, "community member")
+		}
+		previous := member.Status
+		if previous == status {
+			return nil
+		}
+		if err := tx.Model(&member).Update("status", status).Error; err != nil {
+			return err
+		}
+		switch {
+		case status == types.MembershipStatusActive:
+			return adjustMemberCount(tx, communityID, 1)
+		case previous == types.MembershipStatusActive:
+			return adjustMemberCount(tx, communityID, -1)
+		}
+		return nil
+	})
+}
+
+func (r *communityRepository) SetMemberRole(ctx context.Context, communityID, userID int64, role types.CommunityRole) error {
+	return r.db.WithContext(ctx).Model(&model.CommunityMember{}).
+		Where("community_id = ? AND user_id = ? AND deleted_at IS NULL", communityID, userID).
+		Update("role", role).Error
+}
+
+// RemoveMember deletes the membership outright so the user can request to join again later
+func (r *communityRepository) RemoveMember(ctx context.Context, communityID, userID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var member model.CommunityMember
+		if err := tx.Where("community_id = ? AND user_id = ? AND deleted_at IS NULL", communityID, userID).First(&member).Error; err != nil {
+			return apperr.Translate(err, "community member")
+		}
+		if err := tx.Unscoped().Delete(&member).Error; err != nil {
+			return err
+		}
+		if member.Status == types.MembershipStatusActive {
+			return adjustMemberCount(tx, communityID, -1)
+		}
+		return nil
+	})
+}
+
+func (r *communityRepository) ListMembers(ctx context.Context, communityID int64, status types.MembershipStatus, limit, offset int) ([]*model.CommunityMember, error) {
+	var members []*model.CommunityMember
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("community_id = ? AND status = ? AND deleted_at IS NULL", communityID, status).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE role WHEN ? THEN 0 WHEN ? THEN 1 ELSE 2 END, created_at ASC",
+			Vars: []any{types.CommunityRoleOwner, types.CommunityRoleModerator},
+		}}).
+		Limit(limit).
+		Offset(offset).
+		Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// CreatePost stores a community post and bumps the community's post count
+func (r *communityRepository) CreatePost(ctx context.Context, post *model.Post) error {
+	if post.CommunityID == nil {
+		return fmt.Errorf("post has no community")
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := model.SetAuthorRegions(tx, post); err != nil {
+			return err
+		}
+		if err := tx.Create(post).Error; err != nil {
+			return apperr.Translate(err, "post")
+		}
+		return tx.Model(&model.Community{}).Where("id = ?", *post.CommunityID).
+			UpdateColumn("post_count", gorm.Expr("post_count + ?", 1)).Error
+	})
+}
+
+// DeletePost soft-deletes a post belonging to the community and returns its author
+func (r *communityRepository) DeletePost(ctx context.Context, communityID, postID int64) (int64, error) {
+	var post model.Post
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND community_id = ? AND deleted_at IS NULL", postID, communityID).First(&post).Error; err != nil {
+			return apperr.Translate(err, "post")
+		}
+		if err := tx.Delete(&post).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Community{}).Where("id = ? AND post_count > 0", communityID).
+			UpdateColumn("post_count", gorm.Expr("post_count - ?", 1)).Error
+	})
+	return post.UserID, err
+}
+
+// FilterRules returns the community's filter rules in the order they were set
+func (r *communityRepository) FilterRules(ctx context.Context, communityID int64) ([]*model.CommunityFilterRule, error) {
+	var rules []*model.CommunityFilterRule
+	err := r.db.WithContext(ctx).
+		Where("community_id = ? AND deleted_at IS NULL", communityID).
+		Order("id ASC").
+		Find(&rules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch filter rules: %w", err)
+	}
+	return rules, nil
+}
+
+// SetFilterRules replaces the community's filter rules with patterns
+func (r *communityRepository) SetFilterRules(ctx context.Context, communityID int64, patterns []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("community_id = ?", communityID).Delete(&model.CommunityFilterRule{}).Error; err != nil {
+			return err
+		}
+		if len(patterns) == 0 {
+			return nil
+		}
+		rules := make([]*model.CommunityFilterRule, len(patterns))
+		for i, pattern := range patterns {
+			rules[i] = &model.CommunityFilterRule{CommunityID: communityID, Pattern: pattern}
+		}
+		return tx.Create(&rules).Error
+	})
+}
+
+// GetComment returns a comment on a community post with the post's
+// community; comments outside communities are not found
+func (r *communityRepository) GetComment(ctx context.Context, commentID int64) (*model.Comment, int64, error) {
+	var row struct {
+		model.Comment
+		CommunityID int64
+	}
+	err := r.db.WithContext(ctx).Table("comments").
+		Select("comments.*, posts.community_id").
+		Joins("INNER JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		Where("comments.id = ? AND comments.deleted_at IS NULL AND posts.community_id IS NOT NULL", commentID).
+		Take(&row).Error
+	if err != nil {
+		return nil, 0, apperr.Translate(err, "comment")
+	}
+	return &row.Comment, row.CommunityID, nil
+}
+
+// ReportComment records a report and returns how many the comment has
+func (r *communityRepository) ReportComment(ctx context.Context, report *model.CommentReport) (int64, error) {
+	var reports int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(report).Error; err != nil {
+			return apperr.Translate(err, "report")
+		}
+		return tx.Model(&model.CommentReport{}).
+			Where("comment_id = ? AND deleted_at IS NULL", report.CommentID).
+			Count(&reports).Error
+	})
+	return reports, err
+}
+
+// HideComment hides a comment as of at, reporting false if it already was
+func (r *communityRepository) HideComment(ctx context.Context, commentID int64, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.Comment{}).
+		Where("id = ? AND hidden_at IS NULL AND deleted_at IS NULL", commentID).
+		UpdateColumn("hidden_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+// RestoreComment shows a hidden comment again and drops its reports, so it
+// takes as many fresh reports to hide it again; it reports false if the
+// comment was not hidden
+func (r *communityRepository) RestoreComment(ctx context.Context, commentID int64) (bool, error) {
+	restored := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Comment{}).
+			Where("id = ? AND hidden_at IS NOT NULL AND deleted_at IS NULL", commentID).
+			UpdateColumn("hidden_at", nil)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		restored = true
+		return tx.Unscoped().Where("comment_id = ?", commentID).Delete(&model.CommentReport{}).Error
+	})
+	return restored, err
+}
+
+func adjustMemberCount(tx *gorm.DB, communityID int64, delta int) error {
+	return tx.Model(&model.Community{}).Where("id = ?", communityID).
+		UpdateColumn("member_count", gorm.Expr("member_count + ?", delta)).Error
+}
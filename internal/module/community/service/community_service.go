@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/community/repository"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/challenge"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/quota"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// Limits on a community and its posts
+const (
+	MaxNameLength        = 100
+	MaxDescriptionLength = 1000
+	MaxPostLength        = 2200
+)
+
+var (
+	ErrCommunityNotFound = apperr.NotFound("community not found")
+	ErrNotMember         = apperr.Forbidden("not a member of this community")
+	ErrAlreadyMember     = apperr.Conflict("already a member or awaiting approval")
+	ErrBanned            = apperr.Forbidden("banned from this community")
+	ErrForbidden         = apperr.Forbidden("insufficient community role")
+	ErrOwnerCannotLeave  = apperr.Conflict("the owner cannot leave the community")
+	ErrPostRejected      = errors.New("post rejected by moderation")
+)
+
+// PostHook inspects a community post before it is stored; returning an error rejects the post
+type PostHook func(ctx context.Context, community *model.Community, post *model.Post) error
+
+type CommunityService interface {
+	Create(ctx context.Context, community *model.Community) error
+	Get(ctx context.Context, id int64) (*model.Community, error)
+	Join(ctx context.Context, communityID, userID int64) (*model.CommunityMember, error)
+	Leave(ctx context.Context, communityID, userID int64) error
+	Approve(ctx context.Context, communityID, moderatorID, userID int64) error
+	Reject(ctx context.Context, communityID, moderatorID, userID int64) error
+	Ban(ctx context.Context, communityID, moderatorID, userID int64) error
+	SetRole(ctx context.Context, communityID, ownerID, userID int64, role types.CommunityRole) error
+	PendingRequests(ctx context.Context, communityID, moderatorID int64, limit, offset int) ([]*model.CommunityMember, error)
+	CreatePost(ctx context.Context, communityID int64, post *model.Post) error
+	RemovePost(ctx context.Context, communityID, moderatorID, postID int64, reason string) error
+	Feed(ctx context.Context, communityID, viewerID int64, limit, offset int) ([]*dto.FeedPost, error)
+	OnPost(hook PostHook)
+
+	ModerationSettings(ctx context.Context, communityID, moderatorID int64) (*dto.CommunityModeration, error)
+	SetModerationSettings(ctx context.Context, communityID, moderatorID int64, settings *dto.CommunityModeration) error
+	FilterComment(ctx context.Context, post *model.Post, comment *model.Comment) error
+	ReportComment(ctx context.Context, commentID, reporterID int64, reason string) error
+	RestoreComment(ctx context.Context, commentID, moderatorID int64) error
+}
+
+type communityService struct {
+	repo     repository.CommunityRepository
+	feedRepo feedrepo.FeedRepository
+	bus      eventbus.Bus
+	hooks    []PostHook
+}
+
+func NewCommunityService(repo repository.CommunityRepository, feedRepo feedrepo.FeedRepository, bus eventbus.Bus) CommunityService {
+	return &communityService{repo: repo, feedRepo: feedRepo, bus: bus}
+}
+
+// LimitPosts returns a PostHook that charges every community post to its
+// author's daily post quota
+func LimitPosts(q *quota.Quota) PostHook {
+	return func(ctx context.Context, community *model.Community, post *model.Post) error {
+		_, err := q.Consume(ctx, post.UserID, quota.ActionPost)
+		return err
+	}
+}
+
+// RequireChallenge returns a PostHook that makes risky authors pass a
+// challenge before posting
+func RequireChallenge(c *challenge.Challenge) PostHook {
+	return func(ctx context.Context, community *model.Community, post *model.Post) error {
+		return c.Require(ctx, post.UserID, challenge.ActionPost)
+	}
+}
+
+// OnPost registers a moderation hook run before every community post is stored
+func (s *communityService) OnPost(hook PostHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+func (s *communityService) Create(ctx context.Context, community *model.Community) error {
+	if err := s.repo.Create(ctx, community); err != nil {
+		return fmt.Errorf("failed to create community: %w", err)
+	}
+	return nil
+}
+
+func (s *communityService) Get(ctx context.Context, id int64) (*model.Community, error) {
+	community, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, ErrCommunityNotFound
+	}
+	return community, err
+}
+
+// Join adds the user to a public community, or files a pending request for a private one
+func (s *communityService) Join(ctx context.Context, communityID, userID int64) (*model.CommunityMember, error) {
+	community, err := s.Get(ctx, communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.member(ctx, communityID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.Status == types.MembershipStatusBanned {
+			return nil, ErrBanned
+		}
+		return nil, ErrAlreadyMember
+	}
+
+	member := &model.CommunityMember{
+		CommunityID: communityID,
+		UserID:      userID,
+		Role:        types.CommunityRoleMember,
+		Status:      types.MembershipStatusActive,
+	}
+	if community.IsPrivate {
+		member.Status = types.MembershipStatusPending
+	}
+	if err := s.repo.AddMember(ctx, member); err != nil {
+		return nil, fmt.Errorf("failed to join community: %w", err)
+	}
+
+	if member.Status == types.MembershipStatusPending {
+		s.publish(ctx, event.CommunityJoinRequested{CommunityID: communityID, UserID: userID})
+	} else {
+		s.publish(ctx, event.CommunityMemberJoined{CommunityID: communityID, UserID: userID})
+	}
+	return member, nil
+}
+
+// Leave removes the user's membership or withdraws a pending request; bans are kept
+func (s *communityService) Leave(ctx context.Context, communityID, userID int64) error {
+	member, err := s.member(ctx, communityID, userID)
+	if err != nil {
+		return err
+	}
+	if member == nil || member.Status == types.MembershipStatusBanned {
+		return ErrNotMember
+	}
+	if member.Role == types.CommunityRoleOwner {
+		return ErrOwnerCannotLeave
+	}
+	if err := s.repo.RemoveMember(ctx, communityID, userID); err != nil {
+		return fmt.Errorf("failed to leave community: %w", err)
+	}
+	if member.Status == types.MembershipStatusActive {
+		s.publish(ctx, event.CommunityMemberLeft{CommunityID: communityID, UserID: userID})
+	}
+	return nil
+}
+
+// Approve accepts a pending join request
+func (s *communityService) Approve(ctx context.Context, communityID, moderatorID, userID int64) error {
+	if _, err := s.requireRole(ctx, communityID, moderatorID, types.CommunityRoleModerator); err != nil {
+		return err
+	}
+	member, err := s.member(ctx, communityID, userID)
+	if err != nil {
+		return err
+	}
+	if member == nil || member.Status != types.MembershipStatusPending {
+		return ErrNotMember
+	}
+	if err := s.repo.SetMemberStatus(ctx, communityID, userID, types.MembershipStatusActive); err != nil {
+		return fmt.Errorf("failed to approve member: %w", err)
+	}
+	s.publish(ctx, event.CommunityMemberJoined{CommunityID: communityID, UserID: userID, ApprovedBy: moderatorID})
+	return nil
+}
+
+// Reject declines a pending join request; the user may ask again later
+func (s *communityService) Reject(ctx context.Context, communityID, moderatorID, userID int64) error {
+	if _, err := s.requireRole(ctx, communityID, moderatorID, types.CommunityRoleModerator); err != nil {
+		return err
+	}
+	member, err := s.member(ctx, communityID, userID)
+	if err != nil {
+		return err
+	}
+	if member == nil || member.Status != types.MembershipStatusPending {
+		return ErrNotMember
+	}
+	return s.repo.RemoveMember(ctx, communityID, userID)
+}
+
+// Ban blocks a user from the community; moderators may only ban plain members
+func (s *communityService) Ban(ctx context.Context, communityID, moderatorID, userID int64) error {
+	actor, err := s.requireRole(ctx, communityID, moderatorID, types.CommunityRoleModerator)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.member(ctx, communityID, userID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		// Ban users who never joined so they cannot join later
+		err = s.repo.AddMember(ctx, &model.CommunityMember{
+			CommunityID: communityID,
+			UserID:      userID,
+			Role:        types.CommunityRoleMember,
+			Status:      types.MembershipStatusBanned,
+		})
+	} else {
+		if target.Role >= actor.Role {
+			return ErrForbidden
+		}
+		err = s.repo.SetMemberStatus(ctx, communityID, userID, types.MembershipStatusBanned)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to ban member: %w", err)
+	}
+
+	s.publish(ctx, event.CommunityMemberBanned{CommunityID: communityID, UserID: userID, ModeratorID: moderatorID})
+	return nil
+}
+
+// SetRole promotes or demotes an active member; only the owner may do this
+func (s *communityService) SetRole(ctx context.Context, communityID, ownerID, userID int64, role types.CommunityRole) error {
+	if _, err := s.requireRole(ctx, communityID, ownerID, types.CommunityRoleOwner); err != nil {
+		return err
+	}
+	if role != types.CommunityRoleMember && role != types.CommunityRoleModerator {
+		return fmt.Errorf("invalid community role: %s", role.String())
+	}
+	target, err := s.member(ctx, communityID, userID)
+	if err != nil {
+		return err
+	}
+	if target == nil || target.Status != types.MembershipStatusActive {
+		return ErrNotMember
+	}
+	if target.Role == types.CommunityRoleOwner {
+		return ErrForbidden
+	}
+	return s.repo.SetMemberRole(ctx, communityID, userID, role)
+}
+
+// PendingRequests lists join requests awaiting moderator approval
+func (s *communityService) PendingRequests(ctx context.Context, communityID, moderatorID int64, limit, offset int) ([]*model.CommunityMember, error) {
+	if _, err := s.requireRole(ctx, communityID, moderatorID, types.CommunityRoleModerator); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(ctx, communityID, types.MembershipStatusPending, limit, offset)
+}
+
+// CreatePost stores a post inside the community after checking it against
+// the community's filter rules and running moderation hooks. Posts in
+// private communities are never public, so they stay out of explore.
+func (s *communityService) CreatePost(ctx context.Context, communityID int64, post *model.Post) error {
+	community, err := s.Get(ctx, communityID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.requireRole(ctx, communityID, post.UserID, types.CommunityRoleMember); err != nil {
+		return err
+	}
+
+	post.CommunityID = &community.ID
+	post.IsPublic = post.IsPublic && !community.IsPrivate
+	if err := s.filter(ctx, communityID, post.Content); err != nil {
+		return fmt.Errorf("%w: %w", ErrPostRejected, err)
+	}
+	for _, hook := range s.hooks {
+		if err := hook(ctx, community, post); err != nil {
+			return fmt.Errorf("%w: %w", ErrPostRejected, err)
+		}
+	}
+
+	if err := s.repo.CreatePost(ctx, post); err != nil {
+		return fmt.Errorf("failed to create community post: %w", err)
+	}
+	s.publish(ctx, event.PostCreated{
+		PostID:      post.ID,
+		AuthorID:    post.UserID,
+		CommunityID: post.CommunityID,
+		IsPublic:    post.IsPublic,
+		CreatedAt:   post.CreatedAt,
+	})
+	return nil
+}
+
+// RemovePost lets a moderator take down a community post
+func (s *communityService) RemovePost(ctx context.Context, communityID, moderatorID, postID int64, reason string) error {
+	if _, err := s.requireRole(ctx, communityID, moderatorID, types.CommunityRoleModerator); err != nil {
+		return err
+	}
+
+	authorID, err := s.repo.DeletePost(ctx, communityID, postID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return fmt.Errorf("post %d is not in this community: %w", postID, err)
+		}
+		return fmt.Errorf("failed to remove community post: %w", err)
+	}
+
+	s.publish(ctx, event.CommunityPostRemoved{
+		CommunityID: communityID,
+		PostID:      postID,
+		AuthorID:    authorID,
+		ModeratorID: moderatorID,
+		Reason:      reason,
+	})
+	return nil
+}
+
+// Feed returns the community's posts; private communities are members only
+func (s *communityService) Feed(ctx context.Context, communityID, viewerID int64, limit, offset int) ([]*dto.FeedPost, error) {
+	community, err := s.Get(ctx, communityID)
+	if err != nil {
+		return nil, err
+	}
+	if community.IsPrivate {
+		if _, err := s.requireRole(ctx, communityID, viewerID, types.CommunityRoleMember); err != nil {
+			return nil, err
+		}
+	}
+	return s.feedRepo.GetCommunityFeed(ctx, communityID, viewerID, limit, offset)
+}
+
+// member returns the user's membership row, or nil when there is none
+func (s *communityService) member(ctx context.Context, communityID, userID int64) (*model.CommunityMember, error) {
+	member, err := s.repo.GetMember(ctx, communityID, userID)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load community member: %w", err)
+	}
+	return member, nil
+}
+
+// requireRole returns the user's active membership if it holds at least role
+func (s *communityService) requireRole(ctx context.Context, communityID, userID int64, role types.CommunityRole) (*model.CommunityMember, error) {
+	member, err := s.member(ctx, communityID, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case member == nil || member.Status == types.MembershipStatusPending:
+		return nil, ErrNotMember
+	case member.Status == types.MembershipStatusBanned:
+		return nil, ErrBanned
+	case member.Role < role:
+		return nil, ErrForbidden
+	}
+	return member, nil
+}
+
+func (s *communityService) publish(ctx context.Context, e eventbus.Event) {
+	if err := s.bus.Publish(ctx, e); err != nil {
+		slog.WarnContext(ctx, "failed to publish community event", slog.String("event", e.EventName()), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// Limits on a community's moderation settings
+const (
+	MaxFilterRules        = 200
+	MaxFilterRuleLength   = 100
+	MaxAutoHideReports    = 1000
+	MaxReportReasonLength = 200
+)
+
+var (
+	ErrContentFiltered = apperr.Forbidden("content contains words this community does not allow")
+	ErrCommentNotFound = apperr.NotFound("comment not found")
+	ErrAlreadyReported = apperr.Conflict("comment already reported")
+	ErrCannotReportOwn = apperr.Conflict("cannot report your own comment")
+)
+
+// ModerationSettings returns the community's filter rules and auto-hide
+// threshold; only moderators may see them
+func (s *communityService) ModerationSettings(ctx context.Context, communityID, moderatorID int64) (*dto.CommunityModeration, error) {
+	community, err := s.Get(ctx, communityID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.requireRole(ctx, communityID, moderatorID, types.CommunityRoleModerator); err != nil {
+		return nil, err
+	}
+	rules, err := s.repo.FilterRules(ctx, communityID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.NewCommunityModeration(community, rules), nil
+}
+
+// SetModerationSettings replaces the community's filter rules and auto-hide
+// threshold. The rules apply to posts and comments from now on; what is
+// already up stays.
+func (s *communityService) SetModerationSettings(ctx context.Context, communityID, moderatorID int64, settings *dto.CommunityModeration) error {
+	if _, err := s.Get(ctx, communityID); err != nil {
+		return err
+	}
+	if _, err := s.requireRole(ctx, communityID, moderatorID, types.CommunityRoleModerator); err != nil {
+		return err
+	}
+
+	if err := s.repo.SetFilterRules(ctx, communityID, settings.FilterRules); err != nil {
+		return fmt.Errorf("failed to set filter rules: %w", err)
+	}
+	if err := s.repo.Update(ctx, communityID, map[string]any{"auto_hide_reports": settings.AutoHideReports}); err != nil {
+		return fmt.Errorf("failed to set auto-hide threshold: %w", err)
+	}
+	return nil
+}
+
+// FilterComment rejects a comment on a community post that matches one of
+// the community's filter rules; it has the shape of a comment hook
+func (s *communityService) FilterComment(ctx context.Context, post *model.Post, comment *model.Comment) error {
+	if post.CommunityID == nil {
+		return nil
+	}
+	return s.filter(ctx, *post.CommunityID, comment.Content)
+}
+
+// filter returns ErrContentFiltered when text matches a filter rule of the community
+func (s *communityService) filter(ctx context.Context, communityID int64, text string) error {
+	rules, err := s.repo.FilterRules(ctx, communityID)
+	if err != nil {
+		return err
+	}
+	patterns := make([]string, len(rules))
+	for i, rule := range rules {
+		patterns[i] = rule.Pattern
+	}
+	if p := filterPattern(patterns); p != nil && p.MatchString(normalize(text)) {
+		return ErrContentFiltered
+	}
+	return nil
+}
+
+// ReportComment files a member's report of a comment on a community post and
+// hides the comment once its reports reach the community's threshold
+func (s *communityService) ReportComment(ctx context.Context, commentID, reporterID int64, reason string) error {
+	comment, communityID, err := s.repo.GetComment(ctx, commentID)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if comment.UserID == reporterID {
+		return ErrCannotReportOwn
+	}
+	community, err := s.Get(ctx, communityID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.requireRole(ctx, communityID, reporterID, types.CommunityRoleMember); err != nil {
+		return err
+	}
+
+	reports, err := s.repo.ReportComment(ctx, &model.CommentReport{CommentID: commentID, ReporterID: reporterID, Reason: reason})
+	if errors.Is(err, apperr.ErrConflict) {
+		return ErrAlreadyReported
+	}
+	if err != nil {
+		return fmt.Errorf("failed to report comment: %w", err)
+	}
+	if community.AutoHideReports == 0 || reports < int64(community.AutoHideReports) {
+		return nil
+	}
+
+	hidden, err := s.repo.HideComment(ctx, commentID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to hide comment: %w", err)
+	}
+	if hidden {
+		s.publish(ctx, event.CommunityCommentHidden{
+			CommunityID: communityID,
+			CommentID:   commentID,
+			AuthorID:    comment.UserID,
+			Reports:     reports,
+		})
+	}
+	return nil
+}
+
+// RestoreComment lets a moderator show a comment hidden over its reports again
+func (s *communityService) RestoreComment(ctx context.Context, commentID, moderatorID int64) error {
+	comment, communityID, err := s.repo.GetComment(ctx, commentID)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := s.requireRole(ctx, communityID, moderatorID, types.CommunityRoleModerator); err != nil {
+		return err
+	}
+
+	restored, err := s.repo.RestoreComment(ctx, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to restore comment: %w", err)
+	}
+	if restored {
+		s.publish(ctx, event.CommunityCommentRestored{
+			CommunityID: communityID,
+			CommentID:   commentID,
+			AuthorID:    comment.UserID,
+			ModeratorID: moderatorID,
+		})
+	}
+	return nil
+}
+
+// lookalikes are the characters written in place of letters to slip past filters
+var lookalikes = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t",
+	"@", "a", "$", "s",
+)
+
+// normalize lowercases text and turns look-alike characters into the
+// letters they stand for
+func normalize(text string) string {
+	return lookalikes.Replace(strings.ToLower(text))
+}
+
+// ValidFilterRule reports whether pattern has a letter or digit to match
+func ValidFilterRule(pattern string) bool {
+	return filterWords(pattern) != nil
+}
+
+// filterWords splits a normalized rule into its words, keeping their *s; it
+// returns nil for a rule with no letter or digit to match
+func filterWords(pattern string) []string {
+	words := strings.FieldsFunc(normalize(pattern), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '*'
+	})
+	var out []string
+	for _, word := range words {
+		if strings.Trim(word, "*") != "" {
+			out = append(out, word)
+		}
+	}
+	return out
+}
+
+// filterPattern compiles rules into one regexp matching any of them as whole
+// words of normalized text. Each letter matches a run of itself, so
+// stretched words match too, a * matches any letters and the words of a
+// phrase may be separated by anything but letters. It returns nil when
+// there is nothing to match.
+func filterPattern(rules []string) *regexp.Regexp {
+	var alternatives []string
+	for _, rule := range rules {
+		words := filterWords(rule)
+		parts := make([]string, 0, len(words))
+		for _, word := range words {
+			var b strings.Builder
+			for _, r := range word {
+				if r == '*' {
+					b.WriteString(`[\p{L}\p{N}]*`)
+					continue
+				}
+				b.WriteString(regexp.QuoteMeta(string(r)) + "+")
+			}
+			parts = append(parts, b.String())
+		}
+		if len(parts) > 0 {
+			alternatives = append(alternatives, strings.Join(parts, `[^\p{L}\p{N}]+`))
+		}
+	}
+	if len(alternatives) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?:^|[^\p{L}\p{N}])(?:` + strings.Join(alternatives, "|") + `)(?:$|[^\p{L}\p{N}])`)
+}
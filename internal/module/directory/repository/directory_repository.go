@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DirectoryRepository interface {
+	ListLinks(ctx context.Context, entryIDs []string) ([]*model.DirectoryUser, error)
+	SaveLinks(ctx context.Context, links []*model.DirectoryUser) error
+	Link(ctx context.Context, link *model.DirectoryUser) error
+	ListStale(ctx context.Context, before time.Time) ([]int64, error)
+	ListMembers(ctx context.Context) ([]*model.DirectoryUser, error)
+
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	UsernameTaken(ctx context.Context, username string) (bool, error)
+	Provision(ctx context.Context, user *model.User, link *model.DirectoryUser) error
+	UpdateUser(ctx context.Context, id int64, updates map[string]any) error
+	Suspend(ctx context.Context, ids []int64, at time.Time) error
+	Unsuspend(ctx context.Context, ids []int64) error
+
+	ListFollows(ctx context.Context) ([]*model.DirectoryFollow, error)
+	AddFollows(ctx context.Context, follows []*model.DirectoryFollow) (int, error)
+	RemoveFollows(ctx context.Context, follows []*model.DirectoryFollow) (int, error)
+}
+
+func NewDirectoryRepository(db *gorm.DB) DirectoryRepository {
+	return &directoryRepository{db: db}
+}
+
+type directoryRepository struct {
+	db *gorm.DB
+}
+
+// ListLinks returns the links of the given entries with their users. Users
+// who deleted their account are left as nil.
+func (r *directoryRepository) ListLinks(ctx context.Context, entryIDs []string) ([]*model.DirectoryUser, error) {
+	var links []*model.DirectoryUser
+	err := r.db.WithContext(ctx).Preload("User").Where("entry_id IN ?", entryIDs).Find(&links).Error
+	return links, err
+}
+
+// SaveLinks updates the DN, manager and sync time of linked entries
+func (r *directoryRepository) SaveLinks(ctx context.Context, links []*model.DirectoryUser) error {
+	if len(links) == 0 {
+		return nil
+	}
+	return apperr.Translate(r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "entry_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"dn", "manager_dn", "synced_at", "updated_at"}),
+	}).Create(&links).Error, "directory link")
+}
+
+// Link links a user to an entry. A user linked to another entry, one that
+// was recreated in the directory, moves to the new one.
+func (r *directoryRepository) Link(ctx context.Context, link *model.DirectoryUser) error {
+	return apperr.Translate(r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"entry_id", "dn", "manager_dn", "synced_at", "updated_at"}),
+	}).Create(link).Error, "directory link")
+}
+
+// ListStale returns the active users whose entries were last seen before
+// the given time, meaning they have left the directory
+func (r *directoryRepository) ListStale(ctx context.Context, before time.Time) ([]int64, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&model.DirectoryUser{}).
+		Joins("JOIN users ON users.id = directory_users.user_id AND users.deleted_at IS NULL AND users.suspended_at IS NULL").
+		Where("directory_users.synced_at < ?", before).
+		Pluck("directory_users.user_id", &ids).Error
+	return ids, err
+}
+
+// ListMembers returns the links of users who are neither suspended nor
+// deleted, who make up the org chart
+func (r *directoryRepository) ListMembers(ctx context.Context) ([]*model.DirectoryUser, error) {
+	var links []*model.DirectoryUser
+	err := r.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = directory_users.user_id AND users.deleted_at IS NULL AND users.suspended_at IS NULL").
+		Find(&links).Error
+	return links, err
+}
+
+func (r *directoryRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).Where("LOWER(email) = LOWER(?) AND deleted_at IS NULL", email).First(&user).Error; err != nil {
+		return nil, apperr.Translate(err, "user")
+	}
+	return &user, nil
+}
+
+// UsernameTaken also counts deleted accounts, whose usernames stay reserved
+func (r *directoryRepository) UsernameTaken(ctx context.Context, username string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).Where("LOWER(username) = LOWER(?)", username).Count(&count).Error
+	return count > 0, err
+}
+
+// Provision creates a user and links them to their directory entry
+func (r *directoryRepository) Provision(ctx context.Context, user *model.User, link *model.DirectoryUser) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return apperr.Translate(err, "user")
+		}
+		link.UserID = user.ID
+		return apperr.Translate(tx.Create(link).Error, "directory link")
+	})
+}
+
+func (r *directoryRepository) UpdateUser(ctx context.Context, id int64, updates map[string]any) error {
+	return apperr.Translate(r.db.WithContext(ctx).Model(&model.User{}).Where("id = ? AND deleted_at IS NULL", id).Updates(updates).Error, "user")
+}
+
+// Suspend deactivates users on behalf of the directory
+func (r *directoryRepository) Suspend(ctx context.Context, ids []int64, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id IN ? AND suspended_at IS NULL AND deleted_at IS NULL", ids).
+		Updates(map[string]any{
+			"suspended_at":   at,
+			"deactivated_at": gorm.Expr("COALESCE(deactivated_at, ?)", at),
+		}).Error
+}
+
+// Unsuspend lifts suspensions and reactivates the accounts
+func (r *directoryRepository) Unsuspend(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id IN ? AND suspended_at IS NOT NULL AND deleted_at IS NULL", ids).
+		Updates(map[string]any{"suspended_at": nil, "deactivated_at": nil}).Error
+}
+
+func (r *directoryRepository) ListFollows(ctx context.Context) ([]*model.DirectoryFollow, error) {
+	var follows []*model.DirectoryFollow
+	err := r.db.WithContext(ctx).Find(&follows).Error
+	return follows, err
+}
+
+// AddFollows records follows the sync wants and creates those that do not
+// exist yet, in one transaction. A follow the user removed themselves is
+// not recreated. It returns how many follows were created.
+func (r *directoryRepository) AddFollows(ctx context.Context, follows []*model.DirectoryFollow) (int, error) {
+	created := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		created = 0
+		for _, f := range follows {
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.Follow{FollowerID: f.FollowerID, FollowingID: f.FollowingID})
+			if res.Error != nil {
+				return res.Error
+			}
+			f.Owned = res.RowsAffected > 0
+			if f.Owned {
+				created++
+			}
+		}
+		return tx.Create(&follows).Error
+	})
+	return created, err
+}
+
+// RemoveFollows forgets follows the sync no longer wants and deletes those
+// it created, in one transaction. It returns how many follows were deleted.
+func (r *directoryRepository) RemoveFollows(ctx context.Context, follows []*model.DirectoryFollow) (int, error) {
+	removed := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		removed = 0
+		for _, f := range follows {
+			if f.Owned {
+				res := tx.Unscoped().Where("follower_id = ? AND following_id = ?", f.FollowerID, f.FollowingID).Delete(&model.Follow{})
+				if res.Error != nil {
+					return res.Error
+				}
+				removed += int(res.RowsAffected)
+			}
+			if err := tx.Unscoped().Delete(f).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
@@ -0,0 +1,470 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/directory/repository"
+	usersvc "github.com/ilhamosaurus/sns-platform/internal/module/user/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/ldapdir"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+)
+
+// accountDisabled is the ACCOUNTDISABLE flag of Active Directory's
+// userAccountControl attribute
+const accountDisabled = 0x2
+
+// Source reads user entries from the directory a page at a time
+type Source interface {
+	Search(ctx context.Context, attributes []string, fn func([]*ldapdir.Entry) error) error
+}
+
+// Attributes names the directory attributes mapped onto users. Empty names
+// fall back to Active Directory's.
+type Attributes struct {
+	ID       string // Stable entry identifier: objectGUID, entryUUID
+	Username string // sAMAccountName, uid
+	Email    string // mail
+	FullName string // displayName, cn
+	Manager  string // DN of the entry's manager
+}
+
+type Config struct {
+	TenantID   int64 // Tenant the synced accounts belong to
+	Attributes Attributes
+
+	// AutoFollow makes everyone follow their manager and direct reports
+	// and, in teams of at most MaxTeamSize, the teammates who share their
+	// manager
+	AutoFollow  bool
+	MaxTeamSize int
+	BatchSize   int // Follows written per transaction
+
+	StripEmailPlus    bool     // Passed to usersvc.NormalizeEmail
+	ReservedUsernames []string // Passed to usersvc.NewUsernamePolicy
+}
+
+// SyncResult counts what a sync changed
+type SyncResult struct {
+	Created        int // Accounts created for new entries
+	Linked         int // Existing accounts linked to new entries by email
+	Updated        int // Accounts whose email or name changed
+	Suspended      int // Accounts disabled in or removed from the directory
+	Reactivated    int // Accounts enabled again
+	Skipped        int // Entries without the attributes an account needs, or that conflict with another account
+	FollowsAdded   int
+	FollowsRemoved int
+}
+
+// DirectoryService syncs accounts and the org chart from an LDAP directory
+type DirectoryService interface {
+	Sync(ctx context.Context) (*SyncResult, error)
+}
+
+type directoryService struct {
+	repo      repository.DirectoryRepository
+	source    Source
+	audit     auditsvc.AuditService
+	config    Config
+	usernames *usersvc.UsernamePolicy
+}
+
+func NewDirectoryService(repo repository.DirectoryRepository, source Source, audit auditsvc.AuditService, config Config) DirectoryService {
+	attrs := &config.Attributes
+	attrs.ID = cmp.Or(attrs.ID, "objectGUID")
+	attrs.Username = cmp.Or(attrs.Username, "sAMAccountName")
+	attrs.Email = cmp.Or(attrs.Email, "mail")
+	attrs.FullName = cmp.Or(attrs.FullName, "displayName")
+	attrs.Manager = cmp.Or(attrs.Manager, "manager")
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	return &directoryService{repo: repo, source: source, audit: audit, config: config, usernames: usersvc.NewUsernamePolicy(config.ReservedUsernames)}
+}
+
+// entry is a directory entry mapped onto account fields
+type entry struct {
+	id        string
+	dn        string
+	managerDN string
+	username  string
+	email     string
+	fullName  string
+	disabled  bool
+}
+
+// Sync creates, updates, suspends and reactivates accounts to match the
+// directory, a page of entries at a time, then brings the follows between
+// teammates in line with the org chart. Accounts whose entries were not
+// seen are suspended; a search that finds nobody changes nothing, as that
+// is more likely a misconfigured filter than an empty company.
+func (s *directoryService) Sync(ctx context.Context) (*SyncResult, error) {
+	ctx = tenant.WithID(ctx, s.config.TenantID)
+	// Whole seconds, so databases storing less precision compare alike
+	start := time.Now().UTC().Truncate(time.Second)
+	result := &SyncResult{}
+
+	seen := 0
+	attrs := s.config.Attributes
+	err := s.source.Search(ctx, []string{attrs.ID, attrs.Username, attrs.Email, attrs.FullName, attrs.Manager, "userAccountControl"}, func(page []*ldapdir.Entry) error {
+		entries := make([]*entry, 0, len(page))
+		for _, e := range page {
+			if mapped, ok := s.entry(e); ok {
+				entries = append(entries, mapped)
+			} else {
+				result.Skipped++
+			}
+		}
+		seen += len(entries)
+		return s.syncPage(ctx, entries, start, result)
+	})
+	if err != nil {
+		return result, err
+	}
+	if seen == 0 {
+		slog.WarnContext(ctx, "directory sync found no users, leaving accounts as they are")
+		return result, nil
+	}
+
+	stale, err := s.repo.ListStale(ctx, start)
+	if err != nil {
+		return result, err
+	}
+	for batch := range slices.Chunk(stale, s.config.BatchSize) {
+		if err := s.repo.Suspend(ctx, batch, start); err != nil {
+			return result, err
+		}
+		for _, id := range batch {
+			s.record(ctx, model.AuditProvisionUpdateUser, id, map[string]any{"active": false, "reason": "removed from directory"})
+		}
+		result.Suspended += len(batch)
+	}
+
+	if s.config.AutoFollow {
+		if err := s.syncFollows(ctx, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (s *directoryService) syncPage(ctx context.Context, entries []*entry, now time.Time, result *SyncResult) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.id
+	}
+	links, err := s.repo.ListLinks(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byEntry := make(map[string]*model.DirectoryUser, len(links))
+	for _, link := range links {
+		byEntry[link.EntryID] = link
+	}
+
+	var touched []*model.DirectoryUser
+	var suspend, reactivate []int64
+	for _, e := range entries {
+		link := byEntry[e.id]
+		if link == nil {
+			user, err := s.link(ctx, e, now, result)
+			if err != nil {
+				return err
+			}
+			if user != nil && user.IsSuspended() && !e.disabled {
+				reactivate = append(reactivate, user.ID)
+			}
+			continue
+		}
+
+		link.DN, link.ManagerDN, link.SyncedAt = e.dn, e.managerDN, now
+		touched = append(touched, link)
+		// The owner deleted their account; keep the link so it is not recreated
+		if link.User == nil {
+			continue
+		}
+		if err := s.update(ctx, link.User, e, result); err != nil {
+			return err
+		}
+		switch {
+		case e.disabled && !link.User.IsSuspended():
+			suspend = append(suspend, link.UserID)
+		case !e.disabled && link.User.IsSuspended():
+			reactivate = append(reactivate, link.UserID)
+		}
+	}
+
+	if err := s.repo.SaveLinks(ctx, touched); err != nil {
+		return err
+	}
+	if err := s.repo.Suspend(ctx, suspend, now); err != nil {
+		return err
+	}
+	for _, id := range suspend {
+		s.record(ctx, model.AuditProvisionUpdateUser, id, map[string]any{"active": false, "reason": "disabled in directory"})
+	}
+	if err := s.repo.Unsuspend(ctx, reactivate); err != nil {
+		return err
+	}
+	for _, id := range reactivate {
+		s.record(ctx, model.AuditProvisionUpdateUser, id, map[string]any{"active": true})
+	}
+	result.Suspended += len(suspend)
+	result.Reactivated += len(reactivate)
+	return nil
+}
+
+// link links a new entry to the account with its email or, failing that,
+// creates one. Disabled entries get no account. It returns the linked
+// account, nil when the entry was skipped.
+func (s *directoryService) link(ctx context.Context, e *entry, now time.Time, result *SyncResult) (*model.User, error) {
+	if e.disabled {
+		return nil, nil
+	}
+	link := &model.DirectoryUser{EntryID: e.id, DN: e.dn, ManagerDN: e.managerDN, SyncedAt: now}
+
+	user, err := s.repo.GetUserByEmail(ctx, e.email)
+	switch {
+	case err == nil:
+		link.UserID = user.ID
+		if err := s.repo.Link(ctx, link); err != nil {
+			return nil, err
+		}
+		result.Linked++
+		return user, s.update(ctx, user, e, result)
+	case !errors.Is(err, apperr.ErrNotFound):
+		return nil, err
+	}
+
+	username, err := s.username(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	// Synced accounts sign in through single sign-on and have no password
+	user = &model.User{Username: username, Email: e.email, FullName: e.fullName}
+	if err := s.repo.Provision(ctx, user, link); err != nil {
+		if errors.Is(err, apperr.ErrConflict) {
+			slog.WarnContext(ctx, "directory sync skipped an entry that conflicts with another account", slog.String("dn", e.dn), slog.Any("error", err))
+			result.Skipped++
+			return nil, nil
+		}
+		return nil, err
+	}
+	result.Created++
+	s.record(ctx, model.AuditProvisionCreateUser, user.ID, map[string]any{"username": user.Username, "email": user.Email, "full_name": user.FullName})
+	return user, nil
+}
+
+// update brings the account's email and name in line with the entry
+func (s *directoryService) update(ctx context.Context, user *model.User, e *entry, result *SyncResult) error {
+	updates := make(map[string]any)
+	if user.Email != e.email {
+		updates["email"] = e.email
+	}
+	if e.fullName != "" && user.FullName != e.fullName {
+		updates["full_name"] = e.fullName
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := s.repo.UpdateUser(ctx, user.ID, updates); err != nil {
+		if errors.Is(err, apperr.ErrConflict) {
+			slog.WarnContext(ctx, "directory sync could not update an account", slog.Int64("user_id", user.ID), slog.String("dn", e.dn), slog.Any("error", err))
+			result.Skipped++
+			return nil
+		}
+		return err
+	}
+	result.Updated++
+	s.record(ctx, model.AuditProvisionUpdateUser, user.ID, updates)
+	return nil
+}
+
+// pair is a follow from follower to following
+type pair struct {
+	follower, following int64
+}
+
+// syncFollows adds the follows the org chart calls for and removes those
+// it no longer does, a batch per transaction
+func (s *directoryService) syncFollows(ctx context.Context, result *SyncResult) error {
+	members, err := s.repo.ListMembers(ctx)
+	if err != nil {
+		return err
+	}
+	want := teamFollows(members, s.config.MaxTeamSize)
+
+	existing, err := s.repo.ListFollows(ctx)
+	if err != nil {
+		return err
+	}
+	var remove []*model.DirectoryFollow
+	for _, f := range existing {
+		p := pair{f.FollowerID, f.FollowingID}
+		if _, ok := want[p]; ok {
+			delete(want, p)
+		} else {
+			remove = append(remove, f)
+		}
+	}
+	add := make([]*model.DirectoryFollow, 0, len(want))
+	for p := range want {
+		add = append(add, &model.DirectoryFollow{FollowerID: p.follower, FollowingID: p.following})
+	}
+	slices.SortFunc(add, func(a, b *model.DirectoryFollow) int {
+		return cmp.Or(cmp.Compare(a.FollowerID, b.FollowerID), cmp.Compare(a.FollowingID, b.FollowingID))
+	})
+
+	for batch := range slices.Chunk(remove, s.config.BatchSize) {
+		n, err := s.repo.RemoveFollows(ctx, batch)
+		if err != nil {
+			return err
+		}
+		result.FollowsRemoved += n
+	}
+	for batch := range slices.Chunk(add, s.config.BatchSize) {
+		n, err := s.repo.AddFollows(ctx, batch)
+		if err != nil {
+			return err
+		}
+		result.FollowsAdded += n
+	}
+	return nil
+}
+
+// teamFollows derives the follows of the org chart: everyone follows their
+// manager and direct reports, and teammates sharing a manager follow each
+// other when there are at most maxTeamSize of them. DNs compare without
+// case.
+func teamFollows(members []*model.DirectoryUser, maxTeamSize int) map[pair]struct{} {
+	byDN := make(map[string]int64, len(members))
+	for _, m := range members {
+		byDN[strings.ToLower(m.DN)] = m.UserID
+	}
+	reports := make(map[int64][]int64)
+	for _, m := range members {
+		if manager, ok := byDN[strings.ToLower(m.ManagerDN)]; ok && manager != m.UserID {
+			reports[manager] = append(reports[manager], m.UserID)
+		}
+	}
+
+	follows := make(map[pair]struct{})
+	for manager, team := range reports {
+		for _, member := range team {
+			follows[pair{member, manager}] = struct{}{}
+			follows[pair{manager, member}] = struct{}{}
+		}
+		if len(team) > maxTeamSize {
+			continue
+		}
+		for _, a := range team {
+			for _, b := range team {
+				if a != b {
+					follows[pair{a, b}] = struct{}{}
+				}
+			}
+		}
+	}
+	return follows
+}
+
+// entry maps a directory entry, reporting false when it lacks an
+// identifier or a usable email
+func (s *directoryService) entry(e *ldapdir.Entry) (*entry, bool) {
+	attrs := s.config.Attributes
+	mapped := &entry{
+		id:        strings.TrimSpace(e.Get(attrs.ID)),
+		dn:        e.DN,
+		managerDN: e.Get(attrs.Manager),
+		username:  strings.TrimSpace(e.Get(attrs.Username)),
+		email:     usersvc.NormalizeEmail(e.Get(attrs.Email), s.config.StripEmailPlus),
+		fullName:  truncate(strings.TrimSpace(e.Get(attrs.FullName)), 100),
+	}
+	if flags, err := strconv.ParseInt(e.Get("userAccountControl"), 10, 64); err == nil {
+		mapped.disabled = flags&accountDisabled != 0
+	}
+	if mapped.id == "" || len(mapped.id) > 255 || len(mapped.email) > 100 || !strings.Contains(mapped.email, "@") {
+		return nil, false
+	}
+	return mapped, true
+}
+
+// username picks a free, unreserved username from the entry's or, failing
+// that, the email's local part
+func (s *directoryService) username(ctx context.Context, e *entry) (string, error) {
+	base := sanitizeUsername(e.username)
+	if base == "" {
+		local, _, _ := strings.Cut(e.email, "@")
+		base = sanitizeUsername(local)
+	}
+	for len(base) < usersvc.MinUsernameLength {
+		base += "0"
+	}
+
+	candidate := base
+	for range 10 {
+		if !s.usernames.Reserved(candidate) {
+			taken, err := s.repo.UsernameTaken(ctx, candidate)
+			if err != nil {
+				return "", err
+			}
+			if !taken {
+				return candidate, nil
+			}
+		}
+		n, _ := rand.Int(rand.Reader, big.NewInt(10000))
+		suffix := fmt.Sprintf("%04d", n.Int64())
+		candidate = base[:min(len(base), usersvc.MaxUsernameLength-len(suffix))] + suffix
+	}
+	return "", apperr.Conflict("could not find a free username")
+}
+
+func (s *directoryService) record(ctx context.Context, action string, userID int64, after map[string]any) {
+	after["source"] = "ldap"
+	s.audit.Record(ctx, &model.AuditLog{
+		Action:     action,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userID,
+		After:      after,
+	})
+}
+
+// sanitizeUsername keeps lowercase letters, digits, dots and underscores
+func sanitizeUsername(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '.':
+			b.WriteRune(r)
+		case r == '-' || r == ' ':
+			b.WriteByte('_')
+		}
+		if b.Len() == usersvc.MaxUsernameLength {
+			break
+		}
+	}
+	return strings.Trim(b.String(), "._")
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/discovery/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type DiscoveryHandler struct {
+	service service.DiscoveryService
+}
+
+func NewDiscoveryHandler(svc service.DiscoveryService) *DiscoveryHandler {
+	return &DiscoveryHandler{service: svc}
+}
+
+// Register mounts the contact discovery routes; they expect an authenticated user in the request context
+func (h *DiscoveryHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/discoverability", h.Discoverability)
+	mux.HandleFunc("PUT /me/discoverability", h.SetDiscoverability)
+	mux.HandleFunc("POST /me/contacts/discover", h.Discover)
+}
+
+// Discoverability returns what the caller can be found by in others' address books
+func (h *DiscoveryHandler) Discoverability(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	settings, err := h.service.Discoverability(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, settings)
+}
+
+// SetDiscoverability changes the fields given of the caller's discoverability
+func (h *DiscoveryHandler) SetDiscoverability(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		DiscoverableByEmail *bool `json:"discoverable_by_email"`
+		DiscoverableByPhone *bool `json:"discoverable_by_phone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	settings, err := h.service.SetDiscoverability(r.Context(), userID, service.Settings{
+		DiscoverableByEmail: body.DiscoverableByEmail,
+		DiscoverableByPhone: body.DiscoverableByPhone,
+	})
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, settings)
+}
+
+// Discover suggests accounts to follow from the caller's address book,
+// uploaded as hex SHA-256 digests of trimmed, lowercased emails and of E.164
+// phone numbers
+func (h *DiscoveryHandler) Discover(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		EmailHashes []string `json:"email_hashes"`
+		PhoneHashes []string `json:"phone_hashes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(body.EmailHashes)+len(body.PhoneHashes) > service.MaxHashes {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("at most %d hashes can be uploaded at once", service.MaxHashes))
+		return
+	}
+	for _, hashes := range [][]string{body.EmailHashes, body.PhoneHashes} {
+		for _, hash := range hashes {
+			if b, err := hex.DecodeString(hash); err != nil || len(b) != 32 {
+				httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("%q is not a hex SHA-256 digest", hash))
+				return
+			}
+		}
+	}
+
+	users, err := h.service.Suggest(r.Context(), userID, body.EmailHashes, body.PhoneHashes)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"users": users})
+}
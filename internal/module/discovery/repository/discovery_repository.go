@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"gorm.io/gorm"
+)
+
+type DiscoveryRepository interface {
+	FindByHashes(ctx context.Context, userID int64, emailHashes, phoneHashes []string, limit int) ([]*model.User, error)
+}
+
+func NewDiscoveryRepository(db *gorm.DB) DiscoveryRepository {
+	return &discoveryRepository{db: db}
+}
+
+type discoveryRepository struct {
+	db *gorm.DB
+}
+
+// FindByHashes returns the active users whose email or verified phone hash
+// is among the given ones, for the identifiers they are discoverable by, whom
+// userID does not follow yet; the most followed come first
+func (r *discoveryRepository) FindByHashes(ctx context.Context, userID int64, emailHashes, phoneHashes []string, limit int) ([]*model.User, error) {
+	if len(emailHashes) == 0 && len(phoneHashes) == 0 {
+		return nil, nil
+	}
+
+	byEmail := r.db.Where("discoverable_by_email = ? AND email_hash IN ?", true, emailHashes)
+	byPhone := r.db.Where("discoverable_by_phone = ? AND phone_verified_at IS NOT NULL AND phone_hash IN ?", true, phoneHashes)
+	var match *gorm.DB
+	switch {
+	case len(phoneHashes) == 0:
+		match = byEmail
+	case len(emailHashes) == 0:
+		match = byPhone
+	default:
+		match = byEmail.Or(byPhone)
+	}
+
+	var users []*model.User
+	err := r.db.WithContext(ctx).
+		Where(match).
+		Where("id <> ? AND deactivated_at IS NULL AND banned_at IS NULL AND deleted_at IS NULL", userID).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM follows
+			WHERE follows.following_id = users.id
+				AND follows.follower_id = ?
+				AND follows.deleted_at IS NULL)`, userID).
+		Order("follower_count DESC, id ASC").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/discovery/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+)
+
+const (
+	MaxHashes      = 1000 // Most email and phone hashes one upload may hold
+	MaxSuggestions = 50   // Most accounts suggested from one upload
+)
+
+// ErrPhoneRequired is returned when a user without a verified phone number
+// turns on discovery by phone
+var ErrPhoneRequired = apperr.Conflict("verify a phone number to be discoverable by phone")
+
+// Settings changes what a user can be discovered by; nil fields are left as
+// they are
+type Settings struct {
+	DiscoverableByEmail *bool
+	DiscoverableByPhone *bool
+}
+
+// DiscoveryService matches the hashed contacts of an address book against
+// the users who opted in to being found by them. Only hashes reach the
+// server, and they are matched, never stored.
+type DiscoveryService interface {
+	Discoverability(ctx context.Context, userID int64) (*dto.Discoverability, error)
+	SetDiscoverability(ctx context.Context, userID int64, settings Settings) (*dto.Discoverability, error)
+	Suggest(ctx context.Context, userID int64, emailHashes, phoneHashes []string) ([]*dto.UserResponse, error)
+}
+
+type discoveryService struct {
+	repo     repository.DiscoveryRepository
+	userRepo userrepo.UserRepository
+	audit    auditsvc.AuditService
+}
+
+func NewDiscoveryService(repo repository.DiscoveryRepository, userRepo userrepo.UserRepository, audit auditsvc.AuditService) DiscoveryService {
+	return &discoveryService{repo: repo, userRepo: userRepo, audit: audit}
+}
+
+func (s *discoveryService) Discoverability(ctx context.Context, userID int64) (*dto.Discoverability, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return discoverabilityOf(user), nil
+}
+
+// SetDiscoverability applies the settings, audited as a privacy change;
+// discovery by phone needs a verified phone number
+func (s *discoveryService) SetDiscoverability(ctx context.Context, userID int64, settings Settings) (*dto.Discoverability, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	after := *user
+	if settings.DiscoverableByEmail != nil {
+		after.DiscoverableByEmail = *settings.DiscoverableByEmail
+	}
+	if settings.DiscoverableByPhone != nil {
+		after.DiscoverableByPhone = *settings.DiscoverableByPhone
+	}
+	if settings.DiscoverableByPhone != nil && *settings.DiscoverableByPhone && !user.HasVerifiedPhone() {
+		return nil, ErrPhoneRequired
+	}
+
+	updates := make(map[string]any)
+	before, changed := make(map[string]any), make(map[string]any)
+	if after.DiscoverableByEmail != user.DiscoverableByEmail {
+		updates["discoverable_by_email"] = after.DiscoverableByEmail
+		before["discoverable_by_email"], changed["discoverable_by_email"] = user.DiscoverableByEmail, after.DiscoverableByEmail
+	}
+	if after.DiscoverableByPhone != user.DiscoverableByPhone {
+		updates["discoverable_by_phone"] = after.DiscoverableByPhone
+		before["discoverable_by_phone"], changed["discoverable_by_phone"] = user.DiscoverableByPhone, after.DiscoverableByPhone
+	}
+	if len(updates) == 0 {
+		return discoverabilityOf(user), nil
+	}
+
+	if err := s.userRepo.Update(ctx, userID, updates); err != nil {
+		return nil, fmt.Errorf("failed to update discoverability: %w", err)
+	}
+	s.audit.Record(ctx, &model.AuditLog{
+		Action:     model.AuditPrivacyChange,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userID,
+		Before:     before,
+		After:      changed,
+	})
+	return discoverabilityOf(&after), nil
+}
+
+// Suggest returns the discoverable accounts, not yet followed, behind the
+// hashes. Hashes are hex SHA-256 digests as model.HashContact computes them;
+// duplicates are matched once.
+func (s *discoveryService) Suggest(ctx context.Context, userID int64, emailHashes, phoneHashes []string) ([]*dto.UserResponse, error) {
+	users, err := s.repo.FindByHashes(ctx, userID, dedupe(emailHashes), dedupe(phoneHashes), MaxSuggestions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match contacts: %w", err)
+	}
+	return dto.NewUserResponses(users), nil
+}
+
+func discoverabilityOf(user *model.User) *dto.Discoverability {
+	return &dto.Discoverability{
+		DiscoverableByEmail: user.DiscoverableByEmail,
+		DiscoverableByPhone: user.DiscoverableByPhone,
+		Phone:               verifiedPhone(user),
+	}
+}
+
+// verifiedPhone returns the user's phone number if it was verified
+func verifiedPhone(user *model.User) string {
+	if !user.HasVerifiedPhone() {
+		return ""
+	}
+	return user.Phone
+}
+
+// dedupe lowercases the hashes and drops repeats
+func dedupe(hashes []string) []string {
+	seen := make(map[string]bool, len(hashes))
+	out := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		hash = strings.ToLower(hash)
+		if !seen[hash] {
+			seen[hash] = true
+			out = append(out, hash)
+		}
+	}
+	return out
+}
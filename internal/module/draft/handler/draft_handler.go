@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/draft/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/oauth"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// MaxDraftLength is the longest draft kept, in characters; it matches the
+// longest direct message
+const MaxDraftLength = 5000
+
+type DraftHandler struct {
+	repo repository.DraftRepository
+}
+
+func NewDraftHandler(repo repository.DraftRepository) *DraftHandler {
+	return &DraftHandler{repo: repo}
+}
+
+// Register mounts the draft routes; they expect an authenticated user in the request context.
+// Targets are conversation/{userID} for direct messages and post/{postID} for comments.
+func (h *DraftHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/drafts", h.List)
+	mux.HandleFunc("GET /me/drafts/{target}/{id}", h.Get)
+	mux.HandleFunc("PUT /me/drafts/{target}/{id}", h.Put)
+	mux.HandleFunc("DELETE /me/drafts/{target}/{id}", h.Delete)
+}
+
+// List returns the caller's drafts, most recently edited first, optionally
+// only those of one ?target= type. Apps without the dm:read scope only see
+// comment drafts.
+func (h *DraftHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	target := types.DraftTargetUnknown
+	if s := r.URL.Query().Get("target"); s != "" {
+		if target = types.StringToDraftTarget(s); target == types.DraftTargetUnknown {
+			httpx.Error(w, http.StatusBadRequest, "target must be conversation or post")
+			return
+		}
+	}
+	if !oauth.Allowed(r.Context(), oauth.ScopeDMRead) {
+		if target == types.DraftTargetConversation {
+			oauth.MissingScope(w, oauth.Require(r.Context(), oauth.ScopeDMRead))
+			return
+		}
+		target = types.DraftTargetPost
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	drafts, err := h.repo.List(r.Context(), userID, target, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"drafts": drafts})
+}
+
+// Get returns the caller's draft for a target
+func (h *DraftHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	target, targetID, ok := parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	draft, err := h.repo.Get(r.Context(), userID, target, targetID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, draft)
+}
+
+// Put saves the caller's draft for a target, replacing any earlier one
+func (h *DraftHandler) Put(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	target, targetID, ok := parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if strings.TrimSpace(body.Content) == "" {
+		httpx.Error(w, http.StatusBadRequest, "content is required")
+		return
+	}
+	if utf8.RuneCountInString(body.Content) > MaxDraftLength {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("content must be at most %d characters", MaxDraftLength))
+		return
+	}
+
+	draft, err := h.repo.Put(r.Context(), userID, target, targetID, body.Content)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, draft)
+}
+
+// Delete discards the caller's draft for a target
+func (h *DraftHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	target, targetID, ok := parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), userID, target, targetID); err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseTarget(w http.ResponseWriter, r *http.Request) (types.DraftTarget, int64, bool) {
+	target := types.StringToDraftTarget(r.PathValue("target"))
+	if target == types.DraftTargetUnknown {
+		httpx.Error(w, http.StatusBadRequest, "target must be conversation or post")
+		return target, 0, false
+	}
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil || id <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid target id")
+		return target, 0, false
+	}
+	return target, id, true
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+type DraftRepository interface {
+	Get(ctx context.Context, userID int64, target types.DraftTarget, targetID int64) (*model.Draft, error)
+	Put(ctx context.Context, userID int64, target types.DraftTarget, targetID int64, content string) (*model.Draft, error)
+	Delete(ctx context.Context, userID int64, target types.DraftTarget, targetID int64) error
+	List(ctx context.Context, userID int64, target types.DraftTarget, limit, offset int) ([]*model.Draft, error)
+}
+
+func NewDraftRepository(db *gorm.DB) DraftRepository {
+	return &draftRepository{db: db}
+}
+
+type draftRepository struct {
+	db *gorm.DB
+}
+
+func (r *draftRepository) Get(ctx context.Context, userID int64, target types.DraftTarget, targetID int64) (*model.Draft, error) {
+	var draft model.Draft
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND target_type = ? AND target_id = ? AND deleted_at IS NULL", userID, target, targetID).
+		First(&draft).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "draft")
+	}
+	return &draft, nil
+}
+
+// Put saves the draft for a target, replacing the one already there
+func (r *draftRepository) Put(ctx context.Context, userID int64, target types.DraftTarget, targetID int64, content string) (*model.Draft, error) {
+	draft := model.Draft{UserID: userID, TargetType: target, TargetID: targetID}
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND target_type = ? AND target_id = ?", userID, target, targetID).
+		Assign(model.Draft{Content: content}).
+		FirstOrCreate(&draft).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "draft")
+	}
+	return &draft, nil
+}
+
+// Delete discards the draft outright so the target can get a fresh one later;
+// deleting a missing draft is a no-op
+func (r *draftRepository) Delete(ctx context.Context, userID int64, target types.DraftTarget, targetID int64) error {
+	return r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND target_type = ? AND target_id = ?", userID, target, targetID).
+		Delete(&model.Draft{}).Error
+}
+
+// List returns the user's drafts of one target type, or all of them for
+// DraftTargetUnknown, most recently edited first
+func (r *draftRepository) List(ctx context.Context, userID int64, target types.DraftTarget, limit, offset int) ([]*model.Draft, error) {
+	var drafts []*model.Draft
+	query := r.db.WithContext(ctx).Where("user_id = ? AND deleted_at IS NULL", userID)
+	if target != types.DraftTargetUnknown {
+		query = query.Where("target_type = ?", target)
+	}
+	err := query.
+		Order("updated_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&drafts).Error
+	return drafts, err
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/embed/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+)
+
+type EmbedHandler struct {
+	service service.EmbedService
+}
+
+func NewEmbedHandler(svc service.EmbedService) *EmbedHandler {
+	return &EmbedHandler{service: svc}
+}
+
+// Register mounts the oEmbed endpoint; it is public so other sites can embed posts
+func (h *EmbedHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /oembed", h.OEmbed)
+}
+
+// OEmbed answers oEmbed discovery for a post URL. Status codes follow the
+// oEmbed spec: 404 for unknown URLs, 501 for formats other than json and 401
+// for posts that may not be embedded.
+func (h *EmbedHandler) OEmbed(w http.ResponseWriter, r *http.Request) {
+	rawURL := r.URL.Query().Get("url")
+	if rawURL == "" {
+		httpx.Error(w, http.StatusBadRequest, "url is required")
+		return
+	}
+	if format := r.URL.Query().Get("format"); format != "" && format != "json" {
+		httpx.Error(w, http.StatusNotImplemented, "only the json format is supported")
+		return
+	}
+
+	embed, err := h.service.OEmbed(r.Context(), rawURL, httpx.QueryInt(r, "maxwidth", 0))
+	if err != nil {
+		status := apperr.Status(err)
+		if errors.Is(err, service.ErrNotEmbeddable) {
+			status = http.StatusUnauthorized
+		}
+		httpx.Error(w, status, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, embed)
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+)
+
+// Embed sizes in pixels
+const (
+	DefaultWidth = 550
+	MinWidth     = 220
+	MaxWidth     = 550
+)
+
+// CacheAge is how long consumers may cache an embed, in seconds
+const CacheAge = 3600
+
+// ErrNotEmbeddable is returned for posts that are private or whose author
+// turned embedding off
+var ErrNotEmbeddable = apperr.Forbidden("this post cannot be embedded")
+
+// Config identifies the site in embeds
+type Config struct {
+	ProviderName string
+	PublicURL    string // Origin of post links; when set, only URLs on it are embedded
+}
+
+type EmbedService interface {
+	OEmbed(ctx context.Context, rawURL string, maxWidth int) (*dto.OEmbed, error)
+}
+
+type embedService struct {
+	postRepo postrepo.PostRepository
+	userRepo userrepo.UserRepository
+	config   Config
+}
+
+func NewEmbedService(postRepo postrepo.PostRepository, userRepo userrepo.UserRepository, config Config) EmbedService {
+	config.PublicURL = strings.TrimSuffix(config.PublicURL, "/")
+	return &embedService{postRepo: postRepo, userRepo: userRepo, config: config}
+}
+
+// OEmbed describes the post at rawURL for embedding on another site. Only
+// public posts outside close friends and communities can be embedded, and
+// authors may turn embedding off per post.
+func (s *embedService) OEmbed(ctx context.Context, rawURL string, maxWidth int) (*dto.OEmbed, error) {
+	postID, err := s.postID(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if !post.IsPublic || post.CloseFriends || post.CommunityID != nil || post.EmbedsDisabled {
+		return nil, ErrNotEmbeddable
+	}
+	author, err := s.userRepo.GetByID(ctx, post.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if author.IsDeactivated() {
+		return nil, apperr.NotFound("post not found")
+	}
+
+	width := DefaultWidth
+	if maxWidth > 0 {
+		width = min(max(maxWidth, MinWidth), MaxWidth)
+	}
+	postURL := fmt.Sprintf("%s/posts/%d", s.config.PublicURL, post.ID)
+	authorURL := fmt.Sprintf("%s/users/%s", s.config.PublicURL, url.PathEscape(author.Username))
+	authorName := author.FullName
+	if authorName == "" {
+		authorName = author.Username
+	}
+
+	return &dto.OEmbed{
+		Version:      "1.0",
+		Type:         "rich",
+		ProviderName: s.config.ProviderName,
+		ProviderURL:  s.config.PublicURL,
+		AuthorName:   authorName,
+		AuthorURL:    authorURL,
+		HTML: fmt.Sprintf(`<blockquote class="sns-post" data-post-id="%d" style="max-width:%dpx"><p>%s</p>&mdash; %s (@%s) <a href="%s">%s</a></blockquote>`,
+			post.ID, width, html.EscapeString(post.Content), html.EscapeString(authorName), html.EscapeString(author.Username),
+			html.EscapeString(postURL), post.CreatedAt.UTC().Format("Jan 2, 2006")),
+		Width:    width,
+		CacheAge: CacheAge,
+	}, nil
+}
+
+// postID extracts the post from a /posts/{id} or /posts/{id}/{slug} link on
+// this site
+func (s *embedService) postID(rawURL string) (int64, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return 0, apperr.NotFound("post not found")
+	}
+	if s.config.PublicURL != "" {
+		origin, err := url.Parse(s.config.PublicURL)
+		if err == nil && !strings.EqualFold(origin.Host, u.Host) {
+			return 0, apperr.NotFound("post not found")
+		}
+	}
+	rest, ok := strings.CutPrefix(strings.TrimSuffix(u.Path, "/"), "/posts/")
+	if !ok {
+		return 0, apperr.NotFound("post not found")
+	}
+	rest, _, _ = strings.Cut(rest, "/")
+	id, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, apperr.NotFound("post not found")
+	}
+	return id, nil
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/module/emoji/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/richtext"
+)
+
+type EmojiHandler struct {
+	service service.EmojiService
+}
+
+func NewEmojiHandler(svc service.EmojiService) *EmojiHandler {
+	return &EmojiHandler{service: svc}
+}
+
+// Register mounts the emoji listing; it needs no authentication
+func (h *EmojiHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /emoji", h.List)
+}
+
+// List returns every shortcode content can use, built-in and custom
+func (h *EmojiHandler) List(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(service.RefreshInterval.Seconds())))
+	httpx.JSON(w, http.StatusOK, map[string]any{"emoji": dto.NewEmojiList(h.service.Custom())})
+}
+
+type AdminHandler struct {
+	service service.EmojiService
+}
+
+func NewAdminHandler(svc service.EmojiService) *AdminHandler {
+	return &AdminHandler{service: svc}
+}
+
+// Register mounts the custom emoji admin routes; callers are expected to wrap mux with admin auth
+func (h *AdminHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /admin/emoji", h.Create)
+	mux.HandleFunc("DELETE /admin/emoji/{shortcode}", h.Delete)
+}
+
+// Create uploads a custom emoji from a multipart form with its "shortcode"
+// and its "image", a PNG, GIF or WebP of at most MaxEmojiSize bytes
+func (h *AdminHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	shortcode := strings.Trim(strings.TrimSpace(r.FormValue("shortcode")), ":")
+	if !richtext.ValidShortcode(shortcode) {
+		httpx.Error(w, http.StatusBadRequest, "shortcode must be 2 to 32 lowercase letters, digits, '_', '+' or '-'")
+		return
+	}
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "missing image file")
+		return
+	}
+	defer file.Close()
+
+	image, err := io.ReadAll(io.LimitReader(file, service.MaxEmojiSize+1))
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid image file")
+		return
+	}
+	if len(image) > service.MaxEmojiSize {
+		httpx.Error(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("image must be at most %d bytes", service.MaxEmojiSize))
+		return
+	}
+	contentType := http.DetectContentType(image)
+	if _, ok := service.ImageTypes[contentType]; !ok {
+		httpx.Error(w, http.StatusBadRequest, "image must be a PNG, GIF or WebP")
+		return
+	}
+
+	emoji, err := h.service.Create(r.Context(), userID, shortcode, contentType, image)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, emoji)
+}
+
+// Delete removes a custom emoji
+func (h *AdminHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), userID, r.PathValue("shortcode")); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+type EmojiRepository interface {
+	Create(ctx context.Context, emoji *model.CustomEmoji) error
+	GetByShortcode(ctx context.Context, shortcode string) (*model.CustomEmoji, error)
+	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context) ([]*model.CustomEmoji, error)
+}
+
+func NewEmojiRepository(db *gorm.DB) EmojiRepository {
+	return &emojiRepository{db: db}
+}
+
+type emojiRepository struct {
+	db *gorm.DB
+}
+
+func (r *emojiRepository) Create(ctx context.Context, emoji *model.CustomEmoji) error {
+	return apperr.Translate(r.db.WithContext(ctx).Create(emoji).Error, "emoji")
+}
+
+func (r *emojiRepository) GetByShortcode(ctx context.Context, shortcode string) (*model.CustomEmoji, error) {
+	var emoji model.CustomEmoji
+	if err := r.db.WithContext(ctx).Where("shortcode = ?", shortcode).First(&emoji).Error; err != nil {
+		return nil, apperr.Translate(err, "emoji")
+	}
+	return &emoji, nil
+}
+
+// Delete removes the row for good so its shortcode can be taken again
+func (r *emojiRepository) Delete(ctx context.Context, id int64) error {
+	res := r.db.WithContext(ctx).Unscoped().Where("id = ?", id).Delete(&model.CustomEmoji{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return apperr.NotFound("emoji not found")
+	}
+	return nil
+}
+
+// List returns every custom emoji by shortcode
+func (r *emojiRepository) List(ctx context.Context) ([]*model.CustomEmoji, error) {
+	var emoji []*model.CustomEmoji
+	if err := r.db.WithContext(ctx).Order("shortcode").Find(&emoji).Error; err != nil {
+		return nil, err
+	}
+	return emoji, nil
+}
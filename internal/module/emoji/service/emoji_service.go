@@ -0,0 +1,171 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/emoji/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/richtext"
+	"github.com/ilhamosaurus/sns-platform/pkg/storage"
+)
+
+// MaxEmojiSize caps an emoji image, in bytes
+const MaxEmojiSize = 256 << 10
+
+// RefreshInterval is how often Run reloads the custom emoji, picking up
+// those other instances added or removed
+const RefreshInterval = time.Minute
+
+// ImageTypes maps the image types an emoji may have to their file extension
+var ImageTypes = map[string]string{
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+var (
+	ErrEmojiNotFound = apperr.NotFound("emoji not found")
+	ErrBuiltinEmoji  = apperr.Conflict("shortcode is taken by a built-in emoji")
+)
+
+type EmojiService interface {
+	// Create stores image, of one of ImageTypes, as the custom emoji shortcode
+	Create(ctx context.Context, adminID int64, shortcode, contentType string, image []byte) (*model.CustomEmoji, error)
+	Delete(ctx context.Context, adminID int64, shortcode string) error
+	// Custom returns the custom emoji as of the last refresh
+	Custom() []*model.CustomEmoji
+	// Lookup returns the image URL of a custom emoji; it is the resolver
+	// content rendering uses
+	Lookup(shortcode string) (string, bool)
+	Refresh(ctx context.Context) error
+	Run(ctx context.Context)
+}
+
+type emojiService struct {
+	repo    repository.EmojiRepository
+	storage storage.Storage
+	audit   auditsvc.AuditService
+	emoji   atomic.Pointer[catalog]
+}
+
+// catalog is a snapshot of the custom emoji
+type catalog struct {
+	list []*model.CustomEmoji
+	urls map[string]string
+}
+
+func NewEmojiService(repo repository.EmojiRepository, store storage.Storage, audit auditsvc.AuditService) EmojiService {
+	s := &emojiService{repo: repo, storage: store, audit: audit}
+	s.emoji.Store(&catalog{})
+	return s
+}
+
+func (s *emojiService) Create(ctx context.Context, adminID int64, shortcode, contentType string, image []byte) (*model.CustomEmoji, error) {
+	if _, ok := richtext.BuiltinEmoji[shortcode]; ok {
+		return nil, ErrBuiltinEmoji
+	}
+	// The key changes on every upload so caches never serve a replaced image
+	key := fmt.Sprintf("emoji/%s-%d%s", shortcode, time.Now().UnixNano(), ImageTypes[contentType])
+	url, err := s.storage.Put(ctx, key, bytes.NewReader(image), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store emoji image: %w", err)
+	}
+
+	emoji := &model.CustomEmoji{Shortcode: shortcode, URL: url, StorageKey: key, CreatedByID: adminID}
+	if err := s.repo.Create(ctx, emoji); err != nil {
+		s.deleteImage(ctx, key)
+		return nil, err
+	}
+	s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    &adminID,
+		Action:     model.AuditCustomEmojiCreate,
+		TargetType: model.AuditTargetCustomEmoji,
+		TargetID:   emoji.ID,
+		After:      map[string]any{"shortcode": emoji.Shortcode, "url": emoji.URL},
+	})
+	s.refresh(ctx)
+	return emoji, nil
+}
+
+// Delete removes a custom emoji; content using it shows the shortcode again
+func (s *emojiService) Delete(ctx context.Context, adminID int64, shortcode string) error {
+	emoji, err := s.repo.GetByShortcode(ctx, shortcode)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return ErrEmojiNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, emoji.ID); err != nil {
+		return err
+	}
+	s.deleteImage(ctx, emoji.StorageKey)
+	s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    &adminID,
+		Action:     model.AuditCustomEmojiDelete,
+		TargetType: model.AuditTargetCustomEmoji,
+		TargetID:   emoji.ID,
+		Before:     map[string]any{"shortcode": emoji.Shortcode, "url": emoji.URL},
+	})
+	s.refresh(ctx)
+	return nil
+}
+
+func (s *emojiService) Custom() []*model.CustomEmoji {
+	return s.emoji.Load().list
+}
+
+func (s *emojiService) Lookup(shortcode string) (string, bool) {
+	url, ok := s.emoji.Load().urls[shortcode]
+	return url, ok
+}
+
+func (s *emojiService) Refresh(ctx context.Context) error {
+	list, err := s.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load custom emoji: %w", err)
+	}
+	urls := make(map[string]string, len(list))
+	for _, emoji := range list {
+		urls[emoji.Shortcode] = emoji.URL
+	}
+	s.emoji.Store(&catalog{list: list, urls: urls})
+	return nil
+}
+
+// Run keeps the custom emoji fresh until ctx is cancelled
+func (s *emojiService) Run(ctx context.Context) {
+	s.refresh(ctx)
+	ticker := time.NewTicker(RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refresh(ctx)
+		}
+	}
+}
+
+// refresh reloads the custom emoji, keeping the last snapshot on failure
+func (s *emojiService) refresh(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+		slog.WarnContext(ctx, "failed to refresh custom emoji", slog.Any("error", err))
+	}
+}
+
+// deleteImage removes a stored image; a leftover file only costs space
+func (s *emojiService) deleteImage(ctx context.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		slog.WarnContext(ctx, "failed to delete emoji image", slog.String("key", key), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/job"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/export/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/jobs"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/signedurl"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// DefaultLinkTTL is how long a signed download link stays valid
+const DefaultLinkTTL = 15 * time.Minute
+
+type ExportHandler struct {
+	service service.ExportService
+	queue   *jobs.Queue
+	signer  *signedurl.Signer
+	linkTTL time.Duration
+	audit   auditsvc.AuditService
+}
+
+func NewExportHandler(svc service.ExportService, queue *jobs.Queue, signer *signedurl.Signer, linkTTL time.Duration, audit auditsvc.AuditService) *ExportHandler {
+	if linkTTL <= 0 {
+		linkTTL = DefaultLinkTTL
+	}
+	return &ExportHandler{service: svc, queue: queue, signer: signer, linkTTL: linkTTL, audit: audit}
+}
+
+// Register mounts the data export routes; /me routes expect an authenticated user
+// in the request context, the download route is authorized by its signature alone
+func (h *ExportHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /me/data-exports", h.Request)
+	mux.HandleFunc("GET /me/data-exports/{id}", h.Status)
+	mux.HandleFunc("GET /data-exports/{id}/download", h.Download)
+}
+
+// Request starts a "download my data" export, reusing one already in progress
+func (h *ExportHandler) Request(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	export, created, err := h.service.Request(r.Context(), userID)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		_, err := h.queue.Enqueue(r.Context(), job.TypeDataExport, job.DataExportPayload{ExportID: export.ID}, jobs.WithQueue(job.QueueExport))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to enqueue data export", slog.Int64("export_id", export.ID), slog.Any("error", err))
+			httpx.Error(w, http.StatusInternalServerError, "failed to schedule data export")
+			return
+		}
+		h.audit.Record(r.Context(), &model.AuditLog{
+			Action:     model.AuditDataExportRequest,
+			TargetType: model.AuditTargetDataExport,
+			TargetID:   export.ID,
+		})
+		status = http.StatusAccepted
+	}
+
+	httpx.JSON(w, status, map[string]any{
+		"export":     export,
+		"status_url": fmt.Sprintf("/me/data-exports/%d", export.ID),
+	})
+}
+
+// Status reports export progress and, once ready, a short-lived signed download link
+func (h *ExportHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid export id")
+		return
+	}
+
+	export, err := h.service.Get(r.Context(), userID, id)
+	if err != nil {
+		writeExportError(w, err)
+		return
+	}
+
+	resp := map[string]any{"export": export}
+	if export.Status == types.ExportStatusReady {
+		expires := time.Now().Add(h.linkTTL)
+		if export.ExpiresAt != nil && export.ExpiresAt.Before(expires) {
+			expires = *export.ExpiresAt
+		}
+		resp["download_url"] = h.signer.Sign(fmt.Sprintf("/data-exports/%d/download", export.ID), expires)
+		resp["download_expires_at"] = expires.UTC()
+	}
+	httpx.JSON(w, http.StatusOK, resp)
+}
+
+// Download streams a ready archive to the holder of a valid signed link
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	if err := h.signer.Verify(r.URL); err != nil {
+		httpx.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid export id")
+		return
+	}
+
+	f, export, err := h.service.Open(r.Context(), id)
+	if err != nil {
+		writeExportError(w, err)
+		return
+	}
+	defer f.Close()
+
+	// The link is the only credential, so the owner is recorded as the target
+	// and the actor stays empty unless the request is authenticated
+	h.audit.Record(r.Context(), &model.AuditLog{
+		Action:     model.AuditDataExportDownload,
+		TargetType: model.AuditTargetDataExport,
+		TargetID:   export.ID,
+		After:      map[string]any{"user_id": export.UserID},
+	})
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="data-export-%d.zip"`, export.ID))
+	w.Header().Set("Cache-Control", "private, no-store")
+	modified := export.UpdatedAt
+	if export.CompletedAt != nil {
+		modified = *export.CompletedAt
+	}
+	http.ServeContent(w, r, "", modified, f)
+}
+
+func writeExportError(w http.ResponseWriter, err error) {
+	httpx.Fail(w, err)
+}
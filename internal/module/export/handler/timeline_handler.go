@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/module/export/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+// Export formats
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+// TimelineHandler serves the caller's posts and followers a page at a time
+// as JSON or CSV, for integrations and backups that do not need a full data
+// export
+type TimelineHandler struct {
+	repo repository.TimelineRepository
+}
+
+func NewTimelineHandler(repo repository.TimelineRepository) *TimelineHandler {
+	return &TimelineHandler{repo: repo}
+}
+
+// Register mounts the timeline export routes; they expect an authenticated user in the request context
+func (h *TimelineHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/exports/posts", h.Posts)
+	mux.HandleFunc("GET /me/exports/followers", h.Followers)
+}
+
+// page is the cursor, size and format of a timeline export request
+type page struct {
+	after  int64
+	limit  int
+	format string
+}
+
+func parsePage(w http.ResponseWriter, r *http.Request) (page, bool) {
+	p := page{
+		limit:  min(max(httpx.QueryInt(r, "limit", 100), 1), 500),
+		format: r.URL.Query().Get("format"),
+	}
+	if p.format == "" {
+		p.format = formatJSON
+	}
+	if p.format != formatJSON && p.format != formatCSV {
+		httpx.Error(w, http.StatusBadRequest, "format must be json or csv")
+		return p, false
+	}
+	if after := r.URL.Query().Get("after"); after != "" {
+		id, err := strconv.ParseInt(after, 10, 64)
+		if err != nil || id < 0 {
+			httpx.Error(w, http.StatusBadRequest, "invalid after cursor")
+			return p, false
+		}
+		p.after = id
+	}
+	return p, true
+}
+
+// setNext points the client at the following page when this one is full
+func setNext(w http.ResponseWriter, r *http.Request, p page, n int, lastID int64) string {
+	if n < p.limit {
+		return ""
+	}
+	cursor := strconv.FormatInt(lastID, 10)
+	next := url.URL{Path: r.URL.Path, RawQuery: url.Values{
+		"after":  {cursor},
+		"limit":  {strconv.Itoa(p.limit)},
+		"format": {p.format},
+	}.Encode()}
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+	return cursor
+}
+
+// Posts exports a page of the caller's posts, oldest first
+func (h *TimelineHandler) Posts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := h.repo.Posts(r.Context(), userID, p.after, p.limit)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var lastID int64
+	if len(posts) > 0 {
+		lastID = posts[len(posts)-1].ID
+	}
+	next := setNext(w, r, p, len(posts), lastID)
+
+	if p.format == formatJSON {
+		items := make([]*dto.PostResponse, len(posts))
+		for i, post := range posts {
+			items[i] = dto.NewPostResponse(post)
+		}
+		httpx.JSON(w, http.StatusOK, map[string]any{"posts": items, "next_cursor": next})
+		return
+	}
+
+	cw := startCSV(w, "posts", next)
+	cw.Write([]string{"id", "created_at", "content", "media_type", "media_url", "is_public", "like_count", "comment_count", "share_count"})
+	for _, post := range posts {
+		cw.Write([]string{
+			strconv.FormatInt(post.ID, 10),
+			post.CreatedAt.UTC().Format(time.RFC3339),
+			post.Content,
+			post.MediaType.String(),
+			post.MediaURL,
+			strconv.FormatBool(post.IsPublic),
+			strconv.FormatInt(post.LikeCount, 10),
+			strconv.FormatInt(post.CommentCount, 10),
+			strconv.FormatInt(post.ShareCount, 10),
+		})
+	}
+	cw.Flush()
+}
+
+// Followers exports a page of the caller's followers in the order they followed
+func (h *TimelineHandler) Followers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	followers, err := h.repo.Followers(r.Context(), userID, p.after, p.limit)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var lastID int64
+	if len(followers) > 0 {
+		lastID = followers[len(followers)-1].ID
+	}
+	next := setNext(w, r, p, len(followers), lastID)
+
+	if p.format == formatJSON {
+		httpx.JSON(w, http.StatusOK, map[string]any{"followers": followers, "next_cursor": next})
+		return
+	}
+
+	cw := startCSV(w, "followers", next)
+	cw.Write([]string{"user_id", "username", "full_name", "since"})
+	for _, f := range followers {
+		cw.Write([]string{strconv.FormatInt(f.UserID, 10), f.Username, f.FullName, f.Since.UTC().Format(time.RFC3339)})
+	}
+	cw.Flush()
+}
+
+// startCSV writes the headers of a CSV download; the next cursor travels in
+// a header since the body has no room for it
+func startCSV(w http.ResponseWriter, name, next string) *csv.Writer {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+	w.Header().Set("Cache-Control", "private, no-store")
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
+	w.WriteHeader(http.StatusOK)
+	return csv.NewWriter(w)
+}
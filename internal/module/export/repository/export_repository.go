@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+type ExportRepository interface {
+	Create(ctx context.Context, export *model.DataExport) error
+	GetByID(ctx context.Context, id int64) (*model.DataExport, error)
+	GetInProgress(ctx context.Context, userID int64) (*model.DataExport, error)
+	MarkProcessing(ctx context.Context, id int64) error
+	MarkReady(ctx context.Context, id int64, filePath string, size int64, expiresAt time.Time) error
+	MarkFailed(ctx context.Context, id int64, cause error) error
+	ListExpired(ctx context.Context, now time.Time) ([]*model.DataExport, error)
+	MarkExpired(ctx context.Context, id int64) error
+}
+
+func NewExportRepository(db *gorm.DB) ExportRepository {
+	return &exportRepository{db: db}
+}
+
+type exportRepository struct {
+	db *gorm.DB
+}
+
+func (r *exportRepository) Create(ctx context.Context, export *model.DataExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r *exportRepository) GetByID(ctx context.Context, id int64) (*model.DataExport, error) {
+	var export model.DataExport
+	if err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&export).Error; err != nil {
+		return nil, apperr.Translate(err, "data export")
+	}
+	return &export, nil
+}
+
+// GetInProgress returns the user's pending or processing export, or nil if there is none
+func (r *exportRepository) GetInProgress(ctx context.Context, userID int64) (*model.DataExport, error) {
+	var export model.DataExport
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status IN ? AND deleted_at IS NULL", userID, []types.ExportStatus{types.ExportStatusPending, types.ExportStatusProcessing}).
+		Order("created_at DESC").
+		First(&export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *exportRepository) MarkProcessing(ctx context.Context, id int64) error {
+	return r.update(ctx, id, map[string]any{"status": types.ExportStatusProcessing, "error": ""})
+}
+
+func (r *exportRepository) MarkReady(ctx context.Context, id int64, filePath string, size int64, expiresAt time.Time) error {
+	return r.update(ctx, id, map[string]any{
+		"status":       types.ExportStatusReady,
+		"file_path":    filePath,
+		"size_bytes":   size,
+		"completed_at": time.Now().UTC(),
+		"expires_at":   expiresAt,
+	})
+}
+
+func (r *exportRepository) MarkFailed(ctx context.Context, id int64, cause error) error {
+	return r.update(ctx, id, map[string]any{"status": types.ExportStatusFailed, "error": cause.Error()})
+}
+
+// ListExpired returns ready exports whose archive has passed its expiry
+func (r *exportRepository) ListExpired(ctx context.Context, now time.Time) ([]*model.DataExport, error) {
+	var exports []*model.DataExport
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ? AND deleted_at IS NULL", types.ExportStatusReady, now).
+		Find(&exports).Error
+	if err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *exportRepository) MarkExpired(ctx context.Context, id int64) error {
+	return r.update(ctx, id, map[string]any{"status": types.ExportStatusExpired, "file_path": ""})
+}
+
+func (r *exportRepository) update(ctx context.Context, id int64, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.DataExport{}).Where("id = ? AND deleted_at IS NULL", id).Updates(updates).Error
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"gorm.io/gorm"
+)
+
+// Follower is one row of a followers export; ID is the follow edge, used as
+// the page cursor
+type Follower struct {
+	ID       int64     `json:"-"`
+	UserID   int64     `json:"user_id"`
+	Username string    `json:"username"`
+	FullName string    `json:"full_name"`
+	Since    time.Time `json:"since"`
+}
+
+// TimelineRepository pages through a user's posts and followers in ID order,
+// for exports that are lighter than a full data archive
+type TimelineRepository interface {
+	Posts(ctx context.Context, userID, afterID int64, limit int) ([]*model.Post, error)
+	Followers(ctx context.Context, userID, afterID int64, limit int) ([]*Follower, error)
+}
+
+func NewTimelineRepository(db *gorm.DB) TimelineRepository {
+	return &timelineRepository{db: db}
+}
+
+type timelineRepository struct {
+	db *gorm.DB
+}
+
+// Posts returns the user's posts with IDs above afterID, oldest first
+func (r *timelineRepository) Posts(ctx context.Context, userID, afterID int64, limit int) ([]*model.Post, error) {
+	var posts []*model.Post
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND id > ? AND deleted_at IS NULL", userID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&posts).Error
+	return posts, err
+}
+
+// Followers returns the user's followers whose follow edge ID is above
+// afterID, in the order they followed
+func (r *timelineRepository) Followers(ctx context.Context, userID, afterID int64, limit int) ([]*Follower, error) {
+	var followers []*Follower
+	err := r.db.WithContext(ctx).Table("follows").
+		Select("follows.id, users.id AS user_id, users.username, users.full_name, follows.created_at AS since").
+		Joins("INNER JOIN users ON users.id = follows.follower_id AND users.deleted_at IS NULL").
+		Where("follows.following_id = ? AND follows.id > ? AND follows.deleted_at IS NULL", userID, afterID).
+		Order("follows.id ASC").
+		Limit(limit).
+		Scan(&followers).Error
+	return followers, err
+}
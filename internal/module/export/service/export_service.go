@@ -0,0 +1,316 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/export/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultRetention is how long a finished archive stays downloadable
+	DefaultRetention = 7 * 24 * time.Hour
+
+	batchSize = 500
+)
+
+var (
+	ErrExportNotFound = apperr.NotFound("data export not found")
+	ErrExportNotReady = apperr.Conflict("data export is not ready")
+)
+
+// Config controls where archives are written and how long they are kept
+type Config struct {
+	Dir       string
+	Retention time.Duration
+}
+
+type ExportService interface {
+	Request(ctx context.Context, userID int64) (export *model.DataExport, created bool, err error)
+	Get(ctx context.Context, userID, id int64) (*model.DataExport, error)
+	Build(ctx context.Context, id int64) error
+	Open(ctx context.Context, id int64) (*os.File, *model.DataExport, error)
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+type exportService struct {
+	db     *gorm.DB
+	repo   repository.ExportRepository
+	config Config
+}
+
+func NewExportService(db *gorm.DB, repo repository.ExportRepository, config Config) ExportService {
+	if config.Dir == "" {
+		config.Dir = "./data/exports"
+	}
+	if config.Retention <= 0 {
+		config.Retention = DefaultRetention
+	}
+	return &exportService{db: db, repo: repo, config: config}
+}
+
+// Request starts a new export for userID, or returns the one already in
+// progress; created reports whether a build job needs to be enqueued
+func (s *exportService) Request(ctx context.Context, userID int64) (*model.DataExport, bool, error) {
+	existing, err := s.repo.GetInProgress(ctx, userID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check existing exports: %w", err)
+	}
+	if existing != nil {
+		return existing, false, nil
+	}
+
+	export := &model.DataExport{UserID: userID, Status: types.ExportStatusPending}
+	if err := s.repo.Create(ctx, export); err != nil {
+		return nil, false, fmt.Errorf("failed to create data export: %w", err)
+	}
+	return export, true, nil
+}
+
+// Get returns an export owned by userID
+func (s *exportService) Get(ctx context.Context, userID, id int64) (*model.DataExport, error) {
+	export, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, apperr.ErrNotFound) || (err == nil && export.UserID != userID) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// Build assembles the archive for an export; it runs inside a background job
+func (s *exportService) Build(ctx context.Context, id int64) error {
+	export, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load data export: %w", err)
+	}
+	if export.Status == types.ExportStatusReady {
+		return nil
+	}
+	if err := s.repo.MarkProcessing(ctx, id); err != nil {
+		return fmt.Errorf("failed to mark data export processing: %w", err)
+	}
+
+	path, size, err := s.writeArchive(ctx, export)
+	if err != nil {
+		if markErr := s.repo.MarkFailed(ctx, id, err); markErr != nil {
+			slog.WarnContext(ctx, "failed to mark data export failed", slog.Int64("export_id", id), slog.Any("error", markErr))
+		}
+		return err
+	}
+
+	if err := s.repo.MarkReady(ctx, id, path, size, time.Now().UTC().Add(s.config.Retention)); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to mark data export ready: %w", err)
+	}
+	slog.InfoContext(ctx, "data export ready", slog.Int64("export_id", id), slog.Int64("user_id", export.UserID), slog.Int64("size", size))
+	return nil
+}
+
+// Open returns the archive of a ready, unexpired export
+func (s *exportService) Open(ctx context.Context, id int64) (*os.File, *model.DataExport, error) {
+	export, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if export.Status != types.ExportStatusReady || (export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt)) {
+		return nil, nil, ErrExportNotReady
+	}
+
+	f, err := os.Open(export.FilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open data export: %w", err)
+	}
+	return f, export, nil
+}
+
+// PurgeExpired deletes archives past their expiry and marks them expired
+func (s *exportService) PurgeExpired(ctx context.Context) (int, error) {
+	exports, err := s.repo.ListExpired(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired data exports: %w", err)
+	}
+
+	purged := 0
+	for _, export := range exports {
+		if err := os.Remove(export.FilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.WarnContext(ctx, "failed to remove data export", slog.Int64("export_id", export.ID), slog.Any("error", err))
+			continue
+		}
+		if err := s.repo.MarkExpired(ctx, export.ID); err != nil {
+			return purged, fmt.Errorf("failed to mark data export expired: %w", err)
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// writeArchive writes the ZIP to a temporary file and moves it into place once complete
+func (s *exportService) writeArchive(ctx context.Context, export *model.DataExport) (string, int64, error) {
+	if err := os.MkdirAll(s.config.Dir, 0o750); err != nil {
+		return "", 0, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+	path := filepath.Join(s.config.Dir, fmt.Sprintf("export-%d-%s.zip", export.ID, hex.EncodeToString(suffix)))
+
+	f, err := os.CreateTemp(s.config.Dir, "export-*.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	if err := s.writeUserData(ctx, zw, export.UserID); err != nil {
+		return "", 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to finalize export archive: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to stat export file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to write export file: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", 0, fmt.Errorf("failed to move export file into place: %w", err)
+	}
+	return path, info.Size(), nil
+}
+
+func (s *exportService) writeUserData(ctx context.Context, zw *zip.Writer, userID int64) error {
+	db := s.db.WithContext(ctx)
+
+	var user model.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+	if err := writeJSON(zw, "profile.json", user); err != nil {
+		return err
+	}
+
+	if err := writeJSONArray[model.Post](zw, "posts.json", db.Where("user_id = ?", userID)); err != nil {
+		return err
+	}
+	if err := writeJSONArray[model.Comment](zw, "comments.json", db.Where("user_id = ?", userID)); err != nil {
+		return err
+	}
+	if err := writeJSONArray[model.Reaction](zw, "reactions.json", db.Where("user_id = ?", userID)); err != nil {
+		return err
+	}
+	if err := writeJSONArray[model.Message](zw, "messages.json", db.Where("sender_id = ? OR receiver_id = ?", userID, userID)); err != nil {
+		return err
+	}
+
+	if err := writeConnections(zw, "followers.csv", db, "follows.follower_id", "follows.following_id", userID); err != nil {
+		return err
+	}
+	return writeConnections(zw, "following.csv", db, "follows.following_id", "follows.follower_id", userID)
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeJSONArray streams every row matched by query into a JSON array, batch by batch
+func writeJSONArray[T any](zw *zip.Writer, name string, query *gorm.DB) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+
+	sep := "\n"
+	io.WriteString(w, "[")
+	var batch []T
+	err = query.FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		for _, row := range batch {
+			data, err := json.Marshal(row)
+			if err != nil {
+				return err
+			}
+			io.WriteString(w, sep)
+			w.Write(data)
+			sep = ",\n"
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	io.WriteString(w, "\n]\n")
+	return nil
+}
+
+// writeConnections writes the users on the other side of userID's follow
+// edges as CSV; otherCol joins to the listed user, selfCol filters on userID
+func writeConnections(zw *zip.Writer, name string, db *gorm.DB, otherCol, selfCol string, userID int64) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+
+	rows, err := db.Table("follows").
+		Select("users.id, users.username, users.full_name, follows.created_at").
+		Joins("INNER JOIN users ON users.id = "+otherCol+" AND users.deleted_at IS NULL").
+		Where(selfCol+" = ? AND follows.deleted_at IS NULL", userID).
+		Order("follows.created_at ASC").
+		Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"user_id", "username", "full_name", "since"})
+	for rows.Next() {
+		var (
+			id       int64
+			username string
+			fullName string
+			since    time.Time
+		)
+		if err := rows.Scan(&id, &username, &fullName, &since); err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		cw.Write([]string{strconv.FormatInt(id, 10), username, fullName, since.UTC().Format(time.RFC3339)})
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	cw.Flush()
+	return cw.Error()
+}
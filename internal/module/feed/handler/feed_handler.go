@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	announcementsvc "github.com/ilhamosaurus/sns-platform/internal/module/announcement/service"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type FeedHandler struct {
+	feedRepo      feedrepo.FeedRepository
+	announcements announcementsvc.AnnouncementService
+}
+
+func NewFeedHandler(feedRepo feedrepo.FeedRepository, announcements announcementsvc.AnnouncementService) *FeedHandler {
+	return &FeedHandler{feedRepo: feedRepo, announcements: announcements}
+}
+
+// Register mounts the feed and post detail routes; they expect an authenticated user in the request context
+func (h *FeedHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/feed", h.Feed)
+	mux.HandleFunc("GET /me/feed/new-count", h.NewCount)
+	mux.HandleFunc("GET /explore", h.Explore)
+	mux.HandleFunc("GET /nearby", h.Nearby)
+	mux.HandleFunc("GET /posts/{id}", h.Post)
+}
+
+// Feed returns a page of the caller's home feed, headed on the first page by
+// the running announcements the caller has not dismissed. Responses carry an
+// ETag only: likes and comments by others change the payload without a
+// timestamp to report as Last-Modified.
+func (h *FeedHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 20), 1), 100)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	posts, err := h.feedRepo.GetUserFeed(r.Context(), userID, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := map[string]any{"posts": posts}
+	if offset == 0 {
+		// The feed still loads without them
+		announcements, err := h.announcements.Active(r.Context(), userID)
+		if err != nil {
+			slog.WarnContext(r.Context(), "failed to load announcements", slog.Any("error", err))
+		}
+		resp["announcements"] = dto.NewAnnouncements(announcements)
+	}
+	httpx.ConditionalJSON(w, r, resp, time.Time{})
+}
+
+// NewCount returns how many posts reached the caller's feed after the
+// "since" post ID or the "since_time" (RFC 3339) the client last showed, for
+// clients polling instead of streaming
+func (h *FeedHandler) NewCount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	cursor, err := parseCursor(r)
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if cursor == (feedCursor{}) {
+		httpx.Error(w, http.StatusBadRequest, "since or since_time is required")
+		return
+	}
+
+	count, err := cursor.countNewer(r.Context(), h.feedRepo, userID)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"count": count})
+}
+
+// Explore returns a page of popular public posts from the last hours (a week
+// by default), in the caller's preferred languages and, given a "region"
+// country code, placed there; like Feed, it is tagged with an ETag only
+func (h *FeedHandler) Explore(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 20), 1), 100)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	hours := min(max(httpx.QueryInt(r, "hours", 7*24), 1), 30*24)
+	region := r.URL.Query().Get("region")
+	if region != "" {
+		if region, ok = model.ParseRegion(region); !ok {
+			httpx.Error(w, http.StatusBadRequest, "region must be an ISO 3166-1 alpha-2 country code")
+			return
+		}
+	}
+	posts, err := h.feedRepo.GetExploreFeed(r.Context(), userID, limit, offset, time.Duration(hours)*time.Hour, region)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.ConditionalJSON(w, r, map[string]any{"posts": posts}, time.Time{})
+}
+
+// Nearby returns a page of public posts from the last hours (a week by
+// default) geotagged within radius meters (5km by default) of the lat and
+// lng query parameters, newest first; like Feed, it is tagged with an ETag
+// only
+func (h *FeedHandler) Nearby(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	lat, err := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+	if err != nil || !(lat >= -90 && lat <= 90) {
+		httpx.Error(w, http.StatusBadRequest, "lat must be a latitude between -90 and 90")
+		return
+	}
+	lng, err := strconv.ParseFloat(r.URL.Query().Get("lng"), 64)
+	if err != nil || !(lng >= -180 && lng <= 180) {
+		httpx.Error(w, http.StatusBadRequest, "lng must be a longitude between -180 and 180")
+		return
+	}
+	limit := min(max(httpx.QueryInt(r, "limit", 20), 1), 100)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	hours := min(max(httpx.QueryInt(r, "hours", 7*24), 1), 30*24)
+	radius := min(max(httpx.QueryInt(r, "radius", 5000), 1), feedrepo.MaxNearbyRadius)
+	posts, err := h.feedRepo.GetNearbyFeed(r.Context(), userID, lat, lng, float64(radius), limit, offset, time.Duration(hours)*time.Hour)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.ConditionalJSON(w, r, map[string]any{"posts": posts}, time.Time{})
+}
+
+// Post returns a post with its comments and reactions, if the caller may see
+// it; like Feed, it is tagged with an ETag only. Comments are oldest first,
+// or most engaging first with "comment_sort=top".
+func (h *FeedHandler) Post(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	postID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+
+	sort := types.StringToCommentSort(r.URL.Query().Get("comment_sort"))
+	post, err := h.feedRepo.GetPostWithDetails(r.Context(), postID, userID, sort)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.ConditionalJSON(w, r, post, time.Time{})
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/module/feed/stream"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+const (
+	// heartbeatInterval keeps idle streams open through proxies and load balancers
+	heartbeatInterval = 25 * time.Second
+
+	// retryMillis is how long clients wait before reconnecting a dropped stream
+	retryMillis = 5000
+)
+
+type StreamHandler struct {
+	hub      *stream.Hub
+	feedRepo feedrepo.FeedRepository
+}
+
+func NewStreamHandler(hub *stream.Hub, feedRepo feedrepo.FeedRepository) *StreamHandler {
+	return &StreamHandler{hub: hub, feedRepo: feedRepo}
+}
+
+// Register mounts the feed streaming route; it expects an authenticated user in the request context
+func (h *StreamHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/feed/stream", h.Stream)
+}
+
+// Stream pushes feed updates as server-sent events so clients can stop polling
+// the feed. Each new post is sent as a "feed.item" event (its id is the post
+// ID) followed by "feed.new_posts" with the number of posts newer than the
+// "since" query parameter, the newest post ID the client has shown, or than
+// "since_time" (RFC 3339). Without either, the Last-Event-ID header sent on
+// reconnect is used instead.
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	cursor, err := parseCursor(r)
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Streams outlive the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	count, err := cursor.countNewer(r.Context(), h.feedRepo, userID)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sub := h.hub.Subscribe(userID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+	if count > 0 {
+		writeEvent(w, "feed.new_posts", "", map[string]any{"count": count})
+	}
+	if err := rc.Flush(); err != nil {
+		slog.WarnContext(r.Context(), "feed stream cannot be flushed", slog.Any("error", err))
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case post, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			count += 1 + sub.TakeMissed()
+			writeEvent(w, "feed.item", strconv.FormatInt(post.ID, 10), map[string]any{"post": post})
+			writeEvent(w, "feed.new_posts", "", map[string]any{"count": count})
+		case <-heartbeat.C:
+			if missed := sub.TakeMissed(); missed > 0 {
+				count += missed
+				writeEvent(w, "feed.new_posts", "", map[string]any{"count": count})
+			} else {
+				io.WriteString(w, ": ping\n\n")
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w io.Writer, name, id string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.Warn("failed to encode feed stream event", slog.String("event", name), slog.Any("error", err))
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+}
+
+// feedCursor is the newest feed item a client has shown, as a post ID or a time
+type feedCursor struct {
+	postID int64
+	time   time.Time
+}
+
+// parseCursor reads the cursor from the "since" or "since_time" query
+// parameter, falling back to the Last-Event-ID header; without any the cursor
+// is empty
+func parseCursor(r *http.Request) (feedCursor, error) {
+	var c feedCursor
+	if value := r.URL.Query().Get("since_time"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c, errors.New("invalid since_time, expected RFC 3339")
+		}
+		c.time = t
+		return c, nil
+	}
+	value := r.URL.Query().Get("since")
+	if value == "" {
+		value = r.Header.Get("Last-Event-ID")
+	}
+	if value == "" {
+		return c, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return c, errors.New("invalid since")
+	}
+	c.postID = id
+	return c, nil
+}
+
+// countNewer counts the posts in the user's feed past the cursor; an empty
+// cursor counts nothing
+func (c feedCursor) countNewer(ctx context.Context, feedRepo feedrepo.FeedRepository, userID int64) (int64, error) {
+	switch {
+	case !c.time.IsZero():
+		return feedRepo.CountNewerSince(ctx, userID, c.time)
+	case c.postID > 0:
+		return feedRepo.CountNewer(ctx, userID, c.postID)
+	}
+	return 0, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"gorm.io/gorm"
+)
+
+// Affinity weights: a comment says more about a reader's interest than a tap
+const (
+	AffinityReactionWeight = 1
+	AffinityCommentWeight  = 3
+)
+
+// AffinityConfig sets how the home feed favors the authors a reader engages with
+type AffinityConfig struct {
+	MinScore int64         // Affinity from which an author's posts are lifted; zero disables it
+	Within   time.Duration // How recent a post must be to be lifted
+}
+
+var DefaultAffinityConfig = AffinityConfig{
+	MinScore: 10,
+	Within:   24 * time.Hour,
+}
+
+// WithAffinity lifts the posts of high-affinity authors from the last
+// config.Within to the top of home feeds, newest first, ahead of everything
+// else in feed order
+func WithAffinity(config AffinityConfig) Option {
+	return func(r *feedRepository) {
+		if config.Within <= 0 {
+			config.Within = DefaultAffinityConfig.Within
+		}
+		r.affinity = config
+	}
+}
+
+// affinityBatchSize is how many affinities each INSERT writes
+const affinityBatchSize = 500
+
+// withAffinity adds a "lifted" column to a feed query, set on the posts
+// WithAffinity puts first for userID
+func (r *feedRepository) withAffinity(userID int64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if r.affinity.MinScore <= 0 {
+			return db.Select("posts.*, 0 AS lifted")
+		}
+		return db.
+			Select("posts.*, CASE WHEN affinities.score >= ? AND posts.created_at >= ? THEN 1 ELSE 0 END AS lifted",
+				r.affinity.MinScore, time.Now().Add(-r.affinity.Within)).
+			Joins("LEFT JOIN affinities ON affinities.user_id = ? AND affinities.author_id = posts.user_id AND affinities.deleted_at IS NULL", userID)
+	}
+}
+
+// RecomputeAffinities replaces every affinity with the reactions and
+// comments each user left on others' posts since since, returning how many
+// user and author pairs it kept
+func (r *feedRepository) RecomputeAffinities(ctx context.Context, since time.Time) (int64, error) {
+	db := r.db.WithContext(ctx)
+	type pair struct {
+		UserID   int64
+		AuthorID int64
+		TenantID int64
+		Count    int64
+	}
+
+	var reactions, comments []pair
+	err := db.Table("reactions").
+		Select("reactions.user_id, posts.user_id AS author_id, posts.tenant_id, COUNT(*) AS count").
+		Joins("INNER JOIN posts ON posts.id = reactions.post_id AND posts.deleted_at IS NULL").
+		Where("reactions.created_at >= ? AND reactions.deleted_at IS NULL AND reactions.user_id <> posts.user_id", since).
+		Group("reactions.user_id, posts.user_id, posts.tenant_id").
+		Scan(&reactions).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count reactions for affinities: %w", err)
+	}
+	err = db.Table("comments").
+		Select("comments.user_id, posts.user_id AS author_id, posts.tenant_id, COUNT(*) AS count").
+		Joins("INNER JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		Where("comments.created_at >= ? AND comments.deleted_at IS NULL AND comments.pending_approval = ? AND comments.user_id <> posts.user_id", since, false).
+		Group("comments.user_id, posts.user_id, posts.tenant_id").
+		Scan(&comments).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count comments for affinities: %w", err)
+	}
+
+	type key struct{ userID, authorID int64 }
+	byPair := make(map[key]*model.Affinity, len(reactions)+len(comments))
+	affinity := func(p pair) *model.Affinity {
+		k := key{p.UserID, p.AuthorID}
+		if byPair[k] == nil {
+			byPair[k] = &model.Affinity{TenantID: p.TenantID, UserID: p.UserID, AuthorID: p.AuthorID}
+		}
+		return byPair[k]
+	}
+	for _, p := range reactions {
+		affinity(p).Reactions = p.Count
+	}
+	for _, p := range comments {
+		affinity(p).Comments = p.Count
+	}
+	affinities := make([]*model.Affinity, 0, len(byPair))
+	for _, a := range byPair {
+		a.Score = a.Reactions*AffinityReactionWeight + a.Comments*AffinityCommentWeight
+		affinities = append(affinities, a)
+	}
+
+	// Feeds read either the previous affinities or the new ones, never a mix
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("1 = 1").Delete(&model.Affinity{}).Error; err != nil {
+			return err
+		}
+		if len(affinities) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(affinities, affinityBatchSize).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to store affinities: %w", err)
+	}
+	return int64(len(affinities)), nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/pkg/cache"
+	"github.com/ilhamosaurus/sns-platform/pkg/metrics"
+)
+
+// CachePage is how many posts of the first page of a home feed are cached;
+// later pages, larger ones and filtered ones are always queried
+const CachePage = 50
+
+// WithCache keeps the first CachePage posts of each home feed in store for
+// ttl, sparing the feed query when the app opens. Fan-out drops the cached
+// feeds it adds a post to; other changes, such as new likes, show once the
+// entry expires.
+func WithCache(store cache.Store, ttl time.Duration) Option {
+	return func(r *feedRepository) {
+		r.cache, r.cacheTTL = store, ttl
+	}
+}
+
+func cacheKey(userID int64) string {
+	return "feed:home:" + strconv.FormatInt(userID, 10)
+}
+
+// cachedUserFeed serves the first page of userID's feed from the cache,
+// filling it on a miss. A broken cache falls back to the query.
+func (r *feedRepository) cachedUserFeed(ctx context.Context, userID int64, limit int) ([]*dto.FeedPost, error) {
+	key := cacheKey(userID)
+	data, ok, err := r.cache.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to read feed cache", slog.Int64("user_id", userID), slog.Any("error", err))
+	}
+	if ok {
+		var posts []*dto.FeedPost
+		if err := json.Unmarshal(data, &posts); err == nil {
+			metrics.CacheLookup("feed", true)
+			return posts[:min(limit, len(posts))], nil
+		}
+	}
+	metrics.CacheLookup("feed", false)
+
+	posts, err := r.userFeed(ctx, userID, CachePage, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(posts); err == nil {
+		if err := r.cache.Set(ctx, key, data, r.cacheTTL); err != nil {
+			slog.WarnContext(ctx, "failed to fill feed cache", slog.Int64("user_id", userID), slog.Any("error", err))
+		}
+	}
+	return posts[:min(limit, len(posts))], nil
+}
+
+// forget drops the cached feeds of userIDs, which then miss until refilled
+func (r *feedRepository) forget(ctx context.Context, userIDs ...int64) {
+	if r.cache == nil || len(userIDs) == 0 {
+		return
+	}
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = cacheKey(userID)
+	}
+	if err := r.cache.Delete(ctx, keys...); err != nil {
+		slog.WarnContext(ctx, "failed to invalidate feed cache", slog.Int("users", len(userIDs)), slog.Any("error", err))
+	}
+}
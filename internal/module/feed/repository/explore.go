@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"gorm.io/gorm"
+)
+
+// ExploreConfig sets which explore windows are precomputed
+type ExploreConfig struct {
+	Windows    []time.Duration // Time ranges materialized; explore ranks other ranges per request
+	Candidates int             // Posts kept per window, tenant, language and region
+	MaxAge     time.Duration   // Candidates older than this are ignored, as the task has stopped; zero trusts them forever
+}
+
+var DefaultExploreConfig = ExploreConfig{
+	Windows:    []time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour},
+	Candidates: 500,
+}
+
+// WithExplore makes explore page over the candidates RecomputeExplore
+// materializes for config's windows
+func WithExplore(config ExploreConfig) Option {
+	return func(r *feedRepository) {
+		if config.Candidates <= 0 {
+			config.Candidates = DefaultExploreConfig.Candidates
+		}
+		r.explore = config
+	}
+}
+
+// exploreBatchSize is how many candidates each INSERT writes
+const exploreBatchSize = 500
+
+// exploreScore weighs the engagement of a post for explore
+const exploreScore = "(posts.like_count * 3 + posts.comment_count * 5 + posts.share_count * 2)"
+
+// explorable restricts a posts query to those anyone may discover, created
+// since cutoff
+func explorable(cutoff time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("posts.is_public = ? AND posts.is_close_friends = ? AND posts.created_at >= ? AND posts.deleted_at IS NULL", true, false, cutoff).
+			Scopes(userrepo.ActiveAuthors("posts.user_id"))
+	}
+}
+
+// precomputed reports whether explore over timeRange can page over fresh
+// candidates
+func (r *feedRepository) precomputed(ctx context.Context, timeRange time.Duration) (bool, error) {
+	known := false
+	for _, window := range r.explore.Windows {
+		known = known || window == timeRange
+	}
+	if !known {
+		return false, nil
+	}
+
+	var latest []*model.ExploreCandidate
+	err := r.db.WithContext(ctx).
+		Select("id", "created_at").
+		Where("window_seconds = ?", int64(timeRange.Seconds())).
+		Order("id DESC").
+		Limit(1).
+		Find(&latest).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check explore candidates: %w", err)
+	}
+	if len(latest) == 0 {
+		return false, nil
+	}
+	return r.explore.MaxAge <= 0 || time.Since(latest[0].CreatedAt) <= r.explore.MaxAge, nil
+}
+
+// RecomputeExplore replaces the candidates of every configured window with
+// the most engaging posts of each tenant, language and region, returning how
+// many it kept. Viewers page over every group their filters admit at once,
+// which still holds the top posts of that union.
+func (r *feedRepository) RecomputeExplore(ctx context.Context, now time.Time) (int64, error) {
+	var kept int64
+	for _, window := range r.explore.Windows {
+		n, err := r.recomputeWindow(ctx, window, now)
+		if err != nil {
+			return kept, fmt.Errorf("failed to recompute explore window %s: %w", window, err)
+		}
+		kept += n
+	}
+	return kept, nil
+}
+
+func (r *feedRepository) recomputeWindow(ctx context.Context, window time.Duration, now time.Time) (int64, error) {
+	db := r.db.WithContext(ctx)
+	cutoff := now.Add(-window)
+
+	var groups []struct {
+		TenantID int64
+		Language string
+		Region   string
+	}
+	err := db.Model(&model.Post{}).
+		Select("DISTINCT posts.tenant_id, COALESCE(posts.language, '') AS language, COALESCE(posts.region, '') AS region").
+		Scopes(explorable(cutoff)).
+		Scan(&groups).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var candidates []*model.ExploreCandidate
+	for _, group := range groups {
+		var top []struct {
+			ID    int64
+			Score int64
+		}
+		err := db.Model(&model.Post{}).
+			Select("posts.id, "+exploreScore+" AS score").
+			Where("posts.tenant_id = ? AND COALESCE(posts.language, '') = ? AND COALESCE(posts.region, '') = ?", group.TenantID, group.Language, group.Region).
+			Scopes(explorable(cutoff)).
+			Order(exploreScore + " DESC, posts.created_at DESC").
+			Limit(r.explore.Candidates).
+			Scan(&top).Error
+		if err != nil {
+			return 0, err
+		}
+		for _, post := range top {
+			candidates = append(candidates, &model.ExploreCandidate{
+				TenantID:      group.TenantID,
+				WindowSeconds: int64(window.Seconds()),
+				PostID:        post.ID,
+				Language:      group.Language,
+				Region:        group.Region,
+				Score:         post.Score,
+			})
+		}
+	}
+
+	// Readers see either the previous candidates or the new ones, never a mix
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("window_seconds = ?", int64(window.Seconds())).Delete(&model.ExploreCandidate{}).Error; err != nil {
+			return err
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(candidates, exploreBatchSize).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(candidates)), nil
+}
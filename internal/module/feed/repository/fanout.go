@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+// FanOutConfig tunes how posts are copied into follower feeds
+type FanOutConfig struct {
+	ChunkSize int // Followers loaded and handed to a worker at a time
+	BatchSize int // Feed rows per INSERT; zero uses the database default
+	Workers   int // Chunks inserted concurrently
+
+	// Authors with at least this many followers are not fanned out; their
+	// posts are merged into follower feeds on read instead. Zero disables it.
+	PullThreshold int64
+}
+
+var DefaultFanOutConfig = FanOutConfig{
+	ChunkSize: 1000,
+	Workers:   4,
+}
+
+// FanOutPost writes a feed entry for the author and each of their followers,
+// or the page's followers for posts published as a page. Followers are paged
+// by ID and inserted chunk by chunk on a bounded pool of workers, so large
+// audiences never load or insert in a single statement.
+func (r *feedRepository) FanOutPost(ctx context.Context, postID, authorID int64, postCreated time.Time) error {
+	// Feed rows inherit the tenant of the post they point at
+	var post model.Post
+	err := r.db.WithContext(ctx).
+		Select("id", "tenant_id", "page_id").
+		Where("id = ?", postID).
+		First(&post).Error
+	if err != nil {
+		return fmt.Errorf("failed to fan out post: %w", err)
+	}
+
+	// A retried job starts over, dropping rows written by the failed attempt
+	err = r.db.WithContext(ctx).Unscoped().
+		Where("post_id = ?", postID).
+		Delete(&model.ActivityFeed{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to fan out post: %w", err)
+	}
+
+	entry := func(userID int64) *model.ActivityFeed {
+		return &model.ActivityFeed{
+			TenantID:    post.TenantID,
+			UserID:      userID,
+			PostID:      postID,
+			AuthorID:    authorID,
+			PostCreated: postCreated,
+		}
+	}
+	if err := r.db.WithContext(ctx).Create(entry(authorID)).Error; err != nil {
+		return fmt.Errorf("failed to fan out post: %w", err)
+	}
+	r.forget(ctx, authorID)
+
+	// Page posts are always pushed; pulled authors are matched by user follows
+	if r.fanOut.PullThreshold > 0 && post.PageID == nil {
+		var followerCount int64
+		err := r.db.WithContext(ctx).Model(&model.User{}).
+			Where("id = ?", authorID).
+			Pluck("follower_count", &followerCount).Error
+		if err != nil {
+			return fmt.Errorf("failed to fan out post: %w", err)
+		}
+		if followerCount >= r.fanOut.PullThreshold {
+			return nil
+		}
+	}
+
+	chunkSize := max(r.fanOut.ChunkSize, 1)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(r.fanOut.Workers, 1))
+
+	var (
+		lastID  int64
+		pageErr error
+	)
+	for gctx.Err() == nil {
+		var followerIDs []int64
+		if post.PageID != nil {
+			pageErr = r.db.WithContext(gctx).Model(&model.PageFollow{}).
+				Where("page_id = ? AND user_id > ? AND user_id <> ? AND deleted_at IS NULL", *post.PageID, lastID, authorID).
+				Order("user_id").
+				Limit(chunkSize).
+				Pluck("user_id", &followerIDs).Error
+		} else {
+			pageErr = r.db.WithContext(gctx).Model(&model.Follow{}).
+				Where("following_id = ? AND follower_id > ? AND deleted_at IS NULL", authorID, lastID).
+				Order("follower_id").
+				Limit(chunkSize).
+				Pluck("follower_id", &followerIDs).Error
+		}
+		if pageErr != nil || len(followerIDs) == 0 {
+			break
+		}
+		lastID = followerIDs[len(followerIDs)-1]
+
+		g.Go(func() error {
+			entries := make([]*model.ActivityFeed, len(followerIDs))
+			for i, followerID := range followerIDs {
+				entries[i] = entry(followerID)
+			}
+			if err := pkgdb.CreateInBatches(gctx, r.db, entries, r.fanOut.BatchSize); err != nil {
+				return err
+			}
+			r.forget(gctx, followerIDs...)
+			return nil
+		})
+		if len(followerIDs) < chunkSize {
+			break
+		}
+	}
+	if err := g.Wait(); err != nil {
+		return fmt.Errorf("failed to fan out post: %w", err)
+	}
+	if pageErr != nil {
+		return fmt.Errorf("failed to load followers: %w", pageErr)
+	}
+	return nil
+}
+
+// feedPosts selects the posts in userID's feed. With a pull threshold the
+// feed also includes posts of followed authors above it, which are never
+// fanned out; fanned-out rows of such authors are matched only once.
+func (r *feedRepository) feedPosts(ctx context.Context, userID int64) *gorm.DB {
+	if r.fanOut.PullThreshold <= 0 {
+		return r.db.WithContext(ctx).Table("activity_feeds").
+			Joins("INNER JOIN posts ON activity_feeds.post_id = posts.id AND posts.deleted_at IS NULL").
+			Where("activity_feeds.user_id = ? AND activity_feeds.deleted_at IS NULL", userID)
+	}
+
+	fannedOut := r.db.Table("activity_feeds").
+		Select("post_id").
+		Where("user_id = ? AND deleted_at IS NULL", userID)
+	pulled := r.db.Table("follows").
+		Select("follows.following_id").
+		Joins("INNER JOIN users ON users.id = follows.following_id").
+		Where("follows.follower_id = ? AND follows.deleted_at IS NULL AND users.follower_count >= ?", userID, r.fanOut.PullThreshold)
+	return r.db.WithContext(ctx).Table("posts").
+		Where("posts.deleted_at IS NULL").
+		Where("posts.id IN (?) OR (posts.community_id IS NULL AND posts.page_id IS NULL AND posts.user_id IN (?))", fannedOut, pulled)
+}
+
+// feedOrder sorts the rows of feedPosts newest first
+func (r *feedRepository) feedOrder() string {
+	if r.fanOut.PullThreshold <= 0 {
+		return "activity_feeds.post_created DESC"
+	}
+	return "posts.created_at DESC, posts.id DESC"
+}
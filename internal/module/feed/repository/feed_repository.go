@@ -3,139 +3,262 @@ package repository
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/hydrator"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	closefriendrepo "github.com/ilhamosaurus/sns-platform/internal/module/closefriend/repository"
+	orgrepo "github.com/ilhamosaurus/sns-platform/internal/module/organization/repository"
+	restrictionrepo "github.com/ilhamosaurus/sns-platform/internal/module/restriction/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/cache"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
 	"github.com/ilhamosaurus/sns-platform/pkg/types"
 	"gorm.io/gorm"
 )
 
 type FeedRepository interface {
 	// Define feed-related data access methods here
-	GetUserFeed(ctx context.Context, userID int64, limit, offset int) ([]*dto.FeedPost, error)
-	GetExploreFeed(ctx context.Context, userID int64, limit, offset int, timeRange time.Duration) ([]*dto.FeedPost, error)
-	GetPostWithDetails(ctx context.Context, postID, userID int64) (*dto.PostDetail, error)
+	GetUserFeed(ctx context.Context, userID int64, limit, offset int, mediaTypes ...types.MediaType) ([]*dto.FeedPost, error)
+	GetExploreFeed(ctx context.Context, userID int64, limit, offset int, timeRange time.Duration, region string, mediaTypes ...types.MediaType) ([]*dto.FeedPost, error)
+	GetNearbyFeed(ctx context.Context, userID int64, lat, lng, radius float64, limit, offset int, timeRange time.Duration) ([]*dto.FeedPost, error)
+	GetCommunityFeed(ctx context.Context, communityID, userID int64, limit, offset int, mediaTypes ...types.MediaType) ([]*dto.FeedPost, error)
+	GetOrganizationFeed(ctx context.Context, orgID, userID int64, limit, offset int, mediaTypes ...types.MediaType) ([]*dto.FeedPost, error)
+	GetPostWithDetails(ctx context.Context, postID, userID int64, sort types.CommentSort) (*dto.PostDetail, error)
+	FanOutPost(ctx context.Context, postID, authorID int64, postCreated time.Time) error
+	CreateBatch(ctx context.Context, entries []*model.ActivityFeed, batchSize int) error
+	FilterRecipients(ctx context.Context, postID int64, userIDs []int64) ([]int64, error)
+	CountNewer(ctx context.Context, userID, afterPostID int64) (int64, error)
+	CountNewerSince(ctx context.Context, userID int64, since time.Time) (int64, error)
+	PruneBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	RecomputeExplore(ctx context.Context, now time.Time) (int64, error)
+	RecomputeAffinities(ctx context.Context, since time.Time) (int64, error)
 }
 
 type feedRepository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	hydrator *hydrator.Hydrator
+	fanOut   FanOutConfig
+	cache    cache.Store // Nil unless WithCache is given
+	cacheTTL time.Duration
+	explore  ExploreConfig  // No windows unless WithExplore is given
+	affinity AffinityConfig // Lifts nothing unless WithAffinity is given
+
+	geoMu   sync.Mutex
+	postgis *bool // Nil until hasPostGIS first asks
+}
+
+// Option configures a feed repository
+type Option func(*feedRepository)
+
+// WithFanOut overrides DefaultFanOutConfig
+func WithFanOut(config FanOutConfig) Option {
+	return func(r *feedRepository) {
+		r.fanOut = config
+	}
 }
 
-func NewFeedRepository(db *gorm.DB) FeedRepository {
-	return &feedRepository{db: db}
+func NewFeedRepository(db *gorm.DB, opts ...Option) FeedRepository {
+	r := &feedRepository{db: db, hydrator: hydrator.NewHydrator(db), fanOut: DefaultFanOutConfig}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// withMediaTypes restricts a posts query to the given media types; none means all
+func withMediaTypes(mediaTypes []types.MediaType) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(mediaTypes) == 0 {
+			return db
+		}
+		return db.Where("posts.media_type IN ?", mediaTypes)
+	}
+}
+
+// withLanguages restricts a posts query to the given languages, keeping posts
+// whose language could not be determined; none means all
+func withLanguages(languages []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(languages) == 0 {
+			return db
+		}
+		return db.Where("posts.language IN ? OR posts.language IS NULL OR posts.language = ''", languages)
+	}
+}
+
+// withRegion restricts a posts query to those placed in region; empty means all
+func withRegion(region string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if region == "" {
+			return db
+		}
+		return db.Where("posts.region = ?", region)
+	}
 }
 
 // GetUserFeed retrieves the activity feed for a user (posts from followed users)
-// This is an optimized query using the pre-computed ActivityFeed table
-func (r *feedRepository) GetUserFeed(ctx context.Context, userID int64, limit, offset int) ([]*dto.FeedPost, error) {
-	var feedPosts []*dto.FeedPost
+// This is an optimized query using the pre-computed ActivityFeed table; with
+// WithAffinity, fresh posts of the authors the user engages with come first
+func (r *feedRepository) GetUserFeed(ctx context.Context, userID int64, limit, offset int, mediaTypes ...types.MediaType) ([]*dto.FeedPost, error) {
+	if r.cache != nil && offset == 0 && limit <= CachePage && len(mediaTypes) == 0 {
+		return r.cachedUserFeed(ctx, userID, limit)
+	}
+	return r.userFeed(ctx, userID, limit, offset, mediaTypes)
+}
 
-	// Query using the denormalized activity_feeds table for better performance
-	err := r.db.WithContext(ctx).Table("activity_feeds").
-		Select(`
-			posts.*,
-			users.id as "author__id",
-			users.username as "author__username",
-			users.full_name as "author__full_name",
-			users.avatar_url as "author__avatar_url",
-			users.is_verified as "author__is_verified",
-			CASE WHEN user_likes.id IS NOT NULL THEN true ELSE false END as has_user_liked
-		`).
-		Joins("INNER JOIN posts ON activity_feeds.post_id = posts.id AND posts.deleted_at IS NULL").
-		Joins("INNER JOIN users ON posts.user_id = users.id AND users.deleted_at IS NULL").
-		Joins(`LEFT JOIN reactions user_likes ON posts.id = user_likes.post_id 
-			AND user_likes.user_id = ? 
-			AND user_likes.type = 'like' 
-			AND user_likes.deleted_at IS NULL`, userID).
-		Where("activity_feeds.user_id = ? AND activity_feeds.deleted_at IS NULL", userID).
-		Order("activity_feeds.post_created DESC").
+func (r *feedRepository) userFeed(ctx context.Context, userID int64, limit, offset int, mediaTypes []types.MediaType) ([]*dto.FeedPost, error) {
+	var posts []*model.Post
+
+	err := r.feedPosts(ctx, userID).
+		Scopes(r.withAffinity(userID), closefriendrepo.VisibleTo(userID), orgrepo.VisibleTo(userID), userrepo.ActiveAuthors("posts.user_id"), withMediaTypes(mediaTypes)).
+		Order("lifted DESC, " + r.feedOrder()).
 		Limit(limit).
 		Offset(offset).
-		Scan(&feedPosts).Error
+		Scan(&posts).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch user feed: %w", err)
 	}
 
-	return feedPosts, nil
+	return r.hydrator.Posts(ctx, posts, userID)
 }
 
-// GetExploreFeed retrieves trending/popular posts for discovery
-func (r *feedRepository) GetExploreFeed(ctx context.Context, userID int64, limit, offset int, timeRange time.Duration) ([]*dto.FeedPost, error) {
-	var feedPosts []*dto.FeedPost
+// GetExploreFeed retrieves trending/popular posts for discovery, in the
+// languages userID prefers and, unless empty, placed in region. Windows with
+// fresh candidates page over them; other time ranges rank every recent post.
+func (r *feedRepository) GetExploreFeed(ctx context.Context, userID int64, limit, offset int, timeRange time.Duration, region string, mediaTypes ...types.MediaType) ([]*dto.FeedPost, error) {
+	var posts []*model.Post
 
 	cutoffTime := time.Now().Add(-timeRange)
 
-	err := r.db.WithContext(ctx).Table("posts").
-		Select(`
-			posts.*,
-			users.id as "author__id",
-			users.username as "author__username",
-			users.full_name as "author__full_name",
-			users.avatar_url as "author__avatar_url",
-			users.is_verified as "author__is_verified",
-			CASE WHEN user_likes.id IS NOT NULL THEN true ELSE false END as has_user_liked,
-			(COALESCE(like_counts.count, 0) * 3 + COALESCE(comment_counts.count, 0) * 5 + posts.share_count * 2) as engagement_score
-		`).
-		Joins("INNER JOIN users ON posts.user_id = users.id AND users.deleted_at IS NULL").
-		Joins(`LEFT JOIN reactions user_likes ON posts.id = user_likes.post_id 
-			AND user_likes.user_id = ? 
-			AND user_likes.type = 'like' 
-			AND user_likes.deleted_at IS NULL`, userID).
-		Where("posts.is_public = ? AND posts.created_at >= ? AND posts.deleted_at IS NULL", true, cutoffTime).
-		Order("engagement_score DESC, posts.created_at DESC").
+	var viewer model.User
+	err := r.db.WithContext(ctx).Select("id", "content_languages").Where("id = ?", userID).Limit(1).Find(&viewer).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch language preferences: %w", err)
+	}
+
+	precomputed, err := r.precomputed(ctx, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	query := r.db.WithContext(ctx).
+		Scopes(explorable(cutoffTime), withMediaTypes(mediaTypes), withLanguages(viewer.PreferredLanguages()), withRegion(region))
+	if precomputed {
+		// Posts made private or removed since the last run drop out here
+		query = query.
+			Select("posts.*").
+			Joins("JOIN explore_candidates ON explore_candidates.post_id = posts.id AND explore_candidates.window_seconds = ? AND explore_candidates.deleted_at IS NULL", int64(timeRange.Seconds())).
+			Order("explore_candidates.score DESC, posts.created_at DESC")
+	} else {
+		query = query.Order(exploreScore + " DESC, posts.created_at DESC")
+	}
+	err = query.
 		Limit(limit).
 		Offset(offset).
-		Scan(&feedPosts).Error
+		Find(&posts).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch explore feed: %w", err)
 	}
 
-	return feedPosts, nil
-}
-
-func (r *feedRepository) GetPostWithDetails(ctx context.Context, postID, userID int64) (*dto.PostDetail, error) {
-	var detail dto.PostDetail
-
-	// Get post with basic stats
-	err := r.db.WithContext(ctx).Table("posts").
-		Select(`
-			posts.*,
-			users.id as "author__id",
-			users.username as "author__username",
-			users.full_name as "author__full_name",
-			users.avatar_url as "author__avatar_url",
-			users.is_verified as "author__is_verified",
-			CASE WHEN user_likes.id IS NOT NULL THEN true ELSE false END as has_user_liked
-		`).
-		Joins("INNER JOIN users ON posts.user_id = users.id AND users.deleted_at IS NULL").
-		Joins(`LEFT JOIN reactions user_likes ON posts.id = user_likes.post_id 
-			AND user_likes.user_id = ? 
-			AND user_likes.type = 'like' 
-			AND user_likes.deleted_at IS NULL`, userID).
+	return r.hydrator.Posts(ctx, posts, userID)
+}
+
+// GetCommunityFeed retrieves the newest posts made inside a community
+func (r *feedRepository) GetCommunityFeed(ctx context.Context, communityID, userID int64, limit, offset int, mediaTypes ...types.MediaType) ([]*dto.FeedPost, error) {
+	var posts []*model.Post
+
+	err := r.db.WithContext(ctx).
+		Where("posts.community_id = ? AND posts.deleted_at IS NULL", communityID).
+		Scopes(closefriendrepo.VisibleTo(userID), userrepo.ActiveAuthors("posts.user_id"), withMediaTypes(mediaTypes)).
+		Order("posts.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&posts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch community feed: %w", err)
+	}
+
+	return r.hydrator.Posts(ctx, posts, userID)
+}
+
+// GetOrganizationFeed retrieves the newest posts made inside an organization,
+// which only its members can see
+func (r *feedRepository) GetOrganizationFeed(ctx context.Context, orgID, userID int64, limit, offset int, mediaTypes ...types.MediaType) ([]*dto.FeedPost, error) {
+	var posts []*model.Post
+
+	err := r.db.WithContext(ctx).
+		Where("posts.organization_id = ? AND posts.deleted_at IS NULL", orgID).
+		Scopes(closefriendrepo.VisibleTo(userID), orgrepo.VisibleTo(userID), userrepo.ActiveAuthors("posts.user_id"), withMediaTypes(mediaTypes)).
+		Order("posts.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&posts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch organization feed: %w", err)
+	}
+
+	return r.hydrator.Posts(ctx, posts, userID)
+}
+
+// GetPostWithDetails loads a post the user may see with its reactions and
+// comment threads, each level in sort order
+func (r *feedRepository) GetPostWithDetails(ctx context.Context, postID, userID int64, sort types.CommentSort) (*dto.PostDetail, error) {
+	var post model.Post
+	err := r.db.WithContext(ctx).
 		Where("posts.id = ? AND posts.deleted_at IS NULL", postID).
-		First(&detail).Error
+		Scopes(closefriendrepo.VisibleTo(userID), orgrepo.VisibleTo(userID), userrepo.ActiveAuthors("posts.user_id")).
+		First(&post).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch post: %w", apperr.Translate(err, "post"))
+	}
+
+	feedPosts, err := r.hydrator.Posts(ctx, []*model.Post{&post}, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch post: %w", err)
+		return nil, err
+	}
+	if len(feedPosts) == 0 {
+		return nil, apperr.NotFound("post not found")
 	}
+	detail := dto.PostDetail{FeedPost: feedPosts[0]}
 
 	// Get reaction summary
 	var reactions []struct {
 		Type  types.ReactionType
 		Count int64
 	}
-	r.db.Table("reactions").
+	err = r.db.WithContext(ctx).Table("reactions").
 		Select("type, COUNT(*) as count").
 		Where("post_id = ? AND deleted_at IS NULL", postID).
 		Group("type").
-		Scan(&reactions)
+		Scan(&reactions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reactions: %w", err)
+	}
 
 	detail.ReactionSummary = make(map[string]int64)
 	for _, reaction := range reactions {
 		detail.ReactionSummary[reaction.Type.String()] = reaction.Count
 	}
 
-	// Get comments with nested replies
-	detail.Comments, err = r.getCommentsWithReplies(ctx, postID, userID, nil)
+	// Get comments with nested replies, loaded in one query and threaded in memory
+	order := "created_at ASC, id ASC"
+	if sort == types.CommentSortTop {
+		order = "rank_score DESC, likes_count DESC, id ASC"
+	}
+	var comments []*model.Comment
+	err = r.db.WithContext(ctx).
+		Where("post_id = ? AND deleted_at IS NULL", postID).
+		Scopes(restrictionrepo.CommentsVisibleTo(userID), userrepo.ActiveAuthors("comments.user_id")).
+		Order(order).
+		Find(&comments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+	detail.Comments, err = r.hydrator.CommentTree(ctx, comments, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch comments: %w", err)
 	}
@@ -143,45 +266,89 @@ func (r *feedRepository) GetPostWithDetails(ctx context.Context, postID, userID
 	return &detail, nil
 }
 
-// getCommentsWithReplies recursively fetches comments and their replies
-func (r *feedRepository) getCommentsWithReplies(ctx context.Context, postID, userID int64, parentID *int64) ([]*dto.CommentWithReplies, error) {
-	var comments []*dto.CommentWithReplies
-
-	query := r.db.WithContext(ctx).Table("comments").
-		Select(`
-			comments.*,
-			users.id as "author__id",
-			users.username as "author__username",
-			users.full_name as "author__full_name",
-			users.avatar_url as "author__avatar_url",
-			CASE WHEN user_likes.id IS NOT NULL THEN true ELSE false END as has_user_liked
-		`).
-		Joins("INNER JOIN users ON comments.user_id = users.id AND users.deleted_at IS NULL").
-		Joins(`LEFT JOIN reactions user_likes ON comments.id = user_likes.comment_id 
-			AND user_likes.user_id = ? 
-			AND user_likes.type = 'like' 
-			AND user_likes.deleted_at IS NULL`, userID).
-		Where("comments.post_id = ? AND comments.deleted_at IS NULL", postID).
-		Order("comments.created_at ASC")
-
-	if parentID == nil {
-		query = query.Where("comments.parent_id IS NULL")
-	} else {
-		query = query.Where("comments.parent_id = ?", *parentID)
+// CreateBatch inserts feed entries batchSize rows per statement (zero for the configured default)
+func (r *feedRepository) CreateBatch(ctx context.Context, entries []*model.ActivityFeed, batchSize int) error {
+	if err := pkgdb.CreateInBatches(ctx, r.db, entries, batchSize); err != nil {
+		return fmt.Errorf("failed to insert feed entries: %w", err)
 	}
+	return nil
+}
 
-	if err := query.Scan(&comments).Error; err != nil {
-		return nil, err
+// FilterRecipients returns the users among userIDs whose feed received postID
+// and who are allowed to see it
+func (r *feedRepository) FilterRecipients(ctx context.Context, postID int64, userIDs []int64) ([]int64, error) {
+	var recipients []int64
+	err := r.db.WithContext(ctx).Table("activity_feeds").
+		Joins("INNER JOIN posts ON activity_feeds.post_id = posts.id AND posts.deleted_at IS NULL").
+		Where("activity_feeds.post_id = ? AND activity_feeds.user_id IN ? AND activity_feeds.deleted_at IS NULL", postID, userIDs).
+		Scopes(closefriendrepo.VisibleToColumn("activity_feeds.user_id"), orgrepo.VisibleToColumn("activity_feeds.user_id")).
+		Pluck("activity_feeds.user_id", &recipients).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter feed recipients: %w", err)
+	}
+	if r.fanOut.PullThreshold <= 0 {
+		return recipients, nil
 	}
 
-	// Fetch replies for each comment
-	for i := range comments {
-		replies, err := r.getCommentsWithReplies(ctx, postID, userID, &comments[i].ID)
-		if err != nil {
-			return nil, err
+	// Posts of pulled authors have no feed rows; their followers see them on read
+	var followers []int64
+	err = r.db.WithContext(ctx).Table("follows").
+		Joins("INNER JOIN posts ON posts.user_id = follows.following_id AND posts.deleted_at IS NULL").
+		Joins("INNER JOIN users ON users.id = posts.user_id").
+		Where("posts.id = ? AND posts.community_id IS NULL AND posts.page_id IS NULL AND users.follower_count >= ?", postID, r.fanOut.PullThreshold).
+		Where("follows.follower_id IN ? AND follows.deleted_at IS NULL", userIDs).
+		Scopes(closefriendrepo.VisibleToColumn("follows.follower_id"), orgrepo.VisibleToColumn("follows.follower_id")).
+		Pluck("follows.follower_id", &followers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter feed recipients: %w", err)
+	}
+	for _, id := range followers {
+		if !slices.Contains(recipients, id) {
+			recipients = append(recipients, id)
 		}
-		comments[i].Replies = replies
 	}
+	return recipients, nil
+}
+
+// CountNewer counts the visible posts in userID's feed newer than afterPostID
+func (r *feedRepository) CountNewer(ctx context.Context, userID, afterPostID int64) (int64, error) {
+	var count int64
+	err := r.feedPosts(ctx, userID).
+		Where("posts.id > ? AND posts.user_id <> ?", afterPostID, userID).
+		Scopes(closefriendrepo.VisibleTo(userID), orgrepo.VisibleTo(userID), userrepo.ActiveAuthors("posts.user_id")).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count new feed posts: %w", err)
+	}
+	return count, nil
+}
+
+// CountNewerSince counts the visible posts in userID's feed created after
+// since, for clients that keep a timestamp rather than a post ID. Without a
+// pull threshold this is a range scan of the user's activity_feeds index.
+func (r *feedRepository) CountNewerSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	created := "posts.created_at"
+	if r.fanOut.PullThreshold <= 0 {
+		created = "activity_feeds.post_created"
+	}
+	var count int64
+	err := r.feedPosts(ctx, userID).
+		Where(created+" > ? AND posts.user_id <> ?", since, userID).
+		Scopes(closefriendrepo.VisibleTo(userID), orgrepo.VisibleTo(userID), userrepo.ActiveAuthors("posts.user_id")).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count new feed posts: %w", err)
+	}
+	return count, nil
+}
 
-	return comments, nil
+// PruneBefore permanently removes feed entries for posts created before cutoff
+func (r *feedRepository) PruneBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Unscoped().
+		Where("post_created < ?", cutoff).
+		Delete(&model.ActivityFeed{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to prune activity feed: %w", res.Error)
+	}
+	return res.RowsAffected, nil
 }
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"gorm.io/gorm"
+)
+
+// MaxNearbyRadius caps how far nearby posts reach, in meters; beyond it the
+// flat approximation used without PostGIS drifts too far from the circle
+const MaxNearbyRadius = 100_000
+
+// metersPerDegree is the length of a degree of latitude on the mean Earth
+const metersPerDegree = 6_371_000 * math.Pi / 180
+
+// geoPoint is a geotag as PostGIS sees it; the GiST index on posts is built
+// on this very expression, so queries must repeat it to use the index
+const geoPoint = "(ST_SetSRID(ST_MakePoint(posts.longitude, posts.latitude), 4326)::geography)"
+
+// withinRadius restricts a posts query to those geotagged within radius
+// meters of lat and lng. Without PostGIS, a bounding box narrows the rows
+// through the latitude and longitude index and an equirectangular distance,
+// which is plain arithmetic on every database, draws the circle; longitudes
+// are compared across the antimeridian.
+func withinRadius(postgis bool, lat, lng, radius float64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("posts.latitude IS NOT NULL AND posts.longitude IS NOT NULL")
+		if postgis {
+			return db.Where("ST_DWithin("+geoPoint+", ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)", lng, lat, radius)
+		}
+
+		dLat := radius / metersPerDegree
+		db = db.Where("posts.latitude BETWEEN ? AND ?", max(lat-dLat, -90), min(lat+dLat, 90))
+		scale := math.Cos(lat * math.Pi / 180) // Length of a degree of longitude relative to one of latitude
+		if dLng := dLat / max(scale, 1e-9); dLng < 180 {
+			west, east := lng-dLng, lng+dLng
+			switch {
+			case west < -180:
+				db = db.Where("posts.longitude >= ? OR posts.longitude <= ?", west+360, east)
+			case east > 180:
+				db = db.Where("posts.longitude >= ? OR posts.longitude <= ?", west, east-360)
+			default:
+				db = db.Where("posts.longitude BETWEEN ? AND ?", west, east)
+			}
+		}
+		return db.Where(`(posts.latitude - @lat) * (posts.latitude - @lat) +
+			(CASE WHEN posts.longitude - @lng > 180 THEN posts.longitude - @lng - 360
+				WHEN posts.longitude - @lng < -180 THEN posts.longitude - @lng + 360
+				ELSE posts.longitude - @lng END) * @scale *
+			(CASE WHEN posts.longitude - @lng > 180 THEN posts.longitude - @lng - 360
+				WHEN posts.longitude - @lng < -180 THEN posts.longitude - @lng + 360
+				ELSE posts.longitude - @lng END) * @scale <= @reach`,
+			map[string]any{"lat": lat, "lng": lng, "scale": scale, "reach": dLat * dLat})
+	}
+}
+
+// GetNearbyFeed retrieves the public posts of the last timeRange geotagged
+// within radius meters (at most MaxNearbyRadius) of lat and lng, newest
+// first, in the languages userID prefers
+func (r *feedRepository) GetNearbyFeed(ctx context.Context, userID int64, lat, lng, radius float64, limit, offset int, timeRange time.Duration) ([]*dto.FeedPost, error) {
+	var viewer model.User
+	err := r.db.WithContext(ctx).Select("id", "content_languages").Where("id = ?", userID).Limit(1).Find(&viewer).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch language preferences: %w", err)
+	}
+
+	postgis, err := r.hasPostGIS(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var posts []*model.Post
+	err = r.db.WithContext(ctx).
+		Scopes(explorable(time.Now().Add(-timeRange)), withinRadius(postgis, lat, lng, min(radius, MaxNearbyRadius)), withLanguages(viewer.PreferredLanguages())).
+		Order("posts.created_at DESC, posts.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&posts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch nearby posts: %w", err)
+	}
+
+	return r.hydrator.Posts(ctx, posts, userID)
+}
+
+// hasPostGIS reports whether the database is PostgreSQL with the PostGIS
+// extension installed; the answer is remembered once found
+func (r *feedRepository) hasPostGIS(ctx context.Context) (bool, error) {
+	if pkgdb.DatabaseType(r.db.Dialector.Name()) != pkgdb.PostgreSQL {
+		return false, nil
+	}
+	r.geoMu.Lock()
+	defer r.geoMu.Unlock()
+	if r.postgis != nil {
+		return *r.postgis, nil
+	}
+
+	var n int64
+	if err := r.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM pg_extension WHERE extname = 'postgis'").Scan(&n).Error; err != nil {
+		return false, fmt.Errorf("failed to check for postgis: %w", err)
+	}
+	postgis := n > 0
+	r.postgis = &postgis
+	return postgis, nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	notificationrepo "github.com/ilhamosaurus/sns-platform/internal/module/notification/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+)
+
+// WarmOnLogin fills the cached first page of a user's home feed and their
+// unread notification count as they log in, off the request path, so the
+// first screen after opening the app is served from the cache. Both
+// repositories must have been built with their WithCache option, and what
+// is already cached is left as is.
+func WarmOnLogin(bus eventbus.Bus, feed feedrepo.FeedRepository, notifications notificationrepo.NotificationRepository) {
+	eventbus.On(bus, func(ctx context.Context, e event.UserLoggedIn) error {
+		// Warming is best effort; the first request fills whatever fails here
+		if _, err := feed.GetUserFeed(ctx, e.UserID, feedrepo.CachePage, 0); err != nil {
+			slog.WarnContext(ctx, "failed to warm feed cache", slog.Int64("user_id", e.UserID), slog.Any("error", err))
+		}
+		if _, err := notifications.CountUnread(ctx, e.UserID); err != nil {
+			slog.WarnContext(ctx, "failed to warm unread count cache", slog.Int64("user_id", e.UserID), slog.Any("error", err))
+		}
+		return nil
+	})
+}
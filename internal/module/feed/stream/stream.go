@@ -0,0 +1,181 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/hydrator"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/broadcast"
+	"github.com/ilhamosaurus/sns-platform/pkg/metrics"
+)
+
+const (
+	// Channel is the broadcast channel carrying feed updates between instances
+	Channel = "feed:updates"
+
+	// subscriptionBuffer is how many items a slow client may fall behind
+	// before further items are dropped; its new-post count stays accurate
+	subscriptionBuffer = 32
+
+	// recipientBatchSize bounds the IN list of each recipient lookup
+	recipientBatchSize = 1000
+)
+
+// Update announces that a post has been fanned out to its author's followers
+type Update struct {
+	PostID      int64     `json:"post_id"`
+	AuthorID    int64     `json:"author_id"`
+	PostCreated time.Time `json:"post_created"`
+}
+
+// Hub pushes new feed items to the clients connected to this instance. Updates
+// are broadcast to every instance, and each one looks up which of its own
+// connected users received the post.
+type Hub struct {
+	broadcaster broadcast.Broadcaster
+	feedRepo    feedrepo.FeedRepository
+	postRepo    postrepo.PostRepository
+	hydrator    *hydrator.Hydrator
+
+	mu   sync.RWMutex
+	subs map[int64]map[*Subscription]struct{}
+}
+
+// NewHub creates a hub and subscribes it to Channel; the broadcaster must be
+// run after all hubs are created
+func NewHub(broadcaster broadcast.Broadcaster, feedRepo feedrepo.FeedRepository, postRepo postrepo.PostRepository, hydrator *hydrator.Hydrator) *Hub {
+	h := &Hub{
+		broadcaster: broadcaster,
+		feedRepo:    feedRepo,
+		postRepo:    postRepo,
+		hydrator:    hydrator,
+		subs:        make(map[int64]map[*Subscription]struct{}),
+	}
+	broadcaster.Subscribe(Channel, h.deliver)
+	return h
+}
+
+// Subscription receives the posts added to one user's feed while it is open
+type Subscription struct {
+	C <-chan *dto.FeedPost
+
+	hub    *Hub
+	userID int64
+	c      chan *dto.FeedPost
+	once   sync.Once
+	missed atomic.Int64
+}
+
+// Subscribe starts receiving new feed items for userID
+func (h *Hub) Subscribe(userID int64) *Subscription {
+	c := make(chan *dto.FeedPost, subscriptionBuffer)
+	sub := &Subscription{C: c, hub: h, userID: userID, c: c}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*Subscription]struct{})
+	}
+	h.subs[userID][sub] = struct{}{}
+	metrics.StreamConnections.Inc()
+	return sub
+}
+
+// Close stops the subscription and closes C
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		defer s.hub.mu.Unlock()
+		delete(s.hub.subs[s.userID], s)
+		if len(s.hub.subs[s.userID]) == 0 {
+			delete(s.hub.subs, s.userID)
+		}
+		close(s.c)
+		metrics.StreamConnections.Dec()
+	})
+}
+
+// TakeMissed returns how many items were dropped because the subscriber fell
+// behind since the last call, so they can still be counted as new posts
+func (s *Subscription) TakeMissed() int64 {
+	return s.missed.Swap(0)
+}
+
+// Publish announces a fanned-out post to every instance
+func (h *Hub) Publish(ctx context.Context, update Update) error {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("failed to encode feed update: %w", err)
+	}
+	return h.broadcaster.Publish(ctx, Channel, payload)
+}
+
+func (h *Hub) deliver(ctx context.Context, payload []byte) {
+	var update Update
+	if err := json.Unmarshal(payload, &update); err != nil {
+		slog.WarnContext(ctx, "failed to decode feed update", slog.Any("error", err))
+		return
+	}
+
+	// Authors are not told about their own posts
+	h.mu.RLock()
+	connected := make([]int64, 0, len(h.subs))
+	for userID := range h.subs {
+		if userID != update.AuthorID {
+			connected = append(connected, userID)
+		}
+	}
+	h.mu.RUnlock()
+	if len(connected) == 0 {
+		return
+	}
+
+	var recipients []int64
+	for start := 0; start < len(connected); start += recipientBatchSize {
+		batch, err := h.feedRepo.FilterRecipients(ctx, update.PostID, connected[start:min(start+recipientBatchSize, len(connected))])
+		if err != nil {
+			slog.WarnContext(ctx, "failed to resolve feed update recipients", slog.Int64("post_id", update.PostID), slog.Any("error", err))
+			return
+		}
+		recipients = append(recipients, batch...)
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	// A brand-new post has no likes or bookmarks yet, so one author-only
+	// hydration serves every recipient
+	post, err := h.postRepo.GetByID(ctx, update.PostID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load post for feed update", slog.Int64("post_id", update.PostID), slog.Any("error", err))
+		return
+	}
+	items, err := h.hydrator.Posts(ctx, []*model.Post{post}, 0)
+	if err != nil || len(items) == 0 {
+		slog.WarnContext(ctx, "failed to hydrate post for feed update", slog.Int64("post_id", update.PostID), slog.Any("error", err))
+		return
+	}
+	item := items[0]
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, userID := range recipients {
+		for sub := range h.subs[userID] {
+			select {
+			case sub.c <- item:
+			default:
+				sub.missed.Add(1)
+				slog.DebugContext(ctx, "feed stream subscriber is behind, dropping item", slog.Int64("user_id", userID), slog.Int64("post_id", post.ID))
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/follow/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type FollowHandler struct {
+	service service.FollowService
+}
+
+func NewFollowHandler(svc service.FollowService) *FollowHandler {
+	return &FollowHandler{service: svc}
+}
+
+// Register mounts the follow routes; they expect an authenticated user in the request context
+func (h *FollowHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("PUT /me/following/{userID}", h.Follow)
+	mux.HandleFunc("DELETE /me/following/{userID}", h.Unfollow)
+}
+
+// Follow makes the caller follow a user
+func (h *FollowHandler) Follow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	followingID, err := httpx.PathInt64(r, "userID")
+	if err != nil || followingID == userID {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.service.Follow(r.Context(), userID, followingID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Unfollow stops the caller following a user
+func (h *FollowHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	followingID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.service.Unfollow(r.Context(), userID, followingID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,13 +1,17 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
 	"gorm.io/gorm"
 )
 
 type FollowRepository interface {
-	Follow(followerID, followingID int64) error
-	Unfollow(followerID, followingID int64) error
+	Follow(ctx context.Context, followerID, followingID int64) error
+	Unfollow(ctx context.Context, followerID, followingID int64) error
+	IsFollowing(ctx context.Context, followerID, followingID int64) (bool, error)
 }
 
 func NewFollowRepository(db *gorm.DB) FollowRepository {
@@ -18,14 +22,31 @@ type followRepository struct {
 	db *gorm.DB
 }
 
-func (r *followRepository) Follow(followerID, followingID int64) error {
+func (r *followRepository) Follow(ctx context.Context, followerID, followingID int64) error {
 	follow := &model.Follow{
 		FollowerID:  followerID,
 		FollowingID: followingID,
 	}
-	return r.db.Create(follow).Error
+	return apperr.Translate(r.db.WithContext(ctx).Create(follow).Error, "follow")
+}
+
+// Unfollow deletes the follow outright so the pair can follow again later
+func (r *followRepository) Unfollow(ctx context.Context, followerID, followingID int64) error {
+	res := r.db.WithContext(ctx).Unscoped().Where("follower_id = ? AND following_id = ?", followerID, followingID).Delete(&model.Follow{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return apperr.NotFound("follow not found")
+	}
+	return nil
 }
 
-func (r *followRepository) Unfollow(followerID, followingID int64) error {
-	return r.db.Where("follower_id = ? AND following_id = ? AND deleted_at IS NULL", followerID, followingID).Delete(&model.Follow{}).Error
+// IsFollowing reports whether followerID follows followingID
+func (r *followRepository) IsFollowing(ctx context.Context, followerID, followingID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Follow{}).
+		Where("follower_id = ? AND following_id = ? AND deleted_at IS NULL", followerID, followingID).
+		Count(&count).Error
+	return count > 0, err
 }
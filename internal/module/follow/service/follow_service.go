@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/module/follow/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/quota"
+)
+
+type FollowService interface {
+	Follow(ctx context.Context, followerID, followingID int64) error
+	Unfollow(ctx context.Context, followerID, followingID int64) error
+}
+
+type followService struct {
+	repo     repository.FollowRepository
+	userRepo userrepo.UserRepository
+	quota    *quota.Quota
+	bus      eventbus.Bus
+}
+
+// NewFollowService creates the follow service; q may be nil to leave follows
+// unlimited
+func NewFollowService(repo repository.FollowRepository, userRepo userrepo.UserRepository, q *quota.Quota, bus eventbus.Bus) FollowService {
+	return &followService{repo: repo, userRepo: userRepo, quota: q, bus: bus}
+}
+
+// Follow makes followerID follow an active user. Only a follow that was
+// stored is charged to the follower's quota; one over the quota is taken
+// back.
+func (s *followService) Follow(ctx context.Context, followerID, followingID int64) error {
+	following, err := s.userRepo.GetByID(ctx, followingID)
+	if err != nil {
+		return err
+	}
+	if following.IsBanned() || following.IsDeactivated() {
+		return apperr.NotFound("user not found")
+	}
+	if err := s.repo.Follow(ctx, followerID, followingID); err != nil {
+		return err
+	}
+	if _, err := s.quota.Consume(ctx, followerID, quota.ActionFollow); err != nil {
+		if undoErr := s.repo.Unfollow(ctx, followerID, followingID); undoErr != nil {
+			slog.ErrorContext(ctx, "failed to undo follow over quota", slog.Int64("follower_id", followerID), slog.Int64("following_id", followingID), slog.Any("error", undoErr))
+		}
+		return err
+	}
+
+	if err := s.bus.Publish(ctx, event.UserFollowed{FollowerID: followerID, FollowingID: followingID}); err != nil {
+		slog.WarnContext(ctx, "failed to publish follow", slog.Int64("follower_id", followerID), slog.Int64("following_id", followingID), slog.Any("error", err))
+	}
+	return nil
+}
+
+// Unfollow stops followerID following followingID
+func (s *followService) Unfollow(ctx context.Context, followerID, followingID int64) error {
+	if err := s.repo.Unfollow(ctx, followerID, followingID); err != nil {
+		return err
+	}
+
+	if err := s.bus.Publish(ctx, event.UserUnfollowed{FollowerID: followerID, FollowingID: followingID}); err != nil {
+		slog.WarnContext(ctx, "failed to publish unfollow", slog.Int64("follower_id", followerID), slog.Int64("following_id", followingID), slog.Any("error", err))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/invite/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type InviteHandler struct {
+	service service.InviteService
+}
+
+func NewInviteHandler(svc service.InviteService) *InviteHandler {
+	return &InviteHandler{service: svc}
+}
+
+// Register mounts the invite routes; they expect an authenticated user in the request context
+func (h *InviteHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/invites", h.List)
+	mux.HandleFunc("POST /me/invites", h.Create)
+	mux.HandleFunc("DELETE /me/invites/{id}", h.Revoke)
+}
+
+// List returns the caller's invites, newest first
+func (h *InviteHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	invites, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"invites": invites})
+}
+
+// Create issues an invite code for the caller to hand out
+func (h *InviteHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	invite, err := h.service.Create(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, invite)
+}
+
+// Revoke voids one of the caller's invites
+func (h *InviteHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	inviteID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid invite id")
+		return
+	}
+
+	if err := h.service.Revoke(r.Context(), userID, inviteID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type AdminHandler struct {
+	service service.InviteService
+}
+
+func NewAdminHandler(svc service.InviteService) *AdminHandler {
+	return &AdminHandler{service: svc}
+}
+
+// Register mounts the invite graph admin routes; callers are expected to wrap mux with admin auth
+func (h *AdminHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/users/{id}/invites", h.Trace)
+	mux.HandleFunc("POST /admin/users/{id}/invites/revoke", h.RevokeAll)
+}
+
+// Trace shows who invited a user, up the chain, and whom they invited
+func (h *AdminHandler) Trace(w http.ResponseWriter, r *http.Request) {
+	userID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	trace, err := h.service.Trace(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, trace)
+}
+
+// RevokeAll voids every unused invite of a user
+func (h *AdminHandler) RevokeAll(w http.ResponseWriter, r *http.Request) {
+	userID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	revoked, err := h.service.RevokeAll(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"revoked": revoked})
+}
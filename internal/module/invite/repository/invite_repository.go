@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+// usable matches the invites that can still admit an account
+const usable = "revoked_at IS NULL AND uses < max_uses AND (expires_at IS NULL OR expires_at > ?)"
+
+type InviteRepository interface {
+	Create(ctx context.Context, invite *model.Invite) error
+	GetByCode(ctx context.Context, code string) (*model.Invite, error)
+	ListByInviter(ctx context.Context, inviterID int64) ([]*model.Invite, error)
+	CountUsable(ctx context.Context, inviterID int64, now time.Time) (int64, error)
+	Use(ctx context.Context, id int64, now time.Time) (bool, error)
+	Release(ctx context.Context, id int64) error
+	Revoke(ctx context.Context, inviterID, id int64, now time.Time) (bool, error)
+	RevokeAll(ctx context.Context, inviterID int64, now time.Time) (int64, error)
+	Invitees(ctx context.Context, inviterID int64) ([]*model.User, error)
+}
+
+func NewInviteRepository(db *gorm.DB) InviteRepository {
+	return &inviteRepository{db: db}
+}
+
+type inviteRepository struct {
+	db *gorm.DB
+}
+
+func (r *inviteRepository) Create(ctx context.Context, invite *model.Invite) error {
+	return apperr.Translate(r.db.WithContext(ctx).Create(invite).Error, "invite")
+}
+
+func (r *inviteRepository) GetByCode(ctx context.Context, code string) (*model.Invite, error) {
+	var invite model.Invite
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&invite).Error; err != nil {
+		return nil, apperr.Translate(err, "invite")
+	}
+	return &invite, nil
+}
+
+// ListByInviter returns the user's invites, newest first
+func (r *inviteRepository) ListByInviter(ctx context.Context, inviterID int64) ([]*model.Invite, error) {
+	var invites []*model.Invite
+	err := r.db.WithContext(ctx).
+		Where("inviter_id = ?", inviterID).
+		Order("created_at DESC, id DESC").
+		Find(&invites).Error
+	if err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// CountUsable counts the user's invites that can still admit an account
+func (r *inviteRepository) CountUsable(ctx context.Context, inviterID int64, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Invite{}).
+		Where("inviter_id = ? AND "+usable, inviterID, now).
+		Count(&count).Error
+	return count, err
+}
+
+// Use takes one of the invite's uses unless it is used up, expired or
+// revoked by then; the check and the increment are one statement, so two
+// sign-ups cannot take its last use
+func (r *inviteRepository) Use(ctx context.Context, id int64, now time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.Invite{}).
+		Where("id = ? AND "+usable, id, now).
+		UpdateColumn("uses", gorm.Expr("uses + 1"))
+	return res.RowsAffected == 1, res.Error
+}
+
+// Release gives back a use taken by a sign-up that failed
+func (r *inviteRepository) Release(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Model(&model.Invite{}).
+		Where("id = ? AND uses > 0", id).
+		UpdateColumn("uses", gorm.Expr("uses - 1")).Error
+}
+
+// Revoke voids one of the inviter's invites; it reports false if there is
+// no such invite or it was already revoked
+func (r *inviteRepository) Revoke(ctx context.Context, inviterID, id int64, now time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.Invite{}).
+		Where("id = ? AND inviter_id = ? AND revoked_at IS NULL", id, inviterID).
+		UpdateColumn("revoked_at", now)
+	return res.RowsAffected == 1, res.Error
+}
+
+// RevokeAll voids every invite of the inviter that can still admit an
+// account and returns how many it voided
+func (r *inviteRepository) RevokeAll(ctx context.Context, inviterID int64, now time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.Invite{}).
+		Where("inviter_id = ? AND "+usable, inviterID, now).
+		UpdateColumn("revoked_at", now)
+	return res.RowsAffected, res.Error
+}
+
+// Invitees returns the users who signed up with one of the inviter's
+// invites, oldest first, deleted accounts included
+func (r *inviteRepository) Invitees(ctx context.Context, inviterID int64) ([]*model.User, error) {
+	var users []*model.User
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("invited_by_id = ?", inviterID).
+		Order("created_at ASC, id ASC").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/invite/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+)
+
+const (
+	codeLength = 10
+	// codeAlphabet leaves out letters and digits people mistake for each other
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	// maxTraceDepth bounds how far up the invite chain a trace follows
+	maxTraceDepth = 20
+)
+
+var (
+	ErrInviteRequired = apperr.Forbidden("an invite code is needed to sign up").WithCode(apperr.CodeInviteRequired)
+	ErrInviteInvalid  = apperr.Forbidden("invite code is invalid, used up, expired or revoked").WithCode(apperr.CodeInviteInvalid)
+	ErrInviteLimit    = apperr.TooMany("you have as many unused invites as you can hold")
+	ErrInviteNotFound = apperr.NotFound("invite not found")
+)
+
+type Config struct {
+	Limit int           // Unused invites a user may hold at once
+	Uses  int           // Sign-ups one invite admits
+	TTL   time.Duration // How long an invite stays valid; zero never expires
+}
+
+// InviteService hands out invite codes and redeems them at sign-up. Every
+// account admitted by an invite records it and its inviter, so admins can
+// trace abuse back up the chain of inviters.
+type InviteService interface {
+	Create(ctx context.Context, userID int64) (*model.Invite, error)
+	List(ctx context.Context, userID int64) ([]*model.Invite, error)
+	Revoke(ctx context.Context, userID, inviteID int64) error
+	Check(ctx context.Context, code string) error
+	Redeem(ctx context.Context, code string) (*model.Invite, error)
+	Release(ctx context.Context, invite *model.Invite) error
+	Trace(ctx context.Context, userID int64) (*dto.InviteTrace, error)
+	RevokeAll(ctx context.Context, userID int64) (int64, error)
+}
+
+type inviteService struct {
+	repo     repository.InviteRepository
+	userRepo userrepo.UserRepository
+	audit    auditsvc.AuditService
+	config   Config
+}
+
+func NewInviteService(repo repository.InviteRepository, userRepo userrepo.UserRepository, audit auditsvc.AuditService, config Config) InviteService {
+	return &inviteService{repo: repo, userRepo: userRepo, audit: audit, config: config}
+}
+
+// Create issues a new invite unless the user already holds Limit unused ones
+func (s *inviteService) Create(ctx context.Context, userID int64) (*model.Invite, error) {
+	now := time.Now().UTC()
+	count, err := s.repo.CountUsable(ctx, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count invites: %w", err)
+	}
+	if count >= int64(s.config.Limit) {
+		return nil, ErrInviteLimit
+	}
+
+	invite := &model.Invite{InviterID: userID, Code: newCode(), MaxUses: max(s.config.Uses, 1)}
+	if s.config.TTL > 0 {
+		expires := now.Add(s.config.TTL)
+		invite.ExpiresAt = &expires
+	}
+	if err := s.repo.Create(ctx, invite); err != nil {
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+	return invite, nil
+}
+
+func (s *inviteService) List(ctx context.Context, userID int64) ([]*model.Invite, error) {
+	return s.repo.ListByInviter(ctx, userID)
+}
+
+// Revoke voids one of the user's invites; the accounts it already admitted stay
+func (s *inviteService) Revoke(ctx context.Context, userID, inviteID int64) error {
+	revoked, err := s.repo.Revoke(ctx, userID, inviteID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to revoke invite: %w", err)
+	}
+	if !revoked {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// Check reports whether code could admit an account now, without using it
+func (s *inviteService) Check(ctx context.Context, code string) error {
+	_, err := s.usable(ctx, code)
+	return err
+}
+
+// Redeem takes one use of the invite with code for a sign-up. Invites of
+// banned inviters admit nobody.
+func (s *inviteService) Redeem(ctx context.Context, code string) (*model.Invite, error) {
+	invite, err := s.usable(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	used, err := s.repo.Use(ctx, invite.ID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem invite: %w", err)
+	}
+	if !used {
+		return nil, ErrInviteInvalid
+	}
+	invite.Uses++
+	return invite, nil
+}
+
+// Release gives back the use a failed sign-up took from invite
+func (s *inviteService) Release(ctx context.Context, invite *model.Invite) error {
+	return s.repo.Release(ctx, invite.ID)
+}
+
+// Trace returns who invited the user, up the chain, whom they invited and
+// the invites they hold
+func (s *inviteService) Trace(ctx context.Context, userID int64) (*dto.InviteTrace, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	trace := &dto.InviteTrace{User: dto.NewUserResponse(user), Inviters: []*dto.UserResponse{}}
+
+	seen := map[int64]bool{user.ID: true}
+	for next := user.InvitedByID; next != nil && !seen[*next] && len(trace.Inviters) < maxTraceDepth; {
+		seen[*next] = true
+		inviter, err := s.userRepo.GetByID(ctx, *next)
+		if errors.Is(err, apperr.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		trace.Inviters = append(trace.Inviters, dto.NewUserResponse(inviter))
+		next = inviter.InvitedByID
+	}
+
+	invitees, err := s.repo.Invitees(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitees: %w", err)
+	}
+	trace.Invitees = dto.NewUserResponses(invitees)
+	if trace.Invites, err = s.repo.ListByInviter(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to list invites: %w", err)
+	}
+	return trace, nil
+}
+
+// RevokeAll voids every unused invite of the user; admins use it on
+// inviters found letting abuse in, and it is audited
+func (s *inviteService) RevokeAll(ctx context.Context, userID int64) (int64, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return 0, err
+	}
+	revoked, err := s.repo.RevokeAll(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke invites: %w", err)
+	}
+	if revoked > 0 {
+		s.audit.Record(ctx, &model.AuditLog{
+			Action:     model.AuditAdminRevokeInvites,
+			TargetType: model.AuditTargetUser,
+			TargetID:   userID,
+			After:      map[string]any{"revoked_invites": revoked},
+		})
+	}
+	return revoked, nil
+}
+
+// usable returns the invite with code if it could admit an account now
+func (s *inviteService) usable(ctx context.Context, code string) (*model.Invite, error) {
+	code = NormalizeCode(code)
+	if code == "" {
+		return nil, ErrInviteRequired
+	}
+	invite, err := s.repo.GetByCode(ctx, code)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, ErrInviteInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !invite.Usable(time.Now()) {
+		return nil, ErrInviteInvalid
+	}
+	inviter, err := s.userRepo.GetByID(ctx, invite.InviterID)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, ErrInviteInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if inviter.IsBanned() {
+		return nil, ErrInviteInvalid
+	}
+	return invite, nil
+}
+
+// NormalizeCode uppercases a code and drops the spaces and dashes people
+// copy it with
+func NormalizeCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+func newCode() string {
+	b := make([]byte, codeLength)
+	rand.Read(b)
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b)
+}
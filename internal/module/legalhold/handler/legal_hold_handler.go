@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/legalhold/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type AdminHandler struct {
+	service service.LegalHoldService
+}
+
+func NewAdminHandler(svc service.LegalHoldService) *AdminHandler {
+	return &AdminHandler{service: svc}
+}
+
+// Register mounts the legal hold admin routes; callers are expected to wrap mux with admin auth
+func (h *AdminHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/legal-holds", h.List)
+	mux.HandleFunc("POST /admin/legal-holds", h.Place)
+	mux.HandleFunc("POST /admin/legal-holds/{id}/release", h.Release)
+}
+
+// List returns legal holds newest first; ?status=all includes released ones
+func (h *AdminHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	var activeOnly bool
+	switch r.URL.Query().Get("status") {
+	case "", "active":
+		activeOnly = true
+	case "all":
+	default:
+		httpx.Error(w, http.StatusBadRequest, "status must be active or all")
+		return
+	}
+
+	holds, err := h.service.List(r.Context(), activeOnly, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"legal_holds": holds})
+}
+
+// Place puts a user, post, comment or message under a legal hold
+func (h *AdminHandler) Place(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		TargetType string `json:"target_type"`
+		TargetID   int64  `json:"target_id"`
+		Reason     string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	hold, err := h.service.Place(r.Context(), adminID, body.TargetType, body.TargetID, body.Reason)
+	if err != nil {
+		writeLegalHoldError(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, hold)
+}
+
+// Release ends a legal hold
+func (h *AdminHandler) Release(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid legal hold id")
+		return
+	}
+
+	hold, err := h.service.Release(r.Context(), adminID, id)
+	if err != nil {
+		writeLegalHoldError(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, hold)
+}
+
+func writeLegalHoldError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTarget), errors.Is(err, service.ErrReasonRequired):
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+	default:
+		httpx.Fail(w, err)
+	}
+}
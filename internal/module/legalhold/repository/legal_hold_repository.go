@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+type LegalHoldRepository interface {
+	Create(ctx context.Context, hold *model.LegalHold) error
+	GetByID(ctx context.Context, id int64) (*model.LegalHold, error)
+	List(ctx context.Context, activeOnly bool, limit, offset int) ([]*model.LegalHold, error)
+	Release(ctx context.Context, id, releasedBy int64, now time.Time) (bool, error)
+	TargetExists(ctx context.Context, targetType string, targetID int64) (bool, error)
+}
+
+func NewLegalHoldRepository(db *gorm.DB) LegalHoldRepository {
+	return &legalHoldRepository{db: db}
+}
+
+type legalHoldRepository struct {
+	db *gorm.DB
+}
+
+func (r *legalHoldRepository) Create(ctx context.Context, hold *model.LegalHold) error {
+	return apperr.Translate(r.db.WithContext(ctx).Create(hold).Error, "legal hold")
+}
+
+func (r *legalHoldRepository) GetByID(ctx context.Context, id int64) (*model.LegalHold, error) {
+	var hold model.LegalHold
+	if err := r.db.WithContext(ctx).First(&hold, id).Error; err != nil {
+		return nil, apperr.Translate(err, "legal hold")
+	}
+	return &hold, nil
+}
+
+// List returns holds newest first, only the active ones with activeOnly
+func (r *legalHoldRepository) List(ctx context.Context, activeOnly bool, limit, offset int) ([]*model.LegalHold, error) {
+	query := r.db.WithContext(ctx)
+	if activeOnly {
+		query = query.Where("released_at IS NULL")
+	}
+	var holds []*model.LegalHold
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&holds).Error; err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+// Release ends an active hold; it reports false if there is no such hold or
+// it was already released
+func (r *legalHoldRepository) Release(ctx context.Context, id, releasedBy int64, now time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.LegalHold{}).
+		Where("id = ? AND released_at IS NULL", id).
+		UpdateColumns(map[string]any{"released_at": now, "released_by_id": releasedBy})
+	return res.RowsAffected == 1, res.Error
+}
+
+// TargetExists reports whether the record a hold would cover exists, deleted
+// or not; holding deleted records is what keeps them from being purged
+func (r *legalHoldRepository) TargetExists(ctx context.Context, targetType string, targetID int64) (bool, error) {
+	var target any
+	switch targetType {
+	case model.LegalHoldUser:
+		target = &model.User{}
+	case model.LegalHoldPost:
+		target = &model.Post{}
+	case model.LegalHoldComment:
+		target = &model.Comment{}
+	case model.LegalHoldMessage:
+		target = &model.Message{}
+	default:
+		return false, nil
+	}
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(target).Where("id = ?", targetID).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/message/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type MessageHandler struct {
+	service service.MessageService
+}
+
+func NewMessageHandler(svc service.MessageService) *MessageHandler {
+	return &MessageHandler{service: svc}
+}
+
+// Register mounts the messaging routes; they expect an authenticated user in the request context
+func (h *MessageHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /messages", h.Send)
+	mux.HandleFunc("GET /me/message-requests", h.Requests)
+	mux.HandleFunc("POST /me/message-requests/{userID}/accept", h.Accept)
+	mux.HandleFunc("DELETE /me/message-requests/{userID}", h.Decline)
+	mux.HandleFunc("PUT /me/messages/settings", h.UpdateSettings)
+}
+
+// Send delivers a direct message, or files it as a message request when the
+// receiver does not follow the caller
+func (h *MessageHandler) Send(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		ReceiverID int64  `json:"receiver_id"`
+		Content    string `json:"content"`
+		MediaURL   string `json:"media_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if body.ReceiverID <= 0 || body.ReceiverID == userID {
+		httpx.Error(w, http.StatusBadRequest, "invalid receiver id")
+		return
+	}
+	body.Content = strings.TrimSpace(body.Content)
+	if body.Content == "" || utf8.RuneCountInString(body.Content) > service.MaxMessageLength {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("content must be 1 to %d characters", service.MaxMessageLength))
+		return
+	}
+
+	message := &model.Message{SenderID: userID, ReceiverID: body.ReceiverID, Content: body.Content, MediaURL: body.MediaURL}
+	if err := h.service.Send(r.Context(), message); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, message)
+}
+
+// Requests returns the messages from strangers waiting for the caller to accept them
+func (h *MessageHandler) Requests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	messages, err := h.service.Requests(r.Context(), userID, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"requests": messages})
+}
+
+// Accept opens a conversation with the sender of message requests
+func (h *MessageHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	senderID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.service.AcceptRequest(r.Context(), userID, senderID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Decline deletes the message requests of a sender
+func (h *MessageHandler) Decline(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	senderID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.service.DeclineRequest(r.Context(), userID, senderID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateSettings changes who can start a conversation with the caller
+func (h *MessageHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		DMPolicy *types.DMPolicy `json:"dm_policy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.DMPolicy == nil || *body.DMPolicy >= types.DMPolicyUnknown {
+		httpx.Error(w, http.StatusBadRequest, "dm_policy must be everyone, following or nobody")
+		return
+	}
+
+	if err := h.service.SetPolicy(r.Context(), userID, *body.DMPolicy); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"dm_policy": body.DMPolicy})
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"gorm.io/gorm"
+)
+
+type MessageRepository interface {
+	Create(ctx context.Context, message *model.Message) error
+	CreateBatch(ctx context.Context, messages []*model.Message, batchSize int) error
+	HasConversation(ctx context.Context, userID, otherID int64) (bool, error)
+	ListRequests(ctx context.Context, receiverID int64, limit, offset int) ([]*model.Message, error)
+	AcceptRequests(ctx context.Context, receiverID, senderID int64) (int64, error)
+	DeleteRequests(ctx context.Context, receiverID, senderID int64) (int64, error)
+}
+
+func NewMessageRepository(db *gorm.DB) MessageRepository {
+	return &messageRepository{db: db}
+}
+
+type messageRepository struct {
+	db *gorm.DB
+}
+
+func (r *messageRepository) Create(ctx context.Context, message *model.Message) error {
+	return apperr.Translate(r.db.WithContext(ctx).Create(message).Error, "message")
+}
+
+// CreateBatch inserts messages batchSize rows per statement (zero for the configured default)
+func (r *messageRepository) CreateBatch(ctx context.Context, messages []*model.Message, batchSize int) error {
+	return apperr.Translate(pkgdb.CreateInBatches(ctx, r.db, messages, batchSize), "message")
+}
+
+// HasConversation reports whether the two users have exchanged a message
+// outside of message requests, in either direction
+func (r *messageRepository) HasConversation(ctx context.Context, userID, otherID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Message{}).
+		Where("((sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)) AND is_request = ? AND deleted_at IS NULL",
+			userID, otherID, otherID, userID, false).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListRequests returns the message requests sent to the receiver, newest first, with their senders
+func (r *messageRepository) ListRequests(ctx context.Context, receiverID int64, limit, offset int) ([]*model.Message, error) {
+	var messages []*model.Message
+	err := r.db.WithContext(ctx).
+		Preload("Sender").
+		Where("receiver_id = ? AND is_request = ? AND deleted_at IS NULL", receiverID, true).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// AcceptRequests moves the sender's message requests into the conversation
+// and returns how many there were
+func (r *messageRepository) AcceptRequests(ctx context.Context, receiverID, senderID int64) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.Message{}).
+		Where("receiver_id = ? AND sender_id = ? AND is_request = ? AND deleted_at IS NULL", receiverID, senderID, true).
+		Update("is_request", false)
+	return res.RowsAffected, res.Error
+}
+
+// DeleteRequests removes the sender's message requests and returns how many there were
+func (r *messageRepository) DeleteRequests(ctx context.Context, receiverID, senderID int64) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("receiver_id = ? AND sender_id = ? AND is_request = ?", receiverID, senderID, true).
+		Delete(&model.Message{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	followrepo "github.com/ilhamosaurus/sns-platform/internal/module/follow/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/module/message/repository"
+	restrictionrepo "github.com/ilhamosaurus/sns-platform/internal/module/restriction/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/quota"
+	"github.com/ilhamosaurus/sns-platform/pkg/richtext"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// MaxMessageLength is the most characters a message may hold
+const MaxMessageLength = 5000
+
+// ErrMessagesClosed is returned when the receiver's DM policy does not let
+// the sender start a conversation
+var ErrMessagesClosed = apperr.Forbidden("this user does not accept messages from you")
+
+type MessageService interface {
+	Send(ctx context.Context, message *model.Message) error
+	Requests(ctx context.Context, userID int64, limit, offset int) ([]*model.Message, error)
+	AcceptRequest(ctx context.Context, userID, senderID int64) error
+	DeclineRequest(ctx context.Context, userID, senderID int64) error
+	SetPolicy(ctx context.Context, userID int64, policy types.DMPolicy) error
+}
+
+type messageService struct {
+	repo         repository.MessageRepository
+	userRepo     userrepo.UserRepository
+	followRepo   followrepo.FollowRepository
+	restrictions restrictionrepo.RestrictionRepository
+	quota        *quota.Quota
+	audit        auditsvc.AuditService
+	bus          eventbus.Bus
+}
+
+// NewMessageService creates the messaging service; q may be nil to leave
+// messages to non-followers unlimited
+func NewMessageService(repo repository.MessageRepository, userRepo userrepo.UserRepository, followRepo followrepo.FollowRepository, restrictions restrictionrepo.RestrictionRepository, q *quota.Quota, audit auditsvc.AuditService, bus eventbus.Bus) MessageService {
+	return &messageService{repo: repo, userRepo: userRepo, followRepo: followRepo, restrictions: restrictions, quota: q, audit: audit, bus: bus}
+}
+
+// Send stores a message once the receiver's DM policy allows it. Users who
+// have already exchanged a message keep talking whatever the policy; anyone
+// else needs to be followed by the receiver, except under DMPolicyEveryone
+// where a stranger's message becomes a message request. Messages from users
+// the receiver restricted are always message requests.
+func (s *messageService) Send(ctx context.Context, message *model.Message) error {
+	receiver, err := s.userRepo.GetByID(ctx, message.ReceiverID)
+	if err != nil {
+		return err
+	}
+	followed, err := s.followRepo.IsFollowing(ctx, receiver.ID, message.SenderID)
+	if err != nil {
+		return fmt.Errorf("failed to check follow: %w", err)
+	}
+	open, err := s.repo.HasConversation(ctx, message.SenderID, receiver.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check conversation: %w", err)
+	}
+
+	restricted, err := s.restrictions.IsRestricted(ctx, receiver.ID, message.SenderID)
+	if err != nil {
+		return fmt.Errorf("failed to check restriction: %w", err)
+	}
+
+	message.IsRequest = restricted
+	if !open {
+		switch receiver.DMPolicy {
+		case types.DMPolicyNobody:
+			return ErrMessagesClosed
+		case types.DMPolicyFollowing:
+			if !followed {
+				return ErrMessagesClosed
+			}
+		default:
+			message.IsRequest = message.IsRequest || !followed
+		}
+	}
+
+	if !followed {
+		if _, err := s.quota.Consume(ctx, message.SenderID, quota.ActionDMToNonFollower); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.Create(ctx, message); err != nil {
+		return err
+	}
+	message.ContentHTML = richtext.Render(message.Content)
+
+	if err := s.bus.Publish(ctx, event.MessageSent{MessageID: message.ID, SenderID: message.SenderID, ReceiverID: message.ReceiverID}); err != nil {
+		slog.WarnContext(ctx, "failed to publish message", slog.Int64("message_id", message.ID), slog.Any("error", err))
+	}
+	return nil
+}
+
+// Requests returns the message requests waiting for the user, newest first
+func (s *messageService) Requests(ctx context.Context, userID int64, limit, offset int) ([]*model.Message, error) {
+	messages, err := s.repo.ListRequests(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for _, message := range messages {
+		message.ContentHTML = richtext.Render(message.Content)
+	}
+	return messages, nil
+}
+
+// AcceptRequest opens a conversation with the sender of message requests;
+// their held messages join it
+func (s *messageService) AcceptRequest(ctx context.Context, userID, senderID int64) error {
+	n, err := s.repo.AcceptRequests(ctx, userID, senderID)
+	if err != nil {
+		return fmt.Errorf("failed to accept message requests: %w", err)
+	}
+	if n == 0 {
+		return apperr.NotFound("message request not found")
+	}
+	return nil
+}
+
+// DeclineRequest deletes a sender's message requests; they may send a new one
+func (s *messageService) DeclineRequest(ctx context.Context, userID, senderID int64) error {
+	n, err := s.repo.DeleteRequests(ctx, userID, senderID)
+	if err != nil {
+		return fmt.Errorf("failed to decline message requests: %w", err)
+	}
+	if n == 0 {
+		return apperr.NotFound("message request not found")
+	}
+	return nil
+}
+
+// SetPolicy changes who can start a conversation with the user
+func (s *messageService) SetPolicy(ctx context.Context, userID int64, policy types.DMPolicy) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DMPolicy == policy {
+		return nil
+	}
+	if err := s.userRepo.Update(ctx, userID, map[string]any{"dm_policy": policy}); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, &model.AuditLog{
+		Action:     model.AuditPrivacyChange,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userID,
+		Before:     map[string]any{"dm_policy": user.DMPolicy.String()},
+		After:      map[string]any{"dm_policy": policy.String()},
+	})
+	return nil
+}
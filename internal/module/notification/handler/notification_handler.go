@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/module/notification/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type NotificationHandler struct {
+	service service.NotificationService
+}
+
+func NewNotificationHandler(svc service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: svc}
+}
+
+// Register mounts the notification routes; they expect an authenticated user in the request context
+func (h *NotificationHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/notifications", h.List)
+	mux.HandleFunc("GET /me/notifications/unread-count", h.UnreadCount)
+	mux.HandleFunc("GET /me/notifications/quiet-hours", h.QuietHours)
+	mux.HandleFunc("PUT /me/notifications/quiet-hours", h.SetQuietHours)
+}
+
+// List returns the caller's notifications in their language, newest first
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 200)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	notifications, err := h.service.List(r.Context(), userID, limit, offset)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"notifications": notifications})
+}
+
+// UnreadCount returns how many of the caller's notifications are unread,
+// for badges
+func (h *NotificationHandler) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	count, err := h.service.UnreadCount(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"count": count})
+}
+
+// QuietHours returns the caller's do-not-disturb settings
+func (h *NotificationHandler) QuietHours(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	quiet, err := h.service.QuietHours(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, quiet)
+}
+
+// SetQuietHours replaces the caller's do-not-disturb settings; pushes and
+// emails that are not urgent wait until the window ends
+func (h *NotificationHandler) SetQuietHours(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body dto.QuietHours
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := service.CheckQuietHours(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.SetQuietHours(r.Context(), userID, body); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, body)
+}
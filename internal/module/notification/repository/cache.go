@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/cache"
+	"github.com/ilhamosaurus/sns-platform/pkg/metrics"
+)
+
+// Option configures a notification repository
+type Option func(*notificationRepository)
+
+// WithCache keeps each user's unread count in store for ttl. Storing and
+// reading notifications drops it; restricting or deactivating an actor
+// shows once it expires.
+func WithCache(store cache.Store, ttl time.Duration) Option {
+	return func(r *notificationRepository) {
+		r.cache, r.cacheTTL = store, ttl
+	}
+}
+
+func unreadKey(userID int64) string {
+	return "notifications:unread:" + strconv.FormatInt(userID, 10)
+}
+
+// cachedCountUnread serves userID's unread count from the cache, filling it
+// on a miss. A broken cache falls back to counting.
+func (r *notificationRepository) cachedCountUnread(ctx context.Context, userID int64) (int64, error) {
+	key := unreadKey(userID)
+	data, ok, err := r.cache.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to read unread count cache", slog.Int64("user_id", userID), slog.Any("error", err))
+	}
+	if ok {
+		if count, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+			metrics.CacheLookup("unread_notifications", true)
+			return count, nil
+		}
+	}
+	metrics.CacheLookup("unread_notifications", false)
+
+	count, err := r.countUnread(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.cache.Set(ctx, key, []byte(strconv.FormatInt(count, 10)), r.cacheTTL); err != nil {
+		slog.WarnContext(ctx, "failed to fill unread count cache", slog.Int64("user_id", userID), slog.Any("error", err))
+	}
+	return count, nil
+}
+
+// forget drops the cached unread counts of userIDs
+func (r *notificationRepository) forget(ctx context.Context, userIDs ...int64) {
+	if r.cache == nil || len(userIDs) == 0 {
+		return
+	}
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = unreadKey(userID)
+	}
+	if err := r.cache.Delete(ctx, keys...); err != nil {
+		slog.WarnContext(ctx, "failed to invalidate unread count cache", slog.Int("users", len(userIDs)), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/cache"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *model.Notification) error
+	CreateBatch(ctx context.Context, notifications []*model.Notification, batchSize int) error
+	ListByUser(ctx context.Context, userID int64, limit, offset int) ([]*model.Notification, error)
+	CountUnread(ctx context.Context, userID int64) (int64, error)
+	MarkAsRead(ctx context.Context, userID int64, ids []int64) error
+	DeleteReadBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	ExistingTargets(ctx context.Context, targetType types.NotificationTarget, ids []int64) (map[int64]bool, error)
+}
+
+func NewNotificationRepository(db *gorm.DB, opts ...Option) NotificationRepository {
+	r := &notificationRepository{db: db}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+type notificationRepository struct {
+	db       *gorm.DB
+	cache    cache.Store // Nil unless WithCache is given
+	cacheTTL time.Duration
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	if err := r.db.WithContext(ctx).Create(notification).Error; err != nil {
+		return apperr.Translate(err, "notification")
+	}
+	r.forget(ctx, notification.UserID)
+	return nil
+}
+
+// CreateBatch inserts notifications batchSize rows per statement (zero for the configured default)
+func (r *notificationRepository) CreateBatch(ctx context.Context, notifications []*model.Notification, batchSize int) error {
+	if err := pkgdb.CreateInBatches(ctx, r.db, notifications, batchSize); err != nil {
+		return apperr.Translate(err, "notification")
+	}
+	userIDs := make([]int64, len(notifications))
+	for i, n := range notifications {
+		userIDs[i] = n.UserID
+	}
+	slices.Sort(userIDs)
+	r.forget(ctx, slices.Compact(userIDs)...)
+	return nil
+}
+
+func (r *notificationRepository) ListByUser(ctx context.Context, userID int64, limit, offset int) ([]*model.Notification, error) {
+	var notifications []*model.Notification
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Scopes(fromVisibleActors).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, userID int64) (int64, error) {
+	if r.cache != nil {
+		return r.cachedCountUnread(ctx, userID)
+	}
+	return r.countUnread(ctx, userID)
+}
+
+func (r *notificationRepository) countUnread(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND is_read = ? AND deleted_at IS NULL", userID, false).
+		Scopes(fromVisibleActors).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepository) MarkAsRead(ctx context.Context, userID int64, ids []int64) error {
+	db := r.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? AND deleted_at IS NULL", userID)
+	if len(ids) > 0 {
+		db = db.Where("id IN ?", ids)
+	}
+	if err := db.Update("is_read", true).Error; err != nil {
+		return err
+	}
+	r.forget(ctx, userID)
+	return nil
+}
+
+// DeleteReadBefore permanently removes read notifications created before
+// cutoff, except those of users under a legal hold
+func (r *notificationRepository) DeleteReadBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Unscoped().
+		Where("is_read = ? AND created_at < ? AND user_id NOT IN ("+model.HeldIDs(model.LegalHoldUser)+")", true, cutoff).
+		Delete(&model.Notification{})
+	return res.RowsAffected, res.Error
+}
+
+// targetTables maps the notification targets that are rows to their table
+var targetTables = map[types.NotificationTarget]string{
+	types.NotificationTargetPost:    "posts",
+	types.NotificationTargetComment: "comments",
+	types.NotificationTargetUser:    "users",
+}
+
+// ExistingTargets reports which of ids still exist, and are not deleted,
+// as targets of targetType. Targets that are not rows always exist.
+func (r *notificationRepository) ExistingTargets(ctx context.Context, targetType types.NotificationTarget, ids []int64) (map[int64]bool, error) {
+	existing := make(map[int64]bool, len(ids))
+	table, ok := targetTables[targetType]
+	if !ok {
+		for _, id := range ids {
+			existing[id] = true
+		}
+		return existing, nil
+	}
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	var found []int64
+	if err := r.db.WithContext(ctx).Table(table).Where("id IN ? AND deleted_at IS NULL", ids).Pluck("id", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		existing[id] = true
+	}
+	return existing, nil
+}
+
+// fromVisibleActors drops notifications whose actor the recipient has
+// restricted, even those sent before the restriction, and those whose actor
+// deactivated their account. Deleted actors stay, to be named as such.
+func fromVisibleActors(db *gorm.DB) *gorm.DB {
+	return db.Where(`NOT EXISTS (
+		SELECT 1 FROM restrictions
+		WHERE restrictions.user_id = notifications.user_id
+			AND restrictions.restricted_id = notifications.actor_id
+			AND restrictions.deleted_at IS NULL)`).
+		Scopes(userrepo.ActiveAuthors("notifications.actor_id"))
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/hydrator"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/notification/repository"
+	restrictionrepo "github.com/ilhamosaurus/sns-platform/internal/module/restriction/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/i18n"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// ExcerptLength is the most characters of a comment or post quoted in a notification
+const ExcerptLength = 80
+
+type NotificationService interface {
+	Notify(ctx context.Context, notification *model.Notification) error
+	List(ctx context.Context, userID int64, limit, offset int) ([]*model.Notification, error)
+	UnreadCount(ctx context.Context, userID int64) (int64, error)
+	QuietHours(ctx context.Context, userID int64) (*dto.QuietHours, error)
+	SetQuietHours(ctx context.Context, userID int64, q dto.QuietHours) error
+}
+
+type notificationService struct {
+	repo         repository.NotificationRepository
+	userRepo     userrepo.UserRepository
+	restrictions restrictionrepo.RestrictionRepository
+	hydrator     *hydrator.Hydrator
+	catalog      *i18n.Catalog
+}
+
+func NewNotificationService(repo repository.NotificationRepository, userRepo userrepo.UserRepository, restrictions restrictionrepo.RestrictionRepository, hydrator *hydrator.Hydrator, catalog *i18n.Catalog) NotificationService {
+	return &notificationService{repo: repo, userRepo: userRepo, restrictions: restrictions, hydrator: hydrator, catalog: catalog}
+}
+
+// Notify stores a notification for its user; users are not notified of
+// their own actions, nor of those of users they restricted
+func (s *notificationService) Notify(ctx context.Context, notification *model.Notification) error {
+	if notification.UserID == notification.ActorID {
+		return nil
+	}
+	restricted, err := s.restrictions.IsRestricted(ctx, notification.UserID, notification.ActorID)
+	if err != nil {
+		return fmt.Errorf("failed to check restriction: %w", err)
+	}
+	if restricted {
+		return nil
+	}
+	if err := s.repo.Create(ctx, notification); err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// List returns the user's notifications, newest first, with their text
+// rendered in the user's language. A deleted actor is named as such, and a
+// notification about something since deleted only says that it was.
+func (s *notificationService) List(ctx context.Context, userID int64, limit, offset int) ([]*model.Notification, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recipient: %w", err)
+	}
+	notifications, err := s.repo.ListByUser(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	actorIDs := make([]int64, len(notifications))
+	for i, n := range notifications {
+		actorIDs[i] = n.ActorID
+	}
+	actors, err := s.hydrator.Users(ctx, actorIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.markDeletedTargets(ctx, notifications); err != nil {
+		return nil, err
+	}
+
+	for _, n := range notifications {
+		n.Actor = actors[n.ActorID]
+		s.render(n, user.Language)
+	}
+	return notifications, nil
+}
+
+// UnreadCount returns how many of the user's notifications are unread
+func (s *notificationService) UnreadCount(ctx context.Context, userID int64) (int64, error) {
+	count, err := s.repo.CountUnread(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// markDeletedTargets sets TargetDeleted on notifications whose target is
+// gone, whether soft-deleted or purged since
+func (s *notificationService) markDeletedTargets(ctx context.Context, notifications []*model.Notification) error {
+	byType := make(map[types.NotificationTarget][]int64)
+	for _, n := range notifications {
+		if n.TargetID != 0 {
+			byType[n.TargetType] = append(byType[n.TargetType], n.TargetID)
+		}
+	}
+	existing := make(map[types.NotificationTarget]map[int64]bool, len(byType))
+	for targetType, ids := range byType {
+		found, err := s.repo.ExistingTargets(ctx, targetType, ids)
+		if err != nil {
+			return fmt.Errorf("failed to look up notification targets: %w", err)
+		}
+		existing[targetType] = found
+	}
+	for _, n := range notifications {
+		n.TargetDeleted = n.TargetID != 0 && !existing[n.TargetType][n.TargetID]
+	}
+	return nil
+}
+
+// render fills Message; the actor's display name and the target type are
+// looked up at read time so renamed users show their current name
+func (s *notificationService) render(n *model.Notification, lang string) {
+	params := make(map[string]string, len(n.Params)+2)
+	switch {
+	case n.Actor != nil:
+		params["actor"] = n.Actor.FullName
+		if params["actor"] == "" {
+			params["actor"] = n.Actor.Username
+		}
+	case n.ActorID != 0:
+		params["actor"] = s.catalog.Render(lang, model.NotificationKeyDeletedActor, nil)
+	}
+	params["target"] = n.TargetType.String()
+	if n.TargetDeleted {
+		// The stored params may quote the deleted content
+		n.Message = s.catalog.Render(lang, model.NotificationKeyDeletedContent, params)
+		return
+	}
+	for k, v := range n.Params {
+		params[k] = v
+	}
+	n.Message = s.catalog.Render(lang, n.TemplateKey, params)
+}
+
+// Excerpt shortens text to ExcerptLength characters on a single line for
+// quoting in a notification
+func Excerpt(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= ExcerptLength {
+		return text
+	}
+	return string([]rune(text)[:ExcerptLength-1]) + "…"
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+)
+
+// quietHoursLayout is the clock format of quiet hours boundaries
+const quietHoursLayout = "15:04"
+
+// QuietHours returns the user's do-not-disturb settings
+func (s *notificationService) QuietHours(ctx context.Context, userID int64) (*dto.QuietHours, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.QuietHours{
+		Enabled:  user.QuietHoursEnabled,
+		Start:    user.QuietHoursStart,
+		End:      user.QuietHoursEnd,
+		TimeZone: user.TimeZone,
+	}, nil
+}
+
+// CheckQuietHours reports what is wrong with quiet hours settings; an empty
+// time zone is set to UTC
+func CheckQuietHours(q *dto.QuietHours) error {
+	if q.TimeZone == "" {
+		q.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(q.TimeZone); err != nil {
+		return fmt.Errorf("unknown time zone %q", q.TimeZone)
+	}
+	for _, clock := range []string{q.Start, q.End} {
+		if _, err := time.Parse(quietHoursLayout, clock); err != nil {
+			return errors.New("start and end must be times like 22:00")
+		}
+	}
+	if q.Start == q.End {
+		return errors.New("start and end must differ")
+	}
+	return nil
+}
+
+// SetQuietHours replaces the user's do-not-disturb settings, which must have
+// passed CheckQuietHours
+func (s *notificationService) SetQuietHours(ctx context.Context, userID int64, q dto.QuietHours) error {
+	return s.userRepo.Update(ctx, userID, map[string]any{
+		"quiet_hours_enabled": q.Enabled,
+		"quiet_hours_start":   q.Start,
+		"quiet_hours_end":     q.End,
+		"time_zone":           q.TimeZone,
+	})
+}
+
+// QuietUntil returns the end of the user's quiet hours if now falls inside
+// them. Boundaries are wall-clock times in the user's time zone, so the
+// window keeps its local hours across daylight saving changes.
+func QuietUntil(user *model.User, now time.Time) (time.Time, bool) {
+	if !user.QuietHoursEnabled {
+		return time.Time{}, false
+	}
+	start, err := time.Parse(quietHoursLayout, user.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := time.Parse(quietHoursLayout, user.QuietHoursEnd)
+	if err != nil {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(user.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	y, m, d := local.Date()
+	at := func(clock time.Time, days int) time.Time {
+		return time.Date(y, m, d+days, clock.Hour(), clock.Minute(), 0, 0, loc)
+	}
+	startToday, endToday := at(start, 0), at(end, 0)
+	switch {
+	case startToday.Before(endToday):
+		if !local.Before(startToday) && local.Before(endToday) {
+			return endToday, true
+		}
+	case endToday.Before(startToday):
+		// The window runs past midnight
+		if local.Before(endToday) {
+			return endToday, true
+		}
+		if !local.Before(startToday) {
+			return at(end, 1), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/module/oauth/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/oauth"
+)
+
+type OAuthHandler struct {
+	service service.OAuthService
+}
+
+func NewOAuthHandler(svc service.OAuthService) *OAuthHandler {
+	return &OAuthHandler{service: svc}
+}
+
+// Register mounts the OAuth provider routes. The token and revocation
+// endpoints authenticate the app itself; the rest expect an authenticated
+// user in the request context.
+func (h *OAuthHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /oauth/apps", h.RegisterApp)
+	mux.HandleFunc("GET /oauth/apps", h.ListApps)
+	mux.HandleFunc("DELETE /oauth/apps/{id}", h.DeleteApp)
+	mux.HandleFunc("GET /oauth/authorize", h.Consent)
+	mux.HandleFunc("POST /oauth/authorize", h.Authorize)
+	mux.HandleFunc("POST /oauth/token", h.Token)
+	mux.HandleFunc("POST /oauth/revoke", h.Revoke)
+	mux.HandleFunc("GET /me/authorized-apps", h.AuthorizedApps)
+	mux.HandleFunc("DELETE /me/authorized-apps/{appID}", h.RevokeApp)
+}
+
+// RegisterApp registers a third-party app owned by the caller
+func (h *OAuthHandler) RegisterApp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req dto.OAuthAppRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := checkApp(req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	app, err := h.service.RegisterApp(r.Context(), userID, req)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, app)
+}
+
+// checkApp reports what is wrong with an app registration
+func checkApp(req dto.OAuthAppRequest) error {
+	if name := strings.TrimSpace(req.Name); name == "" || len(name) > service.MaxAppNameLength {
+		return fmt.Errorf("name must be 1 to %d characters", service.MaxAppNameLength)
+	}
+	if len(req.RedirectURIs) == 0 || len(req.RedirectURIs) > service.MaxRedirectURIs {
+		return fmt.Errorf("between 1 and %d redirect_uris are required", service.MaxRedirectURIs)
+	}
+	for _, uri := range req.RedirectURIs {
+		u, err := url.Parse(uri)
+		if err != nil || len(uri) > service.MaxRedirectLength || u.Scheme == "" || u.Fragment != "" || strings.ContainsAny(uri, " \t\n") {
+			return fmt.Errorf("redirect_uri %q must be an absolute URI without a fragment", uri)
+		}
+		if u.Scheme == "http" && u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1" {
+			return fmt.Errorf("redirect_uri %q must use https unless it is on localhost", uri)
+		}
+	}
+	scopes, err := oauth.ParseScopes(strings.Join(req.Scopes, " "))
+	if err != nil {
+		return err
+	}
+	if len(scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	return nil
+}
+
+// ListApps returns the apps the caller registered
+func (h *OAuthHandler) ListApps(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	apps, err := h.service.ListApps(r.Context(), userID)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"apps": apps})
+}
+
+// DeleteApp removes one of the caller's apps and revokes its tokens
+func (h *OAuthHandler) DeleteApp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	appID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid app id")
+		return
+	}
+
+	if err := h.service.DeleteApp(r.Context(), userID, appID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Consent describes an authorization request so the client can ask the
+// caller to approve it
+func (h *OAuthHandler) Consent(w http.ResponseWriter, r *http.Request) {
+	if _, ok := logger.UserID(r.Context()); !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	consent, err := h.service.Consent(r.Context(), authorizeRequest(r.URL.Query()))
+	if err != nil {
+		oauthError(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, consent)
+}
+
+// Authorize approves an authorization request on the caller's behalf and
+// returns the redirect URI, carrying the code, to send the browser to
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	redirect, err := h.service.Authorize(r.Context(), userID, authorizeRequest(r.Form))
+	if err != nil {
+		oauthError(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]string{"redirect_uri": redirect})
+}
+
+func authorizeRequest(values url.Values) service.AuthorizeRequest {
+	return service.AuthorizeRequest{
+		ResponseType:        values.Get("response_type"),
+		ClientID:            values.Get("client_id"),
+		RedirectURI:         values.Get("redirect_uri"),
+		Scope:               values.Get("scope"),
+		State:               values.Get("state"),
+		CodeChallenge:       values.Get("code_challenge"),
+		CodeChallengeMethod: values.Get("code_challenge_method"),
+	}
+}
+
+// Token exchanges an authorization code or refresh token for tokens. Apps
+// authenticate with HTTP Basic or client_id and client_secret form fields.
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		oauthError(w, oauth.NewError(oauth.ErrCodeInvalidRequest, "invalid request body"))
+		return
+	}
+	clientID, clientSecret := clientCredentials(r)
+
+	token, err := h.service.Token(r.Context(), service.TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+	})
+	if err != nil {
+		oauthError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	httpx.JSON(w, http.StatusOK, token)
+}
+
+// Revoke invalidates an access or refresh token held by the calling app
+func (h *OAuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		oauthError(w, oauth.NewError(oauth.ErrCodeInvalidRequest, "invalid request body"))
+		return
+	}
+	clientID, clientSecret := clientCredentials(r)
+
+	if err := h.service.Revoke(r.Context(), clientID, clientSecret, r.PostForm.Get("token")); err != nil {
+		oauthError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// clientCredentials reads the app's credentials from HTTP Basic or the form
+func clientCredentials(r *http.Request) (clientID, clientSecret string) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		return id, secret
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+// oauthError writes OAuth errors in the RFC 6749 format and anything else
+// like other handlers do
+func oauthError(w http.ResponseWriter, err error) {
+	var oauthErr *oauth.Error
+	if !errors.As(err, &oauthErr) {
+		httpx.Fail(w, err)
+		return
+	}
+	status := http.StatusBadRequest
+	if oauthErr.Code == oauth.ErrCodeInvalidClient {
+		status = http.StatusUnauthorized
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	httpx.JSON(w, status, oauthErr)
+}
+
+// AuthorizedApps lists the apps the caller has granted access to
+func (h *OAuthHandler) AuthorizedApps(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	apps, err := h.service.AuthorizedApps(r.Context(), userID)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"apps": apps})
+}
+
+// RevokeApp withdraws the caller's grant to an app
+func (h *OAuthHandler) RevokeApp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	appID, err := httpx.PathInt64(r, "appID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid app id")
+		return
+	}
+
+	if err := h.service.RevokeApp(r.Context(), userID, appID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+type OAuthRepository interface {
+	CreateApp(ctx context.Context, app *model.OAuthApp) error
+	GetAppByClientID(ctx context.Context, clientID string) (*model.OAuthApp, error)
+	ListApps(ctx context.Context, ownerID int64) ([]*model.OAuthApp, error)
+	DeleteApp(ctx context.Context, ownerID, appID int64) error
+
+	CreateCode(ctx context.Context, code *model.OAuthCode) error
+	TakeCode(ctx context.Context, appID int64, codeHash string) (*model.OAuthCode, error)
+
+	CreateToken(ctx context.Context, token *model.OAuthToken) error
+	GetToken(ctx context.Context, accessHash string, now time.Time) (*model.OAuthToken, error)
+	RotateToken(ctx context.Context, appID int64, refreshHash string, now time.Time, next *model.OAuthToken) (*model.OAuthToken, error)
+	RevokeToken(ctx context.Context, appID int64, tokenHash string) error
+
+	AuthorizedApps(ctx context.Context, userID int64) ([]dto.AuthorizedApp, error)
+	RevokeApp(ctx context.Context, userID, appID int64) (bool, error)
+}
+
+func NewOAuthRepository(db *gorm.DB) OAuthRepository {
+	return &oauthRepository{db: db}
+}
+
+type oauthRepository struct {
+	db *gorm.DB
+}
+
+func (r *oauthRepository) CreateApp(ctx context.Context, app *model.OAuthApp) error {
+	return apperr.Translate(r.db.WithContext(ctx).Create(app).Error, "app")
+}
+
+func (r *oauthRepository) GetAppByClientID(ctx context.Context, clientID string) (*model.OAuthApp, error) {
+	var app model.OAuthApp
+	if err := r.db.WithContext(ctx).Where("client_id = ? AND deleted_at IS NULL", clientID).First(&app).Error; err != nil {
+		return nil, apperr.Translate(err, "app")
+	}
+	return &app, nil
+}
+
+func (r *oauthRepository) ListApps(ctx context.Context, ownerID int64) ([]*model.OAuthApp, error) {
+	var apps []*model.OAuthApp
+	err := r.db.WithContext(ctx).
+		Where("owner_id = ? AND deleted_at IS NULL", ownerID).
+		Order("id ASC").
+		Find(&apps).Error
+	return apps, err
+}
+
+// DeleteApp removes one of the owner's apps along with every code and token
+// issued to it
+func (r *oauthRepository) DeleteApp(ctx context.Context, ownerID, appID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND owner_id = ?", appID, ownerID).Delete(&model.OAuthApp{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apperr.NotFound("app not found")
+		}
+		if err := tx.Unscoped().Where("app_id = ?", appID).Delete(&model.OAuthCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("app_id = ?", appID).Delete(&model.OAuthToken{}).Error
+	})
+}
+
+func (r *oauthRepository) CreateCode(ctx context.Context, code *model.OAuthCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+// TakeCode returns an app's authorization code and deletes it, so each code
+// is exchanged at most once
+func (r *oauthRepository) TakeCode(ctx context.Context, appID int64, codeHash string) (*model.OAuthCode, error) {
+	var code model.OAuthCode
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("app_id = ? AND code_hash = ?", appID, codeHash).First(&code).Error; err != nil {
+			return apperr.Translate(err, "authorization code")
+		}
+		res := tx.Unscoped().Delete(&code)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apperr.NotFound("authorization code not found")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *oauthRepository) CreateToken(ctx context.Context, token *model.OAuthToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetToken returns an unexpired access token of a live app whose user has
+// not deactivated their account
+func (r *oauthRepository) GetToken(ctx context.Context, accessHash string, now time.Time) (*model.OAuthToken, error) {
+	var token model.OAuthToken
+	err := r.db.WithContext(ctx).
+		Joins("INNER JOIN oauth_apps ON oauth_apps.id = oauth_tokens.app_id AND oauth_apps.deleted_at IS NULL").
+		Where("oauth_tokens.access_hash = ? AND oauth_tokens.expires_at > ? AND oauth_tokens.deleted_at IS NULL", accessHash, now).
+		Scopes(userrepo.ActiveAuthors("oauth_tokens.user_id")).
+		First(&token).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "access token")
+	}
+	return &token, nil
+}
+
+// RotateToken replaces an app's token, found by its unexpired refresh
+// token, with next; next takes over the user and scopes of the old token
+func (r *oauthRepository) RotateToken(ctx context.Context, appID int64, refreshHash string, now time.Time, next *model.OAuthToken) (*model.OAuthToken, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var old model.OAuthToken
+		err := tx.Where("app_id = ? AND refresh_hash = ? AND refresh_expires_at > ?", appID, refreshHash, now).
+			Scopes(userrepo.ActiveAuthors("oauth_tokens.user_id")).
+			First(&old).Error
+		if err != nil {
+			return apperr.Translate(err, "refresh token")
+		}
+		res := tx.Unscoped().Delete(&old)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apperr.NotFound("refresh token not found")
+		}
+		next.AppID, next.UserID, next.Scopes = old.AppID, old.UserID, old.Scopes
+		return tx.Create(next).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// RevokeToken deletes an app's token by either its access or refresh token
+func (r *oauthRepository) RevokeToken(ctx context.Context, appID int64, tokenHash string) error {
+	return r.db.WithContext(ctx).Unscoped().
+		Where("app_id = ? AND (access_hash = ? OR refresh_hash = ?)", appID, tokenHash, tokenHash).
+		Delete(&model.OAuthToken{}).Error
+}
+
+// AuthorizedApps lists the apps holding tokens for the user, with the scopes
+// of their most recent grant
+func (r *oauthRepository) AuthorizedApps(ctx context.Context, userID int64) ([]dto.AuthorizedApp, error) {
+	var tokens []*model.OAuthToken
+	err := r.db.WithContext(ctx).
+		Preload("App").
+		Joins("INNER JOIN oauth_apps ON oauth_apps.id = oauth_tokens.app_id AND oauth_apps.deleted_at IS NULL").
+		Where("oauth_tokens.user_id = ? AND oauth_tokens.deleted_at IS NULL", userID).
+		Order("oauth_tokens.id DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool)
+	apps := []dto.AuthorizedApp{}
+	for _, token := range tokens {
+		if seen[token.AppID] || token.App == nil {
+			continue
+		}
+		seen[token.AppID] = true
+		apps = append(apps, dto.AuthorizedApp{
+			AppID:        token.AppID,
+			Name:         token.App.Name,
+			Scopes:       token.Scopes,
+			AuthorizedAt: token.CreatedAt,
+		})
+	}
+	return apps, nil
+}
+
+// RevokeApp deletes every token and pending code the user gave an app and
+// reports whether there were any
+func (r *oauthRepository) RevokeApp(ctx context.Context, userID, appID int64) (bool, error) {
+	var revoked bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ? AND app_id = ?", userID, appID).Delete(&model.OAuthCode{}).Error; err != nil {
+			return err
+		}
+		res := tx.Unscoped().Where("user_id = ? AND app_id = ?", userID, appID).Delete(&model.OAuthToken{})
+		revoked = res.RowsAffected > 0
+		return res.Error
+	})
+	return revoked, err
+}
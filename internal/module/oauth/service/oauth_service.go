@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/oauth/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/oauth"
+)
+
+// Limits on app registration
+const (
+	MaxAppNameLength  = 100
+	MaxRedirectURIs   = 10
+	MaxRedirectLength = 2048
+	MaxAppsPerUser    = 25
+)
+
+// Config sets how long codes and tokens live
+type Config struct {
+	CodeTTL         time.Duration
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// AuthorizeRequest is an authorization code request (RFC 6749 section
+// 4.1.1) with its PKCE challenge (RFC 7636)
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// TokenRequest is a token endpoint request for the authorization_code or
+// refresh_token grant
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+}
+
+type OAuthService interface {
+	RegisterApp(ctx context.Context, ownerID int64, req dto.OAuthAppRequest) (*dto.OAuthAppResponse, error)
+	ListApps(ctx context.Context, ownerID int64) ([]*dto.OAuthAppResponse, error)
+	DeleteApp(ctx context.Context, ownerID, appID int64) error
+
+	Consent(ctx context.Context, req AuthorizeRequest) (*dto.OAuthConsent, error)
+	Authorize(ctx context.Context, userID int64, req AuthorizeRequest) (string, error)
+	Token(ctx context.Context, req TokenRequest) (*dto.OAuthTokenResponse, error)
+	Revoke(ctx context.Context, clientID, clientSecret, token string) error
+	Authenticate(ctx context.Context, accessToken string) (*oauth.Grant, error)
+
+	AuthorizedApps(ctx context.Context, userID int64) ([]dto.AuthorizedApp, error)
+	RevokeApp(ctx context.Context, userID, appID int64) error
+}
+
+type oauthService struct {
+	repo   repository.OAuthRepository
+	config Config
+}
+
+func NewOAuthService(repo repository.OAuthRepository, config Config) OAuthService {
+	if config.CodeTTL <= 0 {
+		config.CodeTTL = 10 * time.Minute
+	}
+	if config.AccessTokenTTL <= 0 {
+		config.AccessTokenTTL = time.Hour
+	}
+	if config.RefreshTokenTTL <= 0 {
+		config.RefreshTokenTTL = 30 * 24 * time.Hour
+	}
+	return &oauthService{repo: repo, config: config}
+}
+
+// RegisterApp creates an app owned by the user. The client secret of a
+// confidential app is only ever returned here.
+func (s *oauthService) RegisterApp(ctx context.Context, ownerID int64, req dto.OAuthAppRequest) (*dto.OAuthAppResponse, error) {
+	apps, err := s.repo.ListApps(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(apps) >= MaxAppsPerUser {
+		return nil, apperr.Conflict(fmt.Sprintf("at most %d apps can be registered", MaxAppsPerUser))
+	}
+	// Scopes were validated by the handler
+	scopes, _ := oauth.ParseScopes(strings.Join(req.Scopes, " "))
+
+	app := &model.OAuthApp{
+		OwnerID:      ownerID,
+		Name:         strings.TrimSpace(req.Name),
+		ClientID:     oauth.NewToken()[:32],
+		RedirectURIs: strings.Join(req.RedirectURIs, "\n"),
+		Scopes:       oauth.FormatScopes(scopes),
+	}
+	var secret string
+	if req.Confidential {
+		secret = oauth.NewToken()
+		app.SecretHash = oauth.HashToken(secret)
+	}
+	if err := s.repo.CreateApp(ctx, app); err != nil {
+		return nil, err
+	}
+
+	resp := dto.NewOAuthAppResponse(app)
+	resp.ClientSecret = secret
+	return resp, nil
+}
+
+func (s *oauthService) ListApps(ctx context.Context, ownerID int64) ([]*dto.OAuthAppResponse, error) {
+	apps, err := s.repo.ListApps(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]*dto.OAuthAppResponse, len(apps))
+	for i, app := range apps {
+		resp[i] = dto.NewOAuthAppResponse(app)
+	}
+	return resp, nil
+}
+
+// DeleteApp removes one of the user's apps, revoking every token it holds
+func (s *oauthService) DeleteApp(ctx context.Context, ownerID, appID int64) error {
+	return s.repo.DeleteApp(ctx, ownerID, appID)
+}
+
+// Consent checks an authorization request and describes it for the user
+func (s *oauthService) Consent(ctx context.Context, req AuthorizeRequest) (*dto.OAuthConsent, error) {
+	app, scopes, err := s.checkAuthorize(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	consent := &dto.OAuthConsent{ClientID: app.ClientID, AppName: app.Name, RedirectURI: req.RedirectURI}
+	for _, scope := range scopes {
+		consent.Scopes = append(consent.Scopes, string(scope))
+	}
+	return consent, nil
+}
+
+// Authorize records the user's approval of an authorization request and
+// returns the redirect URI carrying the authorization code
+func (s *oauthService) Authorize(ctx context.Context, userID int64, req AuthorizeRequest) (string, error) {
+	app, scopes, err := s.checkAuthorize(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	code := oauth.NewToken()
+	err = s.repo.CreateCode(ctx, &model.OAuthCode{
+		AppID:         app.ID,
+		UserID:        userID,
+		CodeHash:      oauth.HashToken(code),
+		RedirectURI:   req.RedirectURI,
+		Scopes:        oauth.FormatScopes(scopes),
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().UTC().Add(s.config.CodeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	redirect, _ := url.Parse(req.RedirectURI)
+	query := redirect.Query()
+	query.Set("code", code)
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	redirect.RawQuery = query.Encode()
+	return redirect.String(), nil
+}
+
+// checkAuthorize validates an authorization request against the app it
+// names. PKCE with S256 is required of every app.
+func (s *oauthService) checkAuthorize(ctx context.Context, req AuthorizeRequest) (*model.OAuthApp, []oauth.Scope, error) {
+	if req.ResponseType != "code" {
+		return nil, nil, oauth.NewError("unsupported_response_type", "response_type must be code")
+	}
+	app, err := s.repo.GetAppByClientID(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, nil, oauth.NewError(oauth.ErrCodeInvalidClient, "unknown client_id")
+		}
+		return nil, nil, err
+	}
+	if !registered(app, req.RedirectURI) {
+		return nil, nil, oauth.NewError(oauth.ErrCodeInvalidRequest, "redirect_uri is not registered for this app")
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return nil, nil, oauth.NewError(oauth.ErrCodeInvalidRequest, "a code_challenge with code_challenge_method S256 is required")
+	}
+
+	requested, err := oauth.ParseScopes(req.Scope)
+	if err != nil {
+		return nil, nil, oauth.NewError(oauth.ErrCodeInvalidScope, "%v", err)
+	}
+	allowed, _ := oauth.ParseScopes(app.Scopes)
+	if len(requested) == 0 {
+		requested = allowed
+	}
+	for _, scope := range requested {
+		if !(&oauth.Grant{Scopes: allowed}).Has(scope) {
+			return nil, nil, oauth.NewError(oauth.ErrCodeInvalidScope, "the app is not registered for the %s scope", scope)
+		}
+	}
+	return app, requested, nil
+}
+
+// registered reports whether uri exactly matches one of the app's redirect URIs
+func registered(app *model.OAuthApp, uri string) bool {
+	for _, registered := range app.RedirectURIList() {
+		if registered == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// Token runs the token endpoint for the authorization_code and
+// refresh_token grants
+func (s *oauthService) Token(ctx context.Context, req TokenRequest) (*dto.OAuthTokenResponse, error) {
+	app, err := s.client(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	access, refresh := oauth.NewToken(), oauth.NewToken()
+	token := &model.OAuthToken{
+		AccessHash:       oauth.HashToken(access),
+		RefreshHash:      oauth.HashToken(refresh),
+		ExpiresAt:        now.Add(s.config.AccessTokenTTL),
+		RefreshExpiresAt: now.Add(s.config.RefreshTokenTTL),
+	}
+
+	switch req.GrantType {
+	case "authorization_code":
+		if req.Code == "" || req.CodeVerifier == "" {
+			return nil, oauth.NewError(oauth.ErrCodeInvalidRequest, "code and code_verifier are required")
+		}
+		code, err := s.repo.TakeCode(ctx, app.ID, oauth.HashToken(req.Code))
+		if err != nil {
+			if errors.Is(err, apperr.ErrNotFound) {
+				return nil, oauth.NewError(oauth.ErrCodeInvalidGrant, "the authorization code is invalid or was already used")
+			}
+			return nil, err
+		}
+		switch {
+		case !code.ExpiresAt.After(now):
+			return nil, oauth.NewError(oauth.ErrCodeInvalidGrant, "the authorization code has expired")
+		case code.RedirectURI != req.RedirectURI:
+			return nil, oauth.NewError(oauth.ErrCodeInvalidGrant, "redirect_uri does not match the authorization request")
+		case !oauth.VerifyPKCE(req.CodeVerifier, code.CodeChallenge):
+			return nil, oauth.NewError(oauth.ErrCodeInvalidGrant, "code_verifier does not match the code challenge")
+		}
+		token.AppID, token.UserID, token.Scopes = app.ID, code.UserID, code.Scopes
+		if err := s.repo.CreateToken(ctx, token); err != nil {
+			return nil, err
+		}
+	case "refresh_token":
+		if req.RefreshToken == "" {
+			return nil, oauth.NewError(oauth.ErrCodeInvalidRequest, "refresh_token is required")
+		}
+		token, err = s.repo.RotateToken(ctx, app.ID, oauth.HashToken(req.RefreshToken), now, token)
+		if err != nil {
+			if errors.Is(err, apperr.ErrNotFound) {
+				return nil, oauth.NewError(oauth.ErrCodeInvalidGrant, "the refresh token is invalid or has expired")
+			}
+			return nil, err
+		}
+	default:
+		return nil, oauth.NewError(oauth.ErrCodeUnsupportedGrantType, "grant_type must be authorization_code or refresh_token")
+	}
+
+	return &dto.OAuthTokenResponse{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.config.AccessTokenTTL.Seconds()),
+		RefreshToken: refresh,
+		Scope:        token.Scopes,
+	}, nil
+}
+
+// Revoke deletes the token an app holds, given either its access or refresh
+// token (RFC 7009). Unknown tokens are not an error.
+func (s *oauthService) Revoke(ctx context.Context, clientID, clientSecret, token string) error {
+	app, err := s.client(ctx, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return oauth.NewError(oauth.ErrCodeInvalidRequest, "token is required")
+	}
+	return s.repo.RevokeToken(ctx, app.ID, oauth.HashToken(token))
+}
+
+// client authenticates the app calling the token endpoint; confidential
+// apps must present their secret
+func (s *oauthService) client(ctx context.Context, clientID, clientSecret string) (*model.OAuthApp, error) {
+	if clientID == "" {
+		return nil, oauth.NewError(oauth.ErrCodeInvalidClient, "client_id is required")
+	}
+	app, err := s.repo.GetAppByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, oauth.NewError(oauth.ErrCodeInvalidClient, "unknown client_id")
+		}
+		return nil, err
+	}
+	if app.Confidential() && subtle.ConstantTimeCompare([]byte(oauth.HashToken(clientSecret)), []byte(app.SecretHash)) != 1 {
+		return nil, oauth.NewError(oauth.ErrCodeInvalidClient, "invalid client credentials")
+	}
+	return app, nil
+}
+
+// Authenticate resolves an access token for the API middleware
+func (s *oauthService) Authenticate(ctx context.Context, accessToken string) (*oauth.Grant, error) {
+	token, err := s.repo.GetToken(ctx, oauth.HashToken(accessToken), time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := oauth.ParseScopes(token.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth.Grant{AppID: token.AppID, UserID: token.UserID, TenantID: token.TenantID, Scopes: scopes}, nil
+}
+
+// AuthorizedApps lists the apps the user has granted access to
+func (s *oauthService) AuthorizedApps(ctx context.Context, userID int64) ([]dto.AuthorizedApp, error) {
+	return s.repo.AuthorizedApps(ctx, userID)
+}
+
+// RevokeApp withdraws the user's grant to an app
+func (s *oauthService) RevokeApp(ctx context.Context, userID, appID int64) error {
+	revoked, err := s.repo.RevokeApp(ctx, userID, appID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return apperr.NotFound("authorized app not found")
+	}
+	return nil
+}
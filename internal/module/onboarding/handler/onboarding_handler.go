@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/onboarding/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type OnboardingHandler struct {
+	service service.OnboardingService
+}
+
+func NewOnboardingHandler(svc service.OnboardingService) *OnboardingHandler {
+	return &OnboardingHandler{service: svc}
+}
+
+// Register mounts the onboarding routes; they expect an authenticated user in the request context
+func (h *OnboardingHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/onboarding", h.State)
+	mux.HandleFunc("POST /me/onboarding/dismiss", h.Dismiss)
+	mux.HandleFunc("POST /me/onboarding/contacts", h.Contacts)
+}
+
+// State returns the caller's onboarding checklist and how much of it is done
+func (h *OnboardingHandler) State(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	state, err := h.service.State(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, state)
+}
+
+// Dismiss hides the caller's checklist for good
+func (h *OnboardingHandler) Dismiss(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if err := h.service.Dismiss(r.Context(), userID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Contacts suggests accounts to follow from the email addresses in the
+// caller's address book
+func (h *OnboardingHandler) Contacts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		Emails []string `json:"emails"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(body.Emails) > service.MaxContacts {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("at most %d contacts can be imported at once", service.MaxContacts))
+		return
+	}
+
+	users, err := h.service.SuggestFromContacts(r.Context(), userID, body.Emails)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"users": users})
+}
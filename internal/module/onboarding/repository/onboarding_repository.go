@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+// stepColumns holds the user columns of the steps stamped when they happen
+var stepColumns = map[types.OnboardingStep]string{
+	types.OnboardingStepFirstFollow: "first_follow_at",
+	types.OnboardingStepFirstPost:   "first_post_at",
+}
+
+type OnboardingRepository interface {
+	MarkStep(ctx context.Context, userID int64, step types.OnboardingStep, at time.Time) error
+	MarkOnboarded(ctx context.Context, userID int64, at time.Time) error
+	FindByEmails(ctx context.Context, userID int64, emails []string, limit int) ([]*model.User, error)
+}
+
+func NewOnboardingRepository(db *gorm.DB) OnboardingRepository {
+	return &onboardingRepository{db: db}
+}
+
+type onboardingRepository struct {
+	db *gorm.DB
+}
+
+// MarkStep stamps a step as done at the given time unless it already was.
+// The columns are written without touching updated_at, which profiles serve
+// as Last-Modified.
+func (r *onboardingRepository) MarkStep(ctx context.Context, userID int64, step types.OnboardingStep, at time.Time) error {
+	column, ok := stepColumns[step]
+	if !ok {
+		return fmt.Errorf("onboarding step %s is not stamped", step)
+	}
+	return r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND "+column+" IS NULL", userID).
+		UpdateColumn(column, at).Error
+}
+
+// MarkOnboarded stamps the user's checklist as finished unless it already was
+func (r *onboardingRepository) MarkOnboarded(ctx context.Context, userID int64, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND onboarded_at IS NULL", userID).
+		UpdateColumn("onboarded_at", at).Error
+}
+
+// FindByEmails returns the active users discoverable by email with one of
+// the emails, compared regardless of case, whom userID does not follow yet;
+// the most followed come first
+func (r *onboardingRepository) FindByEmails(ctx context.Context, userID int64, emails []string, limit int) ([]*model.User, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+	var users []*model.User
+	err := r.db.WithContext(ctx).
+		Where("LOWER(email) IN ? AND id <> ?", emails, userID).
+		Where("discoverable_by_email = ?", true).
+		Where("deactivated_at IS NULL AND banned_at IS NULL AND deleted_at IS NULL").
+		Where(`NOT EXISTS (
+			SELECT 1 FROM follows
+			WHERE follows.following_id = users.id
+				AND follows.follower_id = ?
+				AND follows.deleted_at IS NULL)`, userID).
+		Order("follower_count DESC, id ASC").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/module/onboarding/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	usersvc "github.com/ilhamosaurus/sns-platform/internal/module/user/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+const (
+	MaxContacts    = 1000 // Most addresses one contact import may hold
+	MaxSuggestions = 50   // Most accounts suggested from one import
+)
+
+// Steps is the onboarding checklist, in the order clients show it
+var Steps = []types.OnboardingStep{
+	types.OnboardingStepAvatar,
+	types.OnboardingStepBio,
+	types.OnboardingStepFirstFollow,
+	types.OnboardingStepFirstPost,
+}
+
+type Config struct {
+	StripEmailPlus bool // Passed to usersvc.NormalizeEmail, as at registration
+}
+
+// OnboardingService tracks new users through the onboarding checklist and
+// suggests whom they could follow first
+type OnboardingService interface {
+	State(ctx context.Context, userID int64) (*dto.Onboarding, error)
+	Complete(ctx context.Context, userID int64, step types.OnboardingStep) error
+	Dismiss(ctx context.Context, userID int64) error
+	SuggestFromContacts(ctx context.Context, userID int64, emails []string) ([]*dto.UserResponse, error)
+}
+
+type onboardingService struct {
+	repo     repository.OnboardingRepository
+	userRepo userrepo.UserRepository
+	config   Config
+}
+
+func NewOnboardingService(repo repository.OnboardingRepository, userRepo userrepo.UserRepository, config Config) OnboardingService {
+	return &onboardingService{repo: repo, userRepo: userRepo, config: config}
+}
+
+// State returns the user's checklist. Follows and posts the events did not
+// report, like those made before onboarding was tracked or synced from a
+// directory, are picked up from the counters and stamped then.
+func (s *onboardingService) State(ctx context.Context, userID int64) (*dto.Onboarding, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if user.FirstFollowAt == nil && user.FollwingCount > 0 {
+		if err := s.repo.MarkStep(ctx, userID, types.OnboardingStepFirstFollow, now); err != nil {
+			return nil, fmt.Errorf("failed to mark first follow: %w", err)
+		}
+		user.FirstFollowAt = &now
+	}
+	if user.FirstPostAt == nil && user.PostCount > 0 {
+		if err := s.repo.MarkStep(ctx, userID, types.OnboardingStepFirstPost, now); err != nil {
+			return nil, fmt.Errorf("failed to mark first post: %w", err)
+		}
+		user.FirstPostAt = &now
+	}
+
+	done := map[types.OnboardingStep]bool{
+		types.OnboardingStepAvatar:      user.AvatarURL != "",
+		types.OnboardingStepBio:         strings.TrimSpace(user.Bio) != "",
+		types.OnboardingStepFirstFollow: user.FirstFollowAt != nil,
+		types.OnboardingStepFirstPost:   user.FirstPostAt != nil,
+	}
+	state := &dto.Onboarding{Steps: make([]dto.OnboardingStep, len(Steps))}
+	completed := 0
+	for i, step := range Steps {
+		state.Steps[i] = dto.OnboardingStep{Step: step, Done: done[step]}
+		if done[step] {
+			completed++
+		}
+	}
+	state.Completeness = completed * 100 / len(Steps)
+
+	if user.OnboardedAt == nil && completed == len(Steps) {
+		if err := s.repo.MarkOnboarded(ctx, userID, now); err != nil {
+			return nil, fmt.Errorf("failed to mark onboarding done: %w", err)
+		}
+		user.OnboardedAt = &now
+	}
+	state.Done = user.OnboardedAt != nil
+	return state, nil
+}
+
+// Complete stamps a step the user just did; steps done before are left alone
+func (s *onboardingService) Complete(ctx context.Context, userID int64, step types.OnboardingStep) error {
+	return s.repo.MarkStep(ctx, userID, step, time.Now().UTC())
+}
+
+// Dismiss finishes the user's checklist whatever is left of it
+func (s *onboardingService) Dismiss(ctx context.Context, userID int64) error {
+	return s.repo.MarkOnboarded(ctx, userID, time.Now().UTC())
+}
+
+// SuggestFromContacts returns the accounts, not yet followed, registered with
+// addresses from the user's address book by users discoverable by email. The
+// addresses are only matched, never stored.
+func (s *onboardingService) SuggestFromContacts(ctx context.Context, userID int64, emails []string) ([]*dto.UserResponse, error) {
+	seen := make(map[string]bool, len(emails))
+	normalized := make([]string, 0, len(emails))
+	for _, email := range emails {
+		email = usersvc.NormalizeEmail(email, s.config.StripEmailPlus)
+		if strings.Contains(email, "@") && !seen[email] {
+			seen[email] = true
+			normalized = append(normalized, email)
+		}
+	}
+	users, err := s.repo.FindByEmails(ctx, userID, normalized, MaxSuggestions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match contacts: %w", err)
+	}
+	return dto.NewUserResponses(users), nil
+}
+
+// TrackOnboarding stamps the first follow and first post of every user as
+// the follow service and the post creators report them
+func TrackOnboarding(bus eventbus.Bus, svc OnboardingService) {
+	eventbus.On(bus, func(ctx context.Context, e event.UserFollowed) error {
+		return svc.Complete(ctx, e.FollowerID, types.OnboardingStepFirstFollow)
+	})
+	eventbus.On(bus, func(ctx context.Context, e event.PostCreated) error {
+		return svc.Complete(ctx, e.AuthorID, types.OnboardingStepFirstPost)
+	})
+}
@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/organization/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type OrganizationHandler struct {
+	service service.OrganizationService
+}
+
+func NewOrganizationHandler(svc service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{service: svc}
+}
+
+// Register mounts the organization routes; they expect an authenticated user in the request context
+func (h *OrganizationHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /orgs", h.Create)
+	mux.HandleFunc("GET /me/orgs", h.Mine)
+	mux.HandleFunc("GET /orgs/{id}", h.Get)
+	mux.HandleFunc("GET /orgs/{id}/members", h.Members)
+	mux.HandleFunc("POST /orgs/{id}/members", h.AddMember)
+	mux.HandleFunc("PATCH /orgs/{id}/members/{userID}", h.SetRole)
+	mux.HandleFunc("DELETE /orgs/{id}/members/{userID}", h.RemoveMember)
+	mux.HandleFunc("POST /orgs/{id}/posts", h.CreatePost)
+	mux.HandleFunc("GET /orgs/{id}/feed", h.Feed)
+}
+
+// Create sets up an organization owned by the caller
+func (h *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		Slug        string `json:"slug"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		AvatarURL   string `json:"avatar_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	body.Slug = strings.ToLower(strings.TrimSpace(body.Slug))
+	body.Name = strings.TrimSpace(body.Name)
+	if !validSlug(body.Slug) {
+		httpx.Error(w, http.StatusBadRequest, "slug must be 2 to 50 lowercase letters, digits or hyphens")
+		return
+	}
+	if body.Name == "" || utf8.RuneCountInString(body.Name) > 100 {
+		httpx.Error(w, http.StatusBadRequest, "name must be 1 to 100 characters")
+		return
+	}
+	if len(body.AvatarURL) > 255 {
+		httpx.Error(w, http.StatusBadRequest, "avatar_url must be at most 255 characters")
+		return
+	}
+
+	org := &model.Organization{
+		Slug:        body.Slug,
+		Name:        body.Name,
+		Description: strings.TrimSpace(body.Description),
+		AvatarURL:   body.AvatarURL,
+		OwnerID:     userID,
+	}
+	if err := h.service.Create(r.Context(), org); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, org)
+}
+
+// Mine lists the organizations the caller belongs to
+func (h *OrganizationHandler) Mine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 100)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	orgs, err := h.service.Mine(r.Context(), userID, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"organizations": orgs})
+}
+
+// Get returns an organization the caller belongs to
+func (h *OrganizationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, orgID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	org, err := h.service.Get(r.Context(), orgID, userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, org)
+}
+
+// Members lists the members of an organization the caller belongs to
+func (h *OrganizationHandler) Members(w http.ResponseWriter, r *http.Request) {
+	userID, orgID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	members, err := h.service.Members(r.Context(), orgID, userID, limit, offset)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"members": dto.NewOrganizationMembers(members)})
+}
+
+// AddMember adds a user to the organization as a member, or as an admin
+// when the caller is the owner
+func (h *OrganizationHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	userID, orgID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		UserID int64  `json:"user_id"`
+		Role   string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if body.UserID <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	role := types.OrganizationRoleMember
+	if body.Role != "" {
+		if role, ok = parseRole(w, body.Role); !ok {
+			return
+		}
+	}
+
+	member, err := h.service.AddMember(r.Context(), orgID, userID, body.UserID, role)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, dto.NewOrganizationMembers([]*model.OrganizationMember{member})[0])
+}
+
+// SetRole makes a member an admin or an admin a member; owner only
+func (h *OrganizationHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	userID, orgID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+	memberID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	role, ok := parseRole(w, body.Role)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SetRole(r.Context(), orgID, userID, memberID, role); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveMember takes a user out of the organization; removing oneself leaves it
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, orgID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+	memberID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.service.RemoveMember(r.Context(), orgID, userID, memberID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreatePost posts to the organization; only its members will see the post
+func (h *OrganizationHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
+	userID, orgID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Content   string          `json:"content"`
+		MediaType types.MediaType `json:"media_type"`
+		MediaURL  string          `json:"media_url"`
+		Region    string          `json:"region"` // Geotag; the author's region when empty
+		Latitude  *float64        `json:"latitude"`
+		Longitude *float64        `json:"longitude"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	body.Content = strings.TrimSpace(body.Content)
+	if body.Content == "" || utf8.RuneCountInString(body.Content) > service.MaxPostLength {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("content must be 1 to %d characters", service.MaxPostLength))
+		return
+	}
+	if body.MediaType == types.MediaTypeUnknown {
+		body.MediaType = types.MediaTypeText
+	}
+	if !model.ValidGeotag(body.Latitude, body.Longitude) {
+		httpx.Error(w, http.StatusBadRequest, "latitude and longitude must be given together, within -90 to 90 and -180 to 180")
+		return
+	}
+	if body.Region != "" {
+		region, ok := model.ParseRegion(body.Region)
+		if !ok {
+			httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("%q is not an ISO 3166-1 alpha-2 country code", body.Region))
+			return
+		}
+		body.Region = region
+	}
+
+	post := &model.Post{UserID: userID, Content: body.Content, MediaType: body.MediaType, MediaURL: body.MediaURL, Region: body.Region, Latitude: body.Latitude, Longitude: body.Longitude}
+	if err := h.service.CreatePost(r.Context(), orgID, post); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, dto.NewPostResponse(post))
+}
+
+// Feed returns a page of the organization's posts, newest first
+func (h *OrganizationHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	userID, orgID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 20), 1), 100)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	posts, err := h.service.Feed(r.Context(), orgID, userID, limit, offset)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"posts": posts})
+}
+
+// caller returns the authenticated user and the {id} organization of the request
+func (h *OrganizationHandler) caller(w http.ResponseWriter, r *http.Request) (userID, orgID int64, ok bool) {
+	userID, ok = logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return 0, 0, false
+	}
+	orgID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid organization id")
+		return 0, 0, false
+	}
+	return userID, orgID, true
+}
+
+// parseRole accepts the roles an owner can hand out
+func parseRole(w http.ResponseWriter, s string) (types.OrganizationRole, bool) {
+	role := types.StringToOrganizationRole(s)
+	if role != types.OrganizationRoleMember && role != types.OrganizationRoleAdmin {
+		httpx.Error(w, http.StatusBadRequest, "role must be member or admin")
+		return role, false
+	}
+	return role, true
+}
+
+// validSlug reports whether s is 2 to 50 lowercase letters, digits and
+// hyphens, not starting or ending with a hyphen
+func validSlug(s string) bool {
+	if len(s) < 2 || len(s) > 50 || s[0] == '-' || s[len(s)-1] == '-' {
+		return false
+	}
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *model.Organization) error
+	GetByID(ctx context.Context, id int64) (*model.Organization, error)
+	ListByMember(ctx context.Context, userID int64, limit, offset int) ([]*model.Organization, error)
+
+	GetMember(ctx context.Context, orgID, userID int64) (*model.OrganizationMember, error)
+	AddMember(ctx context.Context, member *model.OrganizationMember) error
+	SetMemberRole(ctx context.Context, orgID, userID int64, role types.OrganizationRole) error
+	RemoveMember(ctx context.Context, orgID, userID int64) error
+	ListMembers(ctx context.Context, orgID int64, limit, offset int) ([]*model.OrganizationMember, error)
+
+	CreatePost(ctx context.Context, post *model.Post) error
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+// VisibleTo restricts a posts query to rows viewerID may see: organization
+// posts only reach the organization's members
+func VisibleTo(viewerID int64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`(posts.organization_id IS NULL OR EXISTS (
+			SELECT 1 FROM organization_members
+			WHERE organization_members.organization_id = posts.organization_id
+				AND organization_members.user_id = ?
+				AND organization_members.deleted_at IS NULL))`, viewerID)
+	}
+}
+
+// VisibleToColumn is VisibleTo for queries covering many viewers at once;
+// viewerColumn names a trusted column holding each row's viewer ID
+func VisibleToColumn(viewerColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`(posts.organization_id IS NULL OR EXISTS (
+			SELECT 1 FROM organization_members
+			WHERE organization_members.organization_id = posts.organization_id
+				AND organization_members.user_id = ` + viewerColumn + `
+				AND organization_members.deleted_at IS NULL))`)
+	}
+}
+
+// Create stores the organization together with its owner's membership
+func (r *organizationRepository) Create(ctx context.Context, org *model.Organization) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		org.MemberCount = 1
+		if err := tx.Create(org).Error; err != nil {
+			return apperr.Translate(err, "organization")
+		}
+		return tx.Create(&model.OrganizationMember{
+			OrganizationID: org.ID,
+			UserID:         org.OwnerID,
+			Role:           types.OrganizationRoleOwner,
+		}).Error
+	})
+}
+
+func (r *organizationRepository) GetByID(ctx context.Context, id int64) (*model.Organization, error) {
+	var org model.Organization
+	if err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&org).Error; err != nil {
+		return nil, apperr.Translate(err, "organization")
+	}
+	return &org, nil
+}
+
+// ListByMember returns the organizations the user belongs to
+func (r *organizationRepository) ListByMember(ctx context.Context, userID int64, limit, offset int) ([]*model.Organization, error) {
+	var orgs []*model.Organization
+	err := r.db.WithContext(ctx).Table("organizations").
+		Select("organizations.*").
+		Joins(`INNER JOIN organization_members ON organization_members.organization_id = organizations.id
+			AND organization_members.user_id = ?
+			AND organization_members.deleted_at IS NULL`, userID).
+		Where("organizations.deleted_at IS NULL").
+		Order("organizations.name ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&orgs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	return orgs, nil
+}
+
+func (r *organizationRepository) GetMember(ctx context.Context, orgID, userID int64) (*model.OrganizationMember, error) {
+	var member model.OrganizationMember
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ? AND deleted_at IS NULL", orgID, userID).
+		First(&member).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "organization member")
+	}
+	return &member, nil
+}
+
+func (r *organizationRepository) AddMember(ctx context.Context, member *model.OrganizationMember) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(member).Error; err != nil {
+			return apperr.Translate(err, "organization member")
+		}
+		return adjustMemberCount(tx, member.OrganizationID, 1)
+	})
+}
+
+func (r *organizationRepository) SetMemberRole(ctx context.Context, orgID, userID int64, role types.OrganizationRole) error {
+	return r.db.WithContext(ctx).Model(&model.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ? AND deleted_at IS NULL", orgID, userID).
+		Update("role", role).Error
+}
+
+// RemoveMember deletes the membership outright so the user can be added again later
+func (r *organizationRepository) RemoveMember(ctx context.Context, orgID, userID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().
+			Where("organization_id = ? AND user_id = ? AND deleted_at IS NULL", orgID, userID).
+			Delete(&model.OrganizationMember{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apperr.NotFound("organization member not found")
+		}
+		return adjustMemberCount(tx, orgID, -1)
+	})
+}
+
+func (r *organizationRepository) ListMembers(ctx context.Context, orgID int64, limit, offset int) ([]*model.OrganizationMember, error) {
+	var members []*model.OrganizationMember
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("organization_id = ? AND deleted_at IS NULL", orgID).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE role WHEN ? THEN 0 WHEN ? THEN 1 ELSE 2 END, created_at ASC",
+			Vars: []any{types.OrganizationRoleOwner, types.OrganizationRoleAdmin},
+		}}).
+		Limit(limit).
+		Offset(offset).
+		Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// CreatePost stores an organization post and bumps the organization's post count
+func (r *organizationRepository) CreatePost(ctx context.Context, post *model.Post) error {
+	if post.OrganizationID == nil {
+		return fmt.Errorf("post has no organization")
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := model.SetAuthorRegions(tx, post); err != nil {
+			return err
+		}
+		if err := tx.Create(post).Error; err != nil {
+			return apperr.Translate(err, "post")
+		}
+		return tx.Model(&model.Organization{}).Where("id = ?", *post.OrganizationID).
+			UpdateColumn("post_count", gorm.Expr("post_count + ?", 1)).Error
+	})
+}
+
+func adjustMemberCount(tx *gorm.DB, orgID int64, delta int) error {
+	return tx.Model(&model.Organization{}).Where("id = ?", orgID).
+		UpdateColumn("member_count", gorm.Expr("member_count + ?", delta)).Error
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/module/organization/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/quota"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// MaxPostLength is the most characters an organization post may hold
+const MaxPostLength = 2200
+
+var (
+	// Organizations are hidden from non-members, who get the same error as
+	// for organizations that do not exist
+	ErrOrganizationNotFound = apperr.NotFound("organization not found")
+	ErrAlreadyMember        = apperr.Conflict("already a member of this organization")
+	ErrNotMember            = apperr.NotFound("not a member of this organization")
+	ErrForbidden            = apperr.Forbidden("insufficient organization role")
+	ErrOwnerCannotLeave     = apperr.Conflict("the owner cannot leave the organization")
+)
+
+type OrganizationService interface {
+	Create(ctx context.Context, org *model.Organization) error
+	Get(ctx context.Context, orgID, viewerID int64) (*model.Organization, error)
+	Mine(ctx context.Context, userID int64, limit, offset int) ([]*model.Organization, error)
+	Members(ctx context.Context, orgID, viewerID int64, limit, offset int) ([]*model.OrganizationMember, error)
+	AddMember(ctx context.Context, orgID, adminID, userID int64, role types.OrganizationRole) (*model.OrganizationMember, error)
+	RemoveMember(ctx context.Context, orgID, adminID, userID int64) error
+	SetRole(ctx context.Context, orgID, ownerID, userID int64, role types.OrganizationRole) error
+	Leave(ctx context.Context, orgID, userID int64) error
+	CreatePost(ctx context.Context, orgID int64, post *model.Post) error
+	Feed(ctx context.Context, orgID, viewerID int64, limit, offset int, mediaTypes ...types.MediaType) ([]*dto.FeedPost, error)
+}
+
+type organizationService struct {
+	repo     repository.OrganizationRepository
+	userRepo userrepo.UserRepository
+	feedRepo feedrepo.FeedRepository
+	bus      eventbus.Bus
+	audit    auditsvc.AuditService
+	quota    *quota.Quota
+}
+
+func NewOrganizationService(repo repository.OrganizationRepository, userRepo userrepo.UserRepository, feedRepo feedrepo.FeedRepository, bus eventbus.Bus, audit auditsvc.AuditService, q *quota.Quota) OrganizationService {
+	return &organizationService{repo: repo, userRepo: userRepo, feedRepo: feedRepo, bus: bus, audit: audit, quota: q}
+}
+
+// Create stores the organization with its creator as owner
+func (s *organizationService) Create(ctx context.Context, org *model.Organization) error {
+	if err := s.repo.Create(ctx, org); err != nil {
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+	return nil
+}
+
+// Get returns an organization the viewer belongs to
+func (s *organizationService) Get(ctx context.Context, orgID, viewerID int64) (*model.Organization, error) {
+	if _, err := s.requireRole(ctx, orgID, viewerID, types.OrganizationRoleMember); err != nil {
+		return nil, err
+	}
+	org, err := s.repo.GetByID(ctx, orgID)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	return org, err
+}
+
+// Mine lists the organizations the user belongs to
+func (s *organizationService) Mine(ctx context.Context, userID int64, limit, offset int) ([]*model.Organization, error) {
+	return s.repo.ListByMember(ctx, userID, limit, offset)
+}
+
+// Members lists an organization's members, owner and admins first
+func (s *organizationService) Members(ctx context.Context, orgID, viewerID int64, limit, offset int) ([]*model.OrganizationMember, error) {
+	if _, err := s.Get(ctx, orgID, viewerID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(ctx, orgID, limit, offset)
+}
+
+// AddMember adds a user to the organization. Admins add members; only the
+// owner may add admins.
+func (s *organizationService) AddMember(ctx context.Context, orgID, adminID, userID int64, role types.OrganizationRole) (*model.OrganizationMember, error) {
+	actor, err := s.requireRole(ctx, orgID, adminID, types.OrganizationRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if role != types.OrganizationRoleMember && role != types.OrganizationRoleAdmin {
+		return nil, fmt.Errorf("invalid organization role: %s", role.String())
+	}
+	if role >= actor.Role {
+		return nil, ErrForbidden
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDeactivated() {
+		return nil, apperr.NotFound("user not found")
+	}
+	existing, err := s.member(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAlreadyMember
+	}
+
+	member := &model.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: role}
+	if err := s.repo.AddMember(ctx, member); err != nil {
+		return nil, fmt.Errorf("failed to add organization member: %w", err)
+	}
+	member.User = user
+	s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    &adminID,
+		Action:     model.AuditOrganizationAddMember,
+		TargetType: model.AuditTargetOrganization,
+		TargetID:   orgID,
+		After:      map[string]any{"user_id": userID, "role": role.String()},
+	})
+	return member, nil
+}
+
+// RemoveMember takes a user out of the organization. Admins remove members;
+// only the owner may remove admins, and the owner cannot be removed.
+func (s *organizationService) RemoveMember(ctx context.Context, orgID, adminID, userID int64) error {
+	if adminID == userID {
+		return s.Leave(ctx, orgID, userID)
+	}
+	actor, err := s.requireRole(ctx, orgID, adminID, types.OrganizationRoleAdmin)
+	if err != nil {
+		return err
+	}
+	target, err := s.member(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return ErrNotMember
+	}
+	if target.Role >= actor.Role {
+		return ErrForbidden
+	}
+	return s.remove(ctx, target, adminID)
+}
+
+// SetRole promotes a member to admin or demotes an admin; only the owner may do this
+func (s *organizationService) SetRole(ctx context.Context, orgID, ownerID, userID int64, role types.OrganizationRole) error {
+	if _, err := s.requireRole(ctx, orgID, ownerID, types.OrganizationRoleOwner); err != nil {
+		return err
+	}
+	if role != types.OrganizationRoleMember && role != types.OrganizationRoleAdmin {
+		return fmt.Errorf("invalid organization role: %s", role.String())
+	}
+	target, err := s.member(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return ErrNotMember
+	}
+	if target.Role == types.OrganizationRoleOwner {
+		return ErrForbidden
+	}
+	if target.Role == role {
+		return nil
+	}
+	if err := s.repo.SetMemberRole(ctx, orgID, userID, role); err != nil {
+		return fmt.Errorf("failed to set organization role: %w", err)
+	}
+	s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    &ownerID,
+		Action:     model.AuditOrganizationSetRole,
+		TargetType: model.AuditTargetOrganization,
+		TargetID:   orgID,
+		Before:     map[string]any{"user_id": userID, "role": target.Role.String()},
+		After:      map[string]any{"user_id": userID, "role": role.String()},
+	})
+	return nil
+}
+
+// Leave removes the user's own membership
+func (s *organizationService) Leave(ctx context.Context, orgID, userID int64) error {
+	member, err := s.requireRole(ctx, orgID, userID, types.OrganizationRoleMember)
+	if err != nil {
+		return err
+	}
+	if member.Role == types.OrganizationRoleOwner {
+		return ErrOwnerCannotLeave
+	}
+	return s.remove(ctx, member, userID)
+}
+
+// CreatePost stores a post only the organization's members can see, charged
+// to the author's post quota. Such posts are never public, so they stay out
+// of explore, reposts and embeds.
+func (s *organizationService) CreatePost(ctx context.Context, orgID int64, post *model.Post) error {
+	if _, err := s.requireRole(ctx, orgID, post.UserID, types.OrganizationRoleMember); err != nil {
+		return err
+	}
+	if _, err := s.quota.Consume(ctx, post.UserID, quota.ActionPost); err != nil {
+		return err
+	}
+
+	post.OrganizationID = &orgID
+	post.CommunityID = nil
+	post.IsPublic = false
+	if err := s.repo.CreatePost(ctx, post); err != nil {
+		return fmt.Errorf("failed to create organization post: %w", err)
+	}
+	if err := s.bus.Publish(ctx, event.PostCreated{
+		PostID:         post.ID,
+		AuthorID:       post.UserID,
+		OrganizationID: post.OrganizationID,
+		CreatedAt:      post.CreatedAt,
+	}); err != nil {
+		slog.WarnContext(ctx, "failed to publish organization post", slog.Int64("post_id", post.ID), slog.Any("error", err))
+	}
+	return nil
+}
+
+// Feed returns the organization's posts, newest first; it is members only
+func (s *organizationService) Feed(ctx context.Context, orgID, viewerID int64, limit, offset int, mediaTypes ...types.MediaType) ([]*dto.FeedPost, error) {
+	if _, err := s.requireRole(ctx, orgID, viewerID, types.OrganizationRoleMember); err != nil {
+		return nil, err
+	}
+	return s.feedRepo.GetOrganizationFeed(ctx, orgID, viewerID, limit, offset, mediaTypes...)
+}
+
+func (s *organizationService) remove(ctx context.Context, member *model.OrganizationMember, actorID int64) error {
+	if err := s.repo.RemoveMember(ctx, member.OrganizationID, member.UserID); err != nil {
+		return fmt.Errorf("failed to remove organization member: %w", err)
+	}
+	s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    &actorID,
+		Action:     model.AuditOrganizationRemoveMember,
+		TargetType: model.AuditTargetOrganization,
+		TargetID:   member.OrganizationID,
+		Before:     map[string]any{"user_id": member.UserID, "role": member.Role.String()},
+	})
+	return nil
+}
+
+// member returns the user's membership row, or nil when there is none
+func (s *organizationService) member(ctx context.Context, orgID, userID int64) (*model.OrganizationMember, error) {
+	member, err := s.repo.GetMember(ctx, orgID, userID)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load organization member: %w", err)
+	}
+	return member, nil
+}
+
+// requireRole returns the user's membership if it holds at least role.
+// Non-members are told the organization does not exist.
+func (s *organizationService) requireRole(ctx context.Context, orgID, userID int64, role types.OrganizationRole) (*model.OrganizationMember, error) {
+	member, err := s.member(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case member == nil:
+		return nil, ErrOrganizationNotFound
+	case member.Role < role:
+		return nil, ErrForbidden
+	}
+	return member, nil
+}
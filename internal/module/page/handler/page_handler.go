@@ -0,0 +1,456 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/page/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type PageHandler struct {
+	service service.PageService
+}
+
+func NewPageHandler(svc service.PageService) *PageHandler {
+	return &PageHandler{service: svc}
+}
+
+// Register mounts the page routes; they expect an authenticated user in the request context
+func (h *PageHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /pages", h.Create)
+	mux.HandleFunc("GET /pages", h.Search)
+	mux.HandleFunc("GET /me/pages", h.Managed)
+	mux.HandleFunc("GET /pages/{id}", h.Get)
+	mux.HandleFunc("PATCH /pages/{id}", h.Update)
+	mux.HandleFunc("GET /pages/{id}/managers", h.Managers)
+	mux.HandleFunc("POST /pages/{id}/managers", h.AddManager)
+	mux.HandleFunc("PATCH /pages/{id}/managers/{userID}", h.SetRole)
+	mux.HandleFunc("DELETE /pages/{id}/managers/{userID}", h.RemoveManager)
+	mux.HandleFunc("PUT /pages/{id}/follow", h.Follow)
+	mux.HandleFunc("DELETE /pages/{id}/follow", h.Unfollow)
+	mux.HandleFunc("POST /pages/{id}/posts", h.CreatePost)
+	mux.HandleFunc("GET /pages/{id}/posts", h.Posts)
+	mux.HandleFunc("GET /pages/{id}/insights", h.Insights)
+}
+
+// Create sets up a page with the caller as its admin
+func (h *PageHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		Handle    string `json:"handle"`
+		Name      string `json:"name"`
+		Bio       string `json:"bio"`
+		Category  string `json:"category"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	body.Handle = strings.ToLower(strings.TrimSpace(body.Handle))
+	body.Name = strings.TrimSpace(body.Name)
+	if !validHandle(body.Handle) {
+		httpx.Error(w, http.StatusBadRequest, "handle must be 2 to 50 lowercase letters, digits or underscores")
+		return
+	}
+	if msg := validateProfile(&body.Name, body.Bio, body.Category, body.AvatarURL); msg != "" {
+		httpx.Error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	page := &model.Page{
+		Handle:    body.Handle,
+		Name:      body.Name,
+		Bio:       strings.TrimSpace(body.Bio),
+		Category:  strings.TrimSpace(body.Category),
+		AvatarURL: body.AvatarURL,
+	}
+	if err := h.service.Create(r.Context(), page, userID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, page)
+}
+
+// Search finds pages by handle or name; ?q= is required
+func (h *PageHandler) Search(w http.ResponseWriter, r *http.Request) {
+	if _, ok := logger.UserID(r.Context()); !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || utf8.RuneCountInString(query) > 100 {
+		httpx.Error(w, http.StatusBadRequest, "q must be 1 to 100 characters")
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 20), 1), 100)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	pages, err := h.service.Search(r.Context(), query, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"pages": pages})
+}
+
+// Managed lists the pages the caller manages
+func (h *PageHandler) Managed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 100)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	pages, err := h.service.Managed(r.Context(), userID, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"pages": pages})
+}
+
+// Get returns a page's profile
+func (h *PageHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.service.Get(r.Context(), pageID, userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, page)
+}
+
+// Update edits a page's profile; admins only. The handle cannot change.
+func (h *PageHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Name      *string `json:"name"`
+		Bio       *string `json:"bio"`
+		Category  *string `json:"category"`
+		AvatarURL *string `json:"avatar_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	updates := make(map[string]any)
+	var bio, category, avatarURL string
+	if body.Name != nil {
+		*body.Name = strings.TrimSpace(*body.Name)
+		updates["name"] = *body.Name
+	}
+	if body.Bio != nil {
+		bio = strings.TrimSpace(*body.Bio)
+		updates["bio"] = bio
+	}
+	if body.Category != nil {
+		category = strings.TrimSpace(*body.Category)
+		updates["category"] = category
+	}
+	if body.AvatarURL != nil {
+		avatarURL = *body.AvatarURL
+		updates["avatar_url"] = avatarURL
+	}
+	if msg := validateProfile(body.Name, bio, category, avatarURL); msg != "" {
+		httpx.Error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	page, err := h.service.Update(r.Context(), pageID, userID, updates)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, page)
+}
+
+// Managers lists the page's managers to its other managers
+func (h *PageHandler) Managers(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	managers, err := h.service.Managers(r.Context(), pageID, userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"managers": dto.NewPageManagers(managers)})
+}
+
+// AddManager gives a user a role on the page; admins only
+func (h *PageHandler) AddManager(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		UserID int64  `json:"user_id"`
+		Role   string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if body.UserID <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	role := types.PageRoleEditor
+	if body.Role != "" {
+		if role, ok = parseRole(w, body.Role); !ok {
+			return
+		}
+	}
+
+	manager, err := h.service.AddManager(r.Context(), pageID, userID, body.UserID, role)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, dto.NewPageManagers([]*model.PageManager{manager})[0])
+}
+
+// SetRole changes a manager's role; admins only
+func (h *PageHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+	managerID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	role, ok := parseRole(w, body.Role)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SetRole(r.Context(), pageID, userID, managerID, role); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveManager takes a user's role on the page away; removing oneself steps down
+func (h *PageHandler) RemoveManager(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+	managerID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.service.RemoveManager(r.Context(), pageID, userID, managerID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PageHandler) Follow(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.Follow(r.Context(), pageID, userID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PageHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.Unfollow(r.Context(), pageID, userID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreatePost publishes a post as the page; editors and admins only
+func (h *PageHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Content   string          `json:"content"`
+		MediaType types.MediaType `json:"media_type"`
+		MediaURL  string          `json:"media_url"`
+		Region    string          `json:"region"` // Geotag; the author's region when empty
+		Latitude  *float64        `json:"latitude"`
+		Longitude *float64        `json:"longitude"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	body.Content = strings.TrimSpace(body.Content)
+	if body.Content == "" || utf8.RuneCountInString(body.Content) > service.MaxPostLength {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("content must be 1 to %d characters", service.MaxPostLength))
+		return
+	}
+	if body.MediaType == types.MediaTypeUnknown {
+		body.MediaType = types.MediaTypeText
+	}
+	if !model.ValidGeotag(body.Latitude, body.Longitude) {
+		httpx.Error(w, http.StatusBadRequest, "latitude and longitude must be given together, within -90 to 90 and -180 to 180")
+		return
+	}
+	if body.Region != "" {
+		region, ok := model.ParseRegion(body.Region)
+		if !ok {
+			httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("%q is not an ISO 3166-1 alpha-2 country code", body.Region))
+			return
+		}
+		body.Region = region
+	}
+
+	actor, err := h.service.ActAs(r.Context(), userID, pageID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	post := &model.Post{Content: body.Content, MediaType: body.MediaType, MediaURL: body.MediaURL, Region: body.Region, Latitude: body.Latitude, Longitude: body.Longitude, IsPublic: true}
+	if err := h.service.Publish(r.Context(), actor, post); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, dto.NewPostResponse(post))
+}
+
+// Posts returns a page of the page's posts, newest first
+func (h *PageHandler) Posts(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 20), 1), 100)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	posts, err := h.service.Posts(r.Context(), pageID, userID, limit, offset)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"posts": posts})
+}
+
+// Insights sums the page's post performance over ?days= (default 28); managers only
+func (h *PageHandler) Insights(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	days := httpx.QueryInt(r, "days", 28)
+	if days < 1 || days > service.MaxInsightDays {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("days must be 1 to %d", service.MaxInsightDays))
+		return
+	}
+	insights, err := h.service.Insights(r.Context(), pageID, userID, days)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, insights)
+}
+
+// caller returns the authenticated user and the {id} page of the request
+func (h *PageHandler) caller(w http.ResponseWriter, r *http.Request) (userID, pageID int64, ok bool) {
+	userID, ok = logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return 0, 0, false
+	}
+	pageID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid page id")
+		return 0, 0, false
+	}
+	return userID, pageID, true
+}
+
+func parseRole(w http.ResponseWriter, s string) (types.PageRole, bool) {
+	role := types.StringToPageRole(s)
+	if role == types.PageRoleUnknown {
+		httpx.Error(w, http.StatusBadRequest, "role must be analyst, editor or admin")
+		return role, false
+	}
+	return role, true
+}
+
+// validateProfile checks the editable profile fields and returns what is
+// wrong with them, or "" when they are fine; a nil name is left unchecked
+func validateProfile(name *string, bio, category, avatarURL string) string {
+	switch {
+	case name != nil && (*name == "" || utf8.RuneCountInString(*name) > 100):
+		return "name must be 1 to 100 characters"
+	case utf8.RuneCountInString(bio) > 500:
+		return "bio must be at most 500 characters"
+	case utf8.RuneCountInString(category) > 50:
+		return "category must be at most 50 characters"
+	case len(avatarURL) > 255:
+		return "avatar_url must be at most 255 characters"
+	}
+	return ""
+}
+
+// validHandle reports whether s is 2 to 50 lowercase letters, digits and
+// underscores
+func validHandle(s string) bool {
+	if len(s) < 2 || len(s) > 50 {
+		return false
+	}
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
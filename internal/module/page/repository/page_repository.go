@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	closefriendrepo "github.com/ilhamosaurus/sns-platform/internal/module/closefriend/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PageRepository interface {
+	Create(ctx context.Context, page *model.Page, adminID int64) error
+	Update(ctx context.Context, id int64, updates map[string]any) error
+	GetByID(ctx context.Context, id int64) (*model.Page, error)
+	Search(ctx context.Context, query string, limit, offset int) ([]*model.Page, error)
+	ListManaged(ctx context.Context, userID int64, limit, offset int) ([]*model.Page, error)
+
+	GetManager(ctx context.Context, pageID, userID int64) (*model.PageManager, error)
+	AddManager(ctx context.Context, manager *model.PageManager) error
+	SetManagerRole(ctx context.Context, pageID, userID int64, role types.PageRole) error
+	RemoveManager(ctx context.Context, pageID, userID int64) error
+	CountManagers(ctx context.Context, pageID int64, role types.PageRole) (int64, error)
+	ListManagers(ctx context.Context, pageID int64) ([]*model.PageManager, error)
+
+	Follow(ctx context.Context, pageID, userID int64) error
+	Unfollow(ctx context.Context, pageID, userID int64) error
+	IsFollowing(ctx context.Context, pageID, userID int64) (bool, error)
+
+	CreatePost(ctx context.Context, post *model.Post) error
+	ListPosts(ctx context.Context, pageID, viewerID int64, limit, offset int) ([]*model.Post, error)
+	DailyStats(ctx context.Context, pageID int64, from, to time.Time) ([]*model.PostDailyStat, error)
+}
+
+func NewPageRepository(db *gorm.DB) PageRepository {
+	return &pageRepository{db: db}
+}
+
+type pageRepository struct {
+	db *gorm.DB
+}
+
+// Create stores the page together with its first admin
+func (r *pageRepository) Create(ctx context.Context, page *model.Page, adminID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(page).Error; err != nil {
+			return apperr.Translate(err, "page")
+		}
+		return tx.Create(&model.PageManager{PageID: page.ID, UserID: adminID, Role: types.PageRoleAdmin}).Error
+	})
+}
+
+func (r *pageRepository) Update(ctx context.Context, id int64, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.Page{}).Where("id = ? AND deleted_at IS NULL", id).Updates(updates).Error
+}
+
+func (r *pageRepository) GetByID(ctx context.Context, id int64) (*model.Page, error) {
+	var page model.Page
+	if err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&page).Error; err != nil {
+		return nil, apperr.Translate(err, "page")
+	}
+	return &page, nil
+}
+
+// Search finds pages whose handle or name starts with query, or whose name
+// has a word starting with it, most followed first
+func (r *pageRepository) Search(ctx context.Context, query string, limit, offset int) ([]*model.Page, error) {
+	pattern := escapeLike(strings.ToLower(query)) + "%"
+	var pages []*model.Page
+	err := r.db.WithContext(ctx).
+		Where(`deleted_at IS NULL AND (LOWER(handle) LIKE ? ESCAPE '!' OR LOWER(name) LIKE ? ESCAPE '!' OR LOWER(name) LIKE ? ESCAPE '!')`,
+			pattern, pattern, "% "+pattern).
+		Order("follower_count DESC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&pages).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search pages: %w", err)
+	}
+	return pages, nil
+}
+
+// ListManaged returns the pages the user manages
+func (r *pageRepository) ListManaged(ctx context.Context, userID int64, limit, offset int) ([]*model.Page, error) {
+	var pages []*model.Page
+	err := r.db.WithContext(ctx).Table("pages").
+		Select("pages.*").
+		Joins(`INNER JOIN page_managers ON page_managers.page_id = pages.id
+			AND page_managers.user_id = ?
+			AND page_managers.deleted_at IS NULL`, userID).
+		Where("pages.deleted_at IS NULL").
+		Order("pages.name ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&pages).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pages: %w", err)
+	}
+	return pages, nil
+}
+
+func (r *pageRepository) GetManager(ctx context.Context, pageID, userID int64) (*model.PageManager, error) {
+	var manager model.PageManager
+	err := r.db.WithContext(ctx).
+		Where("page_id = ? AND user_id = ? AND deleted_at IS NULL", pageID, userID).
+		First(&manager).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "page manager")
+	}
+	return &manager, nil
+}
+
+func (r *pageRepository) AddManager(ctx context.Context, manager *model.PageManager) error {
+	return apperr.Translate(r.db.WithContext(ctx).Create(manager).Error, "page manager")
+}
+
+func (r *pageRepository) SetManagerRole(ctx context.Context, pageID, userID int64, role types.PageRole) error {
+	return r.db.WithContext(ctx).Model(&model.PageManager{}).
+		Where("page_id = ? AND user_id = ? AND deleted_at IS NULL", pageID, userID).
+		Update("role", role).Error
+}
+
+// RemoveManager deletes the manager outright so the user can be added again later
+func (r *pageRepository) RemoveManager(ctx context.Context, pageID, userID int64) error {
+	res := r.db.WithContext(ctx).Unscoped().
+		Where("page_id = ? AND user_id = ? AND deleted_at IS NULL", pageID, userID).
+		Delete(&model.PageManager{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return apperr.NotFound("page manager not found")
+	}
+	return nil
+}
+
+// CountManagers counts the page's managers holding role
+func (r *pageRepository) CountManagers(ctx context.Context, pageID int64, role types.PageRole) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.PageManager{}).
+		Where("page_id = ? AND role = ? AND deleted_at IS NULL", pageID, role).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *pageRepository) ListManagers(ctx context.Context, pageID int64) ([]*model.PageManager, error) {
+	var managers []*model.PageManager
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("page_id = ? AND deleted_at IS NULL", pageID).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE role WHEN ? THEN 0 WHEN ? THEN 1 ELSE 2 END, created_at ASC",
+			Vars: []any{types.PageRoleAdmin, types.PageRoleEditor},
+		}}).
+		Find(&managers).Error
+	if err != nil {
+		return nil, err
+	}
+	return managers, nil
+}
+
+// Follow adds a follower and bumps the follower count; following twice is a no-op
+func (r *pageRepository) Follow(ctx context.Context, pageID, userID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.PageFollow{PageID: pageID, UserID: userID})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return apperr.Translate(res.Error, "page follow")
+		}
+		return adjustFollowerCount(tx, pageID, 1)
+	})
+}
+
+// Unfollow removes a follower; unfollowing a page one does not follow is a no-op
+func (r *pageRepository) Unfollow(ctx context.Context, pageID, userID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Where("page_id = ? AND user_id = ?", pageID, userID).Delete(&model.PageFollow{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return adjustFollowerCount(tx, pageID, -1)
+	})
+}
+
+func (r *pageRepository) IsFollowing(ctx context.Context, pageID, userID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.PageFollow{}).
+		Where("page_id = ? AND user_id = ? AND deleted_at IS NULL", pageID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreatePost stores a page post and bumps the page's post count
+func (r *pageRepository) CreatePost(ctx context.Context, post *model.Post) error {
+	if post.PageID == nil {
+		return fmt.Errorf("post has no page")
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := model.SetAuthorRegions(tx, post); err != nil {
+			return err
+		}
+		if err := tx.Create(post).Error; err != nil {
+			return apperr.Translate(err, "post")
+		}
+		return tx.Model(&model.Page{}).Where("id = ?", *post.PageID).
+			UpdateColumn("post_count", gorm.Expr("post_count + ?", 1)).Error
+	})
+}
+
+// ListPosts returns the posts published as the page, newest first
+func (r *pageRepository) ListPosts(ctx context.Context, pageID, viewerID int64, limit, offset int) ([]*model.Post, error) {
+	var posts []*model.Post
+	err := r.db.WithContext(ctx).
+		Where("posts.page_id = ? AND posts.deleted_at IS NULL", pageID).
+		Scopes(closefriendrepo.VisibleTo(viewerID), userrepo.ActiveAuthors("posts.user_id")).
+		Order("posts.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// DailyStats sums the daily stats of the page's posts for days in [from, to),
+// oldest first
+func (r *pageRepository) DailyStats(ctx context.Context, pageID int64, from, to time.Time) ([]*model.PostDailyStat, error) {
+	var stats []*model.PostDailyStat
+	err := r.db.WithContext(ctx).Table("post_daily_stats").
+		Select(`post_daily_stats.day,
+			SUM(post_daily_stats.impressions) AS impressions,
+			SUM(post_daily_stats.reach) AS reach,
+			SUM(post_daily_stats.likes) AS likes,
+			SUM(post_daily_stats.comments) AS comments,
+			SUM(post_daily_stats.link_clicks) AS link_clicks`).
+		Joins("INNER JOIN posts ON posts.id = post_daily_stats.post_id AND posts.page_id = ?", pageID).
+		Where("post_daily_stats.day >= ? AND post_daily_stats.day < ? AND post_daily_stats.deleted_at IS NULL", from, to).
+		Group("post_daily_stats.day").
+		Order("post_daily_stats.day").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load page stats: %w", err)
+	}
+	return stats, nil
+}
+
+func adjustFollowerCount(tx *gorm.DB, pageID int64, delta int) error {
+	return tx.Model(&model.Page{}).Where("id = ?", pageID).
+		UpdateColumn("follower_count", gorm.Expr("follower_count + ?", delta)).Error
+}
+
+// escapeLike escapes the LIKE wildcards in s with !, which unlike a backslash
+// needs no escaping in MySQL string literals
+func escapeLike(s string) string {
+	return strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(s)
+}
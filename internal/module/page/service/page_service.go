@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/hydrator"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/page/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/challenge"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/quota"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+const (
+	// MaxPostLength is the most characters a page post may hold
+	MaxPostLength = 2200
+	// MaxInsightDays is the longest window page insights cover
+	MaxInsightDays = 90
+)
+
+var (
+	ErrPageNotFound    = apperr.NotFound("page not found")
+	ErrNotManager      = apperr.Forbidden("not a manager of this page")
+	ErrForbidden       = apperr.Forbidden("insufficient page role")
+	ErrManagerNotFound = apperr.NotFound("page manager not found")
+	ErrAlreadyManager  = apperr.Conflict("already a manager of this page")
+	ErrLastAdmin       = apperr.Conflict("a page needs at least one admin")
+	ErrNotActingAsPage = errors.New("not acting as a page")
+)
+
+// Actor is the identity an action is performed as: a user acting as
+// themselves, or a manager acting as a page. UserID is always the person
+// behind the action, who remains accountable for it.
+type Actor struct {
+	UserID int64
+	PageID int64 // Zero when acting as oneself
+}
+
+// AsUser is the actor for a user acting as themselves
+func AsUser(userID int64) Actor {
+	return Actor{UserID: userID}
+}
+
+// IsPage reports whether the actor acts as a page
+func (a Actor) IsPage() bool {
+	return a.PageID != 0
+}
+
+type PageService interface {
+	Create(ctx context.Context, page *model.Page, adminID int64) error
+	Get(ctx context.Context, pageID, viewerID int64) (*dto.PageProfile, error)
+	Update(ctx context.Context, pageID, adminID int64, updates map[string]any) (*model.Page, error)
+	Search(ctx context.Context, query string, limit, offset int) ([]*model.Page, error)
+	Managed(ctx context.Context, userID int64, limit, offset int) ([]*model.Page, error)
+
+	Managers(ctx context.Context, pageID, userID int64) ([]*model.PageManager, error)
+	AddManager(ctx context.Context, pageID, adminID, userID int64, role types.PageRole) (*model.PageManager, error)
+	SetRole(ctx context.Context, pageID, adminID, userID int64, role types.PageRole) error
+	RemoveManager(ctx context.Context, pageID, adminID, userID int64) error
+
+	Follow(ctx context.Context, pageID, userID int64) error
+	Unfollow(ctx context.Context, pageID, userID int64) error
+
+	ActAs(ctx context.Context, userID, pageID int64) (Actor, error)
+	Publish(ctx context.Context, actor Actor, post *model.Post) error
+	Posts(ctx context.Context, pageID, viewerID int64, limit, offset int) ([]*dto.FeedPost, error)
+	Insights(ctx context.Context, pageID, userID int64, days int) (*dto.PageInsights, error)
+}
+
+type pageService struct {
+	repo       repository.PageRepository
+	userRepo   userrepo.UserRepository
+	hydrator   *hydrator.Hydrator
+	bus        eventbus.Bus
+	audit      auditsvc.AuditService
+	challenges *challenge.Challenge
+	quota      *quota.Quota
+}
+
+func NewPageService(repo repository.PageRepository, userRepo userrepo.UserRepository, hyd *hydrator.Hydrator, bus eventbus.Bus, audit auditsvc.AuditService, challenges *challenge.Challenge, q *quota.Quota) PageService {
+	return &pageService{repo: repo, userRepo: userRepo, hydrator: hyd, bus: bus, audit: audit, challenges: challenges, quota: q}
+}
+
+// Create stores the page with its creator as admin
+func (s *pageService) Create(ctx context.Context, page *model.Page, adminID int64) error {
+	if err := s.repo.Create(ctx, page, adminID); err != nil {
+		return fmt.Errorf("failed to create page: %w", err)
+	}
+	return nil
+}
+
+// Get returns the page's profile, with the viewer's follow and role
+func (s *pageService) Get(ctx context.Context, pageID, viewerID int64) (*dto.PageProfile, error) {
+	page, err := s.page(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+	following, err := s.repo.IsFollowing(ctx, pageID, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check page follow: %w", err)
+	}
+	profile := &dto.PageProfile{Page: page, IsFollowing: following}
+	if manager, err := s.manager(ctx, pageID, viewerID); err != nil {
+		return nil, err
+	} else if manager != nil {
+		profile.Role = manager.Role
+	}
+	return profile, nil
+}
+
+// Update edits the page's profile; admins only
+func (s *pageService) Update(ctx context.Context, pageID, adminID int64, updates map[string]any) (*model.Page, error) {
+	if _, err := s.requireRole(ctx, pageID, adminID, types.PageRoleAdmin); err != nil {
+		return nil, err
+	}
+	if len(updates) > 0 {
+		if err := s.repo.Update(ctx, pageID, updates); err != nil {
+			return nil, fmt.Errorf("failed to update page: %w", err)
+		}
+	}
+	return s.page(ctx, pageID)
+}
+
+// Search finds pages by the start of their handle or of a word of their name
+func (s *pageService) Search(ctx context.Context, query string, limit, offset int) ([]*model.Page, error) {
+	return s.repo.Search(ctx, query, limit, offset)
+}
+
+// Managed lists the pages the user manages
+func (s *pageService) Managed(ctx context.Context, userID int64, limit, offset int) ([]*model.Page, error) {
+	return s.repo.ListManaged(ctx, userID, limit, offset)
+}
+
+// Managers lists the page's managers, admins first; managers only
+func (s *pageService) Managers(ctx context.Context, pageID, userID int64) ([]*model.PageManager, error) {
+	if _, err := s.requireRole(ctx, pageID, userID, types.PageRoleAnalyst); err != nil {
+		return nil, err
+	}
+	return s.repo.ListManagers(ctx, pageID)
+}
+
+// AddManager gives a user a role on the page; admins only
+func (s *pageService) AddManager(ctx context.Context, pageID, adminID, userID int64, role types.PageRole) (*model.PageManager, error) {
+	if _, err := s.requireRole(ctx, pageID, adminID, types.PageRoleAdmin); err != nil {
+		return nil, err
+	}
+	if role == types.PageRoleUnknown {
+		return nil, fmt.Errorf("invalid page role: %s", role.String())
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDeactivated() {
+		return nil, apperr.NotFound("user not found")
+	}
+	existing, err := s.manager(ctx, pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAlreadyManager
+	}
+
+	manager := &model.PageManager{PageID: pageID, UserID: userID, Role: role}
+	if err := s.repo.AddManager(ctx, manager); err != nil {
+		return nil, fmt.Errorf("failed to add page manager: %w", err)
+	}
+	manager.User = user
+	s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    &adminID,
+		Action:     model.AuditPageAddManager,
+		TargetType: model.AuditTargetPage,
+		TargetID:   pageID,
+		After:      map[string]any{"user_id": userID, "role": role.String()},
+	})
+	return manager, nil
+}
+
+// SetRole changes a manager's role; admins only, and the last admin stays one
+func (s *pageService) SetRole(ctx context.Context, pageID, adminID, userID int64, role types.PageRole) error {
+	if _, err := s.requireRole(ctx, pageID, adminID, types.PageRoleAdmin); err != nil {
+		return err
+	}
+	if role == types.PageRoleUnknown {
+		return fmt.Errorf("invalid page role: %s", role.String())
+	}
+	target, err := s.manager(ctx, pageID, userID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return ErrManagerNotFound
+	}
+	if target.Role == role {
+		return nil
+	}
+	if err := s.keepAdmin(ctx, target); err != nil {
+		return err
+	}
+	if err := s.repo.SetManagerRole(ctx, pageID, userID, role); err != nil {
+		return fmt.Errorf("failed to set page role: %w", err)
+	}
+	s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    &adminID,
+		Action:     model.AuditPageSetRole,
+		TargetType: model.AuditTargetPage,
+		TargetID:   pageID,
+		Before:     map[string]any{"user_id": userID, "role": target.Role.String()},
+		After:      map[string]any{"user_id": userID, "role": role.String()},
+	})
+	return nil
+}
+
+// RemoveManager takes a user's role on the page away. Admins remove anyone,
+// and any manager may step down, but the last admin stays.
+func (s *pageService) RemoveManager(ctx context.Context, pageID, adminID, userID int64) error {
+	required := types.PageRoleAdmin
+	if adminID == userID {
+		required = types.PageRoleAnalyst
+	}
+	if _, err := s.requireRole(ctx, pageID, adminID, required); err != nil {
+		return err
+	}
+	target, err := s.manager(ctx, pageID, userID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return ErrManagerNotFound
+	}
+	if err := s.keepAdmin(ctx, target); err != nil {
+		return err
+	}
+	if err := s.repo.RemoveManager(ctx, pageID, userID); err != nil {
+		return fmt.Errorf("failed to remove page manager: %w", err)
+	}
+	s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    &adminID,
+		Action:     model.AuditPageRemoveManager,
+		TargetType: model.AuditTargetPage,
+		TargetID:   pageID,
+		Before:     map[string]any{"user_id": userID, "role": target.Role.String()},
+	})
+	return nil
+}
+
+func (s *pageService) Follow(ctx context.Context, pageID, userID int64) error {
+	if _, err := s.page(ctx, pageID); err != nil {
+		return err
+	}
+	if _, err := s.quota.Consume(ctx, userID, quota.ActionFollow); err != nil {
+		return err
+	}
+	return s.repo.Follow(ctx, pageID, userID)
+}
+
+func (s *pageService) Unfollow(ctx context.Context, pageID, userID int64) error {
+	return s.repo.Unfollow(ctx, pageID, userID)
+}
+
+// ActAs returns the actor for a user acting as a page, which takes the
+// editor role
+func (s *pageService) ActAs(ctx context.Context, userID, pageID int64) (Actor, error) {
+	if _, err := s.page(ctx, pageID); err != nil {
+		return Actor{}, err
+	}
+	if _, err := s.requireRole(ctx, pageID, userID, types.PageRoleEditor); err != nil {
+		return Actor{}, err
+	}
+	return Actor{UserID: userID, PageID: pageID}, nil
+}
+
+// Publish stores a post published as the actor's page. The post keeps the
+// manager who wrote it as its user, for moderation and audits, while
+// clients show the page as its author, and counts against that manager's
+// post quota. Risky managers must pass a challenge first.
+func (s *pageService) Publish(ctx context.Context, actor Actor, post *model.Post) error {
+	if !actor.IsPage() {
+		return ErrNotActingAsPage
+	}
+	if err := s.challenges.Require(ctx, actor.UserID, challenge.ActionPost); err != nil {
+		return err
+	}
+	if _, err := s.quota.Consume(ctx, actor.UserID, quota.ActionPost); err != nil {
+		return err
+	}
+	post.UserID = actor.UserID
+	post.PageID = &actor.PageID
+	post.CommunityID = nil
+	post.OrganizationID = nil
+	post.CloseFriends = false
+	if err := s.repo.CreatePost(ctx, post); err != nil {
+		return fmt.Errorf("failed to create page post: %w", err)
+	}
+	if err := s.bus.Publish(ctx, event.PostCreated{
+		PostID:    post.ID,
+		AuthorID:  post.UserID,
+		PageID:    post.PageID,
+		IsPublic:  post.IsPublic,
+		CreatedAt: post.CreatedAt,
+	}); err != nil {
+		slog.WarnContext(ctx, "failed to publish page post", slog.Int64("post_id", post.ID), slog.Any("error", err))
+	}
+	return nil
+}
+
+// Posts returns the page's posts, newest first
+func (s *pageService) Posts(ctx context.Context, pageID, viewerID int64, limit, offset int) ([]*dto.FeedPost, error) {
+	if _, err := s.page(ctx, pageID); err != nil {
+		return nil, err
+	}
+	posts, err := s.repo.ListPosts(ctx, pageID, viewerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list page posts: %w", err)
+	}
+	return s.hydrator.Posts(ctx, posts, viewerID)
+}
+
+// Insights sums the performance of the page's posts over the last days;
+// managers only
+func (s *pageService) Insights(ctx context.Context, pageID, userID int64, days int) (*dto.PageInsights, error) {
+	page, err := s.page(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.requireRole(ctx, pageID, userID, types.PageRoleAnalyst); err != nil {
+		return nil, err
+	}
+
+	days = min(max(days, 1), MaxInsightDays)
+	to := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	from := to.AddDate(0, 0, -days)
+	stats, err := s.repo.DailyStats(ctx, pageID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byDay := make(map[string]*model.PostDailyStat, len(stats))
+	for _, stat := range stats {
+		byDay[stat.Day.UTC().Format(dto.DateLayout)] = stat
+	}
+
+	insights := &dto.PageInsights{
+		PageID:        pageID,
+		Since:         from.Format(dto.DateLayout),
+		Until:         to.Add(-time.Hour).Format(dto.DateLayout),
+		FollowerCount: page.FollowerCount,
+		PostCount:     page.PostCount,
+	}
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		d := dto.PostInsightDay{Day: day.Format(dto.DateLayout)}
+		if stat := byDay[d.Day]; stat != nil {
+			d.Impressions, d.Reach, d.Likes, d.Comments = stat.Impressions, stat.Reach, stat.Likes, stat.Comments
+			d.LinkClicks = stat.LinkClicks
+			d.EngagementRate = dto.EngagementRate(d.Likes+d.Comments, d.Impressions)
+		}
+		insights.Impressions += d.Impressions
+		insights.Reach += d.Reach
+		insights.Likes += d.Likes
+		insights.Comments += d.Comments
+		insights.LinkClicks += d.LinkClicks
+		insights.Daily = append(insights.Daily, d)
+	}
+	insights.EngagementRate = dto.EngagementRate(insights.Likes+insights.Comments, insights.Impressions)
+	return insights, nil
+}
+
+func (s *pageService) page(ctx context.Context, pageID int64) (*model.Page, error) {
+	page, err := s.repo.GetByID(ctx, pageID)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, ErrPageNotFound
+	}
+	return page, err
+}
+
+// keepAdmin refuses to demote or remove the page's last admin
+func (s *pageService) keepAdmin(ctx context.Context, target *model.PageManager) error {
+	if target.Role != types.PageRoleAdmin {
+		return nil
+	}
+	admins, err := s.repo.CountManagers(ctx, target.PageID, types.PageRoleAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to count page admins: %w", err)
+	}
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// manager returns the user's manager row, or nil when there is none
+func (s *pageService) manager(ctx context.Context, pageID, userID int64) (*model.PageManager, error) {
+	manager, err := s.repo.GetManager(ctx, pageID, userID)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load page manager: %w", err)
+	}
+	return manager, nil
+}
+
+// requireRole returns the user's manager row if it holds at least role
+func (s *pageService) requireRole(ctx context.Context, pageID, userID int64, role types.PageRole) (*model.PageManager, error) {
+	manager, err := s.manager(ctx, pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case manager == nil:
+		return nil, ErrNotManager
+	case manager.Role < role:
+		return nil, ErrForbidden
+	}
+	return manager, nil
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/phone/service"
+	securitysvc "github.com/ilhamosaurus/sns-platform/internal/module/security/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type PhoneHandler struct {
+	service  service.PhoneService
+	security securitysvc.SecurityService
+}
+
+func NewPhoneHandler(svc service.PhoneService, security securitysvc.SecurityService) *PhoneHandler {
+	return &PhoneHandler{service: svc, security: security}
+}
+
+// Register mounts the phone number and two-factor routes; they expect an authenticated user in the request context
+func (h *PhoneHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /me/phone", h.Add)
+	mux.HandleFunc("POST /me/phone/verify", h.Verify)
+	mux.HandleFunc("DELETE /me/phone", h.Remove)
+	mux.HandleFunc("PUT /me/two-factor", h.SetTwoFactor)
+}
+
+// Add texts a verification code to the number the caller wants to add; it
+// replaces their current number once verified
+func (h *PhoneHandler) Add(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		Phone string `json:"phone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	phone, ok := service.NormalizePhone(body.Phone)
+	if !ok {
+		httpx.Error(w, http.StatusBadRequest, "phone must be an international number starting with + and the country code")
+		return
+	}
+
+	if err := h.service.SendVerification(r.Context(), userID, phone); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusAccepted, map[string]any{"phone": phone, "expires_in": int(service.CodeTTL.Seconds())})
+}
+
+// Verify checks the texted code and makes the number the caller's
+func (h *PhoneHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Code == "" {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.Verify(r.Context(), userID, body.Code); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Remove drops the caller's phone number, turning off discovery by phone
+// and SMS two-factor sign-in
+func (h *PhoneHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	twoFactorDisabled, err := h.service.Remove(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	if twoFactorDisabled {
+		if _, err := h.security.Record(r.Context(), r, userID, types.SecurityEventTypeTwoFactorDisabled); err != nil {
+			httpx.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetTwoFactor turns SMS two-factor sign-in on or off for the caller
+func (h *PhoneHandler) SetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		SMS *bool `json:"sms"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.SMS == nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	changed, err := h.service.SetTwoFactor(r.Context(), userID, *body.SMS)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	if changed {
+		eventType := types.SecurityEventTypeTwoFactorDisabled
+		if *body.SMS {
+			eventType = types.SecurityEventTypeTwoFactorEnabled
+		}
+		if _, err := h.security.Record(r.Context(), r, userID, eventType); err != nil {
+			httpx.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"sms": *body.SMS})
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+type PhoneRepository interface {
+	SaveCode(ctx context.Context, code *model.PhoneCode) error
+	GetCode(ctx context.Context, userID int64, purpose types.PhoneCodePurpose) (*model.PhoneCode, error)
+	RecordAttempt(ctx context.Context, id int64) (attempts int, err error)
+	DeleteCode(ctx context.Context, id int64) error
+	PhoneTaken(ctx context.Context, phone string, exceptUserID int64) (bool, error)
+}
+
+func NewPhoneRepository(db *gorm.DB) PhoneRepository {
+	return &phoneRepository{db: db}
+}
+
+type phoneRepository struct {
+	db *gorm.DB
+}
+
+// SaveCode stores a code, replacing the one the user had for its purpose
+func (r *phoneRepository) SaveCode(ctx context.Context, code *model.PhoneCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().
+			Where("user_id = ? AND purpose = ?", code.UserID, code.Purpose).
+			Delete(&model.PhoneCode{}).Error
+		if err != nil {
+			return err
+		}
+		return apperr.Translate(tx.Create(code).Error, "phone code")
+	})
+}
+
+func (r *phoneRepository) GetCode(ctx context.Context, userID int64, purpose types.PhoneCodePurpose) (*model.PhoneCode, error) {
+	var code model.PhoneCode
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND purpose = ? AND deleted_at IS NULL", userID, purpose).
+		First(&code).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "phone code")
+	}
+	return &code, nil
+}
+
+// RecordAttempt counts an attempt at entering the code and returns the
+// count including it. The row stays locked from the increment to the read,
+// so concurrent attempts each see a count of their own.
+func (r *phoneRepository) RecordAttempt(ctx context.Context, id int64) (int, error) {
+	var attempts int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.PhoneCode{}).
+			Where("id = ?", id).
+			UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apperr.NotFound("phone code not found")
+		}
+		return tx.Model(&model.PhoneCode{}).Where("id = ?", id).Pluck("attempts", &attempts).Error
+	})
+	return attempts, err
+}
+
+// DeleteCode removes a code outright once it is used up
+func (r *phoneRepository) DeleteCode(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Unscoped().Where("id = ?", id).Delete(&model.PhoneCode{}).Error
+}
+
+// PhoneTaken reports whether another active user has verified the phone number
+func (r *phoneRepository) PhoneTaken(ctx context.Context, phone string, exceptUserID int64) (bool, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("phone = ? AND phone_verified_at IS NOT NULL AND id <> ? AND deleted_at IS NULL", phone, exceptUserID).
+		Limit(1).
+		Pluck("id", &ids).Error
+	return len(ids) > 0, err
+}
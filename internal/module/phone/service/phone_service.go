@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/phone/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/i18n"
+	"github.com/ilhamosaurus/sns-platform/pkg/sms"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+const (
+	CodeTTL     = 10 * time.Minute
+	MaxAttempts = 5           // Wrong codes entered before a code is voided
+	ResendAfter = time.Minute // Least time between two codes for the same purpose
+)
+
+var (
+	ErrCodeInvalid     = apperr.Forbidden("code is wrong or has expired")
+	ErrResendTooSoon   = apperr.TooMany("wait a minute before asking for another code")
+	ErrPhoneVerified   = apperr.Conflict("phone number is already verified")
+	ErrPhoneTaken      = apperr.Conflict("phone number is verified by another account")
+	ErrNoVerifiedPhone = apperr.Conflict("verify a phone number first")
+)
+
+type Config struct {
+	AppName string // Named in the texts
+}
+
+// PhoneService verifies users' phone numbers with texted one-time codes and
+// sends and checks the codes of SMS two-factor sign-in. Codes are stored
+// hashed and void after MaxAttempts wrong entries.
+type PhoneService interface {
+	SendVerification(ctx context.Context, userID int64, phone string) error
+	Verify(ctx context.Context, userID int64, code string) error
+	Remove(ctx context.Context, userID int64) (twoFactorDisabled bool, err error)
+	SetTwoFactor(ctx context.Context, userID int64, enabled bool) (changed bool, err error)
+	SendSignInCode(ctx context.Context, userID int64) error
+	VerifySignInCode(ctx context.Context, userID int64, code string) error
+}
+
+type phoneService struct {
+	repo     repository.PhoneRepository
+	userRepo userrepo.UserRepository
+	sender   sms.Sender
+	catalog  *i18n.Catalog
+	config   Config
+}
+
+func NewPhoneService(repo repository.PhoneRepository, userRepo userrepo.UserRepository, sender sms.Sender, catalog *i18n.Catalog, config Config) PhoneService {
+	return &phoneService{repo: repo, userRepo: userRepo, sender: sender, catalog: catalog, config: config}
+}
+
+// SendVerification texts a code to phone, an E.164 number; the number
+// becomes the user's once Verify is given the code
+func (s *phoneService) SendVerification(ctx context.Context, userID int64, phone string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.HasVerifiedPhone() && user.Phone == phone {
+		return ErrPhoneVerified
+	}
+	taken, err := s.repo.PhoneTaken(ctx, phone, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check phone number: %w", err)
+	}
+	if taken {
+		return ErrPhoneTaken
+	}
+	return s.send(ctx, user, types.PhoneCodePurposeVerify, phone)
+}
+
+// Verify sets the number the code was sent to as the user's verified phone
+func (s *phoneService) Verify(ctx context.Context, userID int64, code string) error {
+	sent, err := s.check(ctx, userID, types.PhoneCodePurposeVerify, code)
+	if err != nil {
+		return err
+	}
+	// Another account may have verified it while the code was on its way
+	taken, err := s.repo.PhoneTaken(ctx, sent.Phone, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check phone number: %w", err)
+	}
+	if taken {
+		return ErrPhoneTaken
+	}
+	return s.userRepo.Update(ctx, userID, map[string]any{"phone": sent.Phone, "phone_verified_at": time.Now().UTC()})
+}
+
+// Remove drops the user's phone number, and with it discovery by phone and
+// SMS two-factor sign-in
+func (s *phoneService) Remove(ctx context.Context, userID int64) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	err = s.userRepo.Update(ctx, userID, map[string]any{
+		"phone":                 "",
+		"phone_verified_at":     nil,
+		"discoverable_by_phone": false,
+		"two_factor_sms":        false,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to remove phone number: %w", err)
+	}
+	return user.TwoFactorSMS, nil
+}
+
+// SetTwoFactor turns SMS two-factor sign-in on or off; turning it on needs a
+// verified phone
+func (s *phoneService) SetTwoFactor(ctx context.Context, userID int64, enabled bool) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if user.TwoFactorSMS == enabled {
+		return false, nil
+	}
+	if enabled && !user.HasVerifiedPhone() {
+		return false, ErrNoVerifiedPhone
+	}
+	if err := s.userRepo.Update(ctx, userID, map[string]any{"two_factor_sms": enabled}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SendSignInCode texts the second factor of a sign-in to the user's phone
+func (s *phoneService) SendSignInCode(ctx context.Context, userID int64) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.HasVerifiedPhone() {
+		return ErrNoVerifiedPhone
+	}
+	return s.send(ctx, user, types.PhoneCodePurposeSignIn, user.Phone)
+}
+
+// VerifySignInCode checks the second factor of a sign-in
+func (s *phoneService) VerifySignInCode(ctx context.Context, userID int64, code string) error {
+	_, err := s.check(ctx, userID, types.PhoneCodePurposeSignIn, code)
+	return err
+}
+
+// NormalizePhone returns a phone number in E.164, e.g. "+15551234567", with
+// the spaces, dashes, dots and parentheses people write it with removed; ok
+// is false for anything else
+func NormalizePhone(phone string) (normalized string, ok bool) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", false
+		}
+	}
+	normalized = b.String()
+	// E.164 numbers carry a country code, which never starts with 0, and at
+	// most 15 digits
+	if len(normalized) < 8 || len(normalized) > 16 || normalized[0] != '+' || normalized[1] == '0' {
+		return "", false
+	}
+	return normalized, true
+}
+
+// send texts a new code for purpose, replacing the user's previous one. A
+// code that could not be sent is dropped so the user can ask again at once.
+func (s *phoneService) send(ctx context.Context, user *model.User, purpose types.PhoneCodePurpose, phone string) error {
+	previous, err := s.repo.GetCode(ctx, user.ID, purpose)
+	switch {
+	case err == nil:
+		if time.Since(previous.CreatedAt) < ResendAfter {
+			return ErrResendTooSoon
+		}
+	case !errors.Is(err, apperr.ErrNotFound):
+		return err
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	sent := &model.PhoneCode{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Phone:     phone,
+		CodeHash:  hashCode(user.ID, purpose, code),
+		ExpiresAt: time.Now().UTC().Add(CodeTTL),
+	}
+	if err := s.repo.SaveCode(ctx, sent); err != nil {
+		return fmt.Errorf("failed to save code: %w", err)
+	}
+
+	text := s.catalog.Render(user.Language, "sms."+purpose.String(), map[string]string{
+		"code":    code,
+		"app":     s.config.AppName,
+		"minutes": strconv.Itoa(int(CodeTTL / time.Minute)),
+	})
+	if err := s.sender.Send(ctx, phone, text); err != nil {
+		if err := s.repo.DeleteCode(ctx, sent.ID); err != nil {
+			return fmt.Errorf("failed to drop unsent code: %w", err)
+		}
+		return fmt.Errorf("failed to text code: %w", err)
+	}
+	return nil
+}
+
+// check matches code against the user's code for purpose and uses it up.
+// The attempt is counted before the code is compared, so concurrent guesses
+// cannot get past MaxAttempts between the count and the comparison.
+func (s *phoneService) check(ctx context.Context, userID int64, purpose types.PhoneCodePurpose, code string) (*model.PhoneCode, error) {
+	sent, err := s.repo.GetCode(ctx, userID, purpose)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, ErrCodeInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(sent.ExpiresAt) {
+		if err := s.repo.DeleteCode(ctx, sent.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrCodeInvalid
+	}
+
+	attempts, err := s.repo.RecordAttempt(ctx, sent.ID)
+	if errors.Is(err, apperr.ErrNotFound) {
+		// Used up or replaced since it was read
+		return nil, ErrCodeInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if attempts > MaxAttempts {
+		if err := s.repo.DeleteCode(ctx, sent.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrCodeInvalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashCode(userID, purpose, code)), []byte(sent.CodeHash)) != 1 {
+		return nil, ErrCodeInvalid
+	}
+	if err := s.repo.DeleteCode(ctx, sent.ID); err != nil {
+		return nil, err
+	}
+	return sent, nil
+}
+
+// hashCode binds a code to its user and purpose, so it cannot be replayed
+// for another
+func hashCode(userID int64, purpose types.PhoneCodePurpose, code string) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d:%s:%s", userID, purpose, code))
+	return hex.EncodeToString(sum[:])
+}
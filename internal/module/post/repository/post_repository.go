@@ -4,25 +4,50 @@ import (
 	"context"
 
 	"github.com/ilhamosaurus/sns-platform/internal/model"
+	closefriendrepo "github.com/ilhamosaurus/sns-platform/internal/module/closefriend/repository"
+	orgrepo "github.com/ilhamosaurus/sns-platform/internal/module/organization/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
 	"github.com/ilhamosaurus/sns-platform/pkg/types"
 	"gorm.io/gorm"
 )
 
 type PostRepository interface {
 	Create(ctx context.Context, post *model.Post) error
+	CreateBatch(ctx context.Context, posts []*model.Post, batchSize int) error
 	Update(ctx context.Context, id int64, updates map[string]any) error
 	GetByID(ctx context.Context, id int64) (*model.Post, error)
-	List(ctx context.Context, query map[string]any, page, pageSize int) ([]*model.Post, int64, error)
+	List(ctx context.Context, query map[string]any, page, pageSize int, opts ...pkgdb.ListOption) ([]*model.Post, int64, error)
+	ListByAuthor(ctx context.Context, authorID, viewerID int64, limit, offset int) ([]*model.Post, error)
 	Delete(ctx context.Context, id int64) error
 	UpdatePostCount(ctx context.Context, id int64, action types.Action) error
 }
 
+func NewPostRepository(db *gorm.DB) PostRepository {
+	return &postRepository{db: db}
+}
+
 type postRepository struct {
 	db *gorm.DB
 }
 
 func (r *postRepository) Create(ctx context.Context, post *model.Post) error {
-	return r.db.WithContext(ctx).Create(post).Error
+	db := r.db.WithContext(ctx)
+	if err := model.SetAuthorRegions(db, post); err != nil {
+		return err
+	}
+	return apperr.Translate(db.Create(post).Error, "post")
+}
+
+// CreateBatch inserts posts batchSize rows per statement (zero for the configured default)
+func (r *postRepository) CreateBatch(ctx context.Context, posts []*model.Post, batchSize int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := model.SetAuthorRegions(tx, posts...); err != nil {
+			return err
+		}
+		return apperr.Translate(pkgdb.CreateInBatches(ctx, tx, posts, batchSize), "post")
+	})
 }
 
 func (r *postRepository) Update(ctx context.Context, id int64, updates map[string]any) error {
@@ -32,16 +57,15 @@ func (r *postRepository) Update(ctx context.Context, id int64, updates map[strin
 func (r *postRepository) GetByID(ctx context.Context, id int64) (*model.Post, error) {
 	var post model.Post
 	if err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&post).Error; err != nil {
-		return nil, err
+		return nil, apperr.Translate(err, "post")
 	}
 	return &post, nil
 }
 
-func (r *postRepository) List(ctx context.Context, query map[string]any, page, pageSize int) ([]*model.Post, int64, error) {
-	var (
-		posts      []*model.Post
-		totalCount int64
-	)
+// List returns a page of posts matching query with their total, counted as
+// selected by opts (exact by default, NoCount when omitted)
+func (r *postRepository) List(ctx context.Context, query map[string]any, page, pageSize int, opts ...pkgdb.ListOption) ([]*model.Post, int64, error) {
+	var posts []*model.Post
 
 	db := r.db.WithContext(ctx).Model(&model.Post{}).Where("deleted_at IS NULL")
 
@@ -49,7 +73,8 @@ func (r *postRepository) List(ctx context.Context, query map[string]any, page, p
 		db = db.Where(key, value)
 	}
 
-	if err := db.Count(&totalCount).Error; err != nil {
+	totalCount, err := pkgdb.CountRows(ctx, db, "posts", len(query) > 0, pkgdb.NewListOptions(opts...).Count)
+	if err != nil {
 		return nil, 0, err
 	}
 
@@ -60,6 +85,22 @@ func (r *postRepository) List(ctx context.Context, query map[string]any, page, p
 	return posts, totalCount, nil
 }
 
+// ListByAuthor returns the posts on an author's profile that viewerID is allowed to see
+func (r *postRepository) ListByAuthor(ctx context.Context, authorID, viewerID int64, limit, offset int) ([]*model.Post, error) {
+	var posts []*model.Post
+	err := r.db.WithContext(ctx).
+		Where("posts.user_id = ? AND posts.community_id IS NULL AND posts.page_id IS NULL AND posts.deleted_at IS NULL", authorID).
+		Scopes(closefriendrepo.VisibleTo(viewerID), orgrepo.VisibleTo(viewerID), userrepo.ActiveAuthors("posts.user_id")).
+		Order("posts.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
 func (r *postRepository) Delete(ctx context.Context, id int64) error {
 	return r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).Delete(&model.Post{}).Error
 }
@@ -72,13 +113,13 @@ func (r *postRepository) UpdatePostCount(ctx context.Context, id int64, action t
 		expr = column + " + 1"
 	case types.ActionUnliked:
 		column = "like_count"
-		expr = "GREATEST(" + column + " - 1, 0)"
+		expr = "CASE WHEN " + column + " > 0 THEN " + column + " - 1 ELSE 0 END"
 	case types.ActionCommented:
 		column = "comment_count"
 		expr = column + " + 1"
 	case types.ActionUncommented:
 		column = "comment_count"
-		expr = "GREATEST(" + column + " - 1, 0)"
+		expr = "CASE WHEN " + column + " > 0 THEN " + column + " - 1 ELSE 0 END"
 	case types.ActionShared:
 		column = "share_count"
 		expr = column + " + 1"
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/presence/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type PresenceHandler struct {
+	service service.PresenceService
+}
+
+func NewPresenceHandler(svc service.PresenceService) *PresenceHandler {
+	return &PresenceHandler{service: svc}
+}
+
+// Register mounts the presence routes; they expect an authenticated user in the request context
+func (h *PresenceHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /me/presence/heartbeat", h.Heartbeat)
+	mux.HandleFunc("DELETE /me/presence", h.Offline)
+}
+
+// Heartbeat keeps the caller online; clients send one more often than the
+// heartbeat TTL while they are open
+func (h *PresenceHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if err := h.service.Heartbeat(r.Context(), userID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Offline sets the caller offline right away, e.g. when they close the app
+func (h *PresenceHandler) Offline(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if err := h.service.SetOffline(r.Context(), userID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
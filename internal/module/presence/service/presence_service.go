@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	onlineKeyPrefix = "presence:online:"
+	lastSeenKey     = "presence:last_seen"
+	// expiriesKey scores every online user by when their heartbeat runs out,
+	// so the sweep finds the users who went offline without saying so
+	expiriesKey = "presence:expiries"
+
+	// ChangesChannel is the Redis channel online/offline transitions are
+	// published on for realtime subscribers
+	ChangesChannel = "presence:changes"
+
+	// DefaultHeartbeatTTL is how long a user stays online without a heartbeat
+	DefaultHeartbeatTTL = 60 * time.Second
+)
+
+// ChangeFunc is called when a user's online status flips
+type ChangeFunc func(ctx context.Context, presence *dto.Presence)
+
+type PresenceService interface {
+	Heartbeat(ctx context.Context, userID int64) error
+	SetOffline(ctx context.Context, userID int64) error
+	GetPresence(ctx context.Context, userIDs []int64) (map[int64]*dto.Presence, error)
+	OnChange(fn ChangeFunc)
+	Run(ctx context.Context)
+}
+
+type presenceService struct {
+	client   *redis.Client
+	ttl      time.Duration
+	onChange []ChangeFunc
+}
+
+func NewPresenceService(client *redis.Client, ttl time.Duration) PresenceService {
+	if ttl <= 0 {
+		ttl = DefaultHeartbeatTTL
+	}
+	return &presenceService{client: client, ttl: ttl}
+}
+
+// OnChange registers a listener for online/offline transitions
+func (s *presenceService) OnChange(fn ChangeFunc) {
+	s.onChange = append(s.onChange, fn)
+}
+
+// Heartbeat marks the user online for another TTL window and records last-seen
+func (s *presenceService) Heartbeat(ctx context.Context, userID int64) error {
+	now := time.Now().UTC()
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, onlineKey(userID), now.Unix(), s.ttl)
+	pipe.HSet(ctx, lastSeenKey, strconv.FormatInt(userID, 10), now.Unix())
+	added := pipe.ZAdd(ctx, expiriesKey, redis.Z{Score: float64(now.Add(s.ttl).Unix()), Member: userID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+
+	// Users stay in expiriesKey until they are swept or set offline, so only
+	// a user coming back from either is announced
+	if added.Val() > 0 {
+		s.emit(ctx, &dto.Presence{UserID: userID, Online: true, LastSeen: &now})
+	}
+	return nil
+}
+
+// SetOffline clears the online key immediately, e.g. on socket disconnect
+func (s *presenceService) SetOffline(ctx context.Context, userID int64) error {
+	now := time.Now().UTC()
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, onlineKey(userID))
+	pipe.HSet(ctx, lastSeenKey, strconv.FormatInt(userID, 10), now.Unix())
+	removed := pipe.ZRem(ctx, expiriesKey, userID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to set user offline: %w", err)
+	}
+
+	if removed.Val() > 0 {
+		s.emit(ctx, &dto.Presence{UserID: userID, Online: false, LastSeen: &now})
+	}
+	return nil
+}
+
+// GetPresence returns online status and last-seen time for each requested user
+func (s *presenceService) GetPresence(ctx context.Context, userIDs []int64) (map[int64]*dto.Presence, error) {
+	result := make(map[int64]*dto.Presence, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	keys := make([]string, len(userIDs))
+	fields := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		keys[i] = onlineKey(id)
+		fields[i] = strconv.FormatInt(id, 10)
+	}
+
+	pipe := s.client.Pipeline()
+	online := pipe.MGet(ctx, keys...)
+	lastSeen := pipe.HMGet(ctx, lastSeenKey, fields...)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to fetch presence: %w", err)
+	}
+
+	onlineVals := online.Val()
+	lastSeenVals := lastSeen.Val()
+	for i, id := range userIDs {
+		p := &dto.Presence{UserID: id, Online: onlineVals[i] != nil}
+		if raw, ok := lastSeenVals[i].(string); ok {
+			if ts, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				t := time.Unix(ts, 0).UTC()
+				p.LastSeen = &t
+			}
+		}
+		result[id] = p
+	}
+
+	return result, nil
+}
+
+// popExpired atomically takes the users whose heartbeat ran out by ARGV[1]
+// off KEYS[1], so each is swept by one instance only
+var popExpired = redis.NewScript(`
+local users = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "WITHSCORES")
+if #users > 0 then
+	redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+end
+return users
+`)
+
+// Run announces users whose heartbeat ran out as offline until ctx is
+// cancelled, checking twice per TTL
+func (s *presenceService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.ttl / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.sweep(ctx); err != nil {
+				slog.WarnContext(ctx, "failed to sweep presence", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// sweep emits an offline change for every user whose heartbeat ran out,
+// last seen at their final heartbeat
+func (s *presenceService) sweep(ctx context.Context) error {
+	res, err := popExpired.Run(ctx, s.client, []string{expiriesKey}, time.Now().Unix()).StringSlice()
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(res); i += 2 {
+		userID, err := strconv.ParseInt(res[i], 10, 64)
+		if err != nil {
+			continue
+		}
+		expiry, err := strconv.ParseFloat(res[i+1], 64)
+		if err != nil {
+			continue
+		}
+		lastSeen := time.Unix(int64(expiry), 0).Add(-s.ttl).UTC()
+		s.emit(ctx, &dto.Presence{UserID: userID, Online: false, LastSeen: &lastSeen})
+	}
+	return nil
+}
+
+func (s *presenceService) emit(ctx context.Context, presence *dto.Presence) {
+	payload, err := json.Marshal(presence)
+	if err == nil {
+		err = s.client.Publish(ctx, ChangesChannel, payload).Err()
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to publish presence change", slog.Int64("user_id", presence.UserID), slog.Any("error", err))
+	}
+
+	for _, fn := range s.onChange {
+		fn(ctx, presence)
+	}
+}
+
+func onlineKey(userID int64) string {
+	return onlineKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+// PublishTo returns a ChangeFunc that forwards transitions to the event bus
+func PublishTo(bus eventbus.Bus) ChangeFunc {
+	return func(ctx context.Context, presence *dto.Presence) {
+		evt := event.PresenceChanged{UserID: presence.UserID, Online: presence.Online}
+		if presence.LastSeen != nil {
+			evt.LastSeen = *presence.LastSeen
+		}
+		if err := bus.Publish(ctx, evt); err != nil {
+			slog.WarnContext(ctx, "failed to publish presence change", slog.Int64("user_id", presence.UserID), slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/profilevisit/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type ProfileVisitHandler struct {
+	service service.ProfileVisitService
+}
+
+func NewProfileVisitHandler(svc service.ProfileVisitService) *ProfileVisitHandler {
+	return &ProfileVisitHandler{service: svc}
+}
+
+// Register mounts the profile visit routes; they expect an authenticated user in the request context
+func (h *ProfileVisitHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/profile-visits", h.List)
+	mux.HandleFunc("PUT /me/profile-visits/settings", h.UpdateSettings)
+}
+
+// List returns who viewed the caller's profile, most recent first
+func (h *ProfileVisitHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	visitors, err := h.service.Visitors(r.Context(), userID, httpx.QueryInt(r, "days", 30), limit, offset)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"visitors": visitors})
+}
+
+// UpdateSettings turns profile visit tracking on or off for the caller
+func (h *ProfileVisitHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Enabled == nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.SetEnabled(r.Context(), userID, *body.Enabled); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"enabled": *body.Enabled})
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+type ProfileVisitRepository interface {
+	Record(ctx context.Context, profileID, viewerID int64, day time.Time) error
+	ListVisitors(ctx context.Context, profileID int64, since time.Time, limit, offset int) ([]*dto.ProfileVisitor, error)
+}
+
+func NewProfileVisitRepository(db *gorm.DB) ProfileVisitRepository {
+	return &profileVisitRepository{db: db}
+}
+
+type profileVisitRepository struct {
+	db *gorm.DB
+}
+
+// Record stores a visit on the UTC day containing day; repeat visits on the
+// same day are a no-op
+func (r *profileVisitRepository) Record(ctx context.Context, profileID, viewerID int64, day time.Time) error {
+	visit := model.ProfileVisit{ProfileID: profileID, ViewerID: viewerID, Day: day.UTC().Truncate(24 * time.Hour)}
+	err := apperr.Translate(r.db.WithContext(ctx).
+		Where("profile_id = ? AND viewer_id = ? AND day = ?", visit.ProfileID, visit.ViewerID, visit.Day).
+		FirstOrCreate(&visit).Error, "profile visit")
+	// A concurrent report of the same visit won the insert
+	if errors.Is(err, apperr.ErrConflict) {
+		return nil
+	}
+	return err
+}
+
+// ListVisitors returns the accounts that visited the profile on or after
+// since, most recent first. Viewers who have since turned tracking off are
+// left out.
+func (r *profileVisitRepository) ListVisitors(ctx context.Context, profileID int64, since time.Time, limit, offset int) ([]*dto.ProfileVisitor, error) {
+	// Visits are inserted in day order, so each viewer's highest ID is their latest visit
+	var rows []struct {
+		LastID    int64
+		VisitDays int64
+	}
+	err := r.db.WithContext(ctx).Table("profile_visits").
+		Select("MAX(profile_visits.id) AS last_id, COUNT(*) AS visit_days").
+		Joins("INNER JOIN users ON users.id = profile_visits.viewer_id AND users.deleted_at IS NULL").
+		Where("profile_visits.profile_id = ? AND profile_visits.day >= ? AND profile_visits.deleted_at IS NULL", profileID, since).
+		Where("users.profile_visits_enabled = ?", true).
+		Group("profile_visits.viewer_id").
+		Order("last_id DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profile visitors: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		ids[i] = row.LastID
+	}
+	var visits []*model.ProfileVisit
+	if err := r.db.WithContext(ctx).Preload("Viewer").Where("id IN ?", ids).Find(&visits).Error; err != nil {
+		return nil, fmt.Errorf("failed to load profile visitors: %w", err)
+	}
+	byID := make(map[int64]*model.ProfileVisit, len(visits))
+	for _, visit := range visits {
+		byID[visit.ID] = visit
+	}
+
+	visitors := make([]*dto.ProfileVisitor, 0, len(rows))
+	for _, row := range rows {
+		if visit := byID[row.LastID]; visit != nil && visit.Viewer != nil {
+			visitors = append(visitors, &dto.ProfileVisitor{
+				User:        dto.NewUserResponse(visit.Viewer),
+				LastVisitOn: visit.Day.UTC().Format(dto.DateLayout),
+				VisitDays:   row.VisitDays,
+			})
+		}
+	}
+	return visitors, nil
+}
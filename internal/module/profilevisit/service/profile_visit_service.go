@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/profilevisit/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+)
+
+// MaxVisitorDays is how far back the visitor list reaches
+const MaxVisitorDays = 90
+
+// ErrTrackingDisabled is returned when a user who has not opted in asks for
+// their visitors
+var ErrTrackingDisabled = apperr.Forbidden("profile visit tracking is turned off")
+
+type ProfileVisitService interface {
+	Record(ctx context.Context, viewerID, profileID int64) error
+	Visitors(ctx context.Context, ownerID int64, days, limit, offset int) ([]*dto.ProfileVisitor, error)
+	SetEnabled(ctx context.Context, userID int64, enabled bool) error
+}
+
+type profileVisitService struct {
+	repo     repository.ProfileVisitRepository
+	userRepo userrepo.UserRepository
+	audit    auditsvc.AuditService
+}
+
+func NewProfileVisitService(repo repository.ProfileVisitRepository, userRepo userrepo.UserRepository, audit auditsvc.AuditService) ProfileVisitService {
+	return &profileVisitService{repo: repo, userRepo: userRepo, audit: audit}
+}
+
+// Record notes that the viewer opened the profile today. Nothing is stored
+// unless both accounts have opted in, so turning tracking off also stops
+// one's own visits from showing up elsewhere.
+func (s *profileVisitService) Record(ctx context.Context, viewerID, profileID int64) error {
+	if viewerID == profileID {
+		return nil
+	}
+	for _, id := range []int64{profileID, viewerID} {
+		user, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !user.ProfileVisitsEnabled {
+			return nil
+		}
+	}
+	return s.repo.Record(ctx, profileID, viewerID, time.Now().UTC())
+}
+
+// Visitors lists who viewed the owner's profile over the last days
+func (s *profileVisitService) Visitors(ctx context.Context, ownerID int64, days, limit, offset int) ([]*dto.ProfileVisitor, error) {
+	owner, err := s.userRepo.GetByID(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if !owner.ProfileVisitsEnabled {
+		return nil, ErrTrackingDisabled
+	}
+
+	days = min(max(days, 1), MaxVisitorDays)
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+	return s.repo.ListVisitors(ctx, ownerID, since, limit, offset)
+}
+
+// SetEnabled opts the user in to or out of profile visit tracking
+func (s *profileVisitService) SetEnabled(ctx context.Context, userID int64, enabled bool) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.ProfileVisitsEnabled == enabled {
+		return nil
+	}
+	if err := s.userRepo.Update(ctx, userID, map[string]any{"profile_visits_enabled": enabled}); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, &model.AuditLog{
+		Action:     model.AuditPrivacyChange,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userID,
+		Before:     map[string]any{"profile_visits_enabled": user.ProfileVisitsEnabled},
+		After:      map[string]any{"profile_visits_enabled": enabled},
+	})
+	return nil
+}
+
+// RecordOnProfileViews records a visit for every profile view reported
+// through the events endpoint
+func RecordOnProfileViews(bus eventbus.Bus, svc ProfileVisitService) {
+	eventbus.On(bus, func(ctx context.Context, e event.EngagementReported) error {
+		if e.TenantID != nil {
+			ctx = tenant.WithID(ctx, *e.TenantID)
+		}
+		for _, engagement := range e.Engagements {
+			if engagement.Type != event.EngagementProfileView {
+				continue
+			}
+			if err := svc.Record(ctx, e.ViewerID, engagement.UserID); err != nil && !errors.Is(err, apperr.ErrNotFound) {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/reaction/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type ReactionHandler struct {
+	service service.ReactionService
+}
+
+func NewReactionHandler(svc service.ReactionService) *ReactionHandler {
+	return &ReactionHandler{service: svc}
+}
+
+// Register mounts the reaction routes; they expect an authenticated user in the request context
+func (h *ReactionHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("PUT /posts/{id}/reaction", h.ReactToPost)
+	mux.HandleFunc("DELETE /posts/{id}/reaction", h.UnreactFromPost)
+	mux.HandleFunc("PUT /comments/{id}/reaction", h.ReactToComment)
+	mux.HandleFunc("DELETE /comments/{id}/reaction", h.UnreactFromComment)
+}
+
+// ReactToPost leaves the caller's reaction on a post
+func (h *ReactionHandler) ReactToPost(w http.ResponseWriter, r *http.Request) {
+	h.react(w, r, false)
+}
+
+// ReactToComment leaves the caller's reaction on a comment
+func (h *ReactionHandler) ReactToComment(w http.ResponseWriter, r *http.Request) {
+	h.react(w, r, true)
+}
+
+// UnreactFromPost removes the caller's reaction from a post
+func (h *ReactionHandler) UnreactFromPost(w http.ResponseWriter, r *http.Request) {
+	h.unreact(w, r, false)
+}
+
+// UnreactFromComment removes the caller's reaction from a comment
+func (h *ReactionHandler) UnreactFromComment(w http.ResponseWriter, r *http.Request) {
+	h.unreact(w, r, true)
+}
+
+func (h *ReactionHandler) react(w http.ResponseWriter, r *http.Request, onComment bool) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	postID, commentID, ok := target(w, r, onComment)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Type types.ReactionType `json:"type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Type == types.ReactionTypeUnknown || body.Type > types.ReactionTypeAngry {
+		httpx.Error(w, http.StatusBadRequest, "type must be like, love, haha, wow, sad or angry")
+		return
+	}
+
+	reaction := &model.Reaction{UserID: userID, PostID: postID, CommentID: commentID, Type: body.Type}
+	if err := h.service.React(r.Context(), reaction); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"type": reaction.Type})
+}
+
+func (h *ReactionHandler) unreact(w http.ResponseWriter, r *http.Request, onComment bool) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	postID, commentID, ok := target(w, r, onComment)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Unreact(r.Context(), userID, postID, commentID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// target reads the id of the post or comment a reaction route is for,
+// answering bad request if it is invalid
+func target(w http.ResponseWriter, r *http.Request, onComment bool) (postID, commentID *int64, ok bool) {
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid id")
+		return nil, nil, false
+	}
+	if onComment {
+		return nil, &id, true
+	}
+	return &id, nil, true
+}
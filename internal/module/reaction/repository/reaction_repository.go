@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+type ReactionRepository interface {
+	React(ctx context.Context, reaction *model.Reaction) (bool, error)
+	Unreact(ctx context.Context, userID int64, postID, commentID *int64) error
+}
+
+func NewReactionRepository(db *gorm.DB) ReactionRepository {
+	return &reactionRepository{db: db}
+}
+
+type reactionRepository struct {
+	db *gorm.DB
+}
+
+// React stores the user's reaction to a post or comment, replacing the type
+// of one they already left. It reports whether the reaction is new, in which
+// case it is counted on its target.
+func (r *reactionRepository) React(ctx context.Context, reaction *model.Reaction) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing model.Reaction
+		err := target(tx, reaction.UserID, reaction.PostID, reaction.CommentID).First(&existing).Error
+		if err == nil {
+			reaction.ID = existing.ID
+			reaction.CreatedAt = existing.CreatedAt
+			return tx.Model(&existing).UpdateColumn("type", reaction.Type).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := tx.Create(reaction).Error; err != nil {
+			return apperr.Translate(err, "reaction")
+		}
+		created = true
+		return count(tx, reaction.PostID, reaction.CommentID, true)
+	})
+	return created, err
+}
+
+// Unreact deletes the user's reaction to a post or comment and takes it off
+// the target's count
+func (r *reactionRepository) Unreact(ctx context.Context, userID int64, postID, commentID *int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := target(tx, userID, postID, commentID).Unscoped().Delete(&model.Reaction{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apperr.NotFound("reaction not found")
+		}
+		return count(tx, postID, commentID, false)
+	})
+}
+
+// target scopes tx to the user's reaction on a post or a comment
+func target(tx *gorm.DB, userID int64, postID, commentID *int64) *gorm.DB {
+	q := tx.Model(&model.Reaction{}).Where("user_id = ?", userID)
+	if postID != nil {
+		return q.Where("post_id = ?", *postID)
+	}
+	return q.Where("comment_id = ?", *commentID)
+}
+
+// count moves the reaction count of a post or a comment up or down by one
+func count(tx *gorm.DB, postID, commentID *int64, up bool) error {
+	q, column := tx.Model(&model.Comment{}).Where("id = ?", commentID), "likes_count"
+	if postID != nil {
+		q, column = tx.Model(&model.Post{}).Where("id = ?", *postID), "like_count"
+	}
+	expr := column + " + 1"
+	if !up {
+		expr = "CASE WHEN " + column + " > 0 THEN " + column + " - 1 ELSE 0 END"
+	}
+	return q.UpdateColumn(column, gorm.Expr(expr)).Error
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	closefriendrepo "github.com/ilhamosaurus/sns-platform/internal/module/closefriend/repository"
+	commentrepo "github.com/ilhamosaurus/sns-platform/internal/module/comment/repository"
+	orgrepo "github.com/ilhamosaurus/sns-platform/internal/module/organization/repository"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/module/reaction/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+)
+
+type ReactionService interface {
+	React(ctx context.Context, reaction *model.Reaction) error
+	Unreact(ctx context.Context, userID int64, postID, commentID *int64) error
+}
+
+type reactionService struct {
+	repo         repository.ReactionRepository
+	postRepo     postrepo.PostRepository
+	commentRepo  commentrepo.CommentRepository
+	closeFriends closefriendrepo.CloseFriendRepository
+	orgs         orgrepo.OrganizationRepository
+	bus          eventbus.Bus
+}
+
+func NewReactionService(repo repository.ReactionRepository, postRepo postrepo.PostRepository, commentRepo commentrepo.CommentRepository, closeFriends closefriendrepo.CloseFriendRepository, orgs orgrepo.OrganizationRepository, bus eventbus.Bus) ReactionService {
+	return &reactionService{repo: repo, postRepo: postRepo, commentRepo: commentRepo, closeFriends: closeFriends, orgs: orgs, bus: bus}
+}
+
+// React leaves the user's reaction on a post or comment they can see,
+// replacing any reaction they left there before
+func (s *reactionService) React(ctx context.Context, reaction *model.Reaction) error {
+	if err := s.checkVisible(ctx, reaction.UserID, reaction.PostID, reaction.CommentID); err != nil {
+		return err
+	}
+	created, err := s.repo.React(ctx, reaction)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+
+	err = s.bus.Publish(ctx, event.ReactionAdded{
+		ReactionID: reaction.ID,
+		UserID:     reaction.UserID,
+		PostID:     reaction.PostID,
+		CommentID:  reaction.CommentID,
+		Type:       reaction.Type,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to publish reaction", slog.Int64("reaction_id", reaction.ID), slog.Any("error", err))
+	}
+	return nil
+}
+
+// Unreact removes the user's reaction from a post or comment
+func (s *reactionService) Unreact(ctx context.Context, userID int64, postID, commentID *int64) error {
+	return s.repo.Unreact(ctx, userID, postID, commentID)
+}
+
+// checkVisible returns a not found error unless the user can see the post,
+// or the post of the comment. Posts for close friends or an organization
+// are hidden from everyone else, as for comments.
+func (s *reactionService) checkVisible(ctx context.Context, userID int64, postID, commentID *int64) error {
+	if commentID != nil {
+		comment, err := s.commentRepo.GetByID(ctx, *commentID)
+		if err != nil {
+			return err
+		}
+		if comment.PendingApproval {
+			return apperr.NotFound("comment not found")
+		}
+		postID = &comment.PostID
+	}
+	post, err := s.postRepo.GetByID(ctx, *postID)
+	if err != nil {
+		return err
+	}
+	if post.CloseFriends && post.UserID != userID {
+		ok, err := s.closeFriends.IsCloseFriend(ctx, post.UserID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to check close friends: %w", err)
+		}
+		if !ok {
+			return apperr.NotFound("post not found")
+		}
+	}
+	if post.OrganizationID != nil {
+		if _, err := s.orgs.GetMember(ctx, *post.OrganizationID, userID); err != nil {
+			if errors.Is(err, apperr.ErrNotFound) {
+				return apperr.NotFound("post not found")
+			}
+			return fmt.Errorf("failed to check organization membership: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/module/repost/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type RepostHandler struct {
+	service service.RepostService
+}
+
+func NewRepostHandler(svc service.RepostService) *RepostHandler {
+	return &RepostHandler{service: svc}
+}
+
+// Register mounts the repost and share control routes; they expect an authenticated user in the request context
+func (h *RepostHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /posts/{id}/repost", h.Repost)
+	mux.HandleFunc("DELETE /posts/{id}/repost", h.Undo)
+	mux.HandleFunc("PUT /posts/{id}/share-controls", h.SetShareControls)
+}
+
+// Repost shares a post with the caller's followers
+func (h *RepostHandler) Repost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	postID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+
+	repost, err := h.service.Repost(r.Context(), userID, postID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, dto.NewPostResponse(repost))
+}
+
+// Undo removes the caller's repost of a post
+func (h *RepostHandler) Undo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	postID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+
+	if err := h.service.Undo(r.Context(), userID, postID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetShareControls turns reposts and embeds of one of the caller's posts on or off
+func (h *RepostHandler) SetShareControls(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	postID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+
+	var body service.ShareControls
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	post, err := h.service.SetShareControls(r.Context(), userID, postID, body)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, dto.NewPostResponse(post))
+}
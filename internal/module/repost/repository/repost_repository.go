@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+type RepostRepository interface {
+	Find(ctx context.Context, userID, originalID int64) (*model.Post, error)
+	Create(ctx context.Context, repost *model.Post) error
+	Delete(ctx context.Context, repost *model.Post) error
+}
+
+func NewRepostRepository(db *gorm.DB) RepostRepository {
+	return &repostRepository{db: db}
+}
+
+type repostRepository struct {
+	db *gorm.DB
+}
+
+// Find returns the user's repost of a post
+func (r *repostRepository) Find(ctx context.Context, userID, originalID int64) (*model.Post, error) {
+	var repost model.Post
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND repost_of_id = ? AND deleted_at IS NULL", userID, originalID).
+		First(&repost).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "repost")
+	}
+	return &repost, nil
+}
+
+// Create stores a repost and counts it as a share of the original and a post
+// of the reposter
+func (r *repostRepository) Create(ctx context.Context, repost *model.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := model.SetAuthorRegions(tx, repost); err != nil {
+			return err
+		}
+		if err := tx.Create(repost).Error; err != nil {
+			return apperr.Translate(err, "repost")
+		}
+		err := tx.Model(&model.Post{}).
+			Where("id = ? AND deleted_at IS NULL", *repost.RepostOfID).
+			UpdateColumn("share_count", gorm.Expr("share_count + 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).
+			Where("id = ? AND deleted_at IS NULL", repost.UserID).
+			UpdateColumn("post_count", gorm.Expr("post_count + 1")).Error
+	})
+}
+
+// Delete removes a repost and takes it off the share and post counts
+func (r *repostRepository) Delete(ctx context.Context, repost *model.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(repost).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&model.Post{}).
+			Where("id = ? AND deleted_at IS NULL", *repost.RepostOfID).
+			UpdateColumn("share_count", gorm.Expr("CASE WHEN share_count > 0 THEN share_count - 1 ELSE 0 END")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).
+			Where("id = ? AND deleted_at IS NULL", repost.UserID).
+			UpdateColumn("post_count", gorm.Expr("CASE WHEN post_count > 0 THEN post_count - 1 ELSE 0 END")).Error
+	})
+}
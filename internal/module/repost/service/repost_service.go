@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/module/repost/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// Errors returned when a post cannot be reposted
+var (
+	ErrRepostsDisabled = apperr.Forbidden("the author has turned off reposts of this post")
+	ErrNotRepostable   = apperr.Forbidden("only public posts outside communities can be reposted")
+)
+
+// ShareControls are the author's switches over how a post spreads; nil
+// fields are left unchanged
+type ShareControls struct {
+	RepostsDisabled *bool `json:"reposts_disabled"`
+	EmbedsDisabled  *bool `json:"embeds_disabled"`
+}
+
+type RepostService interface {
+	Repost(ctx context.Context, userID, postID int64) (*model.Post, error)
+	Undo(ctx context.Context, userID, postID int64) error
+	SetShareControls(ctx context.Context, userID, postID int64, controls ShareControls) (*model.Post, error)
+}
+
+type repostService struct {
+	repo     repository.RepostRepository
+	postRepo postrepo.PostRepository
+	bus      eventbus.Bus
+}
+
+func NewRepostService(repo repository.RepostRepository, postRepo postrepo.PostRepository, bus eventbus.Bus) RepostService {
+	return &repostService{repo: repo, postRepo: postRepo, bus: bus}
+}
+
+// Repost shares a post on the user's profile and followers' feeds. Reposting
+// a repost shares its original. Authors may turn reposts of a post off for
+// everyone but themselves.
+func (s *repostService) Repost(ctx context.Context, userID, postID int64) (*model.Post, error) {
+	original, err := s.original(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if !original.IsPublic || original.CloseFriends || original.CommunityID != nil {
+		return nil, ErrNotRepostable
+	}
+	if original.RepostsDisabled && original.UserID != userID {
+		return nil, ErrRepostsDisabled
+	}
+	if _, err := s.repo.Find(ctx, userID, original.ID); err == nil {
+		return nil, apperr.Conflict("post already reposted")
+	} else if !errors.Is(err, apperr.ErrNotFound) {
+		return nil, err
+	}
+
+	repost := &model.Post{
+		UserID:     userID,
+		RepostOfID: &original.ID,
+		MediaType:  types.MediaTypeText,
+		IsPublic:   true,
+	}
+	if err := s.repo.Create(ctx, repost); err != nil {
+		return nil, fmt.Errorf("failed to create repost: %w", err)
+	}
+	repost.RepostOf = original
+
+	if err := s.bus.Publish(ctx, event.PostCreated{
+		PostID:    repost.ID,
+		AuthorID:  userID,
+		IsPublic:  true,
+		CreatedAt: repost.CreatedAt,
+	}); err != nil {
+		slog.WarnContext(ctx, "failed to publish repost", slog.Int64("post_id", repost.ID), slog.Any("error", err))
+	}
+	return repost, nil
+}
+
+// Undo removes the user's repost of a post
+func (s *repostService) Undo(ctx context.Context, userID, postID int64) error {
+	original, err := s.original(ctx, postID)
+	if err != nil {
+		return err
+	}
+	repost, err := s.repo.Find(ctx, userID, original.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, repost); err != nil {
+		return fmt.Errorf("failed to delete repost: %w", err)
+	}
+	return nil
+}
+
+// SetShareControls lets the author turn reposts and embeds of a post on or off
+func (s *repostService) SetShareControls(ctx context.Context, userID, postID int64, controls ShareControls) (*model.Post, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.UserID != userID {
+		return nil, apperr.Forbidden("only the post author can change share controls")
+	}
+
+	updates := map[string]any{}
+	if controls.RepostsDisabled != nil {
+		updates["reposts_disabled"] = *controls.RepostsDisabled
+		post.RepostsDisabled = *controls.RepostsDisabled
+	}
+	if controls.EmbedsDisabled != nil {
+		updates["embeds_disabled"] = *controls.EmbedsDisabled
+		post.EmbedsDisabled = *controls.EmbedsDisabled
+	}
+	if len(updates) == 0 {
+		return post, nil
+	}
+	if err := s.postRepo.Update(ctx, postID, updates); err != nil {
+		return nil, fmt.Errorf("failed to update share controls: %w", err)
+	}
+	return post, nil
+}
+
+// original loads a post, following a repost to the post it shares
+func (s *repostService) original(ctx context.Context, postID int64) (*model.Post, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.RepostOfID == nil {
+		return post, nil
+	}
+	return s.postRepo.GetByID(ctx, *post.RepostOfID)
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/module/restriction/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type RestrictionHandler struct {
+	repo repository.RestrictionRepository
+}
+
+func NewRestrictionHandler(repo repository.RestrictionRepository) *RestrictionHandler {
+	return &RestrictionHandler{repo: repo}
+}
+
+// Register mounts the restriction routes; they expect an authenticated user in the request context
+func (h *RestrictionHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/restricted", h.List)
+	mux.HandleFunc("PUT /me/restricted/{userID}", h.Add)
+	mux.HandleFunc("DELETE /me/restricted/{userID}", h.Remove)
+}
+
+// List returns the users the caller has restricted
+func (h *RestrictionHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	users, err := h.repo.List(r.Context(), userID, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"users": dto.NewUserResponses(users)})
+}
+
+// Add restricts a user on the caller's account
+func (h *RestrictionHandler) Add(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	restrictedID, err := httpx.PathInt64(r, "userID")
+	if err != nil || restrictedID == userID {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.repo.Add(r.Context(), userID, restrictedID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Remove lifts a restriction; comments already awaiting approval stay pending
+func (h *RestrictionHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	restrictedID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.repo.Remove(r.Context(), userID, restrictedID); err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+type RestrictionRepository interface {
+	Add(ctx context.Context, userID, restrictedID int64) error
+	Remove(ctx context.Context, userID, restrictedID int64) error
+	List(ctx context.Context, userID int64, limit, offset int) ([]*model.User, error)
+	IsRestricted(ctx context.Context, userID, restrictedID int64) (bool, error)
+}
+
+func NewRestrictionRepository(db *gorm.DB) RestrictionRepository {
+	return &restrictionRepository{db: db}
+}
+
+type restrictionRepository struct {
+	db *gorm.DB
+}
+
+// Add restricts restrictedID on userID's account; restricting someone twice is a no-op
+func (r *restrictionRepository) Add(ctx context.Context, userID, restrictedID int64) error {
+	entry := model.Restriction{UserID: userID, RestrictedID: restrictedID}
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND restricted_id = ?", userID, restrictedID).
+		FirstOrCreate(&entry).Error
+	return apperr.Translate(err, "restriction")
+}
+
+// Remove deletes the entry outright so the pair can be restricted again later
+func (r *restrictionRepository) Remove(ctx context.Context, userID, restrictedID int64) error {
+	return r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND restricted_id = ?", userID, restrictedID).
+		Delete(&model.Restriction{}).Error
+}
+
+// List returns the users userID has restricted, most recently restricted first
+func (r *restrictionRepository) List(ctx context.Context, userID int64, limit, offset int) ([]*model.User, error) {
+	var users []*model.User
+	err := r.db.WithContext(ctx).Table("users").
+		Select("users.*").
+		Joins("INNER JOIN restrictions ON restrictions.restricted_id = users.id AND restrictions.deleted_at IS NULL").
+		Where("restrictions.user_id = ? AND users.deleted_at IS NULL", userID).
+		Order("restrictions.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *restrictionRepository) IsRestricted(ctx context.Context, userID, restrictedID int64) (bool, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&model.Restriction{}).
+		Where("user_id = ? AND restricted_id = ? AND deleted_at IS NULL", userID, restrictedID).
+		Limit(1).
+		Pluck("id", &ids).Error
+	return len(ids) > 0, err
+}
+
+// CommentsVisibleTo restricts a comments query to rows viewerID may see:
+// comments awaiting approval only reach their author and the post author,
+// and comments a community hid only their author
+func CommentsVisibleTo(viewerID int64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`(comments.pending_approval = ? OR comments.user_id = ? OR EXISTS (
+			SELECT 1 FROM posts
+			WHERE posts.id = comments.post_id
+				AND posts.user_id = ?))`, false, viewerID, viewerID).
+			Where("(comments.hidden_at IS NULL OR comments.user_id = ?)", viewerID)
+	}
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/scim/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/scim"
+)
+
+type SCIMHandler struct {
+	service   service.SCIMService
+	tokenHash [sha256.Size]byte
+}
+
+// NewSCIMHandler serves the SCIM API to identity providers presenting token
+func NewSCIMHandler(svc service.SCIMService, token string) *SCIMHandler {
+	return &SCIMHandler{service: svc, tokenHash: sha256.Sum256([]byte(token))}
+}
+
+// Register mounts the SCIM 2.0 routes under /scim/v2
+func (h *SCIMHandler) Register(mux *http.ServeMux) {
+	mux.Handle("GET /scim/v2/ServiceProviderConfig", h.authenticated(h.ServiceProviderConfig))
+	mux.Handle("GET /scim/v2/ResourceTypes", h.authenticated(h.ResourceTypes))
+	mux.Handle("GET /scim/v2/Users", h.authenticated(h.List))
+	mux.Handle("POST /scim/v2/Users", h.authenticated(h.Create))
+	mux.Handle("GET /scim/v2/Users/{id}", h.authenticated(h.Get))
+	mux.Handle("PUT /scim/v2/Users/{id}", h.authenticated(h.Replace))
+	mux.Handle("PATCH /scim/v2/Users/{id}", h.authenticated(h.Patch))
+	mux.Handle("DELETE /scim/v2/Users/{id}", h.authenticated(h.Delete))
+}
+
+// authenticated rejects requests without the provisioning bearer token
+func (h *SCIMHandler) authenticated(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+		if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare(sum[:], h.tokenHash[:]) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+			scim.WriteError(w, scim.NewError(http.StatusUnauthorized, "", "invalid or missing bearer token"))
+			return
+		}
+		next(w, r)
+	})
+}
+
+// ServiceProviderConfig advertises the supported features
+func (h *SCIMHandler) ServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	unsupported := map[string]bool{"supported": false}
+	scim.JSON(w, http.StatusOK, map[string]any{
+		"schemas":        []string{scim.SchemaServiceProviderConfig},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": service.MaxCount},
+		"changePassword": unsupported,
+		"sort":           unsupported,
+		"etag":           unsupported,
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Authentication with the provisioning token",
+			"primary":     true,
+		}},
+	})
+}
+
+// ResourceTypes lists the provisionable resources, which are users only
+func (h *SCIMHandler) ResourceTypes(w http.ResponseWriter, r *http.Request) {
+	scim.JSON(w, http.StatusOK, scim.ListResponse{
+		TotalResults: 1,
+		StartIndex:   1,
+		Resources: []any{map[string]any{
+			"schemas":  []string{scim.SchemaResourceType},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   scim.SchemaUser,
+		}},
+	})
+}
+
+// List returns a page of users. startIndex is 1-based; count defaults to
+// 100 and is capped at 200.
+func (h *SCIMHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter, err := scim.ParseFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		scim.WriteError(w, err)
+		return
+	}
+	startIndex := max(httpx.QueryInt(r, "startIndex", 1), 1)
+	count := min(max(httpx.QueryInt(r, "count", service.DefaultCount), 0), service.MaxCount)
+
+	resp, err := h.service.List(r.Context(), filter, startIndex, count)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	scim.JSON(w, http.StatusOK, resp)
+}
+
+func (h *SCIMHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	scim.JSON(w, http.StatusOK, user)
+}
+
+func (h *SCIMHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var resource scim.User
+	if !decode(w, r, &resource) {
+		return
+	}
+	user, err := h.service.Create(r.Context(), &resource)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", user.Meta.Location)
+	scim.JSON(w, http.StatusCreated, user)
+}
+
+func (h *SCIMHandler) Replace(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	var resource scim.User
+	if !decode(w, r, &resource) {
+		return
+	}
+	user, err := h.service.Replace(r.Context(), id, &resource)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	scim.JSON(w, http.StatusOK, user)
+}
+
+func (h *SCIMHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	var req scim.PatchRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	user, err := h.service.Patch(r.Context(), id, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	scim.JSON(w, http.StatusOK, user)
+}
+
+// Delete deprovisions the user
+func (h *SCIMHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userID parses the {id} path value. Ids are numeric, so any other value
+// names no user.
+func userID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		scim.WriteError(w, scim.NewError(http.StatusNotFound, "", "user not found"))
+		return 0, false
+	}
+	return id, true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var e *scim.Error
+		if !errors.As(err, &e) {
+			e = scim.NewError(http.StatusBadRequest, scim.ErrTypeInvalidSyntax, "invalid request body")
+		}
+		scim.WriteError(w, e)
+		return false
+	}
+	return true
+}
+
+// writeError maps service errors to SCIM errors; conflicts are uniqueness
+// violations of userName, email or externalId
+func writeError(w http.ResponseWriter, err error) {
+	var e *scim.Error
+	switch {
+	case errors.As(err, &e):
+	case errors.Is(err, apperr.ErrConflict):
+		e = scim.NewError(http.StatusConflict, scim.ErrTypeUniqueness, err.Error())
+	default:
+		e = scim.NewError(apperr.Status(err), "", err.Error())
+	}
+	scim.WriteError(w, e)
+}
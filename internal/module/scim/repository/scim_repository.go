@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+// Columns users can be looked up by
+const (
+	ColumnUsername   = "username"
+	ColumnEmail      = "email"
+	ColumnExternalID = "external_id"
+	ColumnID         = "id"
+)
+
+type SCIMRepository interface {
+	List(ctx context.Context, column, value string, offset, limit int) ([]*model.User, int64, error)
+	GetByID(ctx context.Context, id int64) (*model.User, error)
+	GetDeletedByExternalID(ctx context.Context, externalID string) (*model.User, error)
+	Create(ctx context.Context, user *model.User) error
+	Update(ctx context.Context, id int64, updates map[string]any) error
+	Restore(ctx context.Context, id int64, updates map[string]any) error
+	Suspend(ctx context.Context, id int64, at time.Time) error
+	Unsuspend(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id int64) error
+}
+
+func NewSCIMRepository(db *gorm.DB) SCIMRepository {
+	return &scimRepository{db: db}
+}
+
+type scimRepository struct {
+	db *gorm.DB
+}
+
+// List returns a page of users, oldest first, optionally only those whose
+// column equals value; usernames and emails compare without case
+func (r *scimRepository) List(ctx context.Context, column, value string, offset, limit int) ([]*model.User, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.User{}).Where("deleted_at IS NULL")
+	switch column {
+	case "":
+	case ColumnUsername, ColumnEmail:
+		db = db.Where("LOWER("+column+") = LOWER(?)", value)
+	default:
+		db = db.Where(column+" = ?", value)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []*model.User
+	err := db.Order("id ASC").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+func (r *scimRepository) GetByID(ctx context.Context, id int64) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&user).Error; err != nil {
+		return nil, apperr.Translate(err, "user")
+	}
+	return &user, nil
+}
+
+// GetDeletedByExternalID finds a deprovisioned user the identity provider
+// knows by externalID
+func (r *scimRepository) GetDeletedByExternalID(ctx context.Context, externalID string) (*model.User, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("external_id = ? AND deleted_at IS NOT NULL", externalID).
+		Order("deleted_at DESC").
+		First(&user).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "user")
+	}
+	return &user, nil
+}
+
+func (r *scimRepository) Create(ctx context.Context, user *model.User) error {
+	return apperr.Translate(r.db.WithContext(ctx).Create(user).Error, "user")
+}
+
+func (r *scimRepository) Update(ctx context.Context, id int64, updates map[string]any) error {
+	return apperr.Translate(r.db.WithContext(ctx).Model(&model.User{}).Where("id = ? AND deleted_at IS NULL", id).Updates(updates).Error, "user")
+}
+
+// Restore undeletes a deprovisioned user, applying updates
+func (r *scimRepository) Restore(ctx context.Context, id int64, updates map[string]any) error {
+	updates["deleted_at"] = nil
+	return apperr.Translate(r.db.WithContext(ctx).Unscoped().Model(&model.User{}).Where("id = ?", id).Updates(updates).Error, "user")
+}
+
+// Suspend deactivates the user on behalf of their identity provider
+func (r *scimRepository) Suspend(ctx context.Context, id int64, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]any{
+			"suspended_at":   at,
+			"deactivated_at": gorm.Expr("COALESCE(deactivated_at, ?)", at),
+		}).Error
+}
+
+// Unsuspend lifts a suspension and reactivates the account
+func (r *scimRepository) Unsuspend(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND suspended_at IS NOT NULL AND deleted_at IS NULL", id).
+		Updates(map[string]any{"suspended_at": nil, "deactivated_at": nil}).Error
+}
+
+// Delete deprovisions the user: the account is soft-deleted, so it can be
+// restored, and their single sign-on identities are unlinked
+func (r *scimRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND deleted_at IS NULL", id).Delete(&model.User{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apperr.NotFound("user not found")
+		}
+		return tx.Unscoped().Where("user_id = ?", id).Delete(&model.SSOIdentity{}).Error
+	})
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/scim/repository"
+	usersvc "github.com/ilhamosaurus/sns-platform/internal/module/user/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/langdetect"
+	"github.com/ilhamosaurus/sns-platform/pkg/scim"
+)
+
+// Limits on provisioned users and list pages
+const (
+	MaxUserNameLength = 50
+	DefaultCount      = 100
+	MaxCount          = 200
+)
+
+type Config struct {
+	BaseURL           string   // Absolute URL of /scim/v2, used in resource locations
+	StripEmailPlus    bool     // Passed to usersvc.NormalizeEmail
+	ReservedUsernames []string // Passed to usersvc.NewUsernamePolicy
+}
+
+// SCIMService provisions users on behalf of an identity provider. Users
+// are mapped onto accounts as follows: userName to username, the primary
+// email to email, displayName or name to full_name, the primary photo to
+// avatar_url, preferredLanguage or locale to language and active to the
+// suspension of the account.
+type SCIMService interface {
+	List(ctx context.Context, filter *scim.Filter, startIndex, count int) (*scim.ListResponse, error)
+	Get(ctx context.Context, id int64) (*scim.User, error)
+	Create(ctx context.Context, resource *scim.User) (*scim.User, error)
+	Replace(ctx context.Context, id int64, resource *scim.User) (*scim.User, error)
+	Patch(ctx context.Context, id int64, req *scim.PatchRequest) (*scim.User, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type scimService struct {
+	repo      repository.SCIMRepository
+	audit     auditsvc.AuditService
+	config    Config
+	usernames *usersvc.UsernamePolicy
+}
+
+func NewSCIMService(repo repository.SCIMRepository, audit auditsvc.AuditService, config Config) SCIMService {
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	return &scimService{repo: repo, audit: audit, config: config, usernames: usersvc.NewUsernamePolicy(config.ReservedUsernames)}
+}
+
+// List returns a page of users, optionally filtered by userName, an email,
+// externalId or id
+func (s *scimService) List(ctx context.Context, filter *scim.Filter, startIndex, count int) (*scim.ListResponse, error) {
+	var column, value string
+	if filter != nil {
+		value = filter.Value
+		switch filter.Attribute {
+		case "username":
+			column = repository.ColumnUsername
+		case "emails", "emails.value":
+			column = repository.ColumnEmail
+		case "externalid":
+			column = repository.ColumnExternalID
+		case "id":
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				return &scim.ListResponse{StartIndex: startIndex}, nil
+			}
+			column = repository.ColumnID
+		default:
+			return nil, scim.NewError(http.StatusBadRequest, scim.ErrTypeInvalidFilter, "users can be filtered by userName, emails, externalId or id")
+		}
+	}
+
+	users, total, err := s.repo.List(ctx, column, value, startIndex-1, count)
+	if err != nil {
+		return nil, err
+	}
+	resp := &scim.ListResponse{TotalResults: total, StartIndex: startIndex}
+	for _, user := range users {
+		resp.Resources = append(resp.Resources, s.resource(user))
+	}
+	return resp, nil
+}
+
+func (s *scimService) Get(ctx context.Context, id int64) (*scim.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.resource(user), nil
+}
+
+// Create provisions a user. A user deprovisioned earlier under the same
+// externalId is restored instead, with their content.
+func (s *scimService) Create(ctx context.Context, resource *scim.User) (*scim.User, error) {
+	attrs, err := s.parse(resource, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUsername(attrs.username, ""); err != nil {
+		return nil, err
+	}
+
+	if attrs.externalID != "" {
+		deleted, err := s.repo.GetDeletedByExternalID(ctx, attrs.externalID)
+		switch {
+		case err == nil:
+			return s.restore(ctx, deleted, attrs)
+		case !errors.Is(err, apperr.ErrNotFound):
+			return nil, err
+		}
+	}
+
+	// Provisioned accounts sign in through single sign-on and have no password
+	user := &model.User{
+		Username:   attrs.username,
+		Email:      attrs.email,
+		FullName:   attrs.fullName,
+		AvatarURL:  attrs.avatarURL,
+		Language:   attrs.language,
+		ExternalID: attrs.externalID,
+	}
+	if err := s.repo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	if attrs.active != nil && !*attrs.active {
+		if err := s.repo.Suspend(ctx, user.ID, time.Now().UTC()); err != nil {
+			return nil, err
+		}
+	}
+	s.record(ctx, model.AuditProvisionCreateUser, user.ID, nil, attrs.changes(nil))
+	return s.Get(ctx, user.ID)
+}
+
+func (s *scimService) restore(ctx context.Context, user *model.User, attrs *attributes) (*scim.User, error) {
+	if err := s.repo.Restore(ctx, user.ID, attrs.updates()); err != nil {
+		return nil, err
+	}
+	after := attrs.changes(user)
+	after["restored"] = true
+	if err := s.setActive(ctx, user, attrs.active, after); err != nil {
+		return nil, err
+	}
+	s.record(ctx, model.AuditProvisionUpdateUser, user.ID, nil, after)
+	return s.Get(ctx, user.ID)
+}
+
+// Replace overwrites the user's provisioned attributes
+func (s *scimService) Replace(ctx context.Context, id int64, resource *scim.User) (*scim.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := s.parse(resource, "")
+	if err != nil {
+		return nil, err
+	}
+	return s.update(ctx, user, attrs)
+}
+
+// Patch applies a PATCH request to the user's current representation
+func (s *scimService) Patch(ctx context.Context, id int64, req *scim.PatchRequest) (*scim.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resource := s.resource(user)
+	if err := req.Apply(resource); err != nil {
+		return nil, err
+	}
+	attrs, err := s.parse(resource, user.FullName)
+	if err != nil {
+		return nil, err
+	}
+	return s.update(ctx, user, attrs)
+}
+
+func (s *scimService) update(ctx context.Context, user *model.User, attrs *attributes) (*scim.User, error) {
+	if err := s.checkUsername(attrs.username, user.Username); err != nil {
+		return nil, err
+	}
+	after := attrs.changes(user)
+	if len(after) > 0 {
+		if err := s.repo.Update(ctx, user.ID, attrs.updates()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.setActive(ctx, user, attrs.active, after); err != nil {
+		return nil, err
+	}
+	// Identity providers sync periodically; only record actual changes
+	if len(after) > 0 {
+		s.record(ctx, model.AuditProvisionUpdateUser, user.ID, nil, after)
+	}
+	return s.Get(ctx, user.ID)
+}
+
+// setActive suspends or unsuspends the user to match active, noting a
+// change in after
+func (s *scimService) setActive(ctx context.Context, user *model.User, active *bool, after map[string]any) error {
+	if active == nil || *active != user.IsSuspended() {
+		return nil
+	}
+	var err error
+	if *active {
+		err = s.repo.Unsuspend(ctx, user.ID)
+	} else {
+		err = s.repo.Suspend(ctx, user.ID, time.Now().UTC())
+	}
+	if err == nil {
+		after["active"] = *active
+	}
+	return err
+}
+
+// Delete deprovisions the user. The account is soft-deleted: it disappears
+// everywhere but can be restored by provisioning the same externalId again.
+func (s *scimService) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.record(ctx, model.AuditProvisionDeleteUser, id, nil, nil)
+	return nil
+}
+
+func (s *scimService) record(ctx context.Context, action string, userID int64, before, after map[string]any) {
+	s.audit.Record(ctx, &model.AuditLog{
+		Action:     action,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// resource maps a user to its SCIM representation. Only suspension makes a
+// user inactive; deactivating their own account does not.
+func (s *scimService) resource(user *model.User) *scim.User {
+	id := strconv.FormatInt(user.ID, 10)
+	active := scim.Bool(!user.IsSuspended())
+	resource := &scim.User{
+		Schemas:           []string{scim.SchemaUser},
+		ID:                id,
+		ExternalID:        user.ExternalID,
+		UserName:          user.Username,
+		DisplayName:       user.FullName,
+		Emails:            []scim.Value{{Value: user.Email, Type: "work", Primary: true}},
+		PreferredLanguage: user.Language,
+		Active:            &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      user.CreatedAt.UTC(),
+			LastModified: user.UpdatedAt.UTC(),
+			Location:     s.config.BaseURL + "/Users/" + id,
+		},
+	}
+	if user.FullName != "" {
+		resource.Name = &scim.Name{Formatted: user.FullName}
+	}
+	if user.AvatarURL != "" {
+		resource.Photos = []scim.Value{{Value: user.AvatarURL, Type: "photo", Primary: true}}
+	}
+	return resource
+}
+
+// attributes are the account fields a SCIM user maps to
+type attributes struct {
+	username   string
+	email      string
+	fullName   string
+	avatarURL  string
+	language   string
+	externalID string
+	active     *bool // Nil leaves the suspension as it is
+}
+
+// parse validates a SCIM user and maps it to account fields. current is
+// the user's full name, so a PATCH of either displayName or name wins over
+// the other, which still holds the old name.
+func (s *scimService) parse(u *scim.User, current string) (*attributes, error) {
+	attrs := &attributes{
+		username:   strings.TrimSpace(u.UserName),
+		email:      usersvc.NormalizeEmail(scim.Primary(u.Emails), s.config.StripEmailPlus),
+		externalID: strings.TrimSpace(u.ExternalID),
+	}
+	if attrs.username == "" || utf8.RuneCountInString(attrs.username) > MaxUserNameLength || strings.ContainsAny(attrs.username, " \t\n/?#") {
+		return nil, invalidValue("userName must be 1 to 50 characters without spaces, slashes, ? or #")
+	}
+	if len(attrs.email) > 100 || !strings.Contains(attrs.email, "@") {
+		return nil, invalidValue("a primary email address of at most 100 characters is required")
+	}
+	if len(attrs.externalID) > 255 {
+		return nil, invalidValue("externalId must be at most 255 characters")
+	}
+
+	names := []string{strings.TrimSpace(u.DisplayName)}
+	if u.Name != nil {
+		names = append(names, strings.TrimSpace(u.Name.Formatted), strings.TrimSpace(u.Name.GivenName+" "+u.Name.FamilyName))
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if attrs.fullName == "" {
+			attrs.fullName = name
+		}
+		if name != current {
+			attrs.fullName = name
+			break
+		}
+	}
+	if utf8.RuneCountInString(attrs.fullName) > 100 {
+		attrs.fullName = string([]rune(attrs.fullName)[:100])
+	}
+
+	if avatar := scim.Primary(u.Photos); len(avatar) <= 255 {
+		if parsed, err := url.Parse(avatar); err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != "" {
+			attrs.avatarURL = avatar
+		}
+	}
+	for _, tag := range []string{u.PreferredLanguage, u.Locale} {
+		lang, _, _ := strings.Cut(strings.ReplaceAll(strings.ToLower(tag), "_", "-"), "-")
+		if langdetect.IsCode(lang) {
+			attrs.language = lang
+			break
+		}
+	}
+	if u.Active != nil {
+		active := bool(*u.Active)
+		attrs.active = &active
+	}
+	return attrs, nil
+}
+
+// updates returns the columns to write. Optional attributes the identity
+// provider left out keep their values, so users may set them themselves.
+func (a *attributes) updates() map[string]any {
+	updates := map[string]any{"username": a.username, "email": a.email}
+	for column, value := range map[string]string{
+		"full_name":   a.fullName,
+		"avatar_url":  a.avatarURL,
+		"language":    a.language,
+		"external_id": a.externalID,
+	} {
+		if value != "" {
+			updates[column] = value
+		}
+	}
+	return updates
+}
+
+// changes returns the updates that differ from user; all of them when user
+// is nil
+func (a *attributes) changes(user *model.User) map[string]any {
+	changes := a.updates()
+	if user == nil {
+		return changes
+	}
+	current := map[string]any{
+		"username":    user.Username,
+		"email":       user.Email,
+		"full_name":   user.FullName,
+		"avatar_url":  user.AvatarURL,
+		"language":    user.Language,
+		"external_id": user.ExternalID,
+	}
+	for column, value := range changes {
+		if current[column] == value {
+			delete(changes, column)
+		}
+	}
+	return changes
+}
+
+// checkUsername applies the username policy to a new or changed username;
+// accounts keep usernames they were given before the policy
+func (s *scimService) checkUsername(username, current string) error {
+	if username == current {
+		return nil
+	}
+	err := s.usernames.Validate(username)
+	if err != nil && !errors.Is(err, usersvc.ErrUsernameReserved) {
+		return invalidValue(err.Error())
+	}
+	return err
+}
+
+func invalidValue(detail string) *scim.Error {
+	return scim.NewError(http.StatusBadRequest, scim.ErrTypeInvalidValue, detail)
+}
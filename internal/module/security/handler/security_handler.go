@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/security/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+type SecurityHandler struct {
+	service service.SecurityService
+}
+
+func NewSecurityHandler(svc service.SecurityService) *SecurityHandler {
+	return &SecurityHandler{service: svc}
+}
+
+// Register mounts the security activity routes; they expect an authenticated user in the request context
+func (h *SecurityHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/security-events", h.List)
+}
+
+// List returns the user's login and account security history, newest first
+func (h *SecurityHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 200)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	events, err := h.service.List(r.Context(), userID, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"events": events})
+}
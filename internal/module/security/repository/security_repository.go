@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+type SecurityRepository interface {
+	Create(ctx context.Context, event *model.SecurityEvent) error
+	ListByUser(ctx context.Context, userID int64, limit, offset int) ([]*model.SecurityEvent, error)
+	HasLogin(ctx context.Context, userID int64) (bool, error)
+	HasLoginFromDevice(ctx context.Context, userID int64, deviceHash string) (bool, error)
+}
+
+func NewSecurityRepository(db *gorm.DB) SecurityRepository {
+	return &securityRepository{db: db}
+}
+
+type securityRepository struct {
+	db *gorm.DB
+}
+
+func (r *securityRepository) Create(ctx context.Context, event *model.SecurityEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *securityRepository) ListByUser(ctx context.Context, userID int64, limit, offset int) ([]*model.SecurityEvent, error) {
+	var events []*model.SecurityEvent
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// HasLogin reports whether the user has ever logged in successfully
+func (r *securityRepository) HasLogin(ctx context.Context, userID int64) (bool, error) {
+	return r.exists(ctx, "user_id = ? AND type = ?", userID, types.SecurityEventTypeLogin)
+}
+
+// HasLoginFromDevice reports whether the user has logged in successfully from deviceHash before
+func (r *securityRepository) HasLoginFromDevice(ctx context.Context, userID int64, deviceHash string) (bool, error) {
+	return r.exists(ctx, "user_id = ? AND type = ? AND device_hash = ?", userID, types.SecurityEventTypeLogin, deviceHash)
+}
+
+func (r *securityRepository) exists(ctx context.Context, query string, args ...any) (bool, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&model.SecurityEvent{}).
+		Where(query+" AND deleted_at IS NULL", args...).
+		Limit(1).
+		Pluck("id", &ids).Error
+	return len(ids) > 0, err
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	notificationrepo "github.com/ilhamosaurus/sns-platform/internal/module/notification/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/module/security/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// Locator derives an approximate location for a request
+type Locator interface {
+	Locate(r *http.Request) string
+}
+
+// LocatorFunc adapts a function to Locator
+type LocatorFunc func(r *http.Request) string
+
+func (f LocatorFunc) Locate(r *http.Request) string { return f(r) }
+
+// HeaderLocator reads the geolocation headers set by common edge proxies
+// (Cloudflare, CloudFront, App Engine); it returns "" when none are present
+var HeaderLocator = LocatorFunc(func(r *http.Request) string {
+	city := firstHeader(r, "CloudFront-Viewer-City", "X-AppEngine-City", "CF-IPCity")
+	country := firstHeader(r, "CF-IPCountry", "CloudFront-Viewer-Country", "X-AppEngine-Country")
+	if country == "XX" || country == "T1" { // Cloudflare: unknown, Tor
+		country = ""
+	}
+	switch {
+	case city != "" && country != "":
+		return city + ", " + country
+	default:
+		return city + country
+	}
+})
+
+type SecurityService interface {
+	Record(ctx context.Context, r *http.Request, userID int64, eventType types.SecurityEventType) (*model.SecurityEvent, error)
+	List(ctx context.Context, userID int64, limit, offset int) ([]*model.SecurityEvent, error)
+}
+
+type securityService struct {
+	repo    repository.SecurityRepository
+	bus     eventbus.Bus
+	locator Locator
+}
+
+// NewSecurityService creates the service; locator may be nil to use HeaderLocator
+func NewSecurityService(repo repository.SecurityRepository, bus eventbus.Bus, locator Locator) SecurityService {
+	if locator == nil {
+		locator = HeaderLocator
+	}
+	return &securityService{repo: repo, bus: bus, locator: locator}
+}
+
+// Record stores a security event for the request. Every successful login
+// publishes UserLoggedIn; one from a device the user has not logged in from
+// before also publishes NewDeviceLogin, except for the user's very first login.
+func (s *securityService) Record(ctx context.Context, r *http.Request, userID int64, eventType types.SecurityEventType) (*model.SecurityEvent, error) {
+	userAgent := r.UserAgent()
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+	e := &model.SecurityEvent{
+		UserID:     userID,
+		Type:       eventType,
+		IP:         httpx.ClientIP(r),
+		UserAgent:  userAgent,
+		Device:     DescribeDevice(userAgent),
+		DeviceHash: deviceHash(userAgent),
+		Location:   s.locator.Locate(r),
+	}
+
+	if eventType == types.SecurityEventTypeLogin {
+		newDevice, err := s.isNewDevice(ctx, userID, e.DeviceHash)
+		if err != nil {
+			return nil, err
+		}
+		e.NewDevice = newDevice
+	}
+
+	if err := s.repo.Create(ctx, e); err != nil {
+		return nil, fmt.Errorf("failed to record security event: %w", err)
+	}
+
+	if eventType == types.SecurityEventTypeLogin {
+		if err := s.bus.Publish(ctx, event.UserLoggedIn{UserID: userID, OccurredAt: e.CreatedAt}); err != nil {
+			slog.WarnContext(ctx, "failed to publish login", slog.Int64("user_id", userID), slog.Any("error", err))
+		}
+	}
+	if e.NewDevice {
+		err := s.bus.Publish(ctx, event.NewDeviceLogin{
+			SecurityEventID: e.ID,
+			UserID:          userID,
+			IP:              e.IP,
+			Device:          e.Device,
+			Location:        e.Location,
+			OccurredAt:      e.CreatedAt,
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "failed to publish new device login", slog.Int64("user_id", userID), slog.Any("error", err))
+		}
+	}
+	return e, nil
+}
+
+func (s *securityService) List(ctx context.Context, userID int64, limit, offset int) ([]*model.SecurityEvent, error) {
+	return s.repo.ListByUser(ctx, userID, limit, offset)
+}
+
+func (s *securityService) isNewDevice(ctx context.Context, userID int64, hash string) (bool, error) {
+	seen, err := s.repo.HasLoginFromDevice(ctx, userID, hash)
+	if err != nil || seen {
+		return false, err
+	}
+	hasLogin, err := s.repo.HasLogin(ctx, userID)
+	return hasLogin, err
+}
+
+// NotifyOnNewDeviceLogin sends the user an in-app notification for every login from a new device
+func NotifyOnNewDeviceLogin(bus eventbus.Bus, repo notificationrepo.NotificationRepository) {
+	eventbus.On(bus, func(ctx context.Context, e event.NewDeviceLogin) error {
+		return repo.Create(ctx, &model.Notification{
+			UserID:      e.UserID,
+			ActorID:     e.UserID,
+			Type:        types.NotificationTypeSecurity,
+			TargetType:  types.NotificationTargetUser,
+			TargetID:    e.UserID,
+			TemplateKey: model.NotificationKeyNewDeviceLogin,
+			Params: map[string]string{
+				"device":   e.Device,
+				"location": e.Location,
+				"ip":       e.IP,
+			},
+		})
+	})
+}
+
+// DescribeDevice turns a User-Agent into a short label such as "Firefox on Windows"
+func DescribeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "okhttp") || strings.Contains(ua, "dalvik"):
+		browser = "Android app"
+	case strings.Contains(ua, "cfnetwork"):
+		browser = "iOS app"
+	}
+
+	os := ""
+	switch {
+	case strings.Contains(ua, "android"):
+		os = "Android"
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") || strings.Contains(ua, "cfnetwork"):
+		os = "iOS"
+	case strings.Contains(ua, "windows"):
+		os = "Windows"
+	case strings.Contains(ua, "mac os") || strings.Contains(ua, "macintosh"):
+		os = "macOS"
+	case strings.Contains(ua, "linux"):
+		os = "Linux"
+	}
+
+	if os == "" || strings.HasSuffix(browser, os+" app") {
+		return browser
+	}
+	return browser + " on " + os
+}
+
+func deviceHash(userAgent string) string {
+	sum := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(sum[:])
+}
+
+func firstHeader(r *http.Request, names ...string) string {
+	for _, name := range names {
+		if v := strings.TrimSpace(r.Header.Get(name)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/module/shortlink/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+type ShortLinkHandler struct {
+	service service.ShortLinkService
+}
+
+func NewShortLinkHandler(svc service.ShortLinkService) *ShortLinkHandler {
+	return &ShortLinkHandler{service: svc}
+}
+
+// Register mounts the share link routes; creating links expects an
+// authenticated user in the request context, the redirects are public
+func (h *ShortLinkHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /posts/{id}/share-link", h.SharePost)
+	mux.HandleFunc("POST /users/{username}/share-link", h.ShareProfile)
+	mux.HandleFunc("GET /p/{code}", h.redirect(types.ShortLinkTargetPost))
+	mux.HandleFunc("GET /u/{code}", h.redirect(types.ShortLinkTargetProfile))
+}
+
+// SharePost returns the caller's short link for a post
+func (h *ShortLinkHandler) SharePost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	postID, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+
+	link, err := h.service.SharePost(r.Context(), userID, postID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, dto.NewShortLinkResponse(link, h.service.ShareURL(link)))
+}
+
+// ShareProfile returns the caller's short link for a profile
+func (h *ShortLinkHandler) ShareProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	link, err := h.service.ShareProfile(r.Context(), userID, r.PathValue("username"))
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, dto.NewShortLinkResponse(link, h.service.ShareURL(link)))
+}
+
+// redirect sends a short link's visitor on to what it points at
+func (h *ShortLinkHandler) redirect(target types.ShortLinkTarget) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		location, err := h.service.Resolve(r.Context(), target, r.PathValue("code"))
+		if err != nil {
+			httpx.Fail(w, err)
+			return
+		}
+		http.Redirect(w, r, location, http.StatusFound)
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	closefriendrepo "github.com/ilhamosaurus/sns-platform/internal/module/closefriend/repository"
+	orgrepo "github.com/ilhamosaurus/sns-platform/internal/module/organization/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+type ShortLinkRepository interface {
+	Find(ctx context.Context, userID int64, target types.ShortLinkTarget, targetID int64) (*model.ShortLink, error)
+	GetByCode(ctx context.Context, code string) (*model.ShortLink, error)
+	Create(ctx context.Context, link *model.ShortLink) error
+	CountClick(ctx context.Context, id int64) error
+	VisiblePost(ctx context.Context, postID, viewerID int64) (*model.Post, error)
+}
+
+func NewShortLinkRepository(db *gorm.DB) ShortLinkRepository {
+	return &shortLinkRepository{db: db}
+}
+
+type shortLinkRepository struct {
+	db *gorm.DB
+}
+
+// Find returns the link a user made for a target
+func (r *shortLinkRepository) Find(ctx context.Context, userID int64, target types.ShortLinkTarget, targetID int64) (*model.ShortLink, error) {
+	var link model.ShortLink
+	err := r.db.WithContext(ctx).
+		Where("created_by = ? AND target_type = ? AND target_id = ? AND deleted_at IS NULL", userID, target, targetID).
+		First(&link).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "short link")
+	}
+	return &link, nil
+}
+
+func (r *shortLinkRepository) GetByCode(ctx context.Context, code string) (*model.ShortLink, error) {
+	var link model.ShortLink
+	if err := r.db.WithContext(ctx).Where("code = ? AND deleted_at IS NULL", code).First(&link).Error; err != nil {
+		return nil, apperr.Translate(err, "short link")
+	}
+	return &link, nil
+}
+
+// Create stores a link; a link to a post counts as a share of it
+func (r *shortLinkRepository) Create(ctx context.Context, link *model.ShortLink) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(link).Error; err != nil {
+			return apperr.Translate(err, "short link")
+		}
+		if link.TargetType != types.ShortLinkTargetPost {
+			return nil
+		}
+		return tx.Model(&model.Post{}).
+			Where("id = ? AND deleted_at IS NULL", link.TargetID).
+			UpdateColumn("share_count", gorm.Expr("share_count + 1")).Error
+	})
+}
+
+func (r *shortLinkRepository) CountClick(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Model(&model.ShortLink{}).
+		Where("id = ?", id).
+		UpdateColumn("click_count", gorm.Expr("click_count + 1")).Error
+}
+
+// VisiblePost returns a post the viewer may read
+func (r *shortLinkRepository) VisiblePost(ctx context.Context, postID, viewerID int64) (*model.Post, error) {
+	var post model.Post
+	err := r.db.WithContext(ctx).
+		Where("posts.id = ? AND posts.deleted_at IS NULL", postID).
+		Scopes(closefriendrepo.VisibleTo(viewerID), orgrepo.VisibleTo(viewerID), userrepo.ActiveAuthors("posts.user_id")).
+		First(&post).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "post")
+	}
+	return &post, nil
+}
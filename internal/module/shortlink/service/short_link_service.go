@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/module/shortlink/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+// CodeLength is the number of base62 characters in a share code
+const CodeLength = 7
+
+// codeAttempts bounds retries when a generated code is already taken
+const codeAttempts = 5
+
+const codeAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ErrNotShareable is returned for close friends posts, which must not leave
+// the author's list through a public link
+var ErrNotShareable = apperr.Forbidden("this post cannot be shared")
+
+// Config sets where links point
+type Config struct {
+	PublicURL string // Origin of share and target links
+}
+
+type ShortLinkService interface {
+	SharePost(ctx context.Context, userID, postID int64) (*model.ShortLink, error)
+	ShareProfile(ctx context.Context, userID int64, username string) (*model.ShortLink, error)
+	Resolve(ctx context.Context, target types.ShortLinkTarget, code string) (string, error)
+	ShareURL(link *model.ShortLink) string
+}
+
+type shortLinkService struct {
+	repo     repository.ShortLinkRepository
+	postRepo postrepo.PostRepository
+	userRepo userrepo.UserRepository
+	config   Config
+}
+
+func NewShortLinkService(repo repository.ShortLinkRepository, postRepo postrepo.PostRepository, userRepo userrepo.UserRepository, config Config) ShortLinkService {
+	config.PublicURL = strings.TrimSuffix(config.PublicURL, "/")
+	return &shortLinkService{repo: repo, postRepo: postRepo, userRepo: userRepo, config: config}
+}
+
+// SharePost returns the user's share link for a post they can see, creating
+// it on first use
+func (s *shortLinkService) SharePost(ctx context.Context, userID, postID int64) (*model.ShortLink, error) {
+	post, err := s.repo.VisiblePost(ctx, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if post.CloseFriends {
+		return nil, ErrNotShareable
+	}
+	return s.link(ctx, userID, types.ShortLinkTargetPost, post.ID)
+}
+
+// ShareProfile returns the user's share link for a profile, creating it on
+// first use
+func (s *shortLinkService) ShareProfile(ctx context.Context, userID int64, username string) (*model.ShortLink, error) {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDeactivated() {
+		return nil, apperr.NotFound("user not found")
+	}
+	return s.link(ctx, userID, types.ShortLinkTargetProfile, user.ID)
+}
+
+// link finds or creates the user's link to a target. A conflict is either a
+// taken code, retried with a new one, or a concurrent request creating the
+// same link, which is then returned.
+func (s *shortLinkService) link(ctx context.Context, userID int64, target types.ShortLinkTarget, targetID int64) (*model.ShortLink, error) {
+	for range codeAttempts {
+		link, err := s.repo.Find(ctx, userID, target, targetID)
+		if err == nil {
+			return link, nil
+		}
+		if !errors.Is(err, apperr.ErrNotFound) {
+			return nil, err
+		}
+
+		link = &model.ShortLink{Code: newCode(), TargetType: target, TargetID: targetID, CreatedBy: userID}
+		err = s.repo.Create(ctx, link)
+		if err == nil {
+			return link, nil
+		}
+		if !errors.Is(err, apperr.ErrConflict) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("failed to generate a unique share code")
+}
+
+// Resolve counts a click on a share link and returns where it points: the
+// post with its slug or the profile
+func (s *shortLinkService) Resolve(ctx context.Context, target types.ShortLinkTarget, code string) (string, error) {
+	link, err := s.repo.GetByCode(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	if link.TargetType != target {
+		return "", apperr.NotFound("short link not found")
+	}
+
+	var location string
+	switch link.TargetType {
+	case types.ShortLinkTargetPost:
+		post, err := s.postRepo.GetByID(ctx, link.TargetID)
+		if err != nil {
+			return "", err
+		}
+		if err := s.activeUser(ctx, post.UserID); err != nil {
+			return "", err
+		}
+		location = fmt.Sprintf("%s/posts/%d", s.config.PublicURL, post.ID)
+		if slug := post.Slug(); slug != "" {
+			location += "/" + slug
+		}
+	case types.ShortLinkTargetProfile:
+		user, err := s.userRepo.GetByID(ctx, link.TargetID)
+		if err != nil {
+			return "", err
+		}
+		if user.IsDeactivated() {
+			return "", apperr.NotFound("user not found")
+		}
+		location = fmt.Sprintf("%s/users/%s", s.config.PublicURL, url.PathEscape(user.Username))
+	default:
+		return "", apperr.NotFound("short link not found")
+	}
+
+	if err := s.repo.CountClick(ctx, link.ID); err != nil {
+		return "", err
+	}
+	return location, nil
+}
+
+// activeUser reports authors who deactivated their account as not found
+func (s *shortLinkService) activeUser(ctx context.Context, userID int64) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.IsDeactivated() {
+		return apperr.NotFound("post not found")
+	}
+	return nil
+}
+
+// ShareURL is the public address of a link, /p/{code} for posts and
+// /u/{code} for profiles
+func (s *shortLinkService) ShareURL(link *model.ShortLink) string {
+	prefix := "p"
+	if link.TargetType == types.ShortLinkTargetProfile {
+		prefix = "u"
+	}
+	return fmt.Sprintf("%s/%s/%s", s.config.PublicURL, prefix, link.Code)
+}
+
+// newCode returns a random base62 share code
+func newCode() string {
+	b := make([]byte, CodeLength)
+	rand.Read(b)
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b)
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	phonesvc "github.com/ilhamosaurus/sns-platform/internal/module/phone/service"
+	securitysvc "github.com/ilhamosaurus/sns-platform/internal/module/security/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/sso/service"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/quota"
+	"github.com/ilhamosaurus/sns-platform/pkg/signedurl"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+const (
+	flowCookie = "sso_flow"
+	flowTTL    = 10 * time.Minute // How long the user has to sign in at the provider
+)
+
+// Config sets how completed sign-ins are handed to the session layer
+type Config struct {
+	TicketTTL   time.Duration
+	RedirectURL string // Where the browser is sent with ?ticket=; empty answers with JSON
+
+	// SecureCookie marks the flow cookie Secure and SameSite=None so it
+	// survives the identity provider's cross-site POST; it needs https
+	SecureCookie bool
+}
+
+type SSOHandler struct {
+	service  service.SSOService
+	security securitysvc.SecurityService
+	phones   phonesvc.PhoneService
+	userRepo userrepo.UserRepository
+	signer   *signedurl.Signer
+	tickets  quota.Store
+	config   Config
+}
+
+func NewSSOHandler(svc service.SSOService, security securitysvc.SecurityService, phones phonesvc.PhoneService, userRepo userrepo.UserRepository, signer *signedurl.Signer, tickets quota.Store, config Config) *SSOHandler {
+	if config.TicketTTL <= 0 {
+		config.TicketTTL = 2 * time.Minute
+	}
+	return &SSOHandler{service: svc, security: security, phones: phones, userRepo: userRepo, signer: signer, tickets: tickets, config: config}
+}
+
+// Register mounts the single sign-on routes. None of them expect an
+// authenticated user: they are how users become one.
+func (h *SSOHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /sso/providers", h.Providers)
+	mux.HandleFunc("GET /sso/{provider}/login", h.Login)
+	mux.HandleFunc("GET /sso/{provider}/callback", h.Callback)
+	mux.HandleFunc("POST /sso/{provider}/acs", h.ACS)
+	mux.HandleFunc("GET /sso/{provider}/metadata", h.Metadata)
+	mux.HandleFunc("POST /sso/tickets/{userID}/{nonce}", h.Redeem)
+}
+
+// Providers lists the configured identity providers
+func (h *SSOHandler) Providers(w http.ResponseWriter, r *http.Request) {
+	httpx.JSON(w, http.StatusOK, map[string]any{"providers": h.service.Providers()})
+}
+
+// Login starts a sign-in and sends the browser to the identity provider.
+// ?invite= carries the invite code to sign up with.
+func (h *SSOHandler) Login(w http.ResponseWriter, r *http.Request) {
+	redirect, flow, err := h.service.Begin(r.Context(), r.PathValue("provider"), r.URL.Query().Get("invite"))
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+
+	payload, _ := json.Marshal(flow)
+	value := h.signer.Sign(base64.RawURLEncoding.EncodeToString(payload), time.Now().Add(flowTTL))
+	http.SetCookie(w, h.cookie(value, int(flowTTL.Seconds())))
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+// Callback completes an OIDC sign-in
+func (h *SSOHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		httpx.Error(w, http.StatusForbidden, fmt.Sprintf("identity provider returned %s: %s", e, q.Get("error_description")))
+		return
+	}
+	h.complete(w, r, service.Callback{State: q.Get("state"), Code: q.Get("code")})
+}
+
+// ACS is the SAML assertion consumer service, completing a SAML sign-in
+func (h *SSOHandler) ACS(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	h.complete(w, r, service.Callback{State: r.PostForm.Get("RelayState"), SAMLResponse: r.PostForm.Get("SAMLResponse")})
+}
+
+func (h *SSOHandler) complete(w http.ResponseWriter, r *http.Request, callback service.Callback) {
+	flow := h.flow(r)
+	// The flow is single use whatever the outcome
+	http.SetCookie(w, h.cookie("", -1))
+
+	login, err := h.service.Complete(r.Context(), r.PathValue("provider"), flow, callback)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	if _, err := h.security.Record(r.Context(), r, login.User.ID, types.SecurityEventTypeLogin); err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	expires := time.Now().Add(h.config.TicketTTL)
+	// The nonce keeps tickets issued within the same second apart
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	ticket := h.signer.Sign(fmt.Sprintf("/sso/tickets/%d/%s", login.User.ID, hex.EncodeToString(nonce)), expires)
+	if h.config.RedirectURL != "" {
+		u, _ := url.Parse(h.config.RedirectURL)
+		q := u.Query()
+		q.Set("ticket", ticket)
+		u.RawQuery = q.Encode()
+		http.Redirect(w, r, u.String(), http.StatusFound)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, dto.SSOLogin{
+		User:        dto.NewUserResponse(login.User),
+		Provisioned: login.Provisioned,
+		Ticket:      ticket,
+		ExpiresAt:   expires.UTC(),
+	})
+}
+
+// flow reads the login in progress from its cookie, nil if it is missing,
+// forged or expired
+func (h *SSOHandler) flow(r *http.Request) *service.Flow {
+	cookie, err := r.Cookie(flowCookie)
+	if err != nil {
+		return nil
+	}
+	u, err := url.Parse(cookie.Value)
+	if err != nil || h.signer.Verify(u) != nil {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(u.Path)
+	if err != nil {
+		return nil
+	}
+	var flow service.Flow
+	if err := json.Unmarshal(payload, &flow); err != nil {
+		return nil
+	}
+	return &flow
+}
+
+func (h *SSOHandler) cookie(value string, maxAge int) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     flowCookie,
+		Value:    value,
+		Path:     "/sso/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if h.config.SecureCookie {
+		cookie.Secure = true
+		cookie.SameSite = http.SameSiteNoneMode
+	}
+	return cookie
+}
+
+// Metadata serves the SAML service provider metadata to register with the
+// identity provider
+func (h *SSOHandler) Metadata(w http.ResponseWriter, r *http.Request) {
+	metadata, err := h.service.Metadata(r.PathValue("provider"))
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.Write(metadata)
+}
+
+// Redeem exchanges a sign-in ticket for the user it was issued to. Each
+// ticket can be redeemed once, and only with the texted code by users who
+// turned on SMS two-factor sign-in.
+func (h *SSOHandler) Redeem(w http.ResponseWriter, r *http.Request) {
+	if err := h.signer.Verify(r.URL); err != nil {
+		httpx.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	userID, err := httpx.PathInt64(r, "userID")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	user, err := h.userRepo.GetByID(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	if user.TwoFactorSMS && !h.secondFactor(w, r, userID) {
+		return
+	}
+
+	_, fresh, err := h.tickets.Take(r.Context(), "sso:ticket:"+r.URL.Query().Get("signature"), 1, h.config.TicketTTL)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !fresh {
+		httpx.Error(w, http.StatusForbidden, "ticket has already been redeemed")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"user": dto.NewUserResponse(user)})
+}
+
+// secondFactor checks the code sent in the body of a redemption, as
+// {"code": ...}, and reports whether it may go on. Without a code, one is
+// texted to the user and the redemption is refused with a 401
+// two_factor_required error whose details carry "two_factor": "sms"; the session layer asks the user for the code and
+// redeems the same ticket again with it, before the ticket expires.
+func (h *SSOHandler) secondFactor(w http.ResponseWriter, r *http.Request, userID int64) bool {
+	var body struct {
+		Code string `json:"code"`
+	}
+	// An empty body asks for a code
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	if body.Code == "" {
+		if err := h.phones.SendSignInCode(r.Context(), userID); err != nil && !errors.Is(err, phonesvc.ErrResendTooSoon) {
+			httpx.Fail(w, err)
+			return false
+		}
+		httpx.ErrorCode(w, http.StatusUnauthorized, apperr.CodeTwoFactorRequired, "enter the code texted to your phone", map[string]string{"two_factor": "sms"})
+		return false
+	}
+
+	if err := h.phones.VerifySignInCode(r.Context(), userID, body.Code); err != nil {
+		if errors.Is(err, phonesvc.ErrCodeInvalid) {
+			if _, err := h.security.Record(r.Context(), r, userID, types.SecurityEventTypeTwoFactorFailed); err != nil {
+				httpx.Error(w, http.StatusInternalServerError, err.Error())
+				return false
+			}
+		}
+		httpx.Fail(w, err)
+		return false
+	}
+	return true
+}
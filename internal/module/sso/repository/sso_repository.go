@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+type SSORepository interface {
+	GetIdentity(ctx context.Context, provider, subject string) (*model.SSOIdentity, error)
+	Link(ctx context.Context, identity *model.SSOIdentity) error
+	UpdateEmail(ctx context.Context, identityID int64, email string) error
+
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	UsernameTaken(ctx context.Context, username string) (bool, error)
+	Provision(ctx context.Context, user *model.User, identity *model.SSOIdentity) error
+}
+
+func NewSSORepository(db *gorm.DB) SSORepository {
+	return &ssoRepository{db: db}
+}
+
+type ssoRepository struct {
+	db *gorm.DB
+}
+
+func (r *ssoRepository) GetIdentity(ctx context.Context, provider, subject string) (*model.SSOIdentity, error) {
+	var identity model.SSOIdentity
+	if err := r.db.WithContext(ctx).Where("provider = ? AND subject = ? AND deleted_at IS NULL", provider, subject).First(&identity).Error; err != nil {
+		return nil, apperr.Translate(err, "identity")
+	}
+	return &identity, nil
+}
+
+func (r *ssoRepository) Link(ctx context.Context, identity *model.SSOIdentity) error {
+	return apperr.Translate(r.db.WithContext(ctx).Create(identity).Error, "identity")
+}
+
+func (r *ssoRepository) UpdateEmail(ctx context.Context, identityID int64, email string) error {
+	return r.db.WithContext(ctx).Model(&model.SSOIdentity{}).Where("id = ?", identityID).Update("email", email).Error
+}
+
+// GetUserByEmail finds a user by email address, ignoring case
+func (r *ssoRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).Where("LOWER(email) = ? AND deleted_at IS NULL", strings.ToLower(email)).First(&user).Error; err != nil {
+		return nil, apperr.Translate(err, "user")
+	}
+	return &user, nil
+}
+
+// UsernameTaken reports whether any user, deleted ones included, holds the
+// username in any case
+func (r *ssoRepository) UsernameTaken(ctx context.Context, username string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).Where("LOWER(username) = LOWER(?)", username).Count(&count).Error
+	return count > 0, err
+}
+
+// Provision creates a user together with the identity they signed in with
+func (r *ssoRepository) Provision(ctx context.Context, user *model.User, identity *model.SSOIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return apperr.Translate(err, "user")
+		}
+		identity.UserID = user.ID
+		return apperr.Translate(tx.Create(identity).Error, "identity")
+	})
+}
@@ -0,0 +1,520 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	invitesvc "github.com/ilhamosaurus/sns-platform/internal/module/invite/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/sso/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	usersvc "github.com/ilhamosaurus/sns-platform/internal/module/user/service"
+	waitlistsvc "github.com/ilhamosaurus/sns-platform/internal/module/waitlist/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/challenge"
+	"github.com/ilhamosaurus/sns-platform/pkg/oidc"
+	"github.com/ilhamosaurus/sns-platform/pkg/saml"
+)
+
+// Identity provider protocols
+const (
+	ProtocolOIDC = "oidc"
+	ProtocolSAML = "saml"
+)
+
+// Attributes names the OIDC claims or SAML attributes mapped onto user
+// fields. Empty names fall back to the protocol's usual ones.
+type Attributes struct {
+	Username  string
+	Email     string
+	FullName  string
+	AvatarURL string
+	Language  string
+}
+
+// Provider configures one identity provider
+type Provider struct {
+	Name        string // Used in URLs and stored with linked identities
+	Protocol    string // oidc or saml
+	DisplayName string
+	OIDC        oidc.Config
+	SAML        saml.Config
+
+	AllowedDomains []string // Email domains allowed to sign in; empty allows any
+	JIT            bool     // Create an account for users signing in for the first time
+	LinkByEmail    bool     // Link first sign-ins to the existing account with the same verified email
+	Attributes     Attributes
+}
+
+type Config struct {
+	Providers         []Provider
+	StripEmailPlus    bool     // Passed to usersvc.NormalizeEmail
+	ReservedUsernames []string // Passed to usersvc.NewUsernamePolicy
+	InviteOnly        bool     // First sign-ins need an invite code to create the account
+	Waitlist          bool     // First sign-ins without an invite wait for their email to be activated
+}
+
+// Flow is a login in progress. The handler keeps it with the browser
+// between Begin and Complete.
+type Flow struct {
+	Provider  string `json:"p"`
+	State     string `json:"s"` // OIDC state or SAML RelayState
+	Nonce     string `json:"n,omitempty"`
+	Verifier  string `json:"v,omitempty"` // PKCE code verifier
+	RequestID string `json:"r,omitempty"` // SAML AuthnRequest ID
+	Invite    string `json:"i,omitempty"` // Invite code to create the account with
+}
+
+// Callback is what the identity provider sent back: code for OIDC,
+// SAMLResponse for SAML
+type Callback struct {
+	State        string
+	Code         string
+	SAMLResponse string
+}
+
+// Profile is a user as described by their identity provider
+type Profile struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	FullName      string
+	AvatarURL     string
+	Language      string
+}
+
+// Login is the outcome of a completed sign-in
+type Login struct {
+	User        *model.User
+	Provisioned bool // The account was created by this sign-in
+}
+
+type SSOService interface {
+	Providers() []dto.SSOProvider
+	Begin(ctx context.Context, name, invite string) (string, *Flow, error)
+	Complete(ctx context.Context, name string, flow *Flow, callback Callback) (*Login, error)
+	Metadata(name string) ([]byte, error)
+}
+
+type provider struct {
+	Provider
+	oidc *oidc.Provider
+	saml *saml.ServiceProvider
+}
+
+type ssoService struct {
+	repo       repository.SSORepository
+	userRepo   userrepo.UserRepository
+	providers  map[string]*provider
+	order      []string
+	stripPlus  bool
+	usernames  *usersvc.UsernamePolicy
+	challenges *challenge.Challenge
+	invites    invitesvc.InviteService
+	inviteOnly bool
+	waitlist   waitlistsvc.WaitlistService
+	waitlisted bool
+}
+
+func NewSSOService(repo repository.SSORepository, userRepo userrepo.UserRepository, challenges *challenge.Challenge, invites invitesvc.InviteService, waitlist waitlistsvc.WaitlistService, config Config) (SSOService, error) {
+	s := &ssoService{repo: repo, userRepo: userRepo, providers: map[string]*provider{}, stripPlus: config.StripEmailPlus, usernames: usersvc.NewUsernamePolicy(config.ReservedUsernames), challenges: challenges, invites: invites, inviteOnly: config.InviteOnly, waitlist: waitlist, waitlisted: config.Waitlist}
+	for _, p := range config.Providers {
+		entry := &provider{Provider: p}
+		switch p.Protocol {
+		case ProtocolOIDC:
+			entry.oidc = oidc.NewProvider(p.OIDC)
+		case ProtocolSAML:
+			sp, err := saml.New(p.SAML)
+			if err != nil {
+				return nil, fmt.Errorf("sso provider %s: %w", p.Name, err)
+			}
+			entry.saml = sp
+		default:
+			return nil, fmt.Errorf("sso provider %s: unknown protocol %q", p.Name, p.Protocol)
+		}
+		s.providers[p.Name] = entry
+		s.order = append(s.order, p.Name)
+	}
+	slices.Sort(s.order)
+	return s, nil
+}
+
+// Providers lists the identity providers users can sign in with
+func (s *ssoService) Providers() []dto.SSOProvider {
+	out := make([]dto.SSOProvider, 0, len(s.order))
+	for _, name := range s.order {
+		p := s.providers[name]
+		out = append(out, dto.SSOProvider{Name: p.Name, DisplayName: p.DisplayName, Protocol: p.Protocol})
+	}
+	return out
+}
+
+func (s *ssoService) provider(name string) (*provider, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, apperr.NotFound("identity provider not found")
+	}
+	return p, nil
+}
+
+// Begin starts a login, returning the identity provider URL to send the
+// browser to and the flow to hand back to Complete. The browser must pass a
+// challenge first, since the login may create an account. An invite code,
+// checked now and used if the account is created, may come along.
+func (s *ssoService) Begin(ctx context.Context, name, invite string) (string, *Flow, error) {
+	p, err := s.provider(name)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.challenges.Require(ctx, 0, challenge.ActionSignIn); err != nil {
+		return "", nil, err
+	}
+	invite = invitesvc.NormalizeCode(invite)
+	if invite != "" {
+		if err := s.invites.Check(ctx, invite); err != nil {
+			return "", nil, err
+		}
+	}
+	flow := &Flow{Provider: p.Name, State: randomToken(), Invite: invite}
+
+	if p.saml != nil {
+		redirect, requestID, err := p.saml.AuthnRequestURL(flow.State)
+		if err != nil {
+			return "", nil, err
+		}
+		flow.RequestID = requestID
+		return redirect, flow, nil
+	}
+
+	flow.Nonce = randomToken()
+	flow.Verifier = randomToken()
+	codeChallenge := sha256.Sum256([]byte(flow.Verifier))
+	redirect, err := p.oidc.AuthURL(ctx, flow.State, flow.Nonce, base64.RawURLEncoding.EncodeToString(codeChallenge[:]))
+	if err != nil {
+		return "", nil, err
+	}
+	return redirect, flow, nil
+}
+
+// Complete verifies the identity provider's answer to flow and signs the
+// user in, linking or provisioning their account as the provider allows
+func (s *ssoService) Complete(ctx context.Context, name string, flow *Flow, callback Callback) (*Login, error) {
+	p, err := s.provider(name)
+	if err != nil {
+		return nil, err
+	}
+	if flow == nil || flow.Provider != p.Name || subtle.ConstantTimeCompare([]byte(flow.State), []byte(callback.State)) != 1 {
+		return nil, apperr.Forbidden("sign-in state does not match; start the sign-in again")
+	}
+
+	var profile *Profile
+	if p.saml != nil {
+		assertion, err := p.saml.ParseResponse(callback.SAMLResponse, flow.RequestID)
+		if err != nil {
+			return nil, apperr.Forbidden(err.Error())
+		}
+		profile = samlProfile(p.Attributes, assertion)
+	} else {
+		claims, err := p.oidc.Login(ctx, callback.Code, flow.Verifier, flow.Nonce)
+		if errors.Is(err, oidc.ErrInvalidToken) || errors.Is(err, oidc.ErrRejected) {
+			return nil, apperr.Forbidden(err.Error())
+		}
+		if err != nil {
+			return nil, err
+		}
+		profile = oidcProfile(p.Attributes, claims)
+	}
+	return s.login(ctx, p, profile, flow.Invite)
+}
+
+// Metadata returns the SAML service provider metadata for a provider
+func (s *ssoService) Metadata(name string) ([]byte, error) {
+	p, err := s.provider(name)
+	if err != nil {
+		return nil, err
+	}
+	if p.saml == nil {
+		return nil, apperr.NotFound("identity provider does not use saml")
+	}
+	return p.saml.Metadata(), nil
+}
+
+func (s *ssoService) login(ctx context.Context, p *provider, profile *Profile, invite string) (*Login, error) {
+	if profile.Subject == "" {
+		return nil, apperr.Forbidden("identity provider did not identify the user")
+	}
+	if profile.Email != "" {
+		profile.Email = usersvc.NormalizeEmail(profile.Email, s.stripPlus)
+	}
+	if len(p.AllowedDomains) > 0 && !domainAllowed(profile.Email, p.AllowedDomains) {
+		return nil, apperr.Forbidden("email domain is not allowed to sign in")
+	}
+
+	identity, err := s.repo.GetIdentity(ctx, p.Name, profile.Subject)
+	switch {
+	case err == nil:
+		user, err := s.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.sync(ctx, identity, user, profile); err != nil {
+			return nil, err
+		}
+		return s.finish(user, false)
+	case !errors.Is(err, apperr.ErrNotFound):
+		return nil, err
+	}
+
+	if profile.Email != "" {
+		user, err := s.repo.GetUserByEmail(ctx, profile.Email)
+		switch {
+		case err == nil:
+			if !p.LinkByEmail || !profile.EmailVerified {
+				return nil, apperr.Conflict("an account with this email already exists")
+			}
+			identity := &model.SSOIdentity{UserID: user.ID, Provider: p.Name, Subject: profile.Subject, Email: profile.Email}
+			if err := s.repo.Link(ctx, identity); err != nil {
+				return nil, err
+			}
+			if err := s.sync(ctx, identity, user, profile); err != nil {
+				return nil, err
+			}
+			return s.finish(user, false)
+		case !errors.Is(err, apperr.ErrNotFound):
+			return nil, err
+		}
+	}
+
+	if !p.JIT {
+		return nil, apperr.Forbidden("no account is linked to this identity")
+	}
+	if profile.Email == "" {
+		return nil, apperr.Forbidden("identity provider did not assert an email address")
+	}
+	return s.provision(ctx, p, profile, invite)
+}
+
+func (s *ssoService) finish(user *model.User, provisioned bool) (*Login, error) {
+	if user.IsBanned() {
+		return nil, apperr.Forbidden("account is banned")
+	}
+	if user.IsSuspended() {
+		return nil, apperr.Forbidden("account is suspended by your organization")
+	}
+	return &Login{User: user, Provisioned: provisioned}, nil
+}
+
+// sync copies the attributes the identity provider manages onto the user
+func (s *ssoService) sync(ctx context.Context, identity *model.SSOIdentity, user *model.User, profile *Profile) error {
+	if profile.Email != "" && profile.Email != identity.Email {
+		if err := s.repo.UpdateEmail(ctx, identity.ID, profile.Email); err != nil {
+			return err
+		}
+	}
+
+	updates := map[string]any{}
+	if profile.FullName != "" && profile.FullName != user.FullName {
+		updates["full_name"] = profile.FullName
+		user.FullName = profile.FullName
+	}
+	if profile.AvatarURL != "" && profile.AvatarURL != user.AvatarURL {
+		updates["avatar_url"] = profile.AvatarURL
+		user.AvatarURL = profile.AvatarURL
+	}
+	if profile.Language != "" && profile.Language != user.Language {
+		updates["language"] = profile.Language
+		user.Language = profile.Language
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return s.userRepo.Update(ctx, user.ID, updates)
+}
+
+// provision creates an account for a first sign-in. SSO accounts have no
+// password. The invite code, required when sign-ups are invite-only, places
+// the account in the invite graph; without invite-only, a code that is no
+// longer valid is ignored. While sign-ups are waitlisted, an account without
+// an invite is only created once its email's turn on the waitlist has come.
+func (s *ssoService) provision(ctx context.Context, p *provider, profile *Profile, code string) (*Login, error) {
+	username, err := s.username(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{
+		Username:  username,
+		Email:     profile.Email,
+		FullName:  profile.FullName,
+		AvatarURL: profile.AvatarURL,
+		Language:  profile.Language,
+	}
+
+	var invite *model.Invite
+	if code != "" || s.inviteOnly {
+		invite, err = s.invites.Redeem(ctx, code)
+		switch {
+		case err == nil:
+			user.InvitedByID, user.InviteID = &invite.InviterID, &invite.ID
+		case s.inviteOnly || !errors.Is(err, invitesvc.ErrInviteInvalid):
+			return nil, err
+		}
+	}
+	if s.waitlisted && invite == nil {
+		if err := s.waitlist.Admit(ctx, profile.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	identity := &model.SSOIdentity{Provider: p.Name, Subject: profile.Subject, Email: profile.Email}
+	if err := s.repo.Provision(ctx, user, identity); err != nil {
+		if invite != nil {
+			if err := s.invites.Release(ctx, invite); err != nil {
+				slog.WarnContext(ctx, "failed to release invite", slog.Int64("invite_id", invite.ID), slog.Any("error", err))
+			}
+		}
+		return nil, err
+	}
+	return s.finish(user, true)
+}
+
+// username picks a free, unreserved username from the asserted one or,
+// failing that, the email's local part
+func (s *ssoService) username(ctx context.Context, profile *Profile) (string, error) {
+	base := sanitizeUsername(profile.Username)
+	if base == "" {
+		local, _, _ := strings.Cut(profile.Email, "@")
+		base = sanitizeUsername(local)
+	}
+	for len(base) < usersvc.MinUsernameLength {
+		base += "0"
+	}
+
+	candidate := base
+	for range 10 {
+		if !s.usernames.Reserved(candidate) {
+			taken, err := s.repo.UsernameTaken(ctx, candidate)
+			if err != nil {
+				return "", err
+			}
+			if !taken {
+				return candidate, nil
+			}
+		}
+		n, _ := rand.Int(rand.Reader, big.NewInt(10000))
+		suffix := fmt.Sprintf("%04d", n.Int64())
+		candidate = base[:min(len(base), usersvc.MaxUsernameLength-len(suffix))] + suffix
+	}
+	return "", apperr.Conflict("could not find a free username")
+}
+
+// sanitizeUsername keeps lowercase letters, digits, dots and underscores
+func sanitizeUsername(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '.':
+			b.WriteRune(r)
+		case r == '-' || r == ' ':
+			b.WriteByte('_')
+		}
+		if b.Len() == usersvc.MaxUsernameLength {
+			break
+		}
+	}
+	return strings.Trim(b.String(), "._")
+}
+
+func domainAllowed(email string, domains []string) bool {
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return false
+	}
+	for _, allowed := range domains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func oidcProfile(attrs Attributes, claims oidc.Claims) *Profile {
+	return normalize(&Profile{
+		Subject:       claims.String("sub"),
+		Email:         claims.String(or(attrs.Email, "email")),
+		EmailVerified: claims.Bool("email_verified"),
+		Username:      claims.String(or(attrs.Username, "preferred_username")),
+		FullName:      claims.String(or(attrs.FullName, "name")),
+		AvatarURL:     claims.String(or(attrs.AvatarURL, "picture")),
+		Language:      claims.String(or(attrs.Language, "locale")),
+	})
+}
+
+// samlProfile maps an assertion. The identity provider vouches for the
+// email it asserts, and an email-format NameID doubles as the address.
+func samlProfile(attrs Attributes, assertion *saml.Assertion) *Profile {
+	profile := &Profile{
+		Subject:       assertion.NameID,
+		Email:         assertion.Attribute(or(attrs.Email, "email")),
+		EmailVerified: true,
+		Username:      assertion.Attribute(attrs.Username),
+		FullName:      assertion.Attribute(or(attrs.FullName, "displayName")),
+		AvatarURL:     assertion.Attribute(attrs.AvatarURL),
+		Language:      assertion.Attribute(attrs.Language),
+	}
+	if profile.Email == "" && assertion.NameIDFormat == saml.NameIDFormatEmail {
+		profile.Email = assertion.NameID
+	}
+	return normalize(profile)
+}
+
+// normalize drops values that do not fit the user columns
+func normalize(p *Profile) *Profile {
+	p.Email = strings.TrimSpace(p.Email)
+	if len(p.Email) > 100 || !strings.Contains(p.Email, "@") {
+		p.Email = ""
+	}
+	p.FullName = truncate(strings.TrimSpace(p.FullName), 100)
+	if u, err := url.Parse(p.AvatarURL); err != nil || len(p.AvatarURL) > 255 || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		p.AvatarURL = ""
+	}
+	// Keep the primary subtag: en-US and en_US become en
+	lang, _, _ := strings.Cut(strings.ReplaceAll(strings.ToLower(p.Language), "_", "-"), "-")
+	if len(lang) < 2 || len(lang) > 3 {
+		lang = ""
+	}
+	p.Language = lang
+	return p
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+func or(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type TenantRepository interface {
+	tenant.Lookup
+	Create(ctx context.Context, t *model.Tenant) error
+	Update(ctx context.Context, id int64, updates map[string]any) error
+	GetByID(ctx context.Context, id int64) (*model.Tenant, error)
+	List(ctx context.Context) ([]*model.Tenant, error)
+}
+
+func NewTenantRepository(db *gorm.DB) TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+type tenantRepository struct {
+	db *gorm.DB
+}
+
+func (r *tenantRepository) Create(ctx context.Context, t *model.Tenant) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *tenantRepository) Update(ctx context.Context, id int64, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.Tenant{}).Where("id = ? AND deleted_at IS NULL", id).Updates(updates).Error
+}
+
+func (r *tenantRepository) GetByID(ctx context.Context, id int64) (*model.Tenant, error) {
+	var t model.Tenant
+	if err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *tenantRepository) List(ctx context.Context) ([]*model.Tenant, error) {
+	var tenants []*model.Tenant
+	if err := r.db.WithContext(ctx).Where("deleted_at IS NULL").Order("id ASC").Find(&tenants).Error; err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
+
+// IDByDomain resolves an active tenant by its custom domain
+func (r *tenantRepository) IDByDomain(ctx context.Context, domain string) (int64, error) {
+	return r.activeID(ctx, "domain = ?", domain)
+}
+
+// IDBySlug resolves an active tenant by its slug
+func (r *tenantRepository) IDBySlug(ctx context.Context, slug string) (int64, error) {
+	return r.activeID(ctx, "slug = ?", slug)
+}
+
+func (r *tenantRepository) activeID(ctx context.Context, cond string, value string) (int64, error) {
+	var t model.Tenant
+	err := r.db.WithContext(ctx).Select("id").
+		Where(cond+" AND is_active = ? AND deleted_at IS NULL", value, true).
+		First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, tenant.ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve tenant: %w", err)
+	}
+	return t.ID, nil
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/module/translation/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/langdetect"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/translate"
+)
+
+type TranslationHandler struct {
+	service service.TranslationService
+}
+
+func NewTranslationHandler(svc service.TranslationService) *TranslationHandler {
+	return &TranslationHandler{service: svc}
+}
+
+// Register mounts the translation routes; they expect an authenticated user in the request context
+func (h *TranslationHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /posts/{id}/translation", h.Post)
+	mux.HandleFunc("GET /comments/{id}/translation", h.Comment)
+}
+
+// Post translates a post into ?to= or the caller's language
+func (h *TranslationHandler) Post(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, h.service.TranslatePost)
+}
+
+// Comment translates a comment into ?to= or the caller's language
+func (h *TranslationHandler) Comment(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, h.service.TranslateComment)
+}
+
+func (h *TranslationHandler) serve(w http.ResponseWriter, r *http.Request, translateFn func(ctx context.Context, viewerID, id int64, target string) (*dto.Translation, error)) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	target := strings.ToLower(r.URL.Query().Get("to"))
+	if target != "" && !langdetect.IsCode(target) {
+		httpx.Error(w, http.StatusBadRequest, "to must be an ISO 639-1 language code")
+		return
+	}
+
+	translation, err := translateFn(r.Context(), userID, id, target)
+	if err != nil {
+		status := apperr.Status(err)
+		if errors.Is(err, translate.ErrUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		httpx.Error(w, status, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, translation)
+}
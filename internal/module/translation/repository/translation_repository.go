@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	closefriendrepo "github.com/ilhamosaurus/sns-platform/internal/module/closefriend/repository"
+	orgrepo "github.com/ilhamosaurus/sns-platform/internal/module/organization/repository"
+	restrictionrepo "github.com/ilhamosaurus/sns-platform/internal/module/restriction/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TranslationRepository interface {
+	Get(ctx context.Context, contentHash, target string) (*model.Translation, error)
+	Save(ctx context.Context, translation *model.Translation) error
+	VisiblePost(ctx context.Context, postID, viewerID int64) (*model.Post, error)
+	VisibleComment(ctx context.Context, commentID, viewerID int64) (*model.Comment, error)
+}
+
+func NewTranslationRepository(db *gorm.DB) TranslationRepository {
+	return &translationRepository{db: db}
+}
+
+type translationRepository struct {
+	db *gorm.DB
+}
+
+// Get returns the cached translation of a text, or nil if there is none
+func (r *translationRepository) Get(ctx context.Context, contentHash, target string) (*model.Translation, error) {
+	var translation model.Translation
+	err := r.db.WithContext(ctx).
+		Where("content_hash = ? AND target_language = ? AND deleted_at IS NULL", contentHash, target).
+		First(&translation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+// Save caches a translation; when two requests race, the first one stored wins
+func (r *translationRepository) Save(ctx context.Context, translation *model.Translation) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(translation).Error
+}
+
+// VisiblePost returns a post the viewer may read, by the rules of the post page
+func (r *translationRepository) VisiblePost(ctx context.Context, postID, viewerID int64) (*model.Post, error) {
+	var post model.Post
+	err := r.db.WithContext(ctx).
+		Where("posts.id = ? AND posts.deleted_at IS NULL", postID).
+		Scopes(closefriendrepo.VisibleTo(viewerID), orgrepo.VisibleTo(viewerID), userrepo.ActiveAuthors("posts.user_id")).
+		First(&post).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "post")
+	}
+	return &post, nil
+}
+
+// VisibleComment returns a comment the viewer may read on a post they may read
+func (r *translationRepository) VisibleComment(ctx context.Context, commentID, viewerID int64) (*model.Comment, error) {
+	var comment model.Comment
+	err := r.db.WithContext(ctx).
+		Joins("INNER JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		Where("comments.id = ? AND comments.deleted_at IS NULL", commentID).
+		Scopes(
+			closefriendrepo.VisibleTo(viewerID),
+			orgrepo.VisibleTo(viewerID),
+			userrepo.ActiveAuthors("posts.user_id"),
+			restrictionrepo.CommentsVisibleTo(viewerID),
+			userrepo.ActiveAuthors("comments.user_id"),
+		).
+		First(&comment).Error
+	if err != nil {
+		return nil, apperr.Translate(err, "comment")
+	}
+	return &comment, nil
+}
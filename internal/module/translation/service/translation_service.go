@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/translation/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/langdetect"
+	"github.com/ilhamosaurus/sns-platform/pkg/translate"
+)
+
+// ErrTranslationUnavailable is returned when the translation provider fails;
+// it unwraps to translate.ErrUnavailable
+var ErrTranslationUnavailable = &apperr.Error{Kind: translate.ErrUnavailable, Message: "translation is unavailable, try again later"}
+
+type TranslationService interface {
+	TranslatePost(ctx context.Context, viewerID, postID int64, target string) (*dto.Translation, error)
+	TranslateComment(ctx context.Context, viewerID, commentID int64, target string) (*dto.Translation, error)
+}
+
+type translationService struct {
+	repo       repository.TranslationRepository
+	userRepo   userrepo.UserRepository
+	translator translate.Translator
+}
+
+func NewTranslationService(repo repository.TranslationRepository, userRepo userrepo.UserRepository, translator translate.Translator) TranslationService {
+	return &translationService{repo: repo, userRepo: userRepo, translator: translator}
+}
+
+// TranslatePost translates a post the viewer can see into target, or into the
+// viewer's language when target is empty
+func (s *translationService) TranslatePost(ctx context.Context, viewerID, postID int64, target string) (*dto.Translation, error) {
+	post, err := s.repo.VisiblePost(ctx, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.translate(ctx, viewerID, post.Content, post.Language, target)
+}
+
+// TranslateComment translates a comment the viewer can see into target, or
+// into the viewer's language when target is empty
+func (s *translationService) TranslateComment(ctx context.Context, viewerID, commentID int64, target string) (*dto.Translation, error) {
+	comment, err := s.repo.VisibleComment(ctx, commentID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.translate(ctx, viewerID, comment.Content, langdetect.Detect(comment.Content), target)
+}
+
+// translate returns text in target, from the cache when it was translated
+// before. Text already in target, and every text under the none provider,
+// comes back as it is and is not cached.
+func (s *translationService) translate(ctx context.Context, viewerID int64, text, source, target string) (*dto.Translation, error) {
+	if target == "" {
+		viewer, err := s.userRepo.GetByID(ctx, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		target = viewer.Language
+	}
+
+	untranslated := &dto.Translation{SourceLanguage: source, TargetLanguage: target, Text: text, Provider: translate.ProviderNone}
+	if text == "" || source == target || s.translator.Provider() == translate.ProviderNone {
+		return untranslated, nil
+	}
+
+	sum := sha256.Sum256([]byte(text))
+	hash := hex.EncodeToString(sum[:])
+	cached, err := s.repo.Get(ctx, hash, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached translation: %w", err)
+	}
+	if cached != nil {
+		return &dto.Translation{SourceLanguage: cached.SourceLanguage, TargetLanguage: target, Text: cached.Text, Provider: cached.Provider}, nil
+	}
+
+	result, err := s.translator.Translate(ctx, text, source, target)
+	if err != nil {
+		if errors.Is(err, translate.ErrUnavailable) {
+			slog.WarnContext(ctx, "translation failed", slog.String("provider", s.translator.Provider()), slog.Any("error", err))
+			return nil, ErrTranslationUnavailable
+		}
+		return nil, err
+	}
+	if result.Source == target {
+		return untranslated, nil
+	}
+
+	translation := &model.Translation{
+		ContentHash:    hash,
+		TargetLanguage: target,
+		SourceLanguage: result.Source,
+		Text:           result.Text,
+		Provider:       s.translator.Provider(),
+	}
+	if err := s.repo.Save(ctx, translation); err != nil {
+		slog.WarnContext(ctx, "failed to cache translation", slog.Any("error", err))
+	}
+	return &dto.Translation{SourceLanguage: result.Source, TargetLanguage: target, Text: result.Text, Provider: translation.Provider}, nil
+}
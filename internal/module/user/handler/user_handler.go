@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	presencesvc "github.com/ilhamosaurus/sns-platform/internal/module/presence/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/module/user/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/langdetect"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+// MaxContentLanguages caps the languages a user can pick for discovery
+const MaxContentLanguages = 10
+
+type UserHandler struct {
+	repo     repository.UserRepository
+	accounts service.AccountService
+	presence presencesvc.PresenceService
+}
+
+// NewUserHandler creates the user handler; presence may be nil to leave it
+// off profiles
+func NewUserHandler(repo repository.UserRepository, accounts service.AccountService, presence presencesvc.PresenceService) *UserHandler {
+	return &UserHandler{repo: repo, accounts: accounts, presence: presence}
+}
+
+// Register mounts the profile and account routes; they expect an authenticated user in the request context
+func (h *UserHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /users/{username}", h.Profile)
+	mux.HandleFunc("POST /me/deactivate", h.Deactivate)
+	mux.HandleFunc("POST /me/reactivate", h.Reactivate)
+	mux.HandleFunc("GET /me/content-languages", h.ContentLanguages)
+	mux.HandleFunc("PUT /me/content-languages", h.SetContentLanguages)
+	mux.HandleFunc("GET /me/region", h.Region)
+	mux.HandleFunc("PUT /me/region", h.SetRegion)
+}
+
+// Profile returns a user's profile as seen by the caller. It carries an ETag
+// and Last-Modified, so unchanged profiles are revalidated with 304s.
+func (h *UserHandler) Profile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	profile, err := h.repo.GetUserProfile(r.Context(), r.PathValue("username"), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	lastModified := profile.UpdatedAt
+	if h.presence != nil {
+		// A profile without presence beats no profile
+		if presence, err := h.presence.GetPresence(r.Context(), []int64{profile.ID}); err != nil {
+			slog.WarnContext(r.Context(), "failed to get presence", slog.Int64("user_id", profile.ID), slog.Any("error", err))
+		} else {
+			profile.Presence = presence[profile.ID]
+			if last := profile.Presence.LastSeen; last != nil && last.After(lastModified) {
+				lastModified = *last
+			}
+		}
+	}
+	httpx.ConditionalJSON(w, r, profile, lastModified)
+}
+
+// Deactivate hides the caller's profile, posts and comments until they
+// reactivate or log in again
+func (h *UserHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if err := h.accounts.Deactivate(r.Context(), userID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Reactivate restores the caller's deactivated account, unless their
+// identity provider suspended it
+func (h *UserHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	user, err := h.repo.GetByID(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	if user.IsSuspended() {
+		httpx.Error(w, http.StatusForbidden, "account is suspended by your organization")
+		return
+	}
+
+	if err := h.accounts.Reactivate(r.Context(), userID); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ContentLanguages returns the languages the caller discovers posts in; an
+// empty list means all
+func (h *UserHandler) ContentLanguages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	user, err := h.repo.GetByID(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"languages": languagesOf(user.PreferredLanguages())})
+}
+
+// SetContentLanguages replaces the languages the caller discovers posts in
+func (h *UserHandler) SetContentLanguages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		Languages []string `json:"languages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	languages := make([]string, 0, len(body.Languages))
+	for _, lang := range body.Languages {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if !langdetect.IsCode(lang) {
+			httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("%q is not an ISO 639-1 language code", lang))
+			return
+		}
+		if !slices.Contains(languages, lang) {
+			languages = append(languages, lang)
+		}
+	}
+	if len(languages) > MaxContentLanguages {
+		httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("at most %d languages can be chosen", MaxContentLanguages))
+		return
+	}
+
+	if err := h.repo.Update(r.Context(), userID, map[string]any{"content_languages": strings.Join(languages, ",")}); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"languages": languages})
+}
+
+// Region returns the country the caller's posts are placed in; empty when unset
+func (h *UserHandler) Region(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	user, err := h.repo.GetByID(r.Context(), userID)
+	if err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"region": user.Region})
+}
+
+// SetRegion sets the country the caller's new posts are placed in, or clears
+// it when empty; posts already made keep theirs
+func (h *UserHandler) SetRegion(w http.ResponseWriter, r *http.Request) {
+	userID, ok := logger.UserID(r.Context())
+	if !ok {
+		httpx.Error(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		Region string `json:"region"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	region := ""
+	if strings.TrimSpace(body.Region) != "" {
+		var ok bool
+		if region, ok = model.ParseRegion(body.Region); !ok {
+			httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("%q is not an ISO 3166-1 alpha-2 country code", body.Region))
+			return
+		}
+	}
+
+	if err := h.repo.Update(r.Context(), userID, map[string]any{"region": region}); err != nil {
+		httpx.Fail(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"region": region})
+}
+
+// languagesOf keeps an empty preference a JSON array rather than null
+func languagesOf(languages []string) []string {
+	if languages == nil {
+		return []string{}
+	}
+	return languages
+}
@@ -3,9 +3,12 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/ilhamosaurus/sns-platform/internal/dto"
 	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
 	"github.com/ilhamosaurus/sns-platform/pkg/types"
 	"gorm.io/gorm"
 )
@@ -14,11 +17,14 @@ type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	Update(ctx context.Context, id int64, updates map[string]any) error
 	GetByID(ctx context.Context, id int64) (*model.User, error)
-	List(ctx context.Context, query map[string]any, page, pageSize int) ([]*model.User, int64, error)
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	List(ctx context.Context, query map[string]any, page, pageSize int, opts ...pkgdb.ListOption) ([]*model.User, int64, error)
 	Delete(ctx context.Context, id int64) error
 	GetUserProfile(ctx context.Context, username string, viewerID int64) (*dto.UserProfile, error)
 	UpdateFollowCount(ctx context.Context, username string, action types.Action) error
 	UpdatePostCount(ctx context.Context, id int64, action types.Action) error
+	Deactivate(ctx context.Context, id int64, at time.Time) (bool, error)
+	Reactivate(ctx context.Context, id int64) (bool, error)
 }
 
 func NewUserRepository(db *gorm.DB) UserRepository {
@@ -30,26 +36,34 @@ type userRepository struct {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *model.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	return apperr.Translate(r.db.WithContext(ctx).Create(user).Error, "user")
 }
 
 func (r *userRepository) Update(ctx context.Context, id int64, updates map[string]any) error {
-	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ? AND deleted_at IS NULL", id).Updates(updates).Error
+	return apperr.Translate(r.db.WithContext(ctx).Model(&model.User{}).Where("id = ? AND deleted_at IS NULL", id).Updates(updates).Error, "user")
 }
 
 func (r userRepository) GetByID(ctx context.Context, id int64) (*model.User, error) {
 	var user model.User
 	if err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&user).Error; err != nil {
-		return nil, err
+		return nil, apperr.Translate(err, "user")
 	}
 	return &user, nil
 }
 
-func (r *userRepository) List(ctx context.Context, query map[string]any, page, pageSize int) ([]*model.User, int64, error) {
-	var (
-		users      []*model.User
-		totalCount int64
-	)
+// GetByUsername finds a user by username, ignoring case
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).Where("LOWER(username) = LOWER(?) AND deleted_at IS NULL", username).First(&user).Error; err != nil {
+		return nil, apperr.Translate(err, "user")
+	}
+	return &user, nil
+}
+
+// List returns a page of users matching query with their total, counted as
+// selected by opts (exact by default, NoCount when omitted)
+func (r *userRepository) List(ctx context.Context, query map[string]any, page, pageSize int, opts ...pkgdb.ListOption) ([]*model.User, int64, error) {
+	var users []*model.User
 
 	db := r.db.WithContext(ctx).Model(&model.User{}).Where("deleted_at IS NULL")
 
@@ -57,7 +71,8 @@ func (r *userRepository) List(ctx context.Context, query map[string]any, page, p
 		db = db.Where(key, value)
 	}
 
-	if err := db.Count(&totalCount).Error; err != nil {
+	totalCount, err := pkgdb.CountRows(ctx, db, "users", len(query) > 0, pkgdb.NewListOptions(opts...).Count)
+	if err != nil {
 		return nil, 0, err
 	}
 
@@ -69,14 +84,28 @@ func (r *userRepository) List(ctx context.Context, query map[string]any, page, p
 	return users, totalCount, nil
 }
 
+// Delete soft-deletes a user, renaming them to their tombstone so their
+// username and email can be registered again; the phone and contact hashes
+// are dropped
 func (r *userRepository) Delete(ctx context.Context, id int64) error {
-	return r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).Delete(&model.User{}).Error
+	username, email := model.Tombstone(id)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).
+			Where("id = ? AND deleted_at IS NULL", id).
+			UpdateColumns(map[string]any{"username": username, "email": email, "phone": "", "email_hash": "", "phone_hash": ""}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND deleted_at IS NULL", id).Delete(&model.User{}).Error
+	})
 }
 
 func (r *userRepository) GetUserProfile(ctx context.Context, username string, viewerID int64) (*dto.UserProfile, error) {
-	var profile dto.UserProfile
-
-	err := r.db.Table("users").
+	var row struct {
+		model.User
+		IsFollowing bool
+	}
+	err := r.db.WithContext(ctx).Table("users").
 		Select(`
 			users.*,
 			CASE WHEN viewer_follows.id IS NOT NULL THEN true ELSE false END as is_following
@@ -84,13 +113,14 @@ func (r *userRepository) GetUserProfile(ctx context.Context, username string, vi
 		Joins(`LEFT JOIN follows viewer_follows ON users.id = viewer_follows.following_id 
 			AND viewer_follows.follower_id = ? 
 			AND viewer_follows.deleted_at IS NULL`, viewerID).
-		Where("users.username = ? AND users.deleted_at IS NULL", username).
-		First(&profile).Error
+		Where("LOWER(users.username) = LOWER(?) AND users.deleted_at IS NULL", username).
+		Where("users.deactivated_at IS NULL OR users.id = ?", viewerID).
+		First(&row).Error
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch user profile: %w", err)
+		return nil, fmt.Errorf("failed to fetch user profile: %w", apperr.Translate(err, "user"))
 	}
 
-	return &profile, nil
+	return dto.NewUserProfile(&row.User, row.IsFollowing), nil
 }
 
 func (r *userRepository) UpdateFollowCount(ctx context.Context, username string, action types.Action) error {
@@ -111,7 +141,8 @@ func (r *userRepository) UpdateFollowCount(ctx context.Context, username string,
 	default:
 		return fmt.Errorf("invalid action type: %s", action.String())
 	}
-	return r.db.WithContext(ctx).Model(&model.User{}).Where(`username = ? AND deleted_at IS NULL`, username).UpdateColumn(column, gorm.Expr(expr, 1)).Error
+	return r.db.WithContext(ctx).Model(&model.User{}).Where(`LOWER(username) = LOWER(?) AND deleted_at IS NULL`, username).
+		UpdateColumns(map[string]any{column: gorm.Expr(expr, 1), "updated_at": time.Now()}).Error
 }
 
 func (r *userRepository) UpdatePostCount(ctx context.Context, id int64, action types.Action) error {
@@ -125,5 +156,32 @@ func (r *userRepository) UpdatePostCount(ctx context.Context, id int64, action t
 		return fmt.Errorf("invalid action type: %s", action.String())
 	}
 
-	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ? AND deleted_at IS NULL", id).UpdateColumn("post_count", gorm.Expr(expr, 1)).Error
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ? AND deleted_at IS NULL", id).
+		UpdateColumns(map[string]any{"post_count": gorm.Expr(expr, 1), "updated_at": time.Now()}).Error
+}
+
+// Deactivate hides the user until Reactivate; it reports false if they were
+// already deactivated
+func (r *userRepository) Deactivate(ctx context.Context, id int64, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND deactivated_at IS NULL AND deleted_at IS NULL", id).
+		Update("deactivated_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Reactivate brings a deactivated user back; it reports false if they were
+// not deactivated or are suspended by their identity provider
+func (r *userRepository) Reactivate(ctx context.Context, id int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND deactivated_at IS NOT NULL AND suspended_at IS NULL AND deleted_at IS NULL", id).
+		Update("deactivated_at", nil)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ActiveAuthors drops rows whose userColumn, e.g. "posts.user_id", belongs to a
+// deactivated account
+func ActiveAuthors(userColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(userColumn + ` NOT IN (SELECT id FROM users WHERE deactivated_at IS NOT NULL)`)
+	}
 }
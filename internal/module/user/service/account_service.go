@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+)
+
+// AccountService deactivates and reactivates accounts. Deactivation is
+// temporary, unlike deletion: the profile, posts and comments are hidden from
+// everyone else and come back untouched on reactivation.
+type AccountService interface {
+	Deactivate(ctx context.Context, userID int64) error
+	Reactivate(ctx context.Context, userID int64) error
+}
+
+type accountService struct {
+	repo  repository.UserRepository
+	audit auditsvc.AuditService
+}
+
+func NewAccountService(repo repository.UserRepository, audit auditsvc.AuditService) AccountService {
+	return &accountService{repo: repo, audit: audit}
+}
+
+// Deactivate hides the user's account; deactivating twice is a no-op
+func (s *accountService) Deactivate(ctx context.Context, userID int64) error {
+	changed, err := s.repo.Deactivate(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to deactivate account: %w", err)
+	}
+	if changed {
+		s.audit.Record(ctx, &model.AuditLog{
+			ActorID:    &userID,
+			Action:     model.AuditAccountDeactivate,
+			TargetType: model.AuditTargetUser,
+			TargetID:   userID,
+		})
+	}
+	return nil
+}
+
+// Reactivate restores a deactivated account; active accounts are left alone
+func (s *accountService) Reactivate(ctx context.Context, userID int64) error {
+	changed, err := s.repo.Reactivate(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to reactivate account: %w", err)
+	}
+	if changed {
+		s.audit.Record(ctx, &model.AuditLog{
+			ActorID:    &userID,
+			Action:     model.AuditAccountReactivate,
+			TargetType: model.AuditTargetUser,
+			TargetID:   userID,
+		})
+	}
+	return nil
+}
+
+// ReactivateOnLogin brings deactivated accounts back when their owner logs in
+func ReactivateOnLogin(bus eventbus.Bus, svc AccountService) {
+	eventbus.On(bus, func(ctx context.Context, e event.UserLoggedIn) error {
+		return svc.Reactivate(ctx, e.UserID)
+	})
+}
@@ -0,0 +1,23 @@
+package service
+
+import "strings"
+
+// NormalizeEmail trims and lowercases an email address so addresses differing
+// only in case belong to one account. With stripPlus, a "+tag" suffix of the
+// local part is dropped as well, so "Ann+news@example.com" becomes
+// "ann@example.com".
+func NormalizeEmail(email string, stripPlus bool) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !stripPlus {
+		return email
+	}
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return email
+	}
+	local, domain := email[:at], email[at:]
+	if plus := strings.IndexByte(local, '+'); plus > 0 {
+		local = local[:plus]
+	}
+	return local + domain
+}
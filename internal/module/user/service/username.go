@@ -0,0 +1,83 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+)
+
+// Username length limits, in characters
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 30
+)
+
+// DefaultReservedUsernames are kept from users when no list is configured:
+// staff and system names people could pass themselves off with, and the top
+// level routes a profile path could be mistaken for
+var DefaultReservedUsernames = []string{
+	"about", "abuse", "admin", "administrator", "announcements", "api",
+	"comments", "data-exports", "events", "explore", "feed", "help", "me",
+	"media", "messages", "metrics", "moderator", "noreply", "notifications",
+	"oauth", "oembed", "official", "orgs", "p", "pages", "posts", "postmaster",
+	"privacy", "root", "scim", "security", "settings", "sso", "staff",
+	"support", "system", "terms", "u", "users", "www",
+}
+
+// ErrUsernameReserved is returned for usernames on the reserved list
+var ErrUsernameReserved = apperr.Conflict("username is reserved")
+
+// UsernamePolicy decides which usernames accounts can be created with or
+// renamed to. Existing usernames are left alone.
+type UsernamePolicy struct {
+	reserved map[string]bool
+}
+
+// NewUsernamePolicy reserves the given names, or DefaultReservedUsernames
+// when there are none
+func NewUsernamePolicy(reserved []string) *UsernamePolicy {
+	if len(reserved) == 0 {
+		reserved = DefaultReservedUsernames
+	}
+	p := &UsernamePolicy{reserved: make(map[string]bool, len(reserved))}
+	for _, name := range reserved {
+		p.reserved[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	return p
+}
+
+// Validate returns why username cannot be taken, or nil. Usernames are
+// MinUsernameLength to MaxUsernameLength ASCII letters, digits, dots and
+// underscores, start and end with a letter or digit, and are not reserved
+// in any case.
+func (p *UsernamePolicy) Validate(username string) error {
+	if !validUsername(username) {
+		return fmt.Errorf("username must be %d to %d letters, digits, dots or underscores, starting and ending with a letter or digit",
+			MinUsernameLength, MaxUsernameLength)
+	}
+	if p.Reserved(username) {
+		return ErrUsernameReserved
+	}
+	return nil
+}
+
+// Reserved reports whether username is on the reserved list, in any case
+func (p *UsernamePolicy) Reserved(username string) bool {
+	return p.reserved[strings.ToLower(username)]
+}
+
+func validUsername(s string) bool {
+	if len(s) < MinUsernameLength || len(s) > MaxUsernameLength {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case (c == '.' || c == '_') && i > 0 && i < len(s)-1:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/internal/module/waitlist/service"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+)
+
+type WaitlistHandler struct {
+	service service.WaitlistService
+}
+
+func NewWaitlistHandler(svc service.WaitlistService) *WaitlistHandler {
+	return &WaitlistHandler{service: svc}
+}
+
+// Register mounts the waitlist sign-up route; it needs no authentication
+func (h *WaitlistHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /waitlist", h.Join)
+}
+
+// Join puts an email on the waitlist and returns its place in line; joining
+// again with the same email only reports the place
+func (h *WaitlistHandler) Join(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	position, err := h.service.Join(r.Context(), body.Email)
+	if err != nil {
+		writeWaitlistError(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, position)
+}
+
+type AdminHandler struct {
+	service service.WaitlistService
+}
+
+func NewAdminHandler(svc service.WaitlistService) *AdminHandler {
+	return &AdminHandler{service: svc}
+}
+
+// Register mounts the waitlist admin routes; callers are expected to wrap mux with admin auth
+func (h *AdminHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/waitlist", h.List)
+	mux.HandleFunc("POST /admin/waitlist/activate", h.Activate)
+}
+
+// List returns waitlist entries in line order, or with ?status=activated
+// the activated ones, latest first, along with how many are in each state
+func (h *AdminHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit := min(max(httpx.QueryInt(r, "limit", 50), 1), 500)
+	offset := max(httpx.QueryInt(r, "offset", 0), 0)
+	var activated bool
+	switch r.URL.Query().Get("status") {
+	case "", "waiting":
+	case "activated":
+		activated = true
+	default:
+		httpx.Error(w, http.StatusBadRequest, "status must be waiting or activated")
+		return
+	}
+
+	entries, err := h.service.List(r.Context(), activated, limit, offset)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	stats, err := h.service.Stats(r.Context())
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"entries": entries, "stats": stats})
+}
+
+// Activate lets in the next count entries in line and emails each of them
+func (h *AdminHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	entries, err := h.service.Activate(r.Context(), body.Count)
+	if err != nil {
+		writeWaitlistError(w, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"activated": len(entries), "entries": entries})
+}
+
+func writeWaitlistError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidEmail), errors.Is(err, service.ErrInvalidBatch):
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+	default:
+		httpx.Fail(w, err)
+	}
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"gorm.io/gorm"
+)
+
+type WaitlistRepository interface {
+	Create(ctx context.Context, entry *model.WaitlistEntry) error
+	GetByEmail(ctx context.Context, email string) (*model.WaitlistEntry, error)
+	Position(ctx context.Context, id int64) (int64, error)
+	ActivateNext(ctx context.Context, count int, now time.Time) ([]*model.WaitlistEntry, error)
+	List(ctx context.Context, activated bool, limit, offset int) ([]*model.WaitlistEntry, error)
+	Count(ctx context.Context, activated bool) (int64, error)
+}
+
+func NewWaitlistRepository(db *gorm.DB) WaitlistRepository {
+	return &waitlistRepository{db: db}
+}
+
+type waitlistRepository struct {
+	db *gorm.DB
+}
+
+func (r *waitlistRepository) Create(ctx context.Context, entry *model.WaitlistEntry) error {
+	return apperr.Translate(r.db.WithContext(ctx).Create(entry).Error, "waitlist entry")
+}
+
+func (r *waitlistRepository) GetByEmail(ctx context.Context, email string) (*model.WaitlistEntry, error) {
+	var entry model.WaitlistEntry
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&entry).Error; err != nil {
+		return nil, apperr.Translate(err, "waitlist entry")
+	}
+	return &entry, nil
+}
+
+// Position counts the waiting entries up to and including id, so the next
+// one in line is at 1
+func (r *waitlistRepository) Position(ctx context.Context, id int64) (int64, error) {
+	var position int64
+	err := r.db.WithContext(ctx).Model(&model.WaitlistEntry{}).
+		Where("activated_at IS NULL AND id <= ?", id).
+		Count(&position).Error
+	return position, err
+}
+
+// ActivateNext activates up to count of the longest-waiting entries and
+// returns them. Each entry is only activated if still waiting, so a batch
+// running alongside another cannot activate anyone twice.
+func (r *waitlistRepository) ActivateNext(ctx context.Context, count int, now time.Time) ([]*model.WaitlistEntry, error) {
+	var activated []*model.WaitlistEntry
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var next []*model.WaitlistEntry
+		if err := tx.Where("activated_at IS NULL").Order("id ASC").Limit(count).Find(&next).Error; err != nil {
+			return err
+		}
+		for _, entry := range next {
+			res := tx.Model(&model.WaitlistEntry{}).
+				Where("id = ? AND activated_at IS NULL", entry.ID).
+				UpdateColumn("activated_at", now)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 1 {
+				entry.ActivatedAt = &now
+				activated = append(activated, entry)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return activated, nil
+}
+
+// List returns waiting entries in line order, or activated ones most
+// recently activated first
+func (r *waitlistRepository) List(ctx context.Context, activated bool, limit, offset int) ([]*model.WaitlistEntry, error) {
+	query := r.db.WithContext(ctx).Where("activated_at IS NULL")
+	order := "id ASC"
+	if activated {
+		query = r.db.WithContext(ctx).Where("activated_at IS NOT NULL")
+		order = "activated_at DESC, id DESC"
+	}
+	var entries []*model.WaitlistEntry
+	if err := query.Order(order).Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *waitlistRepository) Count(ctx context.Context, activated bool) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.WaitlistEntry{}).Where("activated_at IS NULL")
+	if activated {
+		query = r.db.WithContext(ctx).Model(&model.WaitlistEntry{}).Where("activated_at IS NOT NULL")
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
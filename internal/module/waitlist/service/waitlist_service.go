@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/event"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	auditsvc "github.com/ilhamosaurus/sns-platform/internal/module/audit/service"
+	usersvc "github.com/ilhamosaurus/sns-platform/internal/module/user/service"
+	"github.com/ilhamosaurus/sns-platform/internal/module/waitlist/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/challenge"
+	"github.com/ilhamosaurus/sns-platform/pkg/eventbus"
+)
+
+// MaxBatch bounds how many entries one activation lets in
+const MaxBatch = 1000
+
+var (
+	ErrInvalidEmail = errors.New("a valid email address is required")
+	ErrInvalidBatch = fmt.Errorf("count must be between 1 and %d", MaxBatch)
+)
+
+type Config struct {
+	StripEmailPlus bool // Passed to usersvc.NormalizeEmail
+}
+
+// WaitlistService queues sign-ups while the waitlist feature is on and lets
+// them in, oldest first, in batches an admin or the scheduler starts. Each
+// activated email gets word through a WaitlistActivated event.
+type WaitlistService interface {
+	Join(ctx context.Context, email string) (*dto.WaitlistPosition, error)
+	Admit(ctx context.Context, email string) error
+	Activate(ctx context.Context, count int) ([]*model.WaitlistEntry, error)
+	List(ctx context.Context, activated bool, limit, offset int) ([]*model.WaitlistEntry, error)
+	Stats(ctx context.Context) (*dto.WaitlistStats, error)
+}
+
+type waitlistService struct {
+	repo       repository.WaitlistRepository
+	bus        eventbus.Bus
+	audit      auditsvc.AuditService
+	challenges *challenge.Challenge
+	stripPlus  bool
+}
+
+func NewWaitlistService(repo repository.WaitlistRepository, bus eventbus.Bus, audit auditsvc.AuditService, challenges *challenge.Challenge, config Config) WaitlistService {
+	return &waitlistService{repo: repo, bus: bus, audit: audit, challenges: challenges, stripPlus: config.StripEmailPlus}
+}
+
+// Join puts email on the waitlist, or finds it there already, and returns
+// its place in line. Anyone may join, so it is challenged like a sign-in.
+func (s *waitlistService) Join(ctx context.Context, email string) (*dto.WaitlistPosition, error) {
+	if err := s.challenges.Require(ctx, 0, challenge.ActionSignIn); err != nil {
+		return nil, err
+	}
+	entry, err := s.join(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	return s.position(ctx, entry)
+}
+
+// Admit lets an activated email through to sign-up. Any other email joins
+// the waitlist and is refused with its place in line.
+func (s *waitlistService) Admit(ctx context.Context, email string) error {
+	entry, err := s.join(ctx, email)
+	if err != nil {
+		return err
+	}
+	if entry.Activated() {
+		return nil
+	}
+	position, err := s.position(ctx, entry)
+	if err != nil {
+		return err
+	}
+	return apperr.Forbidden(fmt.Sprintf("sign-ups are waitlisted; you are number %d in line and will get an email once your account is activated", position.Position)).WithCode(apperr.CodeWaitlisted)
+}
+
+// Activate lets in the count longest-waiting entries. It is audited, and
+// each activated email is told through a WaitlistActivated event.
+func (s *waitlistService) Activate(ctx context.Context, count int) ([]*model.WaitlistEntry, error) {
+	if count < 1 || count > MaxBatch {
+		return nil, ErrInvalidBatch
+	}
+	entries, err := s.repo.ActivateNext(ctx, count, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to activate waitlist entries: %w", err)
+	}
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	s.audit.Record(ctx, &model.AuditLog{
+		Action:     model.AuditAdminActivateWaitlist,
+		TargetType: model.AuditTargetWaitlist,
+		After:      map[string]any{"activated": len(entries), "first_id": entries[0].ID, "last_id": entries[len(entries)-1].ID},
+	})
+	for _, entry := range entries {
+		if err := s.bus.Publish(ctx, event.WaitlistActivated{
+			EntryID:     entry.ID,
+			Email:       entry.Email,
+			ActivatedAt: *entry.ActivatedAt,
+		}); err != nil {
+			slog.WarnContext(ctx, "failed to publish waitlist activation", slog.Int64("entry_id", entry.ID), slog.Any("error", err))
+		}
+	}
+	return entries, nil
+}
+
+func (s *waitlistService) List(ctx context.Context, activated bool, limit, offset int) ([]*model.WaitlistEntry, error) {
+	return s.repo.List(ctx, activated, limit, offset)
+}
+
+func (s *waitlistService) Stats(ctx context.Context) (*dto.WaitlistStats, error) {
+	waiting, err := s.repo.Count(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count waitlist: %w", err)
+	}
+	activated, err := s.repo.Count(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count waitlist: %w", err)
+	}
+	return &dto.WaitlistStats{Waiting: waiting, Activated: activated}, nil
+}
+
+// join returns the entry of email, creating it if email is new
+func (s *waitlistService) join(ctx context.Context, email string) (*model.WaitlistEntry, error) {
+	email = usersvc.NormalizeEmail(email, s.stripPlus)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email || len(email) > 100 {
+		return nil, ErrInvalidEmail
+	}
+
+	entry, err := s.repo.GetByEmail(ctx, email)
+	if err == nil {
+		return entry, nil
+	}
+	if !errors.Is(err, apperr.ErrNotFound) {
+		return nil, err
+	}
+	entry = &model.WaitlistEntry{Email: email}
+	err = s.repo.Create(ctx, entry)
+	if errors.Is(err, apperr.ErrConflict) {
+		// Joined at the same moment from elsewhere
+		return s.repo.GetByEmail(ctx, email)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to join waitlist: %w", err)
+	}
+	return entry, nil
+}
+
+func (s *waitlistService) position(ctx context.Context, entry *model.WaitlistEntry) (*dto.WaitlistPosition, error) {
+	position := &dto.WaitlistPosition{Email: entry.Email, ActivatedAt: entry.ActivatedAt}
+	if entry.Activated() {
+		return position, nil
+	}
+	n, err := s.repo.Position(ctx, entry.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find waitlist position: %w", err)
+	}
+	position.Position = n
+	return position, nil
+}
@@ -0,0 +1,66 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	announcementrepo "github.com/ilhamosaurus/sns-platform/internal/module/announcement/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+)
+
+func testAnnouncements(t *testing.T, f *fixtures) {
+	repo := announcementrepo.NewAnnouncementRepository(f.db)
+	ctx := t.Context()
+	admin, reader, other := f.user(t), f.user(t), f.user(t)
+	now := time.Now().UTC()
+
+	running := &model.Announcement{AuthorID: admin.ID, Title: "Maintenance", Body: "Tonight", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}
+	scheduled := &model.Announcement{AuthorID: admin.ID, Title: "Launch", Body: "Soon", StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)}
+	ended := &model.Announcement{AuthorID: admin.ID, Title: "Old", Body: "Over", StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)}
+	for _, a := range []*model.Announcement{running, scheduled, ended} {
+		must(t, repo.Create(ctx, a))
+	}
+
+	active, err := repo.Active(ctx, reader.ID, now)
+	must(t, err)
+	if len(active) != 1 || active[0].ID != running.ID {
+		t.Errorf("Active = %d announcements, want the running one", len(active))
+	}
+	active, err = repo.Active(ctx, reader.ID, now.Add(90*time.Minute))
+	must(t, err)
+	if len(active) != 1 || active[0].ID != scheduled.ID {
+		t.Errorf("Active later = %d announcements, want the scheduled one", len(active))
+	}
+
+	must(t, repo.Dismiss(ctx, running.ID, reader.ID))
+	must(t, repo.Dismiss(ctx, running.ID, reader.ID))
+	for user, want := range map[int64]int{reader.ID: 0, other.ID: 1} {
+		active, err := repo.Active(ctx, user, now)
+		must(t, err)
+		if len(active) != want {
+			t.Errorf("Active for user %d = %d announcements, want %d", user, len(active), want)
+		}
+	}
+
+	must(t, repo.Update(ctx, scheduled.ID, map[string]any{"starts_at": now.Add(-time.Minute)}))
+	active, err = repo.Active(ctx, reader.ID, now)
+	must(t, err)
+	if len(active) != 1 || active[0].ID != scheduled.ID {
+		t.Errorf("Active after moving the start = %d announcements, want the rescheduled one", len(active))
+	}
+
+	listed, err := repo.List(ctx, 10, 0)
+	must(t, err)
+	if len(listed) < 3 {
+		t.Errorf("List = %d announcements, want at least 3", len(listed))
+	}
+	must(t, repo.Delete(ctx, scheduled.ID))
+	if err := repo.Delete(ctx, scheduled.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("Delete twice = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.GetByID(ctx, scheduled.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetByID after Delete = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,72 @@
+package repotest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	commentrepo "github.com/ilhamosaurus/sns-platform/internal/module/comment/repository"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+func testComments(t *testing.T, f *fixtures) {
+	repo := commentrepo.NewCommentRepository(f.db)
+	feeds := feedrepo.NewFeedRepository(f.db)
+	ctx := t.Context()
+	author, reader := f.user(t), f.user(t)
+	post := f.post(t, author.ID)
+	now := time.Now().UTC()
+
+	comment := func(age time.Duration, likes, replies int64) *model.Comment {
+		c := &model.Comment{
+			BaseModel:    model.BaseModel{CreatedAt: now.Add(-age)},
+			PostID:       post.ID,
+			UserID:       reader.ID,
+			Content:      "comment",
+			LikesCount:   likes,
+			RepliesCount: replies,
+		}
+		must(t, f.db.Create(c).Error)
+		return c
+	}
+	quiet := comment(time.Hour, 0, 0)
+	stale := comment(3*24*time.Hour, 20, 0)
+	fresh := comment(2*time.Hour, 3, 1)
+	archived := comment(30*24*time.Hour, 50, 0)
+
+	if _, err := repo.Rescore(ctx, now.Add(-7*24*time.Hour), now); err != nil {
+		t.Fatal(err)
+	}
+	scores := map[int64]float64{}
+	var rows []*model.Comment
+	must(t, f.db.Where("post_id = ?", post.ID).Find(&rows).Error)
+	for _, c := range rows {
+		scores[c.ID] = c.RankScore
+	}
+	if scores[quiet.ID] != 0 || scores[archived.ID] != 0 {
+		t.Errorf("rank scores of a comment without engagement and one outside the window = %v, %v, want 0", scores[quiet.ID], scores[archived.ID])
+	}
+	if !(scores[fresh.ID] > scores[stale.ID] && scores[stale.ID] > 0) {
+		t.Errorf("rank scores fresh %v, stale %v, want fresh above stale above 0", scores[fresh.ID], scores[stale.ID])
+	}
+	if got, want := commentrepo.RankScore(2, 1, 2*time.Hour), 0.5; got != want {
+		t.Errorf("RankScore(2 likes, 1 reply, 2h) = %v, want %v", got, want)
+	}
+
+	detail, err := feeds.GetPostWithDetails(ctx, post.ID, reader.ID, types.CommentSortTop)
+	must(t, err)
+	var order []int64
+	for _, c := range detail.Comments {
+		order = append(order, c.ID)
+	}
+	if want := []int64{fresh.ID, stale.ID, archived.ID, quiet.ID}; !slices.Equal(order, want) {
+		t.Errorf("top comments = %v, want %v", order, want)
+	}
+	detail, err = feeds.GetPostWithDetails(ctx, post.ID, reader.ID, types.CommentSortOldest)
+	must(t, err)
+	if len(detail.Comments) != 4 || detail.Comments[0].ID != archived.ID {
+		t.Errorf("oldest comments start with %d, want %d", detail.Comments[0].ID, archived.ID)
+	}
+}
@@ -0,0 +1,155 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	communityrepo "github.com/ilhamosaurus/sns-platform/internal/module/community/repository"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+func testCommunities(t *testing.T, f *fixtures) {
+	repo := communityrepo.NewCommunityRepository(f.db)
+	ctx := t.Context()
+	owner, moderator, member, applicant := f.user(t), f.user(t), f.user(t), f.user(t)
+
+	community := &model.Community{Slug: f.name("c"), Name: "Community", OwnerID: owner.ID}
+	must(t, repo.Create(ctx, community))
+	if err := repo.Create(ctx, &model.Community{Slug: community.Slug, Name: "Copy", OwnerID: owner.ID}); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("Create with a taken slug = %v, want ErrConflict", err)
+	}
+
+	// Members join before the moderator so ordering by role is observable
+	for _, m := range []*model.CommunityMember{
+		{CommunityID: community.ID, UserID: member.ID, Role: types.CommunityRoleMember, Status: types.MembershipStatusActive},
+		{CommunityID: community.ID, UserID: moderator.ID, Role: types.CommunityRoleMember, Status: types.MembershipStatusActive},
+		{CommunityID: community.ID, UserID: applicant.ID, Role: types.CommunityRoleMember, Status: types.MembershipStatusPending},
+	} {
+		must(t, repo.AddMember(ctx, m))
+	}
+	must(t, repo.SetMemberRole(ctx, community.ID, moderator.ID, types.CommunityRoleModerator))
+	must(t, repo.SetMemberStatus(ctx, community.ID, applicant.ID, types.MembershipStatusActive))
+	must(t, repo.SetMemberStatus(ctx, community.ID, member.ID, types.MembershipStatusBanned))
+
+	got, err := repo.GetBySlug(ctx, community.Slug)
+	must(t, err)
+	if got.ID != community.ID || got.MemberCount != 3 {
+		t.Errorf("GetBySlug = community %d with %d members, want %d with 3", got.ID, got.MemberCount, community.ID)
+	}
+
+	members, err := repo.ListMembers(ctx, community.ID, types.MembershipStatusActive, 10, 0)
+	must(t, err)
+	var order []int64
+	for _, m := range members {
+		order = append(order, m.UserID)
+	}
+	if len(order) != 3 || order[0] != owner.ID || order[1] != moderator.ID || order[2] != applicant.ID {
+		t.Errorf("ListMembers = users %v, want owner, moderator, then members", order)
+	}
+
+	listed, err := repo.ListByMember(ctx, moderator.ID, 10, 0)
+	must(t, err)
+	if len(listed) != 1 || listed[0].ID != community.ID {
+		t.Errorf("ListByMember = %d communities, want the community", len(listed))
+	}
+
+	m, err := repo.GetMember(ctx, community.ID, moderator.ID)
+	must(t, err)
+	if m.Role != types.CommunityRoleModerator {
+		t.Errorf("GetMember role = %v, want moderator", m.Role)
+	}
+	must(t, repo.RemoveMember(ctx, community.ID, moderator.ID))
+	if _, err := repo.GetMember(ctx, community.ID, moderator.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetMember after RemoveMember = %v, want ErrNotFound", err)
+	}
+	must(t, repo.AddMember(ctx, &model.CommunityMember{CommunityID: community.ID, UserID: moderator.ID, Role: types.CommunityRoleMember, Status: types.MembershipStatusPending}))
+
+	post := &model.Post{UserID: owner.ID, CommunityID: &community.ID, Content: "hello", IsPublic: false}
+	must(t, repo.CreatePost(ctx, post))
+	feed, err := feedrepo.NewFeedRepository(f.db).GetCommunityFeed(ctx, community.ID, member.ID, 10, 0)
+	must(t, err)
+	if len(feed) != 1 || feed[0].ID != post.ID || feed[0].IsPublic {
+		t.Errorf("GetCommunityFeed = posts %v, want the private post %d", postIDs(feed), post.ID)
+	}
+
+	testCommunityModeration(t, f, repo, community, post, member.ID, applicant.ID)
+
+	authorID, err := repo.DeletePost(ctx, community.ID, post.ID)
+	must(t, err)
+	if authorID != owner.ID {
+		t.Errorf("DeletePost returned author %d, want %d", authorID, owner.ID)
+	}
+	if _, err := repo.DeletePost(ctx, community.ID, post.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("DeletePost twice = %v, want ErrNotFound", err)
+	}
+
+	must(t, repo.Update(ctx, community.ID, map[string]any{"description": "about"}))
+	got, err = repo.GetByID(ctx, community.ID)
+	must(t, err)
+	if got.Description != "about" || got.PostCount != 0 {
+		t.Errorf("GetByID = %q with %d posts, want about with 0", got.Description, got.PostCount)
+	}
+
+	must(t, repo.Delete(ctx, community.ID))
+	if _, err := repo.GetByID(ctx, community.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetByID after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func testCommunityModeration(t *testing.T, f *fixtures, repo communityrepo.CommunityRepository, community *model.Community, post *model.Post, authorID, reporterID int64) {
+	ctx := t.Context()
+
+	must(t, repo.SetFilterRules(ctx, community.ID, []string{"spam", "buy now"}))
+	must(t, repo.SetFilterRules(ctx, community.ID, []string{"scam*", "spam"}))
+	rules, err := repo.FilterRules(ctx, community.ID)
+	must(t, err)
+	if len(rules) != 2 || rules[0].Pattern != "scam*" || rules[1].Pattern != "spam" {
+		t.Errorf("FilterRules = %d rules, want the two set last in order", len(rules))
+	}
+
+	outside := &model.Comment{PostID: f.post(t, authorID).ID, UserID: authorID, Content: "elsewhere"}
+	must(t, f.db.Create(outside).Error)
+	if _, _, err := repo.GetComment(ctx, outside.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetComment outside a community = %v, want ErrNotFound", err)
+	}
+	comment := &model.Comment{PostID: post.ID, UserID: authorID, Content: "reported"}
+	must(t, f.db.Create(comment).Error)
+	got, communityID, err := repo.GetComment(ctx, comment.ID)
+	must(t, err)
+	if got.ID != comment.ID || communityID != community.ID {
+		t.Errorf("GetComment = comment %d in community %d, want %d in %d", got.ID, communityID, comment.ID, community.ID)
+	}
+
+	reports, err := repo.ReportComment(ctx, &model.CommentReport{CommentID: comment.ID, ReporterID: reporterID, Reason: "rude"})
+	must(t, err)
+	if reports != 1 {
+		t.Errorf("ReportComment = %d reports, want 1", reports)
+	}
+	if _, err := repo.ReportComment(ctx, &model.CommentReport{CommentID: comment.ID, ReporterID: reporterID}); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("ReportComment twice = %v, want ErrConflict", err)
+	}
+
+	for i, want := range []bool{true, false} {
+		hidden, err := repo.HideComment(ctx, comment.ID, time.Now())
+		must(t, err)
+		if hidden != want {
+			t.Errorf("HideComment #%d = %v, want %v", i+1, hidden, want)
+		}
+	}
+	for i, want := range []bool{true, false} {
+		restored, err := repo.RestoreComment(ctx, comment.ID)
+		must(t, err)
+		if restored != want {
+			t.Errorf("RestoreComment #%d = %v, want %v", i+1, restored, want)
+		}
+	}
+	reports, err = repo.ReportComment(ctx, &model.CommentReport{CommentID: comment.ID, ReporterID: reporterID})
+	must(t, err)
+	if reports != 1 {
+		t.Errorf("ReportComment after RestoreComment = %d reports, want 1 as the old ones are dropped", reports)
+	}
+}
@@ -0,0 +1,64 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/ilhamosaurus/sns-platform/internal/counter"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+)
+
+func testCounters(t *testing.T, f *fixtures) {
+	ctx := t.Context()
+	author, reader := f.user(t), f.user(t)
+	post := f.post(t, author.ID)
+	must(t, f.db.Create(&model.Comment{PostID: post.ID, UserID: reader.ID, Content: "counted"}).Error)
+	must(t, f.db.Model(&model.Post{}).Where("id = ?", post.ID).UpdateColumn("comment_count", 7).Error)
+
+	drifted := func(results []counter.Result, name string) int64 {
+		for _, r := range results {
+			if r.Counter.Name() == name {
+				return r.Drifted
+			}
+		}
+		t.Fatalf("no result for counter %s", name)
+		return 0
+	}
+	commentCount := func() int64 {
+		var stored model.Post
+		must(t, f.db.First(&stored, post.ID).Error)
+		return stored.CommentCount
+	}
+
+	results, err := counter.Reconcile(ctx, f.db, counter.DryRun(), counter.WithBatchSize(2))
+	must(t, err)
+	if drifted(results, "posts.comment_count") == 0 {
+		t.Error("dry run found no drifted posts.comment_count")
+	}
+	for _, r := range results {
+		if r.Fixed != 0 || len(r.Samples) > counter.MaxSamples || int64(len(r.Samples)) > r.Drifted {
+			t.Errorf("dry run of %s fixed %d with %d samples of %d drifted", r.Counter.Name(), r.Fixed, len(r.Samples), r.Drifted)
+		}
+	}
+	if got := commentCount(); got != 7 {
+		t.Errorf("comment_count after a dry run = %d, want it untouched", got)
+	}
+
+	results, err = counter.Reconcile(ctx, f.db, counter.WithBatchSize(2))
+	must(t, err)
+	for _, r := range results {
+		if r.Fixed != r.Drifted {
+			t.Errorf("%s fixed %d of %d drifted rows", r.Counter.Name(), r.Fixed, r.Drifted)
+		}
+	}
+	if got := commentCount(); got != 1 {
+		t.Errorf("comment_count after reconciling = %d, want 1", got)
+	}
+
+	results, err = counter.Reconcile(ctx, f.db, counter.DryRun())
+	must(t, err)
+	for _, r := range results {
+		if r.Drifted != 0 {
+			t.Errorf("%s has %d drifted rows after reconciling, first %+v", r.Counter.Name(), r.Drifted, r.Samples)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package repotest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/discovery/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+)
+
+func testDiscovery(t *testing.T, f *fixtures) {
+	ctx := t.Context()
+	repo := repository.NewDiscoveryRepository(f.db)
+	users := userrepo.NewUserRepository(f.db)
+	user, byEmail, byPhone, hidden := f.user(t), f.user(t), f.user(t), f.user(t)
+	phone := fmt.Sprintf("+1%010d", time.Now().UnixNano()%1e10) // Unique to the run, like the fixtures
+
+	var stored model.User
+	must(t, f.db.First(&stored, byEmail.ID).Error)
+	if stored.EmailHash != model.HashContact(byEmail.Email) {
+		t.Errorf("email_hash = %q, want the hash of %s", stored.EmailHash, byEmail.Email)
+	}
+	must(t, users.Update(ctx, byEmail.ID, map[string]any{"discoverable_by_email": true}))
+	must(t, users.Update(ctx, byPhone.ID, map[string]any{"phone": phone, "phone_verified_at": time.Now().UTC(), "discoverable_by_phone": true}))
+	must(t, users.Update(ctx, hidden.ID, map[string]any{"phone": phone, "discoverable_by_phone": true})) // Not verified
+	var withPhone model.User
+	must(t, f.db.First(&withPhone, byPhone.ID).Error)
+	if withPhone.PhoneHash != model.HashContact(phone) {
+		t.Errorf("phone_hash = %q after setting the phone, want its hash", withPhone.PhoneHash)
+	}
+
+	emailHashes := []string{model.HashContact(byEmail.Email), model.HashContact(hidden.Email), model.HashContact(user.Email)}
+	found, err := repo.FindByHashes(ctx, user.ID, emailHashes, []string{model.HashContact(phone)}, 10)
+	must(t, err)
+	got := make(map[int64]bool)
+	for _, u := range found {
+		got[u.ID] = true
+	}
+	if len(found) != 2 || !got[byEmail.ID] || !got[byPhone.ID] {
+		t.Errorf("FindByHashes found %v, want only the users discoverable by the matched identifier", got)
+	}
+
+	found, err = repo.FindByHashes(ctx, user.ID, nil, []string{model.HashContact(byEmail.Email)}, 10)
+	must(t, err)
+	if len(found) != 0 {
+		t.Errorf("an email hash uploaded as a phone hash found %d users", len(found))
+	}
+}
@@ -0,0 +1,153 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	archiverepo "github.com/ilhamosaurus/sns-platform/internal/module/archive/repository"
+	exportrepo "github.com/ilhamosaurus/sns-platform/internal/module/export/repository"
+	tenantrepo "github.com/ilhamosaurus/sns-platform/internal/module/tenant/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+func testExports(t *testing.T, f *fixtures) {
+	repo := exportrepo.NewExportRepository(f.db)
+	ctx := t.Context()
+	user := f.user(t)
+
+	if export, err := repo.GetInProgress(ctx, user.ID); err != nil || export != nil {
+		t.Errorf("GetInProgress with no exports = %v, %v, want nil", export, err)
+	}
+
+	export := &model.DataExport{UserID: user.ID, Status: types.ExportStatusPending}
+	must(t, repo.Create(ctx, export))
+	must(t, repo.MarkProcessing(ctx, export.ID))
+	inProgress, err := repo.GetInProgress(ctx, user.ID)
+	must(t, err)
+	if inProgress == nil || inProgress.ID != export.ID {
+		t.Fatalf("GetInProgress = %v, want export %d", inProgress, export.ID)
+	}
+
+	expiresAt := time.Now().Add(-time.Minute).UTC()
+	must(t, repo.MarkReady(ctx, export.ID, "exports/x.zip", 42, expiresAt))
+	got, err := repo.GetByID(ctx, export.ID)
+	must(t, err)
+	if got.Status != types.ExportStatusReady || got.SizeBytes != 42 || got.CompletedAt == nil {
+		t.Errorf("GetByID after MarkReady = %+v", got)
+	}
+
+	expired, err := repo.ListExpired(ctx, time.Now())
+	must(t, err)
+	found := false
+	for _, e := range expired {
+		found = found || e.ID == export.ID
+	}
+	if !found {
+		t.Error("ListExpired is missing an expired export")
+	}
+	must(t, repo.MarkExpired(ctx, export.ID))
+
+	failed := &model.DataExport{UserID: user.ID, Status: types.ExportStatusPending}
+	must(t, repo.Create(ctx, failed))
+	must(t, repo.MarkFailed(ctx, failed.ID, errors.New("disk full")))
+	got, err = repo.GetByID(ctx, failed.ID)
+	must(t, err)
+	if got.Status != types.ExportStatusFailed || got.Error != "disk full" {
+		t.Errorf("GetByID after MarkFailed = %v/%q", got.Status, got.Error)
+	}
+
+	if _, err := repo.GetByID(ctx, 1<<40); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetByID of a missing export = %v, want ErrNotFound", err)
+	}
+}
+
+func testArchive(t *testing.T, f *fixtures) {
+	repo := archiverepo.NewArchiveRepository(f.db)
+	ctx := t.Context()
+	user := f.user(t)
+
+	imp := &model.ArchiveImport{UserID: user.ID, Source: types.ImportSourceMastodon, Status: types.ImportStatusPending}
+	must(t, repo.Create(ctx, imp))
+	must(t, repo.MarkProcessing(ctx, imp.ID, 2))
+
+	must(t, f.db.Model(user).UpdateColumn("region", "ID").Error)
+	if region, err := repo.UserRegion(ctx, user.ID); err != nil || region != "ID" {
+		t.Errorf("UserRegion = %q, %v, want ID", region, err)
+	}
+	if ok, err := repo.HasItem(ctx, user.ID, types.ImportSourceMastodon, "1"); err != nil || ok {
+		t.Errorf("HasItem before import = %v, %v", ok, err)
+	}
+	created := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	post := &model.Post{UserID: user.ID, Content: "imported", IsPublic: false}
+	post.CreatedAt = created
+	item := &model.ImportedItem{UserID: user.ID, Source: types.ImportSourceMastodon, ExternalID: "1", ImportID: imp.ID}
+	must(t, repo.CreatePost(ctx, post, item))
+	if ok, _ := repo.HasItem(ctx, user.ID, types.ImportSourceMastodon, "1"); !ok {
+		t.Error("HasItem after CreatePost = false")
+	}
+	if err := repo.CreatePost(ctx, &model.Post{UserID: user.ID, Content: "again"}, &model.ImportedItem{UserID: user.ID, Source: types.ImportSourceMastodon, ExternalID: "1", ImportID: imp.ID}); err == nil {
+		t.Error("CreatePost stored the same item twice")
+	}
+
+	var stored model.Post
+	must(t, f.db.First(&stored, post.ID).Error)
+	if stored.IsPublic || !stored.CreatedAt.Equal(created) {
+		t.Errorf("imported post is_public=%v created_at=%v, want false and %v", stored.IsPublic, stored.CreatedAt, created)
+	}
+
+	must(t, repo.UpdateProgress(ctx, imp.ID, archiverepo.Progress{Processed: 1, Imported: 1}))
+	must(t, repo.MarkCompleted(ctx, imp.ID, archiverepo.Progress{Processed: 2, Imported: 1, Skipped: 1}))
+	got, err := repo.GetByID(ctx, imp.ID)
+	must(t, err)
+	if got.Status != types.ImportStatusCompleted || got.TotalItems != 2 || got.SkippedItems != 1 || got.CompletedAt == nil {
+		t.Errorf("GetByID after MarkCompleted = %+v", got)
+	}
+
+	failed := &model.ArchiveImport{UserID: user.ID, Source: types.ImportSourceTwitter, Status: types.ImportStatusPending}
+	must(t, repo.Create(ctx, failed))
+	must(t, repo.MarkFailed(ctx, failed.ID, errors.New("bad archive")))
+	if got, _ := repo.GetByID(ctx, failed.ID); got == nil || got.Status != types.ImportStatusFailed {
+		t.Errorf("GetByID after MarkFailed = %+v", got)
+	}
+}
+
+func testTenants(t *testing.T, f *fixtures) {
+	repo := tenantrepo.NewTenantRepository(f.db)
+	ctx := t.Context()
+
+	domain := f.name("t") + ".example.com"
+	acme := &model.Tenant{Slug: f.name("t"), Name: "Acme", Domain: &domain, IsActive: true}
+	must(t, repo.Create(ctx, acme))
+
+	if id, err := repo.IDBySlug(ctx, acme.Slug); err != nil || id != acme.ID {
+		t.Errorf("IDBySlug = %d, %v, want %d", id, err, acme.ID)
+	}
+	if id, err := repo.IDByDomain(ctx, domain); err != nil || id != acme.ID {
+		t.Errorf("IDByDomain = %d, %v, want %d", id, err, acme.ID)
+	}
+
+	must(t, repo.Update(ctx, acme.ID, map[string]any{"is_active": false}))
+	if _, err := repo.IDBySlug(ctx, acme.Slug); !errors.Is(err, tenant.ErrNotFound) {
+		t.Errorf("IDBySlug of an inactive tenant = %v, want ErrNotFound", err)
+	}
+
+	got, err := repo.GetByID(ctx, acme.ID)
+	must(t, err)
+	if got.IsActive {
+		t.Error("GetByID reports a deactivated tenant as active")
+	}
+
+	tenants, err := repo.List(ctx)
+	must(t, err)
+	found := false
+	for _, tn := range tenants {
+		found = found || tn.ID == acme.ID
+	}
+	if !found {
+		t.Error("List is missing the tenant")
+	}
+}
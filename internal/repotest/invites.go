@@ -0,0 +1,62 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/invite/repository"
+)
+
+func testInvites(t *testing.T, f *fixtures) {
+	ctx := t.Context()
+	repo := repository.NewInviteRepository(f.db)
+	inviter := f.user(t)
+	now := time.Now().UTC()
+	past := now.Add(-time.Hour)
+
+	invite := &model.Invite{InviterID: inviter.ID, Code: f.name("I"), MaxUses: 2}
+	expired := &model.Invite{InviterID: inviter.ID, Code: f.name("E"), MaxUses: 1, ExpiresAt: &past}
+	must(t, repo.Create(ctx, invite))
+	must(t, repo.Create(ctx, expired))
+	if count, err := repo.CountUsable(ctx, inviter.ID, now); err != nil || count != 1 {
+		t.Errorf("CountUsable = %d, %v, want 1", count, err)
+	}
+	if got, err := repo.GetByCode(ctx, invite.Code); err != nil || got.ID != invite.ID {
+		t.Errorf("GetByCode = %v, %v, want invite %d", got, err, invite.ID)
+	}
+
+	for i, want := range []bool{true, true, false} {
+		if used, err := repo.Use(ctx, invite.ID, now); err != nil || used != want {
+			t.Errorf("Use #%d = %v, %v, want %v", i+1, used, err, want)
+		}
+	}
+	if used, err := repo.Use(ctx, expired.ID, now); err != nil || used {
+		t.Errorf("Use of an expired invite = %v, %v, want false", used, err)
+	}
+	must(t, repo.Release(ctx, invite.ID))
+	if used, err := repo.Use(ctx, invite.ID, now); err != nil || !used {
+		t.Errorf("Use after release = %v, %v, want true", used, err)
+	}
+
+	other := &model.Invite{InviterID: inviter.ID, Code: f.name("O"), MaxUses: 1}
+	must(t, repo.Create(ctx, other))
+	if revoked, err := repo.Revoke(ctx, inviter.ID+1, other.ID, now); err != nil || revoked {
+		t.Errorf("Revoke by someone else = %v, %v, want false", revoked, err)
+	}
+	if revoked, err := repo.RevokeAll(ctx, inviter.ID, now); err != nil || revoked != 1 {
+		t.Errorf("RevokeAll = %d, %v, want the one usable invite", revoked, err)
+	}
+	if used, err := repo.Use(ctx, other.ID, now); err != nil || used {
+		t.Errorf("Use of a revoked invite = %v, %v, want false", used, err)
+	}
+	if invites, err := repo.ListByInviter(ctx, inviter.ID); err != nil || len(invites) != 3 || invites[0].ID != other.ID {
+		t.Errorf("ListByInviter = %v, %v, want 3 invites, newest first", invites, err)
+	}
+
+	invitee := f.user(t)
+	must(t, f.db.Model(&model.User{}).Where("id = ?", invitee.ID).UpdateColumns(map[string]any{"invited_by_id": inviter.ID, "invite_id": invite.ID}).Error)
+	if users, err := repo.Invitees(ctx, inviter.ID); err != nil || len(users) != 1 || users[0].ID != invitee.ID {
+		t.Errorf("Invitees = %v, %v, want the invitee", users, err)
+	}
+}
@@ -0,0 +1,69 @@
+package repotest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/legalhold/repository"
+	"github.com/ilhamosaurus/sns-platform/internal/retention"
+)
+
+func testLegalHolds(t *testing.T, f *fixtures) {
+	ctx := t.Context()
+	repo := repository.NewLegalHoldRepository(f.db)
+	author, other := f.user(t), f.user(t)
+	old := time.Now().UTC().Add(-3 * time.Hour)
+
+	heldPost, freePost := f.post(t, other.ID), f.post(t, other.ID)
+	authorPost := f.post(t, author.ID)
+	message := &model.Message{SenderID: author.ID, ReceiverID: other.ID, Content: "held"}
+	message.CreatedAt = old
+	must(t, f.db.Create(message).Error)
+	for _, id := range []int64{heldPost.ID, freePost.ID, authorPost.ID} {
+		must(t, f.db.Model(&model.Post{}).Where("id = ?", id).UpdateColumn("deleted_at", old).Error)
+	}
+
+	if exists, err := repo.TargetExists(ctx, model.LegalHoldPost, heldPost.ID); err != nil || !exists {
+		t.Errorf("TargetExists of a deleted post = %v, %v, want true", exists, err)
+	}
+	userHold := &model.LegalHold{TargetType: model.LegalHoldUser, TargetID: author.ID, Reason: "matter"}
+	postHold := &model.LegalHold{TargetType: model.LegalHoldPost, TargetID: heldPost.ID, Reason: "matter"}
+	must(t, repo.Create(ctx, userHold))
+	must(t, repo.Create(ctx, postHold))
+
+	policies, err := retention.Policies(retention.Config{Messages: 2 * time.Hour, SoftDeleted: 2 * time.Hour})
+	must(t, err)
+	_, err = retention.Apply(ctx, f.db, policies)
+	must(t, err)
+	exists := func(row any, id int64) bool {
+		var count int64
+		must(t, f.db.Unscoped().Model(row).Where("id = ?", id).Count(&count).Error)
+		return count == 1
+	}
+	if !exists(&model.Post{}, heldPost.ID) || !exists(&model.Post{}, authorPost.ID) || !exists(&model.Message{}, message.ID) {
+		t.Error("retention deleted a held post, a held user's post or a held user's message")
+	}
+	if exists(&model.Post{}, freePost.ID) {
+		t.Error("retention kept a deleted post no hold covers")
+	}
+
+	if released, err := repo.Release(ctx, postHold.ID, other.ID, time.Now().UTC()); err != nil || !released {
+		t.Errorf("Release = %v, %v, want true", released, err)
+	}
+	if released, err := repo.Release(ctx, postHold.ID, other.ID, time.Now().UTC()); err != nil || released {
+		t.Errorf("Release of a released hold = %v, %v, want false", released, err)
+	}
+	if active, err := repo.List(ctx, true, 100, 0); err != nil || slices.ContainsFunc(active, func(h *model.LegalHold) bool { return h.ID == postHold.ID }) {
+		t.Errorf("List of active holds = %v, %v, want the released hold left out", active, err)
+	}
+	_, err = retention.Apply(ctx, f.db, policies)
+	must(t, err)
+	if exists(&model.Post{}, heldPost.ID) {
+		t.Error("retention kept a post after its hold was released")
+	}
+	// Later runs on the same database must not find the user held
+	_, err = repo.Release(ctx, userHold.ID, other.ID, time.Now().UTC())
+	must(t, err)
+}
@@ -0,0 +1,137 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	messagerepo "github.com/ilhamosaurus/sns-platform/internal/module/message/repository"
+	notificationrepo "github.com/ilhamosaurus/sns-platform/internal/module/notification/repository"
+	restrictionrepo "github.com/ilhamosaurus/sns-platform/internal/module/restriction/repository"
+	securityrepo "github.com/ilhamosaurus/sns-platform/internal/module/security/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+func testNotifications(t *testing.T, f *fixtures) {
+	repo := notificationrepo.NewNotificationRepository(f.db)
+	ctx := t.Context()
+	user, actor := f.user(t), f.user(t)
+
+	follow := &model.Notification{UserID: user.ID, ActorID: actor.ID, Type: types.NotificationTypeFollow, TemplateKey: model.NotificationKeyFollow}
+	must(t, repo.Create(ctx, follow))
+	batch := make([]*model.Notification, 5)
+	for i := range batch {
+		batch[i] = &model.Notification{
+			UserID:      user.ID,
+			ActorID:     actor.ID,
+			Type:        types.NotificationTypeLike,
+			TargetType:  types.NotificationTargetPost,
+			TargetID:    int64(i + 1),
+			TemplateKey: model.NotificationKeyLike,
+			Params:      map[string]string{"n": "x"},
+		}
+	}
+	must(t, repo.CreateBatch(ctx, batch, 2))
+
+	list, err := repo.ListByUser(ctx, user.ID, 10, 0)
+	must(t, err)
+	if len(list) != 6 {
+		t.Fatalf("ListByUser = %d notifications, want 6", len(list))
+	}
+	for _, n := range list {
+		if n.ID == batch[0].ID && (n.Type != types.NotificationTypeLike || n.Params["n"] != "x") {
+			t.Errorf("ListByUser round-tripped %+v", n)
+		}
+	}
+
+	must(t, repo.MarkAsRead(ctx, user.ID, []int64{follow.ID}))
+	unread, err := repo.CountUnread(ctx, user.ID)
+	must(t, err)
+	if unread != 5 {
+		t.Errorf("CountUnread = %d, want 5", unread)
+	}
+	must(t, repo.MarkAsRead(ctx, user.ID, nil))
+	if unread, _ := repo.CountUnread(ctx, user.ID); unread != 0 {
+		t.Errorf("CountUnread after marking all = %d, want 0", unread)
+	}
+
+	// Restricted and deactivated actors drop out; deleted ones stay
+	restricted, deactivated, deletedActor := f.user(t), f.user(t), f.user(t)
+	for _, actorID := range []int64{restricted.ID, deactivated.ID, deletedActor.ID} {
+		must(t, repo.Create(ctx, &model.Notification{UserID: user.ID, ActorID: actorID, Type: types.NotificationTypeFollow, TemplateKey: model.NotificationKeyFollow}))
+	}
+	must(t, restrictionrepo.NewRestrictionRepository(f.db).Add(ctx, user.ID, restricted.ID))
+	must(t, f.db.Model(&model.User{}).Where("id = ?", deactivated.ID).Update("deactivated_at", time.Now()).Error)
+	must(t, f.db.Delete(&model.User{}, deletedActor.ID).Error)
+	if unread, _ := repo.CountUnread(ctx, user.ID); unread != 1 {
+		t.Errorf("CountUnread with restricted, deactivated and deleted actors = %d, want 1", unread)
+	}
+	if list, _ := repo.ListByUser(ctx, user.ID, 10, 0); len(list) != 7 || list[0].ActorID != deletedActor.ID {
+		t.Errorf("ListByUser with restricted, deactivated and deleted actors = %d notifications, want 7", len(list))
+	}
+
+	post, gone := f.post(t, user.ID), f.post(t, user.ID)
+	must(t, f.db.Delete(&model.Post{}, gone.ID).Error)
+	existing, err := repo.ExistingTargets(ctx, types.NotificationTargetPost, []int64{post.ID, gone.ID, 0})
+	must(t, err)
+	if !existing[post.ID] || existing[gone.ID] || existing[0] {
+		t.Errorf("ExistingTargets = %v, want only post %d", existing, post.ID)
+	}
+	if existing, _ := repo.ExistingTargets(ctx, types.NotificationTargetUnknown, []int64{7}); !existing[7] {
+		t.Error("ExistingTargets of a target that is not a row is missing")
+	}
+
+	must(t, repo.MarkAsRead(ctx, user.ID, nil))
+	deleted, err := repo.DeleteReadBefore(ctx, time.Now().Add(time.Minute))
+	must(t, err)
+	if deleted < 6 {
+		t.Errorf("DeleteReadBefore removed %d notifications, want at least 6", deleted)
+	}
+}
+
+func testMessages(t *testing.T, f *fixtures) {
+	repo := messagerepo.NewMessageRepository(f.db)
+	ctx := t.Context()
+	sender, receiver := f.user(t), f.user(t)
+
+	must(t, repo.Create(ctx, &model.Message{SenderID: sender.ID, ReceiverID: receiver.ID, Content: "hi"}))
+	batch := make([]*model.Message, 3)
+	for i := range batch {
+		batch[i] = &model.Message{SenderID: receiver.ID, ReceiverID: sender.ID, Content: "reply"}
+	}
+	must(t, repo.CreateBatch(ctx, batch, 2))
+
+	var count int64
+	must(t, f.db.Model(&model.Message{}).Where("sender_id IN ?", []int64{sender.ID, receiver.ID}).Count(&count).Error)
+	if count != 4 {
+		t.Errorf("stored %d messages, want 4", count)
+	}
+}
+
+func testSecurity(t *testing.T, f *fixtures) {
+	repo := securityrepo.NewSecurityRepository(f.db)
+	ctx := t.Context()
+	user := f.user(t)
+
+	if ok, err := repo.HasLogin(ctx, user.ID); err != nil || ok {
+		t.Errorf("HasLogin before any login = %v, %v", ok, err)
+	}
+	must(t, repo.Create(ctx, &model.SecurityEvent{UserID: user.ID, Type: types.SecurityEventTypeLoginFailed, DeviceHash: "b"}))
+	must(t, repo.Create(ctx, &model.SecurityEvent{UserID: user.ID, Type: types.SecurityEventTypeLogin, DeviceHash: "a", Device: "Firefox on Linux"}))
+
+	if ok, err := repo.HasLogin(ctx, user.ID); err != nil || !ok {
+		t.Errorf("HasLogin = %v, %v, want true", ok, err)
+	}
+	if ok, _ := repo.HasLoginFromDevice(ctx, user.ID, "a"); !ok {
+		t.Error("HasLoginFromDevice of the login device = false")
+	}
+	if ok, _ := repo.HasLoginFromDevice(ctx, user.ID, "b"); ok {
+		t.Error("HasLoginFromDevice counts failed logins")
+	}
+
+	events, err := repo.ListByUser(ctx, user.ID, 10, 0)
+	must(t, err)
+	if len(events) != 2 {
+		t.Errorf("ListByUser = %d events, want 2", len(events))
+	}
+}
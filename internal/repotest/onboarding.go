@@ -0,0 +1,45 @@
+package repotest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/onboarding/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+func testOnboarding(t *testing.T, f *fixtures) {
+	ctx := t.Context()
+	repo := repository.NewOnboardingRepository(f.db)
+	user, friend, followed, gone := f.user(t), f.user(t), f.user(t), f.user(t)
+
+	first := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	must(t, repo.MarkStep(ctx, user.ID, types.OnboardingStepFirstPost, first))
+	must(t, repo.MarkStep(ctx, user.ID, types.OnboardingStepFirstPost, time.Now().UTC()))
+	var stored model.User
+	must(t, f.db.First(&stored, user.ID).Error)
+	if stored.FirstPostAt == nil || !stored.FirstPostAt.Equal(first) {
+		t.Errorf("first_post_at = %v, want the first stamp %v", stored.FirstPostAt, first)
+	}
+	if stored.FirstFollowAt != nil || stored.OnboardedAt != nil {
+		t.Errorf("unmarked steps stamped: first follow %v, onboarded %v", stored.FirstFollowAt, stored.OnboardedAt)
+	}
+	if !stored.UpdatedAt.Equal(user.UpdatedAt) {
+		t.Errorf("marking a step moved updated_at from %v to %v", user.UpdatedAt, stored.UpdatedAt)
+	}
+	if err := repo.MarkStep(ctx, user.ID, types.OnboardingStepAvatar, first); err == nil {
+		t.Error("avatar step was stamped, want an error")
+	}
+
+	f.follow(t, user.ID, followed.ID)
+	must(t, f.db.Model(&model.User{}).Where("id IN ?", []int64{friend.ID, followed.ID, gone.ID}).UpdateColumn("discoverable_by_email", true).Error)
+	must(t, f.db.Model(&model.User{}).Where("id = ?", gone.ID).UpdateColumn("deactivated_at", time.Now().UTC()).Error)
+	emails := []string{strings.ToLower(friend.Email), followed.Email, gone.Email, user.Email, "nobody@example.com"}
+	found, err := repo.FindByEmails(ctx, user.ID, emails, 10)
+	must(t, err)
+	if len(found) != 1 || found[0].ID != friend.ID {
+		t.Errorf("FindByEmails = %v, want only the unfollowed active contact %d", found, friend.ID)
+	}
+}
@@ -0,0 +1,85 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	orgrepo "github.com/ilhamosaurus/sns-platform/internal/module/organization/repository"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+func testOrganizations(t *testing.T, f *fixtures) {
+	repo := orgrepo.NewOrganizationRepository(f.db)
+	ctx := t.Context()
+	owner, admin, member, outsider := f.user(t), f.user(t), f.user(t), f.user(t)
+
+	org := &model.Organization{Slug: f.name("o"), Name: "Organization", OwnerID: owner.ID}
+	must(t, repo.Create(ctx, org))
+	if err := repo.Create(ctx, &model.Organization{Slug: org.Slug, Name: "Copy", OwnerID: owner.ID}); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("Create with a taken slug = %v, want ErrConflict", err)
+	}
+
+	// Members join before the admin so ordering by role is observable
+	must(t, repo.AddMember(ctx, &model.OrganizationMember{OrganizationID: org.ID, UserID: member.ID, Role: types.OrganizationRoleMember}))
+	must(t, repo.AddMember(ctx, &model.OrganizationMember{OrganizationID: org.ID, UserID: admin.ID, Role: types.OrganizationRoleMember}))
+	if err := repo.AddMember(ctx, &model.OrganizationMember{OrganizationID: org.ID, UserID: admin.ID, Role: types.OrganizationRoleMember}); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("AddMember twice = %v, want ErrConflict", err)
+	}
+	must(t, repo.SetMemberRole(ctx, org.ID, admin.ID, types.OrganizationRoleAdmin))
+
+	members, err := repo.ListMembers(ctx, org.ID, 10, 0)
+	must(t, err)
+	var order []int64
+	for _, m := range members {
+		order = append(order, m.UserID)
+	}
+	if len(order) != 3 || order[0] != owner.ID || order[1] != admin.ID || order[2] != member.ID {
+		t.Errorf("ListMembers = users %v, want owner, admin, then members", order)
+	}
+
+	listed, err := repo.ListByMember(ctx, member.ID, 10, 0)
+	must(t, err)
+	if len(listed) != 1 || listed[0].ID != org.ID {
+		t.Errorf("ListByMember = %d organizations, want the organization", len(listed))
+	}
+
+	post := &model.Post{UserID: member.ID, OrganizationID: &org.ID, Content: "internal", IsPublic: false}
+	must(t, repo.CreatePost(ctx, post))
+	f.post(t, member.ID)
+
+	feeds := feedrepo.NewFeedRepository(f.db)
+	feed, err := feeds.GetOrganizationFeed(ctx, org.ID, admin.ID, 10, 0)
+	must(t, err)
+	if len(feed) != 1 || feed[0].ID != post.ID || feed[0].IsPublic {
+		t.Errorf("GetOrganizationFeed = posts %v, want the private post %d", postIDs(feed), post.ID)
+	}
+	if _, err := feeds.GetPostWithDetails(ctx, post.ID, outsider.ID, types.CommentSortOldest); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetPostWithDetails by an outsider = %v, want ErrNotFound", err)
+	}
+	posts := postrepo.NewPostRepository(f.db)
+	for viewer, want := range map[int64]int{admin.ID: 2, outsider.ID: 1} {
+		listed, err := posts.ListByAuthor(ctx, member.ID, viewer, 10, 0)
+		must(t, err)
+		if len(listed) != want {
+			t.Errorf("ListByAuthor seen by user %d = %d posts, want %d", viewer, len(listed), want)
+		}
+	}
+
+	must(t, repo.RemoveMember(ctx, org.ID, admin.ID))
+	if err := repo.RemoveMember(ctx, org.ID, admin.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("RemoveMember twice = %v, want ErrNotFound", err)
+	}
+	if _, err := feeds.GetPostWithDetails(ctx, post.ID, admin.ID, types.CommentSortOldest); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetPostWithDetails by a former member = %v, want ErrNotFound", err)
+	}
+
+	got, err := repo.GetByID(ctx, org.ID)
+	must(t, err)
+	if got.MemberCount != 2 || got.PostCount != 1 {
+		t.Errorf("GetByID = %d members and %d posts, want 2 and 1", got.MemberCount, got.PostCount)
+	}
+}
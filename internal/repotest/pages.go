@@ -0,0 +1,137 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	pagerepo "github.com/ilhamosaurus/sns-platform/internal/module/page/repository"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+func testPages(t *testing.T, f *fixtures) {
+	repo := pagerepo.NewPageRepository(f.db)
+	ctx := t.Context()
+	admin, editor, analyst, fan, personal := f.user(t), f.user(t), f.user(t), f.user(t), f.user(t)
+
+	page := &model.Page{Handle: f.name("page"), Name: "Acme Coffee Roasters"}
+	must(t, repo.Create(ctx, page, admin.ID))
+	if err := repo.Create(ctx, &model.Page{Handle: page.Handle, Name: "Copy"}, admin.ID); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("Create with a taken handle = %v, want ErrConflict", err)
+	}
+	// Wildcards in the query are matched literally
+	for query, want := range map[string]int{page.Handle: 1, "ACME": 1, "roast": 1, "offee": 0, page.Handle + "%": 0} {
+		found, err := repo.Search(ctx, query, 10, 0)
+		must(t, err)
+		n := 0
+		for _, p := range found {
+			if p.ID == page.ID {
+				n++
+			}
+		}
+		if n != want {
+			t.Errorf("Search(%q) found the page %d times, want %d", query, n, want)
+		}
+	}
+
+	// The analyst is added before the editor so ordering by role is observable
+	must(t, repo.AddManager(ctx, &model.PageManager{PageID: page.ID, UserID: analyst.ID, Role: types.PageRoleAnalyst}))
+	must(t, repo.AddManager(ctx, &model.PageManager{PageID: page.ID, UserID: editor.ID, Role: types.PageRoleAnalyst}))
+	if err := repo.AddManager(ctx, &model.PageManager{PageID: page.ID, UserID: editor.ID, Role: types.PageRoleEditor}); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("AddManager twice = %v, want ErrConflict", err)
+	}
+	must(t, repo.SetManagerRole(ctx, page.ID, editor.ID, types.PageRoleEditor))
+	managers, err := repo.ListManagers(ctx, page.ID)
+	must(t, err)
+	var order []int64
+	for _, m := range managers {
+		order = append(order, m.UserID)
+	}
+	if len(order) != 3 || order[0] != admin.ID || order[1] != editor.ID || order[2] != analyst.ID {
+		t.Errorf("ListManagers = users %v, want admin, editor, then analyst", order)
+	}
+	admins, err := repo.CountManagers(ctx, page.ID, types.PageRoleAdmin)
+	must(t, err)
+	if admins != 1 {
+		t.Errorf("CountManagers of admins = %d, want 1", admins)
+	}
+	managed, err := repo.ListManaged(ctx, editor.ID, 10, 0)
+	must(t, err)
+	if len(managed) != 1 || managed[0].ID != page.ID {
+		t.Errorf("ListManaged = %d pages, want the page", len(managed))
+	}
+
+	must(t, repo.Follow(ctx, page.ID, fan.ID))
+	must(t, repo.Follow(ctx, page.ID, fan.ID))
+	must(t, repo.Follow(ctx, page.ID, editor.ID))
+	must(t, repo.Unfollow(ctx, page.ID, editor.ID))
+	must(t, repo.Unfollow(ctx, page.ID, editor.ID))
+	if following, err := repo.IsFollowing(ctx, page.ID, fan.ID); err != nil || !following {
+		t.Errorf("IsFollowing = %v, %v, want true", following, err)
+	}
+
+	// The editor's own followers follow them, not the page
+	f.follow(t, personal.ID, editor.ID)
+	post := &model.Post{UserID: editor.ID, PageID: &page.ID, Content: f.name("post"), IsPublic: true}
+	must(t, repo.CreatePost(ctx, post))
+	own := f.post(t, editor.ID)
+
+	got, err := repo.GetByID(ctx, page.ID)
+	must(t, err)
+	if got.FollowerCount != 1 || got.PostCount != 1 {
+		t.Errorf("page counts = %d followers, %d posts, want 1 and 1", got.FollowerCount, got.PostCount)
+	}
+
+	feeds := feedrepo.NewFeedRepository(f.db, feedrepo.WithFanOut(feedrepo.FanOutConfig{ChunkSize: 10, Workers: 1, PullThreshold: 1}))
+	must(t, feeds.FanOutPost(ctx, post.ID, editor.ID, post.CreatedAt))
+	for reader, want := range map[int64]int{fan.ID: 1, personal.ID: 0} {
+		feed, err := feeds.GetUserFeed(ctx, reader, 10, 0)
+		must(t, err)
+		n := 0
+		for _, p := range feed {
+			if p.ID == post.ID {
+				n++
+				if p.Page == nil || p.Page.ID != page.ID {
+					t.Errorf("GetUserFeed post %d has page %v, want %d", p.ID, p.Page, page.ID)
+				}
+			}
+		}
+		if n != want {
+			t.Errorf("GetUserFeed of user %d has the page post %d times, want %d", reader, n, want)
+		}
+	}
+
+	listed, err := repo.ListPosts(ctx, page.ID, fan.ID, 10, 0)
+	must(t, err)
+	if len(listed) != 1 || listed[0].ID != post.ID {
+		t.Errorf("ListPosts = %d posts, want the page post", len(listed))
+	}
+	byAuthor, err := postrepo.NewPostRepository(f.db).ListByAuthor(ctx, editor.ID, fan.ID, 10, 0)
+	must(t, err)
+	if len(byAuthor) != 1 || byAuthor[0].ID != own.ID {
+		t.Errorf("ListByAuthor = %d posts, want only the editor's own post", len(byAuthor))
+	}
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	must(t, f.db.Create([]*model.PostDailyStat{
+		{PostID: post.ID, AuthorID: editor.ID, Day: day, Impressions: 10, Reach: 8, Likes: 2},
+		{PostID: own.ID, AuthorID: editor.ID, Day: day, Impressions: 100},
+	}).Error)
+	stats, err := repo.DailyStats(ctx, page.ID, day.AddDate(0, 0, -1), day.AddDate(0, 0, 1))
+	must(t, err)
+	if len(stats) != 1 || stats[0].Impressions != 10 || stats[0].Reach != 8 || stats[0].Likes != 2 {
+		t.Errorf("DailyStats = %d days, want one day of the page post only", len(stats))
+	}
+
+	must(t, repo.RemoveManager(ctx, page.ID, analyst.ID))
+	if err := repo.RemoveManager(ctx, page.ID, analyst.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("RemoveManager twice = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.GetManager(ctx, page.ID, analyst.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetManager after removal = %v, want ErrNotFound", err)
+	}
+}
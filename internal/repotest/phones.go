@@ -0,0 +1,60 @@
+package repotest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/phone/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+func testPhones(t *testing.T, f *fixtures) {
+	ctx := t.Context()
+	repo := repository.NewPhoneRepository(f.db)
+	user, other := f.user(t), f.user(t)
+	phone := fmt.Sprintf("+1%010d", time.Now().UnixNano()%1e10)
+	expires := time.Now().UTC().Add(time.Minute)
+
+	must(t, repo.SaveCode(ctx, &model.PhoneCode{UserID: user.ID, Purpose: types.PhoneCodePurposeVerify, Phone: phone, CodeHash: "first", ExpiresAt: expires}))
+	must(t, repo.SaveCode(ctx, &model.PhoneCode{UserID: user.ID, Purpose: types.PhoneCodePurposeVerify, Phone: phone, CodeHash: "second", ExpiresAt: expires}))
+	must(t, repo.SaveCode(ctx, &model.PhoneCode{UserID: user.ID, Purpose: types.PhoneCodePurposeSignIn, Phone: phone, CodeHash: "sign-in", ExpiresAt: expires}))
+	code, err := repo.GetCode(ctx, user.ID, types.PhoneCodePurposeVerify)
+	must(t, err)
+	if code.CodeHash != "second" {
+		t.Errorf("code hash = %q, want the replacing code", code.CodeHash)
+	}
+	for want := 1; want <= 2; want++ {
+		if attempts, err := repo.RecordAttempt(ctx, code.ID); err != nil || attempts != want {
+			t.Errorf("RecordAttempt = %d, %v, want %d", attempts, err, want)
+		}
+	}
+	if code, err = repo.GetCode(ctx, user.ID, types.PhoneCodePurposeVerify); err != nil || code.Attempts != 2 {
+		t.Errorf("attempts = %v (%v), want 2", code, err)
+	}
+	must(t, repo.DeleteCode(ctx, code.ID))
+	if _, err := repo.GetCode(ctx, user.ID, types.PhoneCodePurposeVerify); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetCode after delete: %v, want not found", err)
+	}
+	if _, err := repo.RecordAttempt(ctx, code.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("RecordAttempt after delete: %v, want not found", err)
+	}
+	if _, err := repo.GetCode(ctx, user.ID, types.PhoneCodePurposeSignIn); err != nil {
+		t.Errorf("the sign-in code went with the verification code: %v", err)
+	}
+
+	must(t, f.db.Model(&model.User{}).Where("id = ?", other.ID).UpdateColumn("phone", phone).Error)
+	if taken, err := repo.PhoneTaken(ctx, phone, user.ID); err != nil || taken {
+		t.Errorf("PhoneTaken = %v, %v for an unverified number, want false", taken, err)
+	}
+	must(t, f.db.Model(&model.User{}).Where("id = ?", other.ID).UpdateColumn("phone_verified_at", time.Now().UTC()).Error)
+	if taken, err := repo.PhoneTaken(ctx, phone, user.ID); err != nil || !taken {
+		t.Errorf("PhoneTaken = %v, %v for a number verified by another user, want true", taken, err)
+	}
+	if taken, err := repo.PhoneTaken(ctx, phone, other.ID); err != nil || taken {
+		t.Errorf("PhoneTaken = %v, %v for the user's own number, want false", taken, err)
+	}
+}
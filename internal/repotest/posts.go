@@ -0,0 +1,330 @@
+package repotest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/dto"
+	"github.com/ilhamosaurus/sns-platform/internal/hydrator"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+func testPosts(t *testing.T, f *fixtures) {
+	repo := postrepo.NewPostRepository(f.db)
+	ctx := t.Context()
+	author, viewer := f.user(t), f.user(t)
+
+	post := &model.Post{UserID: author.ID, Content: "first", MediaType: types.MediaTypeImage, IsPublic: true}
+	must(t, repo.Create(ctx, post))
+
+	batch := []*model.Post{
+		{UserID: author.ID, Content: "public", IsPublic: true},
+		{UserID: author.ID, Content: "private", IsPublic: false},
+		{UserID: author.ID, Content: "close friends", IsPublic: true, CloseFriends: true},
+	}
+	must(t, repo.CreateBatch(ctx, batch, 2))
+	private, err := repo.GetByID(ctx, batch[1].ID)
+	must(t, err)
+	if private.IsPublic {
+		t.Error("CreateBatch stored a private post as public")
+	}
+
+	got, err := repo.GetByID(ctx, post.ID)
+	must(t, err)
+	if got.MediaType != types.MediaTypeImage {
+		t.Errorf("GetByID media type = %v, want image", got.MediaType)
+	}
+
+	must(t, repo.Update(ctx, post.ID, map[string]any{"content": "edited"}))
+	got, err = repo.GetByID(ctx, post.ID)
+	must(t, err)
+	if got.Content != "edited" {
+		t.Errorf("content after Update = %q, want edited", got.Content)
+	}
+
+	// Decrements stop at zero on every database
+	for _, action := range []types.Action{types.ActionLiked, types.ActionUnliked, types.ActionUnliked, types.ActionCommented, types.ActionShared, types.ActionUncommented, types.ActionUncommented} {
+		must(t, repo.UpdatePostCount(ctx, post.ID, action))
+	}
+	got, err = repo.GetByID(ctx, post.ID)
+	must(t, err)
+	if got.LikeCount != 0 || got.CommentCount != 0 || got.ShareCount != 1 {
+		t.Errorf("counts = %d/%d/%d, want 0/0/1", got.LikeCount, got.CommentCount, got.ShareCount)
+	}
+
+	byAuthor, err := repo.ListByAuthor(ctx, author.ID, viewer.ID, 10, 0)
+	must(t, err)
+	if len(byAuthor) != 3 {
+		t.Errorf("ListByAuthor for a stranger = %d posts, want 3 without the close friends post", len(byAuthor))
+	}
+	byAuthor, err = repo.ListByAuthor(ctx, author.ID, author.ID, 10, 0)
+	must(t, err)
+	if len(byAuthor) != 4 {
+		t.Errorf("ListByAuthor for the author = %d posts, want 4", len(byAuthor))
+	}
+
+	posts, total, err := repo.List(ctx, map[string]any{"user_id = ?": author.ID}, 1, 2)
+	must(t, err)
+	if total != 4 || len(posts) != 2 {
+		t.Errorf("List = %d posts of %d, want 2 of 4", len(posts), total)
+	}
+
+	must(t, repo.Delete(ctx, post.ID))
+	if _, err := repo.GetByID(ctx, post.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetByID after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func testFeed(t *testing.T, f *fixtures) {
+	ctx := t.Context()
+	author, celebrity := f.user(t), f.user(t)
+	followers := make([]*model.User, 5)
+	for i := range followers {
+		followers[i] = f.user(t)
+		f.follow(t, followers[i].ID, author.ID)
+		f.follow(t, followers[i].ID, celebrity.ID)
+	}
+	must(t, f.db.Model(celebrity).UpdateColumn("follower_count", len(followers)).Error)
+
+	// Tiny chunks and batches push the fan-out through several workers
+	repo := feedrepo.NewFeedRepository(f.db, feedrepo.WithFanOut(feedrepo.FanOutConfig{
+		ChunkSize:     2,
+		BatchSize:     2,
+		Workers:       2,
+		PullThreshold: int64(len(followers)),
+	}))
+
+	older := f.post(t, author.ID)
+	must(t, repo.FanOutPost(ctx, older.ID, author.ID, older.CreatedAt))
+	video := f.post(t, author.ID, func(p *model.Post) { p.MediaType = types.MediaTypeVideo })
+	must(t, repo.FanOutPost(ctx, video.ID, author.ID, video.CreatedAt))
+	must(t, repo.FanOutPost(ctx, video.ID, author.ID, video.CreatedAt)) // retried job
+	pulled := f.post(t, celebrity.ID)
+	must(t, repo.FanOutPost(ctx, pulled.ID, celebrity.ID, pulled.CreatedAt))
+
+	var rows int64
+	must(t, f.db.Model(&model.ActivityFeed{}).Where("post_id = ?", video.ID).Count(&rows).Error)
+	if rows != int64(len(followers))+1 {
+		t.Errorf("fan-out wrote %d feed rows, want %d", rows, len(followers)+1)
+	}
+	must(t, f.db.Model(&model.ActivityFeed{}).Where("post_id = ?", pulled.ID).Count(&rows).Error)
+	if rows != 1 {
+		t.Errorf("fan-out of a pulled author wrote %d feed rows, want only the author's", rows)
+	}
+
+	reader := followers[0].ID
+	feed, err := repo.GetUserFeed(ctx, reader, 10, 0)
+	must(t, err)
+	if ids := postIDs(feed); !slices.Contains(ids, older.ID) || !slices.Contains(ids, video.ID) || !slices.Contains(ids, pulled.ID) || len(ids) != 3 {
+		t.Errorf("GetUserFeed = posts %v, want %d, %d and %d", ids, older.ID, video.ID, pulled.ID)
+	}
+	feed, err = repo.GetUserFeed(ctx, reader, 10, 0, types.MediaTypeVideo)
+	must(t, err)
+	if ids := postIDs(feed); len(ids) != 1 || ids[0] != video.ID {
+		t.Errorf("GetUserFeed of videos = posts %v, want %d", ids, video.ID)
+	}
+
+	// Comments on the author's posts lift them above the newer pulled post
+	for range 4 {
+		must(t, f.db.Create(&model.Comment{PostID: older.ID, UserID: reader, Content: "again"}).Error)
+	}
+	lifting := feedrepo.NewFeedRepository(f.db, feedrepo.WithFanOut(feedrepo.FanOutConfig{ChunkSize: 2, Workers: 1, PullThreshold: int64(len(followers))}),
+		feedrepo.WithAffinity(feedrepo.AffinityConfig{MinScore: 4 * feedrepo.AffinityCommentWeight, Within: time.Hour}))
+	_, err = lifting.RecomputeAffinities(ctx, time.Now().Add(-time.Hour))
+	must(t, err)
+	feed, err = lifting.GetUserFeed(ctx, reader, 10, 0)
+	must(t, err)
+	if ids := postIDs(feed); !slices.Equal(ids, []int64{video.ID, older.ID, pulled.ID}) {
+		t.Errorf("GetUserFeed with affinity = posts %v, want %v", ids, []int64{video.ID, older.ID, pulled.ID})
+	}
+
+	newer, err := repo.CountNewer(ctx, reader, older.ID)
+	must(t, err)
+	if newer != 2 {
+		t.Errorf("CountNewer = %d, want 2", newer)
+	}
+	// With a pull threshold the count reads posts; without one, only feed rows
+	for name, r := range map[string]feedrepo.FeedRepository{"pull": repo, "push": feedrepo.NewFeedRepository(f.db)} {
+		for since, want := range map[time.Time]int64{older.CreatedAt.Add(-time.Hour): 3, time.Now().Add(time.Hour): 0} {
+			newer, err := r.CountNewerSince(ctx, reader, since)
+			must(t, err)
+			if name == "push" && want > 0 {
+				want-- // The pulled post was never fanned out
+			}
+			if newer != want {
+				t.Errorf("CountNewerSince(%s) with %s = %d, want %d", since.Format(time.RFC3339), name, newer, want)
+			}
+		}
+	}
+
+	ids := make([]int64, len(followers))
+	for i, follower := range followers {
+		ids[i] = follower.ID
+	}
+	for _, post := range []*model.Post{video, pulled} {
+		recipients, err := repo.FilterRecipients(ctx, post.ID, append(ids, f.user(t).ID))
+		must(t, err)
+		if len(recipients) != len(followers) {
+			t.Errorf("FilterRecipients(%d) = %d users, want %d followers", post.ID, len(recipients), len(followers))
+		}
+	}
+
+	explore, err := repo.GetExploreFeed(ctx, reader, 1000, 0, time.Hour, "")
+	must(t, err)
+	if !slices.Contains(postIDs(explore), video.ID) {
+		t.Error("GetExploreFeed is missing a new public post")
+	}
+	// A precomputed window serves only its top candidates, best first
+	must(t, f.db.Model(video).UpdateColumn("like_count", 1_000_000).Error)
+	precomputed := feedrepo.NewFeedRepository(f.db, feedrepo.WithExplore(feedrepo.ExploreConfig{Windows: []time.Duration{time.Hour}, Candidates: 1}))
+	kept, err := precomputed.RecomputeExplore(ctx, time.Now())
+	must(t, err)
+	explore, err = precomputed.GetExploreFeed(ctx, reader, 1000, 0, time.Hour, "")
+	must(t, err)
+	if ids := postIDs(explore); len(ids) == 0 || int64(len(ids)) > kept || ids[0] != video.ID {
+		t.Errorf("precomputed GetExploreFeed = posts %v of %d candidates, want %d first", ids, kept, video.ID)
+	}
+
+	// Posts take their author's region unless geotagged; explore can narrow to one
+	local := f.user(t)
+	must(t, f.db.Model(local).UpdateColumn("region", "ID").Error)
+	posts := postrepo.NewPostRepository(f.db)
+	inherited := &model.Post{UserID: local.ID, Content: f.name("post"), IsPublic: true}
+	must(t, posts.Create(ctx, inherited))
+	tagged := f.post(t, author.ID, func(p *model.Post) { p.Region = "ID" })
+	if inherited.Region != "ID" || tagged.Region != "ID" {
+		t.Errorf("post regions = %q and %q, want ID for both", inherited.Region, tagged.Region)
+	}
+	regional := []*model.Post{
+		{UserID: local.ID, Content: f.name("post"), IsPublic: true},
+		{UserID: author.ID, Content: f.name("post"), IsPublic: true},
+		{UserID: author.ID, Content: f.name("post"), Region: "JP", IsPublic: true},
+	}
+	must(t, posts.CreateBatch(ctx, regional, 0))
+	if regional[0].Region != "ID" || regional[1].Region != "" || regional[2].Region != "JP" {
+		t.Errorf("CreateBatch post regions = %q, %q and %q, want ID, none and JP", regional[0].Region, regional[1].Region, regional[2].Region)
+	}
+	_, err = precomputed.RecomputeExplore(ctx, time.Now())
+	must(t, err)
+	for name, r := range map[string]feedrepo.FeedRepository{"live": repo, "precomputed": precomputed} {
+		explore, err := r.GetExploreFeed(ctx, reader, 1000, 0, time.Hour, "ID")
+		must(t, err)
+		for _, post := range explore {
+			if post.Region != "ID" {
+				t.Errorf("%s GetExploreFeed in ID has post %d of region %q", name, post.ID, post.Region)
+			}
+		}
+		if ids := postIDs(explore); len(ids) == 0 || (name == "live" && !(slices.Contains(ids, inherited.ID) && slices.Contains(ids, tagged.ID))) {
+			t.Errorf("%s GetExploreFeed in ID = posts %v, want %d and %d", name, ids, inherited.ID, tagged.ID)
+		}
+	}
+
+	// Nearby draws a circle, also across the antimeridian
+	at := func(lat, lng float64) func(*model.Post) {
+		return func(p *model.Post) { p.Latitude, p.Longitude = &lat, &lng }
+	}
+	here := f.post(t, author.ID, at(-6.2, 106.8))
+	walk := f.post(t, author.ID, at(-6.2+0.027, 106.8)) // 3km north
+	city := f.post(t, author.ID, at(-6.9, 107.6))       // 120km away
+	fiji := f.post(t, author.ID, at(-17.7, 179.995))
+	for _, c := range []struct {
+		lat, lng, radius float64
+		want, not        []int64
+	}{
+		{-6.2, 106.8, 5000, []int64{here.ID, walk.ID}, []int64{city.ID, fiji.ID}},
+		{-6.2, 106.8, 1000, []int64{here.ID}, []int64{walk.ID, city.ID}},
+		{-6.2 + 0.0135, 106.8 + 0.02, 3000, []int64{here.ID, walk.ID}, []int64{city.ID}},
+		{-17.7, -179.995, 2000, []int64{fiji.ID}, []int64{here.ID}},
+	} {
+		nearby, err := repo.GetNearbyFeed(ctx, reader, c.lat, c.lng, c.radius, 1000, 0, time.Hour)
+		must(t, err)
+		ids := postIDs(nearby)
+		for _, id := range c.want {
+			if !slices.Contains(ids, id) {
+				t.Errorf("GetNearbyFeed(%v, %v, %vm) = posts %v, missing %d", c.lat, c.lng, c.radius, ids, id)
+			}
+		}
+		for _, id := range c.not {
+			if slices.Contains(ids, id) {
+				t.Errorf("GetNearbyFeed(%v, %v, %vm) = posts %v, want no %d", c.lat, c.lng, c.radius, ids, id)
+			}
+		}
+	}
+
+	detail, err := repo.GetPostWithDetails(ctx, video.ID, reader, types.CommentSortOldest)
+	must(t, err)
+	if detail.ID != video.ID || detail.Author.ID != author.ID || detail.Comments == nil || detail.ReactionSummary == nil {
+		t.Errorf("GetPostWithDetails = %+v", detail)
+	}
+	comment := &model.Comment{PostID: video.ID, UserID: author.ID, Content: "first"}
+	must(t, f.db.Create(comment).Error)
+	must(t, f.db.Create(&model.Comment{PostID: video.ID, UserID: reader, ParentID: &comment.ID, ReplyToUserID: &author.ID, Content: "reply"}).Error)
+	reactors := make([]int64, hydrator.TopReactors+1)
+	for i := range reactors {
+		reactors[i] = followers[i].ID
+		kind := types.ReactionTypeLike
+		if i == 0 {
+			kind = types.ReactionTypeLove
+		}
+		must(t, f.db.Create(&model.Reaction{
+			BaseModel: model.BaseModel{CreatedAt: time.Now().Add(time.Duration(i) * time.Second)},
+			UserID:    followers[i].ID,
+			CommentID: &comment.ID,
+			Type:      kind,
+		}).Error)
+	}
+	detail, err = repo.GetPostWithDetails(ctx, video.ID, reader, types.CommentSortOldest)
+	must(t, err)
+	if len(detail.Comments) != 1 || len(detail.Comments[0].Replies) != 1 {
+		t.Fatalf("GetPostWithDetails has %d comment threads, want 1 with a reply", len(detail.Comments))
+	}
+	thread := detail.Comments[0]
+	if thread.ReactionSummary["like"] != int64(hydrator.TopReactors) || thread.ReactionSummary["love"] != 1 {
+		t.Errorf("comment reaction summary = %v, want %d likes and 1 love", thread.ReactionSummary, hydrator.TopReactors)
+	}
+	var top []int64
+	for _, user := range thread.TopReactors {
+		top = append(top, user.ID)
+	}
+	want := slices.Clone(reactors[1:])
+	slices.Reverse(want)
+	if !slices.Equal(top, want) {
+		t.Errorf("comment top reactors = %v, want the latest %v", top, want)
+	}
+	if reply := thread.Replies[0]; len(reply.ReactionSummary) != 0 || len(reply.TopReactors) != 0 {
+		t.Errorf("reply without reactions has summary %v and reactors %d", reply.ReactionSummary, len(reply.TopReactors))
+	}
+	if reply := thread.Replies[0]; reply.ReplyingTo == nil || reply.ReplyingTo.ID != author.ID {
+		t.Errorf("reply is replying to %+v, want the comment author %d", reply.ReplyingTo, author.ID)
+	}
+	if thread.ReplyingTo != nil {
+		t.Errorf("top-level comment is replying to %+v", thread.ReplyingTo)
+	}
+	if _, err := repo.GetPostWithDetails(ctx, 1<<40, reader, types.CommentSortOldest); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetPostWithDetails of a missing post = %v, want ErrNotFound", err)
+	}
+
+	stale := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	must(t, repo.CreateBatch(ctx, []*model.ActivityFeed{
+		{UserID: reader, PostID: older.ID, AuthorID: author.ID, PostCreated: stale},
+	}, 0))
+	pruned, err := repo.PruneBefore(ctx, stale.Add(time.Hour))
+	must(t, err)
+	if pruned < 1 {
+		t.Errorf("PruneBefore removed %d rows, want at least 1", pruned)
+	}
+}
+
+func postIDs(posts []*dto.FeedPost) []int64 {
+	ids := make([]int64, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	return ids
+}
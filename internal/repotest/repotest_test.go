@@ -0,0 +1,16 @@
+package repotest_test
+
+import (
+	"testing"
+
+	"github.com/ilhamosaurus/sns-platform/internal/repotest"
+	"github.com/ilhamosaurus/sns-platform/internal/testutil"
+)
+
+func TestRepositories(t *testing.T) {
+	for name, config := range repotest.Databases(t) {
+		t.Run(name, func(t *testing.T) {
+			repotest.Run(t, testutil.Open(t, config))
+		})
+	}
+}
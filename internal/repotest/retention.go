@@ -0,0 +1,65 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/retention"
+)
+
+func testRetention(t *testing.T, f *fixtures) {
+	ctx := t.Context()
+	sender, receiver := f.user(t), f.user(t)
+	old := time.Now().UTC().Add(-3 * time.Hour)
+
+	expired := &model.Message{SenderID: sender.ID, ReceiverID: receiver.ID, Content: "expired"}
+	expired.CreatedAt = old
+	kept := &model.Message{SenderID: sender.ID, ReceiverID: receiver.ID, Content: "kept"}
+	entry := &model.AuditLog{Action: f.name("retention"), TargetType: model.AuditTargetUser, TargetID: sender.ID, CreatedAt: old}
+	must(t, f.db.Create(expired).Error)
+	must(t, f.db.Create(kept).Error)
+	must(t, f.db.Create(entry).Error)
+	deleted := f.post(t, sender.ID)
+	must(t, f.db.Model(&model.Post{}).Where("id = ?", deleted.ID).UpdateColumn("deleted_at", old).Error)
+
+	policies, err := retention.Policies(retention.Config{Messages: 2 * time.Hour, AuditLogs: 2 * time.Hour, SoftDeleted: 2 * time.Hour})
+	must(t, err)
+	exists := func(row any, id int64) bool {
+		var count int64
+		must(t, f.db.Unscoped().Model(row).Where("id = ?", id).Count(&count).Error)
+		return count == 1
+	}
+
+	results, err := retention.Apply(ctx, f.db, policies, retention.DryRun())
+	must(t, err)
+	expiredBy := map[string]int64{}
+	for _, r := range results {
+		if r.Deleted != 0 {
+			t.Errorf("dry run of %s deleted %d rows", r.Policy.Name, r.Deleted)
+		}
+		expiredBy[r.Policy.Name] = r.Expired
+	}
+	for _, name := range []string{"messages", "audit_logs", "soft_deleted:posts"} {
+		if expiredBy[name] == 0 {
+			t.Errorf("dry run found nothing expired for %s", name)
+		}
+	}
+	if !exists(&model.Message{}, expired.ID) {
+		t.Error("dry run deleted an expired message")
+	}
+
+	results, err = retention.Apply(ctx, f.db, policies, retention.WithBatchSize(1))
+	must(t, err)
+	for _, r := range results {
+		if r.Deleted != r.Expired {
+			t.Errorf("%s deleted %d of %d expired rows", r.Policy.Name, r.Deleted, r.Expired)
+		}
+	}
+	if exists(&model.Message{}, expired.ID) || exists(&model.AuditLog{}, entry.ID) || exists(&model.Post{}, deleted.ID) {
+		t.Error("expired message, audit log entry or soft-deleted post survived")
+	}
+	if !exists(&model.Message{}, kept.ID) || !exists(&model.User{}, sender.ID) {
+		t.Error("retention deleted a row that had not expired")
+	}
+}
@@ -0,0 +1,182 @@
+package repotest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	closefriendrepo "github.com/ilhamosaurus/sns-platform/internal/module/closefriend/repository"
+	followrepo "github.com/ilhamosaurus/sns-platform/internal/module/follow/repository"
+	userrepo "github.com/ilhamosaurus/sns-platform/internal/module/user/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+)
+
+func testUsers(t *testing.T, f *fixtures) {
+	repo := userrepo.NewUserRepository(f.db)
+	ctx := t.Context()
+
+	name := f.name("u")
+	user := &model.User{Username: name, Email: name + "@example.com", PasswordHash: "x"}
+	must(t, repo.Create(ctx, user))
+	if user.ID == 0 {
+		t.Fatal("Create did not set the ID")
+	}
+
+	dup := &model.User{Username: name, Email: "other_" + name + "@example.com", PasswordHash: "x"}
+	if err := repo.Create(ctx, dup); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("Create with a taken username = %v, want ErrConflict", err)
+	}
+	dup = &model.User{Username: strings.ToUpper(name), Email: "upper_" + name + "@example.com", PasswordHash: "x"}
+	if err := repo.Create(ctx, dup); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("Create with a taken username in another case = %v, want ErrConflict", err)
+	}
+	dup = &model.User{Username: "upper_" + name, Email: strings.ToUpper(name) + "@example.com", PasswordHash: "x"}
+	if err := repo.Create(ctx, dup); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("Create with a taken email in another case = %v, want ErrConflict", err)
+	}
+	if got, err := repo.GetByUsername(ctx, strings.ToUpper(name)); err != nil || got.ID != user.ID {
+		t.Errorf("GetByUsername in another case = %v, %v, want user %d", got, err, user.ID)
+	}
+
+	must(t, repo.Update(ctx, user.ID, map[string]any{"full_name": "Renamed", "bio": "hello"}))
+	got, err := repo.GetByID(ctx, user.ID)
+	must(t, err)
+	if got.FullName != "Renamed" || got.Bio != "hello" || got.Language != "en" {
+		t.Errorf("GetByID = %q/%q/%q, want Renamed/hello/en", got.FullName, got.Bio, got.Language)
+	}
+
+	must(t, repo.UpdateFollowCount(ctx, name, types.ActionFollowed))
+	must(t, repo.UpdateFollowCount(ctx, name, types.ActionFollowing))
+	must(t, repo.UpdatePostCount(ctx, user.ID, types.ActionCreated))
+	if err := repo.UpdateFollowCount(ctx, name, types.ActionLiked); err == nil {
+		t.Error("UpdateFollowCount accepted an unrelated action")
+	}
+
+	viewer := f.user(t)
+	f.follow(t, viewer.ID, user.ID)
+	profile, err := repo.GetUserProfile(ctx, name, viewer.ID)
+	must(t, err)
+	if !profile.IsFollowing || profile.FollowerCount != 1 || profile.FollowingCount != 1 || profile.PostCount != 1 {
+		t.Errorf("GetUserProfile = %+v, want following with counts 1/1/1", profile)
+	}
+	profile, err = repo.GetUserProfile(ctx, name, 0)
+	must(t, err)
+	if profile.IsFollowing {
+		t.Error("GetUserProfile reports an anonymous viewer as following")
+	}
+
+	users, total, err := repo.List(ctx, map[string]any{"username = ?": name}, 1, 10)
+	must(t, err)
+	if total != 1 || len(users) != 1 || users[0].ID != user.ID {
+		t.Errorf("List = %d users of %d, want just the user", len(users), total)
+	}
+
+	// Deactivated profiles are hidden from everyone but their owner
+	changed, err := repo.Deactivate(ctx, user.ID, time.Now().UTC())
+	must(t, err)
+	if again, _ := repo.Deactivate(ctx, user.ID, time.Now().UTC()); !changed || again {
+		t.Errorf("Deactivate twice = %v then %v, want true then false", changed, again)
+	}
+	if _, err := repo.GetUserProfile(ctx, name, viewer.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetUserProfile of a deactivated user = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.GetUserProfile(ctx, name, user.ID); err != nil {
+		t.Errorf("GetUserProfile of oneself while deactivated = %v", err)
+	}
+	changed, err = repo.Reactivate(ctx, user.ID)
+	must(t, err)
+	if !changed {
+		t.Error("Reactivate = false for a deactivated user")
+	}
+	if _, err := repo.GetUserProfile(ctx, name, viewer.ID); err != nil {
+		t.Errorf("GetUserProfile after Reactivate = %v", err)
+	}
+
+	must(t, repo.Delete(ctx, user.ID))
+	if _, err := repo.GetByID(ctx, user.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetByID after Delete = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.GetUserProfile(ctx, name, 0); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("GetUserProfile after Delete = %v, want ErrNotFound", err)
+	}
+	var deleted model.User
+	must(t, f.db.Unscoped().First(&deleted, user.ID).Error)
+	if username, email := model.Tombstone(user.ID); deleted.Username != username || deleted.Email != email {
+		t.Errorf("deleted user kept %s <%s>, want the tombstone %s <%s>", deleted.Username, deleted.Email, username, email)
+	}
+	again := &model.User{Username: name, Email: name + "@example.com", PasswordHash: "x"}
+	must(t, repo.Create(ctx, again))
+	if got, err := repo.GetByUsername(ctx, name); err != nil || got.ID != again.ID {
+		t.Errorf("GetByUsername of a re-registered username = %v, %v, want user %d", got, err, again.ID)
+	}
+}
+
+func testFollows(t *testing.T, f *fixtures) {
+	repo := followrepo.NewFollowRepository(f.db)
+	ctx := t.Context()
+	a, b := f.user(t), f.user(t)
+
+	must(t, repo.Follow(ctx, a.ID, b.ID))
+	if err := repo.Follow(ctx, a.ID, b.ID); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("Follow twice = %v, want ErrConflict", err)
+	}
+	if err := repo.Follow(ctx, a.ID, a.ID); err == nil {
+		t.Error("Follow accepted following oneself")
+	}
+	must(t, repo.Unfollow(ctx, a.ID, b.ID))
+
+	var count int64
+	must(t, f.db.Model(&model.Follow{}).Where("follower_id = ? AND deleted_at IS NULL", a.ID).Count(&count).Error)
+	if count != 0 {
+		t.Errorf("%d follows left after Unfollow, want 0", count)
+	}
+	if err := repo.Unfollow(ctx, a.ID, b.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("Unfollow twice = %v, want ErrNotFound", err)
+	}
+	must(t, repo.Follow(ctx, a.ID, b.ID))
+}
+
+func testCloseFriends(t *testing.T, f *fixtures) {
+	repo := closefriendrepo.NewCloseFriendRepository(f.db)
+	ctx := t.Context()
+	owner, friend, other := f.user(t), f.user(t), f.user(t)
+
+	must(t, repo.Add(ctx, owner.ID, friend.ID))
+	must(t, repo.Add(ctx, owner.ID, friend.ID))
+
+	ok, err := repo.IsCloseFriend(ctx, owner.ID, friend.ID)
+	must(t, err)
+	if !ok {
+		t.Error("IsCloseFriend = false after Add")
+	}
+	if ok, _ := repo.IsCloseFriend(ctx, friend.ID, owner.ID); ok {
+		t.Error("IsCloseFriend is not directional")
+	}
+
+	users, err := repo.List(ctx, owner.ID, 10, 0)
+	must(t, err)
+	if len(users) != 1 || users[0].ID != friend.ID {
+		t.Errorf("List = %d users, want just the friend", len(users))
+	}
+
+	// Close friends posts reach the author and the list, nobody else
+	post := f.post(t, owner.ID, func(p *model.Post) { p.CloseFriends = true })
+	for viewer, want := range map[int64]bool{owner.ID: true, friend.ID: true, other.ID: false} {
+		var ids []int64
+		must(t, f.db.Model(&model.Post{}).Where("posts.id = ?", post.ID).
+			Scopes(closefriendrepo.VisibleTo(viewer)).Pluck("posts.id", &ids).Error)
+		if (len(ids) == 1) != want {
+			t.Errorf("VisibleTo(%d) sees the post = %v, want %v", viewer, len(ids) == 1, want)
+		}
+	}
+
+	must(t, repo.Remove(ctx, owner.ID, friend.ID))
+	must(t, repo.Add(ctx, owner.ID, friend.ID))
+	must(t, repo.Remove(ctx, owner.ID, friend.ID))
+	if ok, _ := repo.IsCloseFriend(ctx, owner.ID, friend.ID); ok {
+		t.Error("IsCloseFriend = true after Remove")
+	}
+}
@@ -0,0 +1,55 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/internal/module/waitlist/repository"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+)
+
+func testWaitlist(t *testing.T, f *fixtures) {
+	ctx := t.Context()
+	repo := repository.NewWaitlistRepository(f.db)
+	now := time.Now().UTC()
+
+	first := &model.WaitlistEntry{Email: f.name("w") + "@example.com"}
+	second := &model.WaitlistEntry{Email: f.name("w") + "@example.com"}
+	must(t, repo.Create(ctx, first))
+	must(t, repo.Create(ctx, second))
+	if err := repo.Create(ctx, &model.WaitlistEntry{Email: first.Email}); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("Create of a listed email = %v, want ErrConflict", err)
+	}
+	if got, err := repo.GetByEmail(ctx, second.Email); err != nil || got.ID != second.ID {
+		t.Errorf("GetByEmail = %v, %v, want entry %d", got, err, second.ID)
+	}
+	firstPos, err := repo.Position(ctx, first.ID)
+	must(t, err)
+	if secondPos, err := repo.Position(ctx, second.ID); err != nil || secondPos != firstPos+1 {
+		t.Errorf("Position of the second entry = %d, %v, want %d", secondPos, err, firstPos+1)
+	}
+
+	// Earlier runs may have left entries waiting ahead of ours
+	for range firstPos - 1 {
+		if _, err := repo.ActivateNext(ctx, 1, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if activated, err := repo.ActivateNext(ctx, 1, now); err != nil || len(activated) != 1 || activated[0].ID != first.ID || activated[0].ActivatedAt == nil {
+		t.Fatalf("ActivateNext = %v, %v, want the first entry", activated, err)
+	}
+	if pos, err := repo.Position(ctx, second.ID); err != nil || pos != 1 {
+		t.Errorf("Position after activation = %d, %v, want 1", pos, err)
+	}
+	if got, err := repo.GetByEmail(ctx, first.Email); err != nil || !got.Activated() {
+		t.Errorf("GetByEmail after activation = %v, %v, want it activated", got, err)
+	}
+	if waiting, err := repo.List(ctx, false, 1, 0); err != nil || len(waiting) != 1 || waiting[0].ID != second.ID {
+		t.Errorf("List waiting = %v, %v, want the second entry first in line", waiting, err)
+	}
+	if count, err := repo.Count(ctx, true); err != nil || count < 1 {
+		t.Errorf("Count activated = %d, %v, want at least 1", count, err)
+	}
+}
@@ -0,0 +1,201 @@
+// Package retention permanently deletes rows once they are older than the
+// period configured for their kind: direct messages, notifications, audit
+// logs, and soft-deleted rows of every table. Each kind is a Policy over a
+// table and the timestamp its rows age by, and Apply runs them all the same
+// way, in batches, or only counts what they would delete. Rows under a legal
+// hold are never deleted.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"gorm.io/gorm"
+)
+
+// DefaultBatchSize is how many rows each delete statement removes
+const DefaultBatchSize = 1000
+
+// Config sets how long each kind of row is kept; zero keeps it forever
+type Config struct {
+	Messages      time.Duration
+	Notifications time.Duration
+	AuditLogs     time.Duration
+	SoftDeleted   time.Duration // Since the row was deleted
+	BatchSize     int
+	DryRun        bool // Only report what would be deleted
+}
+
+// Policy deletes the rows of Table whose Column is older than Keep, except
+// those matching Exempt
+type Policy struct {
+	Name   string
+	Table  string
+	Column string
+	Keep   time.Duration
+	Exempt string // SQL condition on Table's rows; empty exempts none
+}
+
+// Policies returns the policies config enables. Soft-deleted rows get one
+// policy per table, named "soft_deleted:<table>".
+func Policies(config Config) ([]Policy, error) {
+	var policies []Policy
+	add := func(name, table, column string, keep time.Duration, exempt string) {
+		if keep > 0 {
+			policies = append(policies, Policy{Name: name, Table: table, Column: column, Keep: keep, Exempt: exempt})
+		}
+	}
+	add("messages", "messages", "created_at", config.Messages, held("messages", nil))
+	add("notifications", "notifications", "created_at", config.Notifications, held("notifications", []string{"user_id"}))
+	add("audit_logs", "audit_logs", "created_at", config.AuditLogs, held("audit_logs", nil))
+
+	if config.SoftDeleted > 0 {
+		tables, err := pkgdb.SoftDeleteTables()
+		if err != nil {
+			return nil, err
+		}
+		for _, table := range tables {
+			add("soft_deleted:"+table.Name, table.Name, "deleted_at", config.SoftDeleted, held(table.Name, table.Columns))
+		}
+	}
+	return policies, nil
+}
+
+// held matches the rows of table a legal hold preserves: held records
+// themselves, rows owned by held users, and rows whose deletion would
+// cascade to a held record
+func held(table string, columns []string) string {
+	var (
+		users    = model.HeldIDs(model.LegalHoldUser)
+		posts    = model.HeldIDs(model.LegalHoldPost)
+		comments = model.HeldIDs(model.LegalHoldComment)
+		messages = model.HeldIDs(model.LegalHoldMessage)
+	)
+	// A NULL column would make the whole condition NULL, which exempts the
+	// row as surely as a match
+	in := func(column, ids string) string {
+		return "(" + column + " IS NOT NULL AND " + column + " IN (" + ids + "))"
+	}
+	var conds []string
+	switch table {
+	case "users":
+		conds = append(conds,
+			in("id", users),
+			in("id", "SELECT user_id FROM posts WHERE id IN ("+posts+")"),
+			in("id", "SELECT user_id FROM comments WHERE id IN ("+comments+")"),
+			in("id", "SELECT sender_id FROM messages WHERE id IN ("+messages+")"),
+			in("id", "SELECT receiver_id FROM messages WHERE id IN ("+messages+")"))
+	case "posts":
+		conds = append(conds, in("id", posts), in("id", "SELECT post_id FROM comments WHERE id IN ("+comments+")"))
+	case "comments":
+		conds = append(conds, in("id", comments), in("id", "SELECT parent_id FROM comments WHERE id IN ("+comments+") AND parent_id IS NOT NULL"))
+	case "messages":
+		conds = append(conds, in("id", messages), in("sender_id", users), in("receiver_id", users))
+	case "audit_logs":
+		conds = append(conds, in("actor_id", users), "(target_type = '"+model.AuditTargetUser+"' AND "+in("target_id", users)+")")
+	}
+	if slices.Contains(columns, "user_id") {
+		conds = append(conds, in("user_id", users))
+	}
+	return strings.Join(conds, " OR ")
+}
+
+// Options tunes an Apply call
+type Options struct {
+	BatchSize int
+	DryRun    bool
+}
+
+// Option customizes an Apply call
+type Option func(*Options)
+
+// WithBatchSize sets how many rows each statement deletes; zero keeps DefaultBatchSize
+func WithBatchSize(n int) Option {
+	return func(o *Options) {
+		if n > 0 {
+			o.BatchSize = n
+		}
+	}
+}
+
+// DryRun makes Apply only count the expired rows
+func DryRun() Option {
+	return func(o *Options) { o.DryRun = true }
+}
+
+// Result reports how many rows of a policy had expired and how many of them
+// were deleted, which is none on a dry run
+type Result struct {
+	Policy  Policy
+	Cutoff  time.Time
+	Expired int64
+	Deleted int64
+}
+
+// Apply deletes the expired rows of every policy. The statements bypass
+// GORM, so soft deletes and model hooks, such as the one keeping audit logs
+// immutable, do not apply: retention is the one sanctioned way rows leave
+// for good. Each batch is deleted on its own, so a failure keeps the
+// batches already done.
+func Apply(ctx context.Context, db *gorm.DB, policies []Policy, opts ...Option) ([]Result, error) {
+	o := Options{BatchSize: DefaultBatchSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	now := time.Now().UTC()
+	results := make([]Result, 0, len(policies))
+	for _, p := range policies {
+		result, err := apply(ctx, db, p, now.Add(-p.Keep), o)
+		if err != nil {
+			return results, fmt.Errorf("failed to apply retention policy %s: %w", p.Name, err)
+		}
+		if result.Expired > 0 {
+			slog.InfoContext(ctx, "found expired rows", slog.String("policy", p.Name),
+				slog.Int64("rows", result.Expired), slog.Int64("deleted", result.Deleted), slog.Bool("dry_run", o.DryRun))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func apply(ctx context.Context, db *gorm.DB, p Policy, cutoff time.Time, o Options) (Result, error) {
+	result := Result{Policy: p, Cutoff: cutoff}
+	db = db.WithContext(ctx)
+	expired := fmt.Sprintf("%s < ?", p.Column)
+	if p.Exempt != "" {
+		expired += fmt.Sprintf(" AND NOT (%s)", p.Exempt)
+	}
+
+	if o.DryRun {
+		err := db.Raw(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", p.Table, expired), cutoff).Scan(&result.Expired).Error
+		return result, err
+	}
+
+	selectBatch := fmt.Sprintf("SELECT id FROM %s WHERE %s ORDER BY id LIMIT ?", p.Table, expired)
+	deleteBatch := fmt.Sprintf("DELETE FROM %s WHERE id IN ?", p.Table)
+	for {
+		var ids []int64
+		if err := db.Raw(selectBatch, cutoff, o.BatchSize).Scan(&ids).Error; err != nil {
+			return result, err
+		}
+		if len(ids) == 0 {
+			return result, nil
+		}
+		result.Expired += int64(len(ids))
+		tx := db.Exec(deleteBatch, ids)
+		if tx.Error != nil {
+			return result, tx.Error
+		}
+		result.Deleted += tx.RowsAffected
+		if len(ids) < o.BatchSize {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package seed fills a database with generated users, follows, posts and
+// likes for local development and load testing.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	postrepo "github.com/ilhamosaurus/sns-platform/internal/module/post/repository"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Password is the password of every seeded user
+const Password = "password"
+
+// Options size the generated data
+type Options struct {
+	Users          int // Users to create
+	FollowsPerUser int // Accounts each user follows, capped by Users-1
+	PostsPerUser   int
+	LikesPerPost   int    // Upper bound; each post gets a random number up to it
+	Seed           uint64 // Random seed, so runs can be reproduced
+	FanOut         feedrepo.FanOutConfig
+	UsernamePrefix string // Defaults to a prefix unique to the run
+	BatchSize      int    // Rows per INSERT, zero for the database default
+	ProgressEvery  int    // Log progress after this many posts are fanned out, zero for 1000
+}
+
+// Scale returns options for n users with proportional activity
+func Scale(n int) Options {
+	return Options{
+		Users:          n,
+		FollowsPerUser: 20,
+		PostsPerUser:   5,
+		LikesPerPost:   10,
+		Seed:           uint64(time.Now().UnixNano()),
+		FanOut:         feedrepo.DefaultFanOutConfig,
+	}
+}
+
+// Summary counts the rows Run created
+type Summary struct {
+	Users, Follows, Posts, Likes int
+}
+
+// Run generates the data in opts and fans every post out to follower feeds
+func Run(ctx context.Context, db *gorm.DB, opts Options) (Summary, error) {
+	var summary Summary
+	if opts.Users <= 0 {
+		return summary, fmt.Errorf("seed needs at least one user")
+	}
+	if opts.UsernamePrefix == "" {
+		opts.UsernamePrefix = "seed" + strconv.FormatInt(time.Now().Unix(), 36) + "_"
+	}
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed>>1|1))
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.DefaultCost)
+	if err != nil {
+		return summary, fmt.Errorf("failed to hash seed password: %w", err)
+	}
+
+	// Follows are chosen up front so the denormalized counts are inserted with the users
+	users := make([]*model.User, opts.Users)
+	for i := range users {
+		name := fmt.Sprintf("%s%d", opts.UsernamePrefix, i+1)
+		users[i] = &model.User{
+			Username:     name,
+			Email:        name + "@example.com",
+			PasswordHash: string(hash),
+			FullName:     fmt.Sprintf("Seed User %d", i+1),
+			PostCount:    int64(opts.PostsPerUser),
+		}
+	}
+	type pair struct{ follower, following int }
+	var pairs []pair
+	perUser := min(opts.FollowsPerUser, opts.Users-1)
+	for i := range users {
+		for _, j := range rng.Perm(opts.Users - 1)[:perUser] {
+			if j >= i {
+				j++ // skip the user themselves
+			}
+			pairs = append(pairs, pair{i, j})
+			users[i].FollwingCount++
+			users[j].FollowerCount++
+		}
+	}
+	if err := pkgdb.CreateInBatches(ctx, db, users, opts.BatchSize); err != nil {
+		return summary, fmt.Errorf("failed to seed users: %w", err)
+	}
+	summary.Users = len(users)
+
+	follows := make([]*model.Follow, len(pairs))
+	for i, p := range pairs {
+		follows[i] = &model.Follow{FollowerID: users[p.follower].ID, FollowingID: users[p.following].ID}
+	}
+	if err := pkgdb.CreateInBatches(ctx, db, follows, opts.BatchSize); err != nil {
+		return summary, fmt.Errorf("failed to seed follows: %w", err)
+	}
+	summary.Follows = len(follows)
+
+	// Posts are spread over the last 30 days, oldest first
+	now := time.Now().UTC()
+	posts := make([]*model.Post, 0, opts.Users*opts.PostsPerUser)
+	for _, user := range users {
+		for range opts.PostsPerUser {
+			post := &model.Post{
+				UserID:    user.ID,
+				Content:   sentences[rng.IntN(len(sentences))],
+				MediaType: types.MediaTypeText,
+				IsPublic:  true,
+			}
+			post.CreatedAt = now.Add(-time.Duration(rng.Int64N(int64(30 * 24 * time.Hour))))
+			posts = append(posts, post)
+		}
+	}
+	if err := postrepo.NewPostRepository(db).CreateBatch(ctx, posts, opts.BatchSize); err != nil {
+		return summary, fmt.Errorf("failed to seed posts: %w", err)
+	}
+	summary.Posts = len(posts)
+
+	var likes []*model.Reaction
+	for _, post := range posts {
+		n := rng.IntN(min(opts.LikesPerPost, opts.Users) + 1)
+		for _, i := range rng.Perm(opts.Users)[:n] {
+			likes = append(likes, &model.Reaction{UserID: users[i].ID, PostID: &post.ID, Type: types.ReactionTypeLike})
+		}
+		post.LikeCount = int64(n)
+	}
+	if err := pkgdb.CreateInBatches(ctx, db, likes, opts.BatchSize); err != nil {
+		return summary, fmt.Errorf("failed to seed likes: %w", err)
+	}
+	for _, post := range posts {
+		if post.LikeCount == 0 {
+			continue
+		}
+		if err := db.WithContext(ctx).Model(post).UpdateColumn("like_count", post.LikeCount).Error; err != nil {
+			return summary, fmt.Errorf("failed to seed like counts: %w", err)
+		}
+	}
+	summary.Likes = len(likes)
+
+	feedRepo := feedrepo.NewFeedRepository(db, feedrepo.WithFanOut(opts.FanOut))
+	every := opts.ProgressEvery
+	if every <= 0 {
+		every = 1000
+	}
+	for i, post := range posts {
+		if err := feedRepo.FanOutPost(ctx, post.ID, post.UserID, post.CreatedAt); err != nil {
+			return summary, fmt.Errorf("failed to seed feeds: %w", err)
+		}
+		if (i+1)%every == 0 {
+			slog.InfoContext(ctx, "fanning out seeded posts", slog.Int("done", i+1), slog.Int("total", len(posts)))
+		}
+	}
+	return summary, nil
+}
+
+var sentences = []string{
+	"Just finished a long run, feeling great!",
+	"Coffee first, then everything else.",
+	"Anyone else watching the game tonight?",
+	"Trying out a new recipe this weekend.",
+	"The sunset today was unreal.",
+	"Reading a fantastic book, will share thoughts soon.",
+	"Monday again already?",
+	"Shipped a new feature at work today.",
+	"Weekend hike with friends, highly recommend the trail.",
+	"Learning something new every day.",
+}
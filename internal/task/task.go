@@ -0,0 +1,372 @@
+package task
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/config"
+	"github.com/ilhamosaurus/sns-platform/internal/counter"
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	analyticssvc "github.com/ilhamosaurus/sns-platform/internal/module/analytics/service"
+	archivesvc "github.com/ilhamosaurus/sns-platform/internal/module/archive/service"
+	commentrepo "github.com/ilhamosaurus/sns-platform/internal/module/comment/repository"
+	directorysvc "github.com/ilhamosaurus/sns-platform/internal/module/directory/service"
+	exportsvc "github.com/ilhamosaurus/sns-platform/internal/module/export/service"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	notificationrepo "github.com/ilhamosaurus/sns-platform/internal/module/notification/repository"
+	waitlistsvc "github.com/ilhamosaurus/sns-platform/internal/module/waitlist/service"
+	"github.com/ilhamosaurus/sns-platform/internal/retention"
+	pkgdb "github.com/ilhamosaurus/sns-platform/pkg/db"
+	"github.com/ilhamosaurus/sns-platform/pkg/scheduler"
+	"github.com/ilhamosaurus/sns-platform/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// Task names, matching the keys under scheduler.tasks in config.yaml
+const (
+	NameFeedPruning           = "feed_pruning"
+	NameNotificationCleanup   = "notification_cleanup"
+	NameTrendingRecompute     = "trending_recompute"
+	NameCounterReconciliation = "counter_reconciliation"
+	NameMediaGC               = "media_gc"
+	NameExportCleanup         = "export_cleanup"
+	NameAnalyticsRollup       = "analytics_rollup"
+	NameUserStatsSnapshot     = "user_stats_snapshot"
+	NameLDAPSync              = "ldap_sync"
+	NameCommentRanking        = "comment_ranking"
+	NameWaitlistActivation    = "waitlist_activation"
+	NameDataRetention         = "data_retention"
+	NameAffinityRecompute     = "affinity_recompute"
+)
+
+// Deps holds the components maintenance tasks operate on
+type Deps struct {
+	FeedRepo         feedrepo.FeedRepository
+	CommentRepo      commentrepo.CommentRepository
+	NotificationRepo notificationrepo.NotificationRepository
+	ExportService    exportsvc.ExportService
+	AnalyticsService analyticssvc.AnalyticsService
+	DirectoryService directorysvc.DirectoryService // Nil when no directory is configured
+	WaitlistService  waitlistsvc.WaitlistService
+	WaitlistBatch    int      // Entries each waitlist_activation run lets in
+	DB               *gorm.DB // For tasks that work across tables
+	Retention        retention.Config
+	Media            storage.Storage
+}
+
+// Register adds every enabled maintenance task to the scheduler
+func Register(s *scheduler.Scheduler, cfg config.SchedulerConfig, deps Deps) {
+	builders := map[string]func(config.TaskConfig) func(ctx context.Context) error{
+		NameFeedPruning: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return pruneFeed(deps.FeedRepo, tc.Retention)
+		},
+		NameNotificationCleanup: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return cleanupNotifications(deps.NotificationRepo, tc.Retention)
+		},
+		NameExportCleanup: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return cleanupExports(deps.ExportService)
+		},
+		NameAnalyticsRollup: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return rollupAnalytics(deps.AnalyticsService)
+		},
+		NameUserStatsSnapshot: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return snapshotUserStats(deps.AnalyticsService)
+		},
+		NameTrendingRecompute: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return recomputeExplore(deps.FeedRepo)
+		},
+		NameAffinityRecompute: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return recomputeAffinities(deps.FeedRepo, tc.Retention)
+		},
+		NameCounterReconciliation: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return reconcileCounters(deps.DB)
+		},
+		NameCommentRanking: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return rankComments(deps.CommentRepo, tc.Retention)
+		},
+		NameWaitlistActivation: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return activateWaitlist(deps.WaitlistService, deps.WaitlistBatch)
+		},
+		NameDataRetention: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return applyRetention(deps.DB, deps.Retention)
+		},
+		NameMediaGC: func(tc config.TaskConfig) func(ctx context.Context) error {
+			return collectMedia(deps.DB, deps.Media, tc.Retention)
+		},
+	}
+	if deps.DirectoryService != nil {
+		builders[NameLDAPSync] = func(tc config.TaskConfig) func(ctx context.Context) error {
+			return syncDirectory(deps.DirectoryService)
+		}
+	}
+
+	for name, tc := range cfg.Tasks {
+		if !tc.Enabled {
+			continue
+		}
+		build, ok := builders[name]
+		if !ok {
+			slog.Warn("scheduler task is enabled but not available in this build", slog.String("task", name))
+			continue
+		}
+		run := build(tc)
+		s.Register(scheduler.Task{Name: name, Interval: tc.Interval, Run: func(ctx context.Context) error {
+			// Maintenance scans whole tables; the query timeout is for requests
+			return run(pkgdb.WithQueryTimeout(ctx, 0))
+		}})
+	}
+}
+
+func pruneFeed(repo feedrepo.FeedRepository, retention time.Duration) func(ctx context.Context) error {
+	if retention <= 0 {
+		retention = 30 * 24 * time.Hour
+	}
+	return func(ctx context.Context) error {
+		n, err := repo.PruneBefore(ctx, time.Now().UTC().Add(-retention))
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "pruned activity feed entries", slog.Int64("count", n))
+		return nil
+	}
+}
+
+func cleanupNotifications(repo notificationrepo.NotificationRepository, retention time.Duration) func(ctx context.Context) error {
+	if retention <= 0 {
+		retention = 90 * 24 * time.Hour
+	}
+	return func(ctx context.Context) error {
+		n, err := repo.DeleteReadBefore(ctx, time.Now().UTC().Add(-retention))
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "deleted read notifications", slog.Int64("count", n))
+		return nil
+	}
+}
+
+// recomputeExplore materializes the explore candidates of each configured
+// window, so explore requests page over them instead of ranking every post
+func recomputeExplore(repo feedrepo.FeedRepository) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		n, err := repo.RecomputeExplore(ctx, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "recomputed explore candidates", slog.Int64("count", n))
+		return nil
+	}
+}
+
+// recomputeAffinities rebuilds the reader-to-author affinities from the
+// engagement of the last window, so affinity fades as interactions age out
+func recomputeAffinities(repo feedrepo.FeedRepository, window time.Duration) func(ctx context.Context) error {
+	if window <= 0 {
+		window = 30 * 24 * time.Hour
+	}
+	return func(ctx context.Context) error {
+		n, err := repo.RecomputeAffinities(ctx, time.Now().UTC().Add(-window))
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "recomputed feed affinities", slog.Int64("pairs", n))
+		return nil
+	}
+}
+
+func cleanupExports(svc exportsvc.ExportService) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		n, err := svc.PurgeExpired(ctx)
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "purged expired data exports", slog.Int("count", n))
+		return nil
+	}
+}
+
+func reconcileCounters(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		results, err := counter.Reconcile(ctx, db)
+		if err != nil {
+			return err
+		}
+		var drifted, fixed int64
+		for _, r := range results {
+			drifted += r.Drifted
+			fixed += r.Fixed
+		}
+		slog.InfoContext(ctx, "reconciled denormalized counters", slog.Int64("drifted", drifted), slog.Int64("fixed", fixed))
+		return nil
+	}
+}
+
+// applyRetention deletes what the retention policies let expire; a dry run
+// only logs how much that would be
+func applyRetention(db *gorm.DB, cfg retention.Config) func(ctx context.Context) error {
+	opts := []retention.Option{retention.WithBatchSize(cfg.BatchSize)}
+	if cfg.DryRun {
+		opts = append(opts, retention.DryRun())
+	}
+	return func(ctx context.Context) error {
+		policies, err := retention.Policies(cfg)
+		if err != nil {
+			return err
+		}
+		results, err := retention.Apply(ctx, db, policies, opts...)
+		if err != nil {
+			return err
+		}
+		var expired, deleted int64
+		for _, r := range results {
+			expired += r.Expired
+			deleted += r.Deleted
+		}
+		slog.InfoContext(ctx, "applied retention policies", slog.Int("policies", len(policies)),
+			slog.Int64("expired", expired), slog.Int64("deleted", deleted), slog.Bool("dry_run", cfg.DryRun))
+		return nil
+	}
+}
+
+// mediaGCBatch is how many stored objects collectMedia looks up at once
+const mediaGCBatch = 500
+
+// collectMedia deletes post media that no post or message refers to any
+// more, e.g. once retention purged the post. Objects younger than grace are
+// kept, as an import stores media before the post that uses it.
+func collectMedia(db *gorm.DB, media storage.Storage, grace time.Duration) func(ctx context.Context) error {
+	if grace <= 0 {
+		grace = 24 * time.Hour
+	}
+	return func(ctx context.Context) error {
+		cutoff := time.Now().Add(-grace)
+		var batch []storage.Object
+		var deleted int
+		flush := func() error {
+			n, err := deleteUnreferenced(ctx, db, media, batch)
+			deleted += n
+			batch = batch[:0]
+			return err
+		}
+		err := media.Walk(ctx, archivesvc.MediaPrefix, func(obj storage.Object) error {
+			if obj.ModifiedAt.After(cutoff) {
+				return nil
+			}
+			batch = append(batch, obj)
+			if len(batch) < mediaGCBatch {
+				return nil
+			}
+			return flush()
+		})
+		if err == nil && len(batch) > 0 {
+			err = flush()
+		}
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "collected unreferenced media", slog.Int("deleted", deleted))
+		return nil
+	}
+}
+
+// deleteUnreferenced deletes the objects no post or message refers to.
+// Deleted posts still count until retention purges them, so they can be
+// restored with their media.
+func deleteUnreferenced(ctx context.Context, db *gorm.DB, media storage.Storage, objects []storage.Object) (int, error) {
+	urls := make([]string, len(objects))
+	for i, obj := range objects {
+		urls[i] = obj.URL
+	}
+	referenced := make(map[string]bool, len(urls))
+	for _, table := range []any{&model.Post{}, &model.Message{}} {
+		var used []string
+		err := db.WithContext(ctx).Unscoped().Model(table).
+			Where("media_url IN ?", urls).
+			Distinct().Pluck("media_url", &used).Error
+		if err != nil {
+			return 0, err
+		}
+		for _, url := range used {
+			referenced[url] = true
+		}
+	}
+
+	deleted := 0
+	for _, obj := range objects {
+		if referenced[obj.URL] {
+			continue
+		}
+		if err := media.Delete(ctx, obj.Key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// rankComments rescores the comments of the last window; older comments have
+// decayed enough that their order no longer moves
+func rankComments(repo commentrepo.CommentRepository, window time.Duration) func(ctx context.Context) error {
+	if window <= 0 {
+		window = 7 * 24 * time.Hour
+	}
+	return func(ctx context.Context) error {
+		now := time.Now().UTC()
+		n, err := repo.Rescore(ctx, now.Add(-window), now)
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "rescored comments", slog.Int64("count", n))
+		return nil
+	}
+}
+
+// activateWaitlist lets the next batch of waitlisted sign-ups in, so a
+// launch opens up at a steady pace without an admin at the controls
+func activateWaitlist(svc waitlistsvc.WaitlistService, batch int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		entries, err := svc.Activate(ctx, batch)
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "activated waitlist entries", slog.Int("count", len(entries)))
+		return nil
+	}
+}
+
+func rollupAnalytics(svc analyticssvc.AnalyticsService) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return svc.Rollup(ctx, time.Now().UTC())
+	}
+}
+
+func snapshotUserStats(svc analyticssvc.AnalyticsService) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		n, err := svc.SnapshotUserStats(ctx, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "snapshotted user stats", slog.Int64("users", n))
+		return nil
+	}
+}
+
+func syncDirectory(svc directorysvc.DirectoryService) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		result, err := svc.Sync(ctx)
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "synced users from directory",
+			slog.Int("created", result.Created),
+			slog.Int("linked", result.Linked),
+			slog.Int("updated", result.Updated),
+			slog.Int("suspended", result.Suspended),
+			slog.Int("reactivated", result.Reactivated),
+			slog.Int("skipped", result.Skipped),
+			slog.Int("follows_added", result.FollowsAdded),
+			slog.Int("follows_removed", result.FollowsRemoved))
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	feedrepo "github.com/ilhamosaurus/sns-platform/internal/module/feed/repository"
+	"gorm.io/gorm"
+)
+
+// Fixtures are the rows Seed creates. Bob follows Alice and has her post in
+// his feed; Carol follows nobody and has Alice as a close friend.
+type Fixtures struct {
+	Alice *model.User
+	Bob   *model.User
+	Carol *model.User
+
+	Post *model.Post // Alice's public post
+}
+
+// Seed creates the minimal social graph most tests start from
+func Seed(t testing.TB, db *gorm.DB) *Fixtures {
+	t.Helper()
+
+	f := &Fixtures{
+		Alice: &model.User{Username: "alice", Email: "alice@example.com", PasswordHash: "x", FullName: "Alice", FollowerCount: 1, PostCount: 1},
+		Bob:   &model.User{Username: "bob", Email: "bob@example.com", PasswordHash: "x", FullName: "Bob", FollwingCount: 1},
+		Carol: &model.User{Username: "carol", Email: "carol@example.com", PasswordHash: "x", FullName: "Carol"},
+	}
+	for _, user := range []*model.User{f.Alice, f.Bob, f.Carol} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("failed to seed user %s: %v", user.Username, err)
+		}
+	}
+	if err := db.Create(&model.Follow{FollowerID: f.Bob.ID, FollowingID: f.Alice.ID}).Error; err != nil {
+		t.Fatalf("failed to seed follow: %v", err)
+	}
+	if err := db.Create(&model.CloseFriend{UserID: f.Carol.ID, FriendID: f.Alice.ID}).Error; err != nil {
+		t.Fatalf("failed to seed close friend: %v", err)
+	}
+
+	f.Post = &model.Post{UserID: f.Alice.ID, Content: "Hello from Alice", IsPublic: true}
+	if err := db.Create(f.Post).Error; err != nil {
+		t.Fatalf("failed to seed post: %v", err)
+	}
+	if err := feedrepo.NewFeedRepository(db).FanOutPost(t.Context(), f.Post.ID, f.Alice.ID, f.Post.CreatedAt); err != nil {
+		t.Fatalf("failed to seed feed: %v", err)
+	}
+	return f
+}
@@ -0,0 +1,90 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// Error kinds shared by repositories, services and handlers. Test for them
+// with errors.Is; every *Error unwraps to its kind.
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrConflict  = errors.New("conflict")
+	ErrForbidden = errors.New("forbidden")
+	ErrTooMany   = errors.New("too many requests")
+)
+
+// Error is an error of one kind with a message safe to show to clients
+type Error struct {
+	Kind    error
+	Message string
+	Err     error // Underlying cause, if any
+	Code    Code  // Returned to clients instead of the kind's generic code, if set
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// WithCode sets the code clients see for e and returns e
+func (e *Error) WithCode(code Code) *Error {
+	e.Code = code
+	return e
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: ErrForbidden, Message: message}
+}
+
+func TooMany(message string) *Error {
+	return &Error{Kind: ErrTooMany, Message: message}
+}
+
+// Translate turns the errors GORM reports with TranslateError enabled into
+// error kinds; what names the record, e.g. "user". Other errors, including
+// nil, are returned unchanged.
+func Translate(err error, what string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &Error{Kind: ErrNotFound, Message: what + " not found", Err: err}
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return &Error{Kind: ErrConflict, Message: what + " already exists", Err: err}
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return &Error{Kind: ErrNotFound, Message: what + " refers to a record that does not exist", Err: err}
+	default:
+		return err
+	}
+}
+
+// Status returns the HTTP status for the kind of err, 500 if it has none
+func Status(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrTooMany):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
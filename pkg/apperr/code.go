@@ -0,0 +1,93 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code is a stable, machine-readable name for an API error. Clients branch on
+// codes; messages are for people and may change.
+type Code string
+
+// Error codes returned by the API. Every code is listed in Codes and in the
+// ErrorCode schema of api/openapi.yaml; codes are only ever added.
+const (
+	CodeInvalidRequest    Code = "invalid_request"
+	CodeUnauthenticated   Code = "unauthenticated"
+	CodeForbidden         Code = "forbidden"
+	CodeNotFound          Code = "not_found"
+	CodeConflict          Code = "conflict"
+	CodePayloadTooLarge   Code = "payload_too_large"
+	CodeRateLimited       Code = "rate_limited"
+	CodeInternal          Code = "internal"
+	CodeNotImplemented    Code = "not_implemented"
+	CodeUnavailable       Code = "unavailable"
+	CodeInsufficientScope Code = "insufficient_scope"
+	CodeTwoFactorRequired Code = "two_factor_required"
+	CodeInviteRequired    Code = "invite_required"
+	CodeInviteInvalid     Code = "invite_invalid"
+	CodeWaitlisted        Code = "waitlisted"
+	CodeMaintenance       Code = "maintenance"
+)
+
+// CodeInfo documents one error code
+type CodeInfo struct {
+	Code        Code
+	Status      int // HTTP status the code is returned with
+	Description string
+}
+
+// Codes is the registry of every error code, generic ones first
+var Codes = []CodeInfo{
+	{CodeInvalidRequest, http.StatusBadRequest, "The request is malformed or a parameter is invalid"},
+	{CodeUnauthenticated, http.StatusUnauthorized, "Authentication is missing or invalid"},
+	{CodeForbidden, http.StatusForbidden, "The caller may not do this"},
+	{CodeNotFound, http.StatusNotFound, "The resource does not exist or is not visible to the caller"},
+	{CodeConflict, http.StatusConflict, "The request conflicts with the current state, e.g. a duplicate"},
+	{CodePayloadTooLarge, http.StatusRequestEntityTooLarge, "The request body is too large"},
+	{CodeRateLimited, http.StatusTooManyRequests, "Too many requests; retry later"},
+	{CodeInternal, http.StatusInternalServerError, "The server failed to handle the request"},
+	{CodeNotImplemented, http.StatusNotImplemented, "The requested variant is not supported"},
+	{CodeUnavailable, http.StatusServiceUnavailable, "A dependency is unavailable; retry later"},
+	{CodeInsufficientScope, http.StatusForbidden, "The access token lacks a scope; details.scope names it"},
+	{CodeTwoFactorRequired, http.StatusUnauthorized, "Sign-in needs a second factor; details.two_factor names it"},
+	{CodeInviteRequired, http.StatusForbidden, "Signing up needs an invite code"},
+	{CodeInviteInvalid, http.StatusForbidden, "The invite code is invalid, used up, expired or revoked"},
+	{CodeWaitlisted, http.StatusForbidden, "Sign-ups are waitlisted; the account is activated later"},
+	{CodeMaintenance, http.StatusServiceUnavailable, "The service is read-only for maintenance; retry after Retry-After seconds"},
+}
+
+// statusCodes are the generic codes of the statuses the API returns
+var statusCodes = map[int]Code{
+	http.StatusBadRequest:            CodeInvalidRequest,
+	http.StatusUnauthorized:          CodeUnauthenticated,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusInternalServerError:   CodeInternal,
+	http.StatusNotImplemented:        CodeNotImplemented,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// CodeForStatus returns the generic code of an HTTP status
+func CodeForStatus(status int) Code {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
+
+// CodeOf returns the code of err: the one set on its *Error, else the
+// generic code of its kind's status
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) && e.Code != "" {
+		return e.Code
+	}
+	return CodeForStatus(Status(err))
+}
@@ -0,0 +1,133 @@
+package broadcast
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Handler receives every message published on a channel
+type Handler func(ctx context.Context, payload []byte)
+
+// Broadcaster delivers each message to every subscribed process, unlike the
+// event bus where processes sharing a consumer group split the events. It is
+// meant for pushing real-time signals to clients connected to any instance.
+type Broadcaster interface {
+	Publish(ctx context.Context, channel string, payload []byte) error
+	Subscribe(channel string, handler Handler)
+	Run(ctx context.Context)
+}
+
+// New picks the broadcaster for the infrastructure at hand: Redis Pub/Sub
+// when client is set, else PostgreSQL LISTEN/NOTIFY when pg is set, else an
+// in-process one
+func New(client *redis.Client, pg *sql.DB) Broadcaster {
+	switch {
+	case client != nil:
+		return NewRedis(client)
+	case pg != nil:
+		return NewPostgres(pg)
+	default:
+		return NewMemory()
+	}
+}
+
+type registry struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func (r *registry) Subscribe(channel string, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.handlers == nil {
+		r.handlers = make(map[string][]Handler)
+	}
+	r.handlers[channel] = append(r.handlers[channel], handler)
+}
+
+func (r *registry) channels() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	channels := make([]string, 0, len(r.handlers))
+	for channel := range r.handlers {
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+func (r *registry) deliver(ctx context.Context, channel string, payload []byte) {
+	r.mu.RLock()
+	handlers := r.handlers[channel]
+	r.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(ctx, payload)
+	}
+}
+
+// Memory delivers messages to handlers in the same process; it suits single
+// instance deployments and development
+type Memory struct {
+	registry
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Publish delivers the message synchronously to the channel's handlers
+func (m *Memory) Publish(ctx context.Context, channel string, payload []byte) error {
+	m.deliver(ctx, channel, payload)
+	return nil
+}
+
+// Run is a no-op; Memory needs no delivery loop
+func (m *Memory) Run(ctx context.Context) {}
+
+// Redis broadcasts messages through Redis Pub/Sub. Delivery is at most once:
+// messages published while an instance is disconnected are not replayed.
+type Redis struct {
+	registry
+	client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+func (b *Redis) Publish(ctx context.Context, channel string, payload []byte) error {
+	if err := b.client.Publish(ctx, channel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Run listens on every subscribed channel until ctx is cancelled; handlers
+// must be registered before Run is called
+func (b *Redis) Run(ctx context.Context) {
+	channels := b.channels()
+	if len(channels) == 0 {
+		return
+	}
+
+	pubsub := b.client.Subscribe(ctx, channels...)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				slog.WarnContext(ctx, "broadcast subscription closed", slog.Any("channels", channels))
+				return
+			}
+			b.deliver(ctx, msg.Channel, []byte(msg.Payload))
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package broadcast
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// MaxPostgresPayload is the largest payload NOTIFY accepts, in bytes
+const MaxPostgresPayload = 7999
+
+// Postgres broadcasts messages through PostgreSQL LISTEN/NOTIFY, so
+// instances sharing a database need no Redis. Run holds one connection of
+// the pool for as long as it listens, which must reach the server directly:
+// a pooler in transaction mode drops notifications. Delivery is at most
+// once, like Redis Pub/Sub.
+type Postgres struct {
+	registry
+	db *sql.DB
+}
+
+// NewPostgres returns a broadcaster on db, a PostgreSQL pool opened through
+// the pgx driver
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (b *Postgres) Publish(ctx context.Context, channel string, payload []byte) error {
+	if len(payload) > MaxPostgresPayload {
+		return fmt.Errorf("failed to publish to %s: payload of %d bytes is over the %d NOTIFY allows", channel, len(payload), MaxPostgresPayload)
+	}
+	if _, err := b.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, string(payload)); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Run listens on every subscribed channel until ctx is cancelled, listening
+// again on a fresh connection whenever the one it holds fails; handlers must
+// be registered before Run is called
+func (b *Postgres) Run(ctx context.Context) {
+	channels := b.channels()
+	if len(channels) == 0 {
+		return
+	}
+
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := b.listen(ctx, channels)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		slog.WarnContext(ctx, "broadcast listener failed", slog.Any("channels", channels), slog.Duration("retry_in", backoff), slog.Any("error", err))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// listen takes a connection out of the pool and delivers its notifications
+// until it fails or ctx is cancelled
+func (b *Postgres) listen(ctx context.Context, channels []string) error {
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var listenErr error
+	_ = conn.Raw(func(driverConn any) error {
+		listenErr = b.wait(ctx, driverConn, channels)
+		// Close the connection rather than hand it back to the pool listening
+		return driver.ErrBadConn
+	})
+	return listenErr
+}
+
+func (b *Postgres) wait(ctx context.Context, driverConn any, channels []string) error {
+	// The pool may wrap the driver's connection, e.g. to expire it early
+	if w, ok := driverConn.(interface{ Unwrap() driver.Conn }); ok {
+		driverConn = w.Unwrap()
+	}
+	c, ok := driverConn.(*stdlib.Conn)
+	if !ok {
+		return fmt.Errorf("unsupported driver connection %T", driverConn)
+	}
+	pg := c.Conn()
+	for _, channel := range channels {
+		if _, err := pg.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", channel, err)
+		}
+	}
+	for {
+		n, err := pg.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		b.deliver(ctx, n.Channel, []byte(n.Payload))
+	}
+}
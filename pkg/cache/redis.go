@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Config holds Redis connection settings
+type Config struct {
+	Host         string `yaml:"host"`
+	Port         string `yaml:"port"`
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	DB           int    `yaml:"db"`
+	PoolSize     int    `yaml:"pool_size"`
+	MinIdleConns int    `yaml:"min_idle_conns"`
+	TLS          bool   `yaml:"tls"`
+}
+
+// NewRedisClient creates a Redis client and verifies the connection
+func NewRedisClient(ctx context.Context, config Config) (*redis.Client, error) {
+	host := config.Host
+	if host == "" {
+		host = "localhost"
+	}
+	port := config.Port
+	if port == "" {
+		port = "6379"
+	}
+
+	opts := &redis.Options{
+		Addr:         net.JoinHostPort(host, port),
+		Username:     config.Username,
+		Password:     config.Password,
+		DB:           config.DB,
+		PoolSize:     config.PoolSize,
+		MinIdleConns: config.MinIdleConns,
+	}
+	if config.TLS {
+		opts.TLSConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	}
+	client := redis.NewClient(opts)
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := client.Ping(pingCtx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	slog.Info("redis connection established", slog.String("addr", net.JoinHostPort(host, port)), slog.Int("db", config.DB))
+	return client, nil
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store holds values by key until they expire
+type Store interface {
+	// Get returns the value of key and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// NewStore returns a Redis store shared by every instance, or an in-process
+// store when client is nil
+func NewStore(client *redis.Client) Store {
+	if client == nil {
+		return NewMemoryStore()
+	}
+	return NewRedisStore(client)
+}
+
+// RedisStore keeps values in Redis so every instance sees them
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.client.Del(ctx, keys...).Err()
+}
+
+// MemoryStore keeps values in process memory, for single-instance deployments
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// sweepInterval is how often Set drops expired entries
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.entries[key] = memoryEntry{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	return nil
+}
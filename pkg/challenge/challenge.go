@@ -0,0 +1,169 @@
+// Package challenge makes risky requests prove there is a person, or at
+// least some work, behind them before they go through: a CAPTCHA solved
+// through hCaptcha or Cloudflare Turnstile, or the built-in proof of work for
+// deployments without a CAPTCHA account.
+package challenge
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/quota"
+)
+
+// Providers
+const (
+	ProviderNone      = "none"
+	ProviderPoW       = "pow"
+	ProviderHCaptcha  = "hcaptcha"
+	ProviderTurnstile = "turnstile"
+)
+
+// Action is something risky users must pass a challenge for
+type Action string
+
+// Actions that can be challenged
+const (
+	ActionSignIn Action = "sign_in" // Starting a sign-in, which creates the account on first use
+	ActionPost   Action = "post"    // Publishing posts and comments
+)
+
+// Actions lists every action that can be challenged
+var Actions = []Action{ActionSignIn, ActionPost}
+
+var (
+	ErrRequired = apperr.Forbidden("complete the challenge from GET /challenge and send its token in the " + Header + " header")
+	ErrFailed   = apperr.Forbidden("challenge token is invalid, expired or already used")
+
+	// ErrUnavailable wraps failures of the CAPTCHA provider
+	ErrUnavailable = errors.New("challenge provider unavailable")
+)
+
+// Verifier checks the token a client got by passing a challenge. remoteIP
+// may be empty.
+type Verifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+	Provider() string
+}
+
+// Config selects the provider and the actions it guards
+type Config struct {
+	Provider string
+	SiteKey  string        // Public CAPTCHA key handed to clients
+	Secret   string        // CAPTCHA secret, or the key proof-of-work puzzles are signed with
+	Endpoint string        // Overrides the provider's verification URL
+	Timeout  time.Duration // Per verification request, 10s when zero
+
+	Difficulty int           // Leading zero bits a proof of work must reach, 20 when zero
+	TTL        time.Duration // How long a puzzle can be solved in, 5m when zero
+
+	Actions []Action
+}
+
+// RiskFunc reports whether a user is risky enough to be challenged before
+// action; userID is zero for requests nobody signed in to
+type RiskFunc func(ctx context.Context, userID int64, action Action) (bool, error)
+
+// Puzzle is what a client needs to pass the challenge: the site key of the
+// CAPTCHA widget, or a proof-of-work puzzle
+type Puzzle struct {
+	Provider   string     `json:"provider"`
+	SiteKey    string     `json:"site_key,omitempty"`
+	Challenge  string     `json:"challenge,omitempty"`
+	Difficulty int        `json:"difficulty,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// Challenge guards actions with the configured provider
+type Challenge struct {
+	verifier Verifier
+	pow      *ProofOfWork
+	config   Config
+	actions  map[Action]bool
+	risky    RiskFunc
+}
+
+// New creates a Challenge for config.Provider, which must not be none.
+// Solved puzzles are spent in store so they cannot be replayed. risky may be
+// nil to challenge everyone.
+func New(store quota.Store, config Config, risky RiskFunc) (*Challenge, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.Difficulty <= 0 {
+		config.Difficulty = 20
+	}
+	if config.TTL <= 0 {
+		config.TTL = 5 * time.Minute
+	}
+	if risky == nil {
+		risky = func(context.Context, int64, Action) (bool, error) { return true, nil }
+	}
+	c := &Challenge{config: config, actions: make(map[Action]bool, len(config.Actions)), risky: risky}
+	for _, action := range config.Actions {
+		c.actions[action] = true
+	}
+
+	switch config.Provider {
+	case ProviderPoW:
+		key := []byte(config.Secret)
+		if len(key) == 0 {
+			// Puzzles then only verify on the instance that issued them
+			key = make([]byte, 32)
+			rand.Read(key)
+		}
+		c.pow = NewProofOfWork(store, key, config.Difficulty, config.TTL)
+		c.verifier = c.pow
+	case ProviderHCaptcha, ProviderTurnstile:
+		if config.SiteKey == "" || config.Secret == "" {
+			return nil, fmt.Errorf("%s needs a site key and a secret", config.Provider)
+		}
+		c.verifier = NewCaptcha(&http.Client{Timeout: config.Timeout}, config.Provider, config.Secret, config.Endpoint)
+	default:
+		return nil, fmt.Errorf("unknown challenge provider %q", config.Provider)
+	}
+	return c, nil
+}
+
+// Issue returns a puzzle for a client about to take the challenge
+func (c *Challenge) Issue() Puzzle {
+	puzzle := Puzzle{Provider: c.config.Provider, SiteKey: c.config.SiteKey}
+	if c.pow != nil {
+		challenge, expires := c.pow.Issue()
+		puzzle.Challenge, puzzle.Difficulty, puzzle.ExpiresAt = challenge, c.config.Difficulty, &expires
+	}
+	return puzzle
+}
+
+// Require lets action through when it is not guarded, when risky does not
+// flag the user, or when the request carries a valid challenge token; it
+// returns ErrRequired or ErrFailed otherwise, and names the action in the
+// response's HeaderRequired. A nil Challenge allows everything.
+func (c *Challenge) Require(ctx context.Context, userID int64, action Action) error {
+	if c == nil || !c.actions[action] {
+		return nil
+	}
+	risky, err := c.risky(ctx, userID, action)
+	if err != nil {
+		return fmt.Errorf("failed to assess risk: %w", err)
+	}
+	if !risky {
+		return nil
+	}
+
+	req, ok := ctx.Value(requestKey{}).(request)
+	if !ok || req.token == "" {
+		req.demand(action)
+		return ErrRequired
+	}
+	if err := c.verifier.Verify(ctx, req.token, req.remoteIP); err != nil {
+		req.demand(action)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package challenge
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+)
+
+const (
+	// Header carries a challenge token on the request retried after passing it
+	Header = "X-Challenge-Token"
+	// QueryParam carries it instead on browser navigations, which cannot set headers
+	QueryParam = "challenge_token"
+	// HeaderRequired names the action a refused request has to pass a challenge for
+	HeaderRequired = "X-Challenge-Required"
+)
+
+type requestKey struct{}
+
+// request is what Require needs of the HTTP request it runs in
+type request struct {
+	token    string
+	remoteIP string
+	header   http.Header
+}
+
+func (r request) demand(action Action) {
+	if r.header != nil {
+		r.header.Set(HeaderRequired, string(action))
+	}
+}
+
+// Middleware hands the request's challenge token and client IP to Require,
+// and lets it name the action it refused in the response headers
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(Header)
+		if token == "" {
+			token = r.URL.Query().Get(QueryParam)
+		}
+		ctx := context.WithValue(r.Context(), requestKey{}, request{token: token, remoteIP: httpx.ClientIP(r), header: w.Header()})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Register mounts GET /challenge, which hands out a puzzle. It does not
+// expect an authenticated user: signing in may need one.
+func (c *Challenge) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /challenge", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		httpx.JSON(w, http.StatusOK, c.Issue())
+	})
+}
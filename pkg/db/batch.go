@@ -0,0 +1,25 @@
+package db
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// DefaultBatchSize is the number of rows per INSERT used for bulk writes
+const DefaultBatchSize = 500
+
+// CreateInBatches inserts rows batchSize at a time. A batchSize of zero uses
+// the connection's configured batch size, or DefaultBatchSize.
+func CreateInBatches[T any](ctx context.Context, tx *gorm.DB, rows []*T, batchSize int) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = tx.CreateBatchSize
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return tx.WithContext(ctx).CreateInBatches(rows, batchSize).Error
+}
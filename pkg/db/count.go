@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+// NoCount is the total returned by CountStrategyNone
+const NoCount int64 = -1
+
+// estimateFloor is the size below which an exact count is cheap enough to
+// replace an estimate, which also covers tables never analyzed yet
+const estimateFloor = 10000
+
+// ListOptions tunes a paginated List call
+type ListOptions struct {
+	Count types.CountStrategy
+}
+
+// ListOption customizes a List call
+type ListOption func(*ListOptions)
+
+// WithCount selects how the total count is computed
+func WithCount(strategy types.CountStrategy) ListOption {
+	return func(o *ListOptions) { o.Count = strategy }
+}
+
+// NewListOptions applies opts over the defaults (exact count)
+func NewListOptions(opts ...ListOption) ListOptions {
+	o := ListOptions{Count: types.CountStrategyExact}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// CountRows counts the rows matched by query using strategy. Estimates come
+// from table statistics (pg_class.reltuples on PostgreSQL,
+// information_schema.TABLES on MySQL), so they are only used when query is
+// not filtered beyond soft deletes or scoped to a tenant; otherwise, and
+// wherever statistics are missing, an exact count is made. CountStrategyNone
+// returns NoCount.
+func CountRows(ctx context.Context, query *gorm.DB, table string, filtered bool, strategy types.CountStrategy) (int64, error) {
+	if _, scoped := tenant.ID(ctx); scoped {
+		filtered = true
+	}
+
+	switch strategy {
+	case types.CountStrategyNone:
+		return NoCount, nil
+	case types.CountStrategyEstimated:
+		if !filtered {
+			estimate, ok, err := estimateRows(ctx, query, table)
+			if err != nil {
+				return 0, err
+			}
+			if ok && estimate >= estimateFloor {
+				return estimate, nil
+			}
+		}
+	}
+
+	var count int64
+	if err := query.WithContext(ctx).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// estimateRows reads the planner's row estimate for table; ok is false when
+// the database keeps none
+func estimateRows(ctx context.Context, query *gorm.DB, table string) (int64, bool, error) {
+	var (
+		estimate sql.NullInt64
+		stmt     string
+	)
+	switch DatabaseType(query.Dialector.Name()) {
+	case PostgreSQL:
+		// reltuples is -1 until the table is first vacuumed or analyzed
+		stmt = "SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass(?)"
+	case MySQL:
+		stmt = "SELECT TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?"
+	default:
+		return 0, false, nil
+	}
+
+	err := query.Session(&gorm.Session{NewDB: true, Context: ctx}).Raw(stmt, table).Scan(&estimate).Error
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to estimate %s rows: %w", table, err)
+	}
+	if !estimate.Valid || estimate.Int64 < 0 {
+		return 0, false, nil
+	}
+	return estimate.Int64, true, nil
+}
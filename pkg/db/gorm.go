@@ -1,17 +1,24 @@
 package db
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ilhamosaurus/sns-platform/internal/model"
+	applogger "github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/tenant"
 	"github.com/ilhamosaurus/sns-platform/pkg/types"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	gormlogger "gorm.io/gorm/logger"
 )
 
 // DatabaseType represents supported database types
@@ -34,21 +41,101 @@ type Config struct {
 	SSLMode  string       `yaml:"sslmode"`  // For PostgreSQL
 	Charset  string       `yaml:"charset"`  // For MySQL
 	FilePath string       `yaml:"filepath"` // For SQLite
+	SQLite   SQLiteConfig `yaml:"sqlite"`
 
 	// Connection pool settings
 	MaxIdleConns    int           `yaml:"max_idle_conns"`
 	MaxOpenConns    int           `yaml:"max_open_conns"`
 	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
 	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	// Each connection expires up to this much, at most half of
+	// ConnMaxLifetime, early so connections opened together are not all
+	// replaced at once
+	ConnMaxLifetimeJitter time.Duration `yaml:"conn_max_lifetime_jitter"`
+	WarmConns             int           `yaml:"warm_conns"` // Connections opened at startup, at most MaxIdleConns
 
 	// GORM settings
-	LogLevel       string `yaml:"log_level"` // silent, error, warn, info
-	PrepareStmt    bool   `yaml:"prepare_stmt"`
-	SkipDefaultTxn bool   `yaml:"skip_default_txn"`
+	LogLevel       string        `yaml:"log_level"` // silent, error, warn, info
+	PrepareStmt    bool          `yaml:"prepare_stmt"`
+	SkipDefaultTxn bool          `yaml:"skip_default_txn"`
+	SlowThreshold  time.Duration `yaml:"slow_threshold"` // Negative disables slow query logging
+	QueryTimeout   time.Duration `yaml:"query_timeout"`  // Per statement unless overridden with WithQueryTimeout, zero or less for none
+	BatchSize      int           `yaml:"batch_size"`     // Rows per INSERT for bulk writes, DefaultBatchSize when zero
+
+	// Scope tenant-owned tables to the tenant in the query context
+	MultiTenant bool `yaml:"multi_tenant"`
+}
+
+// SQLiteConfig holds the pragmas applied to every SQLite connection; zero
+// values keep the driver's defaults
+type SQLiteConfig struct {
+	JournalMode  string        `yaml:"journal_mode"` // delete, truncate, persist, memory, wal, off
+	Synchronous  string        `yaml:"synchronous"`  // off, normal, full, extra
+	BusyTimeout  time.Duration `yaml:"busy_timeout"` // How long a write waits for the lock before failing
+	ForeignKeys  bool          `yaml:"foreign_keys"`
+	SingleWriter bool          `yaml:"single_writer"` // Cap the pool at one connection so writes queue instead of colliding
 }
 
 var db *gorm.DB
 
+// models lists every migrated model; those with a tenant_id column are tenant-scoped
+var models = []any{
+	&model.Tenant{},
+	&model.User{},
+	&model.Follow{},
+	&model.CloseFriend{},
+	&model.Community{},
+	&model.CommunityMember{},
+	&model.CommunityFilterRule{},
+	&model.Organization{},
+	&model.OrganizationMember{},
+	&model.Page{},
+	&model.PageManager{},
+	&model.PageFollow{},
+	&model.Post{},
+	&model.Comment{},
+	&model.CommentReport{},
+	&model.Reaction{},
+	&model.Bookmark{},
+	&model.Message{},
+	&model.Notification{},
+	&model.ActivityFeed{},
+	&model.ExploreCandidate{},
+	&model.Affinity{},
+	&model.Job{},
+	&model.DeadLetter{},
+	&model.DataExport{},
+	&model.SecurityEvent{},
+	&model.PhoneCode{},
+	&model.Invite{},
+	&model.WaitlistEntry{},
+	&model.ArchiveImport{},
+	&model.ImportedItem{},
+	&model.PostImpression{},
+	&model.ProfileView{},
+	&model.ProfileVisit{},
+	&model.LinkClick{},
+	&model.PostDailyStat{},
+	&model.PostSourceDailyStat{},
+	&model.AccountDailyStat{},
+	&model.UserStatsDaily{},
+	&model.AuditLog{},
+	&model.LegalHold{},
+	&model.Restriction{},
+	&model.Draft{},
+	&model.Translation{},
+	&model.ShortLink{},
+	&model.OAuthApp{},
+	&model.OAuthCode{},
+	&model.OAuthToken{},
+	&model.SSOIdentity{},
+	&model.DirectoryUser{},
+	&model.DirectoryFollow{},
+	&model.Announcement{},
+	&model.AnnouncementDismissal{},
+	&model.CustomEmoji{},
+}
+
 // Initialize establishes database connection with optimized settings
 func Initialize(config Config) (*gorm.DB, error) {
 	var dialector gorm.Dialector
@@ -70,23 +157,44 @@ func Initialize(config Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to create database dialector: %w", err)
 	}
 
+	connMaxLifetime := config.ConnMaxLifetime
+	if connMaxLifetime == 0 {
+		connMaxLifetime = time.Hour
+	}
+	if config.ConnMaxLifetimeJitter > 0 {
+		jitter := min(config.ConnMaxLifetimeJitter, connMaxLifetime/2)
+		if dialector, err = withLifetimeJitter(dialector, connMaxLifetime, jitter); err != nil {
+			return nil, fmt.Errorf("failed to open connection pool: %w", err)
+		}
+	}
+
 	// Set GORM logger level
 	logLevel := getLogLevel(config.LogLevel)
 
 	// Open database connection
 	db, err = gorm.Open(dialector, &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+		Logger: applogger.NewGormLogger(slog.Default(), logLevel, config.SlowThreshold),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
 		TranslateError:         true,
 		PrepareStmt:            config.PrepareStmt,
 		SkipDefaultTransaction: config.SkipDefaultTxn,
+		CreateBatchSize:        config.BatchSize,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if config.MultiTenant {
+		if err := db.Use(tenant.NewPlugin(models...)); err != nil {
+			return nil, fmt.Errorf("failed to enable multi-tenancy: %w", err)
+		}
+	}
+	if err := db.Use(&queryTimeoutPlugin{timeout: config.QueryTimeout}); err != nil {
+		return nil, fmt.Errorf("failed to enable query timeouts: %w", err)
+	}
+
 	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -102,21 +210,30 @@ func Initialize(config Config) (*gorm.DB, error) {
 	if maxOpenConns == 0 {
 		maxOpenConns = 100
 	}
-	connMaxLifetime := config.ConnMaxLifetime
-	if connMaxLifetime == 0 {
-		connMaxLifetime = time.Hour
-	}
 	connMaxIdleTime := config.ConnMaxIdleTime
 	if connMaxIdleTime == 0 {
 		connMaxIdleTime = 10 * time.Minute
 	}
 
+	if config.Type == SQLite && config.SQLite.SingleWriter {
+		// One connection queues writers in the pool instead of having SQLite
+		// turn them away with "database is locked"
+		maxOpenConns, maxIdleConns = 1, 1
+	}
+
 	sqlDB.SetMaxIdleConns(maxIdleConns)
 	sqlDB.SetMaxOpenConns(maxOpenConns)
 	sqlDB.SetConnMaxLifetime(connMaxLifetime)
 	sqlDB.SetConnMaxIdleTime(connMaxIdleTime)
 
-	log.Printf("✓ Database connection established successfully (Type: %s)", config.Type)
+	if warm := min(config.WarmConns, maxIdleConns); warm > 0 {
+		if err := warmUp(sqlDB, warm); err != nil {
+			return nil, fmt.Errorf("failed to warm up connection pool: %w", err)
+		}
+		slog.Info("connection pool warmed up", slog.Int("conns", warm))
+	}
+
+	slog.Info("database connection established", slog.String("type", string(config.Type)))
 	return db, nil
 }
 
@@ -132,7 +249,7 @@ func getPostgresDialector(config Config) (gorm.Dialector, error) {
 		getSSLMode(config.SSLMode),
 	)
 
-	log.Printf("Connecting to PostgreSQL: %s:%s/%s", config.Host, config.Port, config.DBName)
+	slog.Info("connecting to postgres", slog.String("host", config.Host), slog.String("port", config.Port), slog.String("dbname", config.DBName))
 	return postgres.Open(dsn), nil
 }
 
@@ -153,7 +270,7 @@ func getMySQLDialector(config Config) (gorm.Dialector, error) {
 		charset,
 	)
 
-	log.Printf("Connecting to MySQL: %s:%s/%s", config.Host, config.Port, config.DBName)
+	slog.Info("connecting to mysql", slog.String("host", config.Host), slog.String("port", config.Port), slog.String("dbname", config.DBName))
 	return mysql.Open(dsn), nil
 }
 
@@ -164,8 +281,40 @@ func getSQLiteDialector(config Config) (gorm.Dialector, error) {
 		filePath = "social_media.db"
 	}
 
-	log.Printf("Connecting to SQLite: %s", filePath)
-	return sqlite.Open(filePath), nil
+	slog.Info("connecting to sqlite", slog.String("file", filePath),
+		slog.String("journal_mode", config.SQLite.JournalMode), slog.Bool("single_writer", config.SQLite.SingleWriter))
+	return sqlite.Open(sqliteDSN(filePath, config.SQLite)), nil
+}
+
+// sqliteDSN adds the pragmas of config to the DSN of filePath as driver
+// parameters, so every connection the pool opens gets them
+func sqliteDSN(filePath string, config SQLiteConfig) string {
+	params := url.Values{}
+	if config.JournalMode != "" {
+		params.Set("_journal_mode", config.JournalMode)
+	}
+	if config.Synchronous != "" {
+		params.Set("_synchronous", config.Synchronous)
+	}
+	if config.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(config.BusyTimeout.Milliseconds(), 10))
+		// Waiting only helps transactions that take the write lock up front;
+		// one upgrading its read lock while another connection writes fails
+		// at once, whatever the timeout
+		params.Set("_txlock", "immediate")
+	}
+	if config.ForeignKeys {
+		params.Set("_foreign_keys", "1")
+	}
+	if len(params) == 0 {
+		return filePath
+	}
+
+	separator := "?"
+	if strings.Contains(filePath, "?") {
+		separator = "&"
+	}
+	return filePath + separator + params.Encode()
 }
 
 // getSSLMode returns appropriate SSL mode or default
@@ -177,58 +326,325 @@ func getSSLMode(sslMode string) string {
 }
 
 // getLogLevel converts string log level to GORM logger level
-func getLogLevel(level string) logger.LogLevel {
+func getLogLevel(level string) gormlogger.LogLevel {
 	switch level {
 	case "silent":
-		return logger.Silent
+		return gormlogger.Silent
 	case "error":
-		return logger.Error
+		return gormlogger.Error
 	case "warn":
-		return logger.Warn
+		return gormlogger.Warn
 	case "info":
-		return logger.Info
+		return gormlogger.Info
 	default:
-		return logger.Info
+		return gormlogger.Info
 	}
 }
 
 // Migrate runs all database migrations
 func Migrate() error {
-	log.Println("Running database migrations...")
+	slog.Info("running database migrations")
+
+	// Altering a large table may outlast the query timeout
+	conn := db
+	db = db.WithContext(WithQueryTimeout(context.Background(), 0))
+	defer func() { db = conn }()
 
 	// Auto-migrate all model
-	err := db.AutoMigrate(
-		&model.User{},
-		&model.Follow{},
-		&model.Post{},
-		&model.Comment{},
-		&model.Reaction{},
-		&model.Message{},
-		&model.Notification{},
-		&model.ActivityFeed{},
-	)
+	err := db.AutoMigrate(models...)
 	if err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
 
+	if err := convertEnumColumns(); err != nil {
+		return err
+	}
+	if err := migrateNotificationMessages(); err != nil {
+		return err
+	}
+	if err := tombstoneDeletedUsers(); err != nil {
+		return err
+	}
+	if err := hashContacts(); err != nil {
+		return err
+	}
+
+	// Users created before roles existed are regular users
+	if err := db.Model(&model.User{}).Where("role IS NULL").UpdateColumn("role", types.UserRoleUser).Error; err != nil {
+		return fmt.Errorf("failed to backfill user roles: %w", err)
+	}
+
+	// Usernames and emails are unique per tenant; drop the old global indexes
+	for _, name := range []string{"idx_users_username", "idx_users_email"} {
+		if db.Migrator().HasIndex(&model.User{}, name) {
+			if err := db.Migrator().DropIndex(&model.User{}, name); err != nil {
+				return fmt.Errorf("failed to drop index %s: %w", name, err)
+			}
+		}
+	}
+
 	// Get database type
 	dbType := getDatabaseType()
 
+	if err := createCaseInsensitiveIndexes(dbType); err != nil {
+		slog.Warn("usernames or emails are not unique regardless of case; merge the accounts differing only in case and migrate again", slog.Any("error", err))
+	}
+
 	// Create database-specific additional indexes
 	if err := createAdditionalIndexes(dbType); err != nil {
-		log.Printf("Warning: Failed to create some additional indexes: %v", err)
+		slog.Warn("failed to create some additional indexes", slog.Any("error", err))
 		// Don't return error - some indexes might not be supported
 	}
 
 	// Create composite indexes
 	if err := createCompositeIndexes(dbType); err != nil {
-		log.Printf("Warning: Failed to create some composite indexes: %v", err)
+		slog.Warn("failed to create some composite indexes", slog.Any("error", err))
+	}
+
+	slog.Info("database migrations completed")
+	return nil
+}
+
+// Rollback drops every migrated table and the data in it
+func Rollback() error {
+	slog.Warn("dropping all tables")
+
+	// Dependent tables go first so foreign keys never block a drop
+	for i := len(models) - 1; i >= 0; i-- {
+		if err := db.Migrator().DropTable(models[i]); err != nil {
+			return fmt.Errorf("rollback failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// TableStatus reports how far a model's table is from its migrated schema
+type TableStatus struct {
+	Table          string
+	Exists         bool
+	MissingColumns []string
+}
+
+// Pending reports whether Migrate would change the table
+func (s TableStatus) Pending() bool {
+	return !s.Exists || len(s.MissingColumns) > 0
+}
+
+// Status compares the database against the migrated models
+func Status() ([]TableStatus, error) {
+	statuses := make([]TableStatus, 0, len(models))
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			return nil, fmt.Errorf("failed to parse model: %w", err)
+		}
+
+		status := TableStatus{Table: stmt.Schema.Table, Exists: db.Migrator().HasTable(m)}
+		if status.Exists {
+			for _, dbName := range stmt.Schema.DBNames {
+				if !db.Migrator().HasColumn(m, dbName) {
+					status.MissingColumns = append(status.MissingColumns, dbName)
+				}
+			}
+		}
+		statuses = append(statuses, status)
 	}
+	return statuses, nil
+}
+
+// Table names a migrated model's table and its columns
+type Table struct {
+	Name    string
+	Columns []string
+}
+
+// SoftDeleteTables lists the tables of the migrated models that soft-delete
+// rows, last migrated first so that rows go before the rows they reference
+func SoftDeleteTables() ([]Table, error) {
+	var tables []Table
+	for i := len(models) - 1; i >= 0; i-- {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(models[i]); err != nil {
+			return nil, fmt.Errorf("failed to parse model: %w", err)
+		}
+		if stmt.Schema.LookUpField("deleted_at") != nil {
+			tables = append(tables, Table{Name: stmt.Schema.Table, Columns: stmt.Schema.DBNames})
+		}
+	}
+	return tables, nil
+}
+
+// enumColumn is a column holding one of the pkg/types enums
+type enumColumn struct {
+	model  any
+	column string
+	name   func(n uint32) string
+}
 
-	log.Println("✓ Database migrations completed successfully")
+func enumOf[T interface {
+	~uint32
+	String() string
+}]() func(uint32) string {
+	return func(n uint32) string { return T(n).String() }
+}
+
+// enumColumns are the enum columns that stored numbers before enums were
+// stored by name; columns added since have only ever held names
+var enumColumns = []enumColumn{
+	{&model.Post{}, "media_type", enumOf[types.MediaType]()},
+	{&model.Reaction{}, "type", enumOf[types.ReactionType]()},
+	{&model.Notification{}, "type", enumOf[types.NotificationType]()},
+	{&model.Notification{}, "target_type", enumOf[types.NotificationTarget]()},
+	{&model.Job{}, "status", enumOf[types.JobStatus]()},
+	{&model.DeadLetter{}, "source", enumOf[types.DeadLetterSource]()},
+	{&model.DataExport{}, "status", enumOf[types.ExportStatus]()},
+	{&model.SecurityEvent{}, "type", enumOf[types.SecurityEventType]()},
+	{&model.CommunityMember{}, "role", enumOf[types.CommunityRole]()},
+	{&model.CommunityMember{}, "status", enumOf[types.MembershipStatus]()},
+	{&model.ArchiveImport{}, "source", enumOf[types.ImportSource]()},
+	{&model.ArchiveImport{}, "status", enumOf[types.ImportStatus]()},
+	{&model.ImportedItem{}, "source", enumOf[types.ImportSource]()},
+}
+
+// convertEnumColumns rewrites enum values stored as numbers, before the
+// columns held names, to their names, in batches as the tables may be large;
+// it is a no-op once converted
+func convertEnumColumns() error {
+	for _, ec := range enumColumns {
+		var (
+			cases strings.Builder
+			nums  []string
+			args  []any
+		)
+		cases.WriteString("CASE " + ec.column)
+		for n := uint32(0); ; n++ {
+			name := ec.name(n)
+			if n > 0 && name == "unknown" {
+				break
+			}
+			cases.WriteString(" WHEN ? THEN ?")
+			nums = append(nums, strconv.FormatUint(uint64(n), 10))
+			args = append(args, nums[len(nums)-1], name)
+		}
+		cases.WriteString(" END")
+
+		_, err := RunBackfill(context.Background(), db, Backfill{
+			Model: ec.model,
+			Where: ec.column + " IN ?",
+			Args:  []any{nums},
+			Set:   map[string]any{ec.column: gorm.Expr(cases.String(), args...)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to convert %s values to names: %w", ec.column, err)
+		}
+	}
 	return nil
 }
 
+// migrateNotificationMessages moves the free-text messages stored before
+// notifications were templated into the legacy template, then drops the column
+func migrateNotificationMessages() error {
+	if !db.Migrator().HasColumn(&model.Notification{}, "message") {
+		return nil
+	}
+
+	var lastID int64
+	for {
+		var rows []struct {
+			ID      int64
+			Message string
+		}
+		err := db.Table("notifications").
+			Select("id, message").
+			Where("id > ? AND (template_key IS NULL OR template_key = '')", lastID).
+			Order("id").
+			Limit(DefaultBatchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return fmt.Errorf("failed to load notification messages: %w", err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			params, err := json.Marshal(map[string]string{"text": row.Message})
+			if err != nil {
+				return err
+			}
+			err = db.Table("notifications").Where("id = ?", row.ID).Updates(map[string]any{
+				"template_key": model.NotificationKeyLegacy,
+				"params":       string(params),
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to migrate notification %d: %w", row.ID, err)
+			}
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+
+	if err := db.Migrator().DropColumn(&model.Notification{}, "message"); err != nil {
+		return fmt.Errorf("failed to drop notifications.message: %w", err)
+	}
+	return nil
+}
+
+// tombstoneDeletedUsers renames users soft-deleted before deletion freed
+// their identifiers, so their usernames and emails can be registered again
+func tombstoneDeletedUsers() error {
+	var lastID int64
+	for {
+		var ids []int64
+		err := db.Unscoped().Model(&model.User{}).
+			Where("id > ? AND deleted_at IS NOT NULL AND username NOT LIKE ?", lastID, model.TombstonePrefix+"%").
+			Order("id").
+			Limit(DefaultBatchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return fmt.Errorf("failed to load deleted users: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		for _, id := range ids {
+			username, email := model.Tombstone(id)
+			err := db.Unscoped().Model(&model.User{}).Where("id = ?", id).
+				UpdateColumns(map[string]any{"username": username, "email": email}).Error
+			if err != nil {
+				return fmt.Errorf("failed to free the identifiers of deleted user %d: %w", id, err)
+			}
+		}
+		lastID = ids[len(ids)-1]
+	}
+}
+
+// hashContacts fills the contact discovery hashes of users created before
+// they were stored
+func hashContacts() error {
+	var lastID int64
+	for {
+		var users []*model.User
+		err := db.Select("id", "email", "phone").
+			Where("id > ? AND (email_hash IS NULL OR email_hash = '') AND deleted_at IS NULL", lastID).
+			Order("id").
+			Limit(DefaultBatchSize).
+			Find(&users).Error
+		if err != nil {
+			return fmt.Errorf("failed to load users to hash: %w", err)
+		}
+		if len(users) == 0 {
+			return nil
+		}
+		for _, user := range users {
+			err := db.Model(&model.User{}).Where("id = ?", user.ID).
+				UpdateColumns(map[string]any{"email_hash": model.HashContact(user.Email), "phone_hash": model.HashContact(user.Phone)}).Error
+			if err != nil {
+				return fmt.Errorf("failed to hash the contacts of user %d: %w", user.ID, err)
+			}
+		}
+		lastID = users[len(users)-1].ID
+	}
+}
+
 // getDatabaseType returns the current database type
 func getDatabaseType() DatabaseType {
 	dbName := db.Name()
@@ -244,6 +660,21 @@ func getDatabaseType() DatabaseType {
 	}
 }
 
+// createCaseInsensitiveIndexes keeps usernames and emails unique per tenant
+// regardless of case, which also serves the LOWER() lookups by them. MySQL's
+// default collations already compare case-insensitively, so the unique
+// indexes on the columns suffice there.
+func createCaseInsensitiveIndexes(dbType DatabaseType) error {
+	if dbType != PostgreSQL && dbType != SQLite {
+		return nil
+	}
+	ctx := context.Background()
+	if err := CreateIndex(ctx, db, Index{Name: "idx_users_tenant_username_lower", Table: "users", Columns: "tenant_id, LOWER(username)", Unique: true}); err != nil {
+		return err
+	}
+	return CreateIndex(ctx, db, Index{Name: "idx_users_tenant_email_lower", Table: "users", Columns: "tenant_id, LOWER(email)", Unique: true})
+}
+
 // createAdditionalIndexes creates performance-critical indexes
 func createAdditionalIndexes(dbType DatabaseType) error {
 	switch dbType {
@@ -258,82 +689,92 @@ func createAdditionalIndexes(dbType DatabaseType) error {
 	}
 }
 
-// createPostgresIndexes creates PostgreSQL-specific indexes
+// createPostgresIndexes creates PostgreSQL-specific indexes, concurrently so
+// that migrating a running deployment does not block writes to the tables
 func createPostgresIndexes() error {
-	log.Println("Creating PostgreSQL-specific indexes...")
+	slog.Info("creating postgres-specific indexes")
+	ctx := context.Background()
 
 	// Enable pg_trgm extension for fuzzy search
 	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
-		log.Printf("Warning: Could not create pg_trgm extension: %v", err)
+		slog.Warn("could not create pg_trgm extension", slog.Any("error", err))
 	}
 
 	// Trigram index for username search
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_users_username_trgm ON users USING gin(username gin_trgm_ops)").Error; err != nil {
-		log.Printf("Warning: Could not create trigram index on username: %v", err)
+	if err := CreateIndex(ctx, db, Index{Name: "idx_users_username_trgm", Table: "users", Using: "gin", Columns: "username gin_trgm_ops"}); err != nil {
+		slog.Warn("could not create trigram index on username", slog.Any("error", err))
+	}
+
+	// PostGIS for nearby posts; without it they fall back to the latitude and
+	// longitude index
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS postgis").Error; err != nil {
+		slog.Warn("could not create postgis extension", slog.Any("error", err))
+	} else if err := CreateIndex(ctx, db, Index{Name: "idx_posts_geo", Table: "posts", Using: "gist", Columns: "(ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography)", Where: "latitude IS NOT NULL AND longitude IS NOT NULL AND deleted_at IS NULL"}); err != nil {
+		slog.Warn("could not create geospatial index on posts", slog.Any("error", err))
 	}
 
 	// Index for post feed queries (most recent posts)
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_created_desc ON posts (created_at DESC) WHERE deleted_at IS NULL").Error; err != nil {
+	if err := CreateIndex(ctx, db, Index{Name: "idx_posts_created_desc", Table: "posts", Columns: "created_at DESC", Where: "deleted_at IS NULL"}); err != nil {
 		return err
 	}
 
 	// Index for notification queries
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications (user_id, is_read, created_at DESC) WHERE deleted_at IS NULL").Error; err != nil {
+	if err := CreateIndex(ctx, db, Index{Name: "idx_notifications_user_unread", Table: "notifications", Columns: "user_id, is_read, created_at DESC", Where: "deleted_at IS NULL"}); err != nil {
 		return err
 	}
 
 	// Index for message conversations
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages (sender_id, receiver_id, created_at DESC) WHERE deleted_at IS NULL").Error; err != nil {
+	if err := CreateIndex(ctx, db, Index{Name: "idx_messages_conversation", Table: "messages", Columns: "sender_id, receiver_id, created_at DESC", Where: "deleted_at IS NULL"}); err != nil {
 		return err
 	}
 
 	// Index for unread messages count
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_messages_unread ON messages (receiver_id, is_read) WHERE deleted_at IS NULL AND is_read = false").Error; err != nil {
+	if err := CreateIndex(ctx, db, Index{Name: "idx_messages_unread", Table: "messages", Columns: "receiver_id, is_read", Where: "deleted_at IS NULL AND is_read = false"}); err != nil {
 		return err
 	}
 
 	// Partial index for public posts
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_public ON posts (created_at DESC) WHERE is_public = true AND deleted_at IS NULL").Error; err != nil {
+	if err := CreateIndex(ctx, db, Index{Name: "idx_posts_public", Table: "posts", Columns: "created_at DESC", Where: "is_public = true AND deleted_at IS NULL"}); err != nil {
 		return err
 	}
 
-	log.Println("✓ PostgreSQL-specific indexes created")
+	slog.Info("postgres-specific indexes created")
 	return nil
 }
 
 // createMySQLIndexes creates MySQL-specific indexes
 func createMySQLIndexes() error {
-	log.Println("Creating MySQL-specific indexes...")
+	slog.Info("creating mysql-specific indexes")
 
 	// MySQL doesn't support partial indexes, so we create regular indexes
 
 	// Index for post feed queries
 	if err := db.Exec("CREATE INDEX idx_posts_created_desc ON posts (created_at DESC)").Error; err != nil {
-		log.Printf("Index may already exist: %v", err)
+		slog.Debug("index may already exist", slog.Any("error", err))
 	}
 
 	// Composite index for notifications
 	if err := db.Exec("CREATE INDEX idx_notifications_user_unread ON notifications (user_id, is_read, created_at)").Error; err != nil {
-		log.Printf("Index may already exist: %v", err)
+		slog.Debug("index may already exist", slog.Any("error", err))
 	}
 
 	// Index for message conversations
 	if err := db.Exec("CREATE INDEX idx_messages_conversation ON messages (sender_id, receiver_id, created_at)").Error; err != nil {
-		log.Printf("Index may already exist: %v", err)
+		slog.Debug("index may already exist", slog.Any("error", err))
 	}
 
 	// Full-text index for username search (MySQL alternative to pg_trgm)
 	if err := db.Exec("CREATE FULLTEXT INDEX idx_users_username_fulltext ON users (username, full_name)").Error; err != nil {
-		log.Printf("Warning: Could not create fulltext index: %v", err)
+		slog.Warn("could not create fulltext index", slog.Any("error", err))
 	}
 
-	log.Println("✓ MySQL-specific indexes created")
+	slog.Info("mysql-specific indexes created")
 	return nil
 }
 
 // createSQLiteIndexes creates SQLite-specific indexes
 func createSQLiteIndexes() error {
-	log.Println("Creating SQLite-specific indexes...")
+	slog.Info("creating sqlite-specific indexes")
 
 	// SQLite has limited index features, create basic indexes
 
@@ -352,13 +793,13 @@ func createSQLiteIndexes() error {
 		return err
 	}
 
-	log.Println("✓ SQLite-specific indexes created")
+	slog.Info("sqlite-specific indexes created")
 	return nil
 }
 
 // createCompositeIndexes creates composite indexes for complex queries
 func createCompositeIndexes(dbType DatabaseType) error {
-	log.Println("Creating composite indexes...")
+	slog.Info("creating composite indexes")
 
 	switch dbType {
 	case PostgreSQL:
@@ -373,17 +814,32 @@ func createCompositeIndexes(dbType DatabaseType) error {
 
 // createPostgresCompositeIndexes creates PostgreSQL composite indexes
 func createPostgresCompositeIndexes() error {
+	ctx := context.Background()
+
 	// Composite index for activity feed ordering
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_activity_feed_user_time ON activity_feeds (user_id, post_created DESC) WHERE deleted_at IS NULL").Error; err != nil {
+	if err := CreateIndex(ctx, db, Index{Name: "idx_activity_feed_user_time", Table: "activity_feeds", Columns: "user_id, post_created DESC", Where: "deleted_at IS NULL"}); err != nil {
 		return err
 	}
 
 	// Composite index for reaction counts
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_reactions_target_type ON reactions (post_id, type) WHERE post_id IS NOT NULL AND deleted_at IS NULL").Error; err != nil {
+	if err := CreateIndex(ctx, db, Index{Name: "idx_reactions_target_type", Table: "reactions", Columns: "post_id, type", Where: "post_id IS NOT NULL AND deleted_at IS NULL"}); err != nil {
+		return err
+	}
+
+	if err := CreateIndex(ctx, db, Index{Name: "idx_reactions_comment_type", Table: "reactions", Columns: "comment_id, type", Where: "comment_id IS NOT NULL AND deleted_at IS NULL"}); err != nil {
+		return err
+	}
+
+	// Time window scans of the analytics rollup
+	if err := CreateIndex(ctx, db, Index{Name: "idx_post_impressions_created", Table: "post_impressions", Columns: "created_at"}); err != nil {
+		return err
+	}
+
+	if err := CreateIndex(ctx, db, Index{Name: "idx_profile_views_created", Table: "profile_views", Columns: "created_at"}); err != nil {
 		return err
 	}
 
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_reactions_comment_type ON reactions (comment_id, type) WHERE comment_id IS NOT NULL AND deleted_at IS NULL").Error; err != nil {
+	if err := CreateIndex(ctx, db, Index{Name: "idx_link_clicks_created", Table: "link_clicks", Columns: "created_at"}); err != nil {
 		return err
 	}
 
@@ -394,15 +850,27 @@ func createPostgresCompositeIndexes() error {
 func createMySQLCompositeIndexes() error {
 	// MySQL composite indexes without partial conditions
 	if err := db.Exec("CREATE INDEX idx_activity_feed_user_time ON activity_feeds (user_id, post_created)").Error; err != nil {
-		log.Printf("Index may already exist: %v", err)
+		slog.Debug("index may already exist", slog.Any("error", err))
 	}
 
 	if err := db.Exec("CREATE INDEX idx_reactions_post_type ON reactions (post_id, type)").Error; err != nil {
-		log.Printf("Index may already exist: %v", err)
+		slog.Debug("index may already exist", slog.Any("error", err))
 	}
 
 	if err := db.Exec("CREATE INDEX idx_reactions_comment_type ON reactions (comment_id, type)").Error; err != nil {
-		log.Printf("Index may already exist: %v", err)
+		slog.Debug("index may already exist", slog.Any("error", err))
+	}
+
+	if err := db.Exec("CREATE INDEX idx_post_impressions_created ON post_impressions (created_at)").Error; err != nil {
+		slog.Debug("index may already exist", slog.Any("error", err))
+	}
+
+	if err := db.Exec("CREATE INDEX idx_profile_views_created ON profile_views (created_at)").Error; err != nil {
+		slog.Debug("index may already exist", slog.Any("error", err))
+	}
+
+	if err := db.Exec("CREATE INDEX idx_link_clicks_created ON link_clicks (created_at)").Error; err != nil {
+		slog.Debug("index may already exist", slog.Any("error", err))
 	}
 
 	return nil
@@ -423,18 +891,30 @@ func createSQLiteCompositeIndexes() error {
 		return err
 	}
 
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_post_impressions_created ON post_impressions (created_at)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_profile_views_created ON profile_views (created_at)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_link_clicks_created ON link_clicks (created_at)").Error; err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // Seed populates database with sample data for testing
 func Seed() error {
-	log.Println("Seeding database with sample data...")
+	slog.Info("seeding database with sample data")
 
 	// Check if data already exists
 	var count int64
 	db.Model(&model.User{}).Count(&count)
 	if count > 0 {
-		log.Println("Database already contains data, skipping seed")
+		slog.Info("database already contains data, skipping seed")
 		return nil
 	}
 
@@ -538,7 +1018,7 @@ func Seed() error {
 		return fmt.Errorf("failed to seed reactions: %w", err)
 	}
 
-	log.Println("✓ Database seeded successfully")
+	slog.Info("database seeded")
 	return nil
 }
 
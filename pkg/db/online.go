@@ -0,0 +1,278 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// The helpers below change the schema of large tables, such as messages,
+// while the platform keeps writing to them: indexes are built without
+// blocking writes, new columns are filled in short batches rather than one
+// long UPDATE, and a column being replaced is kept in step with its
+// replacement until every reader has moved over.
+
+// Index describes an index for CreateIndex
+type Index struct {
+	Name    string
+	Table   string
+	Columns string // What goes between the parentheses, columns or expressions
+	Using   string // Index method on PostgreSQL, such as gin; the default when empty
+	Where   string // Predicate of a partial index; MySQL indexes every row
+	Unique  bool
+}
+
+// CreateIndex builds index unless it exists. PostgreSQL builds it
+// CONCURRENTLY and MySQL in place, so writes to the table carry on meanwhile;
+// SQLite has a single writer and builds it as it would any index.
+func CreateIndex(ctx context.Context, tx *gorm.DB, index Index) error {
+	// Building an index takes as long as the table is large
+	tx = tx.WithContext(WithQueryTimeout(ctx, 0))
+
+	switch tx.Name() {
+	case "postgres":
+		if err := dropInvalidIndex(tx, index.Name); err != nil {
+			return err
+		}
+		return tx.Exec(index.sql("CONCURRENTLY IF NOT EXISTS", true, "")).Error
+	case "mysql":
+		if tx.Migrator().HasIndex(index.Table, index.Name) {
+			return nil
+		}
+		return tx.Exec(index.sql("", false, " ALGORITHM=INPLACE LOCK=NONE")).Error
+	default:
+		return tx.Exec(index.sql("IF NOT EXISTS", true, "")).Error
+	}
+}
+
+func (index Index) sql(modifiers string, partial bool, options string) string {
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if index.Unique {
+		b.WriteString("UNIQUE ")
+	}
+	b.WriteString("INDEX ")
+	if modifiers != "" {
+		b.WriteString(modifiers + " ")
+	}
+	b.WriteString(index.Name + " ON " + index.Table)
+	if index.Using != "" && partial {
+		b.WriteString(" USING " + index.Using)
+	}
+	b.WriteString(" (" + index.Columns + ")")
+	if index.Where != "" && partial {
+		b.WriteString(" WHERE " + index.Where)
+	}
+	b.WriteString(options)
+	return b.String()
+}
+
+// dropInvalidIndex drops what a concurrent build of the index left behind
+// when it failed: an invalid index IF NOT EXISTS would take as built
+func dropInvalidIndex(tx *gorm.DB, name string) error {
+	var invalid bool
+	err := tx.Raw(`SELECT NOT i.indisvalid FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		WHERE c.relname = ? AND pg_table_is_visible(c.oid)`, name).Scan(&invalid).Error
+	if err != nil {
+		return fmt.Errorf("failed to check index %s: %w", name, err)
+	}
+	if !invalid {
+		return nil
+	}
+	slog.Warn("dropping invalid index left by a failed build", slog.String("index", name))
+	return tx.Exec("DROP INDEX CONCURRENTLY IF EXISTS " + name).Error
+}
+
+// Backfill describes an update RunBackfill makes batch by batch
+type Backfill struct {
+	Model     any            // Model of the table, keyed by id
+	Where     string         // Rows still to update; batches stop finding them once updated
+	Args      []any          // Arguments of Where
+	Set       map[string]any // Column values, gorm.Expr for ones computed from the row
+	BatchSize int            // Rows per batch, DefaultBatchSize when zero
+	Pause     time.Duration  // Wait between batches, leaving room for other writers
+}
+
+// RunBackfill makes the update in batches of rows in id order, each its own
+// short statement, so no lock is held on the table for long; it returns the
+// number of rows updated
+func RunBackfill(ctx context.Context, tx *gorm.DB, b Backfill) (int64, error) {
+	size := b.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	tx = tx.WithContext(ctx)
+
+	var lastID, updated int64
+	for {
+		var ids []int64
+		err := tx.Unscoped().Model(b.Model).
+			Where("id > ?", lastID).
+			Where(b.Where, b.Args...).
+			Order("id").
+			Limit(size).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return updated, err
+		}
+		if len(ids) == 0 {
+			return updated, nil
+		}
+
+		// Where is checked again for rows written since they were listed
+		result := tx.Unscoped().Model(b.Model).
+			Where("id IN ?", ids).
+			Where(b.Where, b.Args...).
+			UpdateColumns(b.Set)
+		if result.Error != nil {
+			return updated, result.Error
+		}
+		updated += result.RowsAffected
+		lastID = ids[len(ids)-1]
+
+		if b.Pause > 0 {
+			select {
+			case <-ctx.Done():
+				return updated, ctx.Err()
+			case <-time.After(b.Pause):
+			}
+		}
+	}
+}
+
+// DualWrite keeps column To a copy of column From in the table of Model: a
+// column being renamed or retyped is written under both names while the
+// release reading the new one rolls out, and backfilled for older rows
+// before the old one is dropped
+type DualWrite struct {
+	Model any
+	From  string
+	To    string
+}
+
+// dualWritePlugin copies the column of each DualWrite on every create and
+// update of its model through GORM; statements written as raw SQL are the
+// caller's to keep in step
+type dualWritePlugin struct {
+	writes []DualWrite
+	fields map[string][]dualWriteFields // By table
+}
+
+type dualWriteFields struct {
+	from, to *schema.Field
+}
+
+// NewDualWritePlugin returns the GORM plugin applying writes; both columns of
+// each must be fields of its model
+func NewDualWritePlugin(writes ...DualWrite) gorm.Plugin {
+	return &dualWritePlugin{writes: writes}
+}
+
+func (p *dualWritePlugin) Name() string {
+	return "dual_write"
+}
+
+func (p *dualWritePlugin) Initialize(db *gorm.DB) error {
+	p.fields = make(map[string][]dualWriteFields)
+	for _, w := range p.writes {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(w.Model); err != nil {
+			return fmt.Errorf("failed to parse dual write model: %w", err)
+		}
+		from, to := stmt.Schema.LookUpField(w.From), stmt.Schema.LookUpField(w.To)
+		if from == nil || to == nil {
+			return fmt.Errorf("dual write %s.%s to %s: both columns must be fields of the model", stmt.Schema.Table, w.From, w.To)
+		}
+		p.fields[stmt.Schema.Table] = append(p.fields[stmt.Schema.Table], dualWriteFields{from: from, to: to})
+	}
+
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("dual_write:create", p.create); err != nil {
+		return err
+	}
+	return cb.Update().Before("gorm:update").Register("dual_write:update", p.update)
+}
+
+func (p *dualWritePlugin) create(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	ctx := db.Statement.Context
+	for _, f := range p.fields[db.Statement.Schema.Table] {
+		if m, ok := db.Statement.Dest.(map[string]any); ok {
+			copyMapColumn(m, f)
+			continue
+		}
+		if ms, ok := db.Statement.Dest.([]map[string]any); ok {
+			for _, m := range ms {
+				copyMapColumn(m, f)
+			}
+			continue
+		}
+
+		rv := db.Statement.ReflectValue
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				row := reflect.Indirect(rv.Index(i))
+				value, _ := f.from.ValueOf(ctx, row)
+				db.AddError(f.to.Set(ctx, row, value))
+			}
+		case reflect.Struct:
+			value, _ := f.from.ValueOf(ctx, rv)
+			db.AddError(f.to.Set(ctx, rv, value))
+		}
+	}
+}
+
+func (p *dualWritePlugin) update(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	selected, restricted := db.Statement.SelectAndOmitColumns(false, true)
+	for _, f := range p.fields[db.Statement.Schema.Table] {
+		if v, ok := selected[f.from.DBName]; ok && !v {
+			continue
+		}
+
+		if m, ok := db.Statement.Dest.(map[string]any); ok {
+			if !copyMapColumn(m, f) {
+				continue
+			}
+		} else {
+			dest := reflect.Indirect(reflect.ValueOf(db.Statement.Dest))
+			if dest.Kind() != reflect.Struct {
+				continue
+			}
+			// As GORM does, a struct updates the columns selected or, when
+			// none are, the ones not left zero
+			value, zero := f.from.ValueOf(db.Statement.Context, dest)
+			if _, ok := selected[f.from.DBName]; !ok && (restricted || zero) {
+				continue
+			}
+			db.Statement.SetColumn(f.to.DBName, value, true)
+		}
+		if restricted {
+			db.Statement.Selects = append(db.Statement.Selects, f.to.DBName)
+		}
+	}
+}
+
+// copyMapColumn copies the column in values keyed by either its field or
+// its column name, reporting whether values held it
+func copyMapColumn(values map[string]any, f dualWriteFields) bool {
+	for _, key := range []string{f.from.DBName, f.from.Name} {
+		if value, ok := values[key]; ok {
+			values[f.to.DBName] = value
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// withLifetimeJitter hands dialector a pool whose connections each expire up
+// to jitter before lifetime, so connections opened together are not all
+// replaced at the same moment
+func withLifetimeJitter(dialector gorm.Dialector, lifetime, jitter time.Duration) (gorm.Dialector, error) {
+	var driverName, dsn string
+	var conn *gorm.ConnPool
+	switch d := dialector.(type) {
+	case *postgres.Dialector:
+		driverName, dsn, conn = "pgx", d.DSN, &d.Conn
+	case *mysql.Dialector:
+		driverName, dsn, conn = d.DriverName, d.DSN, &d.Conn
+		if driverName == "" {
+			driverName = mysql.DefaultDriverName
+		}
+	case *sqlite.Dialector:
+		driverName, dsn, conn = d.DriverName, d.DSN, &d.Conn
+		if driverName == "" {
+			driverName = sqlite.DriverName
+		}
+	default:
+		return nil, fmt.Errorf("lifetime jitter is not supported for %s", dialector.Name())
+	}
+
+	connector, err := newConnector(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	*conn = sql.OpenDB(&expiringConnector{Connector: connector, lifetime: lifetime, jitter: jitter})
+	return dialector, nil
+}
+
+// newConnector returns the connector sql.Open would use for dsn
+func newConnector(driverName, dsn string) (driver.Connector, error) {
+	// sql.Open only looks the driver up; it connects nothing
+	pool, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := pool.Driver()
+	pool.Close()
+
+	if dc, ok := d.(driver.DriverContext); ok {
+		return dc.OpenConnector(dsn)
+	}
+	return dsnConnector{driver: d, dsn: dsn}, nil
+}
+
+// dsnConnector connects through a driver that has no connector of its own
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
+
+type expiringConnector struct {
+	driver.Connector
+	lifetime time.Duration
+	jitter   time.Duration
+}
+
+func (c *expiringConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lifetime := c.lifetime - time.Duration(rand.Int64N(int64(c.jitter)+1))
+	return &expiringConn{Conn: conn, expires: time.Now().Add(lifetime)}, nil
+}
+
+// expiringConn is a driver connection the pool drops once it expires: when
+// it is next taken from the pool or handed back to it
+type expiringConn struct {
+	driver.Conn
+	expires time.Time
+}
+
+// Unwrap returns the driver's own connection, for sql.Conn.Raw callers
+// that need it
+func (c *expiringConn) Unwrap() driver.Conn { return c.Conn }
+
+func (c *expiringConn) IsValid() bool {
+	if time.Now().After(c.expires) {
+		return false
+	}
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *expiringConn) ResetSession(ctx context.Context) error {
+	if time.Now().After(c.expires) {
+		return driver.ErrBadConn
+	}
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// The driver's optional interfaces are passed through; driver.ErrSkip has
+// database/sql fall back to what every driver supports
+
+func (c *expiringConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *expiringConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.ReadOnly || opts.Isolation != 0 {
+		return nil, fmt.Errorf("transaction options are not supported by the driver")
+	}
+	return c.Conn.Begin()
+}
+
+func (c *expiringConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *expiringConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *expiringConn) CheckNamedValue(v *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
+
+func (c *expiringConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// warmUp holds n connections at once and hands them back to the pool idle,
+// so the first requests do not each pay for a new connection
+func warmUp(pool *sql.DB, n int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	for range n {
+		conn, err := pool.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		conns = append(conns, conn)
+		if err := conn.PingContext(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout overrides Config.QueryTimeout for the statements run with
+// ctx; zero or less runs them with no timeout of their own
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// queryTimeoutPlugin cancels a statement that runs longer than its timeout,
+// so one slow query cannot hold a connection indefinitely. That includes
+// statements on a context that can never be cancelled, such as a job's;
+// work expected to run long opts out with WithQueryTimeout(ctx, 0). Row and
+// Rows are left alone, as their results are read after the statement
+// returns.
+type queryTimeoutPlugin struct {
+	timeout time.Duration
+}
+
+// queryTimeoutSetting is the statement setting holding the running timeout
+const queryTimeoutSetting = "query_timeout"
+
+type runningTimeout struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+func (p *queryTimeoutPlugin) Name() string {
+	return "query_timeout"
+}
+
+func (p *queryTimeoutPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("*").Register("query_timeout:create", p.start); err != nil {
+		return err
+	}
+	if err := cb.Create().After("*").Register("query_timeout:create_done", p.stop); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("*").Register("query_timeout:query", p.start); err != nil {
+		return err
+	}
+	if err := cb.Query().After("*").Register("query_timeout:query_done", p.stop); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("*").Register("query_timeout:update", p.start); err != nil {
+		return err
+	}
+	if err := cb.Update().After("*").Register("query_timeout:update_done", p.stop); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("*").Register("query_timeout:delete", p.start); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("*").Register("query_timeout:delete_done", p.stop); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("*").Register("query_timeout:raw", p.start); err != nil {
+		return err
+	}
+	return cb.Raw().After("*").Register("query_timeout:raw_done", p.stop)
+}
+
+func (p *queryTimeoutPlugin) start(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := p.timeout
+	if override, ok := ctx.Value(queryTimeoutKey{}).(time.Duration); ok {
+		timeout = override
+	}
+	if timeout <= 0 {
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	db.Statement.Context = timeoutCtx
+	db.Statement.Settings.Store(queryTimeoutSetting, runningTimeout{parent: ctx, cancel: cancel})
+}
+
+// stop releases the timeout and gives the statement its own context back,
+// as a reused query builder runs its next statement with it
+func (p *queryTimeoutPlugin) stop(db *gorm.DB) {
+	v, ok := db.Statement.Settings.LoadAndDelete(queryTimeoutSetting)
+	if !ok {
+		return
+	}
+	running := v.(runningTimeout)
+	running.cancel()
+	db.Statement.Context = running.parent
+
+	if errors.Is(db.Error, context.DeadlineExceeded) && running.parent.Err() == nil {
+		slog.WarnContext(running.parent, "query timed out", slog.String("sql", db.Statement.SQL.String()))
+	}
+}
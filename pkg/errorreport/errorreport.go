@@ -0,0 +1,158 @@
+package errorreport
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+// Config holds error reporting settings. Any Sentry-compatible DSN works,
+// e.g. a self-hosted Sentry or GlitchTip instance.
+type Config struct {
+	DSN              string  `yaml:"dsn"`
+	Environment      string  `yaml:"environment"`
+	Release          string  `yaml:"release"`
+	SampleRate       float64 `yaml:"sample_rate"`        // share of errors reported, 0 means all
+	TracesSampleRate float64 `yaml:"traces_sample_rate"` // share of requests traced
+	Debug            bool    `yaml:"debug"`
+}
+
+// enabled is false until Init succeeds with a DSN, making every call a no-op
+var enabled bool
+
+// Init configures the reporting client. With an empty DSN reporting stays
+// disabled and panics are only logged. The returned func flushes buffered
+// events and should be deferred by main.
+func Init(config Config) (func(), error) {
+	if config.DSN == "" {
+		return func() {}, nil
+	}
+
+	sampleRate := config.SampleRate
+	if sampleRate <= 0 || sampleRate > 1 {
+		sampleRate = 1
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:              config.DSN,
+		Environment:      config.Environment,
+		Release:          config.Release,
+		SampleRate:       sampleRate,
+		TracesSampleRate: config.TracesSampleRate,
+		AttachStacktrace: true,
+		Debug:            config.Debug,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize error reporting: %w", err)
+	}
+
+	enabled = true
+	slog.Info("error reporting enabled", slog.String("environment", config.Environment), slog.Float64("sample_rate", sampleRate))
+	return func() { sentry.Flush(5 * time.Second) }, nil
+}
+
+// Capture reports err with the request and user attached to ctx
+func Capture(ctx context.Context, err error) {
+	if !enabled || err == nil {
+		return
+	}
+	hub := hubFrom(ctx)
+	hub.WithScope(func(scope *sentry.Scope) {
+		applyContext(ctx, scope)
+		hub.CaptureException(err)
+	})
+}
+
+// SetUser tags every event reported for this request with the user; auth
+// middleware should call it once the user is known
+func SetUser(ctx context.Context, userID int64) {
+	if !enabled {
+		return
+	}
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		hub.Scope().SetUser(sentry.User{ID: strconv.FormatInt(userID, 10)})
+	}
+}
+
+// Middleware gives each request its own reporting scope and turns panics into
+// a 500 response, reporting them with the stack trace and request details.
+// Server errors written through httpx are reported as well.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		// The panic response below goes to w, so it is not reported twice
+		rw := w
+		if enabled {
+			hub := sentry.CurrentHub().Clone()
+			hub.Scope().SetRequest(r)
+			ctx = sentry.SetHubOnContext(ctx, hub)
+			r = r.WithContext(ctx)
+			rw = &reportingWriter{ResponseWriter: w, ctx: ctx}
+		}
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Let the server abort the response as it would without this middleware
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			slog.ErrorContext(ctx, "panic recovered",
+				slog.Any("panic", rec),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("stack", string(debug.Stack())),
+			)
+			if enabled {
+				hub := hubFrom(ctx)
+				applyContext(ctx, hub.Scope())
+				hub.RecoverWithContext(ctx, rec)
+			}
+			httpx.Error(w, http.StatusInternalServerError, "internal server error")
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// reportingWriter reports the server errors httpx writes for a request
+type reportingWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+}
+
+func (w *reportingWriter) ReportError(err error) {
+	Capture(w.ctx, err)
+}
+
+func (w *reportingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func hubFrom(ctx context.Context) *sentry.Hub {
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		return hub
+	}
+	return sentry.CurrentHub()
+}
+
+// applyContext copies request-scoped logging fields onto the event scope
+func applyContext(ctx context.Context, scope *sentry.Scope) {
+	if id := logger.RequestID(ctx); id != "" {
+		scope.SetTag("request_id", id)
+	}
+	if id, ok := logger.UserID(ctx); ok {
+		scope.SetUser(sentry.User{ID: strconv.FormatInt(id, 10)})
+	}
+}
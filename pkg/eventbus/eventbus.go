@@ -0,0 +1,254 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Supported bus drivers
+const (
+	DriverMemory = "memory"
+	DriverRedis  = "redis"
+	DriverNATS   = "nats"
+)
+
+// Event is anything that can be published on the bus
+type Event interface {
+	EventName() string
+}
+
+// Handler processes a single event
+type Handler func(ctx context.Context, event Event) error
+
+// Bus decouples event producers from their consumers
+type Bus interface {
+	Publish(ctx context.Context, events ...Event) error
+	Subscribe(name string, handler Handler)
+}
+
+// Broker is a Bus with a delivery loop
+type Broker interface {
+	Bus
+	Run(ctx context.Context)        // delivers events until ctx is cancelled
+	OnDeadLetter(fn DeadLetterFunc) // reports events a handler gave up on
+}
+
+// Decoder rebuilds a typed event from its JSON encoding
+type Decoder func(data []byte) (Event, error)
+
+// decoderRegistrar is implemented by buses that receive events over the wire
+type decoderRegistrar interface {
+	RegisterDecoder(name string, decoder Decoder)
+}
+
+// Config selects and tunes a Bus implementation
+type Config struct {
+	Driver  string // memory, redis or nats
+	Workers int    // memory: delivery goroutines
+	Buffer  int    // memory: queued events before Publish blocks
+	URL     string // nats: server URLs, comma separated
+	Stream  StreamConfig
+}
+
+// New builds the Broker selected by config.Driver
+func New(ctx context.Context, config Config, client *redis.Client) (Broker, error) {
+	switch config.Driver {
+	case "", DriverMemory:
+		return NewDispatcher(config.Workers, config.Buffer), nil
+	case DriverRedis:
+		if client == nil {
+			return nil, fmt.Errorf("event bus driver %s requires redis", config.Driver)
+		}
+		return NewStreamBus(client, config.Stream), nil
+	case DriverNATS:
+		if config.URL == "" {
+			return nil, fmt.Errorf("event bus driver %s requires a url", config.Driver)
+		}
+		return NewJetStreamBus(ctx, config.URL, config.Stream)
+	default:
+		return nil, fmt.Errorf("unsupported event bus driver: %s", config.Driver)
+	}
+}
+
+// On subscribes a handler typed to a concrete event
+func On[T Event](bus Bus, handler func(ctx context.Context, event T) error) {
+	var zero T
+	if r, ok := bus.(decoderRegistrar); ok {
+		r.RegisterDecoder(zero.EventName(), func(data []byte) (Event, error) {
+			var event T
+			if err := json.Unmarshal(data, &event); err != nil {
+				return nil, err
+			}
+			return event, nil
+		})
+	}
+	bus.Subscribe(zero.EventName(), func(ctx context.Context, event Event) error {
+		typed, ok := event.(T)
+		if !ok {
+			return fmt.Errorf("unexpected event type %T for %s", event, zero.EventName())
+		}
+		return handler(ctx, typed)
+	})
+}
+
+type envelope struct {
+	ctx   context.Context
+	event Event
+}
+
+// Dispatcher is an in-process Bus that delivers events asynchronously
+// through a bounded queue drained by a fixed set of workers. A handler is
+// called once per event; its failure is dead-lettered rather than retried.
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	dead     []DeadLetterFunc
+	queue    chan envelope
+	workers  int
+	wg       sync.WaitGroup
+
+	// Close closes done, waits out the Publish calls already under way and
+	// only then closes queue, so no event is sent on a closed channel
+	closeMu    sync.Mutex
+	closed     bool
+	done       chan struct{}
+	publishing sync.WaitGroup
+}
+
+// ErrClosed is returned by Publish once the dispatcher is closed
+var ErrClosed = errors.New("event bus closed")
+
+// NewDispatcher creates an in-process dispatcher
+func NewDispatcher(workers, buffer int) *Dispatcher {
+	if workers <= 0 {
+		workers = 4
+	}
+	if buffer <= 0 {
+		buffer = 1024
+	}
+	return &Dispatcher{
+		handlers: make(map[string][]Handler),
+		queue:    make(chan envelope, buffer),
+		workers:  workers,
+		done:     make(chan struct{}),
+	}
+}
+
+// Subscribe registers a handler for events with the given name
+func (d *Dispatcher) Subscribe(name string, handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[name] = append(d.handlers[name], handler)
+}
+
+// OnDeadLetter registers a listener for events a handler failed
+func (d *Dispatcher) OnDeadLetter(fn DeadLetterFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dead = append(d.dead, fn)
+}
+
+// Publish enqueues events for delivery, blocking only when the queue is
+// full; it returns ErrClosed once the dispatcher is closed
+func (d *Dispatcher) Publish(ctx context.Context, events ...Event) error {
+	d.closeMu.Lock()
+	if d.closed {
+		d.closeMu.Unlock()
+		return ErrClosed
+	}
+	d.publishing.Add(1)
+	d.closeMu.Unlock()
+	defer d.publishing.Done()
+
+	// Detach from request cancellation; handlers run after the write path returns
+	deliveryCtx := context.WithoutCancel(ctx)
+	for _, event := range events {
+		select {
+		case d.queue <- envelope{ctx: deliveryCtx, event: event}:
+		case <-d.done:
+			return fmt.Errorf("failed to publish %s: %w", event.EventName(), ErrClosed)
+		case <-ctx.Done():
+			return fmt.Errorf("failed to publish %s: %w", event.EventName(), ctx.Err())
+		}
+	}
+	return nil
+}
+
+// Start launches the delivery workers
+func (d *Dispatcher) Start() {
+	for range d.workers {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for env := range d.queue {
+				d.Dispatch(env.ctx, env.event)
+			}
+		}()
+	}
+}
+
+// Run starts the workers and closes the dispatcher once ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.Start()
+	<-ctx.Done()
+	d.Close()
+}
+
+// Close stops accepting events and waits for queued ones to be delivered;
+// events published afterwards fail with ErrClosed
+func (d *Dispatcher) Close() {
+	d.closeMu.Lock()
+	if d.closed {
+		d.closeMu.Unlock()
+		return
+	}
+	d.closed = true
+	d.closeMu.Unlock()
+
+	close(d.done)
+	d.publishing.Wait()
+	close(d.queue)
+	d.wg.Wait()
+}
+
+// Dispatch delivers an event synchronously to every subscribed handler
+func (d *Dispatcher) Dispatch(ctx context.Context, event Event) {
+	d.mu.RLock()
+	handlers := d.handlers[event.EventName()]
+	listeners := d.dead
+	d.mu.RUnlock()
+
+	for _, handler := range handlers {
+		err := safeCall(ctx, handler, event)
+		if err == nil {
+			continue
+		}
+		slog.ErrorContext(ctx, "dead-lettering event", slog.String("event", event.EventName()), slog.Any("error", err))
+		if len(listeners) == 0 {
+			continue
+		}
+		dl := DeadLetter{Name: event.EventName(), Attempts: 1, Err: err}
+		if dl.Payload, err = json.Marshal(event); err != nil {
+			slog.WarnContext(ctx, "failed to encode dead-lettered event", slog.String("event", event.EventName()), slog.Any("error", err))
+		}
+		for _, fn := range listeners {
+			fn(ctx, dl)
+		}
+	}
+}
+
+// safeCall runs a handler, converting panics into errors
+func safeCall(ctx context.Context, handler Handler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, event)
+}
@@ -0,0 +1,201 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// JetStreamBus is a Bus backed by a NATS JetStream stream. Each event is
+// published on the subject "<stream>.<event name>". Processes sharing a group
+// pull from the same durable consumer and split the stream, while each group
+// receives every event, as with StreamBus.
+type JetStreamBus struct {
+	conn     *nats.Conn
+	js       jetstream.JetStream
+	config   StreamConfig
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	decoders map[string]Decoder
+	dead     []DeadLetterFunc
+}
+
+// NewJetStreamBus connects to the NATS servers at url, a comma-separated
+// list, and creates the stream unless it exists
+func NewJetStreamBus(ctx context.Context, url string, config StreamConfig) (*JetStreamBus, error) {
+	if config.Stream == "" {
+		config.Stream = "events"
+	}
+	if config.Group == "" {
+		config.Group = "default"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 32
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = 3
+	}
+
+	conn, err := nats.Connect(url, nats.Name("sns-platform"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open jetstream: %w", err)
+	}
+	maxMsgs := int64(-1) // Unlimited
+	if config.MaxLen > 0 {
+		maxMsgs = config.MaxLen
+	}
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     config.Stream,
+		Subjects: []string{config.Stream + ".>"},
+		MaxMsgs:  maxMsgs,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream %s: %w", config.Stream, err)
+	}
+
+	return &JetStreamBus{
+		conn:     conn,
+		js:       js,
+		config:   config,
+		handlers: make(map[string][]Handler),
+		decoders: make(map[string]Decoder),
+	}, nil
+}
+
+// Subscribe registers a handler for events with the given name
+func (b *JetStreamBus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// RegisterDecoder tells the bus how to rebuild events named name from the wire
+func (b *JetStreamBus) RegisterDecoder(name string, decoder Decoder) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.decoders[name] = decoder
+}
+
+// OnDeadLetter registers a listener for poison messages
+func (b *JetStreamBus) OnDeadLetter(fn DeadLetterFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dead = append(b.dead, fn)
+}
+
+// Publish stores events in the stream as JSON, returning once JetStream has
+// acknowledged each
+func (b *JetStreamBus) Publish(ctx context.Context, events ...Event) error {
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", event.EventName(), err)
+		}
+		if _, err := b.js.Publish(ctx, b.config.Stream+"."+event.EventName(), data); err != nil {
+			return fmt.Errorf("failed to publish %s: %w", event.EventName(), err)
+		}
+	}
+	return nil
+}
+
+// Run consumes the stream until ctx is cancelled, then drains the
+// connection. Messages left unacknowledged by a previous run are redelivered.
+func (b *JetStreamBus) Run(ctx context.Context) {
+	defer b.conn.Drain()
+
+	consumer, err := b.js.CreateOrUpdateConsumer(ctx, b.config.Stream, jetstream.ConsumerConfig{
+		Durable:       b.config.Group,
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		// Leaves room for every handler of a message to use up its retries
+		AckWait: time.Minute,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create consumer", slog.String("group", b.config.Group), slog.Any("error", err))
+		return
+	}
+
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		b.deliver(context.WithoutCancel(ctx), msg)
+	},
+		jetstream.PullMaxMessages(int(b.config.BatchSize)),
+		jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+			if ctx.Err() == nil && !errors.Is(err, jetstream.ErrNoHeartbeat) {
+				slog.WarnContext(ctx, "failed to read from stream", slog.String("stream", b.config.Stream), slog.Any("error", err))
+			}
+		}),
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to consume stream", slog.String("stream", b.config.Stream), slog.Any("error", err))
+		return
+	}
+
+	slog.InfoContext(ctx, "event bus consuming jetstream", slog.String("stream", b.config.Stream), slog.String("group", b.config.Group))
+	<-ctx.Done()
+	consumeCtx.Drain()
+	<-consumeCtx.Closed()
+}
+
+// deliver dispatches one message and acknowledges it, retrying and
+// dead-lettering as StreamBus does
+func (b *JetStreamBus) deliver(ctx context.Context, msg jetstream.Msg) {
+	name := strings.TrimPrefix(msg.Subject(), b.config.Stream+".")
+
+	b.mu.RLock()
+	handlers := b.handlers[name]
+	decoder := b.decoders[name]
+	b.mu.RUnlock()
+
+	if len(handlers) > 0 {
+		dl := DeadLetter{Name: name, Group: b.config.Group, Payload: msg.Data()}
+		if meta, err := msg.Metadata(); err == nil {
+			dl.MessageID = strconv.FormatUint(meta.Sequence.Stream, 10)
+		}
+		if decoder == nil {
+			dl.Err = fmt.Errorf("no decoder registered for %s", name)
+			b.deadLetter(ctx, dl)
+		} else if event, err := decoder(msg.Data()); err != nil {
+			dl.Err = fmt.Errorf("failed to decode %s: %w", name, err)
+			b.deadLetter(ctx, dl)
+		} else {
+			for _, handler := range handlers {
+				attempts, err := handle(ctx, handler, event, b.config.MaxRetries)
+				if err != nil {
+					dl.Attempts = attempts
+					dl.Err = err
+					b.deadLetter(ctx, dl)
+				}
+			}
+		}
+	}
+
+	if err := msg.Ack(); err != nil {
+		slog.WarnContext(ctx, "failed to ack message", slog.String("subject", msg.Subject()), slog.Any("error", err))
+	}
+}
+
+func (b *JetStreamBus) deadLetter(ctx context.Context, dl DeadLetter) {
+	slog.ErrorContext(ctx, "dead-lettering event", slog.String("event", dl.Name), slog.String("message_id", dl.MessageID), slog.Int("attempts", dl.Attempts), slog.Any("error", dl.Err))
+
+	b.mu.RLock()
+	listeners := b.dead
+	b.mu.RUnlock()
+	for _, fn := range listeners {
+		fn(ctx, dl)
+	}
+}
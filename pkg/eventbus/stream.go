@@ -0,0 +1,237 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	fieldName    = "name"
+	fieldPayload = "payload"
+)
+
+// StreamConfig tunes a bus backed by a Redis or NATS JetStream stream
+type StreamConfig struct {
+	Stream       string        // stream key shared by producers and consumers
+	Group        string        // consumer group; processes in the same group split the stream
+	Consumer     string        // redis: unique consumer name within the group, defaults to the hostname
+	MaxLen       int64         // approximate stream length cap, 0 keeps everything
+	BatchSize    int64         // messages read per round trip
+	BlockTimeout time.Duration // redis: how long a read waits for new messages
+	MaxRetries   int           // handler attempts per message before it is dead-lettered
+}
+
+// DeadLetter describes an event that could not be processed
+type DeadLetter struct {
+	Name      string
+	Group     string // Consumer group; empty on the in-memory bus
+	MessageID string // Stream message ID or JetStream sequence; empty on the in-memory bus
+	Payload   []byte
+	Attempts  int
+	Err       error
+}
+
+// DeadLetterFunc receives events that failed decoding or exhausted their retries
+type DeadLetterFunc func(ctx context.Context, dl DeadLetter)
+
+// StreamBus is a Bus backed by a Redis stream. Every process that subscribes
+// under a distinct group receives every event, while processes sharing a group
+// load-balance between them, so fan-out and notification workers can be split
+// into separate deployments consuming the same event stream.
+type StreamBus struct {
+	client   *redis.Client
+	config   StreamConfig
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	decoders map[string]Decoder
+	dead     []DeadLetterFunc
+}
+
+// NewStreamBus creates a StreamBus on top of an existing Redis client
+func NewStreamBus(client *redis.Client, config StreamConfig) *StreamBus {
+	if config.Stream == "" {
+		config.Stream = "events"
+	}
+	if config.Group == "" {
+		config.Group = "default"
+	}
+	if config.Consumer == "" {
+		config.Consumer, _ = os.Hostname()
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 32
+	}
+	if config.BlockTimeout <= 0 {
+		config.BlockTimeout = 5 * time.Second
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = 3
+	}
+	return &StreamBus{
+		client:   client,
+		config:   config,
+		handlers: make(map[string][]Handler),
+		decoders: make(map[string]Decoder),
+	}
+}
+
+// Subscribe registers a handler for events with the given name
+func (b *StreamBus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// RegisterDecoder tells the bus how to rebuild events named name from the wire
+func (b *StreamBus) RegisterDecoder(name string, decoder Decoder) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.decoders[name] = decoder
+}
+
+// OnDeadLetter registers a listener for poison messages
+func (b *StreamBus) OnDeadLetter(fn DeadLetterFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dead = append(b.dead, fn)
+}
+
+// Publish appends events to the stream as JSON
+func (b *StreamBus) Publish(ctx context.Context, events ...Event) error {
+	pipe := b.client.Pipeline()
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", event.EventName(), err)
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: b.config.Stream,
+			MaxLen: b.config.MaxLen,
+			Approx: b.config.MaxLen > 0,
+			Values: map[string]any{fieldName: event.EventName(), fieldPayload: data},
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to publish events: %w", err)
+	}
+	return nil
+}
+
+// Run consumes the stream until ctx is cancelled. Messages left pending by a
+// previous run of the same consumer are redelivered first.
+func (b *StreamBus) Run(ctx context.Context) {
+	err := b.client.XGroupCreateMkStream(ctx, b.config.Stream, b.config.Group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		slog.ErrorContext(ctx, "failed to create consumer group", slog.String("group", b.config.Group), slog.Any("error", err))
+		return
+	}
+
+	slog.InfoContext(ctx, "event bus consuming stream", slog.String("stream", b.config.Stream), slog.String("group", b.config.Group), slog.String("consumer", b.config.Consumer))
+
+	// "0" reads our own pending backlog; once drained switch to new messages
+	cursor := "0"
+	for ctx.Err() == nil {
+		streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    b.config.Group,
+			Consumer: b.config.Consumer,
+			Streams:  []string{b.config.Stream, cursor},
+			Count:    b.config.BatchSize,
+			Block:    b.config.BlockTimeout,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.WarnContext(ctx, "failed to read from stream", slog.String("stream", b.config.Stream), slog.Any("error", err))
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+
+		var messages []redis.XMessage
+		for _, stream := range streams {
+			messages = append(messages, stream.Messages...)
+		}
+		if cursor == "0" && len(messages) == 0 {
+			cursor = ">"
+			continue
+		}
+
+		for _, msg := range messages {
+			b.deliver(context.WithoutCancel(ctx), msg)
+		}
+	}
+}
+
+// deliver dispatches one stream message and acknowledges it. Each handler is
+// retried up to MaxRetries times; messages that still fail, or cannot be
+// decoded, are handed to the dead-letter listeners instead of blocking the group.
+func (b *StreamBus) deliver(ctx context.Context, msg redis.XMessage) {
+	name, _ := msg.Values[fieldName].(string)
+	payload, _ := msg.Values[fieldPayload].(string)
+
+	b.mu.RLock()
+	handlers := b.handlers[name]
+	decoder := b.decoders[name]
+	b.mu.RUnlock()
+
+	if len(handlers) > 0 {
+		dl := DeadLetter{Name: name, Group: b.config.Group, MessageID: msg.ID, Payload: []byte(payload)}
+		if decoder == nil {
+			dl.Err = fmt.Errorf("no decoder registered for %s", name)
+			b.deadLetter(ctx, dl)
+		} else if event, err := decoder([]byte(payload)); err != nil {
+			dl.Err = fmt.Errorf("failed to decode %s: %w", name, err)
+			b.deadLetter(ctx, dl)
+		} else {
+			for _, handler := range handlers {
+				attempts, err := handle(ctx, handler, event, b.config.MaxRetries)
+				if err != nil {
+					dl.Attempts = attempts
+					dl.Err = err
+					b.deadLetter(ctx, dl)
+				}
+			}
+		}
+	}
+
+	if err := b.client.XAck(ctx, b.config.Stream, b.config.Group, msg.ID).Err(); err != nil {
+		slog.WarnContext(ctx, "failed to ack message", slog.String("message_id", msg.ID), slog.Any("error", err))
+	}
+}
+
+// handle runs a handler up to maxRetries times with short linear backoff
+// between attempts, returning the attempts made
+func handle(ctx context.Context, handler Handler, event Event, maxRetries int) (int, error) {
+	var err error
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		if err = safeCall(ctx, handler, event); err == nil {
+			return attempt, nil
+		}
+		if attempt < maxRetries {
+			time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+		}
+	}
+	return maxRetries, err
+}
+
+func (b *StreamBus) deadLetter(ctx context.Context, dl DeadLetter) {
+	slog.ErrorContext(ctx, "dead-lettering event", slog.String("event", dl.Name), slog.String("message_id", dl.MessageID), slog.Int("attempts", dl.Attempts), slog.Any("error", dl.Err))
+
+	b.mu.RLock()
+	listeners := b.dead
+	b.mu.RUnlock()
+	for _, fn := range listeners {
+		fn(ctx, dl)
+	}
+}
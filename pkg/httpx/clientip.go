@@ -0,0 +1,34 @@
+package httpx
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+type clientIPKey struct{}
+
+// WithClientIP stores the resolved client IP in the context
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ContextClientIP returns the client IP stored by the server middleware, or
+// "" outside of a request
+func ContextClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// ClientIP returns the client IP resolved by the server middleware, falling
+// back to the connection's remote address
+func ClientIP(r *http.Request) string {
+	if ip := ContextClientIP(r.Context()); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
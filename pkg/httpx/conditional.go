@@ -0,0 +1,33 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ConditionalJSON writes v as a JSON response tagged with an ETag of its
+// encoding and, unless lastModified is zero, a Last-Modified header. Requests
+// whose If-None-Match matches, or that send only an If-Modified-Since not
+// before lastModified, get 304 Not Modified without a body. lastModified must
+// advance whenever the payload changes; pass zero when nothing tracks that.
+func ConditionalJSON(w http.ResponseWriter, r *http.Request, v any, lastModified time.Time) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		slog.Warn("failed to encode response", slog.Any("error", err))
+		Error(w, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+	body = append(body, '\n')
+	sum := sha256.Sum256(body)
+
+	// Payloads differ per viewer, so shared caches may only revalidate them
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", `"`+base64.RawURLEncoding.EncodeToString(sum[:16])+`"`)
+	http.ServeContent(w, r, "", lastModified, bytes.NewReader(body))
+}
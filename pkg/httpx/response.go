@@ -0,0 +1,99 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+// JSON writes v as a JSON response with the given status code
+func JSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("failed to encode response", slog.Any("error", err))
+	}
+}
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Code      apperr.Code `json:"code"`
+	Message   string      `json:"message"`
+	Details   any         `json:"details,omitempty"`    // Code-specific fields, documented with the code
+	RequestID string      `json:"request_id,omitempty"` // Also in the X-Request-ID header; quote it when reporting a problem
+}
+
+// Error writes an error response with the given status code and its generic
+// error code
+func Error(w http.ResponseWriter, status int, message string) {
+	ErrorCode(w, status, apperr.CodeForStatus(status), message, nil)
+}
+
+// ErrorCode writes an error response with a specific error code and, unless
+// nil, code-specific details
+func ErrorCode(w http.ResponseWriter, status int, code apperr.Code, message string, details any) {
+	if status >= http.StatusInternalServerError {
+		reportError(w, errors.New(message))
+	}
+	writeError(w, status, code, message, details)
+}
+
+// Fail writes err as an error response with the status and code of its kind
+func Fail(w http.ResponseWriter, err error) {
+	status := apperr.Status(err)
+	if status >= http.StatusInternalServerError {
+		reportError(w, err)
+	}
+	writeError(w, status, apperr.CodeOf(err), err.Error(), nil)
+}
+
+func writeError(w http.ResponseWriter, status int, code apperr.Code, message string, details any) {
+	JSON(w, status, ErrorResponse{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: w.Header().Get(logger.RequestIDHeader),
+	})
+}
+
+// ErrorReporter is implemented by a response writer that reports the server
+// errors written to it, such as the one errorreport.Middleware gives each
+// request
+type ErrorReporter interface {
+	ReportError(err error)
+}
+
+// reportError hands err to the first ErrorReporter among w and the writers
+// it wraps
+func reportError(w http.ResponseWriter, err error) {
+	for {
+		if reporter, ok := w.(ErrorReporter); ok {
+			reporter.ReportError(err)
+			return
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
+
+// QueryInt reads an integer query parameter, falling back to def when absent or invalid
+func QueryInt(r *http.Request, key string, def int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// PathInt64 parses an integer path parameter
+func PathInt64(r *http.Request, key string) (int64, error) {
+	return strconv.ParseInt(r.PathValue(key), 10, 64)
+}
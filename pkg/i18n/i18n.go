@@ -0,0 +1,120 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// DefaultLanguage is used when a message has no translation in the requested language
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Catalog holds message templates per language. Templates use text/template
+// syntax over a map of string parameters, e.g. "{{.actor}} followed you";
+// missing parameters render as empty strings.
+type Catalog struct {
+	messages map[string]map[string]*template.Template
+}
+
+func NewCatalog() *Catalog {
+	return &Catalog{messages: make(map[string]map[string]*template.Template)}
+}
+
+// Default returns a catalog with the built-in translations
+func Default() (*Catalog, error) {
+	sub, err := fs.Sub(locales, "locales")
+	if err != nil {
+		return nil, err
+	}
+	c := NewCatalog()
+	if err := c.Load(sub); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load adds every <language>.json file at the root of fsys, each a flat
+// object of message keys to templates
+func (c *Catalog) Load(fsys fs.FS) error {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if err := c.Add(strings.TrimSuffix(path.Base(file), ".json"), messages); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// Add registers messages for lang, replacing existing templates with the same key
+func (c *Catalog) Add(lang string, messages map[string]string) error {
+	lang = normalize(lang)
+	if c.messages[lang] == nil {
+		c.messages[lang] = make(map[string]*template.Template, len(messages))
+	}
+	for key, text := range messages {
+		tmpl, err := template.New(key).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid template %q: %w", key, err)
+		}
+		c.messages[lang][key] = tmpl
+	}
+	return nil
+}
+
+// Render formats the message key in lang, falling back to the base language
+// ("pt" for "pt-BR"), then DefaultLanguage, then the key itself
+func (c *Catalog) Render(lang, key string, params map[string]string) string {
+	tmpl := c.lookup(lang, key)
+	if tmpl == nil {
+		return key
+	}
+	if params == nil {
+		params = map[string]string{}
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, params); err != nil {
+		return key
+	}
+	return b.String()
+}
+
+// Supports reports whether lang, or its base language, has any messages
+func (c *Catalog) Supports(lang string) bool {
+	lang = normalize(lang)
+	base, _, _ := strings.Cut(lang, "-")
+	return c.messages[lang] != nil || c.messages[base] != nil
+}
+
+func (c *Catalog) lookup(lang, key string) *template.Template {
+	lang = normalize(lang)
+	base, _, _ := strings.Cut(lang, "-")
+	for _, l := range []string{lang, base, DefaultLanguage} {
+		if tmpl, ok := c.messages[l][key]; ok {
+			return tmpl
+		}
+	}
+	return nil
+}
+
+// normalize turns language tags such as "pt_BR" into "pt-br"
+func normalize(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
@@ -0,0 +1,127 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+// ErrDeadLetterNotFound is returned when a dead letter does not exist
+var ErrDeadLetterNotFound = apperr.NotFound("dead letter not found")
+
+// ErrNotRequeueable is returned when a dead letter cannot be turned back into a job
+var ErrNotRequeueable = apperr.Conflict("dead letter cannot be requeued")
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the worker dead-letters the job without further retries
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// AddDeadLetter stores a message that exhausted its retries
+func (q *Queue) AddDeadLetter(ctx context.Context, dl *model.DeadLetter) error {
+	if err := q.db.WithContext(ctx).Create(dl).Error; err != nil {
+		return fmt.Errorf("failed to store dead letter for %s: %w", dl.Type, err)
+	}
+	return nil
+}
+
+// ListDeadLetters returns the most recent dead letters, optionally filtered by source
+func (q *Queue) ListDeadLetters(ctx context.Context, source types.DeadLetterSource, limit int) ([]*model.DeadLetter, error) {
+	db := q.db.WithContext(ctx).Where("deleted_at IS NULL")
+	if source != types.DeadLetterSourceUnknown {
+		db = db.Where("source = ?", source)
+	}
+
+	var letters []*model.DeadLetter
+	if err := db.Order("created_at DESC").Limit(limit).Find(&letters).Error; err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+// GetDeadLetter fetches a single dead letter
+func (q *Queue) GetDeadLetter(ctx context.Context, id int64) (*model.DeadLetter, error) {
+	var dl model.DeadLetter
+	err := q.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&dl).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dead letter %d: %w", id, err)
+	}
+	return &dl, nil
+}
+
+// Requeue enqueues a fresh job from a job dead letter and removes the letter
+func (q *Queue) Requeue(ctx context.Context, id int64) (*model.Job, error) {
+	dl, err := q.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if dl.Source != types.DeadLetterSourceJob {
+		return nil, ErrNotRequeueable
+	}
+
+	var job *model.Job
+	err = q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		job, err = (&Queue{db: tx}).Enqueue(ctx, dl.Type, json.RawMessage(dl.Payload), WithQueue(dl.Queue))
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.DeadLetter{}, dl.ID).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue dead letter %d: %w", id, err)
+	}
+	return job, nil
+}
+
+// Discard permanently deletes a dead letter
+func (q *Queue) Discard(ctx context.Context, id int64) error {
+	res := q.db.WithContext(ctx).Unscoped().Delete(&model.DeadLetter{}, id)
+	if res.Error != nil {
+		return fmt.Errorf("failed to discard dead letter %d: %w", id, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// jobDeadLetter builds the dead letter recorded for a permanently failed job
+func jobDeadLetter(job *model.Job, attempts int, err error) *model.DeadLetter {
+	return &model.DeadLetter{
+		Source:   types.DeadLetterSourceJob,
+		SourceID: strconv.FormatInt(job.ID, 10),
+		Queue:    job.Queue,
+		Type:     job.Type,
+		Payload:  job.Payload,
+		Error:    err.Error(),
+		Attempts: attempts,
+	}
+}
@@ -0,0 +1,167 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/broadcast"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultQueue       = "default"
+	DefaultMaxAttempts = 5
+
+	// WakeChannel is the broadcast channel announcing, by queue name, jobs
+	// that are due as soon as they are enqueued
+	WakeChannel = "jobs:wake"
+)
+
+// Queue persists jobs in the jobs table so they survive restarts and can be
+// consumed by any instance sharing the database
+type Queue struct {
+	db          *gorm.DB
+	broadcaster broadcast.Broadcaster
+}
+
+func NewQueue(db *gorm.DB) *Queue {
+	return &Queue{db: db}
+}
+
+// WakeWorkers makes Enqueue announce jobs due right away on b, so workers
+// woken by b start them without waiting out their poll interval
+func (q *Queue) WakeWorkers(b broadcast.Broadcaster) {
+	q.broadcaster = b
+}
+
+type enqueueOptions struct {
+	queue       string
+	runAt       time.Time
+	maxAttempts int
+}
+
+// Option customizes an enqueued job
+type Option func(*enqueueOptions)
+
+// WithQueue routes the job to a named queue
+func WithQueue(name string) Option {
+	return func(o *enqueueOptions) { o.queue = name }
+}
+
+// WithDelay schedules the job to run after d
+func WithDelay(d time.Duration) Option {
+	return func(o *enqueueOptions) { o.runAt = time.Now().UTC().Add(d) }
+}
+
+// WithRunAt schedules the job to run at t
+func WithRunAt(t time.Time) Option {
+	return func(o *enqueueOptions) { o.runAt = t.UTC() }
+}
+
+// WithMaxAttempts overrides how many times the job is tried before failing
+func WithMaxAttempts(n int) Option {
+	return func(o *enqueueOptions) { o.maxAttempts = n }
+}
+
+// Enqueue stores a job with a JSON-encoded payload
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload any, opts ...Option) (*model.Job, error) {
+	o := enqueueOptions{
+		queue:       DefaultQueue,
+		runAt:       time.Now().UTC(),
+		maxAttempts: DefaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", jobType, err)
+	}
+
+	job := &model.Job{
+		Queue:       o.queue,
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      types.JobStatusPending,
+		MaxAttempts: o.maxAttempts,
+		RunAt:       o.runAt,
+	}
+	if err := q.db.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to enqueue %s: %w", jobType, err)
+	}
+	if q.broadcaster != nil && !job.RunAt.After(time.Now().UTC()) {
+		// Unwoken workers still find the job on their next poll
+		if err := q.broadcaster.Publish(ctx, WakeChannel, []byte(job.Queue)); err != nil {
+			slog.WarnContext(ctx, "failed to wake workers", slog.String("queue", job.Queue), slog.Any("error", err))
+		}
+	}
+	return job, nil
+}
+
+// Stats summarizes queue depth by status
+type Stats struct {
+	Queue     string `json:"queue"`
+	Pending   int64  `json:"pending"`
+	Running   int64  `json:"running"`
+	Completed int64  `json:"completed"`
+	Failed    int64  `json:"failed"`
+}
+
+// Stats returns job counts per queue and status
+func (q *Queue) Stats(ctx context.Context) ([]*Stats, error) {
+	var rows []struct {
+		Queue  string
+		Status types.JobStatus
+		Count  int64
+	}
+	err := q.db.WithContext(ctx).Model(&model.Job{}).
+		Select("queue, status, COUNT(*) as count").
+		Where("deleted_at IS NULL").
+		Group("queue, status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch queue stats: %w", err)
+	}
+
+	byQueue := make(map[string]*Stats)
+	var stats []*Stats
+	for _, row := range rows {
+		s, ok := byQueue[row.Queue]
+		if !ok {
+			s = &Stats{Queue: row.Queue}
+			byQueue[row.Queue] = s
+			stats = append(stats, s)
+		}
+		switch row.Status {
+		case types.JobStatusPending:
+			s.Pending = row.Count
+		case types.JobStatusRunning:
+			s.Running = row.Count
+		case types.JobStatusCompleted:
+			s.Completed = row.Count
+		case types.JobStatusFailed:
+			s.Failed = row.Count
+		}
+	}
+	return stats, nil
+}
+
+// ListFailed returns the most recently failed jobs
+func (q *Queue) ListFailed(ctx context.Context, limit int) ([]*model.Job, error) {
+	var failed []*model.Job
+	err := q.db.WithContext(ctx).
+		Where("status = ? AND deleted_at IS NULL", types.JobStatusFailed).
+		Order("finished_at DESC").
+		Limit(limit).
+		Find(&failed).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed jobs: %w", err)
+	}
+	return failed, nil
+}
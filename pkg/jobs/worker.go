@@ -0,0 +1,308 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/internal/model"
+	"github.com/ilhamosaurus/sns-platform/pkg/broadcast"
+	"github.com/ilhamosaurus/sns-platform/pkg/types"
+	"gorm.io/gorm"
+)
+
+// HandlerFunc processes a job's raw JSON payload
+type HandlerFunc func(ctx context.Context, payload []byte) error
+
+// Register binds a typed handler to a job type, decoding the payload into T
+func Register[T any](w *Worker, jobType string, fn func(ctx context.Context, payload T) error) {
+	w.Handle(jobType, func(ctx context.Context, raw []byte) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return Permanent(fmt.Errorf("failed to decode %s payload: %w", jobType, err))
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// WorkerConfig tunes a worker pool
+type WorkerConfig struct {
+	Queues       []string
+	Concurrency  int
+	PollInterval time.Duration
+	LockTimeout  time.Duration // running jobs whose lock was not refreshed for this long are considered abandoned
+}
+
+// Worker polls the jobs table and runs handlers with bounded concurrency
+type Worker struct {
+	queue    *Queue
+	config   WorkerConfig
+	handlers map[string]HandlerFunc
+	wake     chan struct{} // Polls right away when signalled
+	paused   func() bool   // Claims nothing while it reports true
+}
+
+func NewWorker(queue *Queue, config WorkerConfig) *Worker {
+	if len(config.Queues) == 0 {
+		config.Queues = []string{DefaultQueue}
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.LockTimeout <= 0 {
+		config.LockTimeout = 10 * time.Minute
+	}
+	return &Worker{queue: queue, config: config, handlers: make(map[string]HandlerFunc), wake: make(chan struct{}, 1)}
+}
+
+// WakeOn has the worker poll as soon as a job for one of its queues is
+// announced on b (see Queue.WakeWorkers); call it before b runs
+func (w *Worker) WakeOn(b broadcast.Broadcaster) {
+	b.Subscribe(WakeChannel, func(ctx context.Context, payload []byte) {
+		if !slices.Contains(w.config.Queues, string(payload)) {
+			return
+		}
+		select {
+		case w.wake <- struct{}{}:
+		default: // A poll is already due
+		}
+	})
+}
+
+// PauseWhile has the worker claim no jobs while paused reports true, e.g.
+// during maintenance; jobs already running finish
+func (w *Worker) PauseWhile(paused func() bool) {
+	w.paused = paused
+}
+
+// Handle registers a raw handler for a job type
+func (w *Worker) Handle(jobType string, fn HandlerFunc) {
+	w.handlers[jobType] = fn
+}
+
+// Run processes jobs until ctx is cancelled, then waits for in-flight jobs
+func (w *Worker) Run(ctx context.Context) {
+	sem := make(chan struct{}, w.config.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if w.paused != nil && w.paused() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				continue
+			}
+		}
+		w.requeueAbandoned(ctx)
+
+		for {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			job, err := w.claim(ctx)
+			if err != nil || job == nil {
+				<-sem
+				if err != nil && ctx.Err() == nil {
+					slog.WarnContext(ctx, "failed to claim job", slog.Any("error", err))
+				}
+				break
+			}
+
+			wg.Add(1)
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				w.execute(context.WithoutCancel(ctx), job)
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+}
+
+// claim picks the next due job and marks it running. The conditional update
+// makes claiming safe across instances without dialect-specific row locking.
+func (w *Worker) claim(ctx context.Context) (*model.Job, error) {
+	db := w.queue.db.WithContext(ctx)
+	now := time.Now().UTC()
+
+	var candidates []*model.Job
+	err := db.Where("queue IN ? AND status = ? AND run_at <= ? AND deleted_at IS NULL", w.config.Queues, types.JobStatusPending, now).
+		Order("run_at ASC").
+		Limit(w.config.Concurrency).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, job := range candidates {
+		res := db.Model(&model.Job{}).
+			Where("id = ? AND status = ?", job.ID, types.JobStatusPending).
+			Updates(map[string]any{"status": types.JobStatusRunning, "locked_at": now})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			job.Status = types.JobStatusRunning
+			job.LockedAt = &now
+			return job, nil
+		}
+	}
+	return nil, nil
+}
+
+// execute runs a claimed job and records the outcome
+func (w *Worker) execute(ctx context.Context, job *model.Job) {
+	stop := make(chan struct{})
+	go w.heartbeat(ctx, job, stop)
+	err := w.invoke(ctx, job)
+	close(stop)
+	now := time.Now().UTC()
+	attempts := job.Attempts + 1
+
+	updates := map[string]any{"attempts": attempts, "locked_at": nil}
+	switch {
+	case err == nil:
+		updates["status"] = types.JobStatusCompleted
+		updates["finished_at"] = now
+		updates["last_error"] = ""
+	case attempts >= job.MaxAttempts || IsPermanent(err):
+		slog.ErrorContext(ctx, "job failed permanently", slog.Int64("job_id", job.ID), slog.String("type", job.Type), slog.Int("attempts", attempts), slog.Any("error", err))
+		updates["status"] = types.JobStatusFailed
+		updates["finished_at"] = now
+		updates["last_error"] = err.Error()
+	default:
+		slog.WarnContext(ctx, "job failed, retrying", slog.Int64("job_id", job.ID), slog.String("type", job.Type), slog.Int("attempts", attempts), slog.Any("error", err))
+		updates["status"] = types.JobStatusPending
+		updates["run_at"] = now.Add(Backoff(attempts))
+		updates["last_error"] = err.Error()
+	}
+
+	// Record the outcome and, for permanent failures, the dead letter atomically
+	dbErr := w.queue.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Job{}).Where("id = ?", job.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if updates["status"] == types.JobStatusFailed {
+			return tx.Create(jobDeadLetter(job, attempts, err)).Error
+		}
+		return nil
+	})
+	if dbErr != nil {
+		slog.WarnContext(ctx, "failed to update job", slog.Int64("job_id", job.ID), slog.Any("error", dbErr))
+	}
+}
+
+// invoke calls the registered handler, turning panics into errors
+func (w *Worker) invoke(ctx context.Context, job *model.Job) (err error) {
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler registered for job type %s", job.Type)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, []byte(job.Payload))
+}
+
+// requeueAbandoned returns jobs stuck in running (e.g. after a crash) to
+// pending, counting the lost run as an attempt; a job with none left fails
+// and is dead-lettered. Running jobs keep their lock fresh through
+// heartbeat, so only jobs whose worker is gone are taken back.
+func (w *Worker) requeueAbandoned(ctx context.Context) {
+	now := time.Now().UTC()
+	cutoff := now.Add(-w.config.LockTimeout)
+	var abandoned []*model.Job
+	err := w.queue.db.WithContext(ctx).
+		Where("queue IN ? AND status = ? AND locked_at < ? AND deleted_at IS NULL", w.config.Queues, types.JobStatusRunning, cutoff).
+		Find(&abandoned).Error
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.WarnContext(ctx, "failed to find abandoned jobs", slog.Any("error", err))
+		}
+		return
+	}
+
+	for _, job := range abandoned {
+		attempts := job.Attempts + 1
+		cause := fmt.Errorf("abandoned: no heartbeat for %s", w.config.LockTimeout)
+		updates := map[string]any{"attempts": attempts, "locked_at": nil, "last_error": cause.Error()}
+		if attempts >= job.MaxAttempts {
+			slog.ErrorContext(ctx, "abandoned job failed permanently", slog.Int64("job_id", job.ID), slog.String("type", job.Type), slog.Int("attempts", attempts))
+			updates["status"] = types.JobStatusFailed
+			updates["finished_at"] = now
+		} else {
+			slog.WarnContext(ctx, "requeueing abandoned job", slog.Int64("job_id", job.ID), slog.String("type", job.Type), slog.Int("attempts", attempts))
+			updates["status"] = types.JobStatusPending
+			updates["run_at"] = now.Add(Backoff(attempts))
+		}
+
+		err := w.queue.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// Another instance may have taken the job back, or its worker
+			// sent a heartbeat, since it was listed
+			res := tx.Model(&model.Job{}).
+				Where("id = ? AND status = ? AND locked_at < ?", job.ID, types.JobStatusRunning, cutoff).
+				Updates(updates)
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			if updates["status"] == types.JobStatusFailed {
+				return tx.Create(jobDeadLetter(job, attempts, cause)).Error
+			}
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "failed to requeue abandoned job", slog.Int64("job_id", job.ID), slog.Any("error", err))
+		}
+	}
+}
+
+// heartbeat refreshes the lock of a running job every third of LockTimeout
+// until stop is closed, so a handler that runs longer than LockTimeout is
+// not taken for abandoned
+func (w *Worker) heartbeat(ctx context.Context, job *model.Job, stop <-chan struct{}) {
+	ticker := time.NewTicker(w.config.LockTimeout / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := w.queue.db.WithContext(ctx).Model(&model.Job{}).
+				Where("id = ? AND status = ?", job.ID, types.JobStatusRunning).
+				Update("locked_at", time.Now().UTC()).Error
+			if err != nil {
+				slog.WarnContext(ctx, "failed to extend job lock", slog.Int64("job_id", job.ID), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Backoff returns an exponential delay with jitter for the given attempt
+func Backoff(attempt int) time.Duration {
+	base := time.Duration(1<<min(attempt, 10)) * time.Second
+	jitter := time.Duration(rand.Int64N(int64(base / 2)))
+	return min(base+jitter, time.Hour)
+}
@@ -0,0 +1,163 @@
+// Package langdetect guesses the language of short texts such as posts. It
+// tells scripts apart by their Unicode ranges and Latin-script languages by
+// their most common words, so it is fast and dependency-free but only knows
+// the languages listed in Languages.
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Languages are the ISO 639-1 codes Detect can return
+var Languages = []string{
+	"ar", "de", "el", "en", "es", "fa", "fr", "he", "hi", "id", "it", "ja",
+	"ko", "nl", "pt", "ru", "th", "uk", "zh",
+}
+
+// minLetters is the shortest text, in letters, worth guessing at
+const minLetters = 3
+
+// stopwords are frequent words of each Latin-script language. Words shared
+// by several languages count for each of them.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "you", "that", "this", "with", "for", "have", "not", "it", "of", "to", "in", "my", "what", "just", "be", "on", "so", "we", "they", "your"},
+	"id": {"yang", "dan", "di", "ini", "itu", "dengan", "untuk", "tidak", "aku", "saya", "kamu", "ada", "ke", "dari", "juga", "sudah", "akan", "bisa", "lagi", "aja", "banget", "kita", "nya", "apa", "gak"},
+	"es": {"el", "la", "de", "que", "y", "en", "los", "las", "es", "por", "con", "para", "una", "un", "no", "lo", "pero", "muy", "como", "más", "mi", "del", "se", "está", "hoy"},
+	"pt": {"o", "a", "de", "que", "e", "do", "da", "em", "um", "uma", "para", "com", "não", "os", "as", "no", "na", "mais", "muito", "é", "eu", "você", "meu", "isso", "hoje"},
+	"fr": {"le", "la", "les", "de", "des", "et", "est", "un", "une", "je", "tu", "il", "que", "qui", "pas", "pour", "dans", "sur", "avec", "ce", "c'est", "mais", "très", "du", "au"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "du", "ein", "eine", "mit", "zu", "auf", "für", "sich", "es", "den", "dem", "auch", "wir", "sie", "heute", "sehr", "aber", "von"},
+	"it": {"il", "la", "di", "che", "e", "è", "un", "una", "per", "non", "sono", "con", "del", "della", "mi", "ma", "ho", "anche", "molto", "questo", "oggi", "gli", "le", "io", "ti"},
+	"nl": {"de", "het", "een", "en", "is", "van", "ik", "niet", "dat", "op", "te", "met", "voor", "zijn", "je", "maar", "ook", "nog", "wat", "heb", "er", "naar", "vandaag", "heel", "we"},
+}
+
+// stopwordIndex maps each stopword to the languages using it
+var stopwordIndex = func() map[string][]string {
+	index := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, w := range words {
+			index[w] = append(index[w], lang)
+		}
+	}
+	return index
+}()
+
+// letterHints are letters used by only one of the Latin-script languages
+var letterHints = map[rune]string{
+	'ñ': "es", '¿': "es", '¡': "es",
+	'ã': "pt", 'õ': "pt",
+	'ß': "de", 'ä': "de", 'ö': "de", 'ü': "de",
+	'ç': "fr", 'œ': "fr", 'ê': "fr", 'è': "fr",
+	'ì': "it", 'ò': "it",
+}
+
+// Detect returns the ISO 639-1 code of the language text is most likely in,
+// or "" when it is too short or too ambiguous to tell
+func Detect(text string) string {
+	var letters, latin int
+	scripts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			scripts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			scripts["han"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["cyrillic"]++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				scripts["uk"]++
+			}
+		case unicode.Is(unicode.Arabic, r):
+			scripts["arabic"]++
+			if strings.ContainsRune("پچژگ", r) {
+				scripts["fa"]++
+			}
+		case unicode.Is(unicode.Hebrew, r):
+			scripts["he"]++
+		case unicode.Is(unicode.Greek, r):
+			scripts["el"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["th"]++
+		case unicode.Is(unicode.Devanagari, r):
+			scripts["hi"]++
+		}
+	}
+	if letters < minLetters {
+		return ""
+	}
+	if latin*2 > letters {
+		return detectLatin(text)
+	}
+
+	// Kana marks Japanese even among mostly Han characters
+	if scripts["ja"] > 0 {
+		return "ja"
+	}
+	best, bestCount := "", 0
+	for script, n := range scripts {
+		if script != "uk" && script != "fa" && n > bestCount {
+			best, bestCount = script, n
+		}
+	}
+	switch best {
+	case "han":
+		return "zh"
+	case "cyrillic":
+		if scripts["uk"] > 0 {
+			return "uk"
+		}
+		return "ru"
+	case "arabic":
+		if scripts["fa"] > 0 {
+			return "fa"
+		}
+		return "ar"
+	}
+	return best
+}
+
+// detectLatin scores text against the stopwords and letter hints of each
+// Latin-script language; the winner needs two points and a clear lead
+func detectLatin(text string) string {
+	scores := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, w := range words {
+		for _, lang := range stopwordIndex[w] {
+			scores[lang]++
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		if lang, ok := letterHints[r]; ok {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore, runnerUp := "", 0, 0
+	for _, lang := range []string{"en", "id", "es", "pt", "fr", "de", "it", "nl"} {
+		switch score := scores[lang]; {
+		case score > bestScore:
+			best, bestScore, runnerUp = lang, score, bestScore
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+	if bestScore < 2 || bestScore == runnerUp {
+		return ""
+	}
+	return best
+}
+
+// IsCode reports whether s looks like an ISO 639-1 code: two lowercase letters
+func IsCode(s string) bool {
+	return len(s) == 2 && s[0] >= 'a' && s[0] <= 'z' && s[1] >= 'a' && s[1] <= 'z'
+}
@@ -0,0 +1,164 @@
+// Package ldapdir reads user entries from an LDAP directory or Active
+// Directory, one page of search results at a time.
+package ldapdir
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Config says where the directory is and which entries to read
+type Config struct {
+	URL                string // ldap://host:389 or ldaps://host:636
+	StartTLS           bool   // Upgrade an ldap:// connection to TLS
+	InsecureSkipVerify bool
+	BindDN             string
+	BindPassword       string
+	BaseDN             string
+	Filter             string // Defaults to (objectClass=person)
+	PageSize           int    // Entries per page, defaults to 500
+	Timeout            time.Duration
+}
+
+// Entry is a directory entry with the attributes that were requested
+type Entry struct {
+	DN         string
+	Attributes map[string][]string // Keyed by lower-cased attribute name
+}
+
+// Get returns the first value of an attribute, matched case-insensitively
+func (e *Entry) Get(name string) string {
+	if values := e.Attributes[strings.ToLower(name)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Client searches a directory. Each search opens its own connection.
+type Client struct {
+	config Config
+}
+
+func New(config Config) *Client {
+	if config.Filter == "" {
+		config.Filter = "(objectClass=person)"
+	}
+	if config.PageSize <= 0 {
+		config.PageSize = 500
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &Client{config: config}
+}
+
+// Search reads the entries under the base DN that match the filter and
+// passes them to fn a page at a time, stopping at the first error fn
+// returns. Binary GUIDs (Active Directory's objectGUID) are rendered in
+// their usual string form.
+func (c *Client) Search(ctx context.Context, attributes []string, fn func([]*Entry) error) error {
+	conn, err := c.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	paging := ldap.NewControlPaging(uint32(c.config.PageSize))
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		req := ldap.NewSearchRequest(c.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+			c.config.Filter, attributes, []ldap.Control{paging})
+		result, err := conn.Search(req)
+		if err != nil {
+			return fmt.Errorf("ldap search: %w", err)
+		}
+
+		entries := make([]*Entry, 0, len(result.Entries))
+		for _, e := range result.Entries {
+			entries = append(entries, convert(e))
+		}
+		if len(entries) > 0 {
+			if err := fn(entries); err != nil {
+				return err
+			}
+		}
+
+		control, ok := ldap.FindControl(result.Controls, ldap.ControlTypePaging).(*ldap.ControlPaging)
+		if !ok || len(control.Cookie) == 0 {
+			return nil
+		}
+		paging.SetCookie(control.Cookie)
+	}
+}
+
+func (c *Client) connect() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.config.InsecureSkipVerify}
+	conn, err := ldap.DialURL(c.config.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: c.config.Timeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("ldap dial: %w", err)
+	}
+	conn.SetTimeout(c.config.Timeout)
+
+	if c.config.StartTLS {
+		if u, err := url.Parse(c.config.URL); err == nil {
+			tlsConfig.ServerName = u.Hostname()
+		}
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap starttls: %w", err)
+		}
+	}
+	if c.config.BindDN != "" {
+		if err := conn.Bind(c.config.BindDN, c.config.BindPassword); err != nil {
+			conn.Close()
+			var ldapErr *ldap.Error
+			if errors.As(err, &ldapErr) && ldapErr.ResultCode == ldap.LDAPResultInvalidCredentials {
+				return nil, errors.New("ldap bind: invalid credentials")
+			}
+			return nil, fmt.Errorf("ldap bind: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func convert(e *ldap.Entry) *Entry {
+	entry := &Entry{DN: e.DN, Attributes: make(map[string][]string, len(e.Attributes))}
+	for _, attr := range e.Attributes {
+		name := strings.ToLower(attr.Name)
+		values := attr.Values
+		if name == "objectguid" {
+			values = make([]string, len(attr.ByteValues))
+			for i, raw := range attr.ByteValues {
+				values[i] = formatGUID(raw)
+			}
+		}
+		entry.Attributes[name] = values
+	}
+	return entry
+}
+
+// formatGUID renders a 16-byte GUID, whose first three fields are little
+// endian, as 8-4-4-4-12 hex digits
+func formatGUID(b []byte) string {
+	if len(b) != 16 {
+		return fmt.Sprintf("%x", b)
+	}
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+		binary.LittleEndian.Uint32(b[0:4]),
+		binary.LittleEndian.Uint16(b[4:6]),
+		binary.LittleEndian.Uint16(b[6:8]),
+		b[8:10], b[10:16])
+}
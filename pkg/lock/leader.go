@@ -0,0 +1,69 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Elector keeps one instance as leader for a named role
+type Elector struct {
+	locker   *Locker
+	name     string
+	ttl      time.Duration
+	isLeader atomic.Bool
+}
+
+// NewElector creates an Elector for the given role name
+func (l *Locker) NewElector(name string, ttl time.Duration) *Elector {
+	return &Elector{locker: l, name: "leader:" + name, ttl: ttl}
+}
+
+// IsLeader reports whether this instance currently holds leadership
+func (e *Elector) IsLeader() bool {
+	return e.isLeader.Load()
+}
+
+// Run campaigns for leadership until ctx is cancelled, renewing while leader
+// and retrying every ttl/3 while follower
+func (e *Elector) Run(ctx context.Context) {
+	interval := e.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var held *Lock
+	defer func() {
+		e.isLeader.Store(false)
+		if held != nil {
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			held.Release(releaseCtx)
+		}
+	}()
+
+	for {
+		if held == nil {
+			lk, err := e.locker.Acquire(ctx, e.name, e.ttl)
+			switch {
+			case err == nil:
+				held = lk
+				e.isLeader.Store(true)
+				slog.InfoContext(ctx, "became leader", slog.String("role", e.name))
+			case !errors.Is(err, ErrNotAcquired):
+				slog.WarnContext(ctx, "leader election failed", slog.String("role", e.name), slog.Any("error", err))
+			}
+		} else if err := held.Renew(ctx); err != nil {
+			slog.WarnContext(ctx, "lost leadership", slog.String("role", e.name), slog.Any("error", err))
+			held = nil
+			e.isLeader.Store(false)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotAcquired is returned when the lock is already held by another instance
+var ErrNotAcquired = errors.New("lock not acquired")
+
+// ErrLockLost is returned when a held lock expires or is taken over before release
+var ErrLockLost = errors.New("lock lost")
+
+const keyPrefix = "lock:"
+
+// releaseScript deletes the key only if it still holds our token
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// renewScript extends the TTL only if the key still holds our token
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Stats holds lock counters, safe for concurrent reads
+type Stats struct {
+	Acquired  atomic.Int64
+	Contended atomic.Int64
+	Renewed   atomic.Int64
+	Lost      atomic.Int64
+	Released  atomic.Int64
+}
+
+// Locker hands out Redis-backed distributed locks
+type Locker struct {
+	client *redis.Client
+	stats  Stats
+}
+
+// NewLocker creates a Locker on top of an existing Redis client
+func NewLocker(client *redis.Client) *Locker {
+	return &Locker{client: client}
+}
+
+// Stats returns the live lock counters
+func (l *Locker) Stats() *Stats {
+	return &l.stats
+}
+
+// Lock is a held distributed lock
+type Lock struct {
+	locker *Locker
+	key    string
+	token  string
+	ttl    time.Duration
+}
+
+// Acquire tries once to take the lock named key for ttl
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := l.client.SetNX(ctx, keyPrefix+key, token, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if !ok {
+		l.stats.Contended.Add(1)
+		return nil, ErrNotAcquired
+	}
+
+	l.stats.Acquired.Add(1)
+	return &Lock{locker: l, key: keyPrefix + key, token: token, ttl: ttl}, nil
+}
+
+// Renew extends the lock TTL, returning ErrLockLost if it is no longer ours
+func (lk *Lock) Renew(ctx context.Context) error {
+	return lk.expireIn(ctx, lk.ttl)
+}
+
+// expireIn sets the lock to expire after d, returning ErrLockLost if it is
+// no longer ours
+func (lk *Lock) expireIn(ctx context.Context, d time.Duration) error {
+	res, err := renewScript.Run(ctx, lk.locker.client, []string{lk.key}, lk.token, d.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to renew lock %s: %w", lk.key, err)
+	}
+	if res == 0 {
+		lk.locker.stats.Lost.Add(1)
+		return ErrLockLost
+	}
+	lk.locker.stats.Renewed.Add(1)
+	return nil
+}
+
+// Release frees the lock if it is still ours
+func (lk *Lock) Release(ctx context.Context) error {
+	if err := releaseScript.Run(ctx, lk.locker.client, []string{lk.key}, lk.token).Err(); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", lk.key, err)
+	}
+	lk.locker.stats.Released.Add(1)
+	return nil
+}
+
+// RunExclusive runs fn only if this instance wins the lock, renewing it at
+// ttl/3 while fn runs. fn's context is cancelled if the lock is lost.
+// Returns ErrNotAcquired without calling fn when another instance holds the lock.
+func (l *Locker) RunExclusive(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	return l.run(ctx, key, ttl, fn, false)
+}
+
+// RunAndHold is RunExclusive, except that the lock is left to expire after
+// fn succeeds rather than released, so no instance runs fn again until ttl
+// has passed; a failed fn releases it for another instance to retry
+func (l *Locker) RunAndHold(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	return l.run(ctx, key, ttl, fn, true)
+}
+
+func (l *Locker) run(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) error, hold bool) error {
+	acquired := time.Now()
+	lk, err := l.Acquire(ctx, key, ttl)
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				if err := lk.Renew(runCtx); err != nil {
+					slog.WarnContext(ctx, "lock renewal failed", slog.String("lock", key), slog.Any("error", err))
+					cancel(ErrLockLost)
+					return
+				}
+			}
+		}
+	}()
+
+	err = fn(runCtx)
+	close(done)
+
+	// Renewals kept the lock for ttl past the last of them; it is held for
+	// ttl from when it was taken
+	if remaining := ttl - time.Since(acquired); hold && err == nil && remaining >= time.Millisecond {
+		if errors.Is(context.Cause(runCtx), ErrLockLost) {
+			return ErrLockLost
+		}
+		if expErr := lk.expireIn(ctx, remaining); expErr != nil {
+			slog.WarnContext(ctx, "failed to hold lock", slog.String("lock", key), slog.Any("error", expErr))
+		}
+		return nil
+	}
+
+	// Release with a fresh context so a cancelled job still frees its lock
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer releaseCancel()
+	if relErr := lk.Release(releaseCtx); relErr != nil {
+		slog.WarnContext(ctx, "failed to release lock", slog.String("lock", key), slog.Any("error", relErr))
+	}
+
+	if err == nil && errors.Is(context.Cause(runCtx), ErrLockLost) {
+		return ErrLockLost
+	}
+	return err
+}
+
+// newToken returns a random value identifying the lock holder
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
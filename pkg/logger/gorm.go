@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/metrics"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// DefaultSlowThreshold is used when no slow query threshold is configured
+const DefaultSlowThreshold = 200 * time.Millisecond
+
+// GormLogger adapts slog to GORM's logger interface so SQL logs share the
+// application's format and request-scoped fields
+type GormLogger struct {
+	logger        *slog.Logger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+// NewGormLogger creates a GORM logger writing through l. Queries slower than
+// slowThreshold are logged as warnings and counted in metrics.SlowQueries;
+// a negative threshold disables slow query detection.
+func NewGormLogger(l *slog.Logger, level gormlogger.LogLevel, slowThreshold time.Duration) *GormLogger {
+	if slowThreshold == 0 {
+		slowThreshold = DefaultSlowThreshold
+	}
+	return &GormLogger{logger: l, level: level, slowThreshold: slowThreshold}
+}
+
+func (g *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *g
+	clone.level = level
+	return &clone
+}
+
+func (g *GormLogger) Info(ctx context.Context, msg string, args ...any) {
+	if g.level >= gormlogger.Info {
+		g.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *GormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if g.level >= gormlogger.Warn {
+		g.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *GormLogger) Error(ctx context.Context, msg string, args ...any) {
+	if g.level >= gormlogger.Error {
+		g.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace logs every executed statement at debug level, slow statements at
+// warn level and failures at error level
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	slow := g.slowThreshold > 0 && elapsed > g.slowThreshold
+	if slow {
+		sql, rows := fc()
+		metrics.SlowQueries.WithLabelValues(statementVerb(sql)).Inc()
+		if g.level >= gormlogger.Warn {
+			g.logger.WarnContext(ctx, "slow sql query",
+				slog.String("sql", sql),
+				slog.Int64("rows", rows),
+				slog.Duration("duration", elapsed),
+				slog.Duration("threshold", g.slowThreshold),
+				slog.String("caller", utils.FileWithLineNum()),
+			)
+		}
+	}
+
+	if g.level <= gormlogger.Silent {
+		return
+	}
+
+	switch {
+	case err != nil && g.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		g.logger.ErrorContext(ctx, "sql query failed",
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Duration("duration", elapsed),
+			slog.String("error", err.Error()),
+		)
+	case g.level >= gormlogger.Info && !slow:
+		sql, rows := fc()
+		g.logger.DebugContext(ctx, "sql query",
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Duration("duration", elapsed),
+		)
+	}
+}
+
+// statementVerb returns the leading SQL keyword, used as a low-cardinality label
+func statementVerb(sql string) string {
+	verb, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	switch verb = strings.ToUpper(verb); verb {
+	case "SELECT", "INSERT", "UPDATE", "DELETE":
+		return verb
+	default:
+		return "OTHER"
+	}
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Config holds logger settings
+type Config struct {
+	Level  string    `yaml:"level"`  // debug, info, warn, error
+	Format string    `yaml:"format"` // json, text
+	Output io.Writer `yaml:"-"`      // defaults to stdout
+}
+
+// New builds a slog logger that also attaches request-scoped fields from the context
+func New(config Config) *slog.Logger {
+	out := config.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	opts := &slog.HandlerOptions{Level: ParseLevel(config.Level)}
+	var handler slog.Handler
+	if strings.ToLower(config.Format) == "text" {
+		handler = slog.NewTextHandler(out, opts)
+	} else {
+		handler = slog.NewJSONHandler(out, opts)
+	}
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// Init builds a logger and installs it as the slog and standard log default
+func Init(config Config) *slog.Logger {
+	l := New(config)
+	slog.SetDefault(l)
+	return l
+}
+
+// ParseLevel converts a config level name to a slog level, defaulting to info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// contextHandler adds request ID and user ID attributes stored in the context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if id, ok := UserID(ctx); ok {
+			r.AddAttrs(slog.Int64("user_id", id))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
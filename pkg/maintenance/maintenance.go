@@ -0,0 +1,145 @@
+// Package maintenance puts the platform into read-only mode for migrations
+// and failovers: writes are turned away with 503 and a Retry-After while
+// reads are still served, and background writers hold off until it ends.
+// Switching it on or off on one instance reaches the others through the
+// broadcaster; an instance starting meanwhile takes its configured state.
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/broadcast"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+)
+
+// Channel is the broadcast channel state changes are published on
+const Channel = "maintenance"
+
+// DefaultRetryAfter is how long clients are told to wait when the state
+// names no time of its own
+const DefaultRetryAfter = 5 * time.Minute
+
+// State is whether maintenance is on, with what clients are told meanwhile
+type State struct {
+	Enabled    bool          `json:"enabled"`
+	Message    string        `json:"message,omitempty"`
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+	Since      *time.Time    `json:"since,omitempty"`
+}
+
+// Mode holds the maintenance state of this instance
+type Mode struct {
+	state       atomic.Pointer[State]
+	broadcaster broadcast.Broadcaster
+	exempt      []string
+}
+
+// New returns the mode in initial state, following the changes published on
+// b; requests under the exempt path prefixes may write regardless, so the
+// endpoint switching maintenance off stays reachable
+func New(b broadcast.Broadcaster, initial State, exempt ...string) *Mode {
+	m := &Mode{broadcaster: b, exempt: exempt}
+	m.store(initial)
+	b.Subscribe(Channel, m.receive)
+	return m
+}
+
+func (m *Mode) State() State {
+	return *m.state.Load()
+}
+
+// Enabled reports whether maintenance is on; it suits the pause checks of
+// background writers
+func (m *Mode) Enabled() bool {
+	return m.state.Load().Enabled
+}
+
+// Set switches maintenance on or off here and on every other instance
+func (m *Mode) Set(ctx context.Context, state State) (State, error) {
+	if current := m.state.Load(); state.Enabled && current.Enabled {
+		// Changing the notice does not restart the maintenance
+		state.Since = current.Since
+	}
+	state = m.store(state)
+
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return state, err
+	}
+	if err := m.broadcaster.Publish(ctx, Channel, payload); err != nil {
+		return state, fmt.Errorf("failed to tell other instances: %w", err)
+	}
+	return state, nil
+}
+
+func (m *Mode) store(state State) State {
+	if !state.Enabled {
+		state = State{}
+	} else {
+		if state.RetryAfter <= 0 {
+			state.RetryAfter = DefaultRetryAfter
+		}
+		if state.Since == nil {
+			now := time.Now().UTC()
+			state.Since = &now
+		}
+	}
+	if previous := m.state.Swap(&state); previous == nil || previous.Enabled != state.Enabled {
+		slog.Info("maintenance mode changed", slog.Bool("enabled", state.Enabled), slog.String("message", state.Message))
+	}
+	return state
+}
+
+func (m *Mode) receive(ctx context.Context, payload []byte) {
+	var state State
+	if err := json.Unmarshal(payload, &state); err != nil {
+		slog.WarnContext(ctx, "ignoring malformed maintenance state", slog.Any("error", err))
+		return
+	}
+	m.store(state)
+}
+
+// Middleware turns away requests that may write with 503 while maintenance
+// is on; GET, HEAD and OPTIONS requests go through
+func (m *Mode) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := m.state.Load()
+		if !state.Enabled || readOnly(r.Method) || m.isExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		message := state.Message
+		if message == "" {
+			message = "the service is read-only for maintenance; retry later"
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(state.RetryAfter.Round(time.Second).Seconds())))
+		httpx.ErrorCode(w, http.StatusServiceUnavailable, apperr.CodeMaintenance, message, nil)
+	})
+}
+
+func (m *Mode) isExempt(path string) bool {
+	for _, prefix := range m.exempt {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func readOnly(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Middleware records request count, latency and in-flight requests. It must
+// wrap the ServeMux directly so the matched route pattern is available after
+// routing; the pattern keeps label cardinality bounded regardless of path IDs.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		HTTPInFlight.Inc()
+		defer HTTPInFlight.Dec()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+
+		next.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).Inc()
+		HTTPDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
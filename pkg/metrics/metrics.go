@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/jobs"
+	"github.com/ilhamosaurus/sns-platform/pkg/lock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "sns"
+
+// Registry holds every application metric; it is what /metrics exposes
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	StreamConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "stream",
+		Name:      "connections",
+		Help:      "Open feed stream (server-sent events) connections on this instance.",
+	})
+
+	SlowQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "slow_queries_total",
+		Help:      "SQL statements slower than the configured threshold, by statement type.",
+	}, []string{"statement"})
+
+	CacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "hits_total",
+		Help:      "Cache lookups served from cache.",
+	}, []string{"cache"})
+
+	CacheMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "misses_total",
+		Help:      "Cache lookups that fell through to the source.",
+	}, []string{"cache"})
+
+	VelocityBreaches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "abuse",
+		Name:      "velocity_breaches_total",
+		Help:      "Users going over a velocity threshold, by metric.",
+	}, []string{"metric"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		HTTPInFlight,
+		StreamConnections,
+		SlowQueries,
+		CacheHits,
+		CacheMisses,
+		VelocityBreaches,
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Register mounts GET /metrics on mux, which should not be the public API's
+func Register(mux *http.ServeMux) {
+	mux.Handle("GET /metrics", Handler())
+}
+
+// CacheLookup records a hit or miss for the named cache; the hit ratio is
+// hits_total / (hits_total + misses_total)
+func CacheLookup(cache string, hit bool) {
+	if hit {
+		CacheHits.WithLabelValues(cache).Inc()
+	} else {
+		CacheMisses.WithLabelValues(cache).Inc()
+	}
+}
+
+// queueCollector reports job queue depth, queried from the database at scrape time
+type queueCollector struct {
+	queue *jobs.Queue
+	depth *prometheus.Desc
+}
+
+// RegisterJobQueue exposes per-queue, per-status job counts
+func RegisterJobQueue(queue *jobs.Queue) {
+	Registry.MustRegister(&queueCollector{
+		queue: queue,
+		depth: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "jobs", "queue_depth"),
+			"Jobs by queue and status.",
+			[]string{"queue", "status"}, nil,
+		),
+	})
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := c.queue.Stats(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to collect job queue metrics", slog.Any("error", err))
+		return
+	}
+	for _, s := range stats {
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(s.Pending), s.Queue, "pending")
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(s.Running), s.Queue, "running")
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(s.Completed), s.Queue, "completed")
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(s.Failed), s.Queue, "failed")
+	}
+}
+
+// lockCollector reports a Locker's counters, read from it at scrape time
+type lockCollector struct {
+	stats      *lock.Stats
+	operations *prometheus.Desc
+}
+
+// RegisterLocker exposes the distributed lock counters by outcome
+func RegisterLocker(locker *lock.Locker) {
+	Registry.MustRegister(&lockCollector{
+		stats: locker.Stats(),
+		operations: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lock", "operations_total"),
+			"Distributed lock operations by outcome.",
+			[]string{"outcome"}, nil,
+		),
+	})
+}
+
+func (c *lockCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.operations
+}
+
+func (c *lockCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.operations, prometheus.CounterValue, float64(c.stats.Acquired.Load()), "acquired")
+	ch <- prometheus.MustNewConstMetric(c.operations, prometheus.CounterValue, float64(c.stats.Contended.Load()), "contended")
+	ch <- prometheus.MustNewConstMetric(c.operations, prometheus.CounterValue, float64(c.stats.Renewed.Load()), "renewed")
+	ch <- prometheus.MustNewConstMetric(c.operations, prometheus.CounterValue, float64(c.stats.Lost.Load()), "lost")
+	ch <- prometheus.MustNewConstMetric(c.operations, prometheus.CounterValue, float64(c.stats.Released.Load()), "released")
+}
@@ -0,0 +1,201 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/errorreport"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+	"github.com/ilhamosaurus/sns-platform/pkg/quota"
+)
+
+// Response headers describing an app's rate limit
+const (
+	HeaderRateLimit     = "X-RateLimit-Limit"
+	HeaderRateRemaining = "X-RateLimit-Remaining"
+)
+
+// Authenticator resolves access tokens to what they grant
+type Authenticator interface {
+	Authenticate(ctx context.Context, accessToken string) (*Grant, error)
+}
+
+// Config caps the requests each app makes across all of its users and maps
+// routes to the scopes they need
+type Config struct {
+	RateLimit  int64 // Requests per window, 0 is unlimited
+	RateWindow time.Duration
+	Rules      []Rule // Defaults to DefaultRules
+
+	// Passthrough lists path prefixes whose bearer tokens are not access
+	// tokens, such as the SCIM API's, and are left to the route
+	Passthrough []string
+}
+
+// Rule sets the scopes apps need for the paths under Prefix: Read for GET
+// and HEAD requests, Write for the rest. An empty scope closes the paths to
+// apps altogether.
+type Rule struct {
+	Prefix string
+	Read   Scope
+	Write  Scope
+}
+
+// DefaultRules map the API's routes to scopes. Account deletion, data
+// portability, sign-in, phone numbers and two-factor settings, invites, the
+// waitlist, app management and administration stay with the user; paths no rule covers
+// need read or write.
+var DefaultRules = []Rule{
+	{Prefix: "/admin/"},
+	{Prefix: "/oauth/"},
+	{Prefix: "/sso/"},
+	{Prefix: "/me/authorized-apps"},
+	{Prefix: "/me/deactivate"},
+	{Prefix: "/me/reactivate"},
+	{Prefix: "/me/data-exports"},
+	{Prefix: "/data-exports/"},
+	{Prefix: "/me/imports"},
+	{Prefix: "/me/security-events"},
+	{Prefix: "/me/phone"},
+	{Prefix: "/me/two-factor"},
+	{Prefix: "/me/invites"},
+	{Prefix: "/waitlist"},
+	{Prefix: "/messages", Read: ScopeDMRead, Write: ScopeDMWrite},
+	{Prefix: "/me/message-requests", Read: ScopeDMRead, Write: ScopeDMWrite},
+	{Prefix: "/me/messages/", Read: ScopeDMRead, Write: ScopeDMWrite},
+	{Prefix: "/me/drafts/conversation/", Read: ScopeDMRead, Write: ScopeDMWrite},
+}
+
+// Permissions looks up the scope each request needs
+type Permissions struct {
+	rules []Rule
+}
+
+// NewPermissions builds a lookup over rules; the longest matching prefix wins
+func NewPermissions(rules []Rule) *Permissions {
+	return &Permissions{rules: rules}
+}
+
+// Required returns the scope an app needs for a request; ok is false for
+// routes apps may not call at all
+func (p *Permissions) Required(r *http.Request) (scope Scope, ok bool) {
+	rule := Rule{Read: ScopeRead, Write: ScopeWrite}
+	matched := -1
+	for _, candidate := range p.rules {
+		if len(candidate.Prefix) > matched && strings.HasPrefix(r.URL.Path, candidate.Prefix) {
+			rule, matched = candidate, len(candidate.Prefix)
+		}
+	}
+	scope = rule.Write
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		scope = rule.Read
+	}
+	return scope, scope != ""
+}
+
+// Middleware authenticates requests carrying a bearer access token, checks
+// the token's scopes against the route and counts the request against its
+// app's rate limit. The token's user is stored as the authenticated user
+// and set on error reports.
+// Requests without a bearer token, or under a Passthrough prefix, pass
+// through untouched.
+func Middleware(auth Authenticator, store quota.Store, config Config) func(http.Handler) http.Handler {
+	rules := config.Rules
+	if rules == nil {
+		rules = DefaultRules
+	}
+	permissions := NewPermissions(rules)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok || slices.ContainsFunc(config.Passthrough, func(prefix string) bool { return strings.HasPrefix(r.URL.Path, prefix) }) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			grant, err := auth.Authenticate(r.Context(), token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				httpx.Error(w, http.StatusUnauthorized, "invalid or expired access token")
+				return
+			}
+			scope, ok := permissions.Required(r)
+			if !ok {
+				httpx.Error(w, http.StatusForbidden, "this endpoint is not available to apps")
+				return
+			}
+			if !grant.Has(scope) {
+				MissingScope(w, &MissingScopeError{Scope: scope})
+				return
+			}
+
+			if config.RateLimit > 0 {
+				count, taken, err := store.Take(r.Context(), fmt.Sprintf("oauth:app:%d", grant.AppID), config.RateLimit, config.RateWindow)
+				if err != nil {
+					httpx.Error(w, http.StatusInternalServerError, err.Error())
+					return
+				}
+				w.Header().Set(HeaderRateLimit, strconv.FormatInt(config.RateLimit, 10))
+				w.Header().Set(HeaderRateRemaining, strconv.FormatInt(max(config.RateLimit-count, 0), 10))
+				if !taken {
+					httpx.Error(w, http.StatusTooManyRequests, "app rate limit exceeded")
+					return
+				}
+			}
+
+			errorreport.SetUser(r.Context(), grant.UserID)
+			ctx := WithGrant(logger.WithUserID(r.Context(), grant.UserID), grant)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Principal returns the tenant of the access token a request carries, for
+// the tenant middleware to hold the request to it. ok is false for requests
+// that Middleware lets through without a token, and for tokens it will turn
+// away anyway.
+func Principal(auth Authenticator, config Config) func(r *http.Request) (tenantID int64, ok bool) {
+	return func(r *http.Request) (int64, bool) {
+		token, ok := bearerToken(r)
+		if !ok || slices.ContainsFunc(config.Passthrough, func(prefix string) bool { return strings.HasPrefix(r.URL.Path, prefix) }) {
+			return 0, false
+		}
+		grant, err := auth.Authenticate(r.Context(), token)
+		if err != nil {
+			return 0, false
+		}
+		return grant.TenantID, true
+	}
+}
+
+// MissingScope writes the 403 for a request whose token lacks a scope,
+// naming the scope in the error details and the WWW-Authenticate header. It
+// reports false, writing nothing, when err is not a *MissingScopeError.
+func MissingScope(w http.ResponseWriter, err error) bool {
+	var missing *MissingScopeError
+	if !errors.As(err, &missing) {
+		return false
+	}
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, missing.Scope))
+	httpx.ErrorCode(w, http.StatusForbidden, apperr.CodeInsufficientScope, missing.Error(), map[string]string{"scope": string(missing.Scope)})
+	return true
+}
+
+// bearerToken returns the token of an Authorization: Bearer header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
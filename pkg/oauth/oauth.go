@@ -0,0 +1,165 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+)
+
+// Scope is a permission a user grants a third-party app
+type Scope string
+
+const (
+	ScopeRead    Scope = "read"     // Read posts, profiles, feeds and notifications
+	ScopeWrite   Scope = "write"    // Post, comment, follow and change settings
+	ScopeDMRead  Scope = "dm:read"  // Read direct messages and their drafts
+	ScopeDMWrite Scope = "dm:write" // Send direct messages and handle message requests
+)
+
+// ScopeDM is shorthand an app may request for both direct message scopes
+const ScopeDM Scope = "dm"
+
+// Scopes lists every scope an app may be granted
+var Scopes = []Scope{ScopeRead, ScopeWrite, ScopeDMRead, ScopeDMWrite}
+
+// ParseScopes splits a space-separated scope list, expanding shorthands
+// and dropping duplicates
+func ParseScopes(s string) ([]Scope, error) {
+	var scopes []Scope
+	for _, field := range strings.Fields(s) {
+		expanded := []Scope{Scope(field)}
+		if Scope(field) == ScopeDM {
+			expanded = []Scope{ScopeDMRead, ScopeDMWrite}
+		}
+		for _, scope := range expanded {
+			if !slices.Contains(Scopes, scope) {
+				return nil, fmt.Errorf("unknown scope %q", field)
+			}
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes, nil
+}
+
+// FormatScopes joins scopes into a space-separated list
+func FormatScopes(scopes []Scope) string {
+	fields := make([]string, len(scopes))
+	for i, scope := range scopes {
+		fields[i] = string(scope)
+	}
+	return strings.Join(fields, " ")
+}
+
+// Grant is what a valid access token lets an app do on behalf of a user
+type Grant struct {
+	AppID    int64
+	UserID   int64
+	TenantID int64 // Tenant the token was issued in
+	Scopes   []Scope
+}
+
+// Has reports whether the grant includes scope
+func (g *Grant) Has(scope Scope) bool {
+	return slices.Contains(g.Scopes, scope)
+}
+
+type grantKey struct{}
+
+// WithGrant stores the grant of the request's access token in the context
+func WithGrant(ctx context.Context, grant *Grant) context.Context {
+	return context.WithValue(ctx, grantKey{}, grant)
+}
+
+// GrantFrom returns the grant stored in the context; requests made by the
+// user directly rather than through an app have none
+func GrantFrom(ctx context.Context) (*Grant, bool) {
+	grant, ok := ctx.Value(grantKey{}).(*Grant)
+	return grant, ok
+}
+
+// MissingScopeError reports that the request's app token lacks a scope; it
+// is of kind apperr.ErrForbidden
+type MissingScopeError struct {
+	Scope Scope
+}
+
+func (e *MissingScopeError) Error() string {
+	return fmt.Sprintf("the %s scope is required", e.Scope)
+}
+
+func (e *MissingScopeError) Unwrap() error {
+	return apperr.ErrForbidden
+}
+
+// Allowed reports whether the request in ctx may use scope. Requests made
+// by users directly rather than through an app may do anything.
+func Allowed(ctx context.Context, scope Scope) bool {
+	grant, ok := GrantFrom(ctx)
+	return !ok || grant.Has(scope)
+}
+
+// Require is Allowed for services and handlers that check scopes beyond
+// what the route needs; it returns a *MissingScopeError when not allowed
+func Require(ctx context.Context, scope Scope) error {
+	if !Allowed(ctx, scope) {
+		return &MissingScopeError{Scope: scope}
+	}
+	return nil
+}
+
+// NewToken returns a random opaque token for codes, secrets and access tokens
+func NewToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// HashToken is the form tokens are stored and looked up in
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyPKCE checks a code verifier against an S256 code challenge (RFC 7636)
+func VerifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
+
+// Error codes of the token endpoint (RFC 6749 section 5.2)
+const (
+	ErrCodeInvalidRequest       = "invalid_request"
+	ErrCodeInvalidClient        = "invalid_client"
+	ErrCodeInvalidGrant         = "invalid_grant"
+	ErrCodeUnauthorizedClient   = "unauthorized_client"
+	ErrCodeUnsupportedGrantType = "unsupported_grant_type"
+	ErrCodeInvalidScope         = "invalid_scope"
+)
+
+// Error is an OAuth 2.0 error response
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Description
+}
+
+// NewError returns an error response with a formatted description
+func NewError(code, format string, args ...any) *Error {
+	return &Error{Code: code, Description: fmt.Sprintf(format, args...)}
+}
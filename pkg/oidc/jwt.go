@@ -0,0 +1,263 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// clockSkew is the leeway allowed on token times
+const clockSkew = 2 * time.Minute
+
+// keySet is a provider's JSON Web Key Set by key ID
+type keySet struct {
+	keys map[string]crypto.PublicKey
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Verify checks an ID token's signature against the provider's keys and its
+// issuer, audience, expiry and nonce, and returns its claims
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (Claims, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if strings.TrimSuffix(claims.String("iss"), "/") != p.config.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.String("iss"))
+	}
+	if !audienceIncludes(claims["aud"], p.config.ClientID) {
+		return nil, fmt.Errorf("%w: not issued for this client", ErrInvalidToken)
+	}
+	if azp := claims.String("azp"); azp != "" && azp != p.config.ClientID {
+		return nil, fmt.Errorf("%w: authorized party %q is not this client", ErrInvalidToken, azp)
+	}
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if iat, ok := claims["iat"].(float64); ok && time.Unix(int64(iat), 0).After(now.Add(clockSkew)) {
+		return nil, fmt.Errorf("%w: issued in the future", ErrInvalidToken)
+	}
+	if claims.String("nonce") != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+	if claims.String("sub") == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, out any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func audienceIncludes(aud any, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []any:
+		for _, a := range v {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifySignature checks a JWS signature; only asymmetric algorithms are
+// accepted so a token can never be checked against a shared secret
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		var err error
+		switch alg[0] {
+		case 'R':
+			err = rsa.VerifyPKCS1v15(k, hash, digest, signature)
+		case 'P':
+			err = rsa.VerifyPSS(k, hash, digest, signature, nil)
+		default:
+			err = fmt.Errorf("algorithm %s does not match an RSA key", alg)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if alg[0] != 'E' || len(signature) != 2*size {
+			return fmt.Errorf("%w: bad ECDSA signature", ErrInvalidToken)
+		}
+		r, s := new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported key type", ErrInvalidToken)
+	}
+	return nil
+}
+
+// key returns the signing key kid, fetching the key set again once when it
+// is not known, as providers rotate keys
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if p.keys == nil || attempt > 0 {
+			keys, err := p.fetchKeys(ctx, meta.JWKSURI)
+			if err != nil {
+				return nil, err
+			}
+			p.keys = keys
+		}
+		if key, ok := p.keys.keys[kid]; ok {
+			return key, nil
+		}
+		// Tokens without a kid are accepted when the provider has one key
+		if kid == "" && len(p.keys.keys) == 1 {
+			for _, key := range p.keys.keys {
+				return key, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+}
+
+func (p *Provider) fetchKeys(ctx context.Context, uri string) (*keySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.do(req, &body); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	set := &keySet{keys: make(map[string]crypto.PublicKey)}
+	for _, k := range body.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			set.keys[k.Kid] = key
+		}
+	}
+	return set, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, fmt.Errorf("bad exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
@@ -0,0 +1,241 @@
+// Package oidc signs users in with an OpenID Connect identity provider using
+// the authorization code flow with PKCE
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned for ID tokens that fail verification
+	ErrInvalidToken = errors.New("invalid id token")
+	// ErrRejected is returned when the provider answers with an OAuth
+	// error, such as invalid_grant for a used or expired code
+	ErrRejected = errors.New("rejected by the identity provider")
+)
+
+// Config identifies the client at an identity provider
+type Config struct {
+	Issuer       string // Discovery is read from {Issuer}/.well-known/openid-configuration
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // openid is always requested
+	Client       *http.Client
+}
+
+// Claims are the claims of a verified ID token merged with the userinfo
+// response
+type Claims map[string]any
+
+// String returns a string claim, empty when missing or of another type
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Bool returns a boolean claim; some providers send "true" as a string
+func (c Claims) Bool(name string) bool {
+	switch v := c[name].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// Tokens are the tokens returned for an authorization code
+type Tokens struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Connect identity provider. Its discovery document
+// and signing keys are fetched on first use and the keys again whenever a
+// token names one that is not known yet.
+type Provider struct {
+	config Config
+
+	mu   sync.Mutex
+	meta *discovery
+	keys *keySet
+}
+
+func NewProvider(config Config) *Provider {
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{config: config}
+}
+
+// AuthURL returns the authorization endpoint URL to send the browser to.
+// state and nonce are echoed back; codeChallenge is the S256 PKCE challenge.
+func (p *Provider) AuthURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	scopes := []string{"openid"}
+	for _, scope := range p.config.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange trades an authorization code and its PKCE verifier for tokens
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*Tokens, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	var tokens Tokens
+	if err := p.do(req, &tokens); err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response carries no id_token")
+	}
+	return &tokens, nil
+}
+
+// UserInfo returns the claims of the userinfo endpoint, nil if the provider
+// has none
+func (p *Provider) UserInfo(ctx context.Context, accessToken string) (Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil || meta.UserInfoEndpoint == "" {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.UserInfoEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	var claims Claims
+	if err := p.do(req, &claims); err != nil {
+		return nil, fmt.Errorf("userinfo request failed: %w", err)
+	}
+	return claims, nil
+}
+
+// Login completes a sign-in: it exchanges the code, verifies the ID token
+// against nonce and merges in the userinfo claims for the same subject
+func (p *Provider) Login(ctx context.Context, code, codeVerifier, nonce string) (Claims, error) {
+	tokens, err := p.Exchange(ctx, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := p.Verify(ctx, tokens.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if tokens.AccessToken == "" {
+		return claims, nil
+	}
+	info, err := p.UserInfo(ctx, tokens.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	if info.String("sub") == claims.String("sub") {
+		for name, value := range info {
+			if _, ok := claims[name]; !ok {
+				claims[name] = value
+			}
+		}
+	}
+	return claims, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var meta discovery
+	if err := p.do(req, &meta); err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", meta.Issuer, p.config.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: authorization, token or jwks endpoint missing")
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// do sends req and decodes a JSON response into out
+func (p *Provider) do(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		if body.Error != "" {
+			return fmt.Errorf("%w: %s %s", ErrRejected, body.Error, body.Description)
+		}
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
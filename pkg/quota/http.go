@@ -0,0 +1,60 @@
+package quota
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"github.com/ilhamosaurus/sns-platform/pkg/logger"
+)
+
+// Response headers describing the last quota consumed by a request
+const (
+	HeaderAction    = "X-Quota-Action"
+	HeaderLimit     = "X-Quota-Limit"
+	HeaderRemaining = "X-Quota-Remaining"
+	HeaderReset     = "X-Quota-Reset" // Unix seconds
+)
+
+type headerKey struct{}
+
+// Middleware lets Consume report quota state in the response headers of
+// the request it runs in
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), headerKey{}, w.Header())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// report sets the quota headers of the request in ctx, if any; handlers
+// write their response after the action, so the headers are still open
+func report(ctx context.Context, state State) {
+	header, ok := ctx.Value(headerKey{}).(http.Header)
+	if !ok {
+		return
+	}
+	header.Set(HeaderAction, string(state.Action))
+	header.Set(HeaderLimit, strconv.FormatInt(state.Limit, 10))
+	header.Set(HeaderRemaining, strconv.FormatInt(state.Remaining, 10))
+	header.Set(HeaderReset, strconv.FormatInt(state.ResetAt.Unix(), 10))
+}
+
+// Register mounts GET /me/quota, which returns the caller's remaining quota;
+// it expects an authenticated user in the request context
+func (q *Quota) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /me/quota", func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := logger.UserID(r.Context())
+		if !ok {
+			httpx.Error(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+		states, err := q.Remaining(r.Context(), userID)
+		if err != nil {
+			httpx.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		httpx.JSON(w, http.StatusOK, map[string]any{"quotas": states})
+	})
+}
@@ -0,0 +1,141 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+)
+
+// Action is something a user may only do a limited number of times per window
+type Action string
+
+// Actions with a quota
+const (
+	ActionPost            Action = "posts"
+	ActionDMToNonFollower Action = "dms_to_non_followers"
+	ActionFollow          Action = "follows"
+)
+
+// Actions lists every action with a quota in the order they are reported
+var Actions = []Action{ActionPost, ActionDMToNonFollower, ActionFollow}
+
+// DefaultTier applies to users whose tier has no limits configured
+const DefaultTier = "default"
+
+const keyPrefix = "quota:"
+
+// Rule caps an action at Limit per fixed Window; a zero Limit is unlimited
+type Rule struct {
+	Limit  int64
+	Window time.Duration
+}
+
+// Tier holds the rules of one class of users
+type Tier map[Action]Rule
+
+// Config holds the tiers by name
+type Config struct {
+	Tiers map[string]Tier
+}
+
+// TierFunc names the tier of a user
+type TierFunc func(ctx context.Context, userID int64) (string, error)
+
+// State is a user's standing against one action's quota
+type State struct {
+	Action    Action    `json:"action"`
+	Limit     int64     `json:"limit"`
+	Remaining int64     `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// Quota enforces per-user caps on actions
+type Quota struct {
+	store  Store
+	config Config
+	tierOf TierFunc
+}
+
+// New creates a Quota counting in store; tierOf may be nil to put every user in DefaultTier
+func New(store Store, config Config, tierOf TierFunc) *Quota {
+	if tierOf == nil {
+		tierOf = func(context.Context, int64) (string, error) { return DefaultTier, nil }
+	}
+	return &Quota{store: store, config: config, tierOf: tierOf}
+}
+
+// Consume charges one use of action to the user. Once the window's limit is
+// reached it returns an error of kind apperr.ErrTooMany without charging. The
+// resulting state is also reported through the request's quota headers. A
+// nil Quota allows everything.
+func (q *Quota) Consume(ctx context.Context, userID int64, action Action) (State, error) {
+	if q == nil {
+		return State{Action: action}, nil
+	}
+	rule, err := q.rule(ctx, userID, action)
+	if err != nil || rule.Limit <= 0 {
+		return State{Action: action}, err
+	}
+
+	key, resetAt := q.window(userID, action, rule, time.Now())
+	used, ok, err := q.store.Take(ctx, key, rule.Limit, time.Until(resetAt))
+	if err != nil {
+		return State{}, fmt.Errorf("failed to consume %s quota: %w", action, err)
+	}
+	state := State{Action: action, Limit: rule.Limit, Remaining: max(rule.Limit-used, 0), ResetAt: resetAt}
+	report(ctx, state)
+	if !ok {
+		return state, apperr.TooMany(fmt.Sprintf("%s quota exceeded, resets at %s", action, resetAt.Format(time.RFC3339)))
+	}
+	return state, nil
+}
+
+// Remaining returns the user's state for every action with a limit
+func (q *Quota) Remaining(ctx context.Context, userID int64) ([]State, error) {
+	if q == nil {
+		return nil, nil
+	}
+	now := time.Now()
+	var states []State
+	for _, action := range Actions {
+		rule, err := q.rule(ctx, userID, action)
+		if err != nil {
+			return nil, err
+		}
+		if rule.Limit <= 0 {
+			continue
+		}
+		key, resetAt := q.window(userID, action, rule, now)
+		used, err := q.store.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s quota: %w", action, err)
+		}
+		states = append(states, State{Action: action, Limit: rule.Limit, Remaining: max(rule.Limit-used, 0), ResetAt: resetAt})
+	}
+	return states, nil
+}
+
+// rule returns the user's rule for action from their tier, or DefaultTier
+// when the tier has none
+func (q *Quota) rule(ctx context.Context, userID int64, action Action) (Rule, error) {
+	name, err := q.tierOf(ctx, userID)
+	if err != nil {
+		return Rule{}, fmt.Errorf("failed to resolve quota tier: %w", err)
+	}
+	if rule, ok := q.config.Tiers[name][action]; ok {
+		return rule, nil
+	}
+	return q.config.Tiers[DefaultTier][action], nil
+}
+
+// window returns the counter key of the fixed window containing now and
+// when that window ends. Windows are aligned to UTC, so daily quotas reset at
+// midnight UTC.
+func (q *Quota) window(userID int64, action Action, rule Rule, now time.Time) (string, time.Time) {
+	start := now.UTC().Truncate(rule.Window)
+	key := keyPrefix + string(action) + ":" + strconv.FormatInt(userID, 10) + ":" + strconv.FormatInt(start.Unix(), 10)
+	return key, start.Add(rule.Window)
+}
@@ -0,0 +1,119 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store keeps the per-window usage counters
+type Store interface {
+	// Take increments key unless it already reached limit and returns the
+	// count afterwards and whether it was incremented. A new key expires
+	// after ttl.
+	Take(ctx context.Context, key string, limit int64, ttl time.Duration) (int64, bool, error)
+	// Get returns the count of key, zero if it does not exist
+	Get(ctx context.Context, key string) (int64, error)
+}
+
+// NewStore returns a Redis store shared by every instance, or an in-process
+// store when client is nil
+func NewStore(client *redis.Client) Store {
+	if client == nil {
+		return NewMemoryStore()
+	}
+	return NewRedisStore(client)
+}
+
+// takeScript increments KEYS[1] below the limit ARGV[1], setting the TTL ARGV[2]
+// in milliseconds on first use; it returns {count, taken}
+var takeScript = redis.NewScript(`
+local count = tonumber(redis.call("GET", KEYS[1]) or "0")
+if count >= tonumber(ARGV[1]) then
+	return {count, 0}
+end
+count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return {count, 1}
+`)
+
+// RedisStore counts in Redis so limits hold across instances
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit int64, ttl time.Duration) (int64, bool, error) {
+	res, err := takeScript.Run(ctx, s.client, []string{key}, limit, max(ttl.Milliseconds(), 1)).Int64Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	return res[0], res[1] == 1, nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (int64, error) {
+	n, err := s.client.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
+// MemoryStore counts in process memory, for single-instance deployments
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*memoryCounter
+}
+
+type memoryCounter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]*memoryCounter)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit int64, ttl time.Duration) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	c := s.counters[key]
+	if c == nil || !now.Before(c.expiresAt) {
+		s.sweep(now)
+		c = &memoryCounter{expiresAt: now.Add(ttl)}
+		s.counters[key] = c
+	}
+	if c.count >= limit {
+		return c.count, false, nil
+	}
+	c.count++
+	return c.count, true, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c := s.counters[key]; c != nil && time.Now().Before(c.expiresAt) {
+		return c.count, nil
+	}
+	return 0, nil
+}
+
+// sweep drops expired counters; it runs whenever a window starts
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, c := range s.counters {
+		if !now.Before(c.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+}
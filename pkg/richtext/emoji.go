@@ -0,0 +1,109 @@
+package richtext
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+)
+
+// shortcodeFormat is what a custom emoji's shortcode may look like; the
+// one-letter codes are left to the built-in set
+var shortcodeFormat = regexp.MustCompile(`^[a-z0-9_+-]{2,32}$`)
+
+// ValidShortcode reports whether code, without its colons, can name a
+// custom emoji: 2 to 32 lowercase letters, digits, '_', '+' or '-'
+func ValidShortcode(code string) bool {
+	return shortcodeFormat.MatchString(code)
+}
+
+// BuiltinEmoji maps the shortcodes every deployment has to their emoji; it
+// is a common subset of the codes chat apps accept, so pasted text mostly
+// renders the same here
+var BuiltinEmoji = map[string]string{
+	"+1":               "👍",
+	"-1":               "👎",
+	"100":              "💯",
+	"angry":            "😠",
+	"astonished":       "😲",
+	"balloon":          "🎈",
+	"beers":            "🍻",
+	"blush":            "😊",
+	"boom":             "💥",
+	"broken_heart":     "💔",
+	"bulb":             "💡",
+	"cake":             "🍰",
+	"camera":           "📷",
+	"cat":              "🐱",
+	"check":            "✔️",
+	"clap":             "👏",
+	"coffee":           "☕",
+	"confused":         "😕",
+	"cool":             "🆒",
+	"cry":              "😢",
+	"dog":              "🐶",
+	"eyes":             "👀",
+	"facepalm":         "🤦",
+	"fire":             "🔥",
+	"flushed":          "😳",
+	"gift":             "🎁",
+	"grimacing":        "😬",
+	"grin":             "😁",
+	"grinning":         "😀",
+	"heart":            "❤️",
+	"heart_eyes":       "😍",
+	"hugs":             "🤗",
+	"hushed":           "😯",
+	"innocent":         "😇",
+	"joy":              "😂",
+	"kiss":             "😘",
+	"laughing":         "😆",
+	"muscle":           "💪",
+	"neutral_face":     "😐",
+	"ok_hand":          "👌",
+	"open_mouth":       "😮",
+	"party":            "🥳",
+	"pensive":          "😔",
+	"point_up":         "☝️",
+	"pray":             "🙏",
+	"rage":             "😡",
+	"raised_hands":     "🙌",
+	"relieved":         "😌",
+	"rocket":           "🚀",
+	"rofl":             "🤣",
+	"rose":             "🌹",
+	"scream":           "😱",
+	"see_no_evil":      "🙈",
+	"shrug":            "🤷",
+	"sleeping":         "😴",
+	"slightly_smiling": "🙂",
+	"smile":            "😄",
+	"smiley":           "😃",
+	"smirk":            "😏",
+	"sob":              "😭",
+	"sparkles":         "✨",
+	"star":             "⭐",
+	"star_struck":      "🤩",
+	"sunglasses":       "😎",
+	"sunny":            "☀️",
+	"sweat_smile":      "😅",
+	"tada":             "🎉",
+	"thinking":         "🤔",
+	"thumbsdown":       "👎",
+	"thumbsup":         "👍",
+	"trophy":           "🏆",
+	"unamused":         "😒",
+	"upside_down":      "🙃",
+	"warning":          "⚠️",
+	"wave":             "👋",
+	"weary":            "😩",
+	"wink":             "😉",
+	"x":                "❌",
+	"yum":              "😋",
+	"zap":              "⚡",
+	"zzz":              "💤",
+}
+
+// BuiltinShortcodes returns the shortcodes of BuiltinEmoji in order
+func BuiltinShortcodes() []string {
+	return slices.Sorted(maps.Keys(BuiltinEmoji))
+}
@@ -0,0 +1,257 @@
+// Package richtext turns user-written text into safe HTML. Markup users type
+// is stripped, everything left is escaped, and only the markup Render adds
+// itself reaches the output: paragraphs, line breaks, links to URLs, mentions
+// and hashtags and, when enabled, a small markdown subset (**bold**,
+// *italic*, `code` and [links](https://example.com)).
+package richtext
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Options selects what Render turns into markup
+type Options struct {
+	Markdown   bool   // Render the markdown subset; otherwise it is left as typed
+	MentionURL string // Link of a mention, with %s for the username
+	HashtagURL string // Link of a hashtag, with %s for the tag
+
+	// CustomEmoji returns the image URL of a shortcode BuiltinEmoji lacks;
+	// nil leaves only the built-in emoji
+	CustomEmoji func(shortcode string) (url string, ok bool)
+}
+
+var DefaultOptions = Options{
+	MentionURL: "/users/%s",
+	HashtagURL: "/hashtags/%s",
+}
+
+var options atomic.Pointer[Options]
+
+// Configure sets the options Render uses from now on
+func Configure(o Options) {
+	if o.MentionURL == "" {
+		o.MentionURL = DefaultOptions.MentionURL
+	}
+	if o.HashtagURL == "" {
+		o.HashtagURL = DefaultOptions.HashtagURL
+	}
+	options.Store(&o)
+}
+
+func current() Options {
+	if o := options.Load(); o != nil {
+		return *o
+	}
+	return DefaultOptions
+}
+
+var (
+	// dangerousElement matches an element whose content must go with its tags
+	dangerousElement = regexp.MustCompile(`(?is)<(script|style|iframe|object|embed|template|noscript|textarea|title|xmp|svg|math)\b[^>]*>.*?</(?:script|style|iframe|object|embed|template|noscript|textarea|title|xmp|svg|math)\s*>`)
+	// tag matches an HTML tag or comment; a lone "<" as in "a < b" or "<3" is
+	// left alone
+	tag = regexp.MustCompile(`(?s)<!--.*?-->|</?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?>`)
+
+	paragraphBreak = regexp.MustCompile(`\n[ \t]*\n\s*`)
+)
+
+const (
+	// markdownPattern matches the markdown subset; it is tried before the
+	// links so URLs inside markdown links and code stay as written
+	markdownPattern = "`(?P<code>[^`\\n]+)`" +
+		`|\[(?P<label>[^\]\n]+)\]\((?P<href>https?://[^\s()<>]+)\)` +
+		`|\*\*(?P<bold>[^*\n]+)\*\*` +
+		`|\*(?P<em>[^*\s](?:[^*\n]*[^*\s])?)\*`
+	linkPattern = `(?P<url>https?://[^\s<>"]+)` +
+		`|@(?P<mention>\w+)` +
+		`|#(?P<hashtag>[\p{L}\p{N}_]*\p{L}[\p{L}\p{N}_]*)` +
+		`|:(?P<emoji>[a-z0-9_+-]+):`
+)
+
+// pattern is a regexp with the indexes of its named groups
+type pattern struct {
+	*regexp.Regexp
+	groups map[string]int
+}
+
+func compile(expr string) pattern {
+	p := pattern{regexp.MustCompile(expr), make(map[string]int)}
+	for i, name := range p.SubexpNames() {
+		if name != "" {
+			p.groups[name] = i
+		}
+	}
+	return p
+}
+
+var (
+	withMarkdown = compile(markdownPattern + "|" + linkPattern)
+	linksOnly    = compile(linkPattern)
+)
+
+// Sanitize strips the HTML tags and comments from text, dropping scripts,
+// styles and embedded frames with their content
+func Sanitize(text string) string {
+	text = dangerousElement.ReplaceAllString(text, "")
+	return tag.ReplaceAllString(text, "")
+}
+
+// Render returns text as HTML, sanitized and with its URLs, mentions and
+// hashtags linked; blank lines separate paragraphs
+func Render(text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(Sanitize(text), "\r\n", "\n"))
+	if text == "" {
+		return ""
+	}
+	r := renderer{current()}
+
+	var b strings.Builder
+	for _, paragraph := range paragraphBreak.Split(text, -1) {
+		b.WriteString("<p>")
+		for i, line := range strings.Split(paragraph, "\n") {
+			if i > 0 {
+				b.WriteString("<br>")
+			}
+			r.inline(&b, line, true)
+		}
+		b.WriteString("</p>")
+	}
+	return b.String()
+}
+
+type renderer struct {
+	Options
+}
+
+// inline writes one line of text, formatted and linked; links are only
+// added when linkable, so link labels do not nest links
+func (r renderer) inline(b *strings.Builder, text string, linkable bool) {
+	p := linksOnly
+	if r.Markdown {
+		p = withMarkdown
+	}
+
+	last := 0
+	for _, m := range p.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[0], m[1]
+		sub := func(name string) (string, bool) {
+			i, ok := p.groups[name]
+			if !ok || m[2*i] < 0 {
+				return "", false
+			}
+			return text[m[2*i]:m[2*i+1]], true
+		}
+		prev, _ := utf8.DecodeLastRuneInString(text[:start])
+		b.WriteString(html.EscapeString(text[last:start]))
+		last = end
+
+		if code, ok := sub("code"); ok {
+			b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+			continue
+		}
+		if label, ok := sub("label"); ok {
+			href, _ := sub("href")
+			r.link(b, href, func() { r.inline(b, label, false) }, linkable)
+			continue
+		}
+		if bold, ok := sub("bold"); ok {
+			b.WriteString("<strong>")
+			r.inline(b, bold, linkable)
+			b.WriteString("</strong>")
+			continue
+		}
+		if em, ok := sub("em"); ok {
+			// Asterisks inside a word, as in 2*3*4, are not emphasis
+			if isWord(prev) {
+				b.WriteString("*")
+				r.inline(b, em, linkable)
+				b.WriteString("*")
+				continue
+			}
+			b.WriteString("<em>")
+			r.inline(b, em, linkable)
+			b.WriteString("</em>")
+			continue
+		}
+		if raw, ok := sub("url"); ok {
+			href, rest := trimURL(raw)
+			r.link(b, href, func() { b.WriteString(html.EscapeString(href)) }, linkable)
+			b.WriteString(html.EscapeString(rest))
+			continue
+		}
+		if name, ok := sub("mention"); ok && linkable && !isWord(prev) && prev != '@' {
+			href := fmt.Sprintf(r.MentionURL, url.PathEscape(name))
+			b.WriteString(`<a class="mention" href="` + html.EscapeString(href) + `">@` + html.EscapeString(name) + "</a>")
+			continue
+		}
+		if tag, ok := sub("hashtag"); ok && linkable && !isWord(prev) && prev != '&' && prev != '#' {
+			href := fmt.Sprintf(r.HashtagURL, url.PathEscape(tag))
+			b.WriteString(`<a class="hashtag" href="` + html.EscapeString(href) + `">#` + html.EscapeString(tag) + "</a>")
+			continue
+		}
+		if code, ok := sub("emoji"); ok && r.emoji(b, code) {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[start:end]))
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+}
+
+// link writes an external link to href around the label written by label,
+// or just the label when not linkable
+func (r renderer) link(b *strings.Builder, href string, label func(), linkable bool) {
+	if !linkable {
+		label()
+		return
+	}
+	b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow noopener ugc" target="_blank">`)
+	label()
+	b.WriteString("</a>")
+}
+
+// emoji writes the emoji of a shortcode, reporting false when it has none;
+// built-in emoji win over custom ones of the same name
+func (r renderer) emoji(b *strings.Builder, code string) bool {
+	if e, ok := BuiltinEmoji[code]; ok {
+		b.WriteString(e)
+		return true
+	}
+	if r.CustomEmoji == nil {
+		return false
+	}
+	src, ok := r.CustomEmoji(code)
+	if !ok {
+		return false
+	}
+	name := html.EscapeString(":" + code + ":")
+	b.WriteString(`<img class="emoji" src="` + html.EscapeString(src) + `" alt="` + name + `" title="` + name + `">`)
+	return true
+}
+
+// trimURL splits the punctuation that ends a sentence, or a closing
+// parenthesis the URL did not open, off the end of a URL
+func trimURL(raw string) (link, rest string) {
+	link = raw
+	for link != "" {
+		last := link[len(link)-1]
+		switch {
+		case strings.ContainsRune(".,:;!?'\"", rune(last)):
+		case last == ')' && strings.Count(link, "(") < strings.Count(link, ")"):
+		default:
+			return link, raw[len(link):]
+		}
+		link = link[:len(link)-1]
+	}
+	return link, raw
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
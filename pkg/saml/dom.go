@@ -0,0 +1,338 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// element is a namespace-aware XML element that keeps the prefixes of the
+// document, as exclusive canonicalization needs them
+type element struct {
+	Space  string // Namespace URI
+	Local  string
+	Prefix string
+	Attrs  []attr
+	Nodes  []node
+	Parent *element
+
+	ns map[string]string // Declarations on this element, by prefix
+}
+
+type attr struct {
+	Space  string
+	Local  string
+	Prefix string
+	Value  string
+}
+
+// node is a child of an element: *element, charData or procInst
+type node any
+
+type charData string
+
+type procInst struct {
+	Target string
+	Inst   string
+}
+
+// parse reads a document into its root element. Documents with a DTD are
+// rejected.
+func parse(data []byte) (*element, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var root, current *element
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &element{Prefix: t.Name.Space, Local: t.Name.Local, Parent: current, ns: map[string]string{}}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					e.ns[""] = a.Value
+				case a.Name.Space == "xmlns":
+					e.ns[a.Name.Local] = a.Value
+				default:
+					e.Attrs = append(e.Attrs, attr{Prefix: a.Name.Space, Local: a.Name.Local, Value: a.Value})
+				}
+			}
+			var ok bool
+			if e.Space, ok = e.lookup(e.Prefix); !ok {
+				return nil, fmt.Errorf("undeclared namespace prefix %q", e.Prefix)
+			}
+			for i := range e.Attrs {
+				if e.Attrs[i].Prefix == "" {
+					continue
+				}
+				if e.Attrs[i].Space, ok = e.lookup(e.Attrs[i].Prefix); !ok {
+					return nil, fmt.Errorf("undeclared namespace prefix %q", e.Attrs[i].Prefix)
+				}
+			}
+			if current == nil {
+				if root != nil {
+					return nil, errors.New("more than one root element")
+				}
+				root = e
+			} else {
+				current.Nodes = append(current.Nodes, e)
+			}
+			current = e
+		case xml.EndElement:
+			if current == nil {
+				return nil, errors.New("unbalanced end element")
+			}
+			current = current.Parent
+		case xml.CharData:
+			if current != nil {
+				current.Nodes = append(current.Nodes, charData(t))
+			}
+		case xml.ProcInst:
+			if current != nil {
+				current.Nodes = append(current.Nodes, procInst{Target: t.Target, Inst: string(t.Inst)})
+			}
+		case xml.Directive:
+			return nil, errors.New("documents with a DTD are not accepted")
+		}
+	}
+	if root == nil || current != nil {
+		return nil, errors.New("incomplete document")
+	}
+	return root, nil
+}
+
+// lookup resolves a prefix in scope at e
+func (e *element) lookup(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return xmlNamespace, true
+	}
+	for el := e; el != nil; el = el.Parent {
+		if uri, ok := el.ns[prefix]; ok {
+			return uri, true
+		}
+	}
+	return "", prefix == ""
+}
+
+// inScope returns every namespace declaration in scope at e
+func (e *element) inScope() map[string]string {
+	scope := map[string]string{}
+	for el := e; el != nil; el = el.Parent {
+		for prefix, uri := range el.ns {
+			if _, ok := scope[prefix]; !ok {
+				scope[prefix] = uri
+			}
+		}
+	}
+	return scope
+}
+
+func (e *element) is(space, local string) bool {
+	return e.Space == space && e.Local == local
+}
+
+func (e *element) attr(local string) string {
+	for _, a := range e.Attrs {
+		if a.Space == "" && a.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// children returns the child elements named space and local
+func (e *element) children(space, local string) []*element {
+	var out []*element
+	for _, n := range e.Nodes {
+		if child, ok := n.(*element); ok && child.is(space, local) {
+			out = append(out, child)
+		}
+	}
+	return out
+}
+
+// child returns the only child element named space and local, nil if there
+// is none or more than one
+func (e *element) child(space, local string) *element {
+	if c := e.children(space, local); len(c) == 1 {
+		return c[0]
+	}
+	return nil
+}
+
+// text returns the character data directly inside e
+func (e *element) text() string {
+	var b strings.Builder
+	for _, n := range e.Nodes {
+		if c, ok := n.(charData); ok {
+			b.WriteString(string(c))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// walk calls fn for e and every element below it
+func (e *element) walk(fn func(*element)) {
+	fn(e)
+	for _, n := range e.Nodes {
+		if child, ok := n.(*element); ok {
+			child.walk(fn)
+		}
+	}
+}
+
+// canonicalize renders e with Exclusive XML Canonicalization without
+// comments (https://www.w3.org/TR/xml-exc-c14n/), leaving out skip and its
+// subtree. Prefixes in inclusive are treated as by inclusive
+// canonicalization.
+func canonicalize(e *element, skip *element, inclusive []string) []byte {
+	var b bytes.Buffer
+	c14n(&b, e, skip, map[string]string{}, inclusive)
+	return b.Bytes()
+}
+
+func c14n(b *bytes.Buffer, e, skip *element, rendered map[string]string, inclusive []string) {
+	// Namespaces visibly utilized by the element and its attributes, plus
+	// the inclusive ones in scope
+	used := map[string]string{e.Prefix: e.Space}
+	for _, a := range e.Attrs {
+		if a.Prefix != "" {
+			used[a.Prefix] = a.Space
+		}
+	}
+	scope := e.inScope()
+	for _, prefix := range inclusive {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		if uri, ok := scope[prefix]; ok {
+			used[prefix] = uri
+		}
+	}
+
+	var decls []string
+	next := rendered
+	for prefix, uri := range used {
+		if prefix == "xml" {
+			continue
+		}
+		if have, ok := rendered[prefix]; ok && have == uri {
+			continue
+		}
+		// An empty default namespace is only declared to undo one in output
+		if prefix == "" && uri == "" {
+			if have, ok := rendered[""]; !ok || have == "" {
+				continue
+			}
+		}
+		if len(decls) == 0 {
+			next = make(map[string]string, len(rendered)+len(used))
+			for k, v := range rendered {
+				next[k] = v
+			}
+		}
+		next[prefix] = uri
+		decls = append(decls, prefix)
+	}
+	sort.Strings(decls)
+
+	attrs := append([]attr(nil), e.Attrs...)
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].Space != attrs[j].Space {
+			return attrs[i].Space < attrs[j].Space
+		}
+		return attrs[i].Local < attrs[j].Local
+	})
+
+	b.WriteByte('<')
+	b.WriteString(qualified(e.Prefix, e.Local))
+	for _, prefix := range decls {
+		if prefix == "" {
+			b.WriteString(` xmlns="`)
+		} else {
+			b.WriteString(` xmlns:` + prefix + `="`)
+		}
+		escapeAttr(b, next[prefix])
+		b.WriteByte('"')
+	}
+	for _, a := range attrs {
+		b.WriteString(" " + qualified(a.Prefix, a.Local) + `="`)
+		escapeAttr(b, a.Value)
+		b.WriteByte('"')
+	}
+	b.WriteByte('>')
+
+	for _, n := range e.Nodes {
+		switch v := n.(type) {
+		case *element:
+			if v != skip {
+				c14n(b, v, skip, next, inclusive)
+			}
+		case charData:
+			escapeText(b, string(v))
+		case procInst:
+			b.WriteString("<?" + v.Target)
+			if v.Inst != "" {
+				b.WriteString(" " + v.Inst)
+			}
+			b.WriteString("?>")
+		}
+	}
+	b.WriteString("</" + qualified(e.Prefix, e.Local) + ">")
+}
+
+func qualified(prefix, local string) string {
+	if prefix == "" {
+		return local
+	}
+	return prefix + ":" + local
+}
+
+func escapeText(b *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '\r':
+			b.WriteString("&#xD;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func escapeAttr(b *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '"':
+			b.WriteString("&quot;")
+		case '\t':
+			b.WriteString("&#x9;")
+		case '\n':
+			b.WriteString("&#xA;")
+		case '\r':
+			b.WriteString("&#xD;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
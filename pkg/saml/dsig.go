@@ -0,0 +1,151 @@
+package saml
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// XML Signature namespaces and algorithms
+const (
+	nsDSig      = "http://www.w3.org/2000/09/xmldsig#"
+	nsExcC14N   = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnvelope = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+)
+
+var signatureAlgorithms = map[string]struct {
+	hash  crypto.Hash
+	ecdsa bool
+}{
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha256":   {crypto.SHA256, false},
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha384":   {crypto.SHA384, false},
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha512":   {crypto.SHA512, false},
+	"http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256": {crypto.SHA256, true},
+	"http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha384": {crypto.SHA384, true},
+	"http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha512": {crypto.SHA512, true},
+}
+
+var digestAlgorithms = map[string]crypto.Hash{
+	"http://www.w3.org/2001/04/xmlenc#sha256":       crypto.SHA256,
+	"http://www.w3.org/2001/04/xmldsig-more#sha384": crypto.SHA384,
+	"http://www.w3.org/2001/04/xmlenc#sha512":       crypto.SHA512,
+}
+
+// ErrUnsigned is returned when an element carries no signature
+var ErrUnsigned = errors.New("element is not signed")
+
+// verifySignature checks the enveloped signature directly inside e. The
+// signature must cover e itself, by its ID, with exclusive
+// canonicalization; SHA-1 is not accepted.
+func verifySignature(e *element, certs []*x509.Certificate) error {
+	signatures := e.children(nsDSig, "Signature")
+	if len(signatures) == 0 {
+		return ErrUnsigned
+	}
+	if len(signatures) > 1 {
+		return errors.New("more than one signature")
+	}
+	sig := signatures[0]
+	signedInfo := sig.child(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return errors.New("signature has no SignedInfo")
+	}
+
+	method := signedInfo.child(nsDSig, "CanonicalizationMethod")
+	if method == nil || method.attr("Algorithm") != nsExcC14N {
+		return errors.New("signature must use exclusive canonicalization")
+	}
+	sigMethod := signedInfo.child(nsDSig, "SignatureMethod")
+	if sigMethod == nil {
+		return errors.New("signature has no SignatureMethod")
+	}
+	alg, ok := signatureAlgorithms[sigMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("unsupported signature algorithm %q", sigMethod.attr("Algorithm"))
+	}
+
+	ref := signedInfo.child(nsDSig, "Reference")
+	if ref == nil {
+		return errors.New("signature must have exactly one reference")
+	}
+	if id := e.attr("ID"); id == "" || ref.attr("URI") != "#"+id {
+		return errors.New("signature does not reference the signed element")
+	}
+	var inclusive []string
+	if transforms := ref.child(nsDSig, "Transforms"); transforms != nil {
+		for _, t := range transforms.children(nsDSig, "Transform") {
+			switch t.attr("Algorithm") {
+			case algEnvelope:
+			case nsExcC14N:
+				if list := t.child(nsExcC14N, "InclusiveNamespaces"); list != nil {
+					inclusive = strings.Fields(list.attr("PrefixList"))
+				}
+			default:
+				return fmt.Errorf("unsupported transform %q", t.attr("Algorithm"))
+			}
+		}
+	}
+	digestMethod := ref.child(nsDSig, "DigestMethod")
+	if digestMethod == nil {
+		return errors.New("reference has no DigestMethod")
+	}
+	digestHash, ok := digestAlgorithms[digestMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("unsupported digest algorithm %q", digestMethod.attr("Algorithm"))
+	}
+	digestValue := ref.child(nsDSig, "DigestValue")
+	if digestValue == nil {
+		return errors.New("reference has no DigestValue")
+	}
+	wantDigest, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(digestValue.text()), ""))
+	if err != nil {
+		return errors.New("malformed DigestValue")
+	}
+
+	h := digestHash.New()
+	h.Write(canonicalize(e, sig, inclusive))
+	if !bytes.Equal(h.Sum(nil), wantDigest) {
+		return errors.New("digest mismatch")
+	}
+
+	var signedInfoInclusive []string
+	if list := method.child(nsExcC14N, "InclusiveNamespaces"); list != nil {
+		signedInfoInclusive = strings.Fields(list.attr("PrefixList"))
+	}
+	valueElem := sig.child(nsDSig, "SignatureValue")
+	if valueElem == nil {
+		return errors.New("signature has no SignatureValue")
+	}
+	value, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(valueElem.text()), ""))
+	if err != nil {
+		return errors.New("malformed SignatureValue")
+	}
+	h = alg.hash.New()
+	h.Write(canonicalize(signedInfo, nil, signedInfoInclusive))
+	digest := h.Sum(nil)
+
+	for _, cert := range certs {
+		switch key := cert.PublicKey.(type) {
+		case *rsa.PublicKey:
+			if !alg.ecdsa && rsa.VerifyPKCS1v15(key, alg.hash, digest, value) == nil {
+				return nil
+			}
+		case *ecdsa.PublicKey:
+			size := (key.Curve.Params().BitSize + 7) / 8
+			if alg.ecdsa && len(value) == 2*size {
+				r, s := new(big.Int).SetBytes(value[:size]), new(big.Int).SetBytes(value[size:])
+				if ecdsa.Verify(key, digest, r, s) {
+					return nil
+				}
+			}
+		}
+	}
+	return errors.New("signature does not verify against the identity provider certificate")
+}
@@ -0,0 +1,315 @@
+// Package saml is a SAML 2.0 service provider for SP-initiated web browser
+// single sign-on: AuthnRequests go out over the HTTP-Redirect binding and
+// signed Responses come back over HTTP-POST
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SAML namespaces, bindings and status codes
+const (
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	bindingPOST   = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	statusSuccess = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	methodBearer  = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+
+	// NameIDFormatEmail asks the identity provider for the email address
+	NameIDFormatEmail = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+)
+
+// clockSkew is the leeway allowed on assertion times
+const clockSkew = 2 * time.Minute
+
+// ErrInvalidResponse wraps every reason a response is rejected
+var ErrInvalidResponse = errors.New("invalid saml response")
+
+// Config describes the service provider and the identity provider it trusts
+type Config struct {
+	EntityID       string // This service provider
+	ACSURL         string // Where the identity provider posts responses
+	IDPEntityID    string
+	IDPSSOURL      string // The identity provider's HTTP-Redirect SSO endpoint
+	IDPCertificate string // PEM; concatenate several to allow for rotation
+	NameIDFormat   string // Optional
+}
+
+// ServiceProvider signs users in with one identity provider
+type ServiceProvider struct {
+	config Config
+	certs  []*x509.Certificate
+}
+
+func New(config Config) (*ServiceProvider, error) {
+	var certs []*x509.Certificate
+	rest := []byte(config.IDPCertificate)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity provider certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no identity provider certificate")
+	}
+	return &ServiceProvider{config: config, certs: certs}, nil
+}
+
+// Assertion is who the identity provider says signed in
+type Assertion struct {
+	NameID       string
+	NameIDFormat string
+	SessionIndex string
+	Attributes   map[string][]string // By Name and by FriendlyName
+}
+
+// Attribute returns the first value of an attribute, empty for an empty name
+func (a *Assertion) Attribute(name string) string {
+	if values := a.Attributes[name]; name != "" && len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// AuthnRequestURL returns the identity provider URL to send the browser to
+// and the request ID the response has to answer
+func (sp *ServiceProvider) AuthnRequestURL(relayState string) (redirect, requestID string, err error) {
+	id := make([]byte, 20)
+	rand.Read(id)
+	requestID = "_" + hex.EncodeToString(id)
+
+	var req strings.Builder
+	fmt.Fprintf(&req, `<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s">`,
+		nsProtocol, nsAssertion, requestID, time.Now().UTC().Format(time.RFC3339),
+		escape(sp.config.IDPSSOURL), escape(sp.config.ACSURL), bindingPOST)
+	fmt.Fprintf(&req, `<saml:Issuer>%s</saml:Issuer>`, escape(sp.config.EntityID))
+	if sp.config.NameIDFormat != "" {
+		fmt.Fprintf(&req, `<samlp:NameIDPolicy Format="%s" AllowCreate="true"/>`, escape(sp.config.NameIDFormat))
+	}
+	req.WriteString(`</samlp:AuthnRequest>`)
+
+	var deflated bytes.Buffer
+	w, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	w.Write([]byte(req.String()))
+	w.Close()
+
+	q := url.Values{"SAMLRequest": {base64.StdEncoding.EncodeToString(deflated.Bytes())}}
+	if relayState != "" {
+		q.Set("RelayState", relayState)
+	}
+	sep := "?"
+	if strings.Contains(sp.config.IDPSSOURL, "?") {
+		sep = "&"
+	}
+	return sp.config.IDPSSOURL + sep + q.Encode(), requestID, nil
+}
+
+// Metadata returns the service provider metadata to register at the
+// identity provider
+func (sp *ServiceProvider) Metadata() []byte {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, `<md:EntityDescriptor xmlns:md="%s" entityID="%s">`, nsMetadata, escape(sp.config.EntityID))
+	fmt.Fprintf(&b, `<md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="%s">`, nsProtocol)
+	if sp.config.NameIDFormat != "" {
+		fmt.Fprintf(&b, `<md:NameIDFormat>%s</md:NameIDFormat>`, escape(sp.config.NameIDFormat))
+	}
+	fmt.Fprintf(&b, `<md:AssertionConsumerService Binding="%s" Location="%s" index="0" isDefault="true"/>`, bindingPOST, escape(sp.config.ACSURL))
+	b.WriteString(`</md:SPSSODescriptor></md:EntityDescriptor>`)
+	return []byte(b.String())
+}
+
+// ParseResponse verifies a base64 SAMLResponse posted to the ACS URL in
+// answer to requestID and returns its assertion. Either the response or the
+// assertion must be signed by the identity provider; encrypted assertions
+// are not supported.
+func (sp *ServiceProvider) ParseResponse(encoded, requestID string) (*Assertion, error) {
+	a, err := sp.parseResponse(encoded, requestID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	return a, nil
+}
+
+func (sp *ServiceProvider) parseResponse(encoded, requestID string, now time.Time) (*Assertion, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+	if err != nil {
+		return nil, errors.New("malformed base64")
+	}
+	root, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if !root.is(nsProtocol, "Response") {
+		return nil, errors.New("not a Response")
+	}
+
+	// Signatures reference elements by ID, so duplicates could point a
+	// verified signature at a different element than the one read
+	ids := map[string]bool{}
+	var duplicate bool
+	root.walk(func(e *element) {
+		if id := e.attr("ID"); id != "" {
+			duplicate = duplicate || ids[id]
+			ids[id] = true
+		}
+	})
+	if duplicate {
+		return nil, errors.New("duplicate IDs")
+	}
+
+	if dest := root.attr("Destination"); dest != "" && dest != sp.config.ACSURL {
+		return nil, fmt.Errorf("destination %q is not this service provider", dest)
+	}
+	if requestID == "" || root.attr("InResponseTo") != requestID {
+		return nil, errors.New("response does not answer the pending request")
+	}
+	if issuer := root.child(nsAssertion, "Issuer"); issuer != nil && issuer.text() != sp.config.IDPEntityID {
+		return nil, fmt.Errorf("unexpected issuer %q", issuer.text())
+	}
+	status := root.child(nsProtocol, "Status")
+	if status == nil {
+		return nil, errors.New("no status")
+	}
+	if code := status.child(nsProtocol, "StatusCode"); code == nil || code.attr("Value") != statusSuccess {
+		value := ""
+		if code != nil {
+			value = code.attr("Value")
+		}
+		return nil, fmt.Errorf("identity provider returned status %q", value)
+	}
+
+	if len(root.children(nsAssertion, "EncryptedAssertion")) > 0 {
+		return nil, errors.New("encrypted assertions are not supported")
+	}
+	assertion := root.child(nsAssertion, "Assertion")
+	if assertion == nil {
+		return nil, errors.New("response must carry exactly one assertion")
+	}
+
+	responseErr := verifySignature(root, sp.certs)
+	if responseErr != nil && !errors.Is(responseErr, ErrUnsigned) {
+		return nil, responseErr
+	}
+	assertionErr := verifySignature(assertion, sp.certs)
+	if assertionErr != nil && !errors.Is(assertionErr, ErrUnsigned) {
+		return nil, assertionErr
+	}
+	if responseErr != nil && assertionErr != nil {
+		return nil, errors.New("neither the response nor the assertion is signed")
+	}
+
+	return sp.readAssertion(assertion, requestID, now)
+}
+
+// readAssertion checks a verified assertion's issuer, subject confirmation
+// and conditions and reads its subject and attributes
+func (sp *ServiceProvider) readAssertion(assertion *element, requestID string, now time.Time) (*Assertion, error) {
+	issuer := assertion.child(nsAssertion, "Issuer")
+	if issuer == nil || issuer.text() != sp.config.IDPEntityID {
+		return nil, errors.New("assertion is not from the identity provider")
+	}
+
+	subject := assertion.child(nsAssertion, "Subject")
+	if subject == nil {
+		return nil, errors.New("assertion has no subject")
+	}
+	nameID := subject.child(nsAssertion, "NameID")
+	if nameID == nil || nameID.text() == "" {
+		return nil, errors.New("assertion has no NameID")
+	}
+	confirmed := false
+	for _, confirmation := range subject.children(nsAssertion, "SubjectConfirmation") {
+		data := confirmation.child(nsAssertion, "SubjectConfirmationData")
+		if confirmation.attr("Method") != methodBearer || data == nil {
+			continue
+		}
+		if data.attr("Recipient") != sp.config.ACSURL || data.attr("InResponseTo") != requestID {
+			continue
+		}
+		if notOnOrAfter, err := time.Parse(time.RFC3339, data.attr("NotOnOrAfter")); err != nil || !now.Before(notOnOrAfter.Add(clockSkew)) {
+			continue
+		}
+		confirmed = true
+	}
+	if !confirmed {
+		return nil, errors.New("no valid bearer subject confirmation")
+	}
+
+	conditions := assertion.child(nsAssertion, "Conditions")
+	if conditions == nil {
+		return nil, errors.New("assertion has no conditions")
+	}
+	if s := conditions.attr("NotBefore"); s != "" {
+		if t, err := time.Parse(time.RFC3339, s); err != nil || now.Add(clockSkew).Before(t) {
+			return nil, errors.New("assertion is not valid yet")
+		}
+	}
+	if s := conditions.attr("NotOnOrAfter"); s != "" {
+		if t, err := time.Parse(time.RFC3339, s); err != nil || !now.Before(t.Add(clockSkew)) {
+			return nil, errors.New("assertion has expired")
+		}
+	}
+	restrictions := conditions.children(nsAssertion, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return nil, errors.New("assertion has no audience restriction")
+	}
+	for _, restriction := range restrictions {
+		found := false
+		for _, audience := range restriction.children(nsAssertion, "Audience") {
+			found = found || audience.text() == sp.config.EntityID
+		}
+		if !found {
+			return nil, errors.New("assertion is not meant for this service provider")
+		}
+	}
+
+	a := &Assertion{
+		NameID:       nameID.text(),
+		NameIDFormat: nameID.attr("Format"),
+		Attributes:   map[string][]string{},
+	}
+	if authn := assertion.child(nsAssertion, "AuthnStatement"); authn != nil {
+		a.SessionIndex = authn.attr("SessionIndex")
+	}
+	for _, statement := range assertion.children(nsAssertion, "AttributeStatement") {
+		for _, attribute := range statement.children(nsAssertion, "Attribute") {
+			var values []string
+			for _, v := range attribute.children(nsAssertion, "AttributeValue") {
+				values = append(values, v.text())
+			}
+			name, friendly := attribute.attr("Name"), attribute.attr("FriendlyName")
+			a.Attributes[name] = append(a.Attributes[name], values...)
+			if friendly != "" && friendly != name {
+				a.Attributes[friendly] = append(a.Attributes[friendly], values...)
+			}
+		}
+	}
+	return a, nil
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	escapeAttr(&b, s)
+	return strings.NewReplacer(">", "&gt;").Replace(b.String())
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/lock"
+)
+
+// Task is a periodic job run by the scheduler
+type Task struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs registered tasks on fixed intervals. When a Locker is set,
+// each run is guarded by a distributed lock so only one instance executes a
+// task per interval.
+type Scheduler struct {
+	locker  *lock.Locker
+	elector *lock.Elector // When set, only its leader runs tasks
+	tasks   []Task
+	paused  func() bool // Runs are skipped while it reports true
+}
+
+func New(locker *lock.Locker) *Scheduler {
+	return &Scheduler{locker: locker}
+}
+
+// PauseWhile has the scheduler skip the runs falling due while paused
+// reports true, e.g. during maintenance
+func (s *Scheduler) PauseWhile(paused func() bool) {
+	s.paused = paused
+}
+
+// LeaderOnly has only the instance e elects run the tasks, so the others
+// skip their ticks instead of contending for every task lock. The task
+// locks still keep two instances from running a task while leadership
+// changes hands. e must be running for this instance to ever lead.
+func (s *Scheduler) LeaderOnly(e *lock.Elector) {
+	s.elector = e
+}
+
+// Register adds a task; tasks with a non-positive interval are ignored
+func (s *Scheduler) Register(task Task) {
+	if task.Interval <= 0 {
+		slog.Warn("scheduler skipping task with no interval", slog.String("task", task.Name))
+		return
+	}
+	s.tasks = append(s.tasks, task)
+}
+
+// Tasks returns the registered tasks
+func (s *Scheduler) Tasks() []Task {
+	return s.tasks
+}
+
+// Run starts every task loop and blocks until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, task := range s.tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, task)
+		}()
+	}
+	slog.InfoContext(ctx, "scheduler started", slog.Int("tasks", len(s.tasks)))
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, task Task) {
+	ticker := time.NewTicker(task.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.paused != nil && s.paused() {
+				slog.DebugContext(ctx, "scheduled task skipped while paused", slog.String("task", task.Name))
+				continue
+			}
+			if s.elector != nil && !s.elector.IsLeader() {
+				continue
+			}
+			s.RunOnce(ctx, task)
+		}
+	}
+}
+
+// RunOnce executes a task immediately, honoring the distributed lock
+func (s *Scheduler) RunOnce(ctx context.Context, task Task) {
+	start := time.Now()
+
+	var err error
+	if s.locker != nil {
+		// The lock outlives a successful run until the interval is up, so the
+		// instances whose tickers fire later in it skip the task; a crashed
+		// holder blocks it for at most one interval
+		err = s.locker.RunAndHold(ctx, "scheduler:"+task.Name, task.Interval, task.Run)
+		if errors.Is(err, lock.ErrNotAcquired) {
+			return
+		}
+	} else {
+		err = task.Run(ctx)
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "scheduled task failed", slog.String("task", task.Name), slog.Duration("duration", time.Since(start)), slog.Any("error", err))
+		return
+	}
+	slog.InfoContext(ctx, "scheduled task completed", slog.String("task", task.Name), slog.Duration("duration", time.Since(start)))
+}
@@ -0,0 +1,52 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Filter is an equality filter, attribute eq value, the only kind identity
+// providers use to look users up before provisioning them
+type Filter struct {
+	Attribute string // Lower-cased, e.g. username or emails.value
+	Value     string
+}
+
+// ParseFilter parses a filter of the form `attribute eq "value"`. An empty
+// filter parses to nil.
+func ParseFilter(s string) (*Filter, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	attribute, rest, ok := strings.Cut(s, " ")
+	if !ok {
+		return nil, invalidFilter("expected attribute eq value")
+	}
+	op, value, ok := strings.Cut(strings.TrimLeft(rest, " "), " ")
+	if !ok || !strings.EqualFold(op, "eq") {
+		return nil, invalidFilter("only the eq operator is supported")
+	}
+
+	value = strings.TrimSpace(value)
+	var parsed any
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		return nil, invalidFilter("value must be a quoted string, a number or a boolean")
+	}
+	switch v := parsed.(type) {
+	case string:
+		value = v
+	case bool, float64:
+	default:
+		return nil, invalidFilter("value must be a quoted string, a number or a boolean")
+	}
+
+	// Drop the schema URN prefix some providers qualify attributes with
+	attribute = strings.TrimPrefix(attribute, SchemaUser+":")
+	return &Filter{Attribute: strings.ToLower(attribute), Value: value}, nil
+}
+
+func invalidFilter(detail string) *Error {
+	return NewError(http.StatusBadRequest, ErrTypeInvalidFilter, detail)
+}
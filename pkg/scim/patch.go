@@ -0,0 +1,244 @@
+package scim
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// PatchRequest is the body of a PATCH request (RFC 7644 section 3.5.2)
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation adds, replaces or removes the attribute at Path. Without
+// a path, Value is an object of attributes to add or replace.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply applies the operations to u in order. Paths to attributes User
+// does not carry are ignored, as they are on create and replace.
+func (p *PatchRequest) Apply(u *User) error {
+	for _, op := range p.Operations {
+		kind := strings.ToLower(op.Op)
+		if kind != "add" && kind != "replace" && kind != "remove" {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidSyntax, "op must be add, replace or remove, got "+op.Op)
+		}
+		if op.Path != "" {
+			if err := u.patch(kind, op.Path, op.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if kind == "remove" {
+			return NewError(http.StatusBadRequest, "noTarget", "remove needs a path")
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &values); err != nil {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidValue, "value must be an object of attributes when there is no path")
+		}
+		for path, value := range values {
+			if err := u.patch(kind, path, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (u *User) patch(kind, path string, value json.RawMessage) error {
+	path = strings.TrimPrefix(path, SchemaUser+":")
+	attribute, filter, sub, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+	remove := kind == "remove"
+
+	switch attribute {
+	case "username":
+		if remove {
+			return NewError(http.StatusBadRequest, ErrTypeMutability, "userName cannot be removed")
+		}
+		return decode(value, &u.UserName)
+	case "externalid":
+		return setString(&u.ExternalID, remove, value)
+	case "displayname":
+		return setString(&u.DisplayName, remove, value)
+	case "preferredlanguage":
+		return setString(&u.PreferredLanguage, remove, value)
+	case "locale":
+		return setString(&u.Locale, remove, value)
+	case "active":
+		if remove {
+			return NewError(http.StatusBadRequest, ErrTypeMutability, "active cannot be removed")
+		}
+		var active Bool
+		if err := decode(value, &active); err != nil {
+			return err
+		}
+		u.Active = &active
+		return nil
+	case "name":
+		return u.patchName(kind, sub, value)
+	case "emails":
+		return patchValues(&u.Emails, kind, filter, sub, value)
+	case "photos":
+		return patchValues(&u.Photos, kind, filter, sub, value)
+	}
+	return nil
+}
+
+func (u *User) patchName(kind, sub string, value json.RawMessage) error {
+	if u.Name == nil {
+		u.Name = &Name{}
+	}
+	remove := kind == "remove"
+	switch sub {
+	case "":
+		if remove {
+			u.Name = nil
+			return nil
+		}
+		var name Name
+		if err := decode(value, &name); err != nil {
+			return err
+		}
+		if kind == "replace" {
+			*u.Name = name
+			return nil
+		}
+		// add merges into the existing name
+		u.Name.Formatted = cmp.Or(name.Formatted, u.Name.Formatted)
+		u.Name.GivenName = cmp.Or(name.GivenName, u.Name.GivenName)
+		u.Name.FamilyName = cmp.Or(name.FamilyName, u.Name.FamilyName)
+		return nil
+	case "formatted":
+		return setString(&u.Name.Formatted, remove, value)
+	case "givenname":
+		return setString(&u.Name.GivenName, remove, value)
+	case "familyname":
+		return setString(&u.Name.FamilyName, remove, value)
+	}
+	return nil
+}
+
+// patchValues applies an operation to a multi-valued attribute. filter
+// selects entries, as in emails[type eq "work"]; sub is the sub-attribute,
+// as in emails[type eq "work"].value.
+func patchValues(values *[]Value, kind string, filter *Filter, sub string, value json.RawMessage) error {
+	if filter == nil && sub == "" {
+		if kind == "remove" {
+			*values = nil
+			return nil
+		}
+		var entries []Value
+		if err := decode(value, &entries); err != nil {
+			var single Value
+			if decode(value, &single) != nil {
+				return err
+			}
+			entries = []Value{single}
+		}
+		if kind == "replace" {
+			*values = entries
+		} else {
+			*values = append(*values, entries...)
+		}
+		return nil
+	}
+
+	// Only values matter: the platform keeps one of each attribute
+	if sub != "" && sub != "value" {
+		return nil
+	}
+	selected := func(v Value) bool { return filter == nil || filter.matches(v) }
+	if kind == "remove" {
+		*values = slices.DeleteFunc(*values, selected)
+		return nil
+	}
+
+	var s string
+	if sub == "value" {
+		if err := decode(value, &s); err != nil {
+			return err
+		}
+	} else {
+		var entry Value
+		if err := decode(value, &entry); err != nil {
+			return err
+		}
+		s = entry.Value
+	}
+	updated := false
+	for i := range *values {
+		if selected((*values)[i]) {
+			(*values)[i].Value = s
+			updated = true
+		}
+	}
+	if !updated {
+		entry := Value{Value: s, Primary: len(*values) == 0}
+		if filter != nil && filter.Attribute == "type" {
+			entry.Type = filter.Value
+		}
+		*values = append(*values, entry)
+	}
+	return nil
+}
+
+func (f *Filter) matches(v Value) bool {
+	switch f.Attribute {
+	case "type":
+		return strings.EqualFold(v.Type, f.Value)
+	case "value":
+		return strings.EqualFold(v.Value, f.Value)
+	case "primary":
+		return (f.Value == "true") == v.Primary
+	}
+	return false
+}
+
+// splitPath splits a path such as emails[type eq "work"].value into its
+// lower-cased attribute, value filter and sub-attribute
+func splitPath(path string) (attribute string, filter *Filter, sub string, err error) {
+	if open := strings.IndexByte(path, '['); open >= 0 {
+		end := strings.LastIndexByte(path, ']')
+		if end < open {
+			return "", nil, "", NewError(http.StatusBadRequest, ErrTypeInvalidPath, "unbalanced brackets in path "+path)
+		}
+		if filter, err = ParseFilter(path[open+1 : end]); err != nil {
+			return "", nil, "", err
+		}
+		attribute, sub = path[:open], strings.TrimPrefix(path[end+1:], ".")
+	} else {
+		attribute, sub, _ = strings.Cut(path, ".")
+	}
+	return strings.ToLower(attribute), filter, strings.ToLower(sub), nil
+}
+
+func setString(dst *string, remove bool, value json.RawMessage) error {
+	if remove {
+		*dst = ""
+		return nil
+	}
+	return decode(value, dst)
+}
+
+func decode(value json.RawMessage, v any) error {
+	if err := json.Unmarshal(value, v); err != nil {
+		var e *Error
+		if errors.As(err, &e) {
+			return e
+		}
+		return NewError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid value: "+string(value))
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Package scim implements the parts of SCIM 2.0 (RFC 7643 and RFC 7644)
+// an identity provider needs to provision users: the User resource, list
+// responses, eq filters, PATCH operations and errors.
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema URIs
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+)
+
+// ContentType is the media type of SCIM requests and responses
+const ContentType = "application/scim+json"
+
+// Error types (RFC 7644 section 3.12)
+const (
+	ErrTypeInvalidFilter = "invalidFilter"
+	ErrTypeInvalidSyntax = "invalidSyntax"
+	ErrTypeInvalidPath   = "invalidPath"
+	ErrTypeInvalidValue  = "invalidValue"
+	ErrTypeUniqueness    = "uniqueness"
+	ErrTypeMutability    = "mutability"
+)
+
+// Error is a SCIM error response
+type Error struct {
+	Status int
+	Type   string // scimType, may be empty
+	Detail string
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// NewError returns an error with the given status, scimType and detail
+func NewError(status int, scimType, detail string) *Error {
+	return &Error{Status: status, Type: scimType, Detail: detail}
+}
+
+// MarshalJSON renders the error body, which carries the status as a string
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Schemas []string `json:"schemas"`
+		Status  string   `json:"status"`
+		Type    string   `json:"scimType,omitempty"`
+		Detail  string   `json:"detail"`
+	}{[]string{SchemaError}, strconv.Itoa(e.Status), e.Type, e.Detail})
+}
+
+// User is the core User resource. Attributes the platform does not store
+// are ignored on input and left out on output.
+type User struct {
+	Schemas           []string `json:"schemas"`
+	ID                string   `json:"id,omitempty"`
+	ExternalID        string   `json:"externalId,omitempty"`
+	UserName          string   `json:"userName"`
+	Name              *Name    `json:"name,omitempty"`
+	DisplayName       string   `json:"displayName,omitempty"`
+	Emails            []Value  `json:"emails,omitempty"`
+	Photos            []Value  `json:"photos,omitempty"`
+	PreferredLanguage string   `json:"preferredLanguage,omitempty"`
+	Locale            string   `json:"locale,omitempty"`
+	Active            *Bool    `json:"active,omitempty"`
+	Meta              *Meta    `json:"meta,omitempty"`
+}
+
+// Name is the components of a user's name
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// Value is an entry of a multi-valued attribute such as emails
+type Value struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Meta is the resource metadata
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+// Bool is a boolean that also accepts the strings "true" and "false" in any
+// case, as some identity providers send them
+type Bool bool
+
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch t := v.(type) {
+	case bool:
+		*b = Bool(t)
+	case string:
+		parsed, err := strconv.ParseBool(strings.ToLower(t))
+		if err != nil {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidValue, "expected a boolean, got "+strconv.Quote(t))
+		}
+		*b = Bool(parsed)
+	default:
+		return NewError(http.StatusBadRequest, ErrTypeInvalidValue, "expected a boolean")
+	}
+	return nil
+}
+
+// Primary returns the primary value of a multi-valued attribute, or the
+// first when none is marked primary
+func Primary(values []Value) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+// ListResponse is a page of query results
+type ListResponse struct {
+	TotalResults int64
+	StartIndex   int // 1-based
+	Resources    []any
+}
+
+func (l ListResponse) MarshalJSON() ([]byte, error) {
+	resources := l.Resources
+	if resources == nil {
+		resources = []any{}
+	}
+	return json.Marshal(struct {
+		Schemas      []string `json:"schemas"`
+		TotalResults int64    `json:"totalResults"`
+		StartIndex   int      `json:"startIndex"`
+		ItemsPerPage int      `json:"itemsPerPage"`
+		Resources    []any    `json:"Resources"`
+	}{[]string{SchemaListResponse}, l.TotalResults, l.StartIndex, len(resources), resources})
+}
+
+// JSON writes v with the SCIM content type
+func JSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// WriteError writes err as a SCIM error, as a 500 unless it is an *Error
+func WriteError(w http.ResponseWriter, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = NewError(http.StatusInternalServerError, "", err.Error())
+	}
+	JSON(w, e.Status, e)
+}
@@ -0,0 +1,60 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// AWSResolver reads secrets from AWS Secrets Manager. References take the
+// form "secret-id" for plain secrets or "secret-id#key" for JSON secrets.
+// Credentials and region come from the default AWS credential chain.
+type AWSResolver struct {
+	once    sync.Once
+	client  *secretsmanager.Client
+	initErr error
+}
+
+// NewAWSResolver creates a resolver that loads AWS configuration on first use
+func NewAWSResolver() *AWSResolver {
+	return &AWSResolver{}
+}
+
+func (a *AWSResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	a.once.Do(func() {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			a.initErr = fmt.Errorf("failed to load AWS configuration: %w", err)
+			return
+		}
+		a.client = secretsmanager.NewFromConfig(cfg)
+	})
+	if a.initErr != nil {
+		return "", a.initErr
+	}
+
+	id, key := splitKey(ref)
+	out, err := a.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+	if err != nil {
+		return "", err
+	}
+	secret := aws.ToString(out.SecretString)
+	if key == "" {
+		return secret, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %w", id, err)
+	}
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret %s", key, id)
+	}
+	return value, nil
+}
@@ -0,0 +1,111 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Resolver fetches the secret identified by ref, the part after "scheme:"
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// Manager resolves secret references such as "vault:secret/data/db#password",
+// "awssm:prod/db#password", "env:DB_PASSWORD" or "file:/run/secrets/db".
+// Values without a registered scheme are returned unchanged.
+type Manager struct {
+	mu        sync.Mutex
+	resolvers map[string]Resolver
+	cache     map[string]string
+}
+
+// NewManager creates a Manager with the env, file, vault and awssm schemes registered
+func NewManager() *Manager {
+	m := &Manager{resolvers: make(map[string]Resolver), cache: make(map[string]string)}
+	m.Register("env", ResolverFunc(resolveEnv))
+	m.Register("file", ResolverFunc(resolveFile))
+	m.Register("vault", NewVaultResolver(os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")))
+	m.Register("awssm", NewAWSResolver())
+	return m
+}
+
+// Register adds or replaces the resolver for a scheme
+func (m *Manager) Register(scheme string, r Resolver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resolvers[scheme] = r
+}
+
+// IsReference reports whether value points at a registered secret backend
+func (m *Manager) IsReference(value string) bool {
+	_, _, ok := m.split(value)
+	return ok
+}
+
+// Resolve returns the secret value for a reference, or value itself if it is not one
+func (m *Manager) Resolve(ctx context.Context, value string) (string, error) {
+	r, ref, ok := m.split(value)
+	if !ok {
+		return value, nil
+	}
+
+	m.mu.Lock()
+	cached, hit := m.cache[value]
+	m.mu.Unlock()
+	if hit {
+		return cached, nil
+	}
+
+	secret, err := r.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s: %w", value, err)
+	}
+
+	m.mu.Lock()
+	m.cache[value] = secret
+	m.mu.Unlock()
+	return secret, nil
+}
+
+func (m *Manager) split(value string) (Resolver, string, bool) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok || ref == "" {
+		return nil, "", false
+	}
+	m.mu.Lock()
+	r, ok := m.resolvers[scheme]
+	m.mu.Unlock()
+	return r, ref, ok
+}
+
+// splitKey separates "path#key" into its parts; key is empty when absent
+func splitKey(ref string) (string, string) {
+	path, key, _ := strings.Cut(ref, "#")
+	return path, key
+}
+
+func resolveEnv(_ context.Context, name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return v, nil
+}
+
+func resolveFile(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultResolver reads secrets over Vault's HTTP API. References take the form
+// "mount/path#key"; both KV v1 and KV v2 ("secret/data/...") responses are understood.
+type VaultResolver struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// NewVaultResolver creates a resolver for the Vault server at addr
+func NewVaultResolver(addr, token string) *VaultResolver {
+	return &VaultResolver{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (v *VaultResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	if v.addr == "" || v.token == "" {
+		return "", errors.New("VAULT_ADDR and VAULT_TOKEN must be set")
+	}
+	path, key := splitKey(ref)
+	if key == "" {
+		return "", fmt.Errorf("vault reference %s is missing a #key", ref)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %s for %s", resp.Status, path)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+
+	data := body.Data
+	// KV v2 nests the secret under data.data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, hasMeta := data["metadata"]; hasMeta {
+			data = nested
+		}
+	}
+
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("key %s not found in vault secret %s", key, path)
+	}
+	return value, nil
+}
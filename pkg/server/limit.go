@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+)
+
+// RouteLimit overrides the request body limits of the routes matching a
+// ServeMux pattern
+type RouteLimit struct {
+	Pattern        string // e.g. "POST /me/imports"
+	MaxBodyBytes   int64  // Zero keeps Config.MaxBodyBytes
+	BytesPerSecond int64  // Body reads are paced to this rate; zero leaves them unpaced
+}
+
+// BodyLimiter enforces Config.MaxBodyBytes and the route limits
+type BodyLimiter struct {
+	limit  int64
+	routes *http.ServeMux // Answers a routeLimit for the requests each route limit matches
+}
+
+type routeLimit struct {
+	http.Handler // Never served; the mux only tells which limit applies
+	RouteLimit
+}
+
+// NewBodyLimiter checks the route limits, reporting a pattern that is
+// invalid or conflicts with another
+func NewBodyLimiter(limit int64, routes []RouteLimit) (*BodyLimiter, error) {
+	l := &BodyLimiter{limit: limit, routes: http.NewServeMux()}
+	for _, route := range routes {
+		if route.MaxBodyBytes < 0 || route.BytesPerSecond < 0 {
+			return nil, fmt.Errorf("route %q: limits must not be negative", route.Pattern)
+		}
+		if err := l.handle(route); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// handle registers route, turning the mux's panic on a bad pattern into an error
+func (l *BodyLimiter) handle(route RouteLimit) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("route %q: %v", route.Pattern, p)
+		}
+	}()
+	l.routes.Handle(route.Pattern, routeLimit{http.NotFoundHandler(), route})
+	return nil
+}
+
+// Middleware rejects request bodies over the limit of their route with 413,
+// up front when Content-Length gives the size away and otherwise once a
+// handler reads past the limit and answers that the body is invalid. Paced
+// routes get their read and write deadlines pushed back by the time their
+// largest body takes to arrive at the pace.
+func (l *BodyLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limit, rate := l.limit, int64(0)
+		if h, _ := l.routes.Handler(r); h != nil {
+			if route, ok := h.(routeLimit); ok {
+				if route.MaxBodyBytes > 0 {
+					limit = route.MaxBodyBytes
+				}
+				rate = route.BytesPerSecond
+			}
+		}
+		if limit > 0 && r.ContentLength > limit {
+			httpx.Error(w, http.StatusRequestEntityTooLarge, tooLarge(limit))
+			return
+		}
+
+		body := &limitedBody{ReadCloser: r.Body}
+		if limit > 0 {
+			body.ReadCloser = http.MaxBytesReader(w, r.Body, limit)
+		}
+		if rate > 0 {
+			body.ReadCloser = &pacedBody{ReadCloser: body.ReadCloser, ctx: r.Context(), rate: rate, start: time.Now()}
+			if limit > 0 {
+				rc := http.NewResponseController(w)
+				extra := time.Duration(limit/rate+1) * time.Second
+				// Unsupported writers keep the server's deadlines
+				_ = rc.SetReadDeadline(time.Now().Add(extra))
+				_ = rc.SetWriteDeadline(time.Now().Add(extra))
+			}
+		}
+		r.Body = body
+		next.ServeHTTP(&limitedWriter{ResponseWriter: w, body: body, limit: limit}, r)
+	})
+}
+
+func tooLarge(limit int64) string {
+	return fmt.Sprintf("request body too large; at most %d bytes are accepted here", limit)
+}
+
+// limitedBody notes whether a read went over the limit
+type limitedBody struct {
+	io.ReadCloser
+	over bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		b.over = true
+	}
+	return n, err
+}
+
+// limitedWriter answers 413 in place of the 400 a handler gives for a body it
+// could not read because it went over the limit
+type limitedWriter struct {
+	http.ResponseWriter
+	body     *limitedBody
+	limit    int64
+	replaced bool
+}
+
+func (w *limitedWriter) WriteHeader(status int) {
+	if w.replaced {
+		return
+	}
+	if status == http.StatusBadRequest && w.body.over {
+		w.replaced = true
+		httpx.Error(w.ResponseWriter, http.StatusRequestEntityTooLarge, tooLarge(w.limit))
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.replaced {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *limitedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// pacedBody slows reads down to rate bytes per second since start, reading
+// at most a second's worth at once
+type pacedBody struct {
+	io.ReadCloser
+	ctx   context.Context
+	rate  int64
+	start time.Time
+	read  int64
+}
+
+func (b *pacedBody) Read(p []byte) (int, error) {
+	if int64(len(p)) > b.rate {
+		p = p[:b.rate]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+
+	wait := time.Until(b.start.Add(time.Duration(float64(b.read) / float64(b.rate) * float64(time.Second))))
+	if wait <= 0 {
+		return n, err
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return n, err
+	case <-b.ctx.Done():
+		return n, b.ctx.Err()
+	}
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Config holds HTTP server tuning and TLS settings
+type Config struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	MaxBodyBytes      int64        // Largest request body of routes without a limit of their own; zero for none
+	RouteLimits       []RouteLimit // Body limits and upload pacing of particular routes
+	TrustedProxies    []string     // CIDRs or IPs whose X-Forwarded-For is trusted
+	TLS               TLSConfig
+}
+
+// TLSConfig enables HTTPS from a certificate pair or via ACME (Let's Encrypt)
+type TLSConfig struct {
+	CertFile         string
+	KeyFile          string
+	AutocertDomains  []string
+	AutocertEmail    string
+	AutocertCacheDir string
+}
+
+// Server wraps http.Server with graceful shutdown and optional TLS
+type Server struct {
+	http     *http.Server
+	config   Config
+	autocert *autocert.Manager
+	acme     *http.Server // Answers ACME HTTP-01 challenges on :80 when autocert is on
+}
+
+// New builds a server for handler. Every request passes through client-IP
+// resolution and the body limit of its route before reaching handler.
+func New(handler http.Handler, config Config) (*Server, error) {
+	if config.Addr == "" {
+		config.Addr = ":8080"
+	}
+	if config.ReadHeaderTimeout <= 0 {
+		config.ReadHeaderTimeout = 10 * time.Second
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = 30 * time.Second
+	}
+
+	resolver, err := NewClientIPResolver(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter, err := NewBodyLimiter(config.MaxBodyBytes, config.RouteLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	handler = resolver.Middleware(limiter.Middleware(handler))
+
+	s := &Server{
+		config: config,
+		http: &http.Server{
+			Addr:              config.Addr,
+			Handler:           handler,
+			ReadTimeout:       config.ReadTimeout,
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			WriteTimeout:      config.WriteTimeout,
+			IdleTimeout:       config.IdleTimeout,
+		},
+	}
+
+	switch {
+	case len(config.TLS.AutocertDomains) > 0:
+		cacheDir := config.TLS.AutocertCacheDir
+		if cacheDir == "" {
+			cacheDir = "./data/autocert"
+		}
+		s.autocert = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(config.TLS.AutocertDomains...),
+			Cache:      autocert.DirCache(cacheDir),
+			Email:      config.TLS.AutocertEmail,
+		}
+		s.http.TLSConfig = s.autocert.TLSConfig()
+		// ACME HTTP-01 challenges and the HTTPS redirect are served on :80
+		s.acme = &http.Server{
+			Addr:              ":80",
+			Handler:           s.autocert.HTTPHandler(nil),
+			ReadTimeout:       config.ReadTimeout,
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			WriteTimeout:      config.WriteTimeout,
+			IdleTimeout:       config.IdleTimeout,
+		}
+	case config.TLS.CertFile != "" || config.TLS.KeyFile != "":
+		if config.TLS.CertFile == "" || config.TLS.KeyFile == "" {
+			return nil, errors.New("both TLS cert and key files are required")
+		}
+		s.http.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return s, nil
+}
+
+// Run serves until ctx is cancelled, then shuts down gracefully. With
+// autocert the :80 challenge listener runs alongside; if either listener
+// fails, both are shut down and the failure is returned.
+func (s *Server) Run(ctx context.Context) error {
+	errCh := make(chan error, 2)
+	go func() {
+		slog.InfoContext(ctx, "http server listening", slog.String("addr", s.config.Addr), slog.Bool("tls", s.http.TLSConfig != nil))
+		var err error
+		switch {
+		case s.autocert != nil:
+			err = s.http.ListenAndServeTLS("", "")
+		case s.http.TLSConfig != nil:
+			err = s.http.ListenAndServeTLS(s.config.TLS.CertFile, s.config.TLS.KeyFile)
+		default:
+			err = s.http.ListenAndServe()
+		}
+		errCh <- fmt.Errorf("http server failed: %w", err)
+	}()
+	if s.acme != nil {
+		go func() {
+			slog.InfoContext(ctx, "acme challenge listener listening", slog.String("addr", s.acme.Addr))
+			errCh <- fmt.Errorf("acme challenge listener failed: %w", s.acme.ListenAndServe())
+		}()
+	}
+
+	var runErr error
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			runErr = err
+		}
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	slog.Info("shutting down http server")
+	var shutdownErr error
+	if err := s.http.Shutdown(shutdownCtx); err != nil {
+		shutdownErr = fmt.Errorf("http server shutdown failed: %w", err)
+	}
+	if s.acme != nil {
+		if err := s.acme.Shutdown(shutdownCtx); err != nil {
+			shutdownErr = errors.Join(shutdownErr, fmt.Errorf("acme challenge listener shutdown failed: %w", err))
+		}
+	}
+	return errors.Join(runErr, shutdownErr)
+}
+
+// ClientIPResolver determines the real client IP behind trusted proxies
+type ClientIPResolver struct {
+	trusted []*net.IPNet
+}
+
+// NewClientIPResolver parses the trusted proxy list; bare IPs are treated as single-host CIDRs
+func NewClientIPResolver(proxies []string) (*ClientIPResolver, error) {
+	r := &ClientIPResolver{}
+	for _, p := range proxies {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+		}
+		r.trusted = append(r.trusted, network)
+	}
+	return r, nil
+}
+
+// Resolve returns the client IP for r. X-Forwarded-For is only honoured when
+// the direct peer is trusted, and is walked right to left skipping trusted hops
+// so a client cannot spoof its address by prepending entries.
+func (c *ClientIPResolver) Resolve(r *http.Request) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+	if !c.isTrusted(peer) {
+		return peer
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !c.isTrusted(hop) {
+			return hop
+		}
+	}
+	return peer
+}
+
+// Middleware stores the resolved client IP in the request context
+func (c *ClientIPResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := httpx.WithClientIP(r.Context(), c.Resolve(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (c *ClientIPResolver) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range c.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+// Package sms sends text messages through Twilio. The mock provider logs
+// messages and keeps them in memory instead, for development and for
+// deployments without an SMS account.
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Providers
+const (
+	ProviderMock   = "mock"
+	ProviderTwilio = "twilio"
+)
+
+// ErrUnavailable wraps failures of the SMS provider
+var ErrUnavailable = errors.New("sms provider unavailable")
+
+// Sender sends a text message to a phone number in E.164
+type Sender interface {
+	Send(ctx context.Context, to, body string) error
+	Provider() string
+}
+
+// Config selects and authenticates the provider
+type Config struct {
+	Provider   string
+	AccountSID string
+	AuthToken  string
+	From       string        // Sending number in E.164, or a messaging service SID
+	Endpoint   string        // Overrides the provider's API URL
+	Timeout    time.Duration // Per request, 10s when zero
+}
+
+// New returns the sender for config.Provider; an empty provider is mock
+func New(config Config) (Sender, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	switch config.Provider {
+	case "", ProviderMock:
+		return &Mock{}, nil
+	case ProviderTwilio:
+		if config.AccountSID == "" || config.AuthToken == "" || config.From == "" {
+			return nil, errors.New("twilio needs an account SID, auth token and from number")
+		}
+		return NewTwilio(&http.Client{Timeout: config.Timeout}, config.AccountSID, config.AuthToken, config.From, config.Endpoint), nil
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", config.Provider)
+	}
+}
+
+// Message is a text message the mock sender was asked to send
+type Message struct {
+	To   string
+	Body string
+}
+
+// Mock logs messages instead of sending them and remembers them
+type Mock struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (m *Mock) Send(ctx context.Context, to, body string) error {
+	slog.InfoContext(ctx, "sms", slog.String("to", to), slog.String("body", body))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, Message{To: to, Body: body})
+	return nil
+}
+
+func (m *Mock) Provider() string { return ProviderMock }
+
+// Sent returns the messages sent so far, oldest first
+func (m *Mock) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.sent...)
+}
@@ -0,0 +1,58 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const twilioEndpoint = "https://api.twilio.com/2010-04-01"
+
+// Twilio sends messages through the Twilio Programmable Messaging API
+type Twilio struct {
+	client     *http.Client
+	accountSID string
+	authToken  string
+	from       string
+	endpoint   string
+}
+
+// NewTwilio creates a Twilio sender; from starting with "MG" is taken as a
+// messaging service SID rather than a number
+func NewTwilio(client *http.Client, accountSID, authToken, from, endpoint string) *Twilio {
+	if endpoint == "" {
+		endpoint = twilioEndpoint
+	}
+	return &Twilio{client: client, accountSID: accountSID, authToken: authToken, from: from, endpoint: strings.TrimSuffix(endpoint, "/")}
+}
+
+func (t *Twilio) Send(ctx context.Context, to, body string) error {
+	form := url.Values{"To": {to}, "Body": {body}}
+	if strings.HasPrefix(t.from, "MG") {
+		form.Set("MessagingServiceSid", t.from)
+	} else {
+		form.Set("From", t.from)
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", t.endpoint, url.PathEscape(t.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.accountSID, t.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: twilio returned %s", ErrUnavailable, resp.Status)
+	}
+	return nil
+}
+
+func (t *Twilio) Provider() string { return ProviderTwilio }
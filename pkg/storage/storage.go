@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage stores uploaded media and returns the URL it is served from
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) (url string, err error)
+	Delete(ctx context.Context, key string) error
+	Walk(ctx context.Context, prefix string, fn func(Object) error) error
+}
+
+// Object is a stored object as Walk reports it
+type Object struct {
+	Key        string
+	URL        string
+	ModifiedAt time.Time
+}
+
+// LocalStorage keeps objects on the local filesystem under dir and serves
+// them from baseURL, e.g. through a static file server or CDN origin
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStorage(dir, baseURL string) *LocalStorage {
+	return &LocalStorage{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Put writes the object to a temporary file and moves it into place once
+// complete, so readers never see a partial object; existing keys are replaced
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, contentType string) (string, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create storage file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := os.Rename(f.Name(), target); err != nil {
+		return "", fmt.Errorf("failed to move %s into place: %w", key, err)
+	}
+	return s.baseURL + "/" + path.Clean(key), nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// Walk calls fn for every object whose key starts with the directory prefix,
+// stopping at the first error fn returns. Uploads still being written are
+// skipped.
+func (s *LocalStorage) Walk(ctx context.Context, prefix string, fn func(Object) error) error {
+	root, err := s.path(strings.TrimSuffix(prefix, "/"))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(root); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.dir, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		return fn(Object{Key: key, URL: s.baseURL + "/" + key, ModifiedAt: info.ModTime()})
+	})
+}
+
+// path maps a slash-separated key into dir, rejecting keys that would escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,115 @@
+package tenant
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Column is the column that carries the owning tenant on scoped tables
+const Column = "tenant_id"
+
+// Plugin scopes every query, update and delete on tenant-owned tables to the
+// tenant in the statement context and stamps new rows with it. Statements whose
+// context carries no tenant (background workers, migrations) are left unscoped.
+type Plugin struct {
+	models []any
+	tables map[string]bool
+}
+
+// NewPlugin creates a plugin for models; only those with a tenant_id column are scoped
+func NewPlugin(models ...any) *Plugin {
+	return &Plugin{models: models, tables: make(map[string]bool)}
+}
+
+func (p *Plugin) Name() string {
+	return "tenant"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	for _, m := range p.models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			return fmt.Errorf("failed to parse tenant model: %w", err)
+		}
+		if stmt.Schema.LookUpField(Column) != nil {
+			p.tables[stmt.Schema.Table] = true
+		}
+	}
+
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("tenant:create", p.stamp); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tenant:query", p.scope); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:update", p.scope); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("tenant:delete", p.scope); err != nil {
+		return err
+	}
+	return cb.Row().Before("gorm:row").Register("tenant:row", p.scope)
+}
+
+// table returns the statement's target table when it is tenant-owned
+func (p *Plugin) table(stmt *gorm.Statement) (string, bool) {
+	table := stmt.Table
+	if table == "" && stmt.Schema != nil {
+		table = stmt.Schema.Table
+	}
+	return table, p.tables[table]
+}
+
+func (p *Plugin) scope(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Context == nil {
+		return
+	}
+	id, ok := ID(db.Statement.Context)
+	if !ok {
+		return
+	}
+	table, ok := p.table(db.Statement)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: table, Name: Column}, Value: id},
+	}})
+}
+
+func (p *Plugin) stamp(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Context == nil || db.Statement.Schema == nil {
+		return
+	}
+	id, ok := ID(db.Statement.Context)
+	if !ok {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(Column)
+	if field == nil {
+		return
+	}
+
+	ctx := db.Statement.Context
+	set := func(rv reflect.Value) {
+		if _, zero := field.ValueOf(ctx, rv); zero {
+			if err := field.Set(ctx, rv, id); err != nil {
+				db.AddError(err)
+			}
+		}
+	}
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range rv.Len() {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
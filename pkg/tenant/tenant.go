@@ -0,0 +1,112 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ilhamosaurus/sns-platform/pkg/apperr"
+	"github.com/ilhamosaurus/sns-platform/pkg/httpx"
+)
+
+// DefaultHeader selects a tenant by slug when no domain mapping applies
+const DefaultHeader = "X-Tenant"
+
+// DefaultID is the tenant every row belongs to when tenancy is disabled
+const DefaultID int64 = 0
+
+// ErrNotFound is returned by a Lookup when no tenant matches
+var ErrNotFound = apperr.NotFound("tenant not found")
+
+type ctxKey struct{}
+
+// WithID stores the tenant ID in the context
+func WithID(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// ID returns the tenant ID stored in the context, if any
+func ID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(ctxKey{}).(int64)
+	return id, ok
+}
+
+// Lookup maps request attributes to tenant IDs
+type Lookup interface {
+	IDByDomain(ctx context.Context, domain string) (int64, error)
+	IDBySlug(ctx context.Context, slug string) (int64, error)
+}
+
+// Config controls how requests are mapped to tenants
+type Config struct {
+	Header   string // Header carrying the tenant slug, defaults to X-Tenant
+	Required bool   // Reject requests that match no tenant instead of using DefaultID
+
+	// Principal returns the tenant the request's credentials were issued
+	// in; ok is false for an anonymous request. Nil treats every request
+	// as anonymous.
+	Principal func(r *http.Request) (id int64, ok bool)
+}
+
+// Middleware resolves the tenant for each request, first from the tenant
+// header and then from the Host domain, and stores it in the request context.
+// A request with credentials is held to their tenant: it is refused if the
+// header or domain names another, and uses theirs if neither names any.
+// Anonymous requests may pick any tenant, as they do to sign in.
+func Middleware(lookup Lookup, config Config) func(http.Handler) http.Handler {
+	header := config.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			var owner int64
+			authenticated := false
+			if config.Principal != nil {
+				owner, authenticated = config.Principal(r)
+			}
+
+			id, err := resolve(ctx, lookup, r.Header.Get(header), r.Host)
+			switch {
+			case err == nil && authenticated && id != owner:
+				httpx.Error(w, http.StatusForbidden, "credentials belong to another tenant")
+				return
+			case errors.Is(err, ErrNotFound) && authenticated:
+				id = owner
+			case errors.Is(err, ErrNotFound) && !config.Required:
+				id = DefaultID
+			case errors.Is(err, ErrNotFound):
+				httpx.Error(w, http.StatusNotFound, "unknown tenant")
+				return
+			case err != nil:
+				slog.ErrorContext(ctx, "failed to resolve tenant", slog.String("host", r.Host), slog.Any("error", err))
+				httpx.Error(w, http.StatusInternalServerError, "failed to resolve tenant")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithID(ctx, id)))
+		})
+	}
+}
+
+func resolve(ctx context.Context, lookup Lookup, slug, host string) (int64, error) {
+	// An explicit slug must match; it never falls back to the domain
+	if slug = strings.TrimSpace(slug); slug != "" {
+		return lookup.IDBySlug(ctx, strings.ToLower(slug))
+	}
+
+	domain, _, err := net.SplitHostPort(host)
+	if err != nil {
+		domain = host
+	}
+	if domain == "" {
+		return 0, ErrNotFound
+	}
+	return lookup.IDByDomain(ctx, strings.ToLower(domain))
+}
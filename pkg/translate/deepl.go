@@ -0,0 +1,81 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DeepL endpoints; keys of the free plan end in ":fx" and use their own host
+const (
+	deeplEndpoint     = "https://api.deepl.com/v2/translate"
+	deeplFreeEndpoint = "https://api-free.deepl.com/v2/translate"
+)
+
+// DeepL translates through the DeepL API
+type DeepL struct {
+	client   *http.Client
+	key      string
+	endpoint string
+}
+
+// NewDeepL creates a DeepL translator; an empty endpoint is picked from the key's plan
+func NewDeepL(client *http.Client, key, endpoint string) *DeepL {
+	if endpoint == "" {
+		endpoint = deeplEndpoint
+		if strings.HasSuffix(key, ":fx") {
+			endpoint = deeplFreeEndpoint
+		}
+	}
+	return &DeepL{client: client, key: key, endpoint: endpoint}
+}
+
+func (d *DeepL) Translate(ctx context.Context, text, source, target string) (Result, error) {
+	payload := map[string]any{
+		"text":        []string{text},
+		"target_lang": strings.ToUpper(target),
+	}
+	if source != "" {
+		payload["source_lang"] = strings.ToUpper(source)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Result{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+d.key)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("%w: deepl returned %s", ErrUnavailable, resp.Status)
+	}
+
+	var out struct {
+		Translations []struct {
+			DetectedSourceLanguage string `json:"detected_source_language"`
+			Text                   string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return Result{}, fmt.Errorf("%w: failed to decode deepl response: %v", ErrUnavailable, err)
+	}
+	if len(out.Translations) == 0 {
+		return Result{}, fmt.Errorf("%w: deepl returned no translation", ErrUnavailable)
+	}
+	t := out.Translations[0]
+	return Result{Text: t.Text, Source: strings.ToLower(t.DetectedSourceLanguage)}, nil
+}
+
+func (d *DeepL) Provider() string { return ProviderDeepL }
@@ -0,0 +1,80 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const googleEndpoint = "https://translation.googleapis.com/language/translate/v2"
+
+// Google translates through the Cloud Translation v2 API with an API key
+type Google struct {
+	client   *http.Client
+	key      string
+	endpoint string
+}
+
+// NewGoogle creates a Google translator; an empty endpoint uses the public API
+func NewGoogle(client *http.Client, key, endpoint string) *Google {
+	if endpoint == "" {
+		endpoint = googleEndpoint
+	}
+	return &Google{client: client, key: key, endpoint: endpoint}
+}
+
+func (g *Google) Translate(ctx context.Context, text, source, target string) (Result, error) {
+	payload := map[string]any{
+		"q":      []string{text},
+		"target": target,
+		"format": "text",
+	}
+	if source != "" {
+		payload["source"] = source
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Result{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint+"?key="+url.QueryEscape(g.key), bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("%w: google returned %s", ErrUnavailable, resp.Status)
+	}
+
+	var out struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText         string `json:"translatedText"`
+				DetectedSourceLanguage string `json:"detectedSourceLanguage"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return Result{}, fmt.Errorf("%w: failed to decode google response: %v", ErrUnavailable, err)
+	}
+	if len(out.Data.Translations) == 0 {
+		return Result{}, fmt.Errorf("%w: google returned no translation", ErrUnavailable)
+	}
+	t := out.Data.Translations[0]
+	result := Result{Text: t.TranslatedText, Source: t.DetectedSourceLanguage}
+	if result.Source == "" {
+		result.Source = source
+	}
+	return result, nil
+}
+
+func (g *Google) Provider() string { return ProviderGoogle }
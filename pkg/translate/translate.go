@@ -0,0 +1,71 @@
+// Package translate machine-translates text through DeepL or Google Cloud
+// Translation. The none provider leaves text as it is, for deployments
+// without a translation account.
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Providers
+const (
+	ProviderNone   = "none"
+	ProviderDeepL  = "deepl"
+	ProviderGoogle = "google"
+)
+
+// ErrUnavailable wraps failures of the translation provider
+var ErrUnavailable = errors.New("translation provider unavailable")
+
+// Result is a translated text and the language it was translated from
+type Result struct {
+	Text   string
+	Source string // ISO 639-1, as given or as detected by the provider
+}
+
+// Translator translates text into target, an ISO 639-1 code. An empty source
+// lets the provider detect it.
+type Translator interface {
+	Translate(ctx context.Context, text, source, target string) (Result, error)
+	Provider() string
+}
+
+// Config selects and authenticates the provider
+type Config struct {
+	Provider string
+	APIKey   string
+	Endpoint string        // Overrides the provider's API URL
+	Timeout  time.Duration // Per request, 10s when zero
+}
+
+// New returns the translator for config.Provider; an empty provider is none
+func New(config Config) (Translator, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	client := &http.Client{Timeout: config.Timeout}
+
+	switch config.Provider {
+	case "", ProviderNone:
+		return Noop{}, nil
+	case ProviderDeepL:
+		return NewDeepL(client, config.APIKey, config.Endpoint), nil
+	case ProviderGoogle:
+		return NewGoogle(client, config.APIKey, config.Endpoint), nil
+	default:
+		return nil, fmt.Errorf("unknown translation provider %q", config.Provider)
+	}
+}
+
+// Noop returns text untranslated
+type Noop struct{}
+
+func (Noop) Translate(ctx context.Context, text, source, target string) (Result, error) {
+	return Result{Text: text, Source: source}, nil
+}
+
+func (Noop) Provider() string { return ProviderNone }
@@ -0,0 +1,568 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The enums in this package are stored and serialized by name, so columns
+// hold "like" rather than 2 and JSON reads {"type":"like"}. Numeric values,
+// as written before, are still accepted when scanning and decoding.
+
+type enum interface {
+	~uint32
+	String() string
+}
+
+func scanEnum[T enum](dst *T, src any, parse func(string) T) error {
+	switch src := src.(type) {
+	case nil:
+		*dst = 0
+		return nil
+	case int64:
+		*dst = T(src)
+		return nil
+	case []byte:
+		return parseEnum(dst, string(src), parse)
+	case string:
+		return parseEnum(dst, src, parse)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, *dst)
+	}
+}
+
+func parseEnum[T enum](dst *T, s string, parse func(string) T) error {
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		*dst = T(n)
+		return nil
+	}
+	v := parse(s)
+	if v.String() == "unknown" && s != "" && !strings.EqualFold(s, "unknown") {
+		return fmt.Errorf("invalid %T %q", v, s)
+	}
+	*dst = v
+	return nil
+}
+
+func marshalEnum[T enum](v T) ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+func unmarshalEnum[T enum](dst *T, data []byte, parse func(string) T) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n uint32
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid %T %s", *dst, data)
+		}
+		*dst = T(n)
+		return nil
+	}
+	return parseEnum(dst, s, parse)
+}
+
+func (mt MediaType) Value() (driver.Value, error) {
+	return mt.String(), nil
+}
+
+func (mt *MediaType) Scan(src any) error {
+	return scanEnum(mt, src, StringToMediaType)
+}
+
+func (mt MediaType) MarshalJSON() ([]byte, error) {
+	return marshalEnum(mt)
+}
+
+func (mt *MediaType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(mt, data, StringToMediaType)
+}
+
+func (MediaType) GormDataType() string {
+	return "string"
+}
+
+func (rt ReactionType) Value() (driver.Value, error) {
+	return rt.String(), nil
+}
+
+func (rt *ReactionType) Scan(src any) error {
+	return scanEnum(rt, src, StringToReactionType)
+}
+
+func (rt ReactionType) MarshalJSON() ([]byte, error) {
+	return marshalEnum(rt)
+}
+
+func (rt *ReactionType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(rt, data, StringToReactionType)
+}
+
+func (ReactionType) GormDataType() string {
+	return "string"
+}
+
+func (nt NotificationType) Value() (driver.Value, error) {
+	return nt.String(), nil
+}
+
+func (nt *NotificationType) Scan(src any) error {
+	return scanEnum(nt, src, StringToNotificationType)
+}
+
+func (nt NotificationType) MarshalJSON() ([]byte, error) {
+	return marshalEnum(nt)
+}
+
+func (nt *NotificationType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(nt, data, StringToNotificationType)
+}
+
+func (NotificationType) GormDataType() string {
+	return "string"
+}
+
+func (nt NotificationTarget) Value() (driver.Value, error) {
+	return nt.String(), nil
+}
+
+func (nt *NotificationTarget) Scan(src any) error {
+	return scanEnum(nt, src, StringToNotificationTarget)
+}
+
+func (nt NotificationTarget) MarshalJSON() ([]byte, error) {
+	return marshalEnum(nt)
+}
+
+func (nt *NotificationTarget) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(nt, data, StringToNotificationTarget)
+}
+
+func (NotificationTarget) GormDataType() string {
+	return "string"
+}
+
+func (a Action) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+func (a *Action) Scan(src any) error {
+	return scanEnum(a, src, StringToAction)
+}
+
+func (a Action) MarshalJSON() ([]byte, error) {
+	return marshalEnum(a)
+}
+
+func (a *Action) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(a, data, StringToAction)
+}
+
+func (Action) GormDataType() string {
+	return "string"
+}
+
+func (js JobStatus) Value() (driver.Value, error) {
+	return js.String(), nil
+}
+
+func (js *JobStatus) Scan(src any) error {
+	return scanEnum(js, src, StringToJobStatus)
+}
+
+func (js JobStatus) MarshalJSON() ([]byte, error) {
+	return marshalEnum(js)
+}
+
+func (js *JobStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(js, data, StringToJobStatus)
+}
+
+func (JobStatus) GormDataType() string {
+	return "string"
+}
+
+func (ds DeadLetterSource) Value() (driver.Value, error) {
+	return ds.String(), nil
+}
+
+func (ds *DeadLetterSource) Scan(src any) error {
+	return scanEnum(ds, src, StringToDeadLetterSource)
+}
+
+func (ds DeadLetterSource) MarshalJSON() ([]byte, error) {
+	return marshalEnum(ds)
+}
+
+func (ds *DeadLetterSource) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(ds, data, StringToDeadLetterSource)
+}
+
+func (DeadLetterSource) GormDataType() string {
+	return "string"
+}
+
+func (es ExportStatus) Value() (driver.Value, error) {
+	return es.String(), nil
+}
+
+func (es *ExportStatus) Scan(src any) error {
+	return scanEnum(es, src, StringToExportStatus)
+}
+
+func (es ExportStatus) MarshalJSON() ([]byte, error) {
+	return marshalEnum(es)
+}
+
+func (es *ExportStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(es, data, StringToExportStatus)
+}
+
+func (ExportStatus) GormDataType() string {
+	return "string"
+}
+
+func (st SecurityEventType) Value() (driver.Value, error) {
+	return st.String(), nil
+}
+
+func (st *SecurityEventType) Scan(src any) error {
+	return scanEnum(st, src, StringToSecurityEventType)
+}
+
+func (st SecurityEventType) MarshalJSON() ([]byte, error) {
+	return marshalEnum(st)
+}
+
+func (st *SecurityEventType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(st, data, StringToSecurityEventType)
+}
+
+func (SecurityEventType) GormDataType() string {
+	return "string"
+}
+
+func (cr CommunityRole) Value() (driver.Value, error) {
+	return cr.String(), nil
+}
+
+func (cr *CommunityRole) Scan(src any) error {
+	return scanEnum(cr, src, StringToCommunityRole)
+}
+
+func (cr CommunityRole) MarshalJSON() ([]byte, error) {
+	return marshalEnum(cr)
+}
+
+func (cr *CommunityRole) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(cr, data, StringToCommunityRole)
+}
+
+func (CommunityRole) GormDataType() string {
+	return "string"
+}
+
+func (or OrganizationRole) Value() (driver.Value, error) {
+	return or.String(), nil
+}
+
+func (or *OrganizationRole) Scan(src any) error {
+	return scanEnum(or, src, StringToOrganizationRole)
+}
+
+func (or OrganizationRole) MarshalJSON() ([]byte, error) {
+	return marshalEnum(or)
+}
+
+func (or *OrganizationRole) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(or, data, StringToOrganizationRole)
+}
+
+func (OrganizationRole) GormDataType() string {
+	return "string"
+}
+
+func (pr PageRole) Value() (driver.Value, error) {
+	return pr.String(), nil
+}
+
+func (pr *PageRole) Scan(src any) error {
+	return scanEnum(pr, src, StringToPageRole)
+}
+
+func (pr PageRole) MarshalJSON() ([]byte, error) {
+	return marshalEnum(pr)
+}
+
+func (pr *PageRole) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(pr, data, StringToPageRole)
+}
+
+func (PageRole) GormDataType() string {
+	return "string"
+}
+
+func (ms MembershipStatus) Value() (driver.Value, error) {
+	return ms.String(), nil
+}
+
+func (ms *MembershipStatus) Scan(src any) error {
+	return scanEnum(ms, src, StringToMembershipStatus)
+}
+
+func (ms MembershipStatus) MarshalJSON() ([]byte, error) {
+	return marshalEnum(ms)
+}
+
+func (ms *MembershipStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(ms, data, StringToMembershipStatus)
+}
+
+func (MembershipStatus) GormDataType() string {
+	return "string"
+}
+
+func (is ImportSource) Value() (driver.Value, error) {
+	return is.String(), nil
+}
+
+func (is *ImportSource) Scan(src any) error {
+	return scanEnum(is, src, StringToImportSource)
+}
+
+func (is ImportSource) MarshalJSON() ([]byte, error) {
+	return marshalEnum(is)
+}
+
+func (is *ImportSource) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(is, data, StringToImportSource)
+}
+
+func (ImportSource) GormDataType() string {
+	return "string"
+}
+
+func (is ImportStatus) Value() (driver.Value, error) {
+	return is.String(), nil
+}
+
+func (is *ImportStatus) Scan(src any) error {
+	return scanEnum(is, src, StringToImportStatus)
+}
+
+func (is ImportStatus) MarshalJSON() ([]byte, error) {
+	return marshalEnum(is)
+}
+
+func (is *ImportStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(is, data, StringToImportStatus)
+}
+
+func (ImportStatus) GormDataType() string {
+	return "string"
+}
+
+func (v Visibility) Value() (driver.Value, error) {
+	return v.String(), nil
+}
+
+func (v *Visibility) Scan(src any) error {
+	return scanEnum(v, src, StringToVisibility)
+}
+
+func (v Visibility) MarshalJSON() ([]byte, error) {
+	return marshalEnum(v)
+}
+
+func (v *Visibility) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(v, data, StringToVisibility)
+}
+
+func (Visibility) GormDataType() string {
+	return "string"
+}
+
+func (ur UserRole) Value() (driver.Value, error) {
+	return ur.String(), nil
+}
+
+func (ur *UserRole) Scan(src any) error {
+	return scanEnum(ur, src, StringToUserRole)
+}
+
+func (ur UserRole) MarshalJSON() ([]byte, error) {
+	return marshalEnum(ur)
+}
+
+func (ur *UserRole) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(ur, data, StringToUserRole)
+}
+
+func (UserRole) GormDataType() string {
+	return "string"
+}
+
+func (cs CountStrategy) Value() (driver.Value, error) {
+	return cs.String(), nil
+}
+
+func (cs *CountStrategy) Scan(src any) error {
+	return scanEnum(cs, src, StringToCountStrategy)
+}
+
+func (cs CountStrategy) MarshalJSON() ([]byte, error) {
+	return marshalEnum(cs)
+}
+
+func (cs *CountStrategy) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(cs, data, StringToCountStrategy)
+}
+
+func (CountStrategy) GormDataType() string {
+	return "string"
+}
+
+func (dp DMPolicy) Value() (driver.Value, error) {
+	return dp.String(), nil
+}
+
+func (dp *DMPolicy) Scan(src any) error {
+	return scanEnum(dp, src, StringToDMPolicy)
+}
+
+func (dp DMPolicy) MarshalJSON() ([]byte, error) {
+	return marshalEnum(dp)
+}
+
+func (dp *DMPolicy) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(dp, data, StringToDMPolicy)
+}
+
+func (DMPolicy) GormDataType() string {
+	return "string"
+}
+
+func (cp CommentPolicy) Value() (driver.Value, error) {
+	return cp.String(), nil
+}
+
+func (cp *CommentPolicy) Scan(src any) error {
+	return scanEnum(cp, src, StringToCommentPolicy)
+}
+
+func (cp CommentPolicy) MarshalJSON() ([]byte, error) {
+	return marshalEnum(cp)
+}
+
+func (cp *CommentPolicy) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(cp, data, StringToCommentPolicy)
+}
+
+func (CommentPolicy) GormDataType() string {
+	return "string"
+}
+
+func (dt DraftTarget) Value() (driver.Value, error) {
+	return dt.String(), nil
+}
+
+func (dt *DraftTarget) Scan(src any) error {
+	return scanEnum(dt, src, StringToDraftTarget)
+}
+
+func (dt DraftTarget) MarshalJSON() ([]byte, error) {
+	return marshalEnum(dt)
+}
+
+func (dt *DraftTarget) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(dt, data, StringToDraftTarget)
+}
+
+func (DraftTarget) GormDataType() string {
+	return "string"
+}
+
+func (st ShortLinkTarget) Value() (driver.Value, error) {
+	return st.String(), nil
+}
+
+func (st *ShortLinkTarget) Scan(src any) error {
+	return scanEnum(st, src, StringToShortLinkTarget)
+}
+
+func (st ShortLinkTarget) MarshalJSON() ([]byte, error) {
+	return marshalEnum(st)
+}
+
+func (st *ShortLinkTarget) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(st, data, StringToShortLinkTarget)
+}
+
+func (ShortLinkTarget) GormDataType() string {
+	return "string"
+}
+
+func (cs CommentSort) Value() (driver.Value, error) {
+	return cs.String(), nil
+}
+
+func (cs *CommentSort) Scan(src any) error {
+	return scanEnum(cs, src, StringToCommentSort)
+}
+
+func (cs CommentSort) MarshalJSON() ([]byte, error) {
+	return marshalEnum(cs)
+}
+
+func (cs *CommentSort) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(cs, data, StringToCommentSort)
+}
+
+func (CommentSort) GormDataType() string {
+	return "string"
+}
+
+func (step OnboardingStep) Value() (driver.Value, error) {
+	return step.String(), nil
+}
+
+func (step *OnboardingStep) Scan(src any) error {
+	return scanEnum(step, src, StringToOnboardingStep)
+}
+
+func (step OnboardingStep) MarshalJSON() ([]byte, error) {
+	return marshalEnum(step)
+}
+
+func (step *OnboardingStep) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(step, data, StringToOnboardingStep)
+}
+
+func (OnboardingStep) GormDataType() string {
+	return "string"
+}
+
+func (pp PhoneCodePurpose) Value() (driver.Value, error) {
+	return pp.String(), nil
+}
+
+func (pp *PhoneCodePurpose) Scan(src any) error {
+	return scanEnum(pp, src, StringToPhoneCodePurpose)
+}
+
+func (pp PhoneCodePurpose) MarshalJSON() ([]byte, error) {
+	return marshalEnum(pp)
+}
+
+func (pp *PhoneCodePurpose) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(pp, data, StringToPhoneCodePurpose)
+}
+
+func (PhoneCodePurpose) GormDataType() string {
+	return "string"
+}
@@ -9,6 +9,10 @@ const (
 	MediaTypeImage
 	MediaTypeVideo
 	MediaTypeText
+	MediaTypeAudio
+	MediaTypeGIF
+	MediaTypeLink
+	MediaTypeDocument
 )
 
 func (mt MediaType) String() string {
@@ -19,6 +23,14 @@ func (mt MediaType) String() string {
 		return "video"
 	case MediaTypeText:
 		return "text"
+	case MediaTypeAudio:
+		return "audio"
+	case MediaTypeGIF:
+		return "gif"
+	case MediaTypeLink:
+		return "link"
+	case MediaTypeDocument:
+		return "document"
 	default:
 		return "unknown"
 	}
@@ -32,6 +44,55 @@ func StringToMediaType(s string) MediaType {
 		return MediaTypeVideo
 	case "text":
 		return MediaTypeText
+	case "audio":
+		return MediaTypeAudio
+	case "gif":
+		return MediaTypeGIF
+	case "link":
+		return MediaTypeLink
+	case "document":
+		return MediaTypeDocument
+	default:
+		return MediaTypeUnknown
+	}
+}
+
+// IsValid reports whether mt is a known media type
+func (mt MediaType) IsValid() bool {
+	return mt >= MediaTypeImage && mt <= MediaTypeDocument
+}
+
+// HasFile reports whether posts of this type carry an uploaded file in
+// media_url; text posts have none and link posts point at a web page
+func (mt MediaType) HasFile() bool {
+	return mt.IsValid() && mt != MediaTypeText && mt != MediaTypeLink
+}
+
+// MediaTypeFromContentType classifies an uploaded file by its MIME type,
+// returning MediaTypeUnknown for types that cannot be attached to a post
+func MediaTypeFromContentType(contentType string) MediaType {
+	ct := strings.ToLower(strings.TrimSpace(contentType))
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = strings.TrimSpace(ct[:i])
+	}
+	switch {
+	case ct == "image/gif":
+		return MediaTypeGIF
+	case strings.HasPrefix(ct, "image/"):
+		return MediaTypeImage
+	case strings.HasPrefix(ct, "video/"):
+		return MediaTypeVideo
+	case strings.HasPrefix(ct, "audio/"):
+		return MediaTypeAudio
+	}
+	switch ct {
+	case "application/pdf", "application/rtf", "text/plain", "text/csv",
+		"application/msword", "application/vnd.ms-excel", "application/vnd.ms-powerpoint",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		"application/vnd.oasis.opendocument.text":
+		return MediaTypeDocument
 	default:
 		return MediaTypeUnknown
 	}
@@ -95,6 +156,7 @@ const (
 	NotificationTypeLike
 	NotificationTypeComment
 	NotificationTypeMention
+	NotificationTypeSecurity
 )
 
 func (nt NotificationType) String() string {
@@ -107,6 +169,8 @@ func (nt NotificationType) String() string {
 		return "comment"
 	case NotificationTypeMention:
 		return "mention"
+	case NotificationTypeSecurity:
+		return "security"
 	default:
 		return "unknown"
 	}
@@ -122,6 +186,8 @@ func StringToNotificationType(s string) NotificationType {
 		return NotificationTypeComment
 	case "mention":
 		return NotificationTypeMention
+	case "security":
+		return NotificationTypeSecurity
 	default:
 		return NotificationTypeUnknown
 	}
@@ -207,3 +273,772 @@ func (a Action) String() string {
 		return "unknown"
 	}
 }
+
+func StringToAction(s string) Action {
+	switch strings.ToLower(s) {
+	case "following":
+		return ActionFollowing
+	case "unfollowing":
+		return ActionUnfollowing
+	case "followed":
+		return ActionFollowed
+	case "unfollowed":
+		return ActionUnfollowed
+	case "created":
+		return ActionCreated
+	case "deleted":
+		return ActionDeleted
+	case "liked":
+		return ActionLiked
+	case "unliked":
+		return ActionUnliked
+	case "commented":
+		return ActionCommented
+	case "uncommented":
+		return ActionUncommented
+	case "shared":
+		return ActionShared
+	default:
+		return ActionUnknown
+	}
+}
+
+type JobStatus uint32
+
+const (
+	JobStatusUnknown JobStatus = iota
+	JobStatusPending
+	JobStatusRunning
+	JobStatusCompleted
+	JobStatusFailed
+)
+
+func (js JobStatus) String() string {
+	switch js {
+	case JobStatusPending:
+		return "pending"
+	case JobStatusRunning:
+		return "running"
+	case JobStatusCompleted:
+		return "completed"
+	case JobStatusFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToJobStatus(s string) JobStatus {
+	switch strings.ToLower(s) {
+	case "pending":
+		return JobStatusPending
+	case "running":
+		return JobStatusRunning
+	case "completed":
+		return JobStatusCompleted
+	case "failed":
+		return JobStatusFailed
+	default:
+		return JobStatusUnknown
+	}
+}
+
+type DeadLetterSource uint32
+
+const (
+	DeadLetterSourceUnknown DeadLetterSource = iota
+	DeadLetterSourceJob
+	DeadLetterSourceEvent
+)
+
+func (ds DeadLetterSource) String() string {
+	switch ds {
+	case DeadLetterSourceJob:
+		return "job"
+	case DeadLetterSourceEvent:
+		return "event"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToDeadLetterSource(s string) DeadLetterSource {
+	switch strings.ToLower(s) {
+	case "job":
+		return DeadLetterSourceJob
+	case "event":
+		return DeadLetterSourceEvent
+	default:
+		return DeadLetterSourceUnknown
+	}
+}
+
+type ExportStatus uint32
+
+const (
+	ExportStatusUnknown ExportStatus = iota
+	ExportStatusPending
+	ExportStatusProcessing
+	ExportStatusReady
+	ExportStatusFailed
+	ExportStatusExpired
+)
+
+func (es ExportStatus) String() string {
+	switch es {
+	case ExportStatusPending:
+		return "pending"
+	case ExportStatusProcessing:
+		return "processing"
+	case ExportStatusReady:
+		return "ready"
+	case ExportStatusFailed:
+		return "failed"
+	case ExportStatusExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToExportStatus(s string) ExportStatus {
+	switch strings.ToLower(s) {
+	case "pending":
+		return ExportStatusPending
+	case "processing":
+		return ExportStatusProcessing
+	case "ready":
+		return ExportStatusReady
+	case "failed":
+		return ExportStatusFailed
+	case "expired":
+		return ExportStatusExpired
+	default:
+		return ExportStatusUnknown
+	}
+}
+
+type SecurityEventType uint32
+
+const (
+	SecurityEventTypeUnknown SecurityEventType = iota
+	SecurityEventTypeLogin
+	SecurityEventTypeLoginFailed
+	SecurityEventTypeLogout
+	SecurityEventTypePasswordChanged
+	SecurityEventTypeTwoFactorEnabled
+	SecurityEventTypeTwoFactorDisabled
+	SecurityEventTypeTwoFactorFailed
+)
+
+func (st SecurityEventType) String() string {
+	switch st {
+	case SecurityEventTypeLogin:
+		return "login"
+	case SecurityEventTypeLoginFailed:
+		return "login_failed"
+	case SecurityEventTypeLogout:
+		return "logout"
+	case SecurityEventTypePasswordChanged:
+		return "password_changed"
+	case SecurityEventTypeTwoFactorEnabled:
+		return "2fa_enabled"
+	case SecurityEventTypeTwoFactorDisabled:
+		return "2fa_disabled"
+	case SecurityEventTypeTwoFactorFailed:
+		return "2fa_failed"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToSecurityEventType(s string) SecurityEventType {
+	switch strings.ToLower(s) {
+	case "login":
+		return SecurityEventTypeLogin
+	case "login_failed":
+		return SecurityEventTypeLoginFailed
+	case "logout":
+		return SecurityEventTypeLogout
+	case "password_changed":
+		return SecurityEventTypePasswordChanged
+	case "2fa_enabled":
+		return SecurityEventTypeTwoFactorEnabled
+	case "2fa_disabled":
+		return SecurityEventTypeTwoFactorDisabled
+	case "2fa_failed":
+		return SecurityEventTypeTwoFactorFailed
+	default:
+		return SecurityEventTypeUnknown
+	}
+}
+
+type CommunityRole uint32
+
+const (
+	CommunityRoleUnknown CommunityRole = iota
+	CommunityRoleMember
+	CommunityRoleModerator
+	CommunityRoleOwner
+)
+
+func (cr CommunityRole) String() string {
+	switch cr {
+	case CommunityRoleMember:
+		return "member"
+	case CommunityRoleModerator:
+		return "moderator"
+	case CommunityRoleOwner:
+		return "owner"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToCommunityRole(s string) CommunityRole {
+	switch strings.ToLower(s) {
+	case "member":
+		return CommunityRoleMember
+	case "moderator", "mod":
+		return CommunityRoleModerator
+	case "owner":
+		return CommunityRoleOwner
+	default:
+		return CommunityRoleUnknown
+	}
+}
+
+// OrganizationRole is a member's standing in an organization; higher roles
+// include the rights of lower ones
+type OrganizationRole uint32
+
+const (
+	OrganizationRoleUnknown OrganizationRole = iota
+	OrganizationRoleMember
+	OrganizationRoleAdmin
+	OrganizationRoleOwner
+)
+
+func (or OrganizationRole) String() string {
+	switch or {
+	case OrganizationRoleMember:
+		return "member"
+	case OrganizationRoleAdmin:
+		return "admin"
+	case OrganizationRoleOwner:
+		return "owner"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToOrganizationRole(s string) OrganizationRole {
+	switch strings.ToLower(s) {
+	case "member":
+		return OrganizationRoleMember
+	case "admin":
+		return OrganizationRoleAdmin
+	case "owner":
+		return OrganizationRoleOwner
+	default:
+		return OrganizationRoleUnknown
+	}
+}
+
+// PageRole is what a manager may do for a page; higher roles include the
+// rights of lower ones
+type PageRole uint32
+
+const (
+	PageRoleUnknown PageRole = iota
+	PageRoleAnalyst          // Sees insights
+	PageRoleEditor           // Also posts as the page
+	PageRoleAdmin            // Also edits the page and its managers
+)
+
+func (pr PageRole) String() string {
+	switch pr {
+	case PageRoleAnalyst:
+		return "analyst"
+	case PageRoleEditor:
+		return "editor"
+	case PageRoleAdmin:
+		return "admin"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToPageRole(s string) PageRole {
+	switch strings.ToLower(s) {
+	case "analyst":
+		return PageRoleAnalyst
+	case "editor":
+		return PageRoleEditor
+	case "admin":
+		return PageRoleAdmin
+	default:
+		return PageRoleUnknown
+	}
+}
+
+type MembershipStatus uint32
+
+const (
+	MembershipStatusUnknown MembershipStatus = iota
+	MembershipStatusPending
+	MembershipStatusActive
+	MembershipStatusBanned
+)
+
+func (ms MembershipStatus) String() string {
+	switch ms {
+	case MembershipStatusPending:
+		return "pending"
+	case MembershipStatusActive:
+		return "active"
+	case MembershipStatusBanned:
+		return "banned"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToMembershipStatus(s string) MembershipStatus {
+	switch strings.ToLower(s) {
+	case "pending":
+		return MembershipStatusPending
+	case "active":
+		return MembershipStatusActive
+	case "banned":
+		return MembershipStatusBanned
+	default:
+		return MembershipStatusUnknown
+	}
+}
+
+type ImportSource uint32
+
+const (
+	ImportSourceUnknown ImportSource = iota
+	ImportSourceTwitter
+	ImportSourceMastodon
+)
+
+func (is ImportSource) String() string {
+	switch is {
+	case ImportSourceTwitter:
+		return "twitter"
+	case ImportSourceMastodon:
+		return "mastodon"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToImportSource(s string) ImportSource {
+	switch strings.ToLower(s) {
+	case "twitter", "x":
+		return ImportSourceTwitter
+	case "mastodon":
+		return ImportSourceMastodon
+	default:
+		return ImportSourceUnknown
+	}
+}
+
+type ImportStatus uint32
+
+const (
+	ImportStatusUnknown ImportStatus = iota
+	ImportStatusPending
+	ImportStatusProcessing
+	ImportStatusCompleted
+	ImportStatusFailed
+)
+
+func (is ImportStatus) String() string {
+	switch is {
+	case ImportStatusPending:
+		return "pending"
+	case ImportStatusProcessing:
+		return "processing"
+	case ImportStatusCompleted:
+		return "completed"
+	case ImportStatusFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToImportStatus(s string) ImportStatus {
+	switch strings.ToLower(s) {
+	case "pending":
+		return ImportStatusPending
+	case "processing":
+		return ImportStatusProcessing
+	case "completed":
+		return ImportStatusCompleted
+	case "failed":
+		return ImportStatusFailed
+	default:
+		return ImportStatusUnknown
+	}
+}
+
+// Visibility is the audience of a piece of content or a profile field
+type Visibility uint32
+
+const (
+	VisibilityUnknown      Visibility = iota
+	VisibilityPublic                  // Anyone, including logged-out visitors
+	VisibilityFollowers               // The owner's followers
+	VisibilityMentioned               // Only the users mentioned in it
+	VisibilityPrivate                 // Only the owner
+	VisibilityCloseFriends            // The people on the owner's close friends list
+)
+
+func (v Visibility) String() string {
+	switch v {
+	case VisibilityPublic:
+		return "public"
+	case VisibilityFollowers:
+		return "followers"
+	case VisibilityMentioned:
+		return "mentioned"
+	case VisibilityPrivate:
+		return "private"
+	case VisibilityCloseFriends:
+		return "close_friends"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToVisibility(s string) Visibility {
+	switch strings.ToLower(s) {
+	case "public":
+		return VisibilityPublic
+	case "followers":
+		return VisibilityFollowers
+	case "mentioned":
+		return VisibilityMentioned
+	case "private", "only_me":
+		return VisibilityPrivate
+	case "close_friends":
+		return VisibilityCloseFriends
+	default:
+		return VisibilityUnknown
+	}
+}
+
+// IsValid reports whether v is a known visibility
+func (v Visibility) IsValid() bool {
+	return v >= VisibilityPublic && v <= VisibilityCloseFriends
+}
+
+// UserRole is a user's platform-wide role
+type UserRole uint32
+
+const (
+	UserRoleUnknown UserRole = iota
+	UserRoleUser
+	UserRoleAdmin // Can use the /admin API
+)
+
+func (ur UserRole) String() string {
+	switch ur {
+	case UserRoleUser:
+		return "user"
+	case UserRoleAdmin:
+		return "admin"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToUserRole(s string) UserRole {
+	switch strings.ToLower(s) {
+	case "user":
+		return UserRoleUser
+	case "admin":
+		return UserRoleAdmin
+	default:
+		return UserRoleUnknown
+	}
+}
+
+// CountStrategy selects how a list endpoint computes its total count
+type CountStrategy uint32
+
+const (
+	CountStrategyExact     CountStrategy = iota // COUNT(*) on every request
+	CountStrategyEstimated                      // Table statistics, exact for filtered or small tables
+	CountStrategyNone                           // No total, for cursor pagination
+)
+
+func (cs CountStrategy) String() string {
+	switch cs {
+	case CountStrategyExact:
+		return "exact"
+	case CountStrategyEstimated:
+		return "estimated"
+	case CountStrategyNone:
+		return "none"
+	default:
+		return "unknown"
+	}
+}
+
+// StringToCountStrategy parses a strategy name, defaulting to exact
+func StringToCountStrategy(s string) CountStrategy {
+	switch strings.ToLower(s) {
+	case "estimated":
+		return CountStrategyEstimated
+	case "none":
+		return CountStrategyNone
+	default:
+		return CountStrategyExact
+	}
+}
+
+// DMPolicy controls who can message a user they have no conversation with yet
+type DMPolicy uint32
+
+const (
+	DMPolicyEveryone  DMPolicy = iota // People the user follows message directly, anyone else lands in message requests
+	DMPolicyFollowing                 // Only people the user follows
+	DMPolicyNobody
+	DMPolicyUnknown
+)
+
+func (dp DMPolicy) String() string {
+	switch dp {
+	case DMPolicyEveryone:
+		return "everyone"
+	case DMPolicyFollowing:
+		return "following"
+	case DMPolicyNobody:
+		return "nobody"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToDMPolicy(s string) DMPolicy {
+	switch strings.ToLower(s) {
+	case "everyone":
+		return DMPolicyEveryone
+	case "following":
+		return DMPolicyFollowing
+	case "nobody":
+		return DMPolicyNobody
+	default:
+		return DMPolicyUnknown
+	}
+}
+
+// CommentPolicy controls who can comment on a post besides its author
+type CommentPolicy uint32
+
+const (
+	CommentPolicyEveryone  CommentPolicy = iota
+	CommentPolicyFollowers               // The author's followers
+	CommentPolicyMentioned               // Users @mentioned in the post
+	CommentPolicyOff                     // Nobody
+	CommentPolicyUnknown
+)
+
+func (cp CommentPolicy) String() string {
+	switch cp {
+	case CommentPolicyEveryone:
+		return "everyone"
+	case CommentPolicyFollowers:
+		return "followers"
+	case CommentPolicyMentioned:
+		return "mentioned"
+	case CommentPolicyOff:
+		return "off"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToCommentPolicy(s string) CommentPolicy {
+	switch strings.ToLower(s) {
+	case "everyone":
+		return CommentPolicyEveryone
+	case "followers":
+		return CommentPolicyFollowers
+	case "mentioned":
+		return CommentPolicyMentioned
+	case "off":
+		return CommentPolicyOff
+	default:
+		return CommentPolicyUnknown
+	}
+}
+
+// DraftTarget is what an unsent draft will be sent to
+type DraftTarget uint32
+
+const (
+	DraftTargetUnknown      DraftTarget = iota
+	DraftTargetConversation             // A direct message conversation, by the other user's ID
+	DraftTargetPost                     // A comment on a post
+)
+
+func (dt DraftTarget) String() string {
+	switch dt {
+	case DraftTargetConversation:
+		return "conversation"
+	case DraftTargetPost:
+		return "post"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToDraftTarget(s string) DraftTarget {
+	switch strings.ToLower(s) {
+	case "conversation":
+		return DraftTargetConversation
+	case "post":
+		return DraftTargetPost
+	default:
+		return DraftTargetUnknown
+	}
+}
+
+// ShortLinkTarget is what a short share link points at
+type ShortLinkTarget uint32
+
+const (
+	ShortLinkTargetUnknown ShortLinkTarget = iota
+	ShortLinkTargetPost
+	ShortLinkTargetProfile
+)
+
+func (st ShortLinkTarget) String() string {
+	switch st {
+	case ShortLinkTargetPost:
+		return "post"
+	case ShortLinkTargetProfile:
+		return "profile"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToShortLinkTarget(s string) ShortLinkTarget {
+	switch strings.ToLower(s) {
+	case "post":
+		return ShortLinkTargetPost
+	case "profile":
+		return ShortLinkTargetProfile
+	default:
+		return ShortLinkTargetUnknown
+	}
+}
+
+// CommentSort orders the comments of a post
+type CommentSort uint32
+
+const (
+	CommentSortOldest CommentSort = iota // Chronological, the default
+	CommentSortTop                       // By rank score, most engaging first
+)
+
+func (cs CommentSort) String() string {
+	switch cs {
+	case CommentSortOldest:
+		return "oldest"
+	case CommentSortTop:
+		return "top"
+	default:
+		return "unknown"
+	}
+}
+
+// StringToCommentSort parses a sort name, defaulting to oldest
+func StringToCommentSort(s string) CommentSort {
+	switch strings.ToLower(s) {
+	case "top":
+		return CommentSortTop
+	default:
+		return CommentSortOldest
+	}
+}
+
+// OnboardingStep is an item of the checklist shown to new users
+type OnboardingStep uint32
+
+const (
+	OnboardingStepUnknown OnboardingStep = iota
+	OnboardingStepAvatar
+	OnboardingStepBio
+	OnboardingStepFirstFollow
+	OnboardingStepFirstPost
+)
+
+func (step OnboardingStep) String() string {
+	switch step {
+	case OnboardingStepAvatar:
+		return "avatar"
+	case OnboardingStepBio:
+		return "bio"
+	case OnboardingStepFirstFollow:
+		return "first_follow"
+	case OnboardingStepFirstPost:
+		return "first_post"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToOnboardingStep(s string) OnboardingStep {
+	switch strings.ToLower(s) {
+	case "avatar":
+		return OnboardingStepAvatar
+	case "bio":
+		return OnboardingStepBio
+	case "first_follow":
+		return OnboardingStepFirstFollow
+	case "first_post":
+		return OnboardingStepFirstPost
+	default:
+		return OnboardingStepUnknown
+	}
+}
+
+// PhoneCodePurpose is what a code texted to a user proves
+type PhoneCodePurpose uint32
+
+const (
+	PhoneCodePurposeUnknown PhoneCodePurpose = iota
+	PhoneCodePurposeVerify                   // Ownership of a number being added
+	PhoneCodePurposeSignIn                   // The second factor of a sign-in
+)
+
+func (pp PhoneCodePurpose) String() string {
+	switch pp {
+	case PhoneCodePurposeVerify:
+		return "verify"
+	case PhoneCodePurposeSignIn:
+		return "sign_in"
+	default:
+		return "unknown"
+	}
+}
+
+func StringToPhoneCodePurpose(s string) PhoneCodePurpose {
+	switch strings.ToLower(s) {
+	case "verify":
+		return PhoneCodePurposeVerify
+	case "sign_in":
+		return PhoneCodePurposeSignIn
+	default:
+		return PhoneCodePurposeUnknown
+	}
+}
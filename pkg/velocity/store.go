@@ -0,0 +1,120 @@
+package velocity
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store keeps, per key, the distinct items seen within a rolling window
+type Store interface {
+	// Add records item under key at now, dropping items older than window,
+	// and returns how many distinct items key then holds. Adding an item
+	// already held only moves it to now.
+	Add(ctx context.Context, key, item string, now time.Time, window time.Duration) (int64, error)
+	// Count returns how many distinct items key held within window of now
+	Count(ctx context.Context, key string, now time.Time, window time.Duration) (int64, error)
+}
+
+// NewStore returns a Redis store shared by every instance, or an in-process
+// store when client is nil
+func NewStore(client *redis.Client) Store {
+	if client == nil {
+		return NewMemoryStore()
+	}
+	return NewRedisStore(client)
+}
+
+// addScript keeps KEYS[1] as a sorted set of items scored by when they were
+// last seen in milliseconds: it drops those scored ARGV[1] or less, adds
+// ARGV[3] at ARGV[2], expires the set after ARGV[4] milliseconds idle and
+// returns its size
+var addScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+redis.call("ZADD", KEYS[1], ARGV[2], ARGV[3])
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+return redis.call("ZCARD", KEYS[1])
+`)
+
+// RedisStore keeps the windows in Redis so rates span every instance
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Add(ctx context.Context, key, item string, now time.Time, window time.Duration) (int64, error) {
+	cutoff := now.Add(-window).UnixMilli()
+	return addScript.Run(ctx, s.client, []string{key}, cutoff, now.UnixMilli(), item, max(window.Milliseconds(), 1)).Int64()
+}
+
+func (s *RedisStore) Count(ctx context.Context, key string, now time.Time, window time.Duration) (int64, error) {
+	return s.client.ZCount(ctx, key, "("+strconv.FormatInt(now.Add(-window).UnixMilli(), 10), "+inf").Result()
+}
+
+// MemoryStore keeps the windows in process memory, for single-instance
+// deployments
+type MemoryStore struct {
+	mu        sync.Mutex
+	windows   map[string]map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{windows: make(map[string]map[string]time.Time)}
+}
+
+func (s *MemoryStore) Add(ctx context.Context, key, item string, now time.Time, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := now.Add(-window)
+	if now.Sub(s.lastSweep) > window {
+		s.sweep(cutoff)
+		s.lastSweep = now
+	}
+	items := s.windows[key]
+	if items == nil {
+		items = make(map[string]time.Time)
+		s.windows[key] = items
+	}
+	items[item] = now
+	return count(items, cutoff), nil
+}
+
+func (s *MemoryStore) Count(ctx context.Context, key string, now time.Time, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return count(s.windows[key], now.Add(-window)), nil
+}
+
+// sweep drops items seen at or before cutoff, and keys left with none; it
+// runs once a window so idle users do not linger
+func (s *MemoryStore) sweep(cutoff time.Time) {
+	for key, items := range s.windows {
+		for item, seen := range items {
+			if !seen.After(cutoff) {
+				delete(items, item)
+			}
+		}
+		if len(items) == 0 {
+			delete(s.windows, key)
+		}
+	}
+}
+
+func count(items map[string]time.Time, cutoff time.Time) int64 {
+	var n int64
+	for _, seen := range items {
+		if seen.After(cutoff) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,140 @@
+// Package velocity measures how fast each user acts over a rolling window:
+// the posts they publish, the people they follow and the distinct people
+// they message. A user going over the threshold of a metric is an abuse
+// signal; listeners registered with OnBreach hear of it once per crossing.
+package velocity
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// Metric is a kind of action whose rate is tracked
+type Metric string
+
+// Tracked metrics
+const (
+	MetricPosts             Metric = "posts"
+	MetricFollows           Metric = "follows"
+	MetricMessageRecipients Metric = "message_recipients" // Distinct users messaged
+)
+
+// Metrics lists every tracked metric
+var Metrics = []Metric{MetricPosts, MetricFollows, MetricMessageRecipients}
+
+// DefaultWindow is how far back rates reach when Config leaves it zero
+const DefaultWindow = time.Hour
+
+// Config sets the rolling window and the threshold of each metric
+type Config struct {
+	Window     time.Duration
+	Thresholds map[Metric]int64 // A missing or zero threshold never breaches
+}
+
+// Rate is a user's count of one metric within the window
+type Rate struct {
+	Metric    Metric `json:"metric"`
+	Count     int64  `json:"count"`
+	Threshold int64  `json:"threshold,omitempty"`
+	Breached  bool   `json:"breached"`
+}
+
+// Breach is a user going over the threshold of a metric
+type Breach struct {
+	UserID    int64
+	Metric    Metric
+	Count     int64
+	Threshold int64
+	Window    time.Duration
+}
+
+// BreachFunc is called when a user goes over a threshold
+type BreachFunc func(ctx context.Context, breach Breach)
+
+// Tracker records actions and reports the rates of each user
+type Tracker struct {
+	store    Store
+	config   Config
+	onBreach []BreachFunc
+}
+
+func New(store Store, config Config) *Tracker {
+	if config.Window <= 0 {
+		config.Window = DefaultWindow
+	}
+	return &Tracker{store: store, config: config}
+}
+
+// OnBreach registers a listener for threshold crossings
+func (t *Tracker) OnBreach(fn BreachFunc) {
+	t.onBreach = append(t.onBreach, fn)
+}
+
+// Window returns how far back rates reach
+func (t *Tracker) Window() time.Duration {
+	return t.config.Window
+}
+
+// Record counts an action of userID. item identifies what the action was on,
+// such as the post published or the user followed; recording the same item
+// again within the window does not add to the count, so repeating an action
+// on one target is not mistaken for reaching many.
+func (t *Tracker) Record(ctx context.Context, userID int64, metric Metric, item int64) error {
+	count, err := t.store.Add(ctx, key(userID, metric), strconv.FormatInt(item, 10), time.Now(), t.config.Window)
+	if err != nil {
+		return err
+	}
+	// Each action adds at most one, so only the one crossing sees threshold+1
+	if threshold := t.config.Thresholds[metric]; threshold > 0 && count == threshold+1 {
+		breach := Breach{UserID: userID, Metric: metric, Count: count, Threshold: threshold, Window: t.config.Window}
+		for _, fn := range t.onBreach {
+			fn(ctx, breach)
+		}
+	}
+	return nil
+}
+
+// Rates returns the current count of every metric for userID
+func (t *Tracker) Rates(ctx context.Context, userID int64) ([]Rate, error) {
+	now := time.Now()
+	rates := make([]Rate, 0, len(Metrics))
+	for _, metric := range Metrics {
+		count, err := t.store.Count(ctx, key(userID, metric), now, t.config.Window)
+		if err != nil {
+			return nil, err
+		}
+		threshold := t.config.Thresholds[metric]
+		rates = append(rates, Rate{Metric: metric, Count: count, Threshold: threshold, Breached: threshold > 0 && count > threshold})
+	}
+	return rates, nil
+}
+
+// Breached reports whether userID is over any threshold right now. A nil
+// Tracker breaches nothing.
+func (t *Tracker) Breached(ctx context.Context, userID int64) (bool, error) {
+	if t == nil {
+		return false, nil
+	}
+	rates, err := t.Rates(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, rate := range rates {
+		if rate.Breached {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// LogBreach is a BreachFunc that logs a warning
+func LogBreach(ctx context.Context, breach Breach) {
+	slog.WarnContext(ctx, "user exceeded velocity threshold", slog.Int64("user_id", breach.UserID), slog.String("metric", string(breach.Metric)),
+		slog.Int64("count", breach.Count), slog.Int64("threshold", breach.Threshold), slog.Duration("window", breach.Window))
+}
+
+func key(userID int64, metric Metric) string {
+	return "velocity:" + string(metric) + ":" + strconv.FormatInt(userID, 10)
+}